@@ -1,15 +1,19 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,6 +23,7 @@ import (
 	"porcupin/backend/db"
 	"porcupin/backend/indexer"
 	"porcupin/backend/ipfs"
+	"porcupin/backend/pinning"
 	"porcupin/backend/storage"
 	"porcupin/backend/version"
 
@@ -30,11 +35,13 @@ import (
 // App struct
 type App struct {
 	ctx           context.Context
-	config        *config.Config
+	config        *config.Config // Until the backup service takes over, see currentConfig
 	database      *db.Database
 	ipfsNode      *ipfs.Node
 	indexer       *indexer.Indexer
 	backupService *core.BackupService
+	jobs          *core.JobManager // Jobs owned by the app (storage migration)
+	secrets       *db.SecretBox    // Seals the tokens of saved remote servers
 }
 
 // NewApp creates a new App application struct
@@ -60,6 +67,11 @@ func (a *App) startup(ctx context.Context) {
 	// Load configuration
 	configPath := filepath.Join(dataDir, "config.yaml")
 	cfg, err := config.LoadConfig(configPath)
+	var invalid *config.ValidationError
+	if errors.As(err, &invalid) {
+		// Falling back to defaults could open a new, empty repository
+		log.Fatalf("Invalid config %s: %v", configPath, err)
+	}
 	if err != nil {
 		log.Printf("Failed to load config: %v, using defaults", err)
 		cfg = config.DefaultConfig()
@@ -84,6 +96,20 @@ func (a *App) startup(ctx context.Context) {
 	a.database = db.NewDatabase(gormDB)
 	log.Println("Database initialized")
 
+	a.secrets, err = db.LoadOrCreateSecretBox(filepath.Join(dataDir, db.SecretKeyFileName))
+	if err != nil {
+		log.Printf("Warning: saved remote servers unavailable: %v", err)
+	}
+
+	// App-level jobs need to restart the IPFS node, so they live here rather
+	// than in the backup service
+	a.jobs = core.NewJobManager(a.database)
+	a.jobs.RegisterHandler(core.JobTypeStorageMigrate, a.runStorageMigrateJob)
+
+	// A migration that already moved the data but was interrupted before the
+	// config was updated must be finished before the node opens the old path
+	a.finishInterruptedMigration(configPath)
+
 	// Initialize IPFS node
 	// Ensure repo path is absolute
 	repoPath := cfg.IPFS.RepoPath
@@ -95,13 +121,20 @@ func (a *App) startup(ctx context.Context) {
 	if err != nil {
 		log.Fatalf("Failed to create IPFS node: %v", err)
 	}
-
-	if err := ipfsNode.Start(ctx); err != nil {
-		log.Fatalf("Failed to start IPFS node: %v", err)
+	if err := ipfsNode.SetColdPath(cfg.IPFS.Tiering.ColdPath); err != nil {
+		log.Fatalf("Failed to set cold storage tier: %v", err)
 	}
 
+	// An unmounted volume isn't fatal: the backup service starts the node
+	// when it returns
 	a.ipfsNode = ipfsNode
-	log.Println("IPFS node started")
+	if err := core.StartIPFS(ctx, ipfsNode, a.database); errors.Is(err, storage.ErrStorageUnavailable) {
+		log.Printf("IPFS node not started, waiting for storage: %v", err)
+	} else if err != nil {
+		log.Fatalf("Failed to start IPFS node: %v", err)
+	} else {
+		log.Println("IPFS node started")
+	}
 
 	// Initialize indexer
 	a.indexer = indexer.NewIndexer(cfg.TZKT.BaseURL)
@@ -109,6 +142,7 @@ func (a *App) startup(ctx context.Context) {
 
 	// Initialize backup service (handles automatic syncing)
 	a.backupService = core.NewBackupService(ipfsNode, a.indexer, a.database, cfg)
+	a.backupService.Replication().SetDialer(api.CatalogDialer)
 	log.Println("Backup service initialized")
 	
 	// Initialize disk usage in background (don't block startup)
@@ -117,10 +151,20 @@ func (a *App) startup(ctx context.Context) {
 		a.backupService.GetManager().UpdateDiskUsage()
 	}()
 	
+	// Forward job progress to the frontend
+	a.backupService.Jobs().OnUpdate(a.emitJobUpdate)
+	a.jobs.OnUpdate(a.emitJobUpdate)
+
 	// Start the automatic backup service
 	a.backupService.Start(ctx)
 	log.Println("Backup service started - auto-syncing enabled")
 
+	// Apply edits to config.yaml without a restart
+	go config.Watch(ctx, configPath, config.WatchInterval, a.backupService.ApplyConfig)
+
+	// Resume an interrupted storage migration
+	a.jobs.ResumeUnfinished(ctx)
+
 	log.Println("Porcupin startup complete!")
 }
 
@@ -154,22 +198,25 @@ func (a *App) beforeClose(ctx context.Context) (prevent bool) {
 	return false
 }
 
-// tezosAddressPattern validates Tezos wallet addresses (tz1, tz2, tz3, KT1)
-var tezosAddressPattern = regexp.MustCompile(`^(tz[1-3]|KT1)[a-zA-Z0-9]{33}$`)
-
-// AddWallet adds a wallet to be tracked
+// AddWallet adds a wallet to be tracked, given its address or .tez domain
 func (a *App) AddWallet(address string, alias string) error {
-	// Validate Tezos address format
-	if !tezosAddressPattern.MatchString(address) {
-		return fmt.Errorf("invalid Tezos address format (expected tz1/tz2/tz3/KT1 followed by 33 alphanumeric characters)")
+	// Accept a Tezos address or a .tez domain, which is resolved to its address
+	address, domain, err := api.ResolveWallet(a.ctx, a.indexer, address)
+	if err != nil {
+		return err
+	}
+	if alias == "" {
+		alias = domain
 	}
 
 	// Use global defaults for sync settings
+	cfg := a.currentConfig()
 	wallet := &db.Wallet{
 		Address:     address,
 		Alias:       alias,
-		SyncOwned:   a.config.Backup.SyncOwned,
-		SyncCreated: a.config.Backup.SyncCreated,
+		Domain:      domain,
+		SyncOwned:   cfg.Backup.SyncOwned,
+		SyncCreated: cfg.Backup.SyncCreated,
 	}
 
 	if err := a.database.SaveWallet(wallet); err != nil {
@@ -182,6 +229,62 @@ func (a *App) AddWallet(address string, alias string) error {
 	return nil
 }
 
+// ImportWallets adds the wallets in a CSV or JSON wallet file, reporting the
+// outcome of every row. Rows that don't set sync_owned or sync_created take
+// the defaults from the config; an empty format is detected from the
+// content.
+func (a *App) ImportWallets(content string, format string, dryRun bool) (*api.WalletImportReport, error) {
+	rows, err := api.ParseWalletFile([]byte(content), format)
+	if err != nil {
+		return nil, err
+	}
+	cfg := a.currentConfig()
+	report, err := api.ImportWallets(a.database, rows, api.WalletImportOptions{
+		DryRun:      dryRun,
+		SyncOwned:   cfg.Backup.SyncOwned,
+		SyncCreated: cfg.Backup.SyncCreated,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		for _, address := range report.AddedAddresses() {
+			a.backupService.AddWallet(address)
+		}
+	}
+	return report, nil
+}
+
+// ExportWallets returns every wallet as a wallet file in format (csv or
+// json), which ImportWallets reads on another node
+func (a *App) ExportWallets(format string) (string, error) {
+	wallets, err := a.database.GetAllWallets()
+	if err != nil {
+		return "", fmt.Errorf("failed to get wallets: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := api.WriteWalletFile(&buf, wallets, format); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// SaveTextFile asks where to save a file, suggesting defaultName, and writes
+// content there. It returns the path, or "" if the dialog was cancelled.
+func (a *App) SaveTextFile(defaultName string, content string) (string, error) {
+	path, err := wailsRuntime.SaveFileDialog(a.ctx, wailsRuntime.SaveDialogOptions{
+		Title:           "Save File",
+		DefaultFilename: defaultName,
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to save file: %w", err)
+	}
+	return path, nil
+}
+
 // UpdateWalletSettings updates the sync settings for a specific wallet
 func (a *App) UpdateWalletSettings(address string, syncOwned bool, syncCreated bool) error {
 	return a.database.Model(&db.Wallet{}).Where("address = ?", address).Updates(map[string]interface{}{
@@ -195,6 +298,33 @@ func (a *App) UpdateWalletAlias(address string, alias string) error {
 	return a.database.Model(&db.Wallet{}).Where("address = ?", address).Update("alias", alias).Error
 }
 
+// UpdateWalletPriority sets how many extra pin queue turns a wallet gets
+func (a *App) UpdateWalletPriority(address string, priority int) error {
+	if err := a.database.Model(&db.Wallet{}).Where("address = ?", address).Update("priority", priority).Error; err != nil {
+		return err
+	}
+	a.backupService.SetWalletPriority(address, priority)
+	return nil
+}
+
+// UpdateWalletQuota sets how many GB of pinned storage a wallet may use (0 = unlimited)
+func (a *App) UpdateWalletQuota(address string, quotaGB int) error {
+	if quotaGB < 0 {
+		return fmt.Errorf("quota cannot be negative")
+	}
+	if err := a.database.Model(&db.Wallet{}).Where("address = ?", address).Update("quota_gb", quotaGB).Error; err != nil {
+		return err
+	}
+	a.backupService.ApplyWalletQuota(address)
+	return nil
+}
+
+// GetWalletUsage returns the pinned storage referenced by each wallet, keyed by address
+func (a *App) GetWalletUsage() (map[string]*db.WalletUsage, error) {
+	usage, _, err := a.database.GetWalletUsage()
+	return usage, err
+}
+
 // DeleteWallet removes a wallet and optionally its associated data (DB only, no unpin)
 func (a *App) DeleteWallet(address string, deleteData bool) error {
 	if deleteData {
@@ -214,40 +344,17 @@ func (a *App) DeleteWallet(address string, deleteData bool) error {
 	return nil
 }
 
-// DeleteWalletWithUnpin removes a wallet and unpins all its assets from IPFS
+// DeleteWalletWithUnpin removes a wallet and unpins all its assets from IPFS.
+// Runs as a persistent job so an interrupted delete resumes on next start.
 func (a *App) DeleteWalletWithUnpin(address string) error {
-	// Get all assets for this wallet
-	assets, err := a.database.GetAssetsByWallet(address)
+	job, err := a.backupService.SubmitJob(core.JobTypeDeleteWallet, core.DeleteWalletParams{
+		Address: address,
+		Unpin:   true,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get assets: %w", err)
-	}
-
-	// Unpin each asset
-	ctx := context.Background()
-	for _, asset := range assets {
-		cid := core.ExtractCIDFromURI(asset.URI)
-		if cid == "" {
-			continue
-		}
-		// Ignore errors - asset may not be pinned or may have already been unpinned
-		_ = a.ipfsNode.Unpin(ctx, cid)
-	}
-
-	// Delete from database
-	if err := a.database.DeleteAssetsByWallet(address); err != nil {
-		return fmt.Errorf("failed to delete assets: %w", err)
-	}
-	if err := a.database.DeleteNFTsByWallet(address); err != nil {
-		return fmt.Errorf("failed to delete NFTs: %w", err)
-	}
-	if err := a.database.DeleteWallet(address); err != nil {
-		return fmt.Errorf("failed to delete wallet: %w", err)
+		return fmt.Errorf("failed to start delete: %w", err)
 	}
-
-	// Mark disk usage dirty so it recalculates
-	a.backupService.GetManager().MarkDiskUsageDirty()
-
-	return nil
+	return a.waitForJob(a.backupService.Jobs(), job.ID)
 }
 
 // SyncWallet synchronizes NFTs for a given wallet (manual trigger)
@@ -294,6 +401,14 @@ type RemoteServerConfig struct {
 	Port   int    `json:"port"`
 	Token  string `json:"token"`
 	UseTLS bool   `json:"useTLS"`
+
+	// Fingerprint pins the server's TLS certificate. It is learned on the
+	// first connection, or from mDNS, when the certificate isn't CA-signed.
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// ServerID refers to a saved server instead, whose address and token
+	// are used (see SaveRemoteServer)
+	ServerID uint64 `json:"serverId,omitempty"`
 }
 
 // RemoteHealthResponse holds the health check response from a remote server
@@ -301,17 +416,44 @@ type RemoteHealthResponse struct {
 	Status    string `json:"status"`
 	Version   string `json:"version"`
 	Timestamp string `json:"timestamp"`
+
+	// Fingerprint is the certificate pinned for the server, to save with its
+	// config; empty if it is CA-signed or the connection is plain HTTP
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// remoteClient returns a client for a remote server. A TLS server seen for
+// the first time has its certificate pinned unless it is CA-signed; the
+// fingerprint pinned is returned.
+func remoteClient(ctx context.Context, cfg RemoteServerConfig) (*api.RemoteClient, string, error) {
+	switch {
+	case !cfg.UseTLS:
+		return api.NewRemoteClient(cfg.Host, cfg.Port, cfg.Token, false), "", nil
+	case cfg.Fingerprint != "":
+		fp := api.NormalizeFingerprint(cfg.Fingerprint)
+		return api.NewPinnedRemoteClient(cfg.Host, cfg.Port, cfg.Token, fp), fp, nil
+	default:
+		return api.TrustOnFirstUse(ctx, cfg.Host, cfg.Port, cfg.Token)
+	}
 }
 
 // TestRemoteConnection tests connectivity to a remote Porcupin server
 func (a *App) TestRemoteConnection(cfg RemoteServerConfig) (*RemoteHealthResponse, error) {
+	cfg, err := a.resolveRemote(cfg)
+	if err != nil {
+		return nil, err
+	}
 	log.Printf("TestRemoteConnection: Connecting to %s:%d (TLS: %v)", cfg.Host, cfg.Port, cfg.UseTLS)
 	
-	client := api.NewRemoteClient(cfg.Host, cfg.Port, cfg.Token, cfg.UseTLS)
-	
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	
+	client, fingerprint, err := remoteClient(ctx, cfg)
+	if err != nil {
+		log.Printf("TestRemoteConnection: Failed - %v", err)
+		return nil, err
+	}
+	
 	health, err := client.Health(ctx)
 	if err != nil {
 		log.Printf("TestRemoteConnection: Failed - %v", err)
@@ -319,13 +461,253 @@ func (a *App) TestRemoteConnection(cfg RemoteServerConfig) (*RemoteHealthRespons
 	}
 	
 	log.Printf("TestRemoteConnection: Success - server version %s", health.Version)
+	if cfg.ServerID != 0 {
+		a.markRemoteConnected(cfg.ServerID, fingerprint)
+	}
 	return &RemoteHealthResponse{
-		Status:    health.Status,
-		Version:   health.Version,
-		Timestamp: health.Timestamp,
+		Status:      health.Status,
+		Version:     health.Version,
+		Timestamp:   health.Timestamp,
+		Fingerprint: fingerprint,
+	}, nil
+}
+
+// PairRemoteRequest holds a pairing code shown by a server, or a pairing
+// URL, which also carries the server's address
+type PairRemoteRequest struct {
+	Host        string `json:"host,omitempty"`
+	Port        int    `json:"port,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"` // From discovery, if known
+	Code        string `json:"code"`                  // Code or pairing URL
+}
+
+// PairRemoteServer pairs with a server, which issues this app a token of
+// its own. The returned config is ready to save and connect with.
+func (a *App) PairRemoteServer(req PairRemoteRequest) (*RemoteServerConfig, error) {
+	if strings.HasPrefix(strings.TrimSpace(req.Code), api.PairingURLScheme+"://") {
+		invite, err := api.ParsePairingURL(req.Code)
+		if err != nil {
+			return nil, err
+		}
+		req.Host, req.Port, req.Code = invite.Host, invite.Port, invite.Code
+		if invite.Fingerprint != "" {
+			req.Fingerprint = invite.Fingerprint
+		}
+	}
+	if req.Host == "" || req.Port == 0 {
+		return nil, fmt.Errorf("enter the server's address, or paste its pairing URL")
+	}
+	log.Printf("PairRemoteServer: Pairing with %s:%d", req.Host, req.Port)
+
+	name := "Porcupin app"
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		name += " on " + hostname
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	paired, fingerprint, err := api.Pair(ctx, req.Host, req.Port, req.Fingerprint, req.Code, name)
+	if err != nil {
+		log.Printf("PairRemoteServer: Failed - %v", err)
+		return nil, err
+	}
+
+	log.Printf("PairRemoteServer: Paired as token %d", paired.ID)
+	return &RemoteServerConfig{
+		Host:        req.Host,
+		Port:        req.Port,
+		Token:       paired.Token,
+		UseTLS:      true,
+		Fingerprint: fingerprint,
+	}, nil
+}
+
+// SavedRemoteServer is a remote server saved in the app. Its token is sealed
+// in the database and never sent back to the frontend.
+type SavedRemoteServer struct {
+	ID              uint64     `json:"id"`
+	Label           string     `json:"label"`
+	Host            string     `json:"host"`
+	Port            int        `json:"port"`
+	UseTLS          bool       `json:"useTLS"`
+	Fingerprint     string     `json:"fingerprint,omitempty"`
+	Token           string     `json:"token,omitempty"` // Only when saving; empty keeps the saved token
+	LastConnectedAt *time.Time `json:"lastConnectedAt,omitempty"`
+}
+
+// savedRemoteServer converts a saved server for the frontend, without its token
+func savedRemoteServer(server *db.RemoteServer) SavedRemoteServer {
+	return SavedRemoteServer{
+		ID:              server.ID,
+		Label:           server.Label,
+		Host:            server.Host,
+		Port:            server.Port,
+		UseTLS:          server.UseTLS,
+		Fingerprint:     server.Fingerprint,
+		LastConnectedAt: server.LastConnectedAt,
+	}
+}
+
+// GetRemoteServers lists the saved remote servers, most recently used first
+func (a *App) GetRemoteServers() ([]SavedRemoteServer, error) {
+	servers, err := a.database.GetRemoteServers()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]SavedRemoteServer, 0, len(servers))
+	for i := range servers {
+		result = append(result, savedRemoteServer(&servers[i]))
+	}
+	return result, nil
+}
+
+// SaveRemoteServer saves a remote server, or updates the one with the same
+// ID or address. The token is sealed before it is stored.
+func (a *App) SaveRemoteServer(in SavedRemoteServer) (*SavedRemoteServer, error) {
+	if a.secrets == nil {
+		return nil, fmt.Errorf("saving servers is unavailable: the secret key could not be loaded")
+	}
+	if in.Host == "" || in.Port <= 0 || in.Port > 65535 {
+		return nil, fmt.Errorf("host and a port between 1 and 65535 are required")
+	}
+
+	var server *db.RemoteServer
+	var err error
+	if in.ID != 0 {
+		server, err = a.database.GetRemoteServer(in.ID)
+	} else {
+		server, err = a.database.GetRemoteServerByAddress(in.Host, in.Port)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if server == nil {
+		if in.ID != 0 {
+			return nil, fmt.Errorf("saved server %d not found", in.ID)
+		}
+		if in.Token == "" {
+			return nil, fmt.Errorf("token is required")
+		}
+		server = &db.RemoteServer{}
+	}
+
+	server.Label = in.Label
+	server.Host = in.Host
+	server.Port = in.Port
+	server.UseTLS = in.UseTLS
+	server.Fingerprint = api.NormalizeFingerprint(in.Fingerprint)
+	if in.Token != "" {
+		if server.SealedToken, err = a.secrets.Seal(in.Token); err != nil {
+			return nil, fmt.Errorf("failed to seal token: %w", err)
+		}
+	}
+	if err := a.database.SaveRemoteServer(server); err != nil {
+		return nil, err
+	}
+	saved := savedRemoteServer(server)
+	return &saved, nil
+}
+
+// DeleteRemoteServer forgets a saved remote server and its token
+func (a *App) DeleteRemoteServer(id uint64) error {
+	return a.database.DeleteRemoteServer(id)
+}
+
+// resolveRemote fills in a config that refers to a saved server with the
+// server's address and token. Other configs are returned unchanged.
+func (a *App) resolveRemote(cfg RemoteServerConfig) (RemoteServerConfig, error) {
+	if cfg.ServerID == 0 {
+		return cfg, nil
+	}
+	server, err := a.database.GetRemoteServer(cfg.ServerID)
+	if err != nil {
+		return cfg, err
+	}
+	if server == nil {
+		return cfg, fmt.Errorf("saved server %d not found", cfg.ServerID)
+	}
+	if a.secrets == nil {
+		return cfg, fmt.Errorf("the token of %s can't be read: the secret key could not be loaded", server.Host)
+	}
+	token, err := a.secrets.Open(server.SealedToken)
+	if err != nil {
+		return cfg, err
+	}
+	return RemoteServerConfig{
+		Host:        server.Host,
+		Port:        server.Port,
+		Token:       token,
+		UseTLS:      server.UseTLS,
+		Fingerprint: server.Fingerprint,
+		ServerID:    server.ID,
 	}, nil
 }
 
+// markRemoteConnected records a successful connection to a saved server,
+// keeping the certificate pinned on first use
+func (a *App) markRemoteConnected(id uint64, fingerprint string) {
+	server, err := a.database.GetRemoteServer(id)
+	if err != nil || server == nil {
+		return
+	}
+	now := time.Now()
+	server.LastConnectedAt = &now
+	if fingerprint != "" {
+		server.Fingerprint = fingerprint
+	}
+	if err := a.database.SaveRemoteServer(server); err != nil {
+		log.Printf("Failed to update saved server %d: %v", id, err)
+	}
+}
+
+// GetFleetOverview asks every saved server for its status, stats and
+// failed assets, and merges them. Servers that can't be reached are
+// flagged in the overview rather than failing it.
+func (a *App) GetFleetOverview() (*api.FleetOverview, error) {
+	servers, err := a.database.GetRemoteServers()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	members := make([]api.FleetMember, 0, len(servers))
+	unavailable := []api.FleetNode{}
+	for _, server := range servers {
+		name := server.Label
+		if name == "" {
+			name = server.Host
+		}
+		address := net.JoinHostPort(server.Host, strconv.Itoa(server.Port))
+		cfg, err := a.resolveRemote(RemoteServerConfig{ServerID: server.ID})
+		if err == nil && cfg.UseTLS && cfg.Fingerprint == "" {
+			// Never trust a new certificate in the background; connecting
+			// to the server from Settings pins it
+			if _, trusted, probeErr := api.ProbeCertificate(ctx, cfg.Host, cfg.Port); probeErr == nil && !trusted {
+				err = fmt.Errorf("the server's certificate isn't pinned yet; connect to it once from Settings")
+			}
+		}
+		if err != nil {
+			unavailable = append(unavailable, api.FleetNode{ID: server.ID, Name: name, Address: address, Error: err.Error()})
+			continue
+		}
+		client, _, err := remoteClient(ctx, cfg)
+		if err != nil {
+			unavailable = append(unavailable, api.FleetNode{ID: server.ID, Name: name, Address: address, Error: err.Error()})
+			continue
+		}
+		members = append(members, api.FleetMember{ID: server.ID, Name: name, Address: address, Client: client})
+	}
+
+	overview := api.GetFleetOverview(ctx, members)
+	overview.Nodes = append(overview.Nodes, unavailable...)
+	overview.Totals.Nodes += len(unavailable)
+	overview.Totals.Unreachable += len(unavailable)
+	return overview, nil
+}
+
 // RemoteProxyRequest holds a generic HTTP request to proxy to a remote server
 type RemoteProxyRequest struct {
 	Host    string            `json:"host"`
@@ -336,6 +718,12 @@ type RemoteProxyRequest struct {
 	Path    string            `json:"path"`
 	Headers map[string]string `json:"headers,omitempty"`
 	Body    string            `json:"body,omitempty"`
+
+	// Fingerprint is the certificate pinned by TestRemoteConnection
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// ServerID refers to a saved server instead of the fields above
+	ServerID uint64 `json:"serverId,omitempty"`
 }
 
 // RemoteProxyResponse holds the response from a proxied request
@@ -348,9 +736,19 @@ type RemoteProxyResponse struct {
 // RemoteProxy proxies an HTTP request to a remote Porcupin server
 // This allows the frontend to make any API call to a remote server via Go
 func (a *App) RemoteProxy(req RemoteProxyRequest) (*RemoteProxyResponse, error) {
+	if req.ServerID != 0 {
+		cfg, err := a.resolveRemote(RemoteServerConfig{ServerID: req.ServerID})
+		if err != nil {
+			return nil, err
+		}
+		req.Host, req.Port, req.Token, req.UseTLS, req.Fingerprint = cfg.Host, cfg.Port, cfg.Token, cfg.UseTLS, cfg.Fingerprint
+	}
 	log.Printf("RemoteProxy: %s %s to %s:%d", req.Method, req.Path, req.Host, req.Port)
 	
 	client := api.NewRemoteClient(req.Host, req.Port, req.Token, req.UseTLS)
+	if req.UseTLS && req.Fingerprint != "" {
+		client = api.NewPinnedRemoteClient(req.Host, req.Port, req.Token, req.Fingerprint)
+	}
 	
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -405,7 +803,7 @@ func (a *App) GetAssetStats() (map[string]int64, error) {
 
 // GetConfig returns the current configuration
 func (a *App) GetConfig() config.Config {
-	return *a.config
+	return *a.currentConfig()
 }
 
 // GetAssets returns a paginated list of assets with their associated NFT info
@@ -421,10 +819,9 @@ func (a *App) GetAssets(page int, limit int, status string, search string) ([]db
 
 	if search != "" {
 		likeSearch := "%" + search + "%"
-		// Join with NFT table for searching by NFT name/description
-		query = query.Joins("LEFT JOIN nfts ON nfts.id = assets.nft_id").
-			Where("assets.type LIKE ? OR assets.mime_type LIKE ? OR assets.uri LIKE ? OR nfts.name LIKE ? OR nfts.description LIKE ?", 
-				likeSearch, likeSearch, likeSearch, likeSearch, likeSearch)
+		// Match the asset itself, or its NFT's metadata through the search index
+		query = query.Where("assets.type LIKE ? OR assets.mime_type LIKE ? OR assets.uri LIKE ? OR assets.nft_id IN (?)",
+			likeSearch, likeSearch, likeSearch, a.database.MatchingNFTIDs(search))
 	}
 	
 	err := query.Order("assets.id desc").Offset(offset).Limit(limit).Find(&assets).Error
@@ -452,37 +849,53 @@ func (a *App) GetRecentActivity(limit int) ([]db.Asset, error) {
 	return assets, nil
 }
 
-// GetNFTsWithAssets returns a paginated list of NFTs with their associated assets
-func (a *App) GetNFTsWithAssets(page int, limit int, status string, search string) ([]db.NFT, error) {
-	var nfts []db.NFT
+// GetNFTsWithAssets returns a paginated list of NFTs with their associated assets.
+// creator and contract, when set, keep the NFTs of one artist or collection;
+// tag and attribute (name or name:value) keep the NFTs whose metadata has
+// them. A search ranks the NFTs best match first and highlights the matches.
+func (a *App) GetNFTsWithAssets(page int, limit int, status string, search string, creator string, contract string, tag string, attribute string) ([]db.NFT, error) {
 	offset := (page - 1) * limit
-	
-	query := a.database.DB.Model(&db.NFT{}).Preload("Assets")
-
-	// If filtering by asset status, we need to join/filter
-	// This is tricky with GORM Preload + Pagination. 
-	// Easier to find matching NFT IDs first if filters are present.
-	if status != "" && status != "all" || search != "" {
-		subQuery := a.database.DB.Model(&db.NFT{}).Select("DISTINCT nfts.id").
-			Joins("LEFT JOIN assets ON assets.nft_id = nfts.id")
-		
+
+	filter := func(query *gorm.DB) *gorm.DB {
 		if status != "" && status != "all" {
-			subQuery = subQuery.Where("assets.status = ?", status)
+			query = query.Where("nfts.id IN (?)",
+				a.database.DB.Model(&db.Asset{}).Select("nft_id").Where("status = ?", status))
 		}
-		
-		if search != "" {
-			likeSearch := "%" + search + "%"
-			subQuery = subQuery.Where("nfts.name LIKE ? OR nfts.description LIKE ? OR assets.type LIKE ? OR assets.mime_type LIKE ? OR assets.uri LIKE ?", 
-				likeSearch, likeSearch, likeSearch, likeSearch, likeSearch)
+		if creator != "" {
+			query = query.Where("nfts.creator_address = ?", creator)
 		}
-		
-		query = query.Where("id IN (?)", subQuery)
+		if contract != "" {
+			query = query.Where("nfts.contract_address = ?", contract)
+		}
+		if tag != "" {
+			query = db.HasTag(tag)(query)
+		}
+		if name, value, _ := strings.Cut(attribute, ":"); name != "" {
+			query = db.HasAttribute(name, value)(query)
+		}
+		return query
+	}
+	preload := []string{"Assets", "Creator", "Contract", "Tags", "Attributes", "Royalties", "Contributors"}
+
+	var nfts []db.NFT
+	var err error
+	if search != "" {
+		var hits []db.SearchHit
+		hits, _, err = a.database.SearchNFTs(db.SearchQuery{Text: search, Filter: filter, Offset: offset, Limit: limit})
+		if err == nil {
+			nfts, err = a.database.NFTsForHits(hits, preload...)
+		}
+	} else {
+		query := filter(a.database.DB.Model(&db.NFT{}))
+		for _, relation := range preload {
+			query = query.Preload(relation)
+		}
+		err = query.
+			Order("id desc").
+			Offset(offset).
+			Limit(limit).
+			Find(&nfts).Error
 	}
-	
-	err := query.Order("id desc").
-		Offset(offset).
-		Limit(limit).
-		Find(&nfts).Error
 	
 	if err != nil {
 		log.Printf("GetNFTsWithAssets error: %v", err)
@@ -493,6 +906,25 @@ func (a *App) GetNFTsWithAssets(page int, limit int, status string, search strin
 	return nfts, nil
 }
 
+// GetNFTMetadataHistory returns the versions of an NFT's metadata, oldest
+// first, with the changes each made
+func (a *App) GetNFTMetadataHistory(nftID uint64) ([]db.MetadataRevision, error) {
+	return a.database.MetadataHistory(nftID)
+}
+
+// GetCreators returns the artists of the NFTs, most NFTs first
+func (a *App) GetCreators(page int, limit int, search string) ([]db.CreatorSummary, error) {
+	creators, _, err := a.database.ListCreators(db.GroupQuery{Search: search, Offset: (page - 1) * limit, Limit: limit})
+	return creators, err
+}
+
+// GetCollections returns the collections of the NFTs, most NFTs first.
+// creator, when set, keeps the collections that account originated.
+func (a *App) GetCollections(page int, limit int, search string, creator string) ([]db.ContractSummary, error) {
+	contracts, _, err := a.database.ListContracts(db.GroupQuery{Search: search, Creator: creator, Offset: (page - 1) * limit, Limit: limit})
+	return contracts, err
+}
+
 // RetryAsset retries a failed asset by immediately pinning it
 func (a *App) RetryAsset(assetID uint64) error {
 	// Use the backup service to immediately pin the asset
@@ -502,6 +934,16 @@ func (a *App) RetryAsset(assetID uint64) error {
 	return a.backupService.PinAsset(ctx, assetID)
 }
 
+// PinAssetNext moves an asset to the front of the pin queue
+func (a *App) PinAssetNext(assetID uint64) error {
+	return a.backupService.PinNext(assetID)
+}
+
+// GetQueueStatus returns the pin queue
+func (a *App) GetQueueStatus() core.QueueStatus {
+	return a.backupService.QueueStatus()
+}
+
 // RetryAllFailed retries all failed assets
 func (a *App) RetryAllFailed() (int64, error) {
 	result := a.database.DB.Model(&db.Asset{}).
@@ -589,10 +1031,14 @@ func (a *App) DeleteAsset(assetID uint64) error {
 		return fmt.Errorf("asset not found: %w", err)
 	}
 
-	// Extract CID and unpin
+	// Extract CID and unpin, unless another asset still needs it
 	cid := extractCIDFromURI(asset.URI)
 	if cid != "" {
-		if err := a.ipfsNode.Unpin(a.ctx, cid); err != nil {
+		if shared, err := a.database.CIDUsedByOtherAsset(cid, asset.ID); err != nil {
+			log.Printf("Keeping %s pinned: could not check other assets: %v", cid, err)
+		} else if shared {
+			log.Printf("Keeping %s pinned: other assets use it", cid)
+		} else if err := a.ipfsNode.Unpin(a.ctx, cid); err != nil {
 			log.Printf("Warning: unpin failed during delete: %v", err)
 		}
 	}
@@ -668,9 +1114,10 @@ type ClearDataStatus struct {
 
 // GetStorageInfo returns current storage usage information
 func (a *App) GetStorageInfo() (StorageInfo, error) {
+	cfg := a.currentConfig()
 	info := StorageInfo{
-		MaxStorageGB: a.config.Backup.MaxStorageGB,
-		WarningPct:   a.config.Backup.StorageWarningPct,
+		MaxStorageGB: cfg.Backup.MaxStorageGB,
+		WarningPct:   cfg.Backup.StorageWarningPct,
 		RepoPath:     a.ipfsNode.GetRepoPath(),
 	}
 
@@ -706,43 +1153,66 @@ func (a *App) GetStorageInfo() (StorageInfo, error) {
 
 // UpdateSettings updates the application settings
 func (a *App) UpdateSettings(settings map[string]interface{}) error {
-	// Update config values
-	if v, ok := settings["max_storage_gb"].(float64); ok {
-		a.config.Backup.MaxStorageGB = int(v)
-	}
-	if v, ok := settings["storage_warning_pct"].(float64); ok {
-		a.config.Backup.StorageWarningPct = int(v)
-	}
-	if v, ok := settings["max_concurrency"].(float64); ok {
-		a.config.Backup.MaxConcurrency = int(v)
-	}
-	if v, ok := settings["min_free_disk_space_gb"].(float64); ok {
-		a.config.Backup.MinFreeDiskSpaceGB = int(v)
-	}
-	if v, ok := settings["max_file_size_gb"].(float64); ok {
-		a.config.IPFS.MaxFileSize = int64(v * 1024 * 1024 * 1024)
-	}
-	if v, ok := settings["pin_timeout_minutes"].(float64); ok {
-		a.config.IPFS.PinTimeout = time.Duration(v) * time.Minute
-	}
-	if v, ok := settings["sync_owned"].(bool); ok {
-		a.config.Backup.SyncOwned = v
-	}
-	if v, ok := settings["sync_created"].(bool); ok {
-		a.config.Backup.SyncCreated = v
-	}
-	// Note: ipfs_swarm_port is saved but requires app restart to take effect
-	if v, ok := settings["ipfs_swarm_port"].(float64); ok {
-		port := int(v)
-		if port >= 1024 && port <= 65535 {
-			a.config.IPFS.SwarmPort = port
+	// The running settings are only replaced if the new ones validate and save
+	err := a.updateConfig(func(cfg *config.Config) error {
+		if v, ok := settings["max_storage_gb"].(float64); ok {
+			cfg.Backup.MaxStorageGB = int(v)
 		}
+		if v, ok := settings["storage_warning_pct"].(float64); ok {
+			cfg.Backup.StorageWarningPct = int(v)
+		}
+		if v, ok := settings["max_concurrency"].(float64); ok {
+			cfg.Backup.MaxConcurrency = int(v)
+		}
+		if v, ok := settings["min_free_disk_space_gb"].(float64); ok {
+			cfg.Backup.MinFreeDiskSpaceGB = int(v)
+		}
+		if v, ok := settings["max_file_size_gb"].(float64); ok {
+			cfg.IPFS.MaxFileSize = int64(v * 1024 * 1024 * 1024)
+		}
+		if v, ok := settings["pin_timeout_minutes"].(float64); ok {
+			cfg.IPFS.PinTimeout = time.Duration(v) * time.Minute
+		}
+		if v, ok := settings["sync_owned"].(bool); ok {
+			cfg.Backup.SyncOwned = v
+		}
+		if v, ok := settings["sync_created"].(bool); ok {
+			cfg.Backup.SyncCreated = v
+		}
+		if v, ok := settings["queue_wallet_priority_weight"].(float64); ok {
+			cfg.Backup.Queue.WalletPriorityWeight = v
+		}
+		if v, ok := settings["queue_asset_type_weight"].(float64); ok {
+			cfg.Backup.Queue.AssetTypeWeight = v
+		}
+		if v, ok := settings["queue_size_weight"].(float64); ok {
+			cfg.Backup.Queue.SizeWeight = v
+		}
+		if v, ok := settings["queue_age_weight"].(float64); ok {
+			cfg.Backup.Queue.AgeWeight = v
+		}
+		// Note: ipfs_swarm_port is saved but requires app restart to take effect
+		if v, ok := settings["ipfs_swarm_port"].(float64); ok {
+			port := int(v)
+			if port >= 1024 && port <= 65535 {
+				cfg.IPFS.SwarmPort = port
+			}
+		}
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+		// Save config to file
+		return cfg.SaveConfig(a.configPath())
+	})
+	if err != nil {
+		return err
 	}
-
-	// Save config to file
-	homeDir, _ := os.UserHomeDir()
-	configPath := filepath.Join(homeDir, ".porcupin", "config.yaml")
-	return a.config.SaveConfig(configPath)
+	if a.backupService != nil {
+		cfg := a.currentConfig()
+		a.backupService.GetManager().SetConcurrency(cfg.Backup.MaxConcurrency)
+		a.backupService.GetManager().SetQueueWeights(cfg.Backup.Queue)
+	}
+	return nil
 }
 
 // RecoverMissingAssets triggers the verification and repair process for missing asset records
@@ -750,7 +1220,8 @@ func (a *App) RecoverMissingAssets() (map[string]int, error) {
 	return a.backupService.VerifyAndFixPins()
 }
 
-// ResetDatabase clears all NFTs, assets, and unpins all IPFS content
+// ResetDatabase clears all NFTs, assets, and unpins all IPFS content.
+// Progress is reported through clear:* events translated from the job updates.
 func (a *App) ResetDatabase() error {
 	log.Println("Starting full data reset...")
 	
@@ -761,72 +1232,36 @@ func (a *App) ResetDatabase() error {
 		Message:    "Unpinning IPFS content...",
 	})
 	
-	// Step 1: Unpin all content from IPFS
-	log.Println("Unpinning all IPFS content...")
-	unpinned, err := a.ipfsNode.UnpinAll(a.ctx, func(total, current int) {
-		wailsRuntime.EventsEmit(a.ctx, "clear:progress", ClearDataStatus{
-			InProgress:    true,
-			Phase:         "unpinning",
-			Message:       fmt.Sprintf("Unpinning content... %d/%d", current, total),
-			TotalPins:     total,
-			UnpinnedCount: current,
-		})
-	})
-	if err != nil {
-		log.Printf("Warning: failed to unpin all: %v", err)
-		wailsRuntime.EventsEmit(a.ctx, "clear:progress", ClearDataStatus{
-			InProgress: true,
-			Phase:      "unpinning",
-			Message:    fmt.Sprintf("Warning: %v", err),
-		})
-	} else {
-		log.Printf("Unpinned %d items", unpinned)
-	}
-	
-	// Step 2: Run garbage collection to free disk space
-	wailsRuntime.EventsEmit(a.ctx, "clear:progress", ClearDataStatus{
-		InProgress: true,
-		Phase:      "garbage_collect",
-		Message:    "Running garbage collection to free disk space...",
-	})
-	log.Println("Running IPFS garbage collection...")
-	if err := a.ipfsNode.GarbageCollect(a.ctx); err != nil {
-		log.Printf("Warning: garbage collection failed: %v", err)
-	}
-	
-	// Step 3: Delete database records
-	wailsRuntime.EventsEmit(a.ctx, "clear:progress", ClearDataStatus{
-		InProgress: true,
-		Phase:      "clearing_db",
-		Message:    "Clearing database records...",
+	job, err := a.backupService.SubmitJob(core.JobTypeUnpinAll, core.UnpinAllParams{
+		GarbageCollect: true,
+		ClearDatabase:  true,
 	})
-	if err := a.database.DB.Exec("DELETE FROM assets").Error; err != nil {
-		wailsRuntime.EventsEmit(a.ctx, "clear:error", ClearDataStatus{
-			InProgress: false,
-			Phase:      "error",
-			Error:      err.Error(),
-		})
-		return fmt.Errorf("failed to delete assets: %w", err)
+	if err == nil {
+		err = a.waitForJob(a.backupService.Jobs(), job.ID)
 	}
-	if err := a.database.DB.Exec("DELETE FROM nfts").Error; err != nil {
+	if err != nil {
 		wailsRuntime.EventsEmit(a.ctx, "clear:error", ClearDataStatus{
 			InProgress: false,
 			Phase:      "error",
 			Error:      err.Error(),
 		})
-		return fmt.Errorf("failed to delete nfts: %w", err)
+		return fmt.Errorf("failed to reset data: %w", err)
 	}
 	
-	// Step 4: Update disk usage
-	a.backupService.GetManager().MarkDiskUsageDirty()
+	// Update disk usage
 	a.backupService.GetManager().UpdateDiskUsage()
 	
+	var cp core.UnpinAllCheckpoint
+	if done, _ := a.backupService.Jobs().Get(job.ID); done != nil && done.Checkpoint != "" {
+		_ = json.Unmarshal([]byte(done.Checkpoint), &cp)
+	}
+	
 	// Emit complete
 	wailsRuntime.EventsEmit(a.ctx, "clear:complete", ClearDataStatus{
 		InProgress:    false,
 		Phase:         "complete",
-		Message:       fmt.Sprintf("Cleared %d pins", unpinned),
-		UnpinnedCount: unpinned,
+		Message:       fmt.Sprintf("Cleared %d pins", cp.Unpinned),
+		UnpinnedCount: cp.Unpinned,
 	})
 	
 	log.Println("Full data reset complete")
@@ -1024,11 +1459,13 @@ func extractCIDFromURI(uri string) string {
 // GetStorageLocation returns information about the current storage location
 func (a *App) GetStorageLocation() (*storage.StorageLocation, error) {
 	repoPath := a.ipfsNode.GetRepoPath()
+	storage.SetTierPaths(repoPath, a.ipfsNode.GetColdPath())
 	return storage.GetStorageInfo(repoPath)
 }
 
 // ListStorageLocations returns all available storage locations
 func (a *App) ListStorageLocations() ([]*storage.StorageLocation, error) {
+	storage.SetTierPaths(a.ipfsNode.GetRepoPath(), a.ipfsNode.GetColdPath())
 	return storage.ListAvailableLocations()
 }
 
@@ -1046,6 +1483,18 @@ func (a *App) GetStorageType(path string) (string, error) {
 	return string(storageType), nil
 }
 
+// StorageMigrateParams are the parameters of a storage_migrate job
+type StorageMigrateParams struct {
+	DestPath string `json:"dest_path"`
+}
+
+// StorageMigrateCheckpoint is the resume state of a storage_migrate job.
+// NewPath is set once the data has been moved, before the config is switched.
+type StorageMigrateCheckpoint struct {
+	SourcePath string `json:"source_path"`
+	NewPath    string `json:"new_path,omitempty"`
+}
+
 // MigrateStorage moves the IPFS repository to a new location
 // This will stop the backup service, move the data, and restart with new location
 func (a *App) MigrateStorage(destPath string) error {
@@ -1059,20 +1508,49 @@ func (a *App) MigrateStorage(destPath string) error {
 	}
 	log.Println("Destination validated successfully")
 
-	// Get current path
-	currentPath := a.ipfsNode.GetRepoPath()
-	log.Printf("Current IPFS path: %s", currentPath)
-	
 	// Check if same path
 	expandedDest, _ := storage.ExpandPath(destPath)
-	if currentPath == expandedDest {
+	if a.ipfsNode.GetRepoPath() == expandedDest {
 		return fmt.Errorf("destination is same as current location")
 	}
 
+	// Run as a persistent job so an interrupted migration resumes on next start
+	job, err := a.jobs.Submit(a.ctx, core.JobTypeStorageMigrate, StorageMigrateParams{DestPath: destPath})
+	if err != nil {
+		return fmt.Errorf("failed to start migration: %w", err)
+	}
+	return a.waitForJob(a.jobs, job.ID)
+}
+
+// runStorageMigrateJob stops the node and backup service, moves the IPFS
+// repository and restarts everything at the new location
+func (a *App) runStorageMigrateJob(ctx context.Context, jc *core.JobContext) error {
+	var params StorageMigrateParams
+	if err := jc.Params(&params); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+
+	var cp StorageMigrateCheckpoint
+	if _, err := jc.Checkpoint(&cp); err != nil {
+		return err
+	}
+	if cp.NewPath != "" {
+		// Data already moved and the config switched at startup
+		return nil
+	}
+
+	// Get current path
+	currentPath := a.ipfsNode.GetRepoPath()
+	log.Printf("Current IPFS path: %s", currentPath)
+	cp.SourcePath = currentPath
+	if err := jc.SaveCheckpoint(cp); err != nil {
+		return err
+	}
+
 	// Emit starting event
 	wailsRuntime.EventsEmit(a.ctx, "storage:migration:start", map[string]interface{}{
 		"source": currentPath,
-		"dest":   destPath,
+		"dest":   params.DestPath,
 	})
 
 	// Stop backup service
@@ -1089,9 +1567,37 @@ func (a *App) MigrateStorage(destPath string) error {
 
 	// Create storage manager and perform migration
 	manager := storage.NewManager(currentPath)
+
+	// Switch the config only once the new copy is verified. The checkpoint is
+	// written first so a crash in between is finished by
+	// finishInterruptedMigration on next start.
+	manager.SetSwitchFunc(func(newPath string) error {
+		cp.NewPath = newPath
+		if err := jc.SaveCheckpoint(cp); err != nil {
+			return fmt.Errorf("failed to checkpoint migration: %w", err)
+		}
+		err := a.updateConfig(func(cfg *config.Config) error {
+			cfg.IPFS.RepoPath = newPath
+			return cfg.SaveConfig(a.configPath())
+		})
+		if err != nil {
+			cp.NewPath = ""
+			if cpErr := jc.SaveCheckpoint(cp); cpErr != nil {
+				log.Printf("Warning: failed to reset migration checkpoint: %v", cpErr)
+			}
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		return nil
+	})
 	
-	err := manager.Migrate(a.ctx, destPath, func(status storage.MigrationStatus) {
+	lastPercent := -1
+	err := manager.Migrate(ctx, params.DestPath, func(status storage.MigrationStatus) {
 		wailsRuntime.EventsEmit(a.ctx, "storage:migration:progress", status)
+		// Only persist whole-percent changes to avoid a write per file
+		if percent := int(status.Progress); percent != lastPercent {
+			lastPercent = percent
+			jc.SetProgress(percent, 100, status.Phase)
+		}
 	})
 
 	if err != nil {
@@ -1099,7 +1605,10 @@ func (a *App) MigrateStorage(destPath string) error {
 		log.Printf("Migration failed, attempting to restart with old path: %v", err)
 		wailsRuntime.EventsEmit(a.ctx, "storage:migration:error", err.Error())
 		
-		newNode, nodeErr := ipfs.NewNode(currentPath, a.config.IPFS.SwarmPort)
+		newNode, nodeErr := ipfs.NewNode(currentPath, a.currentConfig().IPFS.SwarmPort)
+		if nodeErr == nil {
+			nodeErr = newNode.SetColdPath(a.currentConfig().IPFS.Tiering.ColdPath)
+		}
 		if nodeErr == nil {
 			nodeErr = newNode.Start(a.ctx)
 			if nodeErr == nil {
@@ -1110,21 +1619,19 @@ func (a *App) MigrateStorage(destPath string) error {
 		return fmt.Errorf("migration failed: %w", err)
 	}
 
-	// Update config with new path
-	a.config.IPFS.RepoPath = expandedDest
-	homeDir, _ := os.UserHomeDir()
-	configPath := filepath.Join(homeDir, ".porcupin", "config.yaml")
-	if err := a.config.SaveConfig(configPath); err != nil {
-		log.Printf("Warning: failed to save config: %v", err)
-	}
+	newPath := manager.GetCurrentPath()
 
 	// Start IPFS node with new path
-	log.Printf("Starting IPFS node at new location: %s", expandedDest)
-	newNode, err := ipfs.NewNode(expandedDest, a.config.IPFS.SwarmPort)
+	log.Printf("Starting IPFS node at new location: %s", newPath)
+	newNode, err := ipfs.NewNode(newPath, a.currentConfig().IPFS.SwarmPort)
 	if err != nil {
 		wailsRuntime.EventsEmit(a.ctx, "storage:migration:error", err.Error())
 		return fmt.Errorf("failed to create node at new location: %w", err)
 	}
+	if err := newNode.SetColdPath(a.currentConfig().IPFS.Tiering.ColdPath); err != nil {
+		wailsRuntime.EventsEmit(a.ctx, "storage:migration:error", err.Error())
+		return fmt.Errorf("failed to set cold storage tier: %w", err)
+	}
 
 	if err := newNode.Start(a.ctx); err != nil {
 		wailsRuntime.EventsEmit(a.ctx, "storage:migration:error", err.Error())
@@ -1141,19 +1648,91 @@ func (a *App) MigrateStorage(destPath string) error {
 	a.backupService.GetManager().UpdateDiskUsage()
 
 	wailsRuntime.EventsEmit(a.ctx, "storage:migration:complete", map[string]interface{}{
-		"new_path": expandedDest,
+		"new_path": newPath,
 	})
 
-	log.Printf("Storage migration complete: %s -> %s", currentPath, expandedDest)
+	log.Printf("Storage migration complete: %s -> %s", currentPath, newPath)
+	return nil
+}
+
+// finishInterruptedMigration switches the config to the new repository path
+// for migrations that moved the data but stopped before updating the config
+func (a *App) finishInterruptedMigration(configPath string) {
+	jobs, err := a.database.GetUnfinishedJobs()
+	if err != nil {
+		log.Printf("Failed to check for interrupted migrations: %v", err)
+		return
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Type != core.JobTypeStorageMigrate || job.Checkpoint == "" {
+			continue
+		}
+		var cp StorageMigrateCheckpoint
+		if err := json.Unmarshal([]byte(job.Checkpoint), &cp); err != nil || cp.NewPath == "" {
+			continue
+		}
+
+		log.Printf("Completing interrupted storage migration: %s -> %s", cp.SourcePath, cp.NewPath)
+		err := a.updateConfig(func(cfg *config.Config) error {
+			cfg.IPFS.RepoPath = cp.NewPath
+			return cfg.SaveConfig(configPath)
+		})
+		if err != nil {
+			log.Printf("Warning: failed to save config: %v", err)
+		}
+		if err := storage.CleanupMigration(cp.SourcePath, cp.NewPath); err != nil {
+			log.Printf("Warning: failed to clean up after migration: %v", err)
+		}
+
+		now := time.Now()
+		job.Status = db.JobStatusCompleted
+		job.Message = "Completed after restart"
+		job.FinishedAt = &now
+		if err := a.database.SaveJob(job); err != nil {
+			log.Printf("Failed to update migration job %d: %v", job.ID, err)
+		}
+	}
+}
+
+// currentConfig returns the settings in effect. Once the backup service is
+// up it owns them, since config reloads replace them; the result must not be
+// modified, use updateConfig.
+func (a *App) currentConfig() *config.Config {
+	if a.backupService != nil {
+		return a.backupService.Config()
+	}
+	return a.config
+}
+
+// updateConfig changes the settings in effect through a copy, which replaces
+// them unless update returns an error
+func (a *App) updateConfig(update func(cfg *config.Config) error) error {
+	if a.backupService != nil {
+		return a.backupService.UpdateConfig(update)
+	}
+	next := *a.config
+	if err := update(&next); err != nil {
+		return err
+	}
+	a.config = &next
 	return nil
 }
 
+// configPath returns the path of the app's config file
+func (a *App) configPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".porcupin", "config.yaml")
+}
+
 // GetMigrationStatus returns the current migration status
 func (a *App) GetMigrationStatus() storage.MigrationStatus {
 	return storage.GetGlobalMigrationStatus()
 }
 
-// CancelMigration cancels an ongoing storage migration
+// CancelMigration cancels an ongoing storage migration.
+// The migration job restarts IPFS and the backup service at the original path.
 func (a *App) CancelMigration() error {
 	log.Println("CancelMigration called")
 	err := storage.CancelGlobalMigration()
@@ -1162,30 +1741,257 @@ func (a *App) CancelMigration() error {
 		return err
 	}
 	
-	wailsRuntime.EventsEmit(a.ctx, "storage:migration:cancelled", nil)
-	
-	// Restart IPFS and backup service with original path
-	log.Println("Restarting services after cancellation...")
-	currentPath := a.ipfsNode.GetRepoPath()
-	
-	newNode, err := ipfs.NewNode(currentPath, a.config.IPFS.SwarmPort)
+	jobs, err := a.database.ListJobs(db.JobStatusRunning, 0)
 	if err != nil {
-		log.Printf("Failed to create node after cancel: %v", err)
-		return fmt.Errorf("failed to restart IPFS: %w", err)
+		return err
 	}
-	
-	if err := newNode.Start(a.ctx); err != nil {
-		log.Printf("Failed to start node after cancel: %v", err)
-		return fmt.Errorf("failed to restart IPFS: %w", err)
+	for _, job := range jobs {
+		if job.Type == core.JobTypeStorageMigrate && a.jobs.IsRunning(job.ID) {
+			if err := a.jobs.Cancel(job.ID); err != nil {
+				log.Printf("Failed to cancel migration job %d: %v", job.ID, err)
+			}
+		}
 	}
 	
-	a.ipfsNode = newNode
-	a.backupService.Start(a.ctx)
+	wailsRuntime.EventsEmit(a.ctx, "storage:migration:cancelled", nil)
 	
-	log.Println("Services restarted after migration cancellation")
+	log.Println("Migration cancelled")
 	return nil
 }
 
+// =============================================================================
+// Replication
+// =============================================================================
+
+// GetReplicationPeers returns the nodes this one replicates, with lag and divergence
+func (a *App) GetReplicationPeers() ([]core.ReplicationStatus, error) {
+	return a.backupService.Replication().Statuses()
+}
+
+// AddReplicationPeer subscribes to another Porcupin server's catalog and starts the first sync.
+// The server must be running with --serve.
+func (a *App) AddReplicationPeer(name string, cfg RemoteServerConfig) (*core.ReplicationStatus, error) {
+	cfg, err := a.resolveRemote(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Host == "" || cfg.Port <= 0 || cfg.Token == "" {
+		return nil, fmt.Errorf("host, port and token are required")
+	}
+	ctx, cancel := context.WithTimeout(a.ctx, 10*time.Second)
+	defer cancel()
+	_, pin, err := remoteClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	peer := &db.ReplicationPeer{
+		Name:    name,
+		Host:    cfg.Host,
+		Port:    cfg.Port,
+		Token:   cfg.Token,
+		UseTLS:  cfg.UseTLS,
+		TLSPin:  pin,
+		Enabled: true,
+	}
+	if err := a.database.SaveReplicationPeer(peer); err != nil {
+		return nil, err
+	}
+	if _, err := a.backupService.SubmitJob(core.JobTypeReplicate, core.ReplicateParams{PeerID: peer.ID, Full: true}); err != nil {
+		log.Printf("Failed to start initial replication of %s: %v", peer.Host, err)
+	}
+	return a.backupService.Replication().Status(peer.ID)
+}
+
+// SyncReplicationPeer starts a sync with a peer in the background
+func (a *App) SyncReplicationPeer(id uint64, full bool) (*db.Job, error) {
+	return a.backupService.SubmitJob(core.JobTypeReplicate, core.ReplicateParams{PeerID: id, Full: full})
+}
+
+// RemoveReplicationPeer stops replicating a peer, optionally unpinning what
+// was replicated from it
+func (a *App) RemoveReplicationPeer(id uint64, unpin bool) error {
+	if !unpin {
+		return a.backupService.Replication().RemovePeer(a.ctx, id, false, nil)
+	}
+	job, err := a.backupService.SubmitJob(core.JobTypeRemovePeer, core.RemovePeerParams{PeerID: id, Unpin: true})
+	if err != nil {
+		return fmt.Errorf("failed to start removal: %w", err)
+	}
+	return a.waitForJob(a.backupService.Jobs(), job.ID)
+}
+
+// =============================================================================
+// Pinning Services
+// =============================================================================
+
+// GetPinningServices returns the pinning services assets are mirrored to, with remote pin counts
+func (a *App) GetPinningServices() ([]core.MirrorStatus, error) {
+	return a.backupService.Mirroring().Statuses()
+}
+
+// AddPinningService starts mirroring pinned assets to an IPFS Pinning Service API
+// endpoint. An empty wallet list mirrors every wallet.
+func (a *App) AddPinningService(name, endpoint, token string, wallets []string) (*core.MirrorStatus, error) {
+	if name == "" || token == "" {
+		return nil, fmt.Errorf("name and token are required")
+	}
+	if err := pinning.ValidateEndpoint(endpoint); err != nil {
+		return nil, err
+	}
+	for _, address := range wallets {
+		if !api.IsValidTezosAddress(address) {
+			return nil, fmt.Errorf("invalid wallet address: %s", address)
+		}
+	}
+	svc := &db.PinningService{
+		Name:     name,
+		Endpoint: endpoint,
+		Token:    token,
+		Enabled:  true,
+	}
+	svc.SetWalletList(wallets)
+	if err := a.database.SavePinningService(svc); err != nil {
+		return nil, err
+	}
+	if _, err := a.backupService.SubmitJob(core.JobTypeMirror, core.MirrorParams{ServiceID: svc.ID}); err != nil {
+		log.Printf("Failed to start mirroring to %s: %v", svc.Name, err)
+	}
+	return a.backupService.Mirroring().Status(svc.ID)
+}
+
+// UpdatePinningService changes which wallets are mirrored to a service and whether it is enabled
+func (a *App) UpdatePinningService(id uint64, wallets []string, enabled bool) (*core.MirrorStatus, error) {
+	svc, err := a.database.GetPinningService(id)
+	if err != nil {
+		return nil, err
+	}
+	if svc == nil {
+		return nil, core.ErrPinningServiceNotFound
+	}
+	for _, address := range wallets {
+		if !api.IsValidTezosAddress(address) {
+			return nil, fmt.Errorf("invalid wallet address: %s", address)
+		}
+	}
+	svc.SetWalletList(wallets)
+	svc.Enabled = enabled
+	if err := a.database.SavePinningService(svc); err != nil {
+		return nil, err
+	}
+	return a.backupService.Mirroring().Status(svc.ID)
+}
+
+// ReconcilePinningService compares a pinning service with the local pins in the background
+func (a *App) ReconcilePinningService(id uint64) (*db.Job, error) {
+	return a.backupService.SubmitJob(core.JobTypeMirror, core.MirrorParams{ServiceID: id})
+}
+
+// RemovePinningService stops mirroring to a service, optionally removing the pins there
+func (a *App) RemovePinningService(id uint64, unpin bool) error {
+	if !unpin {
+		return a.backupService.Mirroring().RemoveService(a.ctx, id, false, nil)
+	}
+	job, err := a.backupService.SubmitJob(core.JobTypeRemoveMirror, core.RemoveMirrorParams{ServiceID: id, Unpin: true})
+	if err != nil {
+		return fmt.Errorf("failed to start removal: %w", err)
+	}
+	return a.waitForJob(a.backupService.Jobs(), job.ID)
+}
+
+// GetAssetRemotePins returns the status of an asset's CID at each pinning service
+func (a *App) GetAssetRemotePins(assetID uint64) ([]db.RemotePin, error) {
+	var asset db.Asset
+	if err := a.database.First(&asset, assetID).Error; err != nil {
+		return nil, err
+	}
+	cid := core.ExtractCIDFromURI(asset.URI)
+	if cid == "" {
+		return []db.RemotePin{}, nil
+	}
+	return a.database.GetRemotePinsByCID(cid)
+}
+
+// =============================================================================
+// Jobs
+// =============================================================================
+
+// GetJobs returns recent jobs, optionally filtered by status
+func (a *App) GetJobs(status string, limit int) ([]db.Job, error) {
+	return a.database.ListJobs(status, limit)
+}
+
+// CancelJob cancels a running or pending job
+func (a *App) CancelJob(id uint64) error {
+	return a.jobManagerFor(id).Cancel(id)
+}
+
+// ResumeJob restarts a failed, cancelled or interrupted job from its checkpoint
+func (a *App) ResumeJob(id uint64) error {
+	jm := a.jobManagerFor(id)
+	if jm == a.jobs {
+		_, err := a.jobs.Resume(a.ctx, id)
+		return err
+	}
+	_, err := a.backupService.ResumeJob(id)
+	return err
+}
+
+// jobManagerFor returns the job manager that handles the given job
+func (a *App) jobManagerFor(id uint64) *core.JobManager {
+	if job, err := a.database.GetJob(id); err == nil && job != nil && job.Type == core.JobTypeStorageMigrate {
+		return a.jobs
+	}
+	return a.backupService.Jobs()
+}
+
+// waitForJob blocks until a job finishes and converts its outcome into an error
+func (a *App) waitForJob(jm *core.JobManager, id uint64) error {
+	jm.Wait(id)
+	job, err := jm.Get(id)
+	if err != nil {
+		return err
+	}
+	if job == nil {
+		return core.ErrJobNotFound
+	}
+	switch job.Status {
+	case db.JobStatusCompleted:
+		return nil
+	case db.JobStatusFailed:
+		return errors.New(job.Error)
+	default:
+		return fmt.Errorf("job %s", job.Status)
+	}
+}
+
+// emitJobUpdate forwards job progress to the frontend. Reset jobs are also
+// reported through the clear:progress events the settings page listens for.
+func (a *App) emitJobUpdate(job db.Job) {
+	wailsRuntime.EventsEmit(a.ctx, "job:update", job)
+
+	if job.Type != core.JobTypeUnpinAll || job.IsFinished() {
+		return
+	}
+	cp := core.UnpinAllCheckpoint{Phase: "unpin"}
+	if job.Checkpoint != "" {
+		_ = json.Unmarshal([]byte(job.Checkpoint), &cp)
+	}
+	phase := "unpinning"
+	switch cp.Phase {
+	case "gc":
+		phase = "garbage_collect"
+	case "clear":
+		phase = "clearing_db"
+	}
+	wailsRuntime.EventsEmit(a.ctx, "clear:progress", ClearDataStatus{
+		InProgress:    true,
+		Phase:         phase,
+		Message:       job.Message,
+		TotalPins:     job.Total,
+		UnpinnedCount: job.Current,
+	})
+}
+
 // BrowseForFolder opens a folder picker dialog
 func (a *App) BrowseForFolder() (string, error) {
 	return wailsRuntime.OpenDirectoryDialog(a.ctx, wailsRuntime.OpenDialogOptions{
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/caddyserver/certmagic"
+	"github.com/libdns/libdns"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// ACMEDirName is the directory in the data directory where ACME accounts
+	// and certificates are kept between runs
+	ACMEDirName = "acme"
+
+	// LetsEncryptCA is the default ACME directory
+	LetsEncryptCA = certmagic.LetsEncryptProductionCA
+
+	// LetsEncryptStagingCA issues untrusted certificates under much higher
+	// rate limits, for trying out a setup
+	LetsEncryptStagingCA = certmagic.LetsEncryptStagingCA
+
+	// DefaultACMEHTTPPort is where the HTTP-01 challenge is answered. CAs
+	// only connect to port 80, so another port needs a forward from 80.
+	DefaultACMEHTTPPort = 80
+)
+
+// ACMEConfig obtains the API's certificate from an ACME CA such as Let's
+// Encrypt, for servers reachable under a public domain name. Certificates
+// are renewed automatically while the server runs, and cached in the data
+// directory.
+type ACMEConfig struct {
+	// Domains are the names the certificate covers; the first is served to
+	// clients that connect by IP address
+	Domains []string
+
+	// Email is given to the CA for expiry notices (optional)
+	Email string
+
+	// CA is the ACME directory URL (default: LetsEncryptCA)
+	CA string
+
+	// TrustedRoots verify the CA's own TLS certificate, for private or test
+	// CAs such as Pebble. Nil uses the system roots.
+	TrustedRoots *x509.CertPool
+
+	// HTTPPort is where the HTTP-01 challenge listener binds while a
+	// certificate is being obtained (default: DefaultACMEHTTPPort)
+	HTTPPort int
+
+	// DNSProvider solves the DNS-01 challenge instead of HTTP-01. It needs
+	// no inbound connections, so it also works for servers that aren't
+	// reachable from the internet.
+	DNSProvider DNSProvider
+}
+
+// DNSProvider creates and deletes the TXT records of DNS-01 challenges. Any
+// provider from github.com/libdns satisfies it.
+type DNSProvider = certmagic.DNSProvider
+
+// DNSProviderFactory creates a DNS provider from the argument after the
+// colon in a provider spec such as "exec:/usr/local/bin/dns-hook"
+type DNSProviderFactory func(arg string) (DNSProvider, error)
+
+var (
+	dnsProvidersMu sync.RWMutex
+	dnsProviders   = map[string]DNSProviderFactory{
+		"exec": newExecDNSProvider,
+	}
+)
+
+// RegisterDNSProvider makes a DNS provider available to NewDNSProvider by
+// name, so builds can add providers for their DNS hosts
+func RegisterDNSProvider(name string, factory DNSProviderFactory) {
+	dnsProvidersMu.Lock()
+	defer dnsProvidersMu.Unlock()
+	dnsProviders[name] = factory
+}
+
+// DNSProviderNames lists the registered DNS providers
+func DNSProviderNames() []string {
+	dnsProvidersMu.RLock()
+	defer dnsProvidersMu.RUnlock()
+	names := make([]string, 0, len(dnsProviders))
+	for name := range dnsProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewDNSProvider creates a registered DNS provider from a spec of the form
+// name:argument
+func NewDNSProvider(spec string) (DNSProvider, error) {
+	name, arg, _ := strings.Cut(spec, ":")
+	dnsProvidersMu.RLock()
+	factory, ok := dnsProviders[name]
+	dnsProvidersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown DNS provider %q (available: %s)", name, strings.Join(DNSProviderNames(), ", "))
+	}
+	return factory(arg)
+}
+
+// execDNSProvider runs a program to change DNS records, as
+// "<program> present|cleanup <fqdn> <value>", for DNS hosts without a
+// built-in provider. The FQDN ends with a dot.
+type execDNSProvider struct {
+	program string
+}
+
+func newExecDNSProvider(program string) (DNSProvider, error) {
+	if program == "" {
+		return nil, fmt.Errorf("exec DNS provider needs a program, e.g. exec:/usr/local/bin/dns-hook")
+	}
+	return &execDNSProvider{program: program}, nil
+}
+
+// AppendRecords presents each record
+func (p *execDNSProvider) AppendRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	return p.run(ctx, "present", zone, recs)
+}
+
+// DeleteRecords cleans up each record
+func (p *execDNSProvider) DeleteRecords(ctx context.Context, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	return p.run(ctx, "cleanup", zone, recs)
+}
+
+func (p *execDNSProvider) run(ctx context.Context, action, zone string, recs []libdns.Record) ([]libdns.Record, error) {
+	for i, rec := range recs {
+		rr := rec.RR()
+		fqdn := libdns.AbsoluteName(rr.Name, zone)
+		out, err := exec.CommandContext(ctx, p.program, action, fqdn, rr.Data).CombinedOutput()
+		if err != nil {
+			return recs[:i], fmt.Errorf("%s %s failed: %w: %s", p.program, action, err, strings.TrimSpace(string(out)))
+		}
+	}
+	return recs, nil
+}
+
+// acmeManager obtains and renews the API certificate
+type acmeManager struct {
+	cache  *certmagic.Cache
+	magic  *certmagic.Config
+	config ACMEConfig
+}
+
+// newACMEManager prepares certificate management for cfg, storing state in
+// dataDir. bindAddress limits the HTTP-01 listener to the API's interface.
+func newACMEManager(cfg ACMEConfig, dataDir, bindAddress string) (*acmeManager, error) {
+	if len(cfg.Domains) == 0 {
+		return nil, fmt.Errorf("ACME needs at least one domain")
+	}
+	if dataDir == "" {
+		return nil, fmt.Errorf("ACME needs a data directory to keep certificates in")
+	}
+	if cfg.CA == "" {
+		cfg.CA = LetsEncryptCA
+	}
+	if cfg.HTTPPort == 0 {
+		cfg.HTTPPort = DefaultACMEHTTPPort
+	}
+
+	logger := acmeLogger()
+	m := &acmeManager{config: cfg}
+	m.cache = certmagic.NewCache(certmagic.CacheOptions{
+		GetConfigForCert: func(certmagic.Certificate) (*certmagic.Config, error) { return m.magic, nil },
+		Logger:           logger,
+	})
+	m.magic = certmagic.New(m.cache, certmagic.Config{
+		Storage:           &certmagic.FileStorage{Path: filepath.Join(dataDir, ACMEDirName)},
+		DefaultServerName: cfg.Domains[0],
+		Logger:            logger,
+	})
+
+	issuer := certmagic.ACMEIssuer{
+		CA:                      cfg.CA,
+		Email:                   cfg.Email,
+		Agreed:                  true,
+		TrustedRoots:            cfg.TrustedRoots,
+		AltHTTPPort:             cfg.HTTPPort,
+		DisableTLSALPNChallenge: true, // Needs port 443, which the API doesn't use
+		Logger:                  logger,
+	}
+	if ip := net.ParseIP(bindAddress); ip != nil && !ip.IsUnspecified() {
+		issuer.ListenHost = bindAddress
+	}
+	if cfg.DNSProvider != nil {
+		issuer.DisableHTTPChallenge = true
+		issuer.DNS01Solver = &certmagic.DNS01Solver{
+			DNSManager: certmagic.DNSManager{DNSProvider: cfg.DNSProvider, Logger: logger},
+		}
+	}
+	m.magic.Issuers = []certmagic.Issuer{certmagic.NewACMEIssuer(m.magic, issuer)}
+	return m, nil
+}
+
+// Start obtains the certificate, or loads it from the cache, and returns a
+// TLS config that serves it. Renewal continues in the background until
+// Stop.
+func (m *acmeManager) Start(ctx context.Context) (*tls.Config, error) {
+	challenge := fmt.Sprintf("HTTP-01 on port %d", m.config.HTTPPort)
+	if m.config.DNSProvider != nil {
+		challenge = "DNS-01"
+	}
+	log.Printf("ACME: Managing certificate for %s from %s (%s)", strings.Join(m.config.Domains, ", "), m.config.CA, challenge)
+	if err := m.magic.ManageSync(ctx, m.config.Domains); err != nil {
+		return nil, fmt.Errorf("failed to obtain certificate for %s: %w", strings.Join(m.config.Domains, ", "), err)
+	}
+	tlsConfig := m.magic.TLSConfig()
+	tlsConfig.NextProtos = nil // TLS-ALPN challenges are disabled
+	return tlsConfig, nil
+}
+
+// Stop ends background renewal
+func (m *acmeManager) Stop() {
+	m.cache.Stop()
+}
+
+// acmeLogger sends certmagic's log through the standard logger, as
+// everything else the server logs
+func acmeLogger() *zap.Logger {
+	encoder := zap.NewDevelopmentEncoderConfig()
+	encoder.TimeKey = "" // The standard logger adds the time
+	encoder.CallerKey = ""
+	core := zapcore.NewCore(zapcore.NewConsoleEncoder(encoder), zapcore.AddSync(log.Writer()), zap.InfoLevel)
+	return zap.New(core).Named("acme")
+}
+
+// LoadCertPool reads PEM certificates from a file into a pool, for
+// ACMEConfig.TrustedRoots
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates in %s", path)
+	}
+	return pool, nil
+}
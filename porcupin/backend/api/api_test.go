@@ -3,11 +3,20 @@ package api
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -15,9 +24,19 @@ import (
 
 	"github.com/glebarez/sqlite"
 	"github.com/go-chi/chi/v5"
+	pebbleca "github.com/letsencrypt/pebble/v2/ca"
+	pebbledb "github.com/letsencrypt/pebble/v2/db"
+	pebbleva "github.com/letsencrypt/pebble/v2/va"
+	pebblewfe "github.com/letsencrypt/pebble/v2/wfe"
+	"github.com/libdns/libdns"
 	"gorm.io/gorm"
 
+	"porcupin/backend/config"
+	"porcupin/backend/core"
 	"porcupin/backend/db"
+	"porcupin/backend/indexer"
+	"porcupin/backend/pinning"
+	"porcupin/backend/storage"
 )
 
 // =============================================================================
@@ -929,6 +948,9 @@ func setupTestDB(t *testing.T) *db.Database {
 	if err != nil {
 		t.Fatalf("Failed to open test database: %v", err)
 	}
+	// Each connection to :memory: is a separate database; keep to one
+	sqlDB, _ := gormDB.DB()
+	sqlDB.SetMaxOpenConns(1)
 	
 	if err := db.InitDB(gormDB); err != nil {
 		t.Fatalf("Failed to initialize test database: %v", err)
@@ -1019,6 +1041,72 @@ func TestAddWallet_Duplicate(t *testing.T) {
 	}
 }
 
+func TestAddWallet_Domain(t *testing.T) {
+	alice := "tz1" + strings.Repeat("a", 33)
+	bob := "tz1" + strings.Repeat("b", 33)
+	tzkt := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expires := time.Now().Add(time.Hour)
+		domains := []indexer.Domain{}
+		q := r.URL.Query()
+		switch {
+		case q.Get("name") == "alice.tez":
+			domains = append(domains, indexer.Domain{Name: "alice.tez", Address: &indexer.AccountInfo{Address: alice}, Expiration: expires})
+		case q.Get("name") == "bob-art.tez":
+			domains = append(domains, indexer.Domain{Name: "bob-art.tez", Address: &indexer.AccountInfo{Address: bob}, Expiration: expires})
+		case q.Get("address.in") == alice:
+			domains = append(domains, indexer.Domain{Name: "alice.tez", Address: &indexer.AccountInfo{Address: alice}, Reverse: true, Expiration: expires})
+		}
+		json.NewEncoder(w).Encode(domains)
+	}))
+	defer tzkt.Close()
+
+	database := setupTestDB(t)
+	svc := core.NewBackupService(nil, indexer.NewIndexer(tzkt.URL), database, config.DefaultConfig())
+	h := NewHandlers(database, svc, t.TempDir(), "test")
+	add := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/wallets", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.AddWallet(rr, req)
+		return rr
+	}
+
+	// A domain is resolved, and the address's reverse record names the wallet
+	rr := add(`{"address": "Alice.tez"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("AddWallet(alice.tez) status = %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	var resp WalletResponse
+	decodeData(t, rr, &resp)
+	if resp.Address != alice || resp.Alias != "alice.tez" || resp.Domain != "alice.tez" {
+		t.Errorf("AddWallet(alice.tez) = %+v", resp)
+	}
+	if rr := add(`{"address": "` + alice + `"}`); rr.Code != http.StatusConflict {
+		t.Errorf("AddWallet(address of alice.tez) status = %d, want %d", rr.Code, http.StatusConflict)
+	}
+
+	// Without a reverse record, the name given is kept; an alias wins over it
+	rr = add(`{"address": "bob-art.tez", "alias": "Bob"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("AddWallet(bob-art.tez) status = %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	if wallet, _ := database.GetWallet(bob); wallet == nil || wallet.Alias != "Bob" || wallet.Domain != "bob-art.tez" {
+		t.Errorf("bob-art.tez wallet = %+v", wallet)
+	}
+
+	if rr := add(`{"address": "nobody.tez"}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("AddWallet(nobody.tez) status = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+	if rr := add(`{"address": "alice.com"}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("AddWallet(alice.com) status = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+
+	// Without the service there's no indexer to ask
+	h = NewHandlers(database, nil, t.TempDir(), "test")
+	if rr := add(`{"address": "carol.tez"}`); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("AddWallet(carol.tez) without a service status = %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+}
+
 func TestGetWallet_Found(t *testing.T) {
 	database := setupTestDB(t)
 	h := NewHandlers(database, nil, t.TempDir(), "test")
@@ -1772,4 +1860,2298 @@ func TestServerGetListenAddress(t *testing.T) {
 	if addr == "" {
 		t.Error("GetListenAddress() after start returned empty string")
 	}
-}
\ No newline at end of file
+}
+// =============================================================================
+// Job Endpoint Tests
+// =============================================================================
+
+// newTestService creates a backup service without an IPFS node
+func newTestService(database *db.Database) *core.BackupService {
+	cfg := config.DefaultConfig()
+	return core.NewBackupService(nil, indexer.NewIndexer(cfg.TZKT.BaseURL), database, cfg)
+}
+
+// decodeData decodes the data field of a wrapped API response into v
+func decodeData(t *testing.T, rr *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	resp := struct {
+		Data json.RawMessage `json:"data"`
+	}{}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if err := json.Unmarshal(resp.Data, v); err != nil {
+		t.Fatalf("Failed to decode data: %v", err)
+	}
+}
+
+func TestJobs_NoService(t *testing.T) {
+	database := setupTestDB(t)
+	router := NewRouter(database, nil, t.TempDir(), "test")
+
+	for _, tc := range []struct{ method, path string }{
+		{"GET", "/api/v1/jobs"},
+		{"GET", "/api/v1/jobs/1"},
+		{"POST", "/api/v1/jobs"},
+		{"POST", "/api/v1/jobs/1/cancel"},
+		{"POST", "/api/v1/jobs/1/resume"},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s status = %d, want %d", tc.method, tc.path, rr.Code, http.StatusServiceUnavailable)
+		}
+	}
+}
+
+func TestSubmitJob_Validation(t *testing.T) {
+	database := setupTestDB(t)
+	router := NewRouter(database, newTestService(database), t.TempDir(), "test")
+
+	for _, body := range []string{
+		`not json`,
+		`{"type": "bogus"}`,
+		`{"type": "delete_wallet", "params": {"address": "nope"}}`,
+	} {
+		req := httptest.NewRequest("POST", "/api/v1/jobs", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("POST /jobs %s status = %d, want %d", body, rr.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestJobs_Lifecycle(t *testing.T) {
+	database := setupTestDB(t)
+	svc := newTestService(database)
+	router := NewRouter(database, svc, t.TempDir(), "test")
+
+	address := "tz1VSUr8wwNhLAzempoch5d6hLRiTh8Cjcjb"
+	database.SaveWallet(&db.Wallet{Address: address})
+
+	body := bytes.NewBufferString(`{"type": "delete_wallet", "params": {"address": "` + address + `", "unpin": true}}`)
+	req := httptest.NewRequest("POST", "/api/v1/jobs", body)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("POST /jobs status = %d, want %d. Body: %s", rr.Code, http.StatusAccepted, rr.Body.String())
+	}
+
+	var job db.Job
+	decodeData(t, rr, &job)
+	if job.ID == 0 || job.Type != core.JobTypeDeleteWallet {
+		t.Fatalf("Unexpected job: %+v", job)
+	}
+	svc.Jobs().Wait(job.ID)
+
+	// Get
+	req = httptest.NewRequest("GET", fmt.Sprintf("/api/v1/jobs/%d", job.ID), nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /jobs/{id} status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	decodeData(t, rr, &job)
+	if job.Status != db.JobStatusCompleted {
+		t.Errorf("Job status = %q, want completed (error: %s)", job.Status, job.Error)
+	}
+	if w, _ := database.GetWallet(address); w != nil {
+		t.Error("Wallet should have been deleted by the job")
+	}
+
+	// List
+	req = httptest.NewRequest("GET", "/api/v1/jobs?status=completed", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	var list JobsListResponse
+	decodeData(t, rr, &list)
+	if list.Total != 1 || len(list.Jobs) != 1 {
+		t.Errorf("GET /jobs returned %d jobs, want 1", list.Total)
+	}
+
+	// Cancel / resume a completed job conflicts
+	for _, action := range []string{"cancel", "resume"} {
+		req = httptest.NewRequest("POST", fmt.Sprintf("/api/v1/jobs/%d/%s", job.ID, action), nil)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusConflict {
+			t.Errorf("POST /jobs/{id}/%s status = %d, want %d", action, rr.Code, http.StatusConflict)
+		}
+	}
+
+	// Missing job
+	for _, path := range []string{"/api/v1/jobs/999", "/api/v1/jobs/999/cancel", "/api/v1/jobs/999/resume"} {
+		method := "POST"
+		if path == "/api/v1/jobs/999" {
+			method = "GET"
+		}
+		req = httptest.NewRequest(method, path, nil)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("%s %s status = %d, want %d", method, path, rr.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestDeleteWallet_UnpinRunsAsJob(t *testing.T) {
+	database := setupTestDB(t)
+	svc := newTestService(database)
+	router := NewRouter(database, svc, t.TempDir(), "test")
+
+	database.SaveWallet(&db.Wallet{Address: "tz1unpin"})
+
+	req := httptest.NewRequest("DELETE", "/api/v1/wallets/tz1unpin?unpin=true", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("DeleteWallet(unpin) status = %d, want %d", rr.Code, http.StatusAccepted)
+	}
+
+	var job db.Job
+	decodeData(t, rr, &job)
+	svc.Jobs().Wait(job.ID)
+
+	if w, _ := database.GetWallet("tz1unpin"); w != nil {
+		t.Error("Wallet should be deleted once the job finishes")
+	}
+}
+
+// =============================================================================
+// Pin Queue Endpoint Tests
+// =============================================================================
+
+func TestPinQueue_NoService(t *testing.T) {
+	database := setupTestDB(t)
+	router := NewRouter(database, nil, t.TempDir(), "test")
+
+	for _, tc := range []struct{ method, path string }{
+		{"GET", "/api/v1/queue"},
+		{"POST", "/api/v1/assets/1/pin-next"},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s status = %d, want %d", tc.method, tc.path, rr.Code, http.StatusServiceUnavailable)
+		}
+	}
+}
+
+func TestPinAssetNext(t *testing.T) {
+	database := setupTestDB(t)
+	svc := newTestService(database)
+	defer svc.GetManager().Shutdown()
+	// Keep the queue workers idle so the bumped asset stays queued
+	svc.Pause()
+	router := NewRouter(database, svc, t.TempDir(), "test")
+
+	nft := &db.NFT{TokenID: "1", ContractAddress: "KT1pinnext", WalletAddress: "tz1pinnext"}
+	database.Create(nft)
+	failed := &db.Asset{URI: "ipfs://pinnext", NFTID: nft.ID, Type: "artifact", Status: db.StatusFailed}
+	database.Create(failed)
+	pinned := &db.Asset{URI: "ipfs://pinned", NFTID: nft.ID, Type: "artifact", Status: db.StatusPinned}
+	database.Create(pinned)
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/api/v1/assets/abc/pin-next", http.StatusBadRequest},
+		{"/api/v1/assets/9999/pin-next", http.StatusNotFound},
+		{fmt.Sprintf("/api/v1/assets/%d/pin-next", pinned.ID), http.StatusConflict},
+		{fmt.Sprintf("/api/v1/assets/%d/pin-next", failed.ID), http.StatusAccepted},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest("POST", tc.path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != tc.want {
+			t.Errorf("POST %s status = %d, want %d. Body: %s", tc.path, rr.Code, tc.want, rr.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/queue", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /queue status = %d, want %d", rr.Code, http.StatusOK)
+	}
+
+	var status core.QueueStatus
+	decodeData(t, rr, &status)
+	if status.Queued != 1 || len(status.Bumped) != 1 || status.Bumped[0].AssetID != failed.ID {
+		t.Errorf("Queue = %+v, want asset %d bumped", status, failed.ID)
+	}
+}
+
+func TestUpdateWallet_Priority(t *testing.T) {
+	database := setupTestDB(t)
+	h := NewHandlers(database, nil, t.TempDir(), "test")
+
+	database.SaveWallet(&db.Wallet{Address: "tz1priority", SyncOwned: true, SyncCreated: true})
+
+	r := chi.NewRouter()
+	r.Put("/api/v1/wallets/{address}", h.UpdateWallet)
+
+	req := httptest.NewRequest("PUT", "/api/v1/wallets/tz1priority", bytes.NewBufferString(`{"priority": 5}`))
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("UpdateWallet() status = %d, want %d", rr.Code, http.StatusOK)
+	}
+
+	var resp WalletResponse
+	decodeData(t, rr, &resp)
+	if resp.Priority != 5 {
+		t.Errorf("Response priority = %d, want 5", resp.Priority)
+	}
+	if updated, _ := database.GetWallet("tz1priority"); updated.Priority != 5 {
+		t.Errorf("Wallet priority = %d, want 5", updated.Priority)
+	}
+}
+
+func TestWalletQuota(t *testing.T) {
+	database := setupTestDB(t)
+	h := NewHandlers(database, nil, t.TempDir(), "test")
+
+	database.SaveWallet(&db.Wallet{Address: "tz1quota", SyncOwned: true, SyncCreated: true})
+	nft := &db.NFT{TokenID: "1", ContractAddress: "KT1quota", WalletAddress: "tz1quota"}
+	database.Create(nft)
+	database.Create(&db.Asset{URI: "ipfs://QmQuota", NFTID: nft.ID, Status: db.StatusPinned, SizeBytes: 2 * 1024 * 1024 * 1024})
+
+	r := chi.NewRouter()
+	r.Put("/api/v1/wallets/{address}", h.UpdateWallet)
+	r.Get("/api/v1/stats", h.GetStats)
+
+	req := httptest.NewRequest("PUT", "/api/v1/wallets/tz1quota", bytes.NewBufferString(`{"quota_gb": -1}`))
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("UpdateWallet() with negative quota status = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+
+	req = httptest.NewRequest("PUT", "/api/v1/wallets/tz1quota", bytes.NewBufferString(`{"quota_gb": 1}`))
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("UpdateWallet() status = %d, want %d", rr.Code, http.StatusOK)
+	}
+
+	var wallet WalletResponse
+	decodeData(t, rr, &wallet)
+	if wallet.QuotaGB != 1 || wallet.UsedGB != 2 {
+		t.Errorf("Wallet quota/used = %d/%v, want 1/2", wallet.QuotaGB, wallet.UsedGB)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/stats", nil)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GetStats() status = %d, want %d", rr.Code, http.StatusOK)
+	}
+
+	var stats StatsResponse
+	decodeData(t, rr, &stats)
+	if stats.PinnedGB != 2 {
+		t.Errorf("pinned_gb = %v, want 2", stats.PinnedGB)
+	}
+	if len(stats.WalletUsage) != 1 || !stats.WalletUsage[0].OverQuota || stats.WalletUsage[0].QuotaGB != 1 {
+		t.Errorf("wallet_usage = %+v, want tz1quota over its 1 GB quota", stats.WalletUsage)
+	}
+}
+
+// =============================================================================
+// REPLICATION TESTS
+// =============================================================================
+
+func TestReplication_NoService(t *testing.T) {
+	database := setupTestDB(t)
+	router := NewRouter(database, nil, t.TempDir(), "test")
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{"GET", "/api/v1/replication/peers"},
+		{"POST", "/api/v1/replication/peers"},
+		{"GET", "/api/v1/replication/peers/1"},
+		{"POST", "/api/v1/replication/peers/1/sync"},
+		{"DELETE", "/api/v1/replication/peers/1"},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s status = %d, want %d", tc.method, tc.path, rr.Code, http.StatusServiceUnavailable)
+		}
+	}
+}
+
+func TestGetCatalog(t *testing.T) {
+	database := setupTestDB(t)
+	router := NewRouter(database, nil, t.TempDir(), "test")
+
+	base := time.Now().Add(-time.Hour).UTC()
+	for i := 0; i < 3; i++ {
+		pinnedAt := base.Add(time.Duration(i) * time.Minute)
+		database.Create(&db.Asset{
+			URI:       fmt.Sprintf("ipfs://QmCatalog%d", i),
+			Type:      "artifact",
+			Status:    db.StatusPinned,
+			SizeBytes: 100,
+			PinnedAt:  &pinnedAt,
+		})
+	}
+	database.Create(&db.Asset{URI: "ipfs://QmCatalogPending", Type: "artifact", Status: db.StatusPending})
+
+	req := httptest.NewRequest("GET", "/api/v1/replication/catalog?limit=2", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+
+	var page core.CatalogPage
+	decodeData(t, rr, &page)
+	if page.Total != 3 || len(page.Entries) != 2 || !page.HasMore {
+		t.Fatalf("page = total %d, %d entries, has_more %v; want 3, 2, true", page.Total, len(page.Entries), page.HasMore)
+	}
+	if page.Entries[0].CID != "QmCatalog0" {
+		t.Errorf("first entry CID = %q, want QmCatalog0", page.Entries[0].CID)
+	}
+
+	since := url.QueryEscape(page.Entries[1].PinnedAt.Format(time.RFC3339Nano))
+	req = httptest.NewRequest("GET", "/api/v1/replication/catalog?since="+since, nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	page = core.CatalogPage{}
+	decodeData(t, rr, &page)
+	if len(page.Entries) != 1 || page.Entries[0].CID != "QmCatalog2" || page.HasMore {
+		t.Errorf("entries since second pin = %+v, want only QmCatalog2", page.Entries)
+	}
+
+	for _, query := range []string{"since=yesterday", "offset=-1", "offset=abc"} {
+		req = httptest.NewRequest("GET", "/api/v1/replication/catalog?"+query, nil)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("catalog?%s status = %d, want %d", query, rr.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestReplicationPeers(t *testing.T) {
+	database := setupTestDB(t)
+	svc := newTestService(database)
+	defer svc.GetManager().Shutdown()
+	router := NewRouter(database, svc, t.TempDir(), "test")
+
+	invalid := []string{
+		`{"port": 8085, "token": "abc"}`,
+		`{"host": "peer.local", "port": 0, "token": "abc"}`,
+		`{"host": "peer.local", "port": 70000, "token": "abc"}`,
+		`{"host": "peer.local", "port": 8085}`,
+		`not json`,
+	}
+	for _, body := range invalid {
+		req := httptest.NewRequest("POST", "/api/v1/replication/peers", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("POST peers %s status = %d, want %d", body, rr.Code, http.StatusBadRequest)
+		}
+	}
+
+	body := `{"name": "studio", "host": "peer.local", "port": 8085, "token": "secret"}`
+	req := httptest.NewRequest("POST", "/api/v1/replication/peers", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("POST peers status = %d, want %d. Body: %s", rr.Code, http.StatusCreated, rr.Body.String())
+	}
+	if strings.Contains(rr.Body.String(), "secret") {
+		t.Error("peer token should not be returned")
+	}
+	var status core.ReplicationStatus
+	decodeData(t, rr, &status)
+	if status.Peer.ID == 0 || status.Peer.Host != "peer.local" {
+		t.Fatalf("created peer = %+v", status.Peer)
+	}
+	peerPath := fmt.Sprintf("/api/v1/replication/peers/%d", status.Peer.ID)
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{"GET", "/api/v1/replication/peers", http.StatusOK},
+		{"GET", peerPath, http.StatusOK},
+		{"GET", "/api/v1/replication/peers/abc", http.StatusBadRequest},
+		{"GET", "/api/v1/replication/peers/9999", http.StatusNotFound},
+		{"POST", peerPath + "/sync?full=true", http.StatusAccepted},
+		{"POST", "/api/v1/replication/peers/9999/sync", http.StatusNotFound},
+		{"DELETE", peerPath, http.StatusNoContent},
+		{"GET", peerPath, http.StatusNotFound},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != tc.want {
+			t.Errorf("%s %s status = %d, want %d. Body: %s", tc.method, tc.path, rr.Code, tc.want, rr.Body.String())
+		}
+	}
+}
+
+// =============================================================================
+// PINNING SERVICE TESTS
+// =============================================================================
+
+func TestPinningServices(t *testing.T) {
+	database := setupTestDB(t)
+	svc := newTestService(database)
+	defer svc.GetManager().Shutdown()
+	router := NewRouter(database, svc, t.TempDir(), "test")
+
+	remote := pinning.NewMemoryService("psa-token")
+	server := httptest.NewServer(remote)
+	defer server.Close()
+
+	nft := &db.NFT{TokenID: "1", ContractAddress: "KT1mirror", WalletAddress: "tz1VSUr8wwNhLAzempoch5d6hLRiTh8Cjcjb"}
+	database.Create(nft)
+	asset := &db.Asset{URI: "ipfs://QmMirrored", NFTID: nft.ID, Type: "artifact", Status: db.StatusPinned}
+	database.Create(asset)
+
+	invalid := []string{
+		`{"endpoint": "` + server.URL + `", "token": "psa-token"}`,
+		`{"name": "stand-in", "endpoint": "not a url", "token": "psa-token"}`,
+		`{"name": "stand-in", "endpoint": "` + server.URL + `"}`,
+		`{"name": "stand-in", "endpoint": "` + server.URL + `", "token": "psa-token", "wallets": ["tz1bad"]}`,
+		`not json`,
+	}
+	for _, body := range invalid {
+		req := httptest.NewRequest("POST", "/api/v1/pinning/services", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("POST pinning services %s status = %d, want %d", body, rr.Code, http.StatusBadRequest)
+		}
+	}
+
+	body := `{"name": "stand-in", "endpoint": "` + server.URL + `", "token": "psa-token", "wallets": ["tz1VSUr8wwNhLAzempoch5d6hLRiTh8Cjcjb"]}`
+	req := httptest.NewRequest("POST", "/api/v1/pinning/services", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("POST pinning services status = %d, want %d. Body: %s", rr.Code, http.StatusCreated, rr.Body.String())
+	}
+	if strings.Contains(rr.Body.String(), "psa-token") {
+		t.Error("pinning service token should not be returned")
+	}
+	var status core.MirrorStatus
+	decodeData(t, rr, &status)
+	if status.Service.ID == 0 || len(status.Wallets) != 1 {
+		t.Fatalf("created service = %+v", status)
+	}
+
+	// The initial reconcile runs as a job
+	if !svc.Jobs().WaitAll(5 * time.Second) {
+		t.Fatal("initial reconcile did not finish")
+	}
+	if pins := remote.Pins(); len(pins) != 1 || pins[0].Pin.CID != "QmMirrored" {
+		t.Fatalf("pins at service = %+v, want QmMirrored", pins)
+	}
+
+	servicePath := fmt.Sprintf("/api/v1/pinning/services/%d", status.Service.ID)
+	req = httptest.NewRequest("GET", servicePath+"/pins?status=pinned", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	var list RemotePinsListResponse
+	decodeData(t, rr, &list)
+	if list.Total != 1 || list.Pins[0].CID != "QmMirrored" || list.Pins[0].RequestID == "" {
+		t.Errorf("remote pins = %+v, want QmMirrored with a request ID", list)
+	}
+
+	req = httptest.NewRequest("GET", fmt.Sprintf("/api/v1/assets/%d/remote-pins", asset.ID), nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	var assetPins []db.RemotePin
+	decodeData(t, rr, &assetPins)
+	if len(assetPins) != 1 || assetPins[0].Status != db.RemotePinPinned {
+		t.Errorf("asset remote pins = %+v, want one pinned", assetPins)
+	}
+
+	req = httptest.NewRequest("PUT", servicePath, strings.NewReader(`{"enabled": false, "wallets": []}`))
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	status = core.MirrorStatus{}
+	decodeData(t, rr, &status)
+	if status.Service.Enabled || len(status.Wallets) != 0 {
+		t.Errorf("updated service = %+v, want disabled and mirroring every wallet", status)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"GET", "/api/v1/pinning/services", "", http.StatusOK},
+		{"GET", "/api/v1/pinning/services/abc", "", http.StatusBadRequest},
+		{"GET", "/api/v1/pinning/services/9999", "", http.StatusNotFound},
+		{"PUT", servicePath, `{"token": ""}`, http.StatusBadRequest},
+		{"PUT", servicePath, `{"wallets": ["nope"]}`, http.StatusBadRequest},
+		{"POST", "/api/v1/pinning/services/9999/reconcile", "", http.StatusNotFound},
+		{"GET", "/api/v1/assets/9999/remote-pins", "", http.StatusNotFound},
+		{"DELETE", servicePath + "?unpin=true", "", http.StatusAccepted},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != tc.want {
+			t.Errorf("%s %s status = %d, want %d. Body: %s", tc.method, tc.path, rr.Code, tc.want, rr.Body.String())
+		}
+	}
+
+	if !svc.Jobs().WaitAll(5 * time.Second) {
+		t.Fatal("remove job did not finish")
+	}
+	if n := len(remote.Pins()); n != 0 {
+		t.Errorf("pins at service after removal = %d, want 0", n)
+	}
+}
+
+func TestStorageMigration_NoService(t *testing.T) {
+	database := setupTestDB(t)
+	router := NewRouter(database, nil, t.TempDir(), "test")
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{"GET", "/api/v1/storage/migration"},
+		{"POST", "/api/v1/storage/migration"},
+		{"POST", "/api/v1/storage/migration/cancel"},
+		{"GET", "/api/v1/storage/migration/events"},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s status = %d, want %d", tc.method, tc.path, rr.Code, http.StatusServiceUnavailable)
+		}
+	}
+}
+
+func TestStorageMigration(t *testing.T) {
+	database := setupTestDB(t)
+	svc := newTestService(database)
+	defer svc.GetManager().Shutdown()
+	router := NewRouter(database, svc, t.TempDir(), "test")
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := post("/api/v1/storage/migration", `{}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("POST without dest_path status = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+
+	// No IPFS node or config path to migrate
+	svc.SetConfigPath(filepath.Join(t.TempDir(), "config.yaml"))
+	if rr := post("/api/v1/storage/migration", `{"dest_path":"`+t.TempDir()+`"}`); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("POST without an IPFS node status = %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+
+	if rr := post("/api/v1/storage/migration/cancel", ``); rr.Code != http.StatusConflict {
+		t.Errorf("cancel with no migration status = %d, want %d", rr.Code, http.StatusConflict)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/storage/migration", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	var status storage.MigrationStatus
+	decodeData(t, rr, &status)
+	if status.InProgress {
+		t.Error("no migration should be in progress")
+	}
+
+	// With nothing running the stream sends the current status and ends
+	req = httptest.NewRequest("GET", "/api/v1/storage/migration/events", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if ct := rr.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("events Content-Type = %q, want text/event-stream", ct)
+	}
+	if body := rr.Body.String(); strings.Count(body, "event: progress\n") != 1 || !strings.Contains(body, `"in_progress":false`) {
+		t.Errorf("events body = %q, want one progress event", body)
+	}
+}
+
+// =============================================================================
+// Remote Client Tests
+// =============================================================================
+
+func TestParseRemoteAddress(t *testing.T) {
+	tests := []struct {
+		addr    string
+		host    string
+		port    int
+		useTLS  bool
+		wantErr bool
+	}{
+		{addr: "nas.local", host: "nas.local", port: 8085, useTLS: true},
+		{addr: "nas.local:9000", host: "nas.local", port: 9000, useTLS: true},
+		{addr: "192.168.1.5:8085", host: "192.168.1.5", port: 8085, useTLS: true},
+		{addr: "[::1]:8085", host: "::1", port: 8085, useTLS: true},
+		{addr: "http://nas.local:9000", host: "nas.local", port: 9000},
+		{addr: "https://nas.local", host: "nas.local", port: 8085, useTLS: true},
+		{addr: "https://nas.local:8443/", host: "nas.local", port: 8443, useTLS: true},
+		{addr: "ftp://nas.local", wantErr: true},
+		{addr: "nas.local:http", wantErr: true},
+		{addr: "nas.local:70000", wantErr: true},
+		{addr: "", wantErr: true},
+		{addr: "https://nas.local/api", wantErr: true},
+	}
+	for _, tt := range tests {
+		host, port, useTLS, err := ParseRemoteAddress(tt.addr)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseRemoteAddress(%q) should fail", tt.addr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseRemoteAddress(%q) error = %v", tt.addr, err)
+			continue
+		}
+		if host != tt.host || port != tt.port || useTLS != tt.useTLS {
+			t.Errorf("ParseRemoteAddress(%q) = %q, %d, %v, want %q, %d, %v", tt.addr, host, port, useTLS, tt.host, tt.port, tt.useTLS)
+		}
+	}
+}
+
+// newRemoteTestServer serves the full router with token auth
+func newRemoteTestServer(t *testing.T, database *db.Database, token string) (*RemoteClient, *httptest.Server) {
+	t.Helper()
+	handlers := NewHandlers(database, nil, t.TempDir(), "test")
+	srv := httptest.NewServer(NewRouterWithConfig(handlers, RouterConfig{Token: token}))
+	t.Cleanup(srv.Close)
+	return remoteClientFor(srv, token), srv
+}
+
+// remoteClientFor returns a client for a plain HTTP test server
+func remoteClientFor(srv *httptest.Server, token string) *RemoteClient {
+	u, _ := url.Parse(srv.URL)
+	host, portStr, _ := net.SplitHostPort(u.Host)
+	var port int
+	fmt.Sscanf(portStr, "%d", &port)
+	return NewRemoteClient(host, port, token, false)
+}
+
+func TestRemoteClient_Do(t *testing.T) {
+	token, _ := GenerateToken()
+	database := setupTestDB(t)
+	client, _ := newRemoteTestServer(t, database, token)
+	ctx := context.Background()
+	address := "tz1" + strings.Repeat("a", 33)
+
+	if err := client.Do(ctx, "POST", "/api/v1/wallets", AddWalletRequest{Address: address, Alias: "Mine"}, nil); err != nil {
+		t.Fatalf("Do(POST wallets) error = %v", err)
+	}
+
+	var wallets []WalletResponse
+	if err := client.Do(ctx, "GET", "/api/v1/wallets", nil, &wallets); err != nil {
+		t.Fatalf("Do(GET wallets) error = %v", err)
+	}
+	if len(wallets) != 1 || wallets[0].Address != address || wallets[0].Alias != "Mine" {
+		t.Errorf("wallets = %+v, want the added wallet", wallets)
+	}
+
+	// Error responses become APIErrors
+	err := client.Do(ctx, "GET", "/api/v1/wallets/tz1missing", nil, nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Code != ErrCodeNotFound {
+		t.Errorf("Do(missing wallet) error = %v, want a 404 APIError", err)
+	}
+
+	// No content decodes nothing
+	if err := client.Do(ctx, "DELETE", "/api/v1/wallets/"+address, nil, &wallets); err != nil {
+		t.Errorf("Do(DELETE wallet) error = %v", err)
+	}
+
+	bad := NewRemoteClient("127.0.0.1", 1, token, false)
+	bad.baseURL = client.baseURL
+	bad.token = "prcpn_wrong"
+	if err := bad.Do(ctx, "GET", "/api/v1/wallets", nil, nil); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Do() with a wrong token error = %v, want 401", err)
+	}
+}
+
+func TestRemoteClient_Typed(t *testing.T) {
+	token, _ := GenerateToken()
+	database := setupTestDB(t)
+	client, _ := newRemoteTestServer(t, database, token)
+	ctx := context.Background()
+	address := "tz1" + strings.Repeat("b", 33)
+
+	added, err := client.AddWallet(ctx, AddWalletRequest{Address: address, Alias: "Mine"})
+	if err != nil || added.Address != address {
+		t.Fatalf("AddWallet() = %+v, %v", added, err)
+	}
+	alias := "Renamed"
+	updated, err := client.UpdateWallet(ctx, address, UpdateWalletRequest{Alias: &alias})
+	if err != nil || updated.Alias != alias {
+		t.Fatalf("UpdateWallet() = %+v, %v", updated, err)
+	}
+	wallets, err := client.Wallets(ctx)
+	if err != nil || len(wallets) != 1 || wallets[0].Alias != alias {
+		t.Fatalf("Wallets() = %+v, %v", wallets, err)
+	}
+
+	nft := &db.NFT{TokenID: "1", ContractAddress: "KT1typed", WalletAddress: address, Name: "Typed"}
+	database.SaveNFT(nft)
+	database.Create(&db.Asset{URI: "ipfs://ok", NFTID: nft.ID, Type: "artifact", Status: db.StatusPinned})
+	database.Create(&db.Asset{URI: "ipfs://bad", NFTID: nft.ID, Type: "thumbnail", Status: db.StatusFailedUnavailable})
+
+	assets, err := client.Assets(ctx, AssetQuery{Status: []string{db.StatusFailed, db.StatusFailedUnavailable}, Wallet: address})
+	if err != nil || assets.Total != 1 || assets.Assets[0].URI != "ipfs://bad" {
+		t.Fatalf("Assets() = %+v, %v", assets, err)
+	}
+	nfts, err := client.NFTs(ctx, NFTQuery{Search: "Typed", Limit: 10})
+	if err != nil || nfts.Total != 1 || len(nfts.NFTs[0].Assets) != 2 {
+		t.Fatalf("NFTs() = %+v, %v", nfts, err)
+	}
+	stats, err := client.Stats(ctx)
+	if err != nil || stats.PinnedAssets != 1 || stats.FailedAssets != 1 {
+		t.Fatalf("Stats() = %+v, %v", stats, err)
+	}
+	if count, err := client.RetryAllFailed(ctx); err != nil || count != 1 {
+		t.Errorf("RetryAllFailed() = %d, %v; want 1", count, err)
+	}
+	if version, err := client.Version(ctx); err != nil || version != "test" {
+		t.Errorf("Version() = %q, %v", version, err)
+	}
+
+	// Errors match the error for their code
+	if _, err := client.Wallet(ctx, "tz1missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Wallet(missing) error = %v, want ErrNotFound", err)
+	}
+	if err := client.Pause(ctx); !errors.Is(err, ErrServiceUnavailable) {
+		t.Errorf("Pause() without a service error = %v, want ErrServiceUnavailable", err)
+	}
+	if err := client.DeleteWallet(ctx, address); err != nil {
+		t.Errorf("DeleteWallet() error = %v", err)
+	}
+}
+
+func TestAPIError_Is(t *testing.T) {
+	tests := []struct {
+		err  *APIError
+		want error
+	}{
+		{&APIError{StatusCode: 409, Code: ErrCodeConflict}, ErrConflict},
+		{&APIError{StatusCode: 500, Code: ErrCodeNotFound}, ErrNotFound}, // The code wins
+		{&APIError{StatusCode: 401}, ErrUnauthorized},
+		{&APIError{StatusCode: 502}, ErrInternal},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("errors.Is(%+v, %v) = false", tt.err, tt.want)
+		}
+	}
+	if err := (&APIError{StatusCode: 418}); errors.Unwrap(err) != nil {
+		t.Errorf("Unwrap(418) = %v, want nil", errors.Unwrap(err))
+	}
+}
+
+func TestRemoteClient_Retry(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+		switch {
+		case r.URL.Path == "/api/v1/stats" && n == 1:
+			WriteRateLimited(w)
+		case r.URL.Path == "/api/v1/stats" && n == 2:
+			w.WriteHeader(http.StatusBadGateway)
+		case r.URL.Path == "/api/v1/stats":
+			WriteJSON(w, http.StatusOK, StatsResponse{TotalNFTs: 7})
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	client := remoteClientFor(srv, "t")
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond})
+	ctx := context.Background()
+
+	// Rate limiting and a failing proxy are retried for reads
+	stats, err := client.Stats(ctx)
+	if err != nil || stats.TotalNFTs != 7 || calls != 3 {
+		t.Fatalf("Stats() = %+v, %v after %d calls; want 7 after 3", stats, err, calls)
+	}
+
+	// A request that changes something isn't repeated after a 502
+	calls = 0
+	if err := client.TriggerSync(ctx); err == nil || calls != 1 {
+		t.Errorf("TriggerSync() = %v after %d calls; want an error after 1", err, calls)
+	}
+
+	// Nor is anything sent twice without retries
+	calls = 0
+	if _, err := client.Queue(WithoutRetries(ctx)); err == nil || calls != 1 {
+		t.Errorf("Queue() without retries = %v after %d calls; want an error after 1", err, calls)
+	}
+
+	// A cancelled context ends the retries
+	calls = 0
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 10, MinBackoff: time.Hour})
+	cancelled, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.Queue(cancelled); err == nil || time.Since(start) > 5*time.Second {
+		t.Errorf("Queue() with a cancelled context = %v after %v", err, time.Since(start))
+	}
+}
+
+func TestRemoteClient_StorageMigrationEvents(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, status := range []storage.MigrationStatus{
+			{InProgress: true, Phase: "copying", DestPath: "/new"},
+			{DestPath: "/new"},
+		} {
+			data, _ := json.Marshal(status)
+			fmt.Fprintf(w, "event: progress\ndata: %s\n\n", data)
+		}
+	}))
+	defer srv.Close()
+
+	var events []storage.MigrationStatus
+	err := remoteClientFor(srv, "t").StorageMigrationEvents(context.Background(), func(status storage.MigrationStatus) {
+		events = append(events, status)
+	})
+	if err != nil || len(events) != 2 || !events[0].InProgress || events[1].InProgress {
+		t.Errorf("StorageMigrationEvents() = %v, events %+v", err, events)
+	}
+}
+
+func TestGetAssets_FilterByWalletAndType(t *testing.T) {
+	database := setupTestDB(t)
+	h := NewHandlers(database, nil, t.TempDir(), "test")
+
+	mine := &db.NFT{TokenID: "1", ContractAddress: "KT1mine", WalletAddress: "tz1mine"}
+	theirs := &db.NFT{TokenID: "2", ContractAddress: "KT1theirs", WalletAddress: "tz1theirs"}
+	database.Create(mine)
+	database.Create(theirs)
+	database.Create(&db.Asset{URI: "ipfs://a1", NFTID: mine.ID, Type: "artifact", Status: db.StatusFailed})
+	database.Create(&db.Asset{URI: "ipfs://a2", NFTID: mine.ID, Type: "thumbnail", Status: db.StatusFailedUnavailable})
+	database.Create(&db.Asset{URI: "ipfs://a3", NFTID: mine.ID, Type: "artifact", Status: db.StatusPinned})
+	database.Create(&db.Asset{URI: "ipfs://a4", NFTID: theirs.ID, Type: "artifact", Status: db.StatusFailed})
+
+	tests := []struct {
+		query string
+		want  int64
+	}{
+		{"wallet=tz1mine", 3},
+		{"wallet=tz1mine&type=artifact", 2},
+		{"status=failed,failed_unavailable", 3},
+		{"status=failed,failed_unavailable&wallet=tz1mine", 2},
+		{"wallet=tz1mine&search=a2", 1},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		h.GetAssets(rr, httptest.NewRequest("GET", "/api/v1/assets?"+tt.query, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("GetAssets(%s) status = %d, want %d", tt.query, rr.Code, http.StatusOK)
+		}
+		var resp AssetsListResponse
+		decodeData(t, rr, &resp)
+		if resp.Total != tt.want {
+			t.Errorf("GetAssets(%s) total = %d, want %d", tt.query, resp.Total, tt.want)
+		}
+		for _, a := range resp.Assets {
+			if a.Wallet == "" {
+				t.Errorf("GetAssets(%s) asset %d has no wallet", tt.query, a.ID)
+			}
+		}
+	}
+}
+
+func TestAuthMiddleware_LocalToken(t *testing.T) {
+	hash, _ := HashToken("prcpn_configured")
+	local, _ := GenerateToken()
+	handler := AuthMiddleware("", hash, local)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for token, want := range map[string]int{
+		"prcpn_configured": http.StatusOK,
+		local:              http.StatusOK,
+		"prcpn_other":      http.StatusUnauthorized,
+	} {
+		req := httptest.NewRequest("GET", "/api/v1/stats", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != want {
+			t.Errorf("AuthMiddleware(%s) status = %d, want %d", token, rr.Code, want)
+		}
+	}
+}
+
+func TestServer_LocalEndpoint(t *testing.T) {
+	token, _ := GenerateToken()
+	database := setupTestDB(t)
+	dataDir := t.TempDir()
+
+	server := NewServer(ServerConfig{
+		Port:        0,
+		BindAddress: "0.0.0.0",
+		Token:       token,
+		DataDir:         dataDir,
+		Version:         "test",
+		PerIPRateLimit:  10,
+		GlobalRateLimit: 100,
+	}, database, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		server.Start(ctx)
+		close(done)
+	}()
+
+	var ep *LocalEndpoint
+	for i := 0; i < 50 && ep == nil; i++ {
+		time.Sleep(20 * time.Millisecond)
+		ep, _ = ReadLocalEndpoint(dataDir)
+	}
+	if ep == nil {
+		cancel()
+		t.Fatal("server did not write the endpoint file")
+	}
+	if !strings.HasPrefix(ep.URL, "http://127.0.0.1:") || ep.Token == "" || ep.Token == token {
+		t.Errorf("endpoint = %+v, want a loopback URL and its own token", ep)
+	}
+
+	// The endpoint's token works without knowing the API token
+	var stats StatsResponse
+	if err := NewLocalClient(ep).Do(context.Background(), "GET", "/api/v1/stats", nil, &stats); err != nil {
+		t.Errorf("local client error = %v", err)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+	if ep, _ := ReadLocalEndpoint(dataDir); ep != nil {
+		t.Error("endpoint file should be removed on shutdown")
+	}
+}
+
+func TestNewLocalClient_PinsCertificate(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteJSONRaw(w, http.StatusOK, HealthResponse{Status: "ok"})
+	}))
+	defer srv.Close()
+
+	ep := &LocalEndpoint{URL: srv.URL, Token: "t", Fingerprint: CertFingerprint(srv.TLS.Certificates[0])}
+	if err := NewLocalClient(ep).Do(context.Background(), "GET", "/api/v1/health", nil, nil); err != nil {
+		t.Errorf("matching fingerprint error = %v", err)
+	}
+
+	ep.Fingerprint = strings.Repeat("0", 64)
+	if err := NewLocalClient(ep).Do(context.Background(), "GET", "/api/v1/health", nil, nil); err == nil {
+		t.Error("a different certificate should be rejected")
+	}
+}
+
+// =============================================================================
+// Self-Signed TLS Tests
+// =============================================================================
+
+func TestLoadOrCreateSelfSignedCert(t *testing.T) {
+	dataDir := t.TempDir()
+
+	cert, err := LoadOrCreateSelfSignedCert(dataDir)
+	if err != nil {
+		t.Fatalf("LoadOrCreateSelfSignedCert() error = %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dataDir, SelfSignedKeyFileName))
+	if err != nil {
+		t.Fatalf("key file not written: %v", err)
+	}
+	if info.Mode().Perm() != TokenFileMode {
+		t.Errorf("key file mode = %v, want %v", info.Mode().Perm(), os.FileMode(TokenFileMode))
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+	if err := leaf.VerifyHostname("localhost"); err != nil {
+		t.Errorf("certificate should name localhost: %v", err)
+	}
+	if err := leaf.VerifyHostname("127.0.0.1"); err != nil {
+		t.Errorf("certificate should name 127.0.0.1: %v", err)
+	}
+
+	// The same certificate is served on every start, so pins stay valid
+	again, err := LoadOrCreateSelfSignedCert(dataDir)
+	if err != nil {
+		t.Fatalf("second LoadOrCreateSelfSignedCert() error = %v", err)
+	}
+	if CertFingerprint(again) != CertFingerprint(cert) {
+		t.Error("certificate should be reused, not regenerated")
+	}
+}
+
+func TestLoadOrCreateSelfSignedCert_Corrupt(t *testing.T) {
+	dataDir := t.TempDir()
+	os.WriteFile(filepath.Join(dataDir, SelfSignedCertFileName), []byte("not a certificate"), 0644)
+
+	// A damaged certificate is reported rather than silently replaced,
+	// which would break every client's pin
+	if _, err := LoadOrCreateSelfSignedCert(dataDir); err == nil {
+		t.Error("a corrupt certificate should be an error")
+	}
+}
+
+func TestNormalizeFingerprint(t *testing.T) {
+	if got := NormalizeFingerprint("AB:cd EF"); got != "abcdef" {
+		t.Errorf("NormalizeFingerprint() = %q, want %q", got, "abcdef")
+	}
+}
+
+// tlsTestServer serves health checks over TLS with httptest's certificate,
+// which the system doesn't trust
+func tlsTestServer(t *testing.T) (srv *httptest.Server, host string, port int) {
+	t.Helper()
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteJSONRaw(w, http.StatusOK, HealthResponse{Status: "ok"})
+	}))
+	t.Cleanup(srv.Close)
+	addr := srv.Listener.Addr().(*net.TCPAddr)
+	return srv, addr.IP.String(), addr.Port
+}
+
+func TestTrustOnFirstUse(t *testing.T) {
+	srv, host, port := tlsTestServer(t)
+	want := CertFingerprint(srv.TLS.Certificates[0])
+
+	client, pinned, err := TrustOnFirstUse(context.Background(), host, port, "t")
+	if err != nil {
+		t.Fatalf("TrustOnFirstUse() error = %v", err)
+	}
+	if pinned != want {
+		t.Errorf("pinned = %q, want %q", pinned, want)
+	}
+	if _, err := client.Health(context.Background()); err != nil {
+		t.Errorf("pinned client error = %v", err)
+	}
+
+	// An unpinned client checks the certificate against the system's CAs
+	if _, err := NewRemoteClient(host, port, "t", true).Health(context.Background()); err == nil {
+		t.Error("an untrusted certificate should be rejected without a pin")
+	}
+}
+
+func TestNewPinnedRemoteClient_Mismatch(t *testing.T) {
+	_, host, port := tlsTestServer(t)
+
+	client := NewPinnedRemoteClient(host, port, "t", strings.Repeat("ab", 32))
+	err := client.Do(context.Background(), "GET", "/api/v1/health", nil, nil)
+	if !errors.Is(err, ErrFingerprintMismatch) {
+		t.Errorf("Do() error = %v, want ErrFingerprintMismatch", err)
+	}
+}
+
+func TestServer_SelfSignedTLS(t *testing.T) {
+	token, _ := GenerateToken()
+	database := setupTestDB(t)
+	dataDir := t.TempDir()
+
+	cfg := DefaultServerConfig()
+	cfg.Port = 0
+	cfg.BindAddress = "127.0.0.1"
+	cfg.Token = token
+	cfg.DataDir = dataDir
+	server := NewServer(cfg, database, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		server.Start(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	var ep *LocalEndpoint
+	for i := 0; i < 50 && ep == nil; i++ {
+		time.Sleep(20 * time.Millisecond)
+		ep, _ = ReadLocalEndpoint(dataDir)
+	}
+	if ep == nil {
+		t.Fatal("server did not write the endpoint file")
+	}
+
+	cert, err := LoadOrCreateSelfSignedCert(dataDir)
+	if err != nil {
+		t.Fatalf("certificate not persisted: %v", err)
+	}
+	if !strings.HasPrefix(ep.URL, "https://") || ep.Fingerprint != CertFingerprint(cert) {
+		t.Errorf("endpoint = %+v, want https with the generated certificate's fingerprint", ep)
+	}
+
+	addr := server.GetListenAddress()
+	host, portStr, _ := net.SplitHostPort(addr)
+	port, _ := strconv.Atoi(portStr)
+	client := NewPinnedRemoteClient(host, port, token, ep.Fingerprint)
+	var stats StatsResponse
+	if err := client.Do(context.Background(), "GET", "/api/v1/stats", nil, &stats); err != nil {
+		t.Errorf("pinned client error = %v", err)
+	}
+}
+
+// =============================================================================
+// ACME Tests
+// =============================================================================
+
+// startPebble runs Pebble, Let's Encrypt's ACME test server, in-process. Its
+// validation authority connects to httpPort for HTTP-01 challenges. It
+// returns the directory URL, the roots trusted for the directory's own TLS,
+// and the root that issued certificates chain to.
+func startPebble(t *testing.T, httpPort int) (directory string, directoryRoots, issuerRoots *x509.CertPool) {
+	t.Helper()
+	t.Setenv("PEBBLE_VA_NOSLEEP", "1")
+	t.Setenv("PEBBLE_WFE_NONCEREJECT", "0")
+
+	logger := log.New(io.Discard, "", 0)
+	store := pebbledb.NewMemoryStore()
+	authority := pebbleca.New(logger, store, "", "ecdsa", 0, 1, map[string]pebbleca.Profile{"default": {Description: "default"}})
+	validator := pebbleva.New(logger, httpPort, 0, false, "", store)
+	frontend := pebblewfe.New(logger, store, validator, authority, []string{"pebble.letsencrypt.org"}, false, false, 0, 0)
+
+	srv := httptest.NewTLSServer(frontend.Handler())
+	t.Cleanup(srv.Close)
+
+	directoryRoots = x509.NewCertPool()
+	directoryRoots.AddCert(srv.Certificate())
+	issuerRoots = x509.NewCertPool()
+	issuerRoots.AddCert(authority.GetRootCert(0).Cert)
+	return srv.URL + pebblewfe.DirectoryPath, directoryRoots, issuerRoots
+}
+
+// freePort returns a TCP port nothing is listening on
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestServer_ACME(t *testing.T) {
+	httpPort := freePort(t)
+	directory, directoryRoots, issuerRoots := startPebble(t, httpPort)
+
+	token, _ := GenerateToken()
+	database := setupTestDB(t)
+	dataDir := t.TempDir()
+
+	cfg := DefaultServerConfig()
+	cfg.Port = 0
+	cfg.Token = token
+	cfg.DataDir = dataDir
+	cfg.ACME = &ACMEConfig{
+		Domains:      []string{"localhost"},
+		CA:           directory,
+		TrustedRoots: directoryRoots,
+		HTTPPort:     httpPort,
+	}
+	server := NewServer(cfg, database, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Start(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// The certificate is obtained before the server listens
+	var ep *LocalEndpoint
+	for i := 0; i < 300 && ep == nil; i++ {
+		select {
+		case err := <-done:
+			t.Fatalf("server stopped: %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+		ep, _ = ReadLocalEndpoint(dataDir)
+	}
+	if ep == nil {
+		t.Fatal("server did not start")
+	}
+	if ep.ServerName != "localhost" || ep.Fingerprint != "" {
+		t.Errorf("endpoint = %+v, want the certificate checked by name, not pinned", ep)
+	}
+
+	// Served with the certificate Pebble issued
+	_, port, _ := net.SplitHostPort(server.GetListenAddress())
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: issuerRoots, ServerName: "localhost"}}}
+	resp, err := client.Get("https://127.0.0.1:" + port + "/api/v1/health")
+	if err != nil {
+		t.Fatalf("health over the ACME certificate failed: %v", err)
+	}
+	resp.Body.Close()
+
+	// Kept in the data directory for the next start
+	var cached []string
+	filepath.WalkDir(filepath.Join(dataDir, ACMEDirName), func(path string, d os.DirEntry, err error) error {
+		if err == nil && strings.HasSuffix(path, ".crt") {
+			cached = append(cached, filepath.Base(path))
+		}
+		return nil
+	})
+	if len(cached) != 1 || cached[0] != "localhost.crt" {
+		t.Errorf("cached certificates = %v, want [localhost.crt]", cached)
+	}
+}
+
+func TestServer_ACMEWithCertFiles(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.Port = 0
+	cfg.DataDir = t.TempDir()
+	cfg.TLSCert = "cert.pem"
+	cfg.TLSKey = "key.pem"
+	cfg.ACME = &ACMEConfig{Domains: []string{"example.com"}}
+
+	if err := NewServer(cfg, setupTestDB(t), nil).Start(context.Background()); err == nil {
+		t.Error("certificate files and ACME together should be an error")
+	}
+}
+
+func TestNewDNSProvider(t *testing.T) {
+	if _, err := NewDNSProvider("nosuch:x"); err == nil || !strings.Contains(err.Error(), "exec") {
+		t.Errorf("unknown provider error = %v, want the available providers listed", err)
+	}
+	if _, err := NewDNSProvider("exec"); err == nil {
+		t.Error("exec without a program should be an error")
+	}
+
+	RegisterDNSProvider("test", func(arg string) (DNSProvider, error) { return &execDNSProvider{program: arg}, nil })
+	provider, err := NewDNSProvider("test:hook")
+	if err != nil {
+		t.Fatalf("NewDNSProvider() error = %v", err)
+	}
+	if p, ok := provider.(*execDNSProvider); !ok || p.program != "hook" {
+		t.Errorf("provider = %#v, want the registered factory's", provider)
+	}
+}
+
+func TestExecDNSProvider(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "calls")
+	hook := filepath.Join(dir, "hook.sh")
+	os.WriteFile(hook, []byte("#!/bin/sh\necho \"$@\" >> "+out+"\n"), 0755)
+
+	provider, err := NewDNSProvider("exec:" + hook)
+	if err != nil {
+		t.Fatalf("NewDNSProvider() error = %v", err)
+	}
+	rec := []libdns.Record{libdns.TXT{Name: "_acme-challenge.nas", Text: "token"}}
+	if _, err := provider.AppendRecords(context.Background(), "example.com.", rec); err != nil {
+		t.Fatalf("AppendRecords() error = %v", err)
+	}
+	if _, err := provider.DeleteRecords(context.Background(), "example.com.", rec); err != nil {
+		t.Fatalf("DeleteRecords() error = %v", err)
+	}
+
+	calls, _ := os.ReadFile(out)
+	want := "present _acme-challenge.nas.example.com. token\ncleanup _acme-challenge.nas.example.com. token\n"
+	if string(calls) != want {
+		t.Errorf("hook calls = %q, want %q", calls, want)
+	}
+}
+
+// =============================================================================
+// Pairing Tests
+// =============================================================================
+
+func TestPairing_Redeem(t *testing.T) {
+	p := NewPairing()
+	if err := p.Redeem("ABCDEFGH"); !errors.Is(err, ErrPairingClosed) {
+		t.Errorf("Redeem() without a window error = %v, want ErrPairingClosed", err)
+	}
+
+	code, _, err := p.Open(time.Minute)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if len(code) != pairingCodeLength || strings.Trim(code, pairingAlphabet) != "" {
+		t.Errorf("code = %q, want %d characters from the pairing alphabet", code, pairingCodeLength)
+	}
+	if err := p.Redeem("WRONGCOD"); !errors.Is(err, ErrPairingCode) {
+		t.Errorf("Redeem(wrong) error = %v, want ErrPairingCode", err)
+	}
+	// Typed as displayed, in lowercase
+	if err := p.Redeem(strings.ToLower(FormatPairingCode(code))); err != nil {
+		t.Errorf("Redeem(code) error = %v", err)
+	}
+	if err := p.Redeem(code); !errors.Is(err, ErrPairingClosed) {
+		t.Errorf("Redeem() reusing the code error = %v, want ErrPairingClosed", err)
+	}
+
+	// Too many wrong codes close the window
+	code, _, _ = p.Open(time.Minute)
+	for i := 0; i < maxPairingAttempts; i++ {
+		p.Redeem("WRONGCOD")
+	}
+	if err := p.Redeem(code); !errors.Is(err, ErrPairingClosed) {
+		t.Errorf("Redeem() after %d wrong codes error = %v, want ErrPairingClosed", maxPairingAttempts, err)
+	}
+
+	// So does time
+	code, _, _ = p.Open(-time.Second)
+	if err := p.Redeem(code); !errors.Is(err, ErrPairingClosed) {
+		t.Errorf("Redeem() after expiry error = %v, want ErrPairingClosed", err)
+	}
+}
+
+func TestParsePairingURL(t *testing.T) {
+	invite := PairingInvite{Host: "192.168.1.10", Port: 8085, Code: "ABCDEFGH", Fingerprint: "ab12"}
+	got, err := ParsePairingURL(invite.URL())
+	if err != nil {
+		t.Fatalf("ParsePairingURL() error = %v", err)
+	}
+	if got != invite {
+		t.Errorf("ParsePairingURL() = %+v, want %+v", got, invite)
+	}
+
+	for _, raw := range []string{
+		"https://pair?host=a&port=1&code=X",
+		"porcupin://pair?host=a&port=0&code=X",
+		"porcupin://pair?port=8085&code=X",
+		"ABCD-EFGH",
+	} {
+		if _, err := ParsePairingURL(raw); err == nil {
+			t.Errorf("ParsePairingURL(%q) should fail", raw)
+		}
+	}
+}
+
+func TestServer_Pairing(t *testing.T) {
+	token, _ := GenerateToken()
+	database := setupTestDB(t)
+	dataDir := t.TempDir()
+
+	cfg := DefaultServerConfig()
+	cfg.Port = 0
+	cfg.BindAddress = "127.0.0.1"
+	cfg.Token = token
+	cfg.DataDir = dataDir
+	server := NewServer(cfg, database, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		server.Start(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	var ep *LocalEndpoint
+	for i := 0; i < 50 && ep == nil; i++ {
+		time.Sleep(20 * time.Millisecond)
+		ep, _ = ReadLocalEndpoint(dataDir)
+	}
+	if ep == nil {
+		t.Fatal("server did not write the endpoint file")
+	}
+	admin := NewLocalClient(ep)
+	host, portStr, _ := net.SplitHostPort(server.GetListenAddress())
+	port, _ := strconv.Atoi(portStr)
+
+	var window PairingResponse
+	if err := admin.Do(ctx, http.MethodPost, "/api/v1/pairing", OpenPairingRequest{WindowSeconds: 60}, &window); err != nil {
+		t.Fatalf("opening pairing failed: %v", err)
+	}
+	invite, err := ParsePairingURL(window.URL)
+	if err != nil {
+		t.Fatalf("pairing URL %q: %v", window.URL, err)
+	}
+	if invite.Host != host || invite.Port != port || invite.Fingerprint != ep.Fingerprint || window.Fingerprint != ep.Fingerprint {
+		t.Errorf("invite = %+v, want %s:%d and fingerprint %s", invite, host, port, ep.Fingerprint)
+	}
+
+	if _, _, err := Pair(ctx, host, port, invite.Fingerprint, "WRONGCOD", "laptop"); err == nil {
+		t.Error("pairing with a wrong code should fail")
+	}
+	paired, pinned, err := Pair(ctx, host, port, "", window.Code, "laptop")
+	if err != nil {
+		t.Fatalf("Pair() error = %v", err)
+	}
+	if pinned != ep.Fingerprint || paired.Name != "laptop" {
+		t.Errorf("Pair() = %+v pinned %q, want laptop pinned to %s", paired, pinned, ep.Fingerprint)
+	}
+
+	// The issued token works until it is revoked
+	client := NewPinnedRemoteClient(host, port, paired.Token, pinned)
+	var tokens []db.APIToken
+	if err := client.Do(ctx, http.MethodGet, "/api/v1/tokens", nil, &tokens); err != nil {
+		t.Fatalf("issued token rejected: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0].ID != paired.ID {
+		t.Errorf("tokens = %+v, want the issued one", tokens)
+	}
+	if err := admin.Do(ctx, http.MethodDelete, fmt.Sprintf("/api/v1/tokens/%d", paired.ID), nil, nil); err != nil {
+		t.Fatalf("revoking failed: %v", err)
+	}
+	var apiErr *APIError
+	if err := client.Do(ctx, http.MethodGet, "/api/v1/stats", nil, nil); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("revoked token error = %v, want 401", err)
+	}
+}
+
+func TestOpenPairing_NeedsTLS(t *testing.T) {
+	handlers := NewHandlers(setupTestDB(t), nil, "", "test")
+	handlers.SetPairingEndpoint("", 8085, false, "")
+	router := NewRouterWithHandlers(handlers)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/pairing", nil))
+	if rr.Code != http.StatusConflict {
+		t.Errorf("OpenPairing() over plain HTTP status = %d, want %d", rr.Code, http.StatusConflict)
+	}
+
+	rr = httptest.NewRecorder()
+	body := strings.NewReader(`{"code":"ABCDEFGH","name":"laptop"}`)
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/pairing/complete", body))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("CompletePairing() over plain HTTP status = %d, want %d", rr.Code, http.StatusForbidden)
+	}
+}
+
+func TestAuthMiddlewareWithIssued(t *testing.T) {
+	database := setupTestDB(t)
+	issued, _, err := IssueAPIToken(database, "laptop")
+	if err != nil {
+		t.Fatalf("IssueAPIToken() error = %v", err)
+	}
+	other, _ := GenerateToken()
+
+	handler := AuthMiddlewareWithIssued("", "", IssuedTokenValidator(database))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for token, want := range map[string]int{issued: http.StatusOK, other: http.StatusUnauthorized} {
+		req := httptest.NewRequest("GET", "/api/v1/stats", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != want {
+			t.Errorf("AuthMiddlewareWithIssued(%s) status = %d, want %d", token, rr.Code, want)
+		}
+	}
+
+	// Pairing needs no token
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/pairing/complete", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("pairing status = %d, want %d", rr.Code, http.StatusOK)
+	}
+}
+
+// =============================================================================
+// Fleet Tests
+// =============================================================================
+
+func TestGetFleetOverview(t *testing.T) {
+	token, _ := GenerateToken()
+
+	database := setupTestDB(t)
+	nft := &db.NFT{TokenID: "1", ContractAddress: "KT1fleet", WalletAddress: "tz1test"}
+	database.Create(nft)
+	database.Create(&db.Asset{URI: "ipfs://pinned", NFTID: nft.ID, Status: db.StatusPinned})
+	database.Create(&db.Asset{URI: "ipfs://failed", NFTID: nft.ID, Status: db.StatusFailed, ErrorMsg: "timeout"})
+	healthy, _ := newRemoteTestServer(t, database, token)
+
+	rejected, _ := newRemoteTestServer(t, setupTestDB(t), token)
+	rejected.token = "prcpn_wrong"
+
+	members := []FleetMember{
+		{ID: 1, Name: "home", Client: healthy},
+		{ID: 2, Name: "office", Client: rejected},
+		{ID: 3, Name: "gone", Client: NewRemoteClient("127.0.0.1", freePort(t), token, false)},
+	}
+	overview := GetFleetOverview(context.Background(), members)
+
+	if len(overview.Nodes) != 3 {
+		t.Fatalf("nodes = %d, want 3", len(overview.Nodes))
+	}
+	home, office, gone := overview.Nodes[0], overview.Nodes[1], overview.Nodes[2]
+	if !home.Reachable || home.Error != "" || home.Stats == nil || home.Version != "test" {
+		t.Errorf("home = %+v, want reachable with stats", home)
+	}
+	if !office.Reachable || office.Error == "" {
+		t.Errorf("office = %+v, want reachable with an error", office)
+	}
+	if gone.Reachable || gone.Error == "" {
+		t.Errorf("gone = %+v, want unreachable", gone)
+	}
+
+	want := FleetTotals{Nodes: 3, Reachable: 2, Unreachable: 1, Errors: 1, TotalNFTs: 1, TotalAssets: 2, PinnedAssets: 1, FailedAssets: 1}
+	got := overview.Totals
+	got.StorageUsedGB = 0
+	if got != want {
+		t.Errorf("totals = %+v, want %+v", got, want)
+	}
+	if len(overview.FailedAssets) != 1 || overview.FailedAssets[0].NodeName != "home" || overview.FailedAssets[0].URI != "ipfs://failed" {
+		t.Errorf("failed assets = %+v, want the failed asset on home", overview.FailedAssets)
+	}
+}
+
+// =============================================================================
+// OpenAPI Tests
+// =============================================================================
+
+// TestOpenAPI_CoversRoutes fails when a route is mounted without being
+// described in apiOperations, or described without being mounted
+func TestOpenAPI_CoversRoutes(t *testing.T) {
+	router := NewRouterWithHandlers(NewHandlers(setupTestDB(t), nil, t.TempDir(), "test"))
+
+	mounted := map[string]bool{}
+	chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		mounted[method+" "+route] = true
+		return nil
+	})
+
+	described := map[string]bool{}
+	for _, op := range apiOperations {
+		key := op.Method + " /api/v1" + op.Path
+		if described[key] {
+			t.Errorf("%s is described twice", key)
+		}
+		described[key] = true
+		if !mounted[key] {
+			t.Errorf("%s is described in apiOperations but not mounted", key)
+		}
+		if op.Public != unauthenticatedPaths["/api/v1"+op.Path] {
+			t.Errorf("%s: Public = %v, but the auth middleware disagrees", key, op.Public)
+		}
+		if len(op.Responses) == 0 {
+			t.Errorf("%s has no successful response", key)
+		}
+		for _, status := range op.Errors {
+			if errorCodes[status] == "" {
+				t.Errorf("%s: error status %d has no error code", key, status)
+			}
+		}
+	}
+	for key := range mounted {
+		if !described[key] {
+			t.Errorf("%s is mounted but missing from apiOperations in openapi.go", key)
+		}
+	}
+}
+
+func TestOpenAPI_Served(t *testing.T) {
+	token, _ := GenerateToken()
+	handlers := NewHandlers(setupTestDB(t), nil, t.TempDir(), "1.2.3")
+	router := NewRouterWithConfig(handlers, RouterConfig{Token: token})
+
+	// Served without a token
+	req := httptest.NewRequest("GET", "/api/v1/openapi.json", nil)
+	req.RemoteAddr = "127.0.0.1:12345"
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json status = %d, want 200. Body: %s", rr.Code, rr.Body.String())
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("Spec is not JSON: %v", err)
+	}
+	if spec["openapi"] != "3.0.3" || spec["info"].(map[string]interface{})["version"] != "1.2.3" {
+		t.Errorf("openapi = %v, info = %v", spec["openapi"], spec["info"])
+	}
+
+	// Every reference resolves
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok {
+				if openAPIRef(spec, ref) == nil {
+					t.Errorf("Unresolved reference %s", ref)
+				}
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(spec)
+
+	wallet := openAPIOperation(spec, "post", "/api/v1/wallets")
+	if wallet == nil || wallet["requestBody"] == nil {
+		t.Fatalf("POST /wallets = %v, want an operation with a request body", wallet)
+	}
+	responses := wallet["responses"].(map[string]interface{})
+	for _, status := range []string{"201", "400", "401", "409"} {
+		if responses[status] == nil {
+			t.Errorf("POST /wallets has no %s response", status)
+		}
+	}
+	if health := openAPIOperation(spec, "get", "/api/v1/health"); health["responses"].(map[string]interface{})["401"] != nil {
+		t.Error("GET /health is public but lists 401")
+	}
+}
+
+// TestOpenAPI_ResponsesMatchSchemas reads every GET route and checks the
+// status is listed and the body matches its schema
+func TestOpenAPI_ResponsesMatchSchemas(t *testing.T) {
+	database := setupTestDB(t)
+	router := NewRouter(database, newTestService(database), t.TempDir(), "test")
+
+	address := "tz1" + strings.Repeat("c", 33)
+	database.SaveWallet(&db.Wallet{Address: address, Alias: "Spec"})
+	nft := &db.NFT{TokenID: "1", ContractAddress: "KT1spec", WalletAddress: address, CreatorAddress: address, Name: "Spec"}
+	database.Create(nft)
+	database.NoteCreator(address, "Spec Artist")
+	database.NoteContract("KT1spec", "Spec Collection")
+	now := time.Now()
+	database.Create(&db.Asset{URI: "ipfs://pinned", NFTID: nft.ID, Type: "artifact", Status: db.StatusPinned, PinnedAt: &now})
+	database.Create(&db.Asset{URI: "ipfs://failed", NFTID: nft.ID, Type: "thumbnail", Status: db.StatusFailed, ErrorMsg: "timeout"})
+	database.CreateJob(&db.Job{Type: core.JobTypeVerifyAndFix, Status: db.JobStatusCompleted})
+	database.SaveReplicationPeer(&db.ReplicationPeer{Host: "peer", Port: 8085, Token: "t"})
+	database.SavePinningService(&db.PinningService{Name: "Remote", Endpoint: "https://pins.example", Token: "t"})
+
+	spec := OpenAPISpec("test")
+	data, _ := json.Marshal(spec)
+	json.Unmarshal(data, &spec) // As a client sees it
+
+	skip := map[string]bool{
+		"/discover":                 true, // Listens for mDNS
+		"/storage/migration/events": true, // Streams
+	}
+	for _, op := range apiOperations {
+		if op.Method != "GET" || skip[op.Path] {
+			continue
+		}
+		path := strings.NewReplacer("{address}", address, "{id}", "1").Replace("/api/v1" + op.Path)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+
+		response, ok := openAPIOperation(spec, "get", "/api/v1"+op.Path)["responses"].(map[string]interface{})[strconv.Itoa(rr.Code)].(map[string]interface{})
+		if !ok {
+			t.Errorf("GET %s returned %d, which the spec doesn't list. Body: %s", op.Path, rr.Code, rr.Body.String())
+			continue
+		}
+		if ref, isRef := response["$ref"].(string); isRef {
+			response = openAPIRef(spec, ref)
+		}
+		schema := response["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+
+		var body interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Errorf("GET %s: %v", op.Path, err)
+			continue
+		}
+		checkOpenAPISchema(t, spec, schema, body, "GET "+op.Path)
+	}
+}
+
+// checkOpenAPISchema reports where v doesn't match schema
+func checkOpenAPISchema(t *testing.T, spec, schema map[string]interface{}, v interface{}, at string) {
+	t.Helper()
+	if ref, ok := schema["$ref"].(string); ok {
+		schema = openAPIRef(spec, ref)
+	}
+	if v == nil {
+		if len(schema) > 0 && schema["nullable"] != true {
+			t.Errorf("%s: null, want %v", at, schema["type"])
+		}
+		return
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			t.Errorf("%s: %T, want an object", at, v)
+			return
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		extra, _ := schema["additionalProperties"].(map[string]interface{})
+		for key, value := range obj {
+			if property, ok := properties[key].(map[string]interface{}); ok {
+				checkOpenAPISchema(t, spec, property, value, at+"."+key)
+			} else if extra != nil {
+				checkOpenAPISchema(t, spec, extra, value, at+"."+key)
+			} else {
+				t.Errorf("%s: unexpected field %q", at, key)
+			}
+		}
+		required, _ := schema["required"].([]interface{})
+		for _, key := range required {
+			if _, ok := obj[key.(string)]; !ok {
+				t.Errorf("%s: missing required field %q", at, key)
+			}
+		}
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			t.Errorf("%s: %T, want an array", at, v)
+			return
+		}
+		for i, item := range items {
+			checkOpenAPISchema(t, spec, schema["items"].(map[string]interface{}), item, fmt.Sprintf("%s[%d]", at, i))
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			t.Errorf("%s: %T, want a string", at, v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			t.Errorf("%s: %T, want a boolean", at, v)
+		}
+	case "integer":
+		if n, ok := v.(float64); !ok || n != float64(int64(n)) {
+			t.Errorf("%s: %v, want an integer", at, v)
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			t.Errorf("%s: %T, want a number", at, v)
+		}
+	}
+}
+
+// openAPIRef resolves a reference within spec, returning nil if it doesn't
+func openAPIRef(spec map[string]interface{}, ref string) map[string]interface{} {
+	var node interface{} = spec
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		node = m[part]
+	}
+	m, _ := node.(map[string]interface{})
+	return m
+}
+
+// openAPIOperation returns the operation for method and path
+func openAPIOperation(spec map[string]interface{}, method, path string) map[string]interface{} {
+	item, _ := spec["paths"].(map[string]interface{})[path].(map[string]interface{})
+	op, _ := item[method].(map[string]interface{})
+	return op
+}
+
+// =============================================================================
+// Wallet Import Tests
+// =============================================================================
+
+func TestParseWalletFile_CSV(t *testing.T) {
+	a, b := "tz1"+strings.Repeat("a", 33), "tz2"+strings.Repeat("b", 33)
+
+	// Header in any order, extra columns ignored, byte order mark
+	rows, err := ParseWalletFile([]byte("\ufeffAlias,Address,Notes,sync_created\nMine,"+a+",x,false\n\n# comment\nOther,"+b+",,\n"), "")
+	if err != nil {
+		t.Fatalf("ParseWalletFile() error = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+	if rows[0].Line != 2 || rows[0].Entry.Address != a || rows[0].Entry.Alias != "Mine" || rows[0].Problem != "" {
+		t.Errorf("rows[0] = %+v", rows[0])
+	}
+	if rows[0].Entry.SyncOwned != nil || rows[0].Entry.SyncCreated == nil || *rows[0].Entry.SyncCreated {
+		t.Errorf("rows[0] sync flags = %v, %v, want unset, false", rows[0].Entry.SyncOwned, rows[0].Entry.SyncCreated)
+	}
+	if rows[1].Line != 5 || rows[1].Entry.Address != b || rows[1].Entry.SyncCreated != nil {
+		t.Errorf("rows[1] = %+v", rows[1])
+	}
+
+	// No header: the export's column order
+	rows, err = ParseWalletFile([]byte(a+",Mine,0,yes\n"), WalletFileCSV)
+	if err != nil {
+		t.Fatalf("ParseWalletFile() error = %v", err)
+	}
+	if rows[0].Line != 1 || rows[0].Entry.Alias != "Mine" || rows[0].Entry.SyncOwned == nil || *rows[0].Entry.SyncOwned {
+		t.Errorf("rows[0] = %+v", rows[0])
+	}
+	if rows[0].Problem == "" {
+		t.Error("sync_created \"yes\" should be a problem")
+	}
+
+	// A first row that's neither an address nor a header is a wallet
+	rows, err = ParseWalletFile([]byte("not-an-address\n"+a+"\n"), WalletFileCSV)
+	if err != nil || len(rows) != 2 || rows[0].Entry.Address != "not-an-address" {
+		t.Errorf("ParseWalletFile() = %+v, %v", rows, err)
+	}
+}
+
+func TestParseWalletFile_JSON(t *testing.T) {
+	a := "tz1" + strings.Repeat("a", 33)
+	rows, err := ParseWalletFile([]byte(` [{"address":"`+a+`","alias":"Mine","sync_owned":false},{"address":1}]`), "")
+	if err != nil {
+		t.Fatalf("ParseWalletFile() error = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+	if rows[0].Line != 1 || rows[0].Entry.Address != a || rows[0].Entry.SyncOwned == nil || *rows[0].Entry.SyncOwned || rows[0].Entry.SyncCreated != nil {
+		t.Errorf("rows[0] = %+v", rows[0])
+	}
+	if rows[1].Line != 2 || rows[1].Problem == "" {
+		t.Errorf("rows[1] = %+v, want a problem", rows[1])
+	}
+}
+
+func TestParseWalletFile_Errors(t *testing.T) {
+	tests := []struct {
+		name, data, format string
+	}{
+		{"empty", "", ""},
+		{"header only", "address,alias\n", ""},
+		{"empty array", "[]", ""},
+		{"not an array", `{"address":"x"}`, ""},
+		{"bad csv", "a,\"b\n", WalletFileCSV},
+		{"unknown format", "x", "xml"},
+	}
+	for _, tt := range tests {
+		if rows, err := ParseWalletFile([]byte(tt.data), tt.format); err == nil {
+			t.Errorf("%s: ParseWalletFile() = %+v, want an error", tt.name, rows)
+		}
+	}
+	data := strings.Repeat("tz1"+strings.Repeat("a", 33)+"\n", MaxWalletImportRows+1)
+	if _, err := ParseWalletFile([]byte(data), ""); err == nil {
+		t.Error("ParseWalletFile() should refuse more than MaxWalletImportRows rows")
+	}
+}
+
+func TestImportWallets(t *testing.T) {
+	database := setupTestDB(t)
+	a, b, c := "tz1"+strings.Repeat("a", 33), "tz2"+strings.Repeat("b", 33), "KT1"+strings.Repeat("c", 33)
+	if err := database.SaveWallet(&db.Wallet{Address: c, Alias: "Kept"}); err != nil {
+		t.Fatal(err)
+	}
+	f := false
+	rows := []WalletImportRow{
+		{Line: 2, Entry: WalletEntry{Address: a, Alias: "A", SyncOwned: &f}},
+		{Line: 3, Entry: WalletEntry{Address: " " + b + " "}},
+		{Line: 4, Entry: WalletEntry{Address: a, Alias: "Again"}},
+		{Line: 5, Entry: WalletEntry{Address: c, Alias: "Changed"}},
+		{Line: 6, Entry: WalletEntry{Address: "tz9nope"}},
+		{Line: 7, Entry: WalletEntry{}},
+		{Line: 8, Entry: WalletEntry{Address: b}, Problem: "sync_owned must be true or false"},
+	}
+	want := []string{WalletImportAdded, WalletImportAdded, WalletImportDuplicate, WalletImportExists,
+		WalletImportInvalid, WalletImportInvalid, WalletImportInvalid}
+
+	// A dry run reports without adding
+	report, err := ImportWallets(database, rows, WalletImportOptions{DryRun: true, SyncOwned: true, SyncCreated: true})
+	if err != nil {
+		t.Fatalf("ImportWallets() error = %v", err)
+	}
+	for i, r := range report.Results {
+		if r.Status != want[i] || r.Line != rows[i].Line {
+			t.Errorf("results[%d] = %+v, want %s", i, r, want[i])
+		}
+	}
+	if !report.DryRun || report.Added != 2 || report.Existing != 1 || report.Duplicates != 1 || report.Invalid != 3 {
+		t.Errorf("report = %+v", report)
+	}
+	if wallets, _ := database.GetAllWallets(); len(wallets) != 1 {
+		t.Fatalf("dry run added wallets: %+v", wallets)
+	}
+
+	report, err = ImportWallets(database, rows, WalletImportOptions{SyncOwned: true, SyncCreated: true})
+	if err != nil {
+		t.Fatalf("ImportWallets() error = %v", err)
+	}
+	if report.DryRun || report.Added != 2 {
+		t.Errorf("report = %+v", report)
+	}
+	if added := report.AddedAddresses(); len(added) != 2 || added[0] != a || added[1] != b {
+		t.Errorf("AddedAddresses() = %v", added)
+	}
+
+	// Flags from the file win over the defaults, even false
+	wallet, _ := database.GetWallet(a)
+	if wallet == nil || wallet.Alias != "A" || wallet.SyncOwned || !wallet.SyncCreated {
+		t.Errorf("wallet %s = %+v", a, wallet)
+	}
+	wallet, _ = database.GetWallet(b)
+	if wallet == nil || !wallet.SyncOwned || !wallet.SyncCreated {
+		t.Errorf("wallet %s = %+v", b, wallet)
+	}
+	// Existing wallets are left alone
+	if wallet, _ = database.GetWallet(c); wallet.Alias != "Kept" {
+		t.Errorf("existing wallet alias = %q, want Kept", wallet.Alias)
+	}
+
+	// Defaults apply to rows without flags
+	d := "tz3" + strings.Repeat("d", 33)
+	if _, err := ImportWallets(database, []WalletImportRow{{Line: 1, Entry: WalletEntry{Address: d}}}, WalletImportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if wallet, _ = database.GetWallet(d); wallet.SyncOwned || wallet.SyncCreated {
+		t.Errorf("wallet %s = %+v, want both sync flags off", d, wallet)
+	}
+}
+
+func TestWalletFile_RoundTrip(t *testing.T) {
+	for _, format := range []string{WalletFileCSV, WalletFileJSON} {
+		source := setupTestDB(t)
+		source.CreateWallet(&db.Wallet{Address: "tz1" + strings.Repeat("a", 33), Alias: "Comma, \"quoted\"", SyncOwned: false, SyncCreated: true})
+		source.CreateWallet(&db.Wallet{Address: "tz2" + strings.Repeat("b", 33), SyncOwned: true, SyncCreated: false})
+		exported, _ := source.GetAllWallets()
+
+		var buf bytes.Buffer
+		if err := WriteWalletFile(&buf, exported, format); err != nil {
+			t.Fatalf("%s: WriteWalletFile() error = %v", format, err)
+		}
+		if DetectWalletFileFormat(buf.Bytes()) != format {
+			t.Errorf("%s: DetectWalletFileFormat() = %s", format, DetectWalletFileFormat(buf.Bytes()))
+		}
+		rows, err := ParseWalletFile(buf.Bytes(), "")
+		if err != nil {
+			t.Fatalf("%s: ParseWalletFile() error = %v", format, err)
+		}
+		target := setupTestDB(t)
+		if _, err := ImportWallets(target, rows, WalletImportOptions{SyncOwned: true, SyncCreated: true}); err != nil {
+			t.Fatalf("%s: ImportWallets() error = %v", format, err)
+		}
+		for _, want := range exported {
+			got, _ := target.GetWallet(want.Address)
+			if got == nil || got.Alias != want.Alias || got.SyncOwned != want.SyncOwned || got.SyncCreated != want.SyncCreated {
+				t.Errorf("%s: imported %+v, want %+v", format, got, want)
+			}
+		}
+	}
+	if err := WriteWalletFile(io.Discard, nil, "xml"); err == nil {
+		t.Error("WriteWalletFile() should refuse an unknown format")
+	}
+}
+
+func TestRemoteClient_ImportExportWallets(t *testing.T) {
+	token, _ := GenerateToken()
+	database := setupTestDB(t)
+	client, srv := newRemoteTestServer(t, database, token)
+	ctx := context.Background()
+	a := "tz1" + strings.Repeat("a", 33)
+	file := []byte("address,alias,sync_owned\n" + a + ",Mine,false\nbogus\n")
+
+	report, err := client.ImportWallets(ctx, file, "", true)
+	if err != nil {
+		t.Fatalf("ImportWallets(dry run) error = %v", err)
+	}
+	if !report.DryRun || report.Added != 1 || report.Invalid != 1 || report.Results[1].Line != 3 {
+		t.Errorf("report = %+v", report)
+	}
+	if wallets, _ := database.GetAllWallets(); len(wallets) != 0 {
+		t.Fatalf("dry run added wallets: %+v", wallets)
+	}
+
+	if report, err = client.ImportWallets(ctx, file, WalletFileCSV, false); err != nil || report.Added != 1 {
+		t.Fatalf("ImportWallets() = %+v, %v", report, err)
+	}
+	if wallet, _ := database.GetWallet(a); wallet == nil || wallet.SyncOwned {
+		t.Errorf("imported wallet = %+v", wallet)
+	}
+	if _, err := client.ImportWallets(ctx, []byte("[]"), "", false); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("ImportWallets(empty) error = %v, want ErrBadRequest", err)
+	}
+
+	exported, err := client.ExportWallets(ctx, WalletFileCSV)
+	if err != nil {
+		t.Fatalf("ExportWallets() error = %v", err)
+	}
+	if want := "address,alias,sync_owned,sync_created\n" + a + ",Mine,false,true\n"; string(exported) != want {
+		t.Errorf("ExportWallets() = %q, want %q", exported, want)
+	}
+	if _, err := client.ExportWallets(ctx, "xml"); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("ExportWallets(xml) error = %v, want ErrBadRequest", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api/v1/wallets/export", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var entries []WalletEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil || len(entries) != 1 || entries[0].Address != a {
+		t.Errorf("default export = %+v, %v", entries, err)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); !strings.Contains(cd, "porcupin-wallets.json") {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+}
+
+// =============================================================================
+// Creator and Collection Tests
+// =============================================================================
+
+// seedCreators stores two artists, one with a profile, and their collections
+func seedCreators(t *testing.T, database *db.Database) {
+	t.Helper()
+	database.SaveCreator(&db.Creator{Address: "tz1alice", Alias: "alice", Name: "Alice", Twitter: "alice"})
+	database.NoteCreator("tz1bob", "bob")
+	database.SaveContract(&db.Contract{Address: "KT1series", Name: "Alice's Series", CreatorAddress: "tz1alice"})
+	database.NoteContract("KT1shared", "Shared")
+	for i, n := range []struct{ creator, contract string }{
+		{"tz1alice", "KT1series"}, {"tz1alice", "KT1series"}, {"tz1alice", "KT1shared"}, {"tz1bob", "KT1shared"},
+	} {
+		database.SaveNFT(&db.NFT{TokenID: strconv.Itoa(i), ContractAddress: n.contract, CreatorAddress: n.creator, Name: "NFT " + strconv.Itoa(i)})
+	}
+}
+
+func TestCreatorEndpoints(t *testing.T) {
+	database := setupTestDB(t)
+	seedCreators(t, database)
+	router := NewRouter(database, newTestService(database), t.TempDir(), "test")
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/creators", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /creators status = %d: %s", rr.Code, rr.Body.String())
+	}
+	var creators CreatorsListResponse
+	decodeData(t, rr, &creators)
+	if creators.Total != 2 || creators.Creators[0].Name != "Alice" || creators.Creators[0].NFTCount != 3 || creators.Creators[1].Name != "bob" {
+		t.Errorf("creators = %+v", creators)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/collections?creator=tz1alice", nil))
+	var collections CollectionsListResponse
+	decodeData(t, rr, &collections)
+	if collections.Total != 1 || collections.Collections[0].Name != "Alice's Series" || collections.Collections[0].NFTCount != 2 {
+		t.Errorf("collections of tz1alice = %+v", collections)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/creators/tz1nobody", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("GET /creators/tz1nobody status = %d, want 404", rr.Code)
+	}
+
+	// NFTs filter by artist and collection, and name both
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/nfts?creator=tz1alice&contract=KT1shared", nil))
+	var nfts NFTsListResponse
+	decodeData(t, rr, &nfts)
+	if nfts.Total != 1 || nfts.NFTs[0].CreatorName != "Alice" || nfts.NFTs[0].CollectionName != "Shared" {
+		t.Errorf("NFTs of tz1alice in KT1shared = %+v", nfts)
+	}
+}
+
+func TestRemoteClient_CreatorsAndCollections(t *testing.T) {
+	token, _ := GenerateToken()
+	database := setupTestDB(t)
+	seedCreators(t, database)
+	client, _ := newRemoteTestServer(t, database, token)
+	ctx := context.Background()
+
+	creators, err := client.Creators(ctx, GroupQuery{Search: "bo"})
+	if err != nil || creators.Total != 1 || creators.Creators[0].Address != "tz1bob" {
+		t.Errorf("Creators(bo) = %+v, %v", creators, err)
+	}
+	creator, err := client.Creator(ctx, "tz1alice")
+	if err != nil || creator.Twitter != "alice" || creator.NFTCount != 3 {
+		t.Errorf("Creator(tz1alice) = %+v, %v", creator, err)
+	}
+	if _, err := client.Collection(ctx, "KT1nothing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Collection(missing) error = %v, want ErrNotFound", err)
+	}
+	collections, err := client.Collections(ctx, GroupQuery{Limit: 1})
+	if err != nil || collections.Total != 2 || len(collections.Collections) != 1 {
+		t.Errorf("Collections(limit 1) = %+v, %v", collections, err)
+	}
+
+	nfts, err := client.NFTs(ctx, NFTQuery{Contract: "KT1series"})
+	if err != nil || nfts.Total != 2 {
+		t.Errorf("NFTs(KT1series) = %+v, %v", nfts, err)
+	}
+}
+
+func TestGetNFTs_Search(t *testing.T) {
+	database := setupTestDB(t)
+	seedCreators(t, database)
+	database.SaveNFT(&db.NFT{TokenID: "9", ContractAddress: "KT1series", CreatorAddress: "tz1bob",
+		Name: "Quiet", Description: "Series of quiet studies", Tags: []db.NFTTag{{Tag: "minimal"}}})
+	router := NewRouter(database, newTestService(database), t.TempDir(), "test")
+
+	// Matching the artist's name, the collection name and the description,
+	// best first
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/nfts?search=series", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /nfts?search status = %d: %s", rr.Code, rr.Body.String())
+	}
+	var nfts NFTsListResponse
+	decodeData(t, rr, &nfts)
+	if nfts.Total != 3 || nfts.NFTs[0].Highlight == nil {
+		t.Fatalf("search series = %+v", nfts)
+	}
+	if quiet := nfts.NFTs[2]; quiet.Name != "Quiet" || quiet.Highlight.Description != "<mark>Series</mark> of quiet studies" {
+		t.Errorf("description match = %+v, want it last and highlighted", quiet)
+	}
+
+	// Filters apply to the matches
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/nfts?search=minimal&creator=tz1alice", nil))
+	decodeData(t, rr, &nfts)
+	if nfts.Total != 0 {
+		t.Errorf("minimal by tz1alice = %+v, want none", nfts)
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/nfts?search=minimal&creator=tz1bob", nil))
+	decodeData(t, rr, &nfts)
+	if nfts.Total != 1 || nfts.NFTs[0].Highlight.Name != "Quiet" {
+		t.Errorf("minimal by tz1bob = %+v", nfts)
+	}
+}
+
+func TestGetNFTs_Metadata(t *testing.T) {
+	database := setupTestDB(t)
+	date := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	database.SaveNFT(&db.NFT{TokenID: "1", ContractAddress: "KT1a", Name: "Tide", Rights: "CC-BY-4.0", Date: &date,
+		Tags:         []db.NFTTag{{Tag: "Generative"}},
+		Attributes:   []db.NFTAttribute{{Name: "Palette", Value: "Blue"}},
+		Royalties:    []db.NFTRoyalty{{Address: "tz1alice", Share: 0.1}},
+		Contributors: []db.NFTContributor{{Role: db.RoleCreator, Name: "tz1alice"}}})
+	database.SaveNFT(&db.NFT{TokenID: "2", ContractAddress: "KT1a", Name: "Plain"})
+	router := NewRouter(database, newTestService(database), t.TempDir(), "test")
+
+	get := func(query string) NFTsListResponse {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/nfts"+query, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("GET /nfts%s status = %d: %s", query, rr.Code, rr.Body.String())
+		}
+		var nfts NFTsListResponse
+		decodeData(t, rr, &nfts)
+		return nfts
+	}
+
+	nfts := get("?tag=generative")
+	if nfts.Total != 1 {
+		t.Fatalf("tag=generative = %+v", nfts)
+	}
+	tide := nfts.NFTs[0]
+	if tide.Rights != "CC-BY-4.0" || tide.Date == nil || *tide.Date != "2021-03-01T00:00:00Z" ||
+		fmt.Sprint(tide.Tags) != "[Generative]" || len(tide.Attributes) != 1 ||
+		len(tide.Royalties) != 1 || tide.Royalties[0].Share != 0.1 || len(tide.Contributors) != 1 {
+		t.Errorf("NFT = %+v", tide)
+	}
+
+	for query, want := range map[string]int64{
+		"?attribute=palette":            1,
+		"?attribute=Palette:blue":       1,
+		"?attribute=Palette:red":        0,
+		"?tag=generative&search=plain":  0,
+		"?attribute=Palette&search=tid": 1,
+	} {
+		if got := get(query); got.Total != want {
+			t.Errorf("%s found %d NFTs, want %d", query, got.Total, want)
+		}
+	}
+
+	// NFTs without metadata details list them as empty
+	if plain := get("?search=plain").NFTs[0]; plain.Tags == nil || plain.Royalties == nil {
+		t.Errorf("plain NFT = %+v", plain)
+	}
+}
+
+func TestGetNFTHistory(t *testing.T) {
+	token, _ := GenerateToken()
+	database := setupTestDB(t)
+	nft := &db.NFT{TokenID: "1", ContractAddress: "KT1a", ArtifactURI: "ipfs://QmB"}
+	database.SaveNFT(nft)
+	database.RecordMetadataVersion(nft.ID, 100, `{"artifactUri":"ipfs://QmA","name":"Tide"}`)
+	database.RecordMetadataVersion(nft.ID, 200, `{"artifactUri":"ipfs://QmB","name":"Tide"}`)
+	client, _ := newRemoteTestServer(t, database, token)
+	ctx := context.Background()
+
+	history, err := client.NFTHistory(ctx, nft.ID)
+	if err != nil || history.NFTID != nft.ID || len(history.Versions) != 2 {
+		t.Fatalf("NFTHistory = %+v, %v", history, err)
+	}
+	latest := history.Versions[1]
+	if latest.Version != 2 || latest.Level != 200 || latest.SeenAt == "" ||
+		len(latest.Changes) != 1 || latest.Changes[0].Field != "artifactUri" || string(latest.Changes[0].New) != `"ipfs://QmB"` {
+		t.Errorf("latest version = %+v", latest)
+	}
+	var metadata map[string]string
+	if err := json.Unmarshal(latest.Metadata, &metadata); err != nil || metadata["name"] != "Tide" {
+		t.Errorf("latest metadata = %s, %v", latest.Metadata, err)
+	}
+
+	if _, err := client.NFTHistory(ctx, nft.ID+1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("NFTHistory(missing) error = %v, want ErrNotFound", err)
+	}
+	router := NewRouter(database, newTestService(database), t.TempDir(), "test")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/nfts/abc/history", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("GET /nfts/abc/history status = %d, want 400", rr.Code)
+	}
+}
+
+func TestReconcilePins_Unavailable(t *testing.T) {
+	database := setupTestDB(t)
+
+	// A service without an IPFS node has no pins to compare
+	router := NewRouter(database, newTestService(database), t.TempDir(), "test")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/assets/reconcile?dry_run=true", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("POST /assets/reconcile status = %d, want 503", rr.Code)
+	}
+
+	token, _ := GenerateToken()
+	client, _ := newRemoteTestServer(t, database, token)
+	if _, err := client.ReconcilePins(context.Background(), true); !errors.Is(err, ErrServiceUnavailable) {
+		t.Errorf("ReconcilePins without a service error = %v, want ErrServiceUnavailable", err)
+	}
+}
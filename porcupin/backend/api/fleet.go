@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"porcupin/backend/core"
+)
+
+// fleetNodeTimeout bounds how long the fleet overview waits for one server
+const fleetNodeTimeout = 10 * time.Second
+
+// FleetMember is a server to include in a fleet overview
+type FleetMember struct {
+	ID      uint64
+	Name    string
+	Address string // host:port, for display
+	Client  *RemoteClient
+}
+
+// FleetNode is one server's part of a fleet overview. A server that can't
+// be reached, or that rejects the request, has Error set and no status.
+type FleetNode struct {
+	ID        uint64              `json:"id"`
+	Name      string              `json:"name"`
+	Address   string              `json:"address"`
+	Reachable bool                `json:"reachable"`
+	Error     string              `json:"error,omitempty"`
+	Version   string              `json:"version,omitempty"`
+	Status    *core.ServiceStatus `json:"status,omitempty"`
+	Stats     *StatsResponse      `json:"stats,omitempty"`
+}
+
+// FleetFailedAsset is a failed asset and the server it failed on
+type FleetFailedAsset struct {
+	NodeID   uint64 `json:"node_id"`
+	NodeName string `json:"node_name"`
+	AssetResponse
+}
+
+// FleetTotals adds up the stats of the servers that answered
+type FleetTotals struct {
+	Nodes         int     `json:"nodes"`
+	Reachable     int     `json:"reachable"`
+	Unreachable   int     `json:"unreachable"`
+	Errors        int     `json:"errors"` // Reachable, but the request failed
+	TotalNFTs     int64   `json:"total_nfts"`
+	TotalAssets   int64   `json:"total_assets"`
+	PinnedAssets  int64   `json:"pinned_assets"`
+	PendingAssets int64   `json:"pending_assets"`
+	FailedAssets  int64   `json:"failed_assets"`
+	StorageUsedGB float64 `json:"storage_used_gb"`
+}
+
+// FleetOverview merges the status, stats and failed assets of several
+// servers
+type FleetOverview struct {
+	Nodes        []FleetNode        `json:"nodes"`
+	Totals       FleetTotals        `json:"totals"`
+	FailedAssets []FleetFailedAsset `json:"failed_assets"`
+	CheckedAt    time.Time          `json:"checked_at"`
+}
+
+// GetFleetOverview asks every member for its status, stats and failed
+// assets at once, and merges the answers. It always returns an overview;
+// members that fail are flagged in it.
+func GetFleetOverview(ctx context.Context, members []FleetMember) *FleetOverview {
+	nodes := make([]FleetNode, len(members))
+	failed := make([][]AssetResponse, len(members))
+
+	var wg sync.WaitGroup
+	for i, member := range members {
+		wg.Add(1)
+		go func(i int, member FleetMember) {
+			defer wg.Done()
+			nodeCtx, cancel := context.WithTimeout(ctx, fleetNodeTimeout)
+			defer cancel()
+			nodes[i], failed[i] = fleetNode(nodeCtx, member)
+		}(i, member)
+	}
+	wg.Wait()
+
+	overview := &FleetOverview{
+		Nodes:        nodes,
+		FailedAssets: []FleetFailedAsset{},
+		CheckedAt:    time.Now().UTC(),
+	}
+	totals := &overview.Totals
+	totals.Nodes = len(nodes)
+	for i, node := range nodes {
+		switch {
+		case !node.Reachable:
+			totals.Unreachable++
+			continue
+		case node.Error != "":
+			totals.Reachable++
+			totals.Errors++
+			continue
+		}
+		totals.Reachable++
+		totals.TotalNFTs += node.Stats.TotalNFTs
+		totals.TotalAssets += node.Stats.TotalAssets
+		totals.PinnedAssets += node.Stats.PinnedAssets
+		totals.PendingAssets += node.Stats.PendingAssets
+		totals.FailedAssets += node.Stats.FailedAssets
+		totals.StorageUsedGB += node.Stats.StorageUsedGB
+		for _, asset := range failed[i] {
+			overview.FailedAssets = append(overview.FailedAssets, FleetFailedAsset{
+				NodeID:        node.ID,
+				NodeName:      node.Name,
+				AssetResponse: asset,
+			})
+		}
+	}
+	sort.SliceStable(overview.FailedAssets, func(a, b int) bool {
+		return overview.FailedAssets[a].NodeName < overview.FailedAssets[b].NodeName
+	})
+	return overview
+}
+
+// fleetNode queries one member. Stats decide whether it answered; status
+// and failed assets are left out if only they fail, as on a server that
+// runs the API without the backup service.
+func fleetNode(ctx context.Context, member FleetMember) (FleetNode, []AssetResponse) {
+	node := FleetNode{ID: member.ID, Name: member.Name, Address: member.Address}
+
+	stats, err := member.Client.Stats(ctx)
+	if err != nil {
+		var apiErr *APIError
+		node.Reachable = errors.As(err, &apiErr)
+		node.Error = err.Error()
+		return node, nil
+	}
+	node.Reachable = true
+	node.Stats = stats
+
+	if version, err := member.Client.Version(ctx); err == nil {
+		node.Version = version
+	}
+	if status, err := member.Client.Status(ctx); err == nil {
+		node.Status = status
+	}
+	failed, _ := member.Client.FailedAssets(ctx)
+	return node, failed
+}
@@ -1,18 +1,27 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
 
 	"porcupin/backend/core"
 	"porcupin/backend/db"
+	"porcupin/backend/indexer"
 	"porcupin/backend/ipfs"
+	"porcupin/backend/pinning"
 )
 
 // MaxRequestBodySize is the maximum allowed request body size (1MB)
@@ -26,6 +35,47 @@ func IsValidTezosAddress(addr string) bool {
 	return tezosAddressPattern.MatchString(addr)
 }
 
+// ErrInvalidWallet is returned by ResolveWallet for input that is neither a
+// Tezos address nor a Tezos Domains name
+var ErrInvalidWallet = errors.New("invalid Tezos address format (expected tz1/tz2/tz3/KT1 followed by 33 alphanumeric characters, or a .tez domain)")
+
+// domainLookupTimeout bounds the reverse lookup made when a wallet is added
+const domainLookupTimeout = 5 * time.Second
+
+// ResolveWallet returns the address of a wallet given as an address or as a
+// Tezos Domains name like alice.tez, and its domain: the name the address
+// resolves back to, or else the name given. A name that points nowhere is
+// indexer.ErrDomainNotFound. The reverse lookup is best effort. With a nil
+// idx only addresses are accepted.
+func ResolveWallet(ctx context.Context, idx *indexer.Indexer, input string) (address, domain string, err error) {
+	input = strings.TrimSpace(input)
+	switch {
+	case IsValidTezosAddress(input):
+		address = input
+	case indexer.IsDomain(input):
+		if idx == nil {
+			return "", "", errors.New("can't resolve Tezos domains without the indexer")
+		}
+		if address, err = idx.ResolveDomain(ctx, input); err != nil {
+			return "", "", err
+		}
+	default:
+		return "", "", ErrInvalidWallet
+	}
+
+	if idx != nil {
+		lookupCtx, cancel := context.WithTimeout(ctx, domainLookupTimeout)
+		defer cancel()
+		if domain, err = idx.ReverseDomain(lookupCtx, address); err != nil {
+			log.Printf("Failed to look up the domain of %s: %v", address, err)
+		}
+	}
+	if domain == "" && !IsValidTezosAddress(input) {
+		domain = indexer.NormalizeDomain(input)
+	}
+	return address, domain, nil
+}
+
 // Handlers holds the API handlers and their dependencies
 type Handlers struct {
 	db       *db.Database
@@ -33,6 +83,16 @@ type Handlers struct {
 	ipfs     *ipfs.Node
 	dataDir  string
 	version  string
+	pairing  *Pairing
+	endpoint pairingEndpoint
+}
+
+// pairingEndpoint is how clients reach the server, for pairing URLs
+type pairingEndpoint struct {
+	host        string
+	port        int
+	useTLS      bool
+	fingerprint string
 }
 
 // NewHandlers creates a new Handlers instance
@@ -42,6 +102,7 @@ func NewHandlers(database *db.Database, service *core.BackupService, dataDir, ve
 		service: service,
 		dataDir: dataDir,
 		version: version,
+		pairing: NewPairing(),
 	}
 }
 
@@ -50,6 +111,14 @@ func (h *Handlers) SetIPFS(node *ipfs.Node) {
 	h.ipfs = node
 }
 
+// SetPairingEndpoint sets the address clients connect to and the
+// fingerprint of the server's self-signed certificate, if any, for pairing
+// URLs. An empty host picks a LAN address of this machine. Pairing needs
+// TLS, as the token it issues is sent to the client.
+func (h *Handlers) SetPairingEndpoint(host string, port int, useTLS bool, fingerprint string) {
+	h.endpoint = pairingEndpoint{host: host, port: port, useTLS: useTLS, fingerprint: fingerprint}
+}
+
 // =============================================================================
 // System Endpoints
 // =============================================================================
@@ -101,6 +170,42 @@ type StatsResponse struct {
 	WalletsCount  int     `json:"wallets_count"`
 	ServiceState  string  `json:"service_state"`
 	LastSyncAt    *string `json:"last_sync_at,omitempty"`
+
+	// Per-wallet usage; PinnedGB counts assets shared between wallets once
+	PinnedGB    float64               `json:"pinned_gb"`
+	WalletUsage []WalletUsageResponse `json:"wallet_usage"`
+}
+
+// WalletUsageResponse is one wallet's pinned storage against its quota
+type WalletUsageResponse struct {
+	Address    string  `json:"address"`
+	Alias      string  `json:"alias,omitempty"`
+	AssetCount int64   `json:"asset_count"`
+	UsedGB     float64 `json:"used_gb"`
+	SharedGB   float64 `json:"shared_gb"`
+	QuotaGB    int     `json:"quota_gb"` // 0 = unlimited
+	OverQuota  bool    `json:"over_quota"`
+}
+
+// bytesToGB converts a byte count for display
+func bytesToGB(b int64) float64 {
+	return float64(b) / (1024 * 1024 * 1024)
+}
+
+// walletUsageResponse reports a wallet's usage against its quota
+func walletUsageResponse(wallet db.Wallet, usage *db.WalletUsage) WalletUsageResponse {
+	resp := WalletUsageResponse{
+		Address: wallet.Address,
+		Alias:   wallet.Alias,
+		QuotaGB: wallet.QuotaGB,
+	}
+	if usage != nil {
+		resp.AssetCount = usage.AssetCount
+		resp.UsedGB = bytesToGB(usage.UsedBytes)
+		resp.SharedGB = bytesToGB(usage.SharedBytes)
+	}
+	resp.OverQuota = wallet.QuotaGB > 0 && resp.UsedGB >= float64(wallet.QuotaGB)
+	return resp
 }
 
 // GetStats returns current statistics
@@ -118,8 +223,22 @@ func (h *Handlers) GetStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get disk usage
-	storageBytes, err := core.GetDiskUsageBytes(h.dataDir + "/ipfs")
+	usage, pinnedBytes, err := h.db.GetWalletUsage()
+	if err != nil {
+		WriteInternalError(w, "failed to get wallet usage: "+err.Error())
+		return
+	}
+	walletUsage := make([]WalletUsageResponse, 0, len(wallets))
+	for _, wallet := range wallets {
+		walletUsage = append(walletUsage, walletUsageResponse(wallet, usage[wallet.Address]))
+	}
+
+	// Get disk usage of the repository, wherever it has been moved to
+	repoPath := h.dataDir + "/ipfs"
+	if h.ipfs != nil {
+		repoPath = h.ipfs.GetRepoPath()
+	}
+	storageBytes, err := core.GetDiskUsageBytes(repoPath)
 	storageGB := 0.0
 	if err == nil {
 		storageGB = float64(storageBytes) / (1024 * 1024 * 1024)
@@ -147,6 +266,8 @@ func (h *Handlers) GetStats(w http.ResponseWriter, r *http.Request) {
 		WalletsCount:  len(wallets),
 		ServiceState:  serviceState,
 		LastSyncAt:    lastSync,
+		PinnedGB:      bytesToGB(pinnedBytes),
+		WalletUsage:   walletUsage,
 	}
 
 	WriteJSON(w, http.StatusOK, resp)
@@ -209,12 +330,40 @@ func (h *Handlers) GetActivity(w http.ResponseWriter, r *http.Request) {
 type WalletResponse struct {
 	Address      string  `json:"address"`
 	Alias        string  `json:"alias,omitempty"`
+	Domain       string  `json:"domain,omitempty"` // Tezos Domains name, e.g. alice.tez
 	SyncOwned    bool    `json:"sync_owned"`
 	SyncCreated  bool    `json:"sync_created"`
+	Priority     int     `json:"priority"`
+	QuotaGB      int     `json:"quota_gb"` // 0 = unlimited
+	UsedGB       float64 `json:"used_gb"`
+	SharedGB     float64 `json:"shared_gb"`
 	LastSyncedAt *string `json:"last_synced_at,omitempty"`
 	NFTCount     int     `json:"nft_count"`
 }
 
+// newWalletResponse builds the response for a wallet and its usage
+func newWalletResponse(wallet db.Wallet, nftCount int64, usage *db.WalletUsage) WalletResponse {
+	resp := WalletResponse{
+		Address:     wallet.Address,
+		Alias:       wallet.Alias,
+		Domain:      wallet.Domain,
+		SyncOwned:   wallet.SyncOwned,
+		SyncCreated: wallet.SyncCreated,
+		Priority:    wallet.Priority,
+		QuotaGB:     wallet.QuotaGB,
+		NFTCount:    int(nftCount),
+	}
+	if usage != nil {
+		resp.UsedGB = bytesToGB(usage.UsedBytes)
+		resp.SharedGB = bytesToGB(usage.SharedBytes)
+	}
+	if wallet.LastSyncedAt != nil {
+		t := wallet.LastSyncedAt.UTC().Format(time.RFC3339)
+		resp.LastSyncedAt = &t
+	}
+	return resp
+}
+
 // GetWallets returns all tracked wallets
 // GET /api/v1/wallets
 func (h *Handlers) GetWallets(w http.ResponseWriter, r *http.Request) {
@@ -224,23 +373,18 @@ func (h *Handlers) GetWallets(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	usage, _, err := h.db.GetWalletUsage()
+	if err != nil {
+		WriteInternalError(w, "failed to get wallet usage: "+err.Error())
+		return
+	}
+
 	resp := make([]WalletResponse, 0, len(wallets))
 	for _, wallet := range wallets {
 		var nftCount int64
 		h.db.Model(&db.NFT{}).Where("wallet_address = ?", wallet.Address).Count(&nftCount)
 
-		wr := WalletResponse{
-			Address:     wallet.Address,
-			Alias:       wallet.Alias,
-			SyncOwned:   wallet.SyncOwned,
-			SyncCreated: wallet.SyncCreated,
-			NFTCount:    int(nftCount),
-		}
-		if wallet.LastSyncedAt != nil {
-			t := wallet.LastSyncedAt.UTC().Format(time.RFC3339)
-			wr.LastSyncedAt = &t
-		}
-		resp = append(resp, wr)
+		resp = append(resp, newWalletResponse(wallet, nftCount, usage[wallet.Address]))
 	}
 
 	WriteJSON(w, http.StatusOK, resp)
@@ -252,6 +396,8 @@ type AddWalletRequest struct {
 	Alias       string `json:"alias,omitempty"`
 	SyncOwned   *bool  `json:"sync_owned,omitempty"`
 	SyncCreated *bool  `json:"sync_created,omitempty"`
+	Priority    *int   `json:"priority,omitempty"`
+	QuotaGB     *int   `json:"quota_gb,omitempty"`
 }
 
 // AddWallet adds a new wallet to track
@@ -271,14 +417,23 @@ func (h *Handlers) AddWallet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate Tezos address format
-	if !IsValidTezosAddress(req.Address) {
-		WriteBadRequest(w, "invalid Tezos address format (expected tz1/tz2/tz3/KT1 followed by 33 alphanumeric characters)")
+	// Accept a Tezos address or a .tez domain, which is resolved to its address
+	var idx *indexer.Indexer
+	if h.service != nil {
+		idx = h.service.Indexer()
+	}
+	address, domain, err := ResolveWallet(r.Context(), idx, req.Address)
+	switch {
+	case errors.Is(err, ErrInvalidWallet), errors.Is(err, indexer.ErrDomainNotFound):
+		WriteBadRequest(w, err.Error())
+		return
+	case err != nil:
+		WriteServiceUnavailable(w, err.Error())
 		return
 	}
 
 	// Check if wallet already exists
-	existing, err := h.db.GetWallet(req.Address)
+	existing, err := h.db.GetWallet(address)
 	if err != nil {
 		WriteInternalError(w, "database error: "+err.Error())
 		return
@@ -288,10 +443,15 @@ func (h *Handlers) AddWallet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create wallet with defaults
+	// Create wallet with defaults, named after its domain unless given an alias
+	alias := req.Alias
+	if alias == "" {
+		alias = domain
+	}
 	wallet := &db.Wallet{
-		Address:     req.Address,
-		Alias:       req.Alias,
+		Address:     address,
+		Alias:       alias,
+		Domain:      domain,
 		SyncOwned:   true,
 		SyncCreated: true,
 	}
@@ -301,6 +461,16 @@ func (h *Handlers) AddWallet(w http.ResponseWriter, r *http.Request) {
 	if req.SyncCreated != nil {
 		wallet.SyncCreated = *req.SyncCreated
 	}
+	if req.Priority != nil {
+		wallet.Priority = *req.Priority
+	}
+	if req.QuotaGB != nil {
+		if *req.QuotaGB < 0 {
+			WriteBadRequest(w, "quota_gb cannot be negative")
+			return
+		}
+		wallet.QuotaGB = *req.QuotaGB
+	}
 
 	if err := h.db.SaveWallet(wallet); err != nil {
 		WriteInternalError(w, "failed to save wallet: "+err.Error())
@@ -309,18 +479,10 @@ func (h *Handlers) AddWallet(w http.ResponseWriter, r *http.Request) {
 
 	// Trigger sync for the new wallet
 	if h.service != nil {
-		h.service.TriggerSync(req.Address)
-	}
-
-	resp := WalletResponse{
-		Address:     wallet.Address,
-		Alias:       wallet.Alias,
-		SyncOwned:   wallet.SyncOwned,
-		SyncCreated: wallet.SyncCreated,
-		NFTCount:    0,
+		h.service.TriggerSync(address)
 	}
 
-	WriteCreated(w, resp)
+	WriteCreated(w, newWalletResponse(*wallet, 0, nil))
 }
 
 // GetWallet returns a single wallet
@@ -345,19 +507,13 @@ func (h *Handlers) GetWallet(w http.ResponseWriter, r *http.Request) {
 	var nftCount int64
 	h.db.Model(&db.NFT{}).Where("wallet_address = ?", address).Count(&nftCount)
 
-	resp := WalletResponse{
-		Address:     wallet.Address,
-		Alias:       wallet.Alias,
-		SyncOwned:   wallet.SyncOwned,
-		SyncCreated: wallet.SyncCreated,
-		NFTCount:    int(nftCount),
-	}
-	if wallet.LastSyncedAt != nil {
-		t := wallet.LastSyncedAt.UTC().Format(time.RFC3339)
-		resp.LastSyncedAt = &t
+	usage, _, err := h.db.GetWalletUsage()
+	if err != nil {
+		WriteInternalError(w, "failed to get wallet usage: "+err.Error())
+		return
 	}
 
-	WriteJSON(w, http.StatusOK, resp)
+	WriteJSON(w, http.StatusOK, newWalletResponse(*wallet, nftCount, usage[address]))
 }
 
 // UpdateWalletRequest is the request body for updating a wallet
@@ -365,6 +521,8 @@ type UpdateWalletRequest struct {
 	Alias       *string `json:"alias,omitempty"`
 	SyncOwned   *bool   `json:"sync_owned,omitempty"`
 	SyncCreated *bool   `json:"sync_created,omitempty"`
+	Priority    *int    `json:"priority,omitempty"`
+	QuotaGB     *int    `json:"quota_gb,omitempty"`
 }
 
 // UpdateWallet updates a wallet's settings
@@ -405,28 +563,39 @@ func (h *Handlers) UpdateWallet(w http.ResponseWriter, r *http.Request) {
 	if req.SyncCreated != nil {
 		wallet.SyncCreated = *req.SyncCreated
 	}
+	if req.Priority != nil {
+		wallet.Priority = *req.Priority
+	}
+	if req.QuotaGB != nil {
+		if *req.QuotaGB < 0 {
+			WriteBadRequest(w, "quota_gb cannot be negative")
+			return
+		}
+		wallet.QuotaGB = *req.QuotaGB
+	}
 
 	if err := h.db.SaveWallet(wallet); err != nil {
 		WriteInternalError(w, "failed to save wallet: "+err.Error())
 		return
 	}
 
+	if req.Priority != nil && h.service != nil {
+		h.service.SetWalletPriority(address, wallet.Priority)
+	}
+	if req.QuotaGB != nil && h.service != nil {
+		h.service.ApplyWalletQuota(address)
+	}
+
 	var nftCount int64
 	h.db.Model(&db.NFT{}).Where("wallet_address = ?", address).Count(&nftCount)
 
-	resp := WalletResponse{
-		Address:     wallet.Address,
-		Alias:       wallet.Alias,
-		SyncOwned:   wallet.SyncOwned,
-		SyncCreated: wallet.SyncCreated,
-		NFTCount:    int(nftCount),
-	}
-	if wallet.LastSyncedAt != nil {
-		t := wallet.LastSyncedAt.UTC().Format(time.RFC3339)
-		resp.LastSyncedAt = &t
+	usage, _, err := h.db.GetWalletUsage()
+	if err != nil {
+		WriteInternalError(w, "failed to get wallet usage: "+err.Error())
+		return
 	}
 
-	WriteJSON(w, http.StatusOK, resp)
+	WriteJSON(w, http.StatusOK, newWalletResponse(*wallet, nftCount, usage[address]))
 }
 
 // DeleteWallet removes a wallet
@@ -453,32 +622,18 @@ func (h *Handlers) DeleteWallet(w http.ResponseWriter, r *http.Request) {
 	unpin := r.URL.Query().Get("unpin") == "true"
 
 	if unpin && h.service != nil {
-		// Get all assets for this wallet and unpin them
-		assets, err := h.db.GetAssetsByWallet(address)
+		// Unpinning can take a long time for large wallets - run it as a
+		// persistent job so it survives restarts
+		job, err := h.service.SubmitJob(core.JobTypeDeleteWallet, core.DeleteWalletParams{
+			Address: address,
+			Unpin:   true,
+		})
 		if err != nil {
-			WriteInternalError(w, "failed to get assets: "+err.Error())
-			return
-		}
-
-		// Unpin each asset (best effort)
-		for _, asset := range assets {
-			cid := core.ExtractCIDFromURI(asset.URI)
-			if cid != "" {
-				_ = h.service.UnpinAsset(cid)
-			}
-		}
-
-		// Delete assets from database
-		if err := h.db.DeleteAssetsByWallet(address); err != nil {
-			WriteInternalError(w, "failed to delete assets: "+err.Error())
-			return
-		}
-
-		// Delete NFTs from database
-		if err := h.db.DeleteNFTsByWallet(address); err != nil {
-			WriteInternalError(w, "failed to delete NFTs: "+err.Error())
+			WriteInternalError(w, "failed to start delete job: "+err.Error())
 			return
 		}
+		WriteAccepted(w, job)
+		return
 	}
 
 	// Delete wallet
@@ -522,23 +677,100 @@ func (h *Handlers) SyncWallet(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ImportWallets adds the wallets in a CSV or JSON wallet file sent as the
+// request body, and reports the outcome of every row
+// POST /api/v1/wallets/import?format=csv&dry_run=true
+func (h *Handlers) ImportWallets(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxRequestBodySize)
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		WriteBadRequest(w, "failed to read body: "+err.Error())
+		return
+	}
+
+	rows, err := ParseWalletFile(data, r.URL.Query().Get("format"))
+	if err != nil {
+		WriteBadRequest(w, err.Error())
+		return
+	}
+
+	opts := WalletImportOptions{
+		DryRun:      r.URL.Query().Get("dry_run") == "true",
+		SyncOwned:   true,
+		SyncCreated: true,
+	}
+	report, err := ImportWallets(h.db, rows, opts)
+	if err != nil {
+		WriteInternalError(w, err.Error())
+		return
+	}
+
+	if h.service != nil && !report.DryRun {
+		for _, address := range report.AddedAddresses() {
+			h.service.TriggerSync(address)
+		}
+	}
+
+	WriteJSON(w, http.StatusOK, report)
+}
+
+// ExportWallets returns every wallet as a wallet file, which ImportWallets
+// reads
+// GET /api/v1/wallets/export?format=json
+func (h *Handlers) ExportWallets(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = WalletFileJSON
+	}
+	contentType := map[string]string{WalletFileCSV: "text/csv", WalletFileJSON: "application/json"}[format]
+	if contentType == "" {
+		WriteBadRequest(w, "format must be csv or json")
+		return
+	}
+
+	wallets, err := h.db.GetAllWallets()
+	if err != nil {
+		WriteInternalError(w, "failed to get wallets: "+err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="porcupin-wallets.`+format+`"`)
+	w.WriteHeader(http.StatusOK)
+	WriteWalletFile(w, wallets, format)
+}
+
 // =============================================================================
 // NFT Endpoints
 // =============================================================================
 
 // NFTResponse is a single NFT in the response
 type NFTResponse struct {
-	ID              uint64          `json:"id"`
-	TokenID         string          `json:"token_id"`
-	ContractAddress string          `json:"contract_address"`
-	WalletAddress   string          `json:"wallet_address"`
-	Name            string          `json:"name"`
-	Description     string          `json:"description"`
-	CreatorAddress  string          `json:"creator"`
-	ArtifactURI     string          `json:"artifact_uri"`
-	DisplayURI      string          `json:"display_uri"`
-	ThumbnailURI    string          `json:"thumbnail_uri"`
-	Assets          []AssetResponse `json:"assets,omitempty"`
+	ID              uint64              `json:"id"`
+	TokenID         string              `json:"token_id"`
+	ContractAddress string              `json:"contract_address"`
+	WalletAddress   string              `json:"wallet_address"`
+	Name            string              `json:"name"`
+	Description     string              `json:"description"`
+	CreatorAddress  string              `json:"creator"`
+	CreatorName     string              `json:"creator_name,omitempty"`    // Profile name or alias, if known
+	CollectionName  string              `json:"collection_name,omitempty"` // Contract name or alias, if known
+	Highlight       *db.Highlight       `json:"highlight,omitempty"`       // Where a search matched
+	Symbol          string              `json:"symbol,omitempty"`
+	Type            string              `json:"type,omitempty"`
+	Language        string              `json:"language,omitempty"`
+	Rights          string              `json:"rights,omitempty"`     // License statement
+	RightsURI       string              `json:"rights_uri,omitempty"` // Link to the full license
+	ExternalURI     string              `json:"external_uri,omitempty"`
+	Date            *string             `json:"date,omitempty"` // When the work was made, per its metadata
+	Tags            []string            `json:"tags"`
+	Attributes      []db.NFTAttribute   `json:"attributes"`
+	Royalties       []db.NFTRoyalty     `json:"royalties"`
+	Contributors    []db.NFTContributor `json:"contributors"`
+	ArtifactURI     string              `json:"artifact_uri"`
+	DisplayURI      string              `json:"display_uri"`
+	ThumbnailURI    string              `json:"thumbnail_uri"`
+	Assets          []AssetResponse     `json:"assets,omitempty"`
 }
 
 // NFTsListResponse is the paginated response for NFTs
@@ -549,8 +781,8 @@ type NFTsListResponse struct {
 	Limit int           `json:"limit"`
 }
 
-// GetNFTs returns paginated NFTs with their assets
-// GET /api/v1/nfts?page=N&limit=N
+// GetNFTs returns paginated NFTs with their assets and metadata
+// GET /api/v1/nfts?page=N&limit=N&search=S&creator=A&contract=C&tag=T&attribute=NAME[:VALUE]
 func (h *Handlers) GetNFTs(w http.ResponseWriter, r *http.Request) {
 	pageStr := r.URL.Query().Get("page")
 	limitStr := r.URL.Query().Get("limit")
@@ -571,23 +803,50 @@ func (h *Handlers) GetNFTs(w http.ResponseWriter, r *http.Request) {
 
 	offset := (page - 1) * limit
 
-	// Build query
-	query := h.db.Model(&db.NFT{})
-
-	search := r.URL.Query().Get("search")
-	if search != "" {
-		likeSearch := "%" + search + "%"
-		query = query.Where("name LIKE ? OR description LIKE ? OR token_id LIKE ? OR contract_address LIKE ? OR creator_address LIKE ?", 
-			likeSearch, likeSearch, likeSearch, likeSearch, likeSearch)
+	// Filters shared by listing and searching
+	creator := r.URL.Query().Get("creator")
+	contract := r.URL.Query().Get("contract")
+	tag := r.URL.Query().Get("tag")
+	attribute, value, _ := strings.Cut(r.URL.Query().Get("attribute"), ":")
+	filter := func(query *gorm.DB) *gorm.DB {
+		if creator != "" {
+			query = query.Where("nfts.creator_address = ?", creator)
+		}
+		if contract != "" {
+			query = query.Where("nfts.contract_address = ?", contract)
+		}
+		if tag != "" {
+			query = db.HasTag(tag)(query)
+		}
+		if attribute != "" {
+			query = db.HasAttribute(attribute, value)(query)
+		}
+		return query
 	}
+	preload := []string{"Assets", "Creator", "Contract", "Tags", "Attributes", "Royalties", "Contributors"}
 
-	// Get total count
-	var total int64
-	query.Count(&total)
-
-	// Get paginated results with assets
 	var nfts []db.NFT
-	query.Preload("Assets").Order("id DESC").Offset(offset).Limit(limit).Find(&nfts)
+	var total int64
+	if search := r.URL.Query().Get("search"); search != "" {
+		// Best matches first, with the matches highlighted
+		hits, n, err := h.db.SearchNFTs(db.SearchQuery{Text: search, Filter: filter, Offset: offset, Limit: limit})
+		if err != nil {
+			WriteInternalError(w, "search failed: "+err.Error())
+			return
+		}
+		if nfts, err = h.db.NFTsForHits(hits, preload...); err != nil {
+			WriteInternalError(w, "failed to get NFTs: "+err.Error())
+			return
+		}
+		total = n
+	} else {
+		query := filter(h.db.Model(&db.NFT{}))
+		query.Count(&total)
+		for _, relation := range preload {
+			query = query.Preload(relation)
+		}
+		query.Order("id DESC").Offset(offset).Limit(limit).Find(&nfts)
+	}
 
 	// Build response
 	resp := NFTsListResponse{
@@ -611,6 +870,27 @@ func (h *Handlers) GetNFTs(w http.ResponseWriter, r *http.Request) {
 			ThumbnailURI:    nft.ThumbnailURI,
 			Assets:          make([]AssetResponse, 0, len(nft.Assets)),
 		}
+		if nft.Creator != nil {
+			nr.CreatorName = nft.Creator.DisplayName()
+		}
+		if nft.Contract != nil {
+			nr.CollectionName = nft.Contract.DisplayName()
+		}
+		nr.Highlight = nft.Highlight
+		nr.Symbol = nft.Symbol
+		nr.Type = nft.Type
+		nr.Language = nft.Language
+		nr.Rights = nft.Rights
+		nr.RightsURI = nft.RightsURI
+		nr.ExternalURI = nft.ExternalURI
+		nr.Date = formatTime(nft.Date)
+		nr.Tags = make([]string, 0, len(nft.Tags))
+		for _, t := range nft.Tags {
+			nr.Tags = append(nr.Tags, t.Tag)
+		}
+		nr.Attributes = append([]db.NFTAttribute{}, nft.Attributes...)
+		nr.Royalties = append([]db.NFTRoyalty{}, nft.Royalties...)
+		nr.Contributors = append([]db.NFTContributor{}, nft.Contributors...)
 		for _, asset := range nft.Assets {
 			ar := AssetResponse{
 				ID:        asset.ID,
@@ -634,6 +914,236 @@ func (h *Handlers) GetNFTs(w http.ResponseWriter, r *http.Request) {
 	WriteJSON(w, http.StatusOK, resp)
 }
 
+// MetadataVersionResponse is a version of an NFT's metadata and how it
+// differs from the version before it
+type MetadataVersionResponse struct {
+	Version  int                 `json:"version"`
+	Level    int64               `json:"level"` // Block level it was seen at, 0 if unknown
+	Hash     string              `json:"hash"`
+	SeenAt   string              `json:"seen_at"`
+	Changes  []db.MetadataChange `json:"changes"` // Every field, for the first version
+	Metadata json.RawMessage     `json:"metadata"`
+}
+
+// MetadataHistoryResponse is the history of an NFT's metadata
+type MetadataHistoryResponse struct {
+	NFTID    uint64                    `json:"nft_id"`
+	Versions []MetadataVersionResponse `json:"versions"` // Oldest first
+}
+
+// GetNFTHistory returns the recorded versions of an NFT's metadata with the
+// changes each made
+// GET /api/v1/nfts/{id}/history
+func (h *Handlers) GetNFTHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		WriteBadRequest(w, "invalid NFT ID")
+		return
+	}
+	if err := h.db.First(&db.NFT{}, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			WriteNotFound(w, "NFT not found")
+		} else {
+			WriteInternalError(w, "database error: "+err.Error())
+		}
+		return
+	}
+
+	history, err := h.db.MetadataHistory(id)
+	if err != nil {
+		WriteInternalError(w, "failed to get metadata history: "+err.Error())
+		return
+	}
+	resp := MetadataHistoryResponse{NFTID: id, Versions: make([]MetadataVersionResponse, 0, len(history))}
+	for _, v := range history {
+		resp.Versions = append(resp.Versions, MetadataVersionResponse{
+			Version:  v.Version,
+			Level:    v.Level,
+			Hash:     v.Hash,
+			SeenAt:   v.CreatedAt.UTC().Format(time.RFC3339),
+			Changes:  v.Changes,
+			Metadata: json.RawMessage(v.Metadata),
+		})
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// =============================================================================
+// Creator and Collection Endpoints
+// =============================================================================
+
+// CreatorResponse is an artist and how many of the tracked NFTs they minted
+type CreatorResponse struct {
+	Address          string  `json:"address"`
+	Name             string  `json:"name"` // Profile name, else alias
+	Alias            string  `json:"alias,omitempty"`
+	Description      string  `json:"description,omitempty"`
+	Website          string  `json:"website,omitempty"`
+	Twitter          string  `json:"twitter,omitempty"`
+	Logo             string  `json:"logo,omitempty"`
+	NFTCount         int64   `json:"nft_count"`
+	ProfileFetchedAt *string `json:"profile_fetched_at,omitempty"`
+}
+
+// CreatorsListResponse is the paginated response for creators
+type CreatorsListResponse struct {
+	Creators []CreatorResponse `json:"creators"`
+	Total    int64             `json:"total"`
+	Page     int               `json:"page"`
+	Limit    int               `json:"limit"`
+}
+
+// CollectionResponse is an NFT contract and how many of the tracked NFTs it
+// holds
+type CollectionResponse struct {
+	Address           string  `json:"address"`
+	Name              string  `json:"name"` // Metadata name, else alias
+	Alias             string  `json:"alias,omitempty"`
+	Description       string  `json:"description,omitempty"`
+	Website           string  `json:"website,omitempty"`
+	Creator           string  `json:"creator,omitempty"` // Account that originated the contract
+	NFTCount          int64   `json:"nft_count"`
+	MetadataFetchedAt *string `json:"metadata_fetched_at,omitempty"`
+}
+
+// CollectionsListResponse is the paginated response for collections
+type CollectionsListResponse struct {
+	Collections []CollectionResponse `json:"collections"`
+	Total       int64                `json:"total"`
+	Page        int                  `json:"page"`
+	Limit       int                  `json:"limit"`
+}
+
+func newCreatorResponse(c *db.CreatorSummary) CreatorResponse {
+	return CreatorResponse{
+		Address:          c.Address,
+		Name:             c.DisplayName(),
+		Alias:            c.Alias,
+		Description:      c.Description,
+		Website:          c.Website,
+		Twitter:          c.Twitter,
+		Logo:             c.Logo,
+		NFTCount:         c.NFTCount,
+		ProfileFetchedAt: formatTime(c.ProfileFetchedAt),
+	}
+}
+
+func newCollectionResponse(c *db.ContractSummary) CollectionResponse {
+	return CollectionResponse{
+		Address:           c.Address,
+		Name:              c.DisplayName(),
+		Alias:             c.Alias,
+		Description:       c.Description,
+		Website:           c.Website,
+		Creator:           c.CreatorAddress,
+		NFTCount:          c.NFTCount,
+		MetadataFetchedAt: formatTime(c.MetadataFetchedAt),
+	}
+}
+
+// formatTime formats an optional time as RFC 3339 in UTC
+func formatTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.UTC().Format(time.RFC3339)
+	return &s
+}
+
+// groupQuery reads the paging and search parameters of a creator or
+// collection list
+func groupQuery(r *http.Request) (db.GroupQuery, int, int) {
+	page := 1
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	limit := 50
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 500 {
+		limit = l
+	}
+	q := db.GroupQuery{
+		Search: r.URL.Query().Get("search"),
+		Offset: (page - 1) * limit,
+		Limit:  limit,
+	}
+	return q, page, limit
+}
+
+// GetCreators returns the artists of the tracked NFTs, most NFTs first
+// GET /api/v1/creators?page=N&limit=N&search=S
+func (h *Handlers) GetCreators(w http.ResponseWriter, r *http.Request) {
+	q, page, limit := groupQuery(r)
+	creators, total, err := h.db.ListCreators(q)
+	if err != nil {
+		WriteInternalError(w, "failed to get creators: "+err.Error())
+		return
+	}
+
+	resp := CreatorsListResponse{
+		Creators: make([]CreatorResponse, 0, len(creators)),
+		Total:    total,
+		Page:     page,
+		Limit:    limit,
+	}
+	for i := range creators {
+		resp.Creators = append(resp.Creators, newCreatorResponse(&creators[i]))
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// GetCreator returns one artist
+// GET /api/v1/creators/{address}
+func (h *Handlers) GetCreator(w http.ResponseWriter, r *http.Request) {
+	creator, err := h.db.GetCreator(chi.URLParam(r, "address"))
+	if err != nil {
+		WriteInternalError(w, "database error: "+err.Error())
+		return
+	}
+	if creator == nil {
+		WriteNotFound(w, "creator not found")
+		return
+	}
+	WriteJSON(w, http.StatusOK, newCreatorResponse(creator))
+}
+
+// GetCollections returns the contracts of the tracked NFTs, most NFTs first
+// GET /api/v1/collections?page=N&limit=N&search=S&creator=A
+func (h *Handlers) GetCollections(w http.ResponseWriter, r *http.Request) {
+	q, page, limit := groupQuery(r)
+	q.Creator = r.URL.Query().Get("creator")
+	contracts, total, err := h.db.ListContracts(q)
+	if err != nil {
+		WriteInternalError(w, "failed to get collections: "+err.Error())
+		return
+	}
+
+	resp := CollectionsListResponse{
+		Collections: make([]CollectionResponse, 0, len(contracts)),
+		Total:       total,
+		Page:        page,
+		Limit:       limit,
+	}
+	for i := range contracts {
+		resp.Collections = append(resp.Collections, newCollectionResponse(&contracts[i]))
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// GetCollection returns one collection
+// GET /api/v1/collections/{address}
+func (h *Handlers) GetCollection(w http.ResponseWriter, r *http.Request) {
+	contract, err := h.db.GetContract(chi.URLParam(r, "address"))
+	if err != nil {
+		WriteInternalError(w, "database error: "+err.Error())
+		return
+	}
+	if contract == nil {
+		WriteNotFound(w, "collection not found")
+		return
+	}
+	WriteJSON(w, http.StatusOK, newCollectionResponse(contract))
+}
+
 // =============================================================================
 // Asset Endpoints
 // =============================================================================
@@ -649,6 +1159,8 @@ type AssetResponse struct {
 	SizeBytes int64   `json:"size_bytes,omitempty"`
 	PinnedAt  *string `json:"pinned_at,omitempty"`
 	NFTID     uint64  `json:"nft_id"`
+	Tier      string  `json:"tier,omitempty"`
+	Wallet    string  `json:"wallet,omitempty"` // Set when listing assets
 }
 
 // AssetsListResponse is the paginated response for assets
@@ -660,7 +1172,8 @@ type AssetsListResponse struct {
 }
 
 // GetAssets returns paginated assets
-// GET /api/v1/assets?page=N&limit=N&status=X
+// GET /api/v1/assets?page=N&limit=N&status=X&wallet=A&type=T
+// status may list several statuses separated by commas
 func (h *Handlers) GetAssets(w http.ResponseWriter, r *http.Request) {
 	status := r.URL.Query().Get("status")
 	pageStr := r.URL.Query().Get("page")
@@ -687,18 +1200,28 @@ func (h *Handlers) GetAssets(w http.ResponseWriter, r *http.Request) {
 
 	status = r.URL.Query().Get("status")
 	search := r.URL.Query().Get("search")
+	wallet := r.URL.Query().Get("wallet")
+	assetType := r.URL.Query().Get("type")
 
 	if status != "" && status != "all" {
-		query = query.Where("status = ?", status)
+		query = query.Where("assets.status IN ?", strings.Split(status, ","))
+	}
+	if assetType != "" {
+		query = query.Where("assets.type = ?", assetType)
 	}
 
+	// Join with NFT table for filtering by wallet
+	if wallet != "" {
+		query = query.Joins("LEFT JOIN nfts ON nfts.id = assets.nft_id").
+			Where("nfts.wallet_address = ?", wallet)
+	}
+
+	// Match the asset itself, or its NFT's metadata through the search index
 	if search != "" {
 		likeSearch := "%" + search + "%"
-		// Join with NFT table for searching by NFT name/description
-		// We need to use a subquery or join for this
-		query = query.Joins("LEFT JOIN nfts ON nfts.id = assets.nft_id").
-			Where("assets.type LIKE ? OR assets.mime_type LIKE ? OR assets.uri LIKE ? OR nfts.name LIKE ? OR nfts.description LIKE ?", 
-				likeSearch, likeSearch, likeSearch, likeSearch, likeSearch)
+		query = query.
+			Where("assets.type LIKE ? OR assets.mime_type LIKE ? OR assets.uri LIKE ? OR assets.nft_id IN (?)",
+				likeSearch, likeSearch, likeSearch, h.db.MatchingNFTIDs(search))
 	}
 
 	// Get total count
@@ -732,6 +1255,10 @@ func (h *Handlers) GetAssets(w http.ResponseWriter, r *http.Request) {
 			t := asset.PinnedAt.UTC().Format(time.RFC3339)
 			ar.PinnedAt = &t
 		}
+		if asset.NFT != nil {
+			ar.Wallet = asset.NFT.WalletAddress
+		}
+		ar.Tier = asset.Tier
 		resp.Assets = append(resp.Assets, ar)
 	}
 
@@ -805,8 +1332,55 @@ func (h *Handlers) RetryAsset(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// RetryAllFailed retries all failed assets
-// POST /api/v1/assets/retry-failed
+// PinAssetNext moves an asset to the front of the pin queue
+// POST /api/v1/assets/{id}/pin-next
+func (h *Handlers) PinAssetNext(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		WriteBadRequest(w, "invalid asset ID")
+		return
+	}
+
+	if h.service == nil {
+		WriteServiceUnavailable(w, "backup service not available")
+		return
+	}
+
+	var asset db.Asset
+	if err := h.db.First(&asset, id).Error; err != nil {
+		WriteNotFound(w, "asset not found")
+		return
+	}
+
+	if err := h.service.PinNext(id); err != nil {
+		if errors.Is(err, core.ErrAssetAlreadyPinned) {
+			WriteConflict(w, "asset is already pinned")
+			return
+		}
+		WriteInternalError(w, "failed to queue asset: "+err.Error())
+		return
+	}
+
+	WriteAccepted(w, map[string]interface{}{
+		"message":  "asset moved to front of queue",
+		"asset_id": id,
+	})
+}
+
+// GetQueue returns the pin queue
+// GET /api/v1/queue
+func (h *Handlers) GetQueue(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		WriteServiceUnavailable(w, "backup service not available")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, h.service.QueueStatus())
+}
+
+// RetryAllFailed retries all failed assets
+// POST /api/v1/assets/retry-failed
 func (h *Handlers) RetryAllFailed(w http.ResponseWriter, r *http.Request) {
 	// Get all failed assets
 	var assets []db.Asset
@@ -887,6 +1461,29 @@ func (h *Handlers) DeleteAsset(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ReconcilePins compares the IPFS node's pins with the assets table. It
+// unpins pins no asset refers to and sets pinned assets the node lost back to
+// pending, or with dry_run only reports them.
+// POST /api/v1/assets/reconcile?dry_run=true
+func (h *Handlers) ReconcilePins(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		WriteServiceUnavailable(w, "backup service not available")
+		return
+	}
+
+	report, err := h.service.ReconcilePins(r.URL.Query().Get("dry_run") == "true")
+	if err != nil {
+		if errors.Is(err, core.ErrReconcileUnavailable) {
+			WriteServiceUnavailable(w, err.Error())
+			return
+		}
+		WriteInternalError(w, "failed to reconcile pins: "+err.Error())
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, report)
+}
+
 // VerifyAndFixPins triggers the verification and repair process
 // POST /api/v1/verify-and-fix
 func (h *Handlers) VerifyAndFixPins(w http.ResponseWriter, r *http.Request) {
@@ -905,85 +1502,1149 @@ func (h *Handlers) VerifyAndFixPins(w http.ResponseWriter, r *http.Request) {
 }
 
 // =============================================================================
-// Control Endpoints
+// Job Endpoints
 // =============================================================================
 
-// TriggerSync triggers a full sync operation
-// POST /api/v1/sync
-func (h *Handlers) TriggerSync(w http.ResponseWriter, r *http.Request) {
+// SubmitJobRequest is the request body for starting a job
+type SubmitJobRequest struct {
+	Type   string          `json:"type"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// JobsListResponse is the response for listing jobs
+type JobsListResponse struct {
+	Jobs  []db.Job `json:"jobs"`
+	Total int      `json:"total"`
+}
+
+// GetJobs returns recent jobs
+// GET /api/v1/jobs?status=running&limit=50
+func (h *Handlers) GetJobs(w http.ResponseWriter, r *http.Request) {
 	if h.service == nil {
 		WriteServiceUnavailable(w, "backup service not available")
 		return
 	}
 
-	h.service.TriggerFullSync()
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 500 {
+			limit = l
+		}
+	}
 
-	WriteAccepted(w, map[string]string{
-		"message": "full sync triggered",
-	})
+	jobs, err := h.service.Jobs().List(r.URL.Query().Get("status"), limit)
+	if err != nil {
+		WriteInternalError(w, "failed to list jobs: "+err.Error())
+		return
+	}
+	if jobs == nil {
+		jobs = []db.Job{}
+	}
+
+	WriteJSON(w, http.StatusOK, JobsListResponse{Jobs: jobs, Total: len(jobs)})
 }
 
-// PauseService pauses the backup service
-// POST /api/v1/pause
-func (h *Handlers) PauseService(w http.ResponseWriter, r *http.Request) {
+// GetJob returns a single job
+// GET /api/v1/jobs/{id}
+func (h *Handlers) GetJob(w http.ResponseWriter, r *http.Request) {
 	if h.service == nil {
 		WriteServiceUnavailable(w, "backup service not available")
 		return
 	}
 
-	h.service.Pause()
-	WriteJSON(w, http.StatusOK, map[string]string{"status": "paused"})
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		WriteBadRequest(w, "invalid job ID")
+		return
+	}
+
+	job, err := h.service.Jobs().Get(id)
+	if err != nil {
+		WriteInternalError(w, "database error: "+err.Error())
+		return
+	}
+	if job == nil {
+		WriteNotFound(w, "job not found")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, job)
 }
 
-// ResumeService resumes the backup service
-// POST /api/v1/resume
-func (h *Handlers) ResumeService(w http.ResponseWriter, r *http.Request) {
+// SubmitJob starts a new job
+// POST /api/v1/jobs
+func (h *Handlers) SubmitJob(w http.ResponseWriter, r *http.Request) {
 	if h.service == nil {
 		WriteServiceUnavailable(w, "backup service not available")
 		return
 	}
 
-	h.service.Resume()
-	WriteJSON(w, http.StatusOK, map[string]string{"status": "resumed"})
+	r.Body = http.MaxBytesReader(w, r.Body, MaxRequestBodySize)
+
+	var req SubmitJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteBadRequest(w, "invalid JSON: "+err.Error())
+		return
+	}
+
+	var params interface{}
+	switch req.Type {
+	case core.JobTypeVerifyAndFix:
+	case core.JobTypeUnpinAll:
+		var p core.UnpinAllParams
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				WriteBadRequest(w, "invalid params: "+err.Error())
+				return
+			}
+		}
+		params = p
+	case core.JobTypeDeleteWallet:
+		var p core.DeleteWalletParams
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				WriteBadRequest(w, "invalid params: "+err.Error())
+				return
+			}
+		}
+		if !IsValidTezosAddress(p.Address) {
+			WriteBadRequest(w, "invalid Tezos address format")
+			return
+		}
+		params = p
+	case core.JobTypeReplicate:
+		var p core.ReplicateParams
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				WriteBadRequest(w, "invalid params: "+err.Error())
+				return
+			}
+		}
+		if peer, err := h.db.GetReplicationPeer(p.PeerID); err != nil || peer == nil {
+			WriteBadRequest(w, "unknown replication peer")
+			return
+		}
+		params = p
+	case core.JobTypeRemovePeer:
+		var p core.RemovePeerParams
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				WriteBadRequest(w, "invalid params: "+err.Error())
+				return
+			}
+		}
+		if peer, err := h.db.GetReplicationPeer(p.PeerID); err != nil || peer == nil {
+			WriteBadRequest(w, "unknown replication peer")
+			return
+		}
+		params = p
+	case core.JobTypeMirror:
+		var p core.MirrorParams
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				WriteBadRequest(w, "invalid params: "+err.Error())
+				return
+			}
+		}
+		if svc, err := h.db.GetPinningService(p.ServiceID); err != nil || svc == nil {
+			WriteBadRequest(w, "unknown pinning service")
+			return
+		}
+		params = p
+	case core.JobTypeRemoveMirror:
+		var p core.RemoveMirrorParams
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				WriteBadRequest(w, "invalid params: "+err.Error())
+				return
+			}
+		}
+		if svc, err := h.db.GetPinningService(p.ServiceID); err != nil || svc == nil {
+			WriteBadRequest(w, "unknown pinning service")
+			return
+		}
+		params = p
+	default:
+		WriteBadRequest(w, "unknown job type: "+req.Type)
+		return
+	}
+
+	job, err := h.service.SubmitJob(req.Type, params)
+	if err != nil {
+		WriteInternalError(w, "failed to start job: "+err.Error())
+		return
+	}
+
+	WriteAccepted(w, job)
 }
 
-// RunGC runs IPFS garbage collection
-// POST /api/v1/gc
-func (h *Handlers) RunGC(w http.ResponseWriter, r *http.Request) {
-	if h.ipfs == nil {
-		WriteServiceUnavailable(w, "IPFS node not available")
+// CancelJob cancels a running or pending job
+// POST /api/v1/jobs/{id}/cancel
+func (h *Handlers) CancelJob(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		WriteServiceUnavailable(w, "backup service not available")
 		return
 	}
 
-	// Run GC in background
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
-		defer cancel()
-		h.ipfs.GarbageCollect(ctx)
-	}()
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		WriteBadRequest(w, "invalid job ID")
+		return
+	}
 
-	WriteAccepted(w, map[string]string{
-		"message": "garbage collection started",
-	})
+	if err := h.service.Jobs().Cancel(id); err != nil {
+		if errors.Is(err, core.ErrJobNotFound) {
+			WriteNotFound(w, "job not found")
+			return
+		}
+		WriteConflict(w, err.Error())
+		return
+	}
+
+	job, _ := h.service.Jobs().Get(id)
+	WriteJSON(w, http.StatusOK, job)
 }
 
-// DiscoverServers scans for Porcupin servers on the local network via mDNS
-// GET /api/v1/discover
-func (h *Handlers) DiscoverServers(w http.ResponseWriter, r *http.Request) {
-	// Parse timeout from query params (default 5 seconds)
-	timeoutStr := r.URL.Query().Get("timeout")
-	timeout := 5 * time.Second
-	if timeoutStr != "" {
-		if t, err := strconv.Atoi(timeoutStr); err == nil && t > 0 && t <= 30 {
-			timeout = time.Duration(t) * time.Second
+// ResumeJob restarts a failed, cancelled or interrupted job from its checkpoint
+// POST /api/v1/jobs/{id}/resume
+func (h *Handlers) ResumeJob(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		WriteServiceUnavailable(w, "backup service not available")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		WriteBadRequest(w, "invalid job ID")
+		return
+	}
+
+	job, err := h.service.ResumeJob(id)
+	if err != nil {
+		if errors.Is(err, core.ErrJobNotFound) {
+			WriteNotFound(w, "job not found")
+			return
 		}
+		WriteConflict(w, err.Error())
+		return
 	}
 
-	servers, err := DiscoverServers(r.Context(), timeout)
+	WriteAccepted(w, job)
+}
+
+// =============================================================================
+// Replication Endpoints
+// =============================================================================
+
+// GetCatalog returns this node's pinned content for peers replicating it
+// GET /api/v1/replication/catalog?since=RFC3339&offset=N&limit=N
+func (h *Handlers) GetCatalog(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var since *time.Time
+	if v := q.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			WriteBadRequest(w, "invalid since (expected RFC3339 timestamp)")
+			return
+		}
+		since = &t
+	}
+
+	offset := 0
+	if v := q.Get("offset"); v != "" {
+		o, err := strconv.Atoi(v)
+		if err != nil || o < 0 {
+			WriteBadRequest(w, "invalid offset")
+			return
+		}
+		offset = o
+	}
+
+	limit := core.MaxCatalogPageSize
+	if v := q.Get("limit"); v != "" {
+		if l, err := strconv.Atoi(v); err == nil && l > 0 && l <= core.MaxCatalogPageSize {
+			limit = l
+		}
+	}
+
+	page, err := core.BuildCatalogPage(h.db, since, offset, limit)
 	if err != nil {
-		WriteInternalError(w, "mDNS discovery failed: "+err.Error())
+		WriteInternalError(w, "failed to read catalog: "+err.Error())
 		return
 	}
+	if h.ipfs != nil {
+		page.NodeID = h.ipfs.PeerID()
+		page.NodeAddrs = h.ipfs.PeerAddrs()
+		page.SwarmPort = h.ipfs.GetSwarmPort()
+	}
 
-	WriteJSON(w, http.StatusOK, servers)
+	WriteJSON(w, http.StatusOK, page)
+}
+
+// AddReplicationPeerRequest is the request body for subscribing to a peer
+type AddReplicationPeerRequest struct {
+	Name   string `json:"name,omitempty"`
+	Host   string `json:"host"`
+	Port   int    `json:"port"`
+	Token  string `json:"token"`
+	UseTLS bool   `json:"use_tls"`
+}
+
+// GetReplicationPeers lists replication peers with their lag and divergence
+// GET /api/v1/replication/peers
+func (h *Handlers) GetReplicationPeers(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		WriteServiceUnavailable(w, "backup service not available")
+		return
+	}
+
+	statuses, err := h.service.Replication().Statuses()
+	if err != nil {
+		WriteInternalError(w, "failed to get replication status: "+err.Error())
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, statuses)
+}
+
+// AddReplicationPeer subscribes to another node's catalog and starts the first sync
+// POST /api/v1/replication/peers
+func (h *Handlers) AddReplicationPeer(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		WriteServiceUnavailable(w, "backup service not available")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxRequestBodySize)
+
+	var req AddReplicationPeerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteBadRequest(w, "invalid JSON: "+err.Error())
+		return
+	}
+
+	if req.Host == "" {
+		WriteBadRequest(w, "host is required")
+		return
+	}
+	if req.Port <= 0 || req.Port > 65535 {
+		WriteBadRequest(w, "port must be between 1 and 65535")
+		return
+	}
+	if req.Token == "" {
+		WriteBadRequest(w, "token is required")
+		return
+	}
+
+	peer := &db.ReplicationPeer{
+		Name:    req.Name,
+		Host:    req.Host,
+		Port:    req.Port,
+		Token:   req.Token,
+		UseTLS:  req.UseTLS,
+		Enabled: true,
+	}
+	if err := h.db.SaveReplicationPeer(peer); err != nil {
+		WriteInternalError(w, "failed to save peer: "+err.Error())
+		return
+	}
+
+	if _, err := h.service.SubmitJob(core.JobTypeReplicate, core.ReplicateParams{PeerID: peer.ID, Full: true}); err != nil {
+		// The periodic sync picks it up later
+		log.Printf("Failed to start initial replication of %s: %v", peer.Host, err)
+	}
+
+	status, err := h.service.Replication().Status(peer.ID)
+	if err != nil {
+		WriteInternalError(w, "failed to get replication status: "+err.Error())
+		return
+	}
+
+	WriteCreated(w, status)
+}
+
+// parsePeerID reads and validates the {id} URL parameter of a replication peer
+func (h *Handlers) parsePeerID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	if h.service == nil {
+		WriteServiceUnavailable(w, "backup service not available")
+		return 0, false
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		WriteBadRequest(w, "invalid peer ID")
+		return 0, false
+	}
+
+	peer, err := h.db.GetReplicationPeer(id)
+	if err != nil {
+		WriteInternalError(w, "database error: "+err.Error())
+		return 0, false
+	}
+	if peer == nil {
+		WriteNotFound(w, "replication peer not found")
+		return 0, false
+	}
+	return id, true
+}
+
+// GetReplicationPeer returns a peer's replication lag and divergence
+// GET /api/v1/replication/peers/{id}
+func (h *Handlers) GetReplicationPeer(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parsePeerID(w, r)
+	if !ok {
+		return
+	}
+
+	status, err := h.service.Replication().Status(id)
+	if err != nil {
+		WriteInternalError(w, "failed to get replication status: "+err.Error())
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, status)
+}
+
+// SyncReplicationPeer starts a sync with a peer as a background job
+// POST /api/v1/replication/peers/{id}/sync?full=true
+func (h *Handlers) SyncReplicationPeer(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parsePeerID(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := h.service.SubmitJob(core.JobTypeReplicate, core.ReplicateParams{
+		PeerID: id,
+		Full:   r.URL.Query().Get("full") == "true",
+	})
+	if err != nil {
+		WriteInternalError(w, "failed to start replication: "+err.Error())
+		return
+	}
+
+	WriteAccepted(w, job)
+}
+
+// DeleteReplicationPeer stops replicating a peer
+// DELETE /api/v1/replication/peers/{id}
+// Query params: unpin=true to also unpin what was replicated from it
+func (h *Handlers) DeleteReplicationPeer(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parsePeerID(w, r)
+	if !ok {
+		return
+	}
+
+	if r.URL.Query().Get("unpin") == "true" {
+		job, err := h.service.SubmitJob(core.JobTypeRemovePeer, core.RemovePeerParams{PeerID: id, Unpin: true})
+		if err != nil {
+			WriteInternalError(w, "failed to start remove job: "+err.Error())
+			return
+		}
+		WriteAccepted(w, job)
+		return
+	}
+
+	if err := h.service.Replication().RemovePeer(r.Context(), id, false, nil); err != nil {
+		WriteInternalError(w, "failed to remove peer: "+err.Error())
+		return
+	}
+
+	WriteNoContent(w)
+}
+
+// =============================================================================
+// Pinning Service Endpoints
+// =============================================================================
+
+// AddPinningServiceRequest is the request body for mirroring to a pinning service
+type AddPinningServiceRequest struct {
+	Name     string   `json:"name"`
+	Endpoint string   `json:"endpoint"`
+	Token    string   `json:"token"`
+	Wallets  []string `json:"wallets,omitempty"` // Empty mirrors every wallet
+}
+
+// UpdatePinningServiceRequest is the request body for updating a pinning service
+type UpdatePinningServiceRequest struct {
+	Name    *string   `json:"name,omitempty"`
+	Token   *string   `json:"token,omitempty"`
+	Wallets *[]string `json:"wallets,omitempty"`
+	Enabled *bool     `json:"enabled,omitempty"`
+}
+
+// RemotePinsListResponse is the paginated response for a service's remote pins
+type RemotePinsListResponse struct {
+	Pins  []db.RemotePin `json:"pins"`
+	Total int64          `json:"total"`
+	Page  int            `json:"page"`
+	Limit int            `json:"limit"`
+}
+
+// validateMirrorWallets checks that every mirrored wallet address is well formed
+func validateMirrorWallets(wallets []string) string {
+	for _, address := range wallets {
+		if !IsValidTezosAddress(address) {
+			return "invalid wallet address: " + address
+		}
+	}
+	return ""
+}
+
+// GetPinningServices lists pinning services with their remote pin counts
+// GET /api/v1/pinning/services
+func (h *Handlers) GetPinningServices(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		WriteServiceUnavailable(w, "backup service not available")
+		return
+	}
+
+	statuses, err := h.service.Mirroring().Statuses()
+	if err != nil {
+		WriteInternalError(w, "failed to get pinning services: "+err.Error())
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, statuses)
+}
+
+// AddPinningService starts mirroring pinned assets to a pinning service
+// POST /api/v1/pinning/services
+func (h *Handlers) AddPinningService(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		WriteServiceUnavailable(w, "backup service not available")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxRequestBodySize)
+
+	var req AddPinningServiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteBadRequest(w, "invalid JSON: "+err.Error())
+		return
+	}
+
+	if req.Name == "" {
+		WriteBadRequest(w, "name is required")
+		return
+	}
+	if err := pinning.ValidateEndpoint(req.Endpoint); err != nil {
+		WriteBadRequest(w, err.Error())
+		return
+	}
+	if req.Token == "" {
+		WriteBadRequest(w, "token is required")
+		return
+	}
+	if msg := validateMirrorWallets(req.Wallets); msg != "" {
+		WriteBadRequest(w, msg)
+		return
+	}
+
+	svc := &db.PinningService{
+		Name:     req.Name,
+		Endpoint: req.Endpoint,
+		Token:    req.Token,
+		Enabled:  true,
+	}
+	svc.SetWalletList(req.Wallets)
+	if err := h.db.SavePinningService(svc); err != nil {
+		WriteInternalError(w, "failed to save pinning service: "+err.Error())
+		return
+	}
+
+	if _, err := h.service.SubmitJob(core.JobTypeMirror, core.MirrorParams{ServiceID: svc.ID}); err != nil {
+		// The periodic reconcile picks it up later
+		log.Printf("Failed to start mirroring to %s: %v", svc.Name, err)
+	}
+
+	status, err := h.service.Mirroring().Status(svc.ID)
+	if err != nil {
+		WriteInternalError(w, "failed to get pinning service: "+err.Error())
+		return
+	}
+
+	WriteCreated(w, status)
+}
+
+// parseServiceID reads the {id} URL parameter and loads the pinning service
+func (h *Handlers) parseServiceID(w http.ResponseWriter, r *http.Request) (*db.PinningService, bool) {
+	if h.service == nil {
+		WriteServiceUnavailable(w, "backup service not available")
+		return nil, false
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		WriteBadRequest(w, "invalid service ID")
+		return nil, false
+	}
+
+	svc, err := h.db.GetPinningService(id)
+	if err != nil {
+		WriteInternalError(w, "database error: "+err.Error())
+		return nil, false
+	}
+	if svc == nil {
+		WriteNotFound(w, "pinning service not found")
+		return nil, false
+	}
+	return svc, true
+}
+
+// GetPinningService returns a pinning service with its remote pin counts
+// GET /api/v1/pinning/services/{id}
+func (h *Handlers) GetPinningService(w http.ResponseWriter, r *http.Request) {
+	svc, ok := h.parseServiceID(w, r)
+	if !ok {
+		return
+	}
+
+	status, err := h.service.Mirroring().Status(svc.ID)
+	if err != nil {
+		WriteInternalError(w, "failed to get pinning service: "+err.Error())
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, status)
+}
+
+// UpdatePinningService changes a pinning service's name, token, wallets or enabled state
+// PUT /api/v1/pinning/services/{id}
+func (h *Handlers) UpdatePinningService(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxRequestBodySize)
+
+	svc, ok := h.parseServiceID(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdatePinningServiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteBadRequest(w, "invalid JSON: "+err.Error())
+		return
+	}
+
+	if req.Name != nil {
+		if *req.Name == "" {
+			WriteBadRequest(w, "name cannot be empty")
+			return
+		}
+		svc.Name = *req.Name
+	}
+	if req.Token != nil {
+		if *req.Token == "" {
+			WriteBadRequest(w, "token cannot be empty")
+			return
+		}
+		svc.Token = *req.Token
+	}
+	if req.Wallets != nil {
+		if msg := validateMirrorWallets(*req.Wallets); msg != "" {
+			WriteBadRequest(w, msg)
+			return
+		}
+		svc.SetWalletList(*req.Wallets)
+	}
+	if req.Enabled != nil {
+		svc.Enabled = *req.Enabled
+	}
+
+	if err := h.db.SavePinningService(svc); err != nil {
+		WriteInternalError(w, "failed to save pinning service: "+err.Error())
+		return
+	}
+
+	status, err := h.service.Mirroring().Status(svc.ID)
+	if err != nil {
+		WriteInternalError(w, "failed to get pinning service: "+err.Error())
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, status)
+}
+
+// ReconcilePinningService compares a pinning service with the local pins as a background job
+// POST /api/v1/pinning/services/{id}/reconcile
+func (h *Handlers) ReconcilePinningService(w http.ResponseWriter, r *http.Request) {
+	svc, ok := h.parseServiceID(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := h.service.SubmitJob(core.JobTypeMirror, core.MirrorParams{ServiceID: svc.ID})
+	if err != nil {
+		WriteInternalError(w, "failed to start reconcile: "+err.Error())
+		return
+	}
+
+	WriteAccepted(w, job)
+}
+
+// GetPinningServicePins returns a page of the CIDs mirrored to a pinning service
+// GET /api/v1/pinning/services/{id}/pins?page=N&limit=N&status=X
+func (h *Handlers) GetPinningServicePins(w http.ResponseWriter, r *http.Request) {
+	svc, ok := h.parseServiceID(w, r)
+	if !ok {
+		return
+	}
+
+	page := 1
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	limit := 50
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 500 {
+		limit = l
+	}
+
+	pins, total, err := h.db.ListRemotePins(svc.ID, r.URL.Query().Get("status"), (page-1)*limit, limit)
+	if err != nil {
+		WriteInternalError(w, "failed to get remote pins: "+err.Error())
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, RemotePinsListResponse{
+		Pins:  pins,
+		Total: total,
+		Page:  page,
+		Limit: limit,
+	})
+}
+
+// DeletePinningService stops mirroring to a pinning service
+// DELETE /api/v1/pinning/services/{id}
+// Query params: unpin=true to also remove the pins at the service
+func (h *Handlers) DeletePinningService(w http.ResponseWriter, r *http.Request) {
+	svc, ok := h.parseServiceID(w, r)
+	if !ok {
+		return
+	}
+
+	if r.URL.Query().Get("unpin") == "true" {
+		job, err := h.service.SubmitJob(core.JobTypeRemoveMirror, core.RemoveMirrorParams{ServiceID: svc.ID, Unpin: true})
+		if err != nil {
+			WriteInternalError(w, "failed to start remove job: "+err.Error())
+			return
+		}
+		WriteAccepted(w, job)
+		return
+	}
+
+	if err := h.service.Mirroring().RemoveService(r.Context(), svc.ID, false, nil); err != nil {
+		if errors.Is(err, core.ErrMirrorInProgress) {
+			WriteConflict(w, err.Error())
+			return
+		}
+		WriteInternalError(w, "failed to remove pinning service: "+err.Error())
+		return
+	}
+
+	WriteNoContent(w)
+}
+
+// GetAssetRemotePins returns the status of an asset's CID at every pinning service
+// GET /api/v1/assets/{id}/remote-pins
+func (h *Handlers) GetAssetRemotePins(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		WriteBadRequest(w, "invalid asset ID")
+		return
+	}
+
+	var asset db.Asset
+	if err := h.db.First(&asset, id).Error; err != nil {
+		WriteNotFound(w, "asset not found")
+		return
+	}
+
+	pins := []db.RemotePin{}
+	if cid := core.ExtractCIDFromURI(asset.URI); cid != "" {
+		if pins, err = h.db.GetRemotePinsByCID(cid); err != nil {
+			WriteInternalError(w, "failed to get remote pins: "+err.Error())
+			return
+		}
+	}
+
+	WriteJSON(w, http.StatusOK, pins)
+}
+
+// =============================================================================
+// Storage Migration Endpoints
+// =============================================================================
+
+// MigrateStorageRequest is the request body for moving the IPFS repository
+type MigrateStorageRequest struct {
+	DestPath string `json:"dest_path"`
+}
+
+// migrationEventInterval is how often the migration event stream checks for progress
+const migrationEventInterval = 500 * time.Millisecond
+
+// GetStorageMigration returns the progress of the current or last storage migration
+// GET /api/v1/storage/migration
+func (h *Handlers) GetStorageMigration(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		WriteServiceUnavailable(w, "backup service not available")
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, h.service.StorageMigrationStatus())
+}
+
+// StartStorageMigration moves the IPFS repository to a new location. The
+// service and IPFS node are stopped while the data moves and restarted at the
+// new location.
+// POST /api/v1/storage/migration
+func (h *Handlers) StartStorageMigration(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		WriteServiceUnavailable(w, "backup service not available")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxRequestBodySize)
+
+	var req MigrateStorageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteBadRequest(w, "invalid JSON: "+err.Error())
+		return
+	}
+	if req.DestPath == "" {
+		WriteBadRequest(w, "dest_path is required")
+		return
+	}
+
+	if err := h.service.StartStorageMigration(req.DestPath); err != nil {
+		switch {
+		case errors.Is(err, core.ErrStorageMigrationUnavailable):
+			WriteServiceUnavailable(w, err.Error())
+		case errors.Is(err, core.ErrStorageMigrationInProgress):
+			WriteConflict(w, err.Error())
+		default:
+			WriteBadRequest(w, err.Error())
+		}
+		return
+	}
+
+	WriteAccepted(w, h.service.StorageMigrationStatus())
+}
+
+// CancelStorageMigration stops a storage migration. The copy made so far is
+// kept, so starting a migration to the same location again resumes it.
+// POST /api/v1/storage/migration/cancel
+func (h *Handlers) CancelStorageMigration(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		WriteServiceUnavailable(w, "backup service not available")
+		return
+	}
+
+	if err := h.service.CancelStorageMigration(); err != nil {
+		WriteConflict(w, err.Error())
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, h.service.StorageMigrationStatus())
+}
+
+// GetStorageMigrationEvents streams migration progress as server-sent events,
+// one "progress" event per change, until the migration ends
+// GET /api/v1/storage/migration/events
+func (h *Handlers) GetStorageMigrationEvents(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		WriteServiceUnavailable(w, "backup service not available")
+		return
+	}
+
+	// A migration outlasts the server's write timeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(migrationEventInterval)
+	defer ticker.Stop()
+
+	var last []byte
+	for {
+		status := h.service.StorageMigrationStatus()
+		data, err := json.Marshal(status)
+		if err != nil {
+			return
+		}
+		if !bytes.Equal(data, last) {
+			last = data
+			fmt.Fprintf(w, "event: progress\ndata: %s\n\n", data)
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+		if !status.InProgress {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// =============================================================================
+// Control Endpoints
+// =============================================================================
+
+// TriggerSync triggers a full sync operation
+// POST /api/v1/sync
+func (h *Handlers) TriggerSync(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		WriteServiceUnavailable(w, "backup service not available")
+		return
+	}
+
+	h.service.TriggerFullSync()
+
+	WriteAccepted(w, map[string]string{
+		"message": "full sync triggered",
+	})
+}
+
+// PauseService pauses the backup service
+// POST /api/v1/pause
+func (h *Handlers) PauseService(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		WriteServiceUnavailable(w, "backup service not available")
+		return
+	}
+
+	h.service.Pause()
+	WriteJSON(w, http.StatusOK, map[string]string{"status": "paused"})
+}
+
+// ResumeService resumes the backup service
+// POST /api/v1/resume
+func (h *Handlers) ResumeService(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		WriteServiceUnavailable(w, "backup service not available")
+		return
+	}
+
+	h.service.Resume()
+	WriteJSON(w, http.StatusOK, map[string]string{"status": "resumed"})
+}
+
+// RunGC runs IPFS garbage collection
+// POST /api/v1/gc
+func (h *Handlers) RunGC(w http.ResponseWriter, r *http.Request) {
+	if h.ipfs == nil {
+		WriteServiceUnavailable(w, "IPFS node not available")
+		return
+	}
+
+	// Run GC in background
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		defer cancel()
+		h.ipfs.GarbageCollect(ctx)
+	}()
+
+	WriteAccepted(w, map[string]string{
+		"message": "garbage collection started",
+	})
+}
+
+// DiscoverServers scans for Porcupin servers on the local network via mDNS
+// GET /api/v1/discover
+func (h *Handlers) DiscoverServers(w http.ResponseWriter, r *http.Request) {
+	// Parse timeout from query params (default 5 seconds)
+	timeoutStr := r.URL.Query().Get("timeout")
+	timeout := 5 * time.Second
+	if timeoutStr != "" {
+		if t, err := strconv.Atoi(timeoutStr); err == nil && t > 0 && t <= 30 {
+			timeout = time.Duration(t) * time.Second
+		}
+	}
+
+	servers, err := DiscoverServers(r.Context(), timeout)
+	if err != nil {
+		WriteInternalError(w, "mDNS discovery failed: "+err.Error())
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, servers)
+}
+
+// =============================================================================
+// Pairing Endpoints
+// =============================================================================
+
+// OpenPairingRequest is the request body for opening a pairing window
+type OpenPairingRequest struct {
+	WindowSeconds int `json:"window_seconds,omitempty"` // Default: DefaultPairingWindow
+}
+
+// PairingResponse is an open pairing window: the code to enter in the
+// client, or the URL that carries it along with the server's address
+type PairingResponse struct {
+	Code        string    `json:"code"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	URL         string    `json:"url"`
+	Fingerprint string    `json:"fingerprint,omitempty"`
+}
+
+// CompletePairingRequest is the request body a client pairs with
+type CompletePairingRequest struct {
+	Code string `json:"code"`
+	Name string `json:"name"` // Shown in the token list, e.g. the client's hostname
+}
+
+// PairResponse is the token issued to a paired client
+type PairResponse struct {
+	ID    uint64 `json:"id"`
+	Name  string `json:"name"`
+	Token string `json:"token"`
+}
+
+// maxTokenNameLength bounds the name a client gives its token
+const maxTokenNameLength = 64
+
+// OpenPairing starts a pairing window, replacing any open one
+// POST /api/v1/pairing
+func (h *Handlers) OpenPairing(w http.ResponseWriter, r *http.Request) {
+	if !h.endpoint.useTLS {
+		WriteConflict(w, "pairing needs TLS, as the token it issues would be sent in cleartext")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxRequestBodySize)
+
+	var req OpenPairingRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteBadRequest(w, "invalid JSON: "+err.Error())
+			return
+		}
+	}
+	window := DefaultPairingWindow
+	if req.WindowSeconds != 0 {
+		window = time.Duration(req.WindowSeconds) * time.Second
+		if window < 0 || window > MaxPairingWindow {
+			WriteBadRequest(w, fmt.Sprintf("window_seconds must be between 1 and %d", int(MaxPairingWindow.Seconds())))
+			return
+		}
+	}
+
+	code, expires, err := h.pairing.Open(window)
+	if err != nil {
+		WriteInternalError(w, err.Error())
+		return
+	}
+	host := h.endpoint.host
+	if host == "" {
+		host = pairingHost()
+	}
+	invite := PairingInvite{
+		Host:        host,
+		Port:        h.endpoint.port,
+		Code:        code,
+		Fingerprint: h.endpoint.fingerprint,
+	}
+	log.Printf("Pairing: window open until %s", expires.Format(time.RFC3339))
+
+	WriteJSON(w, http.StatusOK, PairingResponse{
+		Code:        FormatPairingCode(code),
+		ExpiresAt:   expires,
+		URL:         invite.URL(),
+		Fingerprint: h.endpoint.fingerprint,
+	})
+}
+
+// ClosePairing ends the pairing window
+// DELETE /api/v1/pairing
+func (h *Handlers) ClosePairing(w http.ResponseWriter, r *http.Request) {
+	h.pairing.Close()
+	WriteNoContent(w)
+}
+
+// CompletePairing exchanges a pairing code for a new named token. It needs
+// no token itself.
+// POST /api/v1/pairing/complete
+func (h *Handlers) CompletePairing(w http.ResponseWriter, r *http.Request) {
+	if r.TLS == nil {
+		WriteForbidden(w, "pairing needs TLS")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxRequestBodySize)
+
+	var req CompletePairingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteBadRequest(w, "invalid JSON: "+err.Error())
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		WriteBadRequest(w, "name is required")
+		return
+	}
+	if len(req.Name) > maxTokenNameLength {
+		WriteBadRequest(w, fmt.Sprintf("name must be at most %d characters", maxTokenNameLength))
+		return
+	}
+
+	if err := h.pairing.Redeem(req.Code); err != nil {
+		log.Printf("Pairing: rejected %q from %s: %v", req.Name, getClientIP(r), err)
+		if errors.Is(err, ErrPairingClosed) {
+			WriteForbidden(w, err.Error())
+		} else {
+			WriteUnauthorized(w, err.Error())
+		}
+		return
+	}
+
+	token, record, err := IssueAPIToken(h.db, req.Name)
+	if err != nil {
+		WriteInternalError(w, err.Error())
+		return
+	}
+	log.Printf("Pairing: issued token %d to %q from %s", record.ID, record.Name, getClientIP(r))
+
+	WriteCreated(w, PairResponse{ID: record.ID, Name: record.Name, Token: token})
+}
+
+// GetAPITokens lists the tokens issued by pairing
+// GET /api/v1/tokens
+func (h *Handlers) GetAPITokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.db.GetAPITokens()
+	if err != nil {
+		WriteInternalError(w, "failed to get tokens: "+err.Error())
+		return
+	}
+	WriteJSON(w, http.StatusOK, tokens)
+}
+
+// RevokeAPIToken deletes an issued token; clients using it are rejected
+// from their next request
+// DELETE /api/v1/tokens/{id}
+func (h *Handlers) RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		WriteBadRequest(w, "invalid token ID")
+		return
+	}
+
+	deleted, err := h.db.DeleteAPIToken(id)
+	if err != nil {
+		WriteInternalError(w, "failed to revoke token: "+err.Error())
+		return
+	}
+	if !deleted {
+		WriteNotFound(w, "token not found")
+		return
+	}
+	log.Printf("Pairing: revoked token %d", id)
+
+	WriteNoContent(w)
 }
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// LocalEndpointFileName is the file in the data directory telling commands
+// on the same host how to reach the running server
+const LocalEndpointFileName = ".api-endpoint"
+
+// LocalEndpoint is written by a running server so the CLI on the same host
+// can send commands through it instead of opening the database and IPFS
+// repository the server holds. The token is generated for each run and
+// accepted alongside the configured API token; the file is readable by its
+// owner only.
+type LocalEndpoint struct {
+	URL         string `json:"url"`
+	Token       string `json:"token"`
+	PID         int    `json:"pid"`
+	Fingerprint string `json:"fingerprint,omitempty"` // SHA-256 of the TLS certificate, hex
+	ServerName  string `json:"server_name,omitempty"` // Name a CA-signed certificate is checked for instead
+}
+
+// ReadLocalEndpoint reads the endpoint file of a data directory. It returns
+// nil if no server has written one.
+func ReadLocalEndpoint(dataDir string) (*LocalEndpoint, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, LocalEndpointFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read endpoint file: %w", err)
+	}
+	var ep LocalEndpoint
+	if err := json.Unmarshal(data, &ep); err != nil {
+		return nil, fmt.Errorf("invalid endpoint file: %w", err)
+	}
+	return &ep, nil
+}
+
+// writeLocalEndpoint writes the endpoint file, replacing any left by a
+// server that didn't shut down cleanly
+func writeLocalEndpoint(dataDir string, ep *LocalEndpoint) error {
+	data, err := json.MarshalIndent(ep, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dataDir, LocalEndpointFileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, TokenFileMode); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// removeLocalEndpoint removes the endpoint file if it is still ours
+func removeLocalEndpoint(dataDir string, ep *LocalEndpoint) {
+	current, err := ReadLocalEndpoint(dataDir)
+	if err != nil || current == nil || current.Token != ep.Token {
+		return
+	}
+	os.Remove(filepath.Join(dataDir, LocalEndpointFileName))
+}
+
+// localEndpointURL is the URL a local client reaches the listener at.
+// Wildcard addresses are reached over loopback.
+func localEndpointURL(listenAddr string, useTLS bool) string {
+	host, port, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		host, port = "127.0.0.1", strconv.Itoa(DefaultServerConfig().Port)
+	}
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+	protocol := "http"
+	if useTLS {
+		protocol = "https"
+	}
+	return protocol + "://" + net.JoinHostPort(host, port)
+}
+
+// NewLocalClient creates a client for the server that wrote ep. Over TLS
+// the server's certificate must match the fingerprint in the file rather
+// than a CA, since the certificate rarely names the loopback address. A
+// certificate renewed through ACME is checked for its domain instead.
+func NewLocalClient(ep *LocalEndpoint) *RemoteClient {
+	client := &RemoteClient{
+		baseURL:    ep.URL,
+		token:      ep.Token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	switch {
+	case ep.Fingerprint != "":
+		client.httpClient.Transport = &http.Transport{TLSClientConfig: pinnedTLSConfig(ep.Fingerprint)}
+	case ep.ServerName != "":
+		client.httpClient.Transport = &http.Transport{TLSClientConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			ServerName: ep.ServerName,
+		}}
+	}
+	return client
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"net"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/grandcat/zeroconf"
@@ -26,20 +27,27 @@ type MDNSServer struct {
 	version  string
 	useTLS   bool
 	hostname string
+
+	// fingerprint is the SHA-256 of the server's TLS certificate, announced
+	// so clients can pin it on first use
+	fingerprint string
 }
 
-// NewMDNSServer creates a new mDNS server for service announcement
-func NewMDNSServer(port int, version string, useTLS bool) *MDNSServer {
+// NewMDNSServer creates a new mDNS server for service announcement. The
+// fingerprint of a self-signed certificate is announced for clients to pin;
+// leave it empty for a CA-signed one.
+func NewMDNSServer(port int, version string, useTLS bool, fingerprint string) *MDNSServer {
 	hostname, _ := os.Hostname()
 	if hostname == "" {
 		hostname = "porcupin"
 	}
 
 	return &MDNSServer{
-		port:     port,
-		version:  version,
-		useTLS:   useTLS,
-		hostname: hostname,
+		port:        port,
+		version:     version,
+		useTLS:      useTLS,
+		hostname:    hostname,
+		fingerprint: fingerprint,
 	}
 }
 
@@ -50,6 +58,9 @@ func (m *MDNSServer) Start() error {
 		fmt.Sprintf("version=%s", m.version),
 		fmt.Sprintf("tls=%v", m.useTLS),
 	}
+	if m.fingerprint != "" {
+		txt = append(txt, "fingerprint="+m.fingerprint)
+	}
 
 	// Create instance name (hostname-porcupin)
 	instanceName := fmt.Sprintf("%s-porcupin", m.hostname)
@@ -102,6 +113,10 @@ type DiscoveredServer struct {
 	Version  string   `json:"version"`
 	UseTLS   bool     `json:"useTLS"`
 	IPs      []string `json:"ips"`
+
+	// Fingerprint is the SHA-256 of the server's TLS certificate, if it
+	// announced one
+	Fingerprint string `json:"fingerprint,omitempty"`
 }
 
 // DiscoverServers scans for Porcupin servers on the local network via mDNS
@@ -213,6 +228,9 @@ func parseServiceEntry(entry *zeroconf.ServiceEntry) *DiscoveredServer {
 		if txt == "tls=true" {
 			server.UseTLS = true
 		}
+		if fp, ok := strings.CutPrefix(txt, "fingerprint="); ok {
+			server.Fingerprint = NormalizeFingerprint(fp)
+		}
 	}
 
 	// Collect IP addresses
@@ -9,7 +9,7 @@ import (
 
 // TestNewMDNSServer verifies constructor sets fields correctly
 func TestNewMDNSServer(t *testing.T) {
-	server := NewMDNSServer(8085, "1.2.3", true)
+	server := NewMDNSServer(8085, "1.2.3", true, "ab12")
 
 	if server.port != 8085 {
 		t.Errorf("expected port 8085, got %d", server.port)
@@ -23,6 +23,10 @@ func TestNewMDNSServer(t *testing.T) {
 		t.Errorf("expected useTLS true, got %v", server.useTLS)
 	}
 
+	if server.fingerprint != "ab12" {
+		t.Errorf("expected fingerprint 'ab12', got %q", server.fingerprint)
+	}
+
 	if server.hostname == "" {
 		t.Error("expected hostname to be set")
 	}
@@ -128,6 +132,24 @@ func TestParseServiceEntryNoTLS(t *testing.T) {
 	}
 }
 
+// TestParseServiceEntryFingerprint verifies the certificate fingerprint is read
+func TestParseServiceEntryFingerprint(t *testing.T) {
+	entry := &zeroconf.ServiceEntry{
+		ServiceRecord: zeroconf.ServiceRecord{
+			Instance: "server",
+		},
+		Port:     8085,
+		Text:     []string{"version=1.0.0", "tls=true", "fingerprint=AB:CD:EF"},
+		AddrIPv4: []net.IP{net.ParseIP("10.0.0.1")},
+	}
+
+	result := parseServiceEntry(entry)
+
+	if !result.UseTLS || result.Fingerprint != "abcdef" {
+		t.Errorf("expected TLS with fingerprint 'abcdef', got %v %q", result.UseTLS, result.Fingerprint)
+	}
+}
+
 // TestParseServiceEntryIPv6Only verifies IPv6-only handling
 func TestParseServiceEntryIPv6Only(t *testing.T) {
 	entry := &zeroconf.ServiceEntry{
@@ -176,7 +198,7 @@ func TestGetLocalIPsFiltering(t *testing.T) {
 
 // TestMDNSServerStopSafety verifies Stop doesn't panic
 func TestMDNSServerStopSafety(t *testing.T) {
-	server := NewMDNSServer(19099, "1.0.0", false)
+	server := NewMDNSServer(19099, "1.0.0", false, "")
 
 	// Stop without start - should not panic
 	server.Stop()
@@ -36,15 +36,30 @@ func CORSMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// unauthenticatedPaths are reachable without a token: health for load
+// balancers and monitoring, pairing for clients that don't have a token
+// yet, and the API description for tooling
+var unauthenticatedPaths = map[string]bool{
+	"/api/v1/health":           true,
+	"/api/v1/openapi.json":     true,
+	"/api/v1/pairing/complete": true,
+}
+
 // AuthMiddleware creates middleware that validates API tokens.
 // Health endpoint is exempt from authentication.
 // If plainToken is provided, uses constant-time comparison.
 // If tokenHash is provided (and plainToken is empty), uses bcrypt comparison.
-func AuthMiddleware(plainToken, tokenHash string) func(http.Handler) http.Handler {
+// localTokens are accepted as well (see LocalEndpoint).
+func AuthMiddleware(plainToken, tokenHash string, localTokens ...string) func(http.Handler) http.Handler {
+	return AuthMiddlewareWithIssued(plainToken, tokenHash, nil, localTokens...)
+}
+
+// AuthMiddlewareWithIssued is AuthMiddleware that also accepts the tokens
+// issued reports valid, such as those issued by pairing
+func AuthMiddlewareWithIssued(plainToken, tokenHash string, issued func(token string) bool, localTokens ...string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Health endpoint is always accessible (for load balancers, monitoring)
-			if r.URL.Path == "/api/v1/health" {
+			if unauthenticatedPaths[r.URL.Path] {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -77,6 +92,14 @@ func AuthMiddleware(plainToken, tokenHash string) func(http.Handler) http.Handle
 				// No token configured - deny all
 				valid = false
 			}
+			for _, local := range localTokens {
+				if !valid && local != "" {
+					valid = ValidateToken(providedToken, local)
+				}
+			}
+			if !valid && issued != nil {
+				valid = issued(providedToken)
+			}
 
 			if !valid {
 				// Log failed auth attempts (without the token)
@@ -335,6 +358,11 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // JSONContentTypeMiddleware sets Content-Type to application/json
 func JSONContentTypeMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
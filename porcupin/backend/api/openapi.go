@@ -0,0 +1,598 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"porcupin/backend/core"
+	"porcupin/backend/db"
+	"porcupin/backend/storage"
+)
+
+// The OpenAPI document served at /api/v1/openapi.json. Every route mounted
+// in mountRoutes has an apiOperation here; schemas are built from the Go
+// types the handlers read and write, so they follow the code. The contract
+// test fails when a route is mounted without an operation, or the other way
+// round.
+
+// apiOperation describes one route
+type apiOperation struct {
+	Method    string
+	Path      string // As mounted under /api/v1, e.g. /wallets/{address}
+	Tag       string
+	Summary   string
+	Public    bool // Served without a token
+	Query     []apiParam
+	Request   interface{}   // Zero value of the request body, nil if none
+	TextBody  []string      // Other content types the request body may have, e.g. text/csv
+	Responses []apiResponse // Successful responses
+	Errors    []int         // Error statuses the handler writes
+}
+
+// apiParam is a query parameter
+type apiParam struct {
+	Name        string
+	Type        string // "string", "integer" or "boolean"
+	Description string
+}
+
+// apiResponse is a successful response. Body is the zero value of what is
+// sent, nil for no body.
+type apiResponse struct {
+	Status int
+	Body   interface{}
+	Raw    bool     // Not wrapped in {"data": ...}
+	Stream bool     // Server-sent events carrying Body
+	Text   []string // Other content types Body may be sent as, e.g. text/csv
+	Note   string
+}
+
+func okBody(body interface{}) apiResponse { return apiResponse{Status: http.StatusOK, Body: body} }
+func createdBody(body interface{}) apiResponse {
+	return apiResponse{Status: http.StatusCreated, Body: body}
+}
+func acceptedBody(body interface{}) apiResponse {
+	return apiResponse{Status: http.StatusAccepted, Body: body}
+}
+func noBody(note string) apiResponse { return apiResponse{Status: http.StatusNoContent, Note: note} }
+
+func queryParam(name, typ, description string) apiParam {
+	return apiParam{Name: name, Type: typ, Description: description}
+}
+
+// Bodies that handlers build as maps
+type (
+	// VersionResponse is the server's version
+	VersionResponse struct {
+		Version string `json:"version"`
+	}
+
+	// ServiceStateResponse is the state the backup service was put in
+	ServiceStateResponse struct {
+		Status string `json:"status"` // "paused" or "resumed"
+	}
+
+	// VerifyAndFixResponse is the result of verifying pins, wrapped once
+	// more by the handler
+	VerifyAndFixResponse struct {
+		Data map[string]int `json:"data"`
+		Meta Meta           `json:"meta"`
+	}
+)
+
+var (
+	pageParam   = queryParam("page", "integer", "Page number, from 1")
+	limitParam  = queryParam("limit", "integer", "Items per page")
+	unpinParam  = queryParam("unpin", "boolean", "Also unpin the content, in a background job returned with 202")
+	jobResponse = acceptedBody(db.Job{})
+)
+
+// apiOperations lists every /api/v1 route
+var apiOperations = []apiOperation{
+	// System
+	{Method: "GET", Path: "/health", Tag: "System", Summary: "Health check", Public: true,
+		Responses: []apiResponse{{Status: http.StatusOK, Body: HealthResponse{}, Raw: true}}},
+	{Method: "GET", Path: "/version", Tag: "System", Summary: "Server version",
+		Responses: []apiResponse{okBody(VersionResponse{})}},
+	{Method: "GET", Path: "/status", Tag: "System", Summary: "Backup service status",
+		Responses: []apiResponse{okBody(core.ServiceStatus{})}, Errors: []int{503}},
+	{Method: "GET", Path: "/openapi.json", Tag: "System", Summary: "This document", Public: true,
+		Responses: []apiResponse{{Status: http.StatusOK, Body: map[string]interface{}{}, Raw: true}}},
+
+	// Statistics
+	{Method: "GET", Path: "/stats", Tag: "Statistics", Summary: "Asset counts, storage and usage by wallet",
+		Responses: []apiResponse{okBody(StatsResponse{})}, Errors: []int{500}},
+	{Method: "GET", Path: "/activity", Tag: "Statistics", Summary: "Recently pinned assets",
+		Query:     []apiParam{queryParam("limit", "integer", "Number of items, up to 100")},
+		Responses: []apiResponse{okBody([]ActivityItem{})}},
+
+	// Wallets
+	{Method: "GET", Path: "/wallets", Tag: "Wallets", Summary: "List wallets",
+		Responses: []apiResponse{okBody([]WalletResponse{})}, Errors: []int{500}},
+	{Method: "POST", Path: "/wallets", Tag: "Wallets", Summary: "Add a wallet",
+		Request:   AddWalletRequest{},
+		Responses: []apiResponse{createdBody(WalletResponse{})}, Errors: []int{400, 409, 500}},
+	{Method: "GET", Path: "/wallets/{address}", Tag: "Wallets", Summary: "Get a wallet",
+		Responses: []apiResponse{okBody(WalletResponse{})}, Errors: []int{400, 404, 500}},
+	{Method: "PUT", Path: "/wallets/{address}", Tag: "Wallets", Summary: "Update alias, priority and quota",
+		Request:   UpdateWalletRequest{},
+		Responses: []apiResponse{okBody(WalletResponse{})}, Errors: []int{400, 404, 500}},
+	{Method: "DELETE", Path: "/wallets/{address}", Tag: "Wallets", Summary: "Remove a wallet",
+		Query:     []apiParam{unpinParam},
+		Responses: []apiResponse{noBody("Removed"), jobResponse}, Errors: []int{400, 404, 500}},
+	{Method: "POST", Path: "/wallets/{address}/sync", Tag: "Wallets", Summary: "Sync a wallet",
+		Responses: []apiResponse{acceptedBody(MessageResponse{})}, Errors: []int{400, 404, 500, 503}},
+	{Method: "POST", Path: "/wallets/import", Tag: "Wallets", Summary: "Add the wallets in a CSV or JSON file",
+		Query: []apiParam{queryParam("format", "string", "csv or json; detected from the body if left out"),
+			queryParam("dry_run", "boolean", "Report what would be added without adding anything")},
+		Request: []WalletEntry{}, TextBody: []string{"text/csv"},
+		Responses: []apiResponse{okBody(WalletImportReport{})}, Errors: []int{400, 500}},
+	{Method: "GET", Path: "/wallets/export", Tag: "Wallets", Summary: "Every wallet, as a file the import reads",
+		Query:     []apiParam{queryParam("format", "string", "csv or json (the default)")},
+		Responses: []apiResponse{{Status: http.StatusOK, Body: []WalletEntry{}, Raw: true, Text: []string{"text/csv"}}},
+		Errors:    []int{400, 500}},
+
+	// NFTs and assets
+	{Method: "GET", Path: "/nfts", Tag: "NFTs", Summary: "List NFTs with their assets",
+		Query: []apiParam{pageParam, limitParam,
+			queryParam("search", "string", "Words to find in the name, description, tags, attributes, artist or collection. Results are ranked best first, with the matches highlighted"),
+			queryParam("creator", "string", "Only NFTs minted by this account"),
+			queryParam("contract", "string", "Only NFTs of this collection"),
+			queryParam("tag", "string", "Only NFTs with this tag, ignoring case"),
+			queryParam("attribute", "string", "Only NFTs with this attribute, as name or name:value, ignoring case")},
+		Responses: []apiResponse{okBody(NFTsListResponse{})}, Errors: []int{500}},
+	{Method: "GET", Path: "/nfts/{id}/history", Tag: "NFTs", Summary: "Versions of an NFT's metadata, with what each changed",
+		Responses: []apiResponse{okBody(MetadataHistoryResponse{})}, Errors: []int{400, 404, 500}},
+	{Method: "GET", Path: "/creators", Tag: "NFTs", Summary: "List the artists of the NFTs, most NFTs first",
+		Query:     []apiParam{pageParam, limitParam, queryParam("search", "string", "Match in address, alias or name")},
+		Responses: []apiResponse{okBody(CreatorsListResponse{})}, Errors: []int{500}},
+	{Method: "GET", Path: "/creators/{address}", Tag: "NFTs", Summary: "Get an artist",
+		Responses: []apiResponse{okBody(CreatorResponse{})}, Errors: []int{404, 500}},
+	{Method: "GET", Path: "/collections", Tag: "NFTs", Summary: "List the collections of the NFTs, most NFTs first",
+		Query: []apiParam{pageParam, limitParam, queryParam("search", "string", "Match in address, alias or name"),
+			queryParam("creator", "string", "Only collections this account originated")},
+		Responses: []apiResponse{okBody(CollectionsListResponse{})}, Errors: []int{500}},
+	{Method: "GET", Path: "/collections/{address}", Tag: "NFTs", Summary: "Get a collection",
+		Responses: []apiResponse{okBody(CollectionResponse{})}, Errors: []int{404, 500}},
+	{Method: "GET", Path: "/assets", Tag: "Assets", Summary: "List assets",
+		Query: []apiParam{pageParam, limitParam,
+			queryParam("status", "string", "Statuses separated by commas, or all"),
+			queryParam("search", "string", "Match in URI, type or the NFT's metadata"),
+			queryParam("wallet", "string", "Only assets of this wallet's NFTs"),
+			queryParam("type", "string", "Asset type, e.g. artifact or thumbnail")},
+		Responses: []apiResponse{okBody(AssetsListResponse{})}},
+	{Method: "GET", Path: "/assets/failed", Tag: "Assets", Summary: "List failed assets",
+		Responses: []apiResponse{okBody([]AssetResponse{})}},
+	{Method: "POST", Path: "/assets/retry-failed", Tag: "Assets", Summary: "Retry every failed asset",
+		Responses: []apiResponse{okBody(MessageResponse{})}},
+	{Method: "DELETE", Path: "/assets/failed", Tag: "Assets", Summary: "Remove every failed asset",
+		Responses: []apiResponse{okBody(MessageResponse{})}},
+	{Method: "POST", Path: "/assets/reconcile", Tag: "Assets", Summary: "Compare the IPFS node's pins with the assets and fix what differs",
+		Query:     []apiParam{queryParam("dry_run", "boolean", "Report the differences without fixing them")},
+		Responses: []apiResponse{okBody(core.PinReconcileReport{})}, Errors: []int{500, 503}},
+	{Method: "POST", Path: "/assets/{id}/retry", Tag: "Assets", Summary: "Retry an asset",
+		Responses: []apiResponse{acceptedBody(MessageResponse{})}, Errors: []int{400, 404, 500}},
+	{Method: "POST", Path: "/assets/{id}/pin-next", Tag: "Assets", Summary: "Pin an asset before anything else",
+		Responses: []apiResponse{acceptedBody(MessageResponse{})}, Errors: []int{400, 404, 409, 500, 503}},
+	{Method: "GET", Path: "/assets/{id}/remote-pins", Tag: "Pinning services", Summary: "An asset's status at each pinning service",
+		Responses: []apiResponse{okBody([]db.RemotePin{})}, Errors: []int{400, 404, 500}},
+	{Method: "DELETE", Path: "/assets/{id}", Tag: "Assets", Summary: "Remove an asset",
+		Responses: []apiResponse{okBody(MessageResponse{})}, Errors: []int{400, 404, 500}},
+
+	// Control
+	{Method: "POST", Path: "/sync", Tag: "Control", Summary: "Sync every wallet",
+		Responses: []apiResponse{acceptedBody(MessageResponse{})}, Errors: []int{503}},
+	{Method: "POST", Path: "/pause", Tag: "Control", Summary: "Pause the backup service",
+		Responses: []apiResponse{okBody(ServiceStateResponse{})}, Errors: []int{503}},
+	{Method: "POST", Path: "/resume", Tag: "Control", Summary: "Resume the backup service",
+		Responses: []apiResponse{okBody(ServiceStateResponse{})}, Errors: []int{503}},
+	{Method: "POST", Path: "/gc", Tag: "Control", Summary: "Start IPFS garbage collection",
+		Responses: []apiResponse{acceptedBody(MessageResponse{})}, Errors: []int{503}},
+	{Method: "POST", Path: "/verify-and-fix", Tag: "Control", Summary: "Verify pins and re-pin missing content",
+		Responses: []apiResponse{okBody(VerifyAndFixResponse{})}, Errors: []int{500}},
+	{Method: "GET", Path: "/queue", Tag: "Control", Summary: "Pin queue, per wallet",
+		Responses: []apiResponse{okBody(core.QueueStatus{})}, Errors: []int{503}},
+
+	// Jobs
+	{Method: "GET", Path: "/jobs", Tag: "Jobs", Summary: "List background jobs",
+		Query:     []apiParam{queryParam("status", "string", "Only jobs in this status"), queryParam("limit", "integer", "Number of jobs")},
+		Responses: []apiResponse{okBody(JobsListResponse{})}, Errors: []int{500, 503}},
+	{Method: "POST", Path: "/jobs", Tag: "Jobs", Summary: "Start a job",
+		Request:   SubmitJobRequest{},
+		Responses: []apiResponse{jobResponse}, Errors: []int{400, 500, 503}},
+	{Method: "GET", Path: "/jobs/{id}", Tag: "Jobs", Summary: "Job status and progress",
+		Responses: []apiResponse{okBody(db.Job{})}, Errors: []int{400, 404, 500, 503}},
+	{Method: "POST", Path: "/jobs/{id}/cancel", Tag: "Jobs", Summary: "Cancel a running or pending job",
+		Responses: []apiResponse{okBody(db.Job{})}, Errors: []int{400, 404, 409, 503}},
+	{Method: "POST", Path: "/jobs/{id}/resume", Tag: "Jobs", Summary: "Resume a failed or cancelled job",
+		Responses: []apiResponse{jobResponse}, Errors: []int{400, 404, 409, 503}},
+
+	// Replication
+	{Method: "GET", Path: "/replication/catalog", Tag: "Replication", Summary: "This node's pinned content, for peers",
+		Query: []apiParam{queryParam("since", "string", "Only content pinned after this RFC 3339 time"),
+			queryParam("offset", "integer", "Entries to skip"), limitParam},
+		Responses: []apiResponse{okBody(core.CatalogPage{})}, Errors: []int{400, 500}},
+	{Method: "GET", Path: "/replication/peers", Tag: "Replication", Summary: "Replicated peers with lag and divergence",
+		Responses: []apiResponse{okBody([]core.ReplicationStatus{})}, Errors: []int{500, 503}},
+	{Method: "POST", Path: "/replication/peers", Tag: "Replication", Summary: "Start replicating a peer",
+		Request:   AddReplicationPeerRequest{},
+		Responses: []apiResponse{createdBody(core.ReplicationStatus{})}, Errors: []int{400, 500, 503}},
+	{Method: "GET", Path: "/replication/peers/{id}", Tag: "Replication", Summary: "Replication status of a peer",
+		Responses: []apiResponse{okBody(core.ReplicationStatus{})}, Errors: []int{400, 404, 500, 503}},
+	{Method: "DELETE", Path: "/replication/peers/{id}", Tag: "Replication", Summary: "Stop replicating a peer",
+		Query:     []apiParam{unpinParam},
+		Responses: []apiResponse{noBody("Removed"), jobResponse}, Errors: []int{400, 404, 500, 503}},
+	{Method: "POST", Path: "/replication/peers/{id}/sync", Tag: "Replication", Summary: "Sync a peer now",
+		Query:     []apiParam{queryParam("full", "boolean", "Read the whole catalog rather than what is new")},
+		Responses: []apiResponse{jobResponse}, Errors: []int{400, 404, 500, 503}},
+
+	// Pinning services
+	{Method: "GET", Path: "/pinning/services", Tag: "Pinning services", Summary: "Pinning services with remote pin counts",
+		Responses: []apiResponse{okBody([]core.MirrorStatus{})}, Errors: []int{500, 503}},
+	{Method: "POST", Path: "/pinning/services", Tag: "Pinning services", Summary: "Start mirroring to a pinning service",
+		Request:   AddPinningServiceRequest{},
+		Responses: []apiResponse{createdBody(core.MirrorStatus{})}, Errors: []int{400, 500, 503}},
+	{Method: "GET", Path: "/pinning/services/{id}", Tag: "Pinning services", Summary: "Mirror status of a pinning service",
+		Responses: []apiResponse{okBody(core.MirrorStatus{})}, Errors: []int{400, 404, 500, 503}},
+	{Method: "PUT", Path: "/pinning/services/{id}", Tag: "Pinning services", Summary: "Update name, token, wallets and enabled",
+		Request:   UpdatePinningServiceRequest{},
+		Responses: []apiResponse{okBody(core.MirrorStatus{})}, Errors: []int{400, 404, 500, 503}},
+	{Method: "DELETE", Path: "/pinning/services/{id}", Tag: "Pinning services", Summary: "Stop mirroring to a pinning service",
+		Query:     []apiParam{unpinParam},
+		Responses: []apiResponse{noBody("Removed"), jobResponse}, Errors: []int{400, 404, 409, 500, 503}},
+	{Method: "GET", Path: "/pinning/services/{id}/pins", Tag: "Pinning services", Summary: "CIDs mirrored to a service",
+		Query:     []apiParam{queryParam("status", "string", "Only pins in this status"), pageParam, limitParam},
+		Responses: []apiResponse{okBody(RemotePinsListResponse{})}, Errors: []int{400, 404, 500, 503}},
+	{Method: "POST", Path: "/pinning/services/{id}/reconcile", Tag: "Pinning services", Summary: "Reconcile a service now",
+		Responses: []apiResponse{jobResponse}, Errors: []int{400, 404, 500, 503}},
+
+	// Storage migration
+	{Method: "GET", Path: "/storage/migration", Tag: "Storage", Summary: "Storage migration progress",
+		Responses: []apiResponse{okBody(storage.MigrationStatus{})}, Errors: []int{503}},
+	{Method: "POST", Path: "/storage/migration", Tag: "Storage", Summary: "Move the IPFS repository",
+		Request:   MigrateStorageRequest{},
+		Responses: []apiResponse{acceptedBody(storage.MigrationStatus{})}, Errors: []int{400, 409, 503}},
+	{Method: "POST", Path: "/storage/migration/cancel", Tag: "Storage", Summary: "Cancel a storage migration",
+		Responses: []apiResponse{okBody(storage.MigrationStatus{})}, Errors: []int{409, 503}},
+	{Method: "GET", Path: "/storage/migration/events", Tag: "Storage", Summary: "Stream migration progress",
+		Responses: []apiResponse{{Status: http.StatusOK, Body: storage.MigrationStatus{}, Stream: true,
+			Note: "A progress event with the migration status on every change, until the migration ends"}},
+		Errors: []int{503}},
+
+	// Discovery
+	{Method: "GET", Path: "/discover", Tag: "Discovery", Summary: "Find Porcupin servers on the local network",
+		Query:     []apiParam{queryParam("timeout", "integer", "Seconds to listen, up to 30")},
+		Responses: []apiResponse{okBody([]DiscoveredServer{})}, Errors: []int{500}},
+
+	// Pairing and issued tokens
+	{Method: "POST", Path: "/pairing", Tag: "Pairing", Summary: "Open a pairing window",
+		Request:   OpenPairingRequest{},
+		Responses: []apiResponse{okBody(PairingResponse{})}, Errors: []int{400, 409, 500}},
+	{Method: "DELETE", Path: "/pairing", Tag: "Pairing", Summary: "Close the pairing window",
+		Responses: []apiResponse{noBody("Closed")}},
+	{Method: "POST", Path: "/pairing/complete", Tag: "Pairing", Summary: "Trade a pairing code for a token", Public: true,
+		Request:   CompletePairingRequest{},
+		Responses: []apiResponse{createdBody(PairResponse{})}, Errors: []int{400, 401, 500}},
+	{Method: "GET", Path: "/tokens", Tag: "Pairing", Summary: "Tokens issued by pairing",
+		Responses: []apiResponse{okBody([]db.APIToken{})}, Errors: []int{500}},
+	{Method: "DELETE", Path: "/tokens/{id}", Tag: "Pairing", Summary: "Revoke an issued token",
+		Responses: []apiResponse{noBody("Revoked")}, Errors: []int{400, 404, 500}},
+}
+
+// errorCodes is the code an error response carries for each status
+var errorCodes = map[int]string{
+	http.StatusBadRequest:          ErrCodeBadRequest,
+	http.StatusUnauthorized:        ErrCodeUnauthorized,
+	http.StatusForbidden:           ErrCodeForbidden,
+	http.StatusNotFound:            ErrCodeNotFound,
+	http.StatusConflict:            ErrCodeConflict,
+	http.StatusTooManyRequests:     ErrCodeRateLimited,
+	http.StatusInternalServerError: ErrCodeInternalError,
+	http.StatusServiceUnavailable:  ErrCodeServiceUnavail,
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// OpenAPISpec returns the OpenAPI 3 document describing the API
+func OpenAPISpec(version string) map[string]interface{} {
+	b := newSchemaBuilder()
+	meta := b.ref(reflect.TypeOf(Meta{}), false)
+
+	paths := map[string]map[string]interface{}{}
+	tags := map[string]bool{}
+	for _, op := range apiOperations {
+		path := "/api/v1" + op.Path
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		tags[op.Tag] = true
+
+		var params []interface{}
+		for _, m := range pathParamPattern.FindAllStringSubmatch(op.Path, -1) {
+			typ := "string"
+			if m[1] == "id" {
+				typ = "integer"
+			}
+			params = append(params, map[string]interface{}{
+				"name": m[1], "in": "path", "required": true,
+				"schema": map[string]interface{}{"type": typ},
+			})
+		}
+		for _, p := range op.Query {
+			params = append(params, map[string]interface{}{
+				"name": p.Name, "in": "query", "description": p.Description,
+				"schema": map[string]interface{}{"type": p.Type},
+			})
+		}
+
+		responses := map[string]interface{}{}
+		for _, resp := range op.Responses {
+			responses[statusKey(resp.Status)] = b.response(resp, meta)
+		}
+		errs := append([]int{http.StatusForbidden, http.StatusTooManyRequests}, op.Errors...)
+		if !op.Public {
+			errs = append(errs, http.StatusUnauthorized)
+		}
+		for _, status := range errs {
+			responses[statusKey(status)] = map[string]interface{}{
+				"$ref": "#/components/responses/" + errorResponseName(status),
+			}
+		}
+
+		operation := map[string]interface{}{
+			"operationId": operationID(op),
+			"summary":     op.Summary,
+			"tags":        []string{op.Tag},
+			"responses":   responses,
+		}
+		if params != nil {
+			operation["parameters"] = params
+		}
+		if op.Request != nil {
+			content := map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": b.schema(reflect.TypeOf(op.Request), true)},
+			}
+			for _, contentType := range op.TextBody {
+				content[contentType] = map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}
+			}
+			operation["requestBody"] = map[string]interface{}{"required": true, "content": content}
+		}
+		if op.Public {
+			operation["security"] = []interface{}{}
+		}
+		paths[path][strings.ToLower(op.Method)] = operation
+	}
+
+	errorResponses := map[string]interface{}{}
+	errorSchema := b.ref(reflect.TypeOf(ErrorResponse{}), false)
+	for status, code := range errorCodes {
+		errorResponses[errorResponseName(status)] = map[string]interface{}{
+			"description": "Error with code " + code,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema":  errorSchema,
+					"example": ErrorResponse{Error: ErrorDetail{Code: code, Message: strings.ToLower(http.StatusText(status))}},
+				},
+			},
+		}
+	}
+
+	tagList := make([]string, 0, len(tags))
+	for tag := range tags {
+		tagList = append(tagList, tag)
+	}
+	sort.Strings(tagList)
+	tagObjects := make([]interface{}, len(tagList))
+	for i, tag := range tagList {
+		tagObjects[i] = map[string]interface{}{"name": tag}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Porcupin API",
+			"version":     version,
+			"description": "REST API of a Porcupin server. Successful responses are wrapped in {\"data\": ...}; errors carry a code and a message.",
+		},
+		"tags":     tagObjects,
+		"paths":    paths,
+		"security": []interface{}{map[string]interface{}{"bearerAuth": []string{}}},
+		"components": map[string]interface{}{
+			"schemas":   b.schemas,
+			"responses": errorResponses,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// GetOpenAPI returns the OpenAPI document of the API
+// GET /api/v1/openapi.json
+func (h *Handlers) GetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	WriteJSONRaw(w, http.StatusOK, OpenAPISpec(h.version))
+}
+
+// response describes a successful response
+func (b *schemaBuilder) response(resp apiResponse, meta map[string]interface{}) map[string]interface{} {
+	description := resp.Note
+	if description == "" {
+		description = http.StatusText(resp.Status)
+	}
+	out := map[string]interface{}{"description": description}
+	if resp.Body == nil {
+		return out
+	}
+
+	schema := b.schema(reflect.TypeOf(resp.Body), false)
+	switch {
+	case resp.Stream:
+		out["content"] = map[string]interface{}{
+			"text/event-stream": map[string]interface{}{
+				"schema": map[string]interface{}{"type": "string", "description": "Events whose data is JSON"},
+			},
+		}
+		out["x-event-data"] = schema
+		return out
+	case !resp.Raw:
+		schema = map[string]interface{}{
+			"type":       "object",
+			"required":   []string{"data"},
+			"properties": map[string]interface{}{"data": schema, "meta": meta},
+		}
+	}
+	content := map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": schema},
+	}
+	for _, contentType := range resp.Text {
+		content[contentType] = map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}
+	}
+	out["content"] = content
+	return out
+}
+
+// operationID names an operation after its handler's route, e.g.
+// get_wallets_address
+func operationID(op apiOperation) string {
+	path := strings.NewReplacer("{", "", "}", "", ".", "_", "-", "_").Replace(op.Path)
+	return strings.ToLower(op.Method) + strings.ReplaceAll(path, "/", "_")
+}
+
+func statusKey(status int) string {
+	return strconv.Itoa(status)
+}
+
+func errorResponseName(status int) string {
+	return strings.ReplaceAll(http.StatusText(status), " ", "")
+}
+
+// =============================================================================
+// Schemas
+// =============================================================================
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaBuilder turns Go types into schemas, collecting named structs as
+// components
+type schemaBuilder struct {
+	schemas map[string]interface{}
+	names   map[reflect.Type]string
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{schemas: map[string]interface{}{}, names: map[reflect.Type]string{}}
+}
+
+// schema returns the schema of t. Fields without omitempty are required in
+// responses; request bodies list no required fields, as the handlers
+// validate them.
+func (b *schemaBuilder) schema(t reflect.Type, request bool) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := b.schema(t.Elem(), request)
+		if _, isRef := s["$ref"]; isRef {
+			return s
+		}
+		s["nullable"] = true
+		return s
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t, request)
+		}
+		return b.ref(t, request)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		// A nil slice or map is sent as null
+		return map[string]interface{}{"type": "array", "items": b.schema(t.Elem(), request), "nullable": true}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schema(t.Elem(), request), "nullable": true}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32:
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	}
+	return map[string]interface{}{}
+}
+
+// ref registers a named struct as a component and returns a reference to it
+func (b *schemaBuilder) ref(t reflect.Type, request bool) map[string]interface{} {
+	name, seen := b.names[t]
+	if !seen {
+		name = t.Name()
+		if _, taken := b.schemas[name]; taken {
+			name = strings.ReplaceAll(t.String(), ".", "_")
+		}
+		b.names[t] = name
+		b.schemas[name] = map[string]interface{}{} // Placeholder for recursive types
+		b.schemas[name] = b.object(t, request)
+	}
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+// object returns the schema of a struct's JSON fields, including those of
+// embedded structs
+func (b *schemaBuilder) object(t reflect.Type, request bool) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	b.fields(t, request, properties, &required)
+
+	s := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		s["required"] = required
+	}
+	return s
+}
+
+func (b *schemaBuilder) fields(t reflect.Type, request bool, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			b.fields(f.Type, request, properties, required)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = b.schema(f.Type, request)
+		if !request && !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
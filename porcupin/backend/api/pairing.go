@@ -0,0 +1,243 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"porcupin/backend/db"
+)
+
+const (
+	// DefaultPairingWindow is how long a pairing code can be used
+	DefaultPairingWindow = 5 * time.Minute
+
+	// MaxPairingWindow bounds the window a caller can ask for
+	MaxPairingWindow = time.Hour
+
+	// maxPairingAttempts wrong codes close the window, so the code can't be
+	// guessed while it is open
+	maxPairingAttempts = 5
+
+	// pairingAlphabet leaves out characters that are easily confused, such
+	// as 0 and O, 1 and I
+	pairingAlphabet   = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	pairingCodeLength = 8
+
+	// PairingURLScheme is the scheme of pairing URLs, which carry everything
+	// a client needs to pair: porcupin://pair?host=...&port=...&code=...
+	PairingURLScheme = "porcupin"
+)
+
+var (
+	// ErrPairingClosed is returned when no pairing window is open
+	ErrPairingClosed = errors.New("no pairing window is open; start one on the server with 'porcupin token pair'")
+
+	// ErrPairingCode is returned for a wrong pairing code
+	ErrPairingCode = errors.New("wrong pairing code")
+)
+
+// Pairing hands out API tokens to clients that present a short code shown
+// on the server, while a pairing window is open. A code can be used once.
+type Pairing struct {
+	mu       sync.Mutex
+	code     string
+	expires  time.Time
+	attempts int
+}
+
+// NewPairing creates a Pairing with no window open
+func NewPairing() *Pairing {
+	return &Pairing{}
+}
+
+// Open starts a pairing window with a new code, replacing any open window
+func (p *Pairing) Open(window time.Duration) (code string, expires time.Time, err error) {
+	code, err = generatePairingCode()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.code = code
+	p.expires = time.Now().Add(window)
+	p.attempts = 0
+	return code, p.expires, nil
+}
+
+// Close ends the pairing window
+func (p *Pairing) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.code = ""
+}
+
+// Redeem checks a code against the open window, closing the window when
+// the code is right or too many codes were wrong
+func (p *Pairing) Redeem(code string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.code == "" || time.Now().After(p.expires) {
+		p.code = ""
+		return ErrPairingClosed
+	}
+	if subtle.ConstantTimeCompare([]byte(NormalizePairingCode(code)), []byte(p.code)) != 1 {
+		p.attempts++
+		if p.attempts >= maxPairingAttempts {
+			p.code = ""
+		}
+		return ErrPairingCode
+	}
+	p.code = ""
+	return nil
+}
+
+// generatePairingCode returns a random code from pairingAlphabet
+func generatePairingCode() (string, error) {
+	max := big.NewInt(int64(len(pairingAlphabet)))
+	code := make([]byte, pairingCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate pairing code: %w", err)
+		}
+		code[i] = pairingAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// FormatPairingCode splits a code in two for reading out, e.g. "ABCD-EFGH"
+func FormatPairingCode(code string) string {
+	if len(code) != pairingCodeLength {
+		return code
+	}
+	return code[:pairingCodeLength/2] + "-" + code[pairingCodeLength/2:]
+}
+
+// NormalizePairingCode uppercases a code and strips the dashes and spaces
+// it may be typed with
+func NormalizePairingCode(code string) string {
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return strings.ToUpper(code)
+}
+
+// IssueAPIToken mints a named API token and saves its hash, returning the
+// token, which is not stored and can't be shown again
+func IssueAPIToken(database *db.Database, name string) (string, *db.APIToken, error) {
+	token, err := GenerateToken()
+	if err != nil {
+		return "", nil, err
+	}
+	record := &db.APIToken{Name: name, TokenHash: hashIssuedToken(token)}
+	if err := database.CreateAPIToken(record); err != nil {
+		return "", nil, fmt.Errorf("failed to save token: %w", err)
+	}
+	return token, record, nil
+}
+
+// hashIssuedToken hashes an issued token for storage. Tokens are random, so
+// unlike passwords they need no slow hash, and a plain one can be looked up.
+func hashIssuedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssuedTokenValidator accepts tokens issued by IssueAPIToken and not yet
+// revoked, for AuthMiddlewareWithIssued
+func IssuedTokenValidator(database *db.Database) func(token string) bool {
+	return func(token string) bool {
+		if !ValidateTokenFormat(token) {
+			return false
+		}
+		record, err := database.UseAPIToken(hashIssuedToken(token))
+		return err == nil && record != nil
+	}
+}
+
+// PairingInvite is what a client needs to pair with a server
+type PairingInvite struct {
+	Host        string `json:"host"`
+	Port        int    `json:"port"`
+	Code        string `json:"code"`
+	Fingerprint string `json:"fingerprint,omitempty"` // SHA-256 of the server's self-signed certificate
+}
+
+// URL encodes the invite as a pairing URL
+func (i PairingInvite) URL() string {
+	query := url.Values{}
+	query.Set("host", i.Host)
+	query.Set("port", strconv.Itoa(i.Port))
+	query.Set("code", i.Code)
+	if i.Fingerprint != "" {
+		query.Set("fingerprint", i.Fingerprint)
+	}
+	return PairingURLScheme + "://pair?" + query.Encode()
+}
+
+// ParsePairingURL decodes a URL made by PairingInvite.URL
+func ParsePairingURL(raw string) (PairingInvite, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Scheme != PairingURLScheme || u.Host != "pair" {
+		return PairingInvite{}, fmt.Errorf("not a pairing URL: %q", raw)
+	}
+	query := u.Query()
+	invite := PairingInvite{
+		Host:        query.Get("host"),
+		Code:        NormalizePairingCode(query.Get("code")),
+		Fingerprint: NormalizeFingerprint(query.Get("fingerprint")),
+	}
+	invite.Port, err = strconv.Atoi(query.Get("port"))
+	if err != nil || invite.Port < 1 || invite.Port > 65535 {
+		return PairingInvite{}, fmt.Errorf("pairing URL has an invalid port %q", query.Get("port"))
+	}
+	if invite.Host == "" || invite.Code == "" {
+		return PairingInvite{}, fmt.Errorf("pairing URL is missing the host or code")
+	}
+	return invite, nil
+}
+
+// pairingHost picks the address to put in pairing URLs: the first private
+// IPv4 address of this machine, as clients pair over the LAN
+func pairingHost() string {
+	for _, addr := range GetLocalIPs() {
+		if ip := net.ParseIP(addr); ip.To4() != nil && isPrivateIP(ip) {
+			return addr
+		}
+	}
+	return "localhost"
+}
+
+// Pair redeems a pairing code with a server and returns the token it
+// issues, named after the client. Without a fingerprint, a self-signed
+// certificate is trusted on first use; the fingerprint it was pinned with
+// is returned for the client to save along with the token.
+func Pair(ctx context.Context, host string, port int, fingerprint, code, name string) (resp *PairResponse, pinned string, err error) {
+	var client *RemoteClient
+	if fingerprint != "" {
+		client = NewPinnedRemoteClient(host, port, "", fingerprint)
+		pinned = NormalizeFingerprint(fingerprint)
+	} else if client, pinned, err = TrustOnFirstUse(ctx, host, port, ""); err != nil {
+		return nil, "", err
+	}
+
+	req := CompletePairingRequest{Code: code, Name: name}
+	resp = &PairResponse{}
+	if err := client.Do(ctx, http.MethodPost, "/api/v1/pairing/complete", req, resp); err != nil {
+		return nil, "", err
+	}
+	return resp, pinned, nil
+}
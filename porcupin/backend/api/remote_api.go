@@ -0,0 +1,736 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"porcupin/backend/core"
+	"porcupin/backend/db"
+	"porcupin/backend/storage"
+)
+
+// Typed methods for the REST API. Each sends one request through Do, so
+// errors are *APIErrors that match the Err* values, and requests are retried
+// according to the client's RetryPolicy.
+
+// MessageResponse is the acknowledgement of an action the server started
+type MessageResponse struct {
+	Message string `json:"message"`
+	Count   int64  `json:"count,omitempty"`    // Assets affected, for bulk actions
+	AssetID uint64 `json:"asset_id,omitempty"` // For actions on one asset
+	Wallet  string `json:"wallet,omitempty"`   // For actions on one wallet
+}
+
+// =============================================================================
+// System
+// =============================================================================
+
+// Version returns the server's version
+func (c *RemoteClient) Version(ctx context.Context) (string, error) {
+	var resp map[string]string
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/version", nil, &resp); err != nil {
+		return "", err
+	}
+	return resp["version"], nil
+}
+
+// Status returns the state of the server's backup service
+func (c *RemoteClient) Status(ctx context.Context) (*core.ServiceStatus, error) {
+	var status core.ServiceStatus
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Stats returns asset counts and storage use
+func (c *RemoteClient) Stats(ctx context.Context) (*StatsResponse, error) {
+	var stats StatsResponse
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/stats", nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Activity returns the most recently pinned assets; limit 0 uses the
+// server's default
+func (c *RemoteClient) Activity(ctx context.Context, limit int) ([]ActivityItem, error) {
+	path := "/api/v1/activity"
+	if limit > 0 {
+		path += "?limit=" + strconv.Itoa(limit)
+	}
+	var items []ActivityItem
+	if err := c.Do(ctx, http.MethodGet, path, nil, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// =============================================================================
+// Wallets
+// =============================================================================
+
+// walletPath returns the path of a wallet, or of an action on it
+func walletPath(address string, action ...string) string {
+	return strings.Join(append([]string{"/api/v1/wallets", url.PathEscape(address)}, action...), "/")
+}
+
+// Wallets returns the tracked wallets with their storage use
+func (c *RemoteClient) Wallets(ctx context.Context) ([]WalletResponse, error) {
+	var wallets []WalletResponse
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/wallets", nil, &wallets); err != nil {
+		return nil, err
+	}
+	return wallets, nil
+}
+
+// Wallet returns one wallet
+func (c *RemoteClient) Wallet(ctx context.Context, address string) (*WalletResponse, error) {
+	var wallet WalletResponse
+	if err := c.Do(ctx, http.MethodGet, walletPath(address), nil, &wallet); err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// AddWallet starts tracking a wallet and syncs it
+func (c *RemoteClient) AddWallet(ctx context.Context, req AddWalletRequest) (*WalletResponse, error) {
+	var wallet WalletResponse
+	if err := c.Do(ctx, http.MethodPost, "/api/v1/wallets", req, &wallet); err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// UpdateWallet changes the settings set in req
+func (c *RemoteClient) UpdateWallet(ctx context.Context, address string, req UpdateWalletRequest) (*WalletResponse, error) {
+	var wallet WalletResponse
+	if err := c.Do(ctx, http.MethodPut, walletPath(address), req, &wallet); err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// DeleteWallet stops tracking a wallet, leaving its assets pinned
+func (c *RemoteClient) DeleteWallet(ctx context.Context, address string) error {
+	return c.Do(ctx, http.MethodDelete, walletPath(address), nil, nil)
+}
+
+// DeleteWalletAndUnpin stops tracking a wallet and unpins its assets in a
+// background job
+func (c *RemoteClient) DeleteWalletAndUnpin(ctx context.Context, address string) (*db.Job, error) {
+	var job db.Job
+	if err := c.Do(ctx, http.MethodDelete, walletPath(address)+"?unpin=true", nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// SyncWallet starts syncing one wallet
+func (c *RemoteClient) SyncWallet(ctx context.Context, address string) error {
+	return c.Do(ctx, http.MethodPost, walletPath(address, "sync"), nil, nil)
+}
+
+// ImportWallets adds the wallets in a CSV or JSON wallet file. An empty
+// format is detected by the server; with dryRun nothing is added.
+func (c *RemoteClient) ImportWallets(ctx context.Context, file []byte, format string, dryRun bool) (*WalletImportReport, error) {
+	query := url.Values{}
+	if format != "" {
+		query.Set("format", format)
+	}
+	if dryRun {
+		query.Set("dry_run", "true")
+	}
+	var report WalletImportReport
+	if err := c.Do(ctx, http.MethodPost, withQuery("/api/v1/wallets/import", query), file, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ExportWallets returns every wallet as a wallet file in format
+func (c *RemoteClient) ExportWallets(ctx context.Context, format string) ([]byte, error) {
+	var file []byte
+	path := withQuery("/api/v1/wallets/export", url.Values{"format": {format}})
+	if err := c.Do(ctx, http.MethodGet, path, nil, &file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// =============================================================================
+// NFTs and Assets
+// =============================================================================
+
+// NFTQuery selects a page of NFTs. Zero values use the server's defaults.
+type NFTQuery struct {
+	Page      int
+	Limit     int
+	Search    string // Words to find in the NFTs' metadata, best matches first
+	Creator   string // Only NFTs minted by this account
+	Contract  string // Only NFTs of this collection
+	Tag       string // Only NFTs with this tag
+	Attribute string // Only NFTs with this attribute, as name or name:value
+}
+
+// NFTs returns a page of NFTs with their assets
+func (c *RemoteClient) NFTs(ctx context.Context, q NFTQuery) (*NFTsListResponse, error) {
+	query := url.Values{}
+	setPage(query, q.Page, q.Limit)
+	if q.Search != "" {
+		query.Set("search", q.Search)
+	}
+	if q.Creator != "" {
+		query.Set("creator", q.Creator)
+	}
+	if q.Contract != "" {
+		query.Set("contract", q.Contract)
+	}
+	if q.Tag != "" {
+		query.Set("tag", q.Tag)
+	}
+	if q.Attribute != "" {
+		query.Set("attribute", q.Attribute)
+	}
+	var resp NFTsListResponse
+	if err := c.Do(ctx, http.MethodGet, withQuery("/api/v1/nfts", query), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// NFTHistory returns the versions of an NFT's metadata, oldest first, with
+// the changes each made
+func (c *RemoteClient) NFTHistory(ctx context.Context, nftID uint64) (*MetadataHistoryResponse, error) {
+	var history MetadataHistoryResponse
+	path := "/api/v1/nfts/" + strconv.FormatUint(nftID, 10) + "/history"
+	if err := c.Do(ctx, http.MethodGet, path, nil, &history); err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// GroupQuery selects a page of creators or collections. Zero values use the
+// server's defaults.
+type GroupQuery struct {
+	Page    int
+	Limit   int
+	Search  string // Matches address, alias or name
+	Creator string // Collections only: originated by this account
+}
+
+func (q GroupQuery) values() url.Values {
+	query := url.Values{}
+	setPage(query, q.Page, q.Limit)
+	if q.Search != "" {
+		query.Set("search", q.Search)
+	}
+	if q.Creator != "" {
+		query.Set("creator", q.Creator)
+	}
+	return query
+}
+
+// Creators returns a page of the NFTs' artists, most NFTs first
+func (c *RemoteClient) Creators(ctx context.Context, q GroupQuery) (*CreatorsListResponse, error) {
+	var resp CreatorsListResponse
+	if err := c.Do(ctx, http.MethodGet, withQuery("/api/v1/creators", q.values()), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Creator returns one artist
+func (c *RemoteClient) Creator(ctx context.Context, address string) (*CreatorResponse, error) {
+	var creator CreatorResponse
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/creators/"+url.PathEscape(address), nil, &creator); err != nil {
+		return nil, err
+	}
+	return &creator, nil
+}
+
+// Collections returns a page of the NFTs' collections, most NFTs first
+func (c *RemoteClient) Collections(ctx context.Context, q GroupQuery) (*CollectionsListResponse, error) {
+	var resp CollectionsListResponse
+	if err := c.Do(ctx, http.MethodGet, withQuery("/api/v1/collections", q.values()), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Collection returns one collection
+func (c *RemoteClient) Collection(ctx context.Context, address string) (*CollectionResponse, error) {
+	var collection CollectionResponse
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/collections/"+url.PathEscape(address), nil, &collection); err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// AssetQuery selects a page of assets. Zero values use the server's
+// defaults.
+type AssetQuery struct {
+	Page   int
+	Limit  int
+	Status []string // Any of these statuses; empty for all
+	Search string
+	Wallet string
+	Type   string // artifact, display, thumbnail...
+}
+
+// Assets returns a page of assets
+func (c *RemoteClient) Assets(ctx context.Context, q AssetQuery) (*AssetsListResponse, error) {
+	query := url.Values{}
+	setPage(query, q.Page, q.Limit)
+	if len(q.Status) > 0 {
+		query.Set("status", strings.Join(q.Status, ","))
+	}
+	if q.Search != "" {
+		query.Set("search", q.Search)
+	}
+	if q.Wallet != "" {
+		query.Set("wallet", q.Wallet)
+	}
+	if q.Type != "" {
+		query.Set("type", q.Type)
+	}
+	var resp AssetsListResponse
+	if err := c.Do(ctx, http.MethodGet, withQuery("/api/v1/assets", query), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// FailedAssets returns the assets that failed to pin
+func (c *RemoteClient) FailedAssets(ctx context.Context) ([]AssetResponse, error) {
+	var assets []AssetResponse
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/assets/failed", nil, &assets); err != nil {
+		return nil, err
+	}
+	return assets, nil
+}
+
+// RetryAsset pins an asset again
+func (c *RemoteClient) RetryAsset(ctx context.Context, id uint64) error {
+	return c.Do(ctx, http.MethodPost, assetPath(id, "retry"), nil, nil)
+}
+
+// PinAssetNext moves an asset to the front of the pin queue
+func (c *RemoteClient) PinAssetNext(ctx context.Context, id uint64) error {
+	return c.Do(ctx, http.MethodPost, assetPath(id, "pin-next"), nil, nil)
+}
+
+// RetryAllFailed sets every failed asset back to pending, returning how
+// many there were
+func (c *RemoteClient) RetryAllFailed(ctx context.Context) (int64, error) {
+	var resp MessageResponse
+	if err := c.Do(ctx, http.MethodPost, "/api/v1/assets/retry-failed", nil, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Count, nil
+}
+
+// ClearFailed deletes every failed asset, returning how many there were
+func (c *RemoteClient) ClearFailed(ctx context.Context) (int64, error) {
+	var resp MessageResponse
+	if err := c.Do(ctx, http.MethodDelete, "/api/v1/assets/failed", nil, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Count, nil
+}
+
+// DeleteAsset deletes an asset from the database
+func (c *RemoteClient) DeleteAsset(ctx context.Context, id uint64) error {
+	return c.Do(ctx, http.MethodDelete, assetPath(id), nil, nil)
+}
+
+// AssetRemotePins returns the copies of an asset on pinning services
+func (c *RemoteClient) AssetRemotePins(ctx context.Context, id uint64) ([]db.RemotePin, error) {
+	var pins []db.RemotePin
+	if err := c.Do(ctx, http.MethodGet, assetPath(id, "remote-pins"), nil, &pins); err != nil {
+		return nil, err
+	}
+	return pins, nil
+}
+
+// assetPath returns the path of an asset, or of an action on it
+func assetPath(id uint64, action ...string) string {
+	return strings.Join(append([]string{"/api/v1/assets", strconv.FormatUint(id, 10)}, action...), "/")
+}
+
+// =============================================================================
+// Control
+// =============================================================================
+
+// TriggerSync starts syncing every wallet
+func (c *RemoteClient) TriggerSync(ctx context.Context) error {
+	return c.Do(ctx, http.MethodPost, "/api/v1/sync", nil, nil)
+}
+
+// Pause pauses the backup service
+func (c *RemoteClient) Pause(ctx context.Context) error {
+	return c.Do(ctx, http.MethodPost, "/api/v1/pause", nil, nil)
+}
+
+// Resume resumes the backup service
+func (c *RemoteClient) Resume(ctx context.Context) error {
+	return c.Do(ctx, http.MethodPost, "/api/v1/resume", nil, nil)
+}
+
+// RunGC starts IPFS garbage collection
+func (c *RemoteClient) RunGC(ctx context.Context) error {
+	return c.Do(ctx, http.MethodPost, "/api/v1/gc", nil, nil)
+}
+
+// VerifyAndFixPins checks every pinned asset and re-pins the missing ones,
+// returning counts by outcome
+func (c *RemoteClient) VerifyAndFixPins(ctx context.Context) (map[string]int, error) {
+	// The handler wraps its result once more
+	var resp struct {
+		Data map[string]int `json:"data"`
+	}
+	if err := c.Do(ctx, http.MethodPost, "/api/v1/verify-and-fix", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// ReconcilePins compares the server's pins with its assets and fixes what
+// differs, or with dryRun only reports it
+func (c *RemoteClient) ReconcilePins(ctx context.Context, dryRun bool) (*core.PinReconcileReport, error) {
+	path := "/api/v1/assets/reconcile"
+	if dryRun {
+		path += "?dry_run=true"
+	}
+	var report core.PinReconcileReport
+	if err := c.Do(ctx, http.MethodPost, path, nil, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Queue returns the pin queue
+func (c *RemoteClient) Queue(ctx context.Context) (*core.QueueStatus, error) {
+	var queue core.QueueStatus
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/queue", nil, &queue); err != nil {
+		return nil, err
+	}
+	return &queue, nil
+}
+
+// =============================================================================
+// Jobs
+// =============================================================================
+
+// Jobs returns recent jobs, optionally only those with a status; limit 0
+// uses the server's default
+func (c *RemoteClient) Jobs(ctx context.Context, status string, limit int) ([]db.Job, error) {
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", status)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var resp JobsListResponse
+	if err := c.Do(ctx, http.MethodGet, withQuery("/api/v1/jobs", query), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Jobs, nil
+}
+
+// Job returns one job
+func (c *RemoteClient) Job(ctx context.Context, id uint64) (*db.Job, error) {
+	return c.jobAction(ctx, http.MethodGet, id, "")
+}
+
+// SubmitJob starts a job of a type such as core.JobTypeVerifyAndFix
+func (c *RemoteClient) SubmitJob(ctx context.Context, jobType string, params interface{}) (*db.Job, error) {
+	req := SubmitJobRequest{Type: jobType}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode job params: %w", err)
+		}
+		req.Params = data
+	}
+	var job db.Job
+	if err := c.Do(ctx, http.MethodPost, "/api/v1/jobs", req, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CancelJob stops a running job
+func (c *RemoteClient) CancelJob(ctx context.Context, id uint64) (*db.Job, error) {
+	return c.jobAction(ctx, http.MethodPost, id, "/cancel")
+}
+
+// ResumeJob restarts a failed or cancelled job from its checkpoint
+func (c *RemoteClient) ResumeJob(ctx context.Context, id uint64) (*db.Job, error) {
+	return c.jobAction(ctx, http.MethodPost, id, "/resume")
+}
+
+func (c *RemoteClient) jobAction(ctx context.Context, method string, id uint64, action string) (*db.Job, error) {
+	var job db.Job
+	if err := c.Do(ctx, method, "/api/v1/jobs/"+strconv.FormatUint(id, 10)+action, nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// =============================================================================
+// Replication and Pinning Services
+// =============================================================================
+
+// ReplicationPeers returns the replication peers with their progress
+func (c *RemoteClient) ReplicationPeers(ctx context.Context) ([]core.ReplicationStatus, error) {
+	var peers []core.ReplicationStatus
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/replication/peers", nil, &peers); err != nil {
+		return nil, err
+	}
+	return peers, nil
+}
+
+// AddReplicationPeer starts replicating another server
+func (c *RemoteClient) AddReplicationPeer(ctx context.Context, req AddReplicationPeerRequest) (*core.ReplicationStatus, error) {
+	var peer core.ReplicationStatus
+	if err := c.Do(ctx, http.MethodPost, "/api/v1/replication/peers", req, &peer); err != nil {
+		return nil, err
+	}
+	return &peer, nil
+}
+
+// ReplicationPeer returns one replication peer
+func (c *RemoteClient) ReplicationPeer(ctx context.Context, id uint64) (*core.ReplicationStatus, error) {
+	var peer core.ReplicationStatus
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/replication/peers/"+strconv.FormatUint(id, 10), nil, &peer); err != nil {
+		return nil, err
+	}
+	return &peer, nil
+}
+
+// SyncReplicationPeer starts catching up with a peer; full rereads its
+// whole catalog
+func (c *RemoteClient) SyncReplicationPeer(ctx context.Context, id uint64, full bool) (*db.Job, error) {
+	path := "/api/v1/replication/peers/" + strconv.FormatUint(id, 10) + "/sync"
+	if full {
+		path += "?full=true"
+	}
+	var job db.Job
+	if err := c.Do(ctx, http.MethodPost, path, nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// DeleteReplicationPeer stops replicating a peer, leaving what was
+// replicated pinned
+func (c *RemoteClient) DeleteReplicationPeer(ctx context.Context, id uint64) error {
+	return c.Do(ctx, http.MethodDelete, "/api/v1/replication/peers/"+strconv.FormatUint(id, 10), nil, nil)
+}
+
+// PinningServices returns the pinning services with their mirror progress
+func (c *RemoteClient) PinningServices(ctx context.Context) ([]core.MirrorStatus, error) {
+	var services []core.MirrorStatus
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/pinning/services", nil, &services); err != nil {
+		return nil, err
+	}
+	return services, nil
+}
+
+// AddPinningService starts mirroring pins to a pinning service
+func (c *RemoteClient) AddPinningService(ctx context.Context, req AddPinningServiceRequest) (*core.MirrorStatus, error) {
+	var service core.MirrorStatus
+	if err := c.Do(ctx, http.MethodPost, "/api/v1/pinning/services", req, &service); err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+// PinningService returns one pinning service
+func (c *RemoteClient) PinningService(ctx context.Context, id uint64) (*core.MirrorStatus, error) {
+	var service core.MirrorStatus
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/pinning/services/"+strconv.FormatUint(id, 10), nil, &service); err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+// UpdatePinningService changes the settings set in req
+func (c *RemoteClient) UpdatePinningService(ctx context.Context, id uint64, req UpdatePinningServiceRequest) (*core.MirrorStatus, error) {
+	var service core.MirrorStatus
+	if err := c.Do(ctx, http.MethodPut, "/api/v1/pinning/services/"+strconv.FormatUint(id, 10), req, &service); err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+// ReconcilePinningService starts comparing a service's pins with ours
+func (c *RemoteClient) ReconcilePinningService(ctx context.Context, id uint64) (*db.Job, error) {
+	var job db.Job
+	if err := c.Do(ctx, http.MethodPost, "/api/v1/pinning/services/"+strconv.FormatUint(id, 10)+"/reconcile", nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// PinningServicePins returns a page of the pins mirrored to a service,
+// optionally only those with a status
+func (c *RemoteClient) PinningServicePins(ctx context.Context, id uint64, status string, page, limit int) (*RemotePinsListResponse, error) {
+	query := url.Values{}
+	setPage(query, page, limit)
+	if status != "" {
+		query.Set("status", status)
+	}
+	var resp RemotePinsListResponse
+	path := withQuery("/api/v1/pinning/services/"+strconv.FormatUint(id, 10)+"/pins", query)
+	if err := c.Do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeletePinningService stops mirroring to a pinning service, leaving its
+// pins in place
+func (c *RemoteClient) DeletePinningService(ctx context.Context, id uint64) error {
+	return c.Do(ctx, http.MethodDelete, "/api/v1/pinning/services/"+strconv.FormatUint(id, 10), nil, nil)
+}
+
+// =============================================================================
+// Storage Migration and Events
+// =============================================================================
+
+// StorageMigration returns the progress of the current or last migration
+func (c *RemoteClient) StorageMigration(ctx context.Context) (*storage.MigrationStatus, error) {
+	return c.migrationAction(ctx, http.MethodGet, "", nil)
+}
+
+// StartStorageMigration moves the server's IPFS repository to destPath, a
+// path on the server
+func (c *RemoteClient) StartStorageMigration(ctx context.Context, destPath string) (*storage.MigrationStatus, error) {
+	return c.migrationAction(ctx, http.MethodPost, "", MigrateStorageRequest{DestPath: destPath})
+}
+
+// CancelStorageMigration stops a migration, keeping what was copied
+func (c *RemoteClient) CancelStorageMigration(ctx context.Context) (*storage.MigrationStatus, error) {
+	return c.migrationAction(ctx, http.MethodPost, "/cancel", nil)
+}
+
+func (c *RemoteClient) migrationAction(ctx context.Context, method, action string, body interface{}) (*storage.MigrationStatus, error) {
+	var status storage.MigrationStatus
+	if err := c.Do(ctx, method, "/api/v1/storage/migration"+action, body, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// StorageMigrationEvents follows the progress of a migration, calling fn on
+// each change until the migration ends or ctx is done. It returns nil once
+// the migration has ended.
+func (c *RemoteClient) StorageMigrationEvents(ctx context.Context, fn func(storage.MigrationStatus)) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/v1/storage/migration/events", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "text/event-stream")
+
+	// The stream lasts as long as the migration, beyond the client's timeout
+	stream := *c.httpClient
+	stream.Timeout = 0
+	resp, err := stream.Do(req)
+	if err != nil {
+		return fmt.Errorf("connection failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var body strings.Builder
+		bufio.NewReader(resp.Body).WriteTo(&body)
+		return newAPIError(resp.StatusCode, []byte(body.String()))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var status storage.MigrationStatus
+		if err := json.Unmarshal([]byte(data), &status); err != nil {
+			return fmt.Errorf("failed to parse event: %w", err)
+		}
+		fn(status)
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("event stream failed: %w", err)
+	}
+	return ctx.Err()
+}
+
+// DiscoverServers asks the server for the Porcupin servers on its network
+func (c *RemoteClient) DiscoverServers(ctx context.Context) ([]DiscoveredServer, error) {
+	var servers []DiscoveredServer
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/discover", nil, &servers); err != nil {
+		return nil, err
+	}
+	return servers, nil
+}
+
+// =============================================================================
+// Pairing and Tokens
+// =============================================================================
+
+// OpenPairing opens a pairing window for window seconds; 0 uses the
+// server's default
+func (c *RemoteClient) OpenPairing(ctx context.Context, windowSeconds int) (*PairingResponse, error) {
+	var resp PairingResponse
+	if err := c.Do(ctx, http.MethodPost, "/api/v1/pairing", OpenPairingRequest{WindowSeconds: windowSeconds}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ClosePairing closes the pairing window
+func (c *RemoteClient) ClosePairing(ctx context.Context) error {
+	return c.Do(ctx, http.MethodDelete, "/api/v1/pairing", nil, nil)
+}
+
+// APITokens returns the tokens issued by pairing
+func (c *RemoteClient) APITokens(ctx context.Context) ([]db.APIToken, error) {
+	var tokens []db.APIToken
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/tokens", nil, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// RevokeAPIToken revokes a token issued by pairing
+func (c *RemoteClient) RevokeAPIToken(ctx context.Context, id uint64) error {
+	return c.Do(ctx, http.MethodDelete, "/api/v1/tokens/"+strconv.FormatUint(id, 10), nil, nil)
+}
+
+// setPage sets the page and limit of a list query
+func setPage(query url.Values, page, limit int) {
+	if page > 0 {
+		query.Set("page", strconv.Itoa(page))
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+}
+
+// withQuery appends a query to a path, if it has any values
+func withQuery(path string, query url.Values) string {
+	if len(query) == 0 {
+		return path
+	}
+	return path + "?" + query.Encode()
+}
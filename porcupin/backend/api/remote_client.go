@@ -4,11 +4,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
+
+	"porcupin/backend/core"
+	"porcupin/backend/db"
 )
 
 // RemoteClient is an HTTP client for connecting to a remote Porcupin server
@@ -16,6 +25,7 @@ type RemoteClient struct {
 	baseURL    string
 	token      string
 	httpClient *http.Client
+	retry      *RetryPolicy // nil uses DefaultRetryPolicy
 }
 
 // HealthResponse represents the health check response
@@ -48,7 +58,7 @@ func NewRemoteClient(host string, port int, token string, useTLS bool) *RemoteCl
 	}
 	
 	return &RemoteClient{
-		baseURL: fmt.Sprintf("%s://%s:%d", protocol, host, port),
+		baseURL: protocol + "://" + net.JoinHostPort(host, strconv.Itoa(port)),
 		token:   token,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
@@ -56,43 +66,315 @@ func NewRemoteClient(host string, port int, token string, useTLS bool) *RemoteCl
 	}
 }
 
-// Health checks the server health
-func (c *RemoteClient) Health(ctx context.Context) (*HealthResponse, error) {
-	url := c.baseURL + "/api/v1/health"
-	log.Printf("RemoteClient: GET %s", url)
-	
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+// ParseRemoteAddress parses a server address given as host, host:port or
+// a URL such as https://host:port. The port defaults to 8085, and TLS is
+// used unless the address is an http URL, as servers serve TLS by default.
+func ParseRemoteAddress(addr string) (host string, port int, useTLS bool, err error) {
+	hostPort := addr
+	useTLS = true
+	if scheme, rest, ok := strings.Cut(addr, "://"); ok {
+		switch scheme {
+		case "http":
+			useTLS = false
+		case "https":
+		default:
+			return "", 0, false, fmt.Errorf("unsupported scheme %q", scheme)
+		}
+		hostPort = strings.TrimSuffix(rest, "/")
+	}
+
+	port = DefaultServerConfig().Port
+	if h, p, splitErr := net.SplitHostPort(hostPort); splitErr == nil {
+		host = h
+		port, err = strconv.Atoi(p)
+		if err != nil || port < 1 || port > 65535 {
+			return "", 0, false, fmt.Errorf("invalid port %q", p)
+		}
+	} else {
+		host = strings.Trim(hostPort, "[]")
+	}
+	if host == "" || strings.ContainsAny(host, "/?#") {
+		return "", 0, false, fmt.Errorf("invalid server address %q", addr)
+	}
+	return host, port, useTLS, nil
+}
+
+// Errors an *APIError matches with errors.Is, by its code or, for servers
+// that send none, its status
+var (
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrForbidden          = errors.New("forbidden")
+	ErrNotFound           = errors.New("not found")
+	ErrBadRequest         = errors.New("bad request")
+	ErrConflict           = errors.New("conflict")
+	ErrRateLimited        = errors.New("rate limited")
+	ErrInternal           = errors.New("internal server error")
+	ErrServiceUnavailable = errors.New("service unavailable")
+)
+
+// APIError is an error response from the server
+type APIError struct {
+	StatusCode int
+	Code       string // e.g. NOT_FOUND
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("server returned %d", e.StatusCode)
+	}
+	return fmt.Sprintf("server returned %d: %s", e.StatusCode, e.Message)
+}
+
+// Unwrap returns the Err* value for the error's code, or nil
+func (e *APIError) Unwrap() error {
+	switch e.Code {
+	case ErrCodeUnauthorized:
+		return ErrUnauthorized
+	case ErrCodeForbidden:
+		return ErrForbidden
+	case ErrCodeNotFound:
+		return ErrNotFound
+	case ErrCodeBadRequest:
+		return ErrBadRequest
+	case ErrCodeConflict:
+		return ErrConflict
+	case ErrCodeRateLimited:
+		return ErrRateLimited
+	case ErrCodeInternalError:
+		return ErrInternal
+	case ErrCodeServiceUnavail:
+		return ErrServiceUnavailable
+	}
+	switch e.StatusCode {
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusBadRequest:
+		return ErrBadRequest
+	case http.StatusConflict:
+		return ErrConflict
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusServiceUnavailable:
+		return ErrServiceUnavailable
+	}
+	if e.StatusCode >= 500 {
+		return ErrInternal
+	}
+	return nil
+}
+
+// RetryPolicy controls how requests are retried. Requests are retried when
+// the server is rate limiting, and, unless they change something, when the
+// connection fails or a proxy in front of the server can't reach it.
+type RetryPolicy struct {
+	MaxAttempts int           // Including the first; 1 disables retries
+	MinBackoff  time.Duration // Wait before the first retry, doubled for each next one
+	MaxBackoff  time.Duration // Also caps the server's Retry-After
+}
+
+// DefaultRetryPolicy is used by clients without a policy of their own
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  250 * time.Millisecond,
+	MaxBackoff:  5 * time.Second,
+}
+
+// SetRetryPolicy changes how the client retries requests
+func (c *RemoteClient) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = &policy
+}
+
+type noRetryKey struct{}
+
+// WithoutRetries returns a context whose requests are sent only once, e.g.
+// to check quickly whether a server is up
+func WithoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryKey{}, true)
+}
+
+// retryPolicy returns the policy for a request
+func (c *RemoteClient) retryPolicy(ctx context.Context) RetryPolicy {
+	if ctx.Value(noRetryKey{}) != nil {
+		return RetryPolicy{MaxAttempts: 1}
+	}
+	if c.retry != nil {
+		return *c.retry
+	}
+	return DefaultRetryPolicy
+}
+
+// idempotent reports whether a request can be repeated without changing the
+// outcome
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// transient reports whether a failed connection may succeed when retried.
+// Certificate errors and timeouts are not retried.
+func transient(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// Do sends a JSON request to an API path such as /api/v1/wallets and
+// decodes the data of the response into out, if out is not nil. A []byte
+// body is sent as is, and a *[]byte out receives the response as is, for
+// files. A response other than 2xx is returned as an *APIError. Failed
+// requests are retried according to the client's RetryPolicy until ctx is
+// done.
+func (c *RemoteClient) Do(ctx context.Context, method, path string, body, out interface{}) error {
+	return c.do(ctx, method, path, body, out, true)
+}
+
+// do sends a request, decoding the response's data, or the whole response
+// if it isn't wrapped
+func (c *RemoteClient) do(ctx context.Context, method, path string, body, out interface{}, wrapped bool) error {
+	var data []byte
+	switch body := body.(type) {
+	case nil:
+	case []byte:
+		data = body
+	default:
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	policy := c.retryPolicy(ctx)
+	backoff := policy.MinBackoff
+	for attempt := 1; ; attempt++ {
+		respBody, retryAfter, err := c.send(ctx, method, path, data)
+		if err == nil {
+			return decodeResponse(respBody, out, wrapped)
+		}
+		if attempt >= policy.MaxAttempts || !c.retryable(method, err) {
+			return err
+		}
+
+		wait := backoff
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		if policy.MaxBackoff > 0 && wait > policy.MaxBackoff {
+			wait = policy.MaxBackoff
+		}
+		backoff *= 2
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable reports whether a failed request should be sent again
+func (c *RemoteClient) retryable(method string, err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return idempotent(method) && transient(err)
+	}
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests:
+		return true // Rejected before it was handled
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent(method)
+	}
+	return false
+}
+
+// send sends a request once. It returns the body of a 2xx response, or an
+// error and how long the server asked to wait before retrying.
+func (c *RemoteClient) send(ctx context.Context, method, path string, data []byte) ([]byte, time.Duration, error) {
+	var bodyReader io.Reader
+	if data != nil {
+		bodyReader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
 	}
-	
 	req.Header.Set("Authorization", "Bearer "+c.token)
-	req.Header.Set("Content-Type", "application/json")
-	
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		log.Printf("RemoteClient: Request failed: %v", err)
-		return nil, fmt.Errorf("connection failed: %w", err)
+		return nil, 0, fmt.Errorf("connection failed: %w", err)
 	}
 	defer resp.Body.Close()
-	
-	log.Printf("RemoteClient: Response status: %d", resp.StatusCode)
-	
-	body, err := io.ReadAll(resp.Body)
+
+	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, 0, fmt.Errorf("failed to read response: %w", err)
 	}
-	
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("RemoteClient: Error response: %s", string(body))
-		return nil, fmt.Errorf("server returned %d: %s", resp.StatusCode, string(body))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var retryAfter time.Duration
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+		return nil, retryAfter, newAPIError(resp.StatusCode, respBody)
 	}
-	
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, 0, nil
+	}
+	return respBody, 0, nil
+}
+
+// newAPIError builds the error for a response other than 2xx
+func newAPIError(status int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: status}
+	var errResp ErrorResponse
+	if json.Unmarshal(body, &errResp) == nil && errResp.Error.Code != "" {
+		apiErr.Code, apiErr.Message = errResp.Error.Code, errResp.Error.Message
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
+
+// decodeResponse decodes a response body into out, unwrapping its data
+func decodeResponse(body []byte, out interface{}, wrapped bool) error {
+	if raw, ok := out.(*[]byte); ok {
+		*raw = body
+		return nil
+	}
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	var err error
+	if wrapped {
+		err = json.Unmarshal(body, &struct {
+			Data interface{} `json:"data"`
+		}{Data: out})
+	} else {
+		err = json.Unmarshal(body, out)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// Health checks the server health
+func (c *RemoteClient) Health(ctx context.Context) (*HealthResponse, error) {
 	var health HealthResponse
-	if err := json.Unmarshal(body, &health); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/health", nil, &health, false); err != nil {
+		return nil, err
 	}
-	
 	return &health, nil
 }
 
@@ -149,3 +431,28 @@ func (c *RemoteClient) Proxy(ctx context.Context, proxyReq ProxyRequest) (*Proxy
 		Body:       string(body),
 	}, nil
 }
+
+// Catalog reads a page of the server's replication catalog.
+// If since is set, only content pinned after it is returned.
+func (c *RemoteClient) Catalog(ctx context.Context, since *time.Time, offset, limit int) (*core.CatalogPage, error) {
+	query := url.Values{}
+	if since != nil {
+		query.Set("since", since.UTC().Format(time.RFC3339Nano))
+	}
+	query.Set("offset", strconv.Itoa(offset))
+	query.Set("limit", strconv.Itoa(limit))
+
+	var page core.CatalogPage
+	if err := c.Do(ctx, http.MethodGet, "/api/v1/replication/catalog?"+query.Encode(), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// CatalogDialer connects the replicator to peers through the REST API
+func CatalogDialer(peer *db.ReplicationPeer) core.CatalogSource {
+	if peer.UseTLS && peer.TLSPin != "" {
+		return NewPinnedRemoteClient(peer.Host, peer.Port, peer.Token, peer.TLSPin)
+	}
+	return NewRemoteClient(peer.Host, peer.Port, peer.Token, peer.UseTLS)
+}
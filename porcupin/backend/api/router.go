@@ -14,6 +14,8 @@ import (
 type RouterConfig struct {
 	Token           string
 	TokenHash       string
+	LocalToken      string // Token from the local endpoint file, for the CLI
+	IssuedTokens    func(token string) bool // Validates tokens issued by pairing
 	AllowPublic     bool
 	RateLimiter     *RateLimiter
 	EnableLogging   bool
@@ -73,7 +75,7 @@ func NewRouterWithConfig(handlers *Handlers, cfg RouterConfig) *chi.Mux {
 	r.Use(IPFilterMiddleware(cfg.AllowPublic))
 
 	// 7. Authentication
-	r.Use(AuthMiddleware(cfg.Token, cfg.TokenHash))
+	r.Use(AuthMiddlewareWithIssued(cfg.Token, cfg.TokenHash, cfg.IssuedTokens, cfg.LocalToken))
 
 	// Mount routes AFTER middleware
 	mountRoutes(r, handlers)
@@ -89,6 +91,7 @@ func mountRoutes(r *chi.Mux, handlers *Handlers) {
 		r.Get("/health", handlers.GetHealth)   // No auth required (handled in AuthMiddleware)
 		r.Get("/version", handlers.GetVersion)
 		r.Get("/status", handlers.GetStatus)
+		r.Get("/openapi.json", handlers.GetOpenAPI) // No auth required (handled in AuthMiddleware)
 
 		// Statistics
 		r.Get("/stats", handlers.GetStats)
@@ -97,6 +100,8 @@ func mountRoutes(r *chi.Mux, handlers *Handlers) {
 		// Wallets CRUD
 		r.Get("/wallets", handlers.GetWallets)
 		r.Post("/wallets", handlers.AddWallet)
+		r.Post("/wallets/import", handlers.ImportWallets)
+		r.Get("/wallets/export", handlers.ExportWallets)
 		r.Get("/wallets/{address}", handlers.GetWallet)
 		r.Put("/wallets/{address}", handlers.UpdateWallet)
 		r.Delete("/wallets/{address}", handlers.DeleteWallet)
@@ -104,13 +109,23 @@ func mountRoutes(r *chi.Mux, handlers *Handlers) {
 
 		// NFTs
 		r.Get("/nfts", handlers.GetNFTs)
+		r.Get("/nfts/{id}/history", handlers.GetNFTHistory)
+
+		// Creators and collections
+		r.Get("/creators", handlers.GetCreators)
+		r.Get("/creators/{address}", handlers.GetCreator)
+		r.Get("/collections", handlers.GetCollections)
+		r.Get("/collections/{address}", handlers.GetCollection)
 
 		// Assets
 		r.Get("/assets", handlers.GetAssets)
 		r.Get("/assets/failed", handlers.GetFailedAssets)
 		r.Post("/assets/retry-failed", handlers.RetryAllFailed)
 		r.Delete("/assets/failed", handlers.ClearFailed)
+		r.Post("/assets/reconcile", handlers.ReconcilePins)
 		r.Post("/assets/{id}/retry", handlers.RetryAsset)
+		r.Post("/assets/{id}/pin-next", handlers.PinAssetNext)
+		r.Get("/assets/{id}/remote-pins", handlers.GetAssetRemotePins)
 		r.Delete("/assets/{id}", handlers.DeleteAsset)
 
 		// Control
@@ -120,7 +135,47 @@ func mountRoutes(r *chi.Mux, handlers *Handlers) {
 		r.Post("/gc", handlers.RunGC)
 		r.Post("/verify-and-fix", handlers.VerifyAndFixPins)
 
+		// Jobs
+		r.Get("/jobs", handlers.GetJobs)
+		r.Post("/jobs", handlers.SubmitJob)
+		r.Get("/jobs/{id}", handlers.GetJob)
+		r.Post("/jobs/{id}/cancel", handlers.CancelJob)
+		r.Post("/jobs/{id}/resume", handlers.ResumeJob)
+
+		// Pin queue
+		r.Get("/queue", handlers.GetQueue)
+
+		// Replication
+		r.Get("/replication/catalog", handlers.GetCatalog)
+		r.Get("/replication/peers", handlers.GetReplicationPeers)
+		r.Post("/replication/peers", handlers.AddReplicationPeer)
+		r.Get("/replication/peers/{id}", handlers.GetReplicationPeer)
+		r.Delete("/replication/peers/{id}", handlers.DeleteReplicationPeer)
+		r.Post("/replication/peers/{id}/sync", handlers.SyncReplicationPeer)
+
+		// Pinning services
+		r.Get("/pinning/services", handlers.GetPinningServices)
+		r.Post("/pinning/services", handlers.AddPinningService)
+		r.Get("/pinning/services/{id}", handlers.GetPinningService)
+		r.Put("/pinning/services/{id}", handlers.UpdatePinningService)
+		r.Delete("/pinning/services/{id}", handlers.DeletePinningService)
+		r.Get("/pinning/services/{id}/pins", handlers.GetPinningServicePins)
+		r.Post("/pinning/services/{id}/reconcile", handlers.ReconcilePinningService)
+
+		// Storage migration
+		r.Get("/storage/migration", handlers.GetStorageMigration)
+		r.Post("/storage/migration", handlers.StartStorageMigration)
+		r.Post("/storage/migration/cancel", handlers.CancelStorageMigration)
+		r.Get("/storage/migration/events", handlers.GetStorageMigrationEvents)
+
 		// Discovery
 		r.Get("/discover", handlers.DiscoverServers)
+
+		// Pairing and issued tokens
+		r.Post("/pairing", handlers.OpenPairing)
+		r.Delete("/pairing", handlers.ClosePairing)
+		r.Post("/pairing/complete", handlers.CompletePairing) // No auth required (handled in AuthMiddleware)
+		r.Get("/tokens", handlers.GetAPITokens)
+		r.Delete("/tokens/{id}", handlers.RevokeAPIToken)
 	})
 }
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"os"
 	"sync"
 	"time"
 
@@ -51,6 +52,15 @@ type ServerConfig struct {
 
 	// TLSKey is the path to the TLS private key file
 	TLSKey string
+
+	// SelfSignedTLS serves TLS with a certificate generated in DataDir when
+	// TLSCert and TLSKey are not set. Clients pin its fingerprint, which is
+	// advertised over mDNS.
+	SelfSignedTLS bool
+
+	// ACME obtains a CA-signed certificate instead, for servers reachable
+	// under a public domain name. It can't be combined with TLSCert.
+	ACME *ACMEConfig
 }
 
 // DefaultServerConfig returns a ServerConfig with secure defaults
@@ -66,6 +76,7 @@ func DefaultServerConfig() ServerConfig {
 		GlobalRateLimit: 100, // 100 requests per second global
 		TLSCert:         "",
 		TLSKey:          "",
+		SelfSignedTLS:   true,
 	}
 }
 
@@ -112,10 +123,18 @@ func (s *Server) Start(ctx context.Context) error {
 		s.handlers.SetIPFS(s.ipfs)
 	}
 
+	// Token for the CLI on this host, published in the endpoint file
+	localToken, err := GenerateToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate local token: %w", err)
+	}
+
 	// Create chi router with handlers and full middleware stack
 	routerCfg := RouterConfig{
 		Token:         s.config.Token,
 		TokenHash:     s.config.TokenHash,
+		LocalToken:    localToken,
+		IssuedTokens:  IssuedTokenValidator(s.database),
 		AllowPublic:   s.config.AllowPublic,
 		RateLimiter:   s.rateLimiter,
 		EnableLogging: true,
@@ -134,8 +153,15 @@ func (s *Server) Start(ctx context.Context) error {
 		MaxHeaderBytes:    1 << 20, // 1MB
 	}
 
-	// Configure TLS if certificates provided
-	if s.config.TLSCert != "" && s.config.TLSKey != "" {
+	// Configure TLS with the certificates provided, from ACME, or our own.
+	// fingerprint pins a fixed certificate for local clients; a generated
+	// one is also announced, since no CA vouches for it.
+	var fingerprint, announced, serverName string
+	switch {
+	case s.config.TLSCert != "" && s.config.TLSKey != "":
+		if s.config.ACME != nil {
+			return fmt.Errorf("TLS certificate files and ACME can't be used together")
+		}
 		cert, err := tls.LoadX509KeyPair(s.config.TLSCert, s.config.TLSKey)
 		if err != nil {
 			return fmt.Errorf("failed to load TLS certificates: %w", err)
@@ -144,7 +170,31 @@ func (s *Server) Start(ctx context.Context) error {
 			Certificates: []tls.Certificate{cert},
 			MinVersion:   tls.VersionTLS12,
 		}
+		fingerprint = CertFingerprint(cert)
+	case s.config.ACME != nil:
+		manager, err := newACMEManager(*s.config.ACME, s.config.DataDir, s.config.BindAddress)
+		if err != nil {
+			return err
+		}
+		defer manager.Stop()
+		if s.httpServer.TLSConfig, err = manager.Start(ctx); err != nil {
+			return err
+		}
+		// Renewals replace the certificate, so it is checked by name
+		serverName = s.config.ACME.Domains[0]
+	case s.config.SelfSignedTLS && s.config.DataDir != "":
+		cert, err := LoadOrCreateSelfSignedCert(s.config.DataDir)
+		if err != nil {
+			return fmt.Errorf("failed to set up TLS: %w", err)
+		}
+		s.httpServer.TLSConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+		fingerprint = CertFingerprint(cert)
+		announced = fingerprint
 	}
+	useTLS := s.httpServer.TLSConfig != nil
 
 	// Start listening
 	listener, err := net.Listen("tcp", addr)
@@ -158,16 +208,39 @@ func (s *Server) Start(ctx context.Context) error {
 	s.mu.Unlock()
 
 	protocol := "http"
-	if s.httpServer.TLSConfig != nil {
+	if useTLS {
 		protocol = "https"
 		listener = tls.NewListener(listener, s.httpServer.TLSConfig)
 	}
+	pairingHost := ""
+	if ip := net.ParseIP(s.config.BindAddress); ip != nil && !ip.IsUnspecified() {
+		pairingHost = s.config.BindAddress
+	}
+	s.handlers.SetPairingEndpoint(pairingHost, listener.Addr().(*net.TCPAddr).Port, useTLS, announced)
 
 	log.Printf("API server listening on %s://%s", protocol, addr)
+	if fingerprint != "" {
+		log.Printf("TLS certificate fingerprint (SHA-256): %s", fingerprint)
+	}
+
+	// Let commands on this host reach the server instead of opening its data
+	if s.config.DataDir != "" {
+		endpoint := &LocalEndpoint{
+			URL:         localEndpointURL(s.listenAddr, useTLS),
+			Token:       localToken,
+			PID:         os.Getpid(),
+			Fingerprint: fingerprint,
+			ServerName:  serverName,
+		}
+		if err := writeLocalEndpoint(s.config.DataDir, endpoint); err != nil {
+			log.Printf("Warning: failed to write %s: %v", LocalEndpointFileName, err)
+		} else {
+			defer removeLocalEndpoint(s.config.DataDir, endpoint)
+		}
+	}
 
 	// Start mDNS announcement
-	useTLS := s.httpServer.TLSConfig != nil
-	s.mdns = NewMDNSServer(s.config.Port, s.config.Version, useTLS)
+	s.mdns = NewMDNSServer(s.config.Port, s.config.Version, useTLS, announced)
 	if err := s.mdns.Start(); err != nil {
 		log.Printf("Warning: mDNS announcement failed: %v", err)
 		// Non-fatal - server still works without mDNS
@@ -210,7 +283,7 @@ func (s *Server) printStartupWarnings() {
 	log.Println("├─────────────────────────────────────────────────────────────┤")
 
 	// Warning 1: No TLS
-	if s.config.TLSCert == "" || s.config.TLSKey == "" {
+	if !s.usesTLS() {
 		log.Println("│ ⚠️  WARNING: No TLS - traffic is unencrypted                │")
 		log.Println("│    For internet exposure, use a reverse proxy with TLS     │")
 	}
@@ -219,6 +292,10 @@ func (s *Server) printStartupWarnings() {
 	if s.config.AllowPublic {
 		log.Println("│ ⚠️  WARNING: --allow-public is enabled                     │")
 		log.Println("│    Public IP addresses can connect to this server         │")
+		if s.usesTLS() && s.config.TLSCert == "" && s.config.ACME == nil {
+			log.Println("│    The certificate is self-signed; for a CA-signed one,   │")
+			log.Println("│    set --acme-domain                                      │")
+		}
 	}
 
 	// Security reminders
@@ -231,7 +308,7 @@ func (s *Server) printStartupWarnings() {
 	log.Println("└─────────────────────────────────────────────────────────────┘")
 	
 	protocol := "http"
-	if s.config.TLSCert != "" && s.config.TLSKey != "" {
+	if s.usesTLS() {
 		protocol = "https"
 	}
 	log.Printf("Bind: %s://%s:%d | Public IPs: %v",
@@ -241,6 +318,12 @@ func (s *Server) printStartupWarnings() {
 		s.config.AllowPublic)
 }
 
+// usesTLS reports whether the configuration enables TLS
+func (s *Server) usesTLS() bool {
+	return (s.config.TLSCert != "" && s.config.TLSKey != "") || s.config.ACME != nil ||
+		(s.config.SelfSignedTLS && s.config.DataDir != "")
+}
+
 // GetListenAddress returns the address the server is listening on
 func (s *Server) GetListenAddress() string {
 	s.mu.RLock()
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SelfSignedCertFileName is the certificate the server generates in the
+	// data directory when no --tls-cert is given
+	SelfSignedCertFileName = "tls-cert.pem"
+
+	// SelfSignedKeyFileName is the private key of the generated certificate
+	SelfSignedKeyFileName = "tls-key.pem"
+
+	// selfSignedValidity is how long a generated certificate is valid. Clients
+	// pin it rather than checking a chain, so it is long-lived; delete the
+	// files to get a new one.
+	selfSignedValidity = 10 * 365 * 24 * time.Hour
+)
+
+// ErrFingerprintMismatch is returned when a server presents a certificate
+// other than the one pinned for it
+var ErrFingerprintMismatch = errors.New("server certificate does not match the pinned fingerprint")
+
+// LoadOrCreateSelfSignedCert loads the server's self-signed certificate from
+// the data directory, generating it on first use
+func LoadOrCreateSelfSignedCert(dataDir string) (tls.Certificate, error) {
+	certPath := filepath.Join(dataDir, SelfSignedCertFileName)
+	keyPath := filepath.Join(dataDir, SelfSignedKeyFileName)
+
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err == nil {
+		return cert, nil
+	}
+	if _, statErr := os.Stat(certPath); !errors.Is(statErr, os.ErrNotExist) {
+		return tls.Certificate{}, fmt.Errorf("failed to load %s: %w", SelfSignedCertFileName, err)
+	}
+
+	certPEM, keyPEM, err := generateSelfSignedCert(time.Now())
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to generate certificate: %w", err)
+	}
+	// Key first, so a certificate on disk always has its key
+	if err := os.WriteFile(keyPath, keyPEM, TokenFileMode); err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to write %s: %w", SelfSignedKeyFileName, err)
+	}
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to write %s: %w", SelfSignedCertFileName, err)
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// generateSelfSignedCert creates a P-256 certificate naming this host, its
+// .local name and loopback
+func generateSelfSignedCert(now time.Time) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hostname, _ := os.Hostname()
+	dnsNames := []string{"localhost"}
+	if hostname != "" && hostname != "localhost" {
+		hostname = strings.TrimSuffix(hostname, ".local")
+		dnsNames = append(dnsNames, hostname, hostname+".local")
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "Porcupin " + hostname, Organization: []string{"Porcupin"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              dnsNames,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// CertFingerprint returns the hex SHA-256 of a certificate's leaf, the value
+// clients pin
+func CertFingerprint(cert tls.Certificate) string {
+	if len(cert.Certificate) == 0 {
+		return ""
+	}
+	return fingerprint(cert.Certificate[0])
+}
+
+// fingerprint returns the hex SHA-256 of a DER certificate
+func fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// NormalizeFingerprint lowercases a fingerprint and strips the colons and
+// spaces it is often displayed with
+func NormalizeFingerprint(fp string) string {
+	fp = strings.NewReplacer(":", "", " ", "").Replace(fp)
+	return strings.ToLower(fp)
+}
+
+// pinnedTLSConfig accepts only the certificate with the given fingerprint.
+// Chain and hostname checks are skipped: the pin is stronger than either.
+func pinnedTLSConfig(want string) *tls.Config {
+	want = NormalizeFingerprint(want)
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: true, // Checked against the fingerprint instead
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return fmt.Errorf("server sent no certificate")
+			}
+			got := fingerprint(cs.PeerCertificates[0].Raw)
+			if subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+				return fmt.Errorf("%w (got %s)", ErrFingerprintMismatch, got)
+			}
+			return nil
+		},
+	}
+}
+
+// NewPinnedRemoteClient creates a client for a TLS server that must present
+// the certificate with the given fingerprint
+func NewPinnedRemoteClient(host string, port int, token, pin string) *RemoteClient {
+	client := NewRemoteClient(host, port, token, true)
+	client.httpClient.Transport = &http.Transport{TLSClientConfig: pinnedTLSConfig(pin)}
+	return client
+}
+
+// ProbeCertificate connects to a TLS server and returns the fingerprint of
+// the certificate it presents, and whether that certificate is trusted by
+// the system's certificate authorities for host
+func ProbeCertificate(ctx context.Context, host string, port int) (fp string, trusted bool, err error) {
+	dialer := &tls.Dialer{Config: &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: true, // Verified below, to report the fingerprint either way
+	}}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return "", false, err
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return "", false, fmt.Errorf("server sent no certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+	_, verifyErr := certs[0].Verify(x509.VerifyOptions{DNSName: host, Intermediates: intermediates})
+	return fingerprint(certs[0].Raw), verifyErr == nil, nil
+}
+
+// TrustOnFirstUse returns a client for a TLS server seen for the first time.
+// A certificate from a trusted authority is checked as usual on every
+// request; any other certificate, such as the one a server generates for
+// itself, is pinned, and its fingerprint returned for the caller to save and
+// pass to NewPinnedRemoteClient from then on.
+func TrustOnFirstUse(ctx context.Context, host string, port int, token string) (client *RemoteClient, pinned string, err error) {
+	fp, trusted, err := ProbeCertificate(ctx, host, port)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to connect: %w", err)
+	}
+	if trusted {
+		return NewRemoteClient(host, port, token, true), "", nil
+	}
+	return NewPinnedRemoteClient(host, port, token, fp), fp, nil
+}
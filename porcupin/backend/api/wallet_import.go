@@ -0,0 +1,346 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"porcupin/backend/db"
+)
+
+// Bulk wallet import and export. A wallet file is CSV with the columns
+// address, alias, sync_owned and sync_created, or JSON: an array of objects
+// with the same fields. Exports use the same formats, so a file exported
+// from one node imports into another.
+
+// Wallet file formats
+const (
+	WalletFileCSV  = "csv"
+	WalletFileJSON = "json"
+)
+
+// MaxWalletImportRows bounds the number of wallets in one import
+const MaxWalletImportRows = 10000
+
+// walletFileColumns are the CSV columns, in the order they are exported
+// and assumed when a file has no header
+var walletFileColumns = []string{"address", "alias", "sync_owned", "sync_created"}
+
+// WalletEntry is one wallet in a wallet file. Sync flags left out take the
+// importing node's defaults.
+type WalletEntry struct {
+	Address     string `json:"address"`
+	Alias       string `json:"alias,omitempty"`
+	SyncOwned   *bool  `json:"sync_owned,omitempty"`
+	SyncCreated *bool  `json:"sync_created,omitempty"`
+}
+
+// WalletImportRow is a wallet read from a file. Problem says why the row
+// can't be imported, if it can't.
+type WalletImportRow struct {
+	Line    int // Line of a CSV file, or position in a JSON array, from 1
+	Entry   WalletEntry
+	Problem string
+}
+
+// Outcomes of importing a row
+const (
+	WalletImportAdded     = "added"     // Added, or would be in a dry run
+	WalletImportExists    = "exists"    // Already tracked, left unchanged
+	WalletImportDuplicate = "duplicate" // Listed earlier in the same file
+	WalletImportInvalid   = "invalid"   // Not a valid wallet
+)
+
+// WalletImportResult is the outcome of importing one row
+type WalletImportResult struct {
+	Line    int    `json:"line"`
+	Address string `json:"address"`
+	Alias   string `json:"alias,omitempty"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// WalletImportReport is the outcome of an import, row by row
+type WalletImportReport struct {
+	DryRun     bool                 `json:"dry_run"`
+	Added      int                  `json:"added"`
+	Existing   int                  `json:"existing"`
+	Duplicates int                  `json:"duplicates"`
+	Invalid    int                  `json:"invalid"`
+	Results    []WalletImportResult `json:"results"`
+}
+
+// AddedAddresses returns the addresses the import added
+func (r *WalletImportReport) AddedAddresses() []string {
+	var addresses []string
+	for _, result := range r.Results {
+		if result.Status == WalletImportAdded {
+			addresses = append(addresses, result.Address)
+		}
+	}
+	return addresses
+}
+
+// WalletImportOptions control an import
+type WalletImportOptions struct {
+	DryRun      bool // Report what would happen without adding anything
+	SyncOwned   bool // For rows that don't set sync_owned
+	SyncCreated bool // For rows that don't set sync_created
+}
+
+// DetectWalletFileFormat guesses the format of a wallet file from its
+// content
+func DetectWalletFileFormat(data []byte) string {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\ufeff")))
+	if len(data) > 0 && (data[0] == '[' || data[0] == '{') {
+		return WalletFileJSON
+	}
+	return WalletFileCSV
+}
+
+// ParseWalletFile reads the wallets in a wallet file; an empty format is
+// detected from the content. Rows that can't be read are returned with a
+// Problem; an error means the file as a whole can't be read.
+func ParseWalletFile(data []byte, format string) ([]WalletImportRow, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff")) // Byte order mark, as written by spreadsheets
+	if format == "" {
+		format = DetectWalletFileFormat(data)
+	}
+
+	var rows []WalletImportRow
+	var err error
+	switch strings.ToLower(format) {
+	case WalletFileCSV:
+		rows, err = parseWalletCSV(data)
+	case WalletFileJSON:
+		rows, err = parseWalletJSON(data)
+	default:
+		return nil, fmt.Errorf("unknown wallet file format %q (expected csv or json)", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("no wallets in file")
+	}
+	if len(rows) > MaxWalletImportRows {
+		return nil, fmt.Errorf("too many wallets in file (%d, at most %d)", len(rows), MaxWalletImportRows)
+	}
+	return rows, nil
+}
+
+func parseWalletJSON(data []byte) ([]WalletImportRow, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("invalid JSON (expected an array of wallets): %w", err)
+	}
+	rows := make([]WalletImportRow, 0, len(items))
+	for i, item := range items {
+		row := WalletImportRow{Line: i + 1}
+		if err := json.Unmarshal(item, &row.Entry); err != nil {
+			row.Problem = "invalid wallet: " + err.Error()
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseWalletCSV(data []byte) ([]WalletImportRow, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	columns := walletFileColumns
+	var rows []WalletImportRow
+	for first := true; ; first = false {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		line, _ := r.FieldPos(0)
+
+		if first && !IsValidTezosAddress(strings.TrimSpace(record[0])) {
+			if header, ok := walletCSVHeader(record); ok {
+				columns = header
+				continue
+			}
+		}
+
+		row := WalletImportRow{Line: line}
+		var problems []string
+		for i, value := range record {
+			if i >= len(columns) {
+				break
+			}
+			value = strings.TrimSpace(value)
+			switch columns[i] {
+			case "address":
+				row.Entry.Address = value
+			case "alias":
+				row.Entry.Alias = value
+			case "sync_owned", "sync_created":
+				if value == "" {
+					continue
+				}
+				b, err := strconv.ParseBool(value)
+				if err != nil {
+					problems = append(problems, columns[i]+" must be true or false")
+					continue
+				}
+				if columns[i] == "sync_owned" {
+					row.Entry.SyncOwned = &b
+				} else {
+					row.Entry.SyncCreated = &b
+				}
+			}
+		}
+		row.Problem = strings.Join(problems, "; ")
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// walletCSVHeader reads a header row, returning false if record isn't one.
+// Columns it doesn't know are ignored.
+func walletCSVHeader(record []string) ([]string, bool) {
+	columns := make([]string, len(record))
+	hasAddress := false
+	for i, name := range record {
+		columns[i] = strings.ToLower(strings.TrimSpace(name))
+		if columns[i] == "address" {
+			hasAddress = true
+		}
+	}
+	return columns, hasAddress
+}
+
+// ImportWallets adds the wallets in rows that aren't tracked yet. Invalid
+// rows, wallets already tracked and repeats within the file are reported
+// and skipped. The wallets are added together, so an error adds none.
+func ImportWallets(database *db.Database, rows []WalletImportRow, opts WalletImportOptions) (*WalletImportReport, error) {
+	existing, err := database.GetAllWallets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallets: %w", err)
+	}
+	tracked := make(map[string]bool, len(existing))
+	for _, wallet := range existing {
+		tracked[wallet.Address] = true
+	}
+
+	report := &WalletImportReport{DryRun: opts.DryRun, Results: make([]WalletImportResult, 0, len(rows))}
+	seen := map[string]bool{}
+	var wallets []db.Wallet
+	for _, row := range rows {
+		address := strings.TrimSpace(row.Entry.Address)
+		result := WalletImportResult{Line: row.Line, Address: address, Alias: row.Entry.Alias}
+		switch {
+		case row.Problem != "":
+			result.Status, result.Error = WalletImportInvalid, row.Problem
+		case address == "":
+			result.Status, result.Error = WalletImportInvalid, "address is required"
+		case !IsValidTezosAddress(address):
+			result.Status, result.Error = WalletImportInvalid, "invalid Tezos address format"
+		case seen[address]:
+			result.Status = WalletImportDuplicate
+		case tracked[address]:
+			result.Status = WalletImportExists
+		default:
+			result.Status = WalletImportAdded
+			wallet := db.Wallet{
+				Address:     address,
+				Alias:       row.Entry.Alias,
+				SyncOwned:   opts.SyncOwned,
+				SyncCreated: opts.SyncCreated,
+			}
+			if row.Entry.SyncOwned != nil {
+				wallet.SyncOwned = *row.Entry.SyncOwned
+			}
+			if row.Entry.SyncCreated != nil {
+				wallet.SyncCreated = *row.Entry.SyncCreated
+			}
+			wallets = append(wallets, wallet)
+		}
+		if result.Status != WalletImportInvalid {
+			seen[address] = true
+		}
+
+		switch result.Status {
+		case WalletImportAdded:
+			report.Added++
+		case WalletImportExists:
+			report.Existing++
+		case WalletImportDuplicate:
+			report.Duplicates++
+		case WalletImportInvalid:
+			report.Invalid++
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	if opts.DryRun || len(wallets) == 0 {
+		return report, nil
+	}
+	err = database.Transaction(func(tx *gorm.DB) error {
+		txdb := &db.Database{DB: tx}
+		for i := range wallets {
+			if err := txdb.CreateWallet(&wallets[i]); err != nil {
+				return fmt.Errorf("failed to add wallet %s: %w", wallets[i].Address, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// WalletEntries returns wallets as entries of a wallet file
+func WalletEntries(wallets []db.Wallet) []WalletEntry {
+	entries := make([]WalletEntry, len(wallets))
+	for i, wallet := range wallets {
+		syncOwned, syncCreated := wallet.SyncOwned, wallet.SyncCreated
+		entries[i] = WalletEntry{
+			Address:     wallet.Address,
+			Alias:       wallet.Alias,
+			SyncOwned:   &syncOwned,
+			SyncCreated: &syncCreated,
+		}
+	}
+	return entries
+}
+
+// WriteWalletFile writes wallets to w as a wallet file
+func WriteWalletFile(w io.Writer, wallets []db.Wallet, format string) error {
+	switch strings.ToLower(format) {
+	case WalletFileJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(WalletEntries(wallets))
+	case WalletFileCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(walletFileColumns)
+		for _, wallet := range wallets {
+			cw.Write([]string{
+				wallet.Address,
+				wallet.Alias,
+				strconv.FormatBool(wallet.SyncOwned),
+				strconv.FormatBool(wallet.SyncCreated),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unknown wallet file format %q (expected csv or json)", format)
+}
@@ -102,6 +102,57 @@ func PrintStats(nfts, total, pinned, pending, failed int64, storageGB float64) {
 	}
 }
 
+// WalletStat is one wallet's pinned storage for PrintWalletStats
+type WalletStat struct {
+	Address  string
+	Alias    string
+	UsedGB   float64
+	SharedGB float64
+	QuotaGB  int // 0 = unlimited
+}
+
+// PrintWalletStats prints each wallet's storage usage against its quota
+func PrintWalletStats(wallets []WalletStat) {
+	if len(wallets) == 0 {
+		return
+	}
+	useColor := shouldShowBanner()
+
+	if useColor {
+		fmt.Printf("%s%sWallet Usage%s\n", Bold, White, Reset)
+		fmt.Println()
+	} else {
+		fmt.Println("Wallet Usage")
+	}
+
+	for _, w := range wallets {
+		name := w.Address
+		if w.Alias != "" {
+			name = fmt.Sprintf("%s (%s)", w.Alias, w.Address)
+		}
+		quota := "unlimited"
+		if w.QuotaGB > 0 {
+			quota = fmt.Sprintf("%d GB", w.QuotaGB)
+		}
+		usage := fmt.Sprintf("%.2f GB / %s (%.2f GB shared)", w.UsedGB, quota, w.SharedGB)
+
+		if !useColor {
+			fmt.Printf("  %s\n    %s\n", name, usage)
+			continue
+		}
+		color := Green
+		if w.QuotaGB > 0 && w.UsedGB >= float64(w.QuotaGB) {
+			color = Yellow
+		}
+		fmt.Printf("  %s%s%s\n    %s%s%s\n", Dim, name, Reset, color, usage, Reset)
+	}
+
+	if useColor {
+		fmt.Println()
+		fmt.Println(hrule(logoWidth))
+	}
+}
+
 // shouldShowBanner checks if we should display the ASCII banner
 func shouldShowBanner() bool {
 	// Respect NO_COLOR environment variable (https://no-color.org/)
@@ -2,6 +2,9 @@ package cli
 
 import (
 	"bytes"
+	"errors"
+	"flag"
+	"fmt"
 	"io"
 	"os"
 	"strings"
@@ -133,6 +136,38 @@ func TestPrintStatsNoColor(t *testing.T) {
 	}
 }
 
+func TestPrintWalletStatsNoColor(t *testing.T) {
+	os.Setenv("NO_COLOR", "1")
+	defer os.Unsetenv("NO_COLOR")
+
+	output := captureOutput(func() {
+		PrintWalletStats([]WalletStat{
+			{Address: "tz1abc", Alias: "studio", UsedGB: 12.5, SharedGB: 1.25, QuotaGB: 20},
+			{Address: "tz1def", UsedGB: 3},
+		})
+	})
+
+	expected := []string{"Wallet Usage", "studio (tz1abc)", "12.50 GB / 20 GB", "1.25 GB shared", "tz1def", "3.00 GB / unlimited"}
+	for _, val := range expected {
+		if !strings.Contains(output, val) {
+			t.Errorf("PrintWalletStats output should contain %q, got:\n%s", val, output)
+		}
+	}
+
+	if strings.Contains(output, "\033[") {
+		t.Error("PrintWalletStats should not contain ANSI codes when NO_COLOR is set")
+	}
+}
+
+func TestPrintWalletStatsEmpty(t *testing.T) {
+	output := captureOutput(func() {
+		PrintWalletStats(nil)
+	})
+	if output != "" {
+		t.Errorf("PrintWalletStats(nil) should print nothing, got %q", output)
+	}
+}
+
 func TestPrintBannerWithVersionNoColor(t *testing.T) {
 	os.Setenv("NO_COLOR", "1")
 	defer os.Unsetenv("NO_COLOR")
@@ -292,3 +327,138 @@ func TestShouldShowBannerRespectsNoColorValues(t *testing.T) {
 		os.Unsetenv("NO_COLOR")
 	}
 }
+
+// =============================================================================
+// COMMAND TESTS
+// =============================================================================
+
+// testProgram records how its commands were run
+type testProgram struct {
+	data   string
+	ran    string
+	args   []string
+	flag   string
+	stderr bytes.Buffer
+}
+
+func newTestProgram(t *testing.T) (*testProgram, *Program) {
+	t.Helper()
+	tp := &testProgram{}
+	leaf := func(name string, err error) *Command {
+		return &Command{Name: name, Args: "[arg...]", Summary: "Run " + name, Setup: func(fs *flag.FlagSet) func(args []string) error {
+			value := fs.String("name", "", "A command flag")
+			return func(args []string) error {
+				tp.ran, tp.args, tp.flag = name, args, *value
+				return err
+			}
+		}}
+	}
+	return tp, &Program{
+		Name: "porcupin",
+		Globals: func(fs *flag.FlagSet) {
+			fs.StringVar(&tp.data, "data", "default", "Data directory")
+		},
+		Default: []string{"run"},
+		Commands: []*Command{
+			leaf("run", nil),
+			{Name: "wallet", Commands: []*Command{
+				leaf("add", nil),
+				leaf("rm", Errorf(ExitNotFound, "wallet not tracked")),
+			}},
+			leaf("verify", Errorf(ExitPartial, "3 missing")),
+			leaf("fail", errors.New("boom")),
+		},
+		Stderr: &tp.stderr,
+	}
+}
+
+func TestProgram_Dispatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		code    int
+		ran     string
+		data    string
+		cmdArgs []string
+		cmdFlag string
+	}{
+		{"default command", nil, ExitOK, "run", "default", nil, ""},
+		{"globals before default", []string{"--data", "/d"}, ExitOK, "run", "/d", nil, ""},
+		{"subcommand", []string{"wallet", "add", "tz1a"}, ExitOK, "add", "default", []string{"tz1a"}, ""},
+		{"globals before command", []string{"--data=/d", "wallet", "add", "tz1a"}, ExitOK, "add", "/d", []string{"tz1a"}, ""},
+		{"flags after arguments", []string{"wallet", "add", "tz1a", "--name", "Me", "--data", "/d"}, ExitOK, "add", "/d", []string{"tz1a"}, "Me"},
+		{"flags between arguments", []string{"wallet", "add", "a", "--name=Me", "b"}, ExitOK, "add", "default", []string{"a", "b"}, "Me"},
+		{"double dash ends flags", []string{"wallet", "add", "--", "--name"}, ExitOK, "add", "default", []string{"--name"}, ""},
+		{"exit code from error", []string{"wallet", "rm", "tz1a"}, ExitNotFound, "rm", "default", []string{"tz1a"}, ""},
+		{"partial failure", []string{"verify"}, ExitPartial, "verify", "default", nil, ""},
+		{"plain error", []string{"fail"}, ExitFailure, "fail", "default", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, p := newTestProgram(t)
+			if code := p.Run(tt.args); code != tt.code {
+				t.Fatalf("exit code = %d, want %d (stderr: %s)", code, tt.code, tp.stderr.String())
+			}
+			if tp.ran != tt.ran || tp.data != tt.data || tp.flag != tt.cmdFlag {
+				t.Errorf("ran %q with data=%q name=%q, want %q with data=%q name=%q", tp.ran, tp.data, tp.flag, tt.ran, tt.data, tt.cmdFlag)
+			}
+			if strings.Join(tp.args, " ") != strings.Join(tt.cmdArgs, " ") {
+				t.Errorf("args = %q, want %q", tp.args, tt.cmdArgs)
+			}
+		})
+	}
+}
+
+func TestProgram_UsageErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code int
+		want string
+	}{
+		{"unknown command", []string{"bogus"}, ExitUsage, `unknown command "porcupin bogus"`},
+		{"unknown subcommand", []string{"wallet", "bogus"}, ExitUsage, `unknown command "porcupin wallet bogus"`},
+		{"group without subcommand", []string{"wallet"}, ExitUsage, "add [arg...]  Run add"},
+		{"unknown flag", []string{"wallet", "add", "--bogus"}, ExitUsage, "flag provided but not defined"},
+		{"unknown global flag", []string{"--bogus"}, ExitUsage, "flag provided but not defined"},
+		{"help", []string{"help"}, ExitOK, "wallet rm [arg...]"},
+		{"command help", []string{"wallet", "add", "-h"}, ExitOK, "Usage: porcupin wallet add [flags] [arg...]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, p := newTestProgram(t)
+			if code := p.Run(tt.args); code != tt.code {
+				t.Fatalf("exit code = %d, want %d", code, tt.code)
+			}
+			if tp.ran != "" {
+				t.Errorf("ran %q, want nothing", tp.ran)
+			}
+			if !strings.Contains(tp.stderr.String(), tt.want) {
+				t.Errorf("stderr missing %q:\n%s", tt.want, tp.stderr.String())
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	wrapped := fmt.Errorf("sync: %w", Errorf(ExitPartial, "2 failed"))
+	if got := ExitCode(wrapped); got != ExitPartial {
+		t.Errorf("ExitCode(wrapped) = %d, want %d", got, ExitPartial)
+	}
+	if got := ExitCode(nil); got != ExitOK {
+		t.Errorf("ExitCode(nil) = %d, want %d", got, ExitOK)
+	}
+	if got := ExitCode(errors.New("x")); got != ExitFailure {
+		t.Errorf("ExitCode(plain) = %d, want %d", got, ExitFailure)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, map[string]int{"pinned": 3}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "{\n  \"pinned\": 3\n}\n" {
+		t.Errorf("WriteJSON = %q", buf.String())
+	}
+}
@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Exit codes returned by porcupin commands
+const (
+	ExitOK       = 0 // Success
+	ExitFailure  = 1 // The command failed
+	ExitUsage    = 2 // Unknown command, bad flag or missing argument
+	ExitConfig   = 3 // The config file or environment is invalid
+	ExitNotFound = 4 // The wallet or asset doesn't exist
+	ExitPartial  = 5 // The command ran but some items failed
+	ExitInUse    = 6 // A running daemon holds the data directory and can't run the command
+)
+
+// ExitError is an error that sets the exit code
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// Errorf returns an error that exits with code
+func Errorf(code int, format string, args ...interface{}) error {
+	return &ExitError{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// ExitCode returns the exit code for an error returned by a command
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	default:
+		return ExitFailure
+	}
+}
+
+// Command is a command such as "gc" or a group such as "wallet" holding
+// subcommands. A command with no subcommands must have Setup.
+type Command struct {
+	Name    string
+	Args    string // Synopsis of the arguments, e.g. "<address>"
+	Summary string
+
+	// Setup registers the command's flags and returns the function that
+	// runs it with the arguments left after the flags
+	Setup func(fs *flag.FlagSet) func(args []string) error
+
+	Commands []*Command
+}
+
+// Program dispatches a command line to its command
+type Program struct {
+	Name     string
+	Globals  func(fs *flag.FlagSet) // Flags every command accepts, e.g. --data
+	Default  []string               // Command line run when none is given
+	Commands []*Command
+	Stderr   io.Writer // Errors and help; defaults to os.Stderr
+}
+
+// Run runs a command line (without the program name) and returns the exit
+// code. Flags may come before the command or among its arguments.
+func (p *Program) Run(args []string) int {
+	if p.Stderr == nil {
+		p.Stderr = os.Stderr
+	}
+
+	// Global flags may precede the command
+	globals := flag.NewFlagSet(p.Name, flag.ContinueOnError)
+	globals.SetOutput(io.Discard)
+	if p.Globals != nil {
+		p.Globals(globals)
+	}
+	if err := globals.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			p.printHelp(p.Name, p.Commands)
+			return ExitOK
+		}
+		fmt.Fprintf(p.Stderr, "Error: %v\n", err)
+		p.printHelp(p.Name, p.Commands)
+		return ExitUsage
+	}
+	rest := globals.Args()
+	if len(rest) == 0 {
+		rest = p.Default
+	}
+	if len(rest) == 0 || rest[0] == "help" {
+		p.printHelp(p.Name, p.Commands)
+		return ExitOK
+	}
+
+	// Walk down the command groups
+	path := p.Name
+	commands := p.Commands
+	for {
+		cmd := find(commands, rest[0])
+		if cmd == nil {
+			fmt.Fprintf(p.Stderr, "Error: unknown command %q\n", strings.TrimSpace(path+" "+rest[0]))
+			p.printHelp(path, commands)
+			return ExitUsage
+		}
+		path += " " + cmd.Name
+		rest = rest[1:]
+		if len(cmd.Commands) == 0 {
+			return p.runCommand(globals, path, cmd, rest)
+		}
+		if len(rest) == 0 || rest[0] == "-h" || rest[0] == "--help" || rest[0] == "help" {
+			p.printHelp(path, cmd.Commands)
+			if len(rest) == 0 {
+				return ExitUsage
+			}
+			return ExitOK
+		}
+		commands = cmd.Commands
+	}
+}
+
+// runCommand parses the flags of a command and runs it. Global flags given
+// before the command are kept.
+func (p *Program) runCommand(globals *flag.FlagSet, path string, cmd *Command, args []string) int {
+	fs := flag.NewFlagSet(path, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if p.Globals != nil {
+		given := make(map[string]string)
+		globals.Visit(func(f *flag.Flag) {
+			given[f.Name] = f.Value.String()
+		})
+		p.Globals(fs) // Resets the shared variables to their defaults
+		for name, value := range given {
+			fs.Set(name, value)
+		}
+	}
+	run := cmd.Setup(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		p.printUsage(path, cmd, fs)
+		return ExitOK
+	}
+	if err != nil {
+		fmt.Fprintf(p.Stderr, "Error: %v\n", err)
+		p.printUsage(path, cmd, fs)
+		return ExitUsage
+	}
+
+	err = run(positional)
+	if err != nil {
+		fmt.Fprintf(p.Stderr, "Error: %v\n", err)
+		if ExitCode(err) == ExitUsage {
+			p.printUsage(path, cmd, fs)
+		}
+	}
+	return ExitCode(err)
+}
+
+// parseInterspersed parses flags that may appear before, between or after
+// the positional arguments, where the flag package stops at the first
+// argument. Everything after "--" is positional.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var trailing []string
+	for i, arg := range args {
+		if arg == "--" {
+			args, trailing = args[:i], args[i+1:]
+			break
+		}
+	}
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return append(positional, trailing...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func find(commands []*Command, name string) *Command {
+	for _, c := range commands {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// printHelp lists the commands of a group
+func (p *Program) printHelp(path string, commands []*Command) {
+	fmt.Fprintf(p.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", path)
+	var lines [][2]string
+	width := 0
+	var walk func(prefix string, cs []*Command)
+	walk = func(prefix string, cs []*Command) {
+		for _, c := range cs {
+			if len(c.Commands) > 0 {
+				walk(prefix+c.Name+" ", c.Commands)
+				continue
+			}
+			name := strings.TrimSpace(prefix + c.Name + " " + c.Args)
+			if len(name) > width {
+				width = len(name)
+			}
+			lines = append(lines, [2]string{name, c.Summary})
+		}
+	}
+	walk("", commands)
+	for _, l := range lines {
+		fmt.Fprintf(p.Stderr, "  %-*s  %s\n", width, l[0], l[1])
+	}
+	fmt.Fprintf(p.Stderr, "\nRun '%s <command> -h' for a command's flags.\n", p.Name)
+}
+
+// printUsage shows a command's arguments and flags
+func (p *Program) printUsage(path string, cmd *Command, fs *flag.FlagSet) {
+	fmt.Fprintf(p.Stderr, "Usage: %s\n", strings.TrimSpace(path+" [flags] "+cmd.Args))
+	if cmd.Summary != "" {
+		fmt.Fprintf(p.Stderr, "\n%s\n", cmd.Summary)
+	}
+	fmt.Fprintln(p.Stderr, "\nFlags:")
+	fs.SetOutput(p.Stderr)
+	fs.PrintDefaults()
+	fs.SetOutput(io.Discard)
+}
+
+// WriteJSON writes v as indented JSON, for commands run with --json
+func WriteJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
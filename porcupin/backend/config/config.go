@@ -16,6 +16,11 @@ type Config struct {
 	Backup BackupConfig `yaml:"backup"`
 	TZKT   TZKTConfig   `yaml:"tzkt"`
 	API    APIConfig    `yaml:"api"`
+
+	Replication ReplicationConfig `yaml:"replication"`
+	Mirror      MirrorConfig      `yaml:"mirror"`
+
+	overrides []override // Fields set from the environment, see ApplyEnv
 }
 
 // IPFSConfig holds IPFS-specific configuration
@@ -25,6 +30,18 @@ type IPFSConfig struct {
 	MaxFileSize int64         `yaml:"max_file_size" json:"max_file_size"`       // in bytes
 	PinTimeout  time.Duration `yaml:"pin_timeout" json:"pin_timeout"`           // timeout for pin operations
 	RateLimit   int           `yaml:"rate_limit_mbps" json:"rate_limit_mbps"`   // bandwidth limit in Mbps
+	Tiering     TieringConfig `yaml:"tiering" json:"tiering"`                   // hot/cold block placement
+}
+
+// TieringConfig places blocks across the repo (hot tier) and a second
+// volume (cold tier). Assets that are recent or small stay hot; the rest of
+// the eligible types are moved cold. Tiering is off while ColdPath is empty.
+type TieringConfig struct {
+	ColdPath  string        `yaml:"cold_path" json:"cold_path"`   // cold tier location, e.g. a USB disk
+	ColdTypes []string      `yaml:"cold_types" json:"cold_types"` // asset types that may be moved cold
+	MinSize   int64         `yaml:"min_size" json:"min_size"`     // in bytes; smaller assets stay hot
+	MinAge    time.Duration `yaml:"min_age" json:"min_age"`       // time since pinning; newer assets stay hot
+	Interval  time.Duration `yaml:"interval" json:"interval"`     // how often placement rules are applied
 }
 
 // ServerConfig holds server configuration
@@ -44,11 +61,45 @@ type BackupConfig struct {
 	StorageWarningPct   int  `yaml:"storage_warning_pct" json:"storage_warning_pct"`       // warn when storage reaches this % (default 80)
 	SyncOwned           bool `yaml:"sync_owned" json:"sync_owned"`                         // default: sync owned NFTs for new wallets
 	SyncCreated         bool `yaml:"sync_created" json:"sync_created"`                     // default: sync created NFTs for new wallets
+	Queue               QueueConfig `yaml:"queue" json:"queue"`                            // pin queue ordering
+	PreviousVersions    string      `yaml:"previous_versions" json:"previous_versions"`    // PreviousVersionsKeep (or "") or PreviousVersionsUnpin
+}
+
+// What to do with the assets of metadata versions an update replaced
+const (
+	PreviousVersionsKeep  = "keep"  // Keep them pinned, as a record of the earlier versions
+	PreviousVersionsUnpin = "unpin" // Unpin the ones no current metadata uses
+)
+
+// QueueConfig holds the weights used to order the pin queue.
+// Wallets are always served round-robin; these tune the order within that.
+type QueueConfig struct {
+	WalletPriorityWeight float64 `yaml:"wallet_priority_weight" json:"wallet_priority_weight"` // extra turns per wallet priority point
+	AssetTypeWeight      float64 `yaml:"asset_type_weight" json:"asset_type_weight"`           // artifacts before display/format/thumbnail
+	SizeWeight           float64 `yaml:"size_weight" json:"size_weight"`                       // smaller files first
+	AgeWeight            float64 `yaml:"age_weight" json:"age_weight"`                         // older pending assets first
+}
+
+// ReplicationConfig holds settings for replicating other Porcupin nodes.
+// Peers themselves are added through the API and stored in the database.
+type ReplicationConfig struct {
+	SyncInterval     time.Duration `yaml:"sync_interval" json:"sync_interval"`           // how often each peer's catalog is checked
+	FullSyncInterval time.Duration `yaml:"full_sync_interval" json:"full_sync_interval"` // how often the whole catalog is compared to find removals
+	MaxRetries       int           `yaml:"max_retries" json:"max_retries"`               // attempts per CID before it stays failed
+}
+
+// MirrorConfig holds settings for mirroring pins to IPFS pinning services.
+// Services themselves are added through the API and stored in the database.
+type MirrorConfig struct {
+	ReconcileInterval time.Duration `yaml:"reconcile_interval" json:"reconcile_interval"` // how often each service is compared with local pins
+	MaxRetries        int           `yaml:"max_retries" json:"max_retries"`               // times a CID the service failed to pin is requested again
 }
 
 // TZKTConfig holds TZKT API configuration
 type TZKTConfig struct {
-	BaseURL string `yaml:"base_url"`
+	BaseURL               string        `yaml:"base_url"`
+	DomainRefreshInterval  time.Duration `yaml:"domain_refresh_interval" json:"domain_refresh_interval"`   // how often wallets' Tezos Domains names are looked up again
+	ProfileRefreshInterval time.Duration `yaml:"profile_refresh_interval" json:"profile_refresh_interval"` // how often creator profiles and collection metadata are looked up again
 }
 
 // APIConfig holds REST API server configuration
@@ -83,6 +134,13 @@ func DefaultConfig() *Config {
 			MaxFileSize: 5 * 1024 * 1024 * 1024, // 5GB
 			PinTimeout:  2 * time.Minute,
 			RateLimit:   10, // 10 Mbps
+			Tiering: TieringConfig{
+				ColdPath:  "", // tiering off
+				ColdTypes: []string{"artifact", "display"},
+				MinSize:   10 * 1024 * 1024, // 10MB
+				MinAge:    7 * 24 * time.Hour,
+				Interval:  time.Hour,
+			},
 		},
 		Server: ServerConfig{
 			BindAddress: "127.0.0.1:8080", // localhost only by default
@@ -98,9 +156,27 @@ func DefaultConfig() *Config {
 			StorageWarningPct:  80,   // warn at 80%
 			SyncOwned:          true, // sync owned by default
 			SyncCreated:        true, // sync created by default
+			PreviousVersions:   PreviousVersionsKeep,
+			Queue: QueueConfig{
+				WalletPriorityWeight: 1.0,
+				AssetTypeWeight:      1.0,
+				SizeWeight:           0.5,
+				AgeWeight:            0.25,
+			},
 		},
 		TZKT: TZKTConfig{
-			BaseURL: "https://api.tzkt.io",
+			BaseURL:                "https://api.tzkt.io",
+			DomainRefreshInterval:  24 * time.Hour,
+			ProfileRefreshInterval: 7 * 24 * time.Hour,
+		},
+		Replication: ReplicationConfig{
+			SyncInterval:     15 * time.Minute,
+			FullSyncInterval: 24 * time.Hour,
+			MaxRetries:       3,
+		},
+		Mirror: MirrorConfig{
+			ReconcileInterval: time.Hour,
+			MaxRetries:        3,
 		},
 		API: APIConfig{
 			Enabled:     false,
@@ -120,30 +196,37 @@ func DefaultConfig() *Config {
 	}
 }
 
-// LoadConfig loads configuration from a YAML file
+// LoadConfig loads configuration in layers: defaults, then the YAML file,
+// then PORCUPIN_* environment variables. The result is validated; a
+// *ValidationError lists every problem found.
 func LoadConfig(path string) (*Config, error) {
 	cfg := DefaultConfig()
 
 	data, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			// Return default config if file doesn't exist
-			return cfg, nil
-		}
+	if err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
+	// A missing file leaves the defaults
+	if err == nil {
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, err
+		}
+	}
 
-	if err := yaml.Unmarshal(data, cfg); err != nil {
+	if err := cfg.ApplyEnv(os.Environ()); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
-
 	return cfg, nil
 }
 
 // SaveConfig saves configuration to a YAML file
 // Uses atomic write pattern: write to temp file, sync, then rename
+// Fields set from the environment keep their file values.
 func (c *Config) SaveConfig(path string) error {
-	data, err := yaml.Marshal(c)
+	data, err := yaml.Marshal(c.fileView())
 	if err != nil {
 		return err
 	}
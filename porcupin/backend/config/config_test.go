@@ -1,10 +1,16 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -48,17 +54,38 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.Backup.StorageWarningPct != 80 {
 		t.Errorf("Backup.StorageWarningPct = %d, want 80", cfg.Backup.StorageWarningPct)
 	}
+	if cfg.Backup.PreviousVersions != PreviousVersionsKeep {
+		t.Errorf("Backup.PreviousVersions = %q, want %q", cfg.Backup.PreviousVersions, PreviousVersionsKeep)
+	}
 	if !cfg.Backup.SyncOwned {
 		t.Error("Backup.SyncOwned should be true by default")
 	}
 	if !cfg.Backup.SyncCreated {
 		t.Error("Backup.SyncCreated should be true by default")
 	}
+	if cfg.Backup.Queue.AssetTypeWeight <= 0 || cfg.Backup.Queue.WalletPriorityWeight <= 0 {
+		t.Errorf("Backup.Queue = %+v, want positive asset type and wallet priority weights", cfg.Backup.Queue)
+	}
+	if cfg.Replication.SyncInterval <= 0 || cfg.Replication.FullSyncInterval < cfg.Replication.SyncInterval {
+		t.Errorf("Replication = %+v, want a positive sync interval no longer than the full sync interval", cfg.Replication)
+	}
+	if cfg.Mirror.ReconcileInterval <= 0 || cfg.Mirror.MaxRetries <= 0 {
+		t.Errorf("Mirror = %+v, want a positive reconcile interval and retry count", cfg.Mirror)
+	}
+	if cfg.IPFS.Tiering.ColdPath != "" || cfg.IPFS.Tiering.Interval <= 0 || len(cfg.IPFS.Tiering.ColdTypes) == 0 {
+		t.Errorf("IPFS.Tiering = %+v, want tiering off with a positive interval and cold asset types", cfg.IPFS.Tiering)
+	}
 
 	// TZKT Defaults
 	if cfg.TZKT.BaseURL != "https://api.tzkt.io" {
 		t.Errorf("TZKT.BaseURL = %q, want 'https://api.tzkt.io'", cfg.TZKT.BaseURL)
 	}
+	if cfg.TZKT.DomainRefreshInterval != 24*time.Hour {
+		t.Errorf("TZKT.DomainRefreshInterval = %v, want 24h", cfg.TZKT.DomainRefreshInterval)
+	}
+	if cfg.TZKT.ProfileRefreshInterval != 7*24*time.Hour {
+		t.Errorf("TZKT.ProfileRefreshInterval = %v, want 168h", cfg.TZKT.ProfileRefreshInterval)
+	}
 }
 
 func TestLoadConfig_NonExistent(t *testing.T) {
@@ -90,6 +117,7 @@ func TestSaveAndLoadConfig(t *testing.T) {
 	cfg := &Config{
 		IPFS: IPFSConfig{
 			RepoPath:    "/custom/ipfs/path",
+			SwarmPort:   4002,
 			MaxFileSize: 10 * 1024 * 1024 * 1024, // 10GB
 			PinTimeout:  5 * time.Minute,
 			RateLimit:   50,
@@ -112,6 +140,9 @@ func TestSaveAndLoadConfig(t *testing.T) {
 		TZKT: TZKTConfig{
 			BaseURL: "https://custom.tzkt.io",
 		},
+		API: APIConfig{
+			Port: 9085,
+		},
 	}
 
 	// Save
@@ -223,7 +254,7 @@ func TestSaveConfig_AtomicWrite(t *testing.T) {
 
 	// Overwrite with new config
 	cfg2 := DefaultConfig()
-	cfg2.Backup.MaxConcurrency = 99
+	cfg2.Backup.MaxConcurrency = 42
 	if err := cfg2.SaveConfig(configPath); err != nil {
 		t.Fatalf("Second SaveConfig failed: %v", err)
 	}
@@ -233,8 +264,8 @@ func TestSaveConfig_AtomicWrite(t *testing.T) {
 	if err != nil {
 		t.Fatalf("LoadConfig failed: %v", err)
 	}
-	if loaded.Backup.MaxConcurrency != 99 {
-		t.Errorf("MaxConcurrency = %d, want 99 (atomic overwrite)", loaded.Backup.MaxConcurrency)
+	if loaded.Backup.MaxConcurrency != 42 {
+		t.Errorf("MaxConcurrency = %d, want 42 (atomic overwrite)", loaded.Backup.MaxConcurrency)
 	}
 
 	// Verify no temp files left behind
@@ -377,3 +408,201 @@ func TestServerConfig_Security(t *testing.T) {
 		t.Error("Auth credentials should be empty by default")
 	}
 }
+
+func TestDefaultConfig_Valid(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Errorf("Default config should be valid: %v", err)
+	}
+}
+
+func TestValidate_ReportsEveryProblem(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.IPFS.SwarmPort = 70000
+	cfg.Backup.MaxConcurrency = 0
+	cfg.Backup.PreviousVersions = "delete"
+	cfg.IPFS.Tiering.ColdPath = "/mnt/cold"
+	cfg.IPFS.Tiering.MinAge = -time.Hour
+	cfg.TZKT.BaseURL = "api.tzkt.io"
+	cfg.API.TLS.Cert = "/etc/porcupin/cert.pem"
+
+	err := cfg.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate() = %v, want a *ValidationError", err)
+	}
+	for _, field := range []string{"ipfs.swarm_port", "backup.max_concurrency", "backup.previous_versions", "ipfs.tiering.min_age", "tzkt.base_url", "api.tls"} {
+		found := false
+		for _, p := range verr.Problems {
+			if strings.HasPrefix(p, field+": ") {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("No problem reported for %s in %v", field, verr.Problems)
+		}
+	}
+	if len(verr.Problems) != 6 {
+		t.Errorf("Got %d problems, want 6: %v", len(verr.Problems), verr.Problems)
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(configPath, []byte("ipfs:\n  swarm_port: 0\n"), 0644)
+
+	_, err := LoadConfig(configPath)
+	if err == nil || !strings.Contains(err.Error(), "ipfs.swarm_port") {
+		t.Errorf("LoadConfig() = %v, want an error naming ipfs.swarm_port", err)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	cfg := DefaultConfig()
+	err := cfg.ApplyEnv([]string{
+		"PORCUPIN_IPFS_SWARM_PORT=4101",
+		"PORCUPIN_IPFS_PIN_TIMEOUT=90s",
+		"PORCUPIN_IPFS_TIERING_COLD_TYPES=artifact, display ,",
+		"PORCUPIN_BACKUP_SYNC_CREATED=false",
+		"PORCUPIN_BACKUP_QUEUE_SIZE_WEIGHT=0.75",
+		"PORCUPIN_TZKT_BASE_URL=https://api.ghostnet.tzkt.io",
+		"PORCUPIN_API_TOKEN=not-a-field",
+		"HOME=/root",
+	})
+	if err != nil {
+		t.Fatalf("ApplyEnv failed: %v", err)
+	}
+
+	if cfg.IPFS.SwarmPort != 4101 {
+		t.Errorf("SwarmPort = %d, want 4101", cfg.IPFS.SwarmPort)
+	}
+	if cfg.IPFS.PinTimeout != 90*time.Second {
+		t.Errorf("PinTimeout = %s, want 90s", cfg.IPFS.PinTimeout)
+	}
+	if !reflect.DeepEqual(cfg.IPFS.Tiering.ColdTypes, []string{"artifact", "display"}) {
+		t.Errorf("ColdTypes = %v, want [artifact display]", cfg.IPFS.Tiering.ColdTypes)
+	}
+	if cfg.Backup.SyncCreated {
+		t.Error("SyncCreated should be false")
+	}
+	if cfg.Backup.Queue.SizeWeight != 0.75 {
+		t.Errorf("SizeWeight = %v, want 0.75", cfg.Backup.Queue.SizeWeight)
+	}
+	if cfg.TZKT.BaseURL != "https://api.ghostnet.tzkt.io" {
+		t.Errorf("BaseURL = %q", cfg.TZKT.BaseURL)
+	}
+	if n := len(cfg.EnvOverrides()); n != 6 {
+		t.Errorf("EnvOverrides() has %d entries, want 6", n)
+	}
+}
+
+func TestApplyEnv_InvalidValues(t *testing.T) {
+	cfg := DefaultConfig()
+	err := cfg.ApplyEnv([]string{
+		"PORCUPIN_IPFS_SWARM_PORT=four",
+		"PORCUPIN_IPFS_PIN_TIMEOUT=soon",
+	})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Problems) != 2 {
+		t.Fatalf("ApplyEnv() = %v, want 2 problems", err)
+	}
+	if cfg.IPFS.SwarmPort != DefaultConfig().IPFS.SwarmPort {
+		t.Error("An invalid value should leave the field alone")
+	}
+}
+
+func TestUnknownEnv(t *testing.T) {
+	unknown := UnknownEnv([]string{
+		"PORCUPIN_IPFS_SWARM_PORT=4101",
+		"PORCUPIN_IPFS_SWARMPORT=4101",
+		"PORCUPIN_DEBUG=1",
+		"PATH=/usr/bin",
+	})
+	if !reflect.DeepEqual(unknown, []string{"PORCUPIN_IPFS_SWARMPORT"}) {
+		t.Errorf("UnknownEnv() = %v, want [PORCUPIN_IPFS_SWARMPORT]", unknown)
+	}
+}
+
+func TestSaveConfig_KeepsEnvOutOfFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+
+	cfg := DefaultConfig()
+	cfg.Backup.MaxConcurrency = 3
+	cfg.ApplyEnv([]string{"PORCUPIN_BACKUP_MAX_CONCURRENCY=12", "PORCUPIN_IPFS_SWARM_PORT=4101"})
+	cfg.Backup.MaxStorageGB = 50 // Changed while running
+	if err := cfg.SaveConfig(configPath); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+	if cfg.Backup.MaxConcurrency != 12 {
+		t.Error("SaveConfig should not change the running config")
+	}
+
+	data, _ := os.ReadFile(configPath)
+	saved := DefaultConfig()
+	if err := yaml.Unmarshal(data, saved); err != nil {
+		t.Fatalf("Saved config doesn't parse: %v", err)
+	}
+	if saved.Backup.MaxConcurrency != 3 || saved.IPFS.SwarmPort != 4001 {
+		t.Errorf("Environment values were saved: max_concurrency=%d swarm_port=%d", saved.Backup.MaxConcurrency, saved.IPFS.SwarmPort)
+	}
+	if saved.Backup.MaxStorageGB != 50 {
+		t.Errorf("max_storage_gb = %d, want the running value 50", saved.Backup.MaxStorageGB)
+	}
+}
+
+func TestDiffAndCopyFields(t *testing.T) {
+	a, b := DefaultConfig(), DefaultConfig()
+	b.Backup.MaxConcurrency = 9
+	b.IPFS.SwarmPort = 4101
+	b.Mirror.ReconcileInterval = time.Minute
+
+	changed := Diff(a, b)
+	want := []string{"ipfs.swarm_port", "backup.max_concurrency", "mirror.reconcile_interval"}
+	if !reflect.DeepEqual(changed, want) {
+		t.Fatalf("Diff() = %v, want %v", changed, want)
+	}
+
+	var safe []string
+	for _, f := range changed {
+		if Reloadable(f) {
+			safe = append(safe, f)
+		}
+	}
+	a.CopyFields(b, safe)
+	if a.Backup.MaxConcurrency != 9 || a.Mirror.ReconcileInterval != time.Minute {
+		t.Error("Reloadable fields should be copied")
+	}
+	if a.IPFS.SwarmPort != 4001 {
+		t.Error("ipfs.swarm_port is not reloadable and should not be copied")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	DefaultConfig().SaveConfig(configPath)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan []string, 4)
+	go Watch(ctx, configPath, 10*time.Millisecond, func(next *Config, changed []string) {
+		changes <- changed
+	})
+	time.Sleep(30 * time.Millisecond)
+
+	// An invalid edit is ignored
+	os.WriteFile(configPath, []byte("backup:\n  max_concurrency: 0\n"), 0644)
+	select {
+	case changed := <-changes:
+		t.Fatalf("Invalid config was applied: %v", changed)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	os.WriteFile(configPath, []byte("backup:\n  max_concurrency: 8\n"), 0644)
+	select {
+	case changed := <-changes:
+		if !reflect.DeepEqual(changed, []string{"backup.max_concurrency"}) {
+			t.Errorf("changed = %v, want [backup.max_concurrency]", changed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Config change was not picked up")
+	}
+}
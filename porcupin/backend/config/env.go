@@ -0,0 +1,217 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix starts the name of every environment variable that overrides a
+// config field. A field's variable is its YAML path in upper case with the
+// dots replaced by underscores: ipfs.swarm_port is PORCUPIN_IPFS_SWARM_PORT.
+const EnvPrefix = "PORCUPIN_"
+
+// reservedEnv are PORCUPIN_* variables that aren't config fields
+var reservedEnv = map[string]bool{
+	"PORCUPIN_API_TOKEN":   true,
+	"PORCUPIN_DEBUG":       true,
+	"PORCUPIN_FINGERPRINT": true,
+	"PORCUPIN_REMOTE":      true,
+}
+
+// EnvOverride is a config field set from the environment
+type EnvOverride struct {
+	Name  string `json:"name"`  // Variable name, e.g. PORCUPIN_IPFS_SWARM_PORT
+	Field string `json:"field"` // YAML path, e.g. ipfs.swarm_port
+}
+
+// override remembers the file's value of a field set from the environment,
+// so SaveConfig never writes environment values to the file
+type override struct {
+	EnvOverride
+	index     []int
+	fileValue reflect.Value
+}
+
+// field is a leaf config field
+type field struct {
+	path  string // YAML path
+	index []int  // reflect index into Config
+	typ   reflect.Type
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// fields lists every leaf field of Config by YAML path
+func fields() []field {
+	var out []field
+	var walk func(t reflect.Type, prefix string, index []int)
+	walk = func(t reflect.Type, prefix string, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+			if !f.IsExported() || name == "" || name == "-" {
+				continue
+			}
+			path := prefix + name
+			idx := append(append([]int{}, index...), i)
+			if f.Type.Kind() == reflect.Struct && f.Type != durationType {
+				walk(f.Type, path+".", idx)
+				continue
+			}
+			out = append(out, field{path: path, index: idx, typ: f.Type})
+		}
+	}
+	walk(reflect.TypeOf(Config{}), "", nil)
+	return out
+}
+
+// envName returns the environment variable for a YAML path
+func envName(path string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+// ApplyEnv overrides config fields from the PORCUPIN_* variables in environ
+// (as returned by os.Environ). Lists are comma separated and durations use
+// Go syntax such as 90s or 2h. Unknown variables are ignored.
+func (c *Config) ApplyEnv(environ []string) error {
+	vars := make(map[string]string)
+	for _, kv := range environ {
+		if name, value, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(name, EnvPrefix) {
+			vars[name] = value
+		}
+	}
+
+	root := reflect.ValueOf(c).Elem()
+	var problems []string
+	for _, f := range fields() {
+		name := envName(f.path)
+		raw, ok := vars[name]
+		if !ok {
+			continue
+		}
+		value, err := parseEnvValue(raw, f.typ)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+
+		dst := root.FieldByIndex(f.index)
+		fileValue := reflect.New(f.typ).Elem()
+		fileValue.Set(dst)
+		if i := c.overrideIndex(name); i >= 0 {
+			fileValue = c.overrides[i].fileValue // Applied twice, keep the file's value
+			c.overrides = append(c.overrides[:i], c.overrides[i+1:]...)
+		}
+		dst.Set(value)
+		c.overrides = append(c.overrides, override{
+			EnvOverride: EnvOverride{Name: name, Field: f.path},
+			index:       f.index,
+			fileValue:   fileValue,
+		})
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func (c *Config) overrideIndex(name string) int {
+	for i, o := range c.overrides {
+		if o.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// EnvOverrides lists the fields set from the environment
+func (c *Config) EnvOverrides() []EnvOverride {
+	out := make([]EnvOverride, len(c.overrides))
+	for i, o := range c.overrides {
+		out[i] = o.EnvOverride
+	}
+	return out
+}
+
+// UnknownEnv returns the PORCUPIN_* variables in environ that match no config
+// field, usually a typo
+func UnknownEnv(environ []string) []string {
+	known := make(map[string]bool)
+	for _, f := range fields() {
+		known[envName(f.path)] = true
+	}
+	var unknown []string
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, EnvPrefix) && !known[name] && !reservedEnv[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// fileView returns a copy of the config with the fields set from the
+// environment put back to their file values
+func (c *Config) fileView() *Config {
+	out := *c
+	out.overrides = nil
+	root := reflect.ValueOf(&out).Elem()
+	for _, o := range c.overrides {
+		root.FieldByIndex(o.index).Set(o.fileValue)
+	}
+	return &out
+}
+
+// parseEnvValue parses an environment variable for a field of type t
+func parseEnvValue(raw string, t reflect.Type) (reflect.Value, error) {
+	raw = strings.TrimSpace(raw)
+	v := reflect.New(t).Elem()
+
+	if t == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return v, fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(d))
+		return v, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return v, fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return v, fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return v, fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		items := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return v, fmt.Errorf("unsupported type %s", t)
+	}
+	return v, nil
+}
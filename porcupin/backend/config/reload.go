@@ -0,0 +1,116 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// WatchInterval is how often Watch checks the config file for changes
+const WatchInterval = 5 * time.Second
+
+// reloadable are the fields, or sections ending in ".", that take effect
+// without restarting the IPFS node or the API server
+var reloadable = []string{
+	"ipfs.max_file_size",
+	"ipfs.pin_timeout",
+	"ipfs.tiering.cold_types",
+	"ipfs.tiering.min_size",
+	"ipfs.tiering.min_age",
+	"ipfs.tiering.interval",
+	"backup.",
+	"tzkt.domain_refresh_interval",
+	"tzkt.profile_refresh_interval",
+	"replication.",
+	"mirror.",
+}
+
+// Reloadable reports whether a field, by YAML path, can change while running
+func Reloadable(path string) bool {
+	for _, r := range reloadable {
+		if path == r || (strings.HasSuffix(r, ".") && strings.HasPrefix(path, r)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff returns the YAML paths of the fields that differ between two configs
+func Diff(a, b *Config) []string {
+	va, vb := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+	var changed []string
+	for _, f := range fields() {
+		if !reflect.DeepEqual(va.FieldByIndex(f.index).Interface(), vb.FieldByIndex(f.index).Interface()) {
+			changed = append(changed, f.path)
+		}
+	}
+	return changed
+}
+
+// CopyFields sets the given fields, by YAML path, to their values in src
+func (c *Config) CopyFields(src *Config, paths []string) {
+	want := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		want[p] = true
+	}
+	dst, from := reflect.ValueOf(c).Elem(), reflect.ValueOf(src).Elem()
+	for _, f := range fields() {
+		if want[f.path] {
+			dst.FieldByIndex(f.index).Set(from.FieldByIndex(f.index))
+		}
+	}
+}
+
+// Watch checks the config file every interval until ctx is done. When the
+// file has changed and still loads and validates, onChange is called with
+// the new config and the fields that changed since the last load. A config
+// that fails to load is logged and ignored, keeping the running settings.
+func Watch(ctx context.Context, path string, interval time.Duration, onChange func(next *Config, changed []string)) {
+	current, err := LoadConfig(path)
+	if err != nil {
+		current = nil // The next good load is compared with the defaults
+	}
+	modTime, size := statConfig(path)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		mt, sz := statConfig(path)
+		if mt.Equal(modTime) && sz == size {
+			continue
+		}
+		modTime, size = mt, sz
+
+		next, err := LoadConfig(path)
+		if err != nil {
+			log.Printf("Config %s not reloaded: %v", path, err)
+			continue
+		}
+		base := current
+		if base == nil {
+			base = DefaultConfig()
+		}
+		current = next
+		if changed := Diff(base, next); len(changed) > 0 {
+			onChange(next, changed)
+		}
+	}
+}
+
+// statConfig returns the modification time and size of the config file
+func statConfig(path string) (time.Time, int64) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, -1
+	}
+	return info.ModTime(), info.Size()
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MaxConcurrencyLimit is the most pins that may run at once
+const MaxConcurrencyLimit = 64
+
+// ValidationError lists every problem found in a config
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+// validator collects problems with config fields
+type validator struct {
+	problems []string
+}
+
+func (v *validator) add(field, format string, args ...interface{}) {
+	v.problems = append(v.problems, field+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) port(field string, port int) {
+	if port < 1 || port > 65535 {
+		v.add(field, "must be a port between 1 and 65535 (got %d)", port)
+	}
+}
+
+func (v *validator) between(field string, n, min, max int) {
+	if n < min || n > max {
+		v.add(field, "must be between %d and %d (got %d)", min, max, n)
+	}
+}
+
+func (v *validator) notNegative(field string, n float64) {
+	if n < 0 {
+		v.add(field, "must not be negative (got %v)", n)
+	}
+}
+
+func (v *validator) duration(field string, d time.Duration) {
+	if d < 0 {
+		v.add(field, "must not be negative (got %s)", d)
+	}
+}
+
+func (v *validator) httpURL(field, raw string) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add(field, "must be an http or https URL (got %q)", raw)
+	}
+}
+
+// Validate checks every field and reports all problems at once
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	// IPFS
+	if strings.TrimSpace(c.IPFS.RepoPath) == "" {
+		v.add("ipfs.repo_path", "must be set")
+	}
+	v.port("ipfs.swarm_port", c.IPFS.SwarmPort)
+	if c.IPFS.MaxFileSize <= 0 {
+		v.add("ipfs.max_file_size", "must be more than 0 bytes (got %d)", c.IPFS.MaxFileSize)
+	}
+	if c.IPFS.PinTimeout <= 0 {
+		v.add("ipfs.pin_timeout", "must be more than 0 (got %s)", c.IPFS.PinTimeout)
+	}
+	v.notNegative("ipfs.rate_limit_mbps", float64(c.IPFS.RateLimit))
+	if t := c.IPFS.Tiering; t.ColdPath != "" {
+		if t.ColdPath == c.IPFS.RepoPath {
+			v.add("ipfs.tiering.cold_path", "must not be the repo path")
+		}
+		if len(t.ColdTypes) == 0 {
+			v.add("ipfs.tiering.cold_types", "must list at least one asset type")
+		}
+		v.notNegative("ipfs.tiering.min_size", float64(t.MinSize))
+		v.duration("ipfs.tiering.min_age", t.MinAge)
+		v.duration("ipfs.tiering.interval", t.Interval)
+	}
+
+	// Server
+	if c.Server.BindAddress != "" {
+		if _, port, err := net.SplitHostPort(c.Server.BindAddress); err != nil {
+			v.add("server.bind_address", "must be host:port (got %q)", c.Server.BindAddress)
+		} else if n, err := net.LookupPort("tcp", port); err != nil || n < 1 {
+			v.add("server.bind_address", "has an invalid port (got %q)", port)
+		}
+	}
+
+	// Backup
+	v.between("backup.max_concurrency", c.Backup.MaxConcurrency, 1, MaxConcurrencyLimit)
+	v.notNegative("backup.min_free_disk_space_gb", float64(c.Backup.MinFreeDiskSpaceGB))
+	if c.Backup.MaxMetadataSizeMB < 1 {
+		v.add("backup.max_metadata_size_mb", "must be at least 1 (got %d)", c.Backup.MaxMetadataSizeMB)
+	}
+	v.notNegative("backup.max_storage_gb", float64(c.Backup.MaxStorageGB))
+	v.between("backup.storage_warning_pct", c.Backup.StorageWarningPct, 0, 100)
+	v.notNegative("backup.queue.wallet_priority_weight", c.Backup.Queue.WalletPriorityWeight)
+	v.notNegative("backup.queue.asset_type_weight", c.Backup.Queue.AssetTypeWeight)
+	v.notNegative("backup.queue.size_weight", c.Backup.Queue.SizeWeight)
+	v.notNegative("backup.queue.age_weight", c.Backup.Queue.AgeWeight)
+	if p := c.Backup.PreviousVersions; p != "" && p != PreviousVersionsKeep && p != PreviousVersionsUnpin {
+		v.add("backup.previous_versions", "must be %q or %q (got %q)", PreviousVersionsKeep, PreviousVersionsUnpin, p)
+	}
+
+	// TZKT
+	v.httpURL("tzkt.base_url", c.TZKT.BaseURL)
+	v.duration("tzkt.domain_refresh_interval", c.TZKT.DomainRefreshInterval)
+	v.duration("tzkt.profile_refresh_interval", c.TZKT.ProfileRefreshInterval)
+
+	// API
+	v.port("api.port", c.API.Port)
+	if c.API.Bind != "" && net.ParseIP(c.API.Bind) == nil && c.API.Bind != "localhost" {
+		v.add("api.bind", "must be an IP address (got %q)", c.API.Bind)
+	}
+	if (c.API.TLS.Cert == "") != (c.API.TLS.Key == "") {
+		v.add("api.tls", "cert and key must be set together")
+	}
+	v.notNegative("api.rate_limit.per_ip", float64(c.API.RateLimit.PerIP))
+	v.notNegative("api.rate_limit.global", float64(c.API.RateLimit.Global))
+	v.notNegative("api.rate_limit.burst", float64(c.API.RateLimit.Burst))
+
+	// Replication and mirroring; a zero interval turns the schedule off
+	v.duration("replication.sync_interval", c.Replication.SyncInterval)
+	v.duration("replication.full_sync_interval", c.Replication.FullSyncInterval)
+	v.notNegative("replication.max_retries", float64(c.Replication.MaxRetries))
+	v.duration("mirror.reconcile_interval", c.Mirror.ReconcileInterval)
+	v.notNegative("mirror.max_retries", float64(c.Mirror.MaxRetries))
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
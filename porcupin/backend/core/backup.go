@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -14,6 +15,7 @@ import (
 	"porcupin/backend/config"
 	"porcupin/backend/db"
 	"porcupin/backend/indexer"
+	"porcupin/backend/storage"
 )
 
 // SyncProgress represents the current sync operation progress
@@ -46,9 +48,9 @@ type BackupManager struct {
 	ipfs     IPFSClient
 	indexer  *indexer.Indexer
 	db       *db.Database
-	config   *config.Config
+	config   atomic.Pointer[config.Config] // Replaced whole on changes, see Config
 	mu       sync.RWMutex
-	workers  chan struct{}
+	workers  *workerSlots
 	shutdown chan struct{}
 	
 	// Pause control
@@ -62,19 +64,41 @@ type BackupManager struct {
 	
 	// Disk usage tracking - update after pins, not on every pin
 	diskUsageDirty int32 // atomic flag: 1 if pins happened since last du
+	
+	// Pin queue, started on first use
+	queue      *PinQueue
+	queueOnce  sync.Once
+	queueCtx   context.Context
+	pinWorkers int // Pin queue workers running, guarded by mu
+	
+	// Per-wallet storage quotas
+	quotaMu sync.Mutex
+	quota   quotaState
+	
+	// Signals the storage monitor when a pin fails because the repo is unavailable
+	storageAlerts chan struct{}
 }
 
 // NewBackupManager creates a new backup manager
 func NewBackupManager(ipfsNode IPFSClient, idx *indexer.Indexer, database *db.Database, cfg *config.Config) *BackupManager {
-	return &BackupManager{
+	bm := &BackupManager{
 		ipfs:     ipfsNode,
 		indexer:  idx,
 		db:       database,
-		config:   cfg,
-		workers:  make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:  newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown: make(chan struct{}),
 		progress: SyncProgress{Phase: "idle"},
+		storageAlerts: make(chan struct{}, 1),
 	}
+	bm.config.Store(cfg)
+	return bm
+}
+
+// Config returns the settings in effect. Pin workers and schedules read it
+// concurrently, so it must not be modified: BackupService.UpdateConfig
+// replaces it with a changed copy instead.
+func (bm *BackupManager) Config() *config.Config {
+	return bm.config.Load()
 }
 
 // SetPaused sets the pause state
@@ -234,48 +258,56 @@ func (bm *BackupManager) SyncWallet(ctx context.Context, address string) (headLe
 
 	log.Printf("Found %d unique NFTs with %d unique assets for %s", len(tokenMap), totalAssets, address)
 
-	// 5. Process each NFT
+	// 5. Record each NFT and queue its assets. Pinning happens in the pin queue
+	// so that one large wallet cannot starve the others.
 	var wg sync.WaitGroup
 	processed := 0
+	queued := 0
 	total := len(tokenMap)
-	
-	for _, token := range tokenMap {
-		// Check for pause before starting new work
-		if bm.IsPaused() {
-			log.Printf("Sync paused, stopping NFT processing")
-			break
-		}
-		
+
+	workers := bm.Config().Backup.MaxConcurrency
+	if workers <= 0 {
+		workers = 1
+	}
+	priority := wallet.Priority
+	tokens := make(chan indexer.Token)
+
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
-		go func(t indexer.Token) {
+		go func() {
 			defer wg.Done()
-			defer func() {
-				if r := recover(); r != nil {
-					log.Printf("Panic processing NFT %s:%s - %v", t.Contract.Address, t.TokenID, r)
-				}
+			for t := range tokens {
+				n := bm.syncToken(ctx, address, priority, t)
 				// Update progress
 				bm.updateProgress(func(p *SyncProgress) {
 					p.ProcessedNFTs++
 					processed++
+					queued += n
 					if t.Metadata != nil && processed < total {
 						p.CurrentItem = t.Metadata.Name
 					} else {
 						p.CurrentItem = "Finishing..."
 					}
 				})
-			}()
-			
-			// Check pause inside goroutine too
-			if bm.IsPaused() {
-				return
 			}
-			
-			if err := bm.processNFT(ctx, address, t); err != nil {
-				log.Printf("Error processing NFT %s:%s - %v", t.Contract.Address, t.TokenID, err)
-			}
-		}(token)
+		}()
 	}
 
+feed:
+	for _, token := range tokenMap {
+		// Check for pause before starting new work
+		if bm.IsPaused() {
+			log.Printf("Sync paused, stopping NFT processing")
+			break
+		}
+		select {
+		case tokens <- token:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(tokens)
+
 	wg.Wait()
 	
 	// Update progress to show completion
@@ -284,7 +316,7 @@ func (bm *BackupManager) SyncWallet(ctx context.Context, address string) (headLe
 		if bm.IsPaused() {
 			p.Message = "Paused"
 		} else {
-			p.Message = fmt.Sprintf("Synced %d NFTs", total)
+			p.Message = fmt.Sprintf("Synced %d NFTs, queued %d assets", total, queued)
 		}
 	})
 	
@@ -292,6 +324,27 @@ func (bm *BackupManager) SyncWallet(ctx context.Context, address string) (headLe
 	return currentHead, nil
 }
 
+// syncToken records one NFT for SyncWallet and queues its assets.
+// Returns the number of assets queued.
+func (bm *BackupManager) syncToken(ctx context.Context, address string, priority int, t indexer.Token) (queued int) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic processing NFT %s:%s - %v", t.Contract.Address, t.TokenID, r)
+		}
+	}()
+
+	// Check pause again, the NFT may have waited for a worker
+	if bm.IsPaused() {
+		return 0
+	}
+
+	queued, err := bm.queueNFT(ctx, address, priority, t)
+	if err != nil {
+		log.Printf("Error processing NFT %s:%s - %v", t.Contract.Address, t.TokenID, err)
+	}
+	return queued
+}
+
 // countAssets counts how many IPFS assets are in token metadata
 func countAssets(m *indexer.TokenMetadata) int {
 	if m == nil {
@@ -349,22 +402,76 @@ func (bm *BackupManager) processNFT(ctx context.Context, walletAddr string, toke
 	}
 
 	// Acquire worker slot (semaphore pattern)
-	select {
-	case bm.workers <- struct{}{}:
-		defer func() { <-bm.workers }()
-	case <-ctx.Done():
-		return ctx.Err()
-	case <-bm.shutdown:
-		return fmt.Errorf("backup manager shutdown")
+	if err := bm.workers.acquire(ctx, bm.shutdown); err != nil {
+		return err
+	}
+	defer bm.workers.release()
+
+	nft, assets, err := bm.saveNFT(ctx, walletAddr, token)
+	if err != nil || nft == nil {
+		return err
 	}
 
+	for _, asset := range assets {
+		if err := bm.backupAsset(ctx, nft.ID, asset.uri, asset.assetType); err != nil {
+			log.Printf("Failed to backup asset %s - %v", asset.uri, err)
+		}
+	}
+
+	return nil
+}
+
+// setNFTMetadata copies the TZIP-21 fields kept for an NFT, beyond its name
+// and content, from its metadata
+func setNFTMetadata(nft *db.NFT, m *indexer.TokenMetadata) {
+	nft.Symbol = string(m.Symbol)
+	nft.Type = string(m.Type)
+	nft.Language = string(m.Language)
+	nft.Rights = string(m.Rights)
+	nft.RightsURI = string(m.RightURI)
+	nft.ExternalURI = string(m.ExternalURI)
+	nft.Date = m.DateTime()
+
+	for _, tag := range m.TagList() {
+		nft.Tags = append(nft.Tags, db.NFTTag{Tag: tag})
+	}
+	for _, a := range m.AttributeList() {
+		nft.Attributes = append(nft.Attributes, db.NFTAttribute{Name: a.Name, Value: a.Value, Type: a.Type})
+	}
+	for _, r := range m.RoyaltyList() {
+		nft.Royalties = append(nft.Royalties, db.NFTRoyalty{Address: r.Address, Share: r.Share})
+	}
+	credits := []struct {
+		role  string
+		names json.RawMessage
+	}{
+		{db.RoleCreator, m.Creators},
+		{db.RoleContributor, m.Contributors},
+		{db.RolePublisher, m.Publishers},
+	}
+	for _, c := range credits {
+		for _, name := range indexer.NameList(c.names) {
+			nft.Contributors = append(nft.Contributors, db.NFTContributor{Role: c.role, Name: name})
+		}
+	}
+}
+
+// nftAsset is an asset URI found in an NFT's metadata
+type nftAsset struct {
+	uri       string
+	assetType string
+}
+
+// saveNFT fetches missing metadata, saves the NFT record and returns the assets
+// to back up. Returns a nil NFT if the token has no IPFS content.
+func (bm *BackupManager) saveNFT(ctx context.Context, walletAddr string, token indexer.Token) (*db.NFT, []nftAsset, error) {
 	// If metadata is nil, try to fetch it from the chain
 	if token.Metadata == nil {
 		metadata, err := bm.fetchMetadataFromChain(ctx, token.Contract.Address, token.TokenID)
 		if err != nil {
 			log.Printf("Could not fetch metadata from chain for %s:%s - %v", token.Contract.Address, token.TokenID, err)
 			// Skip this token if we can't get metadata
-			return nil
+			return nil, nil, nil
 		}
 		token.Metadata = metadata
 	}
@@ -372,7 +479,7 @@ func (bm *BackupManager) processNFT(ctx context.Context, walletAddr string, toke
 	// Skip if still no useful content after fetching
 	if token.Metadata == nil || !hasIPFSContent(token.Metadata) {
 		log.Printf("Skipping %s:%s - no IPFS content found", token.Contract.Address, token.TokenID)
-		return nil
+		return nil, nil, nil
 	}
 
 	// 1. Save NFT to database with full metadata
@@ -387,107 +494,80 @@ func (bm *BackupManager) processNFT(ctx context.Context, walletAddr string, toke
 		ThumbnailURI:    token.Metadata.ThumbnailURI,
 	}
 
-	// Set creator from firstMinter if available
+	// Set creator from firstMinter if available, else the minter the
+	// metadata names
 	if token.FirstMinter != nil {
 		nft.CreatorAddress = token.FirstMinter.Address
+	} else {
+		nft.CreatorAddress = string(token.Metadata.Minter)
 	}
 
-	// Try to fetch raw metadata URI
-	rawURI, err := bm.indexer.FetchRawMetadataURI(ctx, token.Contract.Address, token.TokenID)
+	setNFTMetadata(nft, token.Metadata)
+
+	// Try to fetch raw metadata URI, and the level the metadata last changed at
+	var metadataLevel int64
+	entry, err := bm.indexer.FetchTokenMetadataEntry(ctx, token.Contract.Address, token.TokenID)
 	if err != nil {
 		log.Printf("Could not fetch raw metadata URI for %s:%s - %v", token.Contract.Address, token.TokenID, err)
 	} else {
-		// Save raw metadata as JSON
-		rawMetadata := map[string]string{"uri": rawURI}
-		rawJSON, _ := json.Marshal(rawMetadata)
-		nft.RawMetadata = string(rawJSON)
+		metadataLevel = entry.Level
+		if entry.URI != "" {
+			// Save raw metadata as JSON
+			rawMetadata := map[string]string{"uri": entry.URI}
+			rawJSON, _ := json.Marshal(rawMetadata)
+			nft.RawMetadata = string(rawJSON)
+		}
 	}
 
-	if err := bm.db.SaveNFT(nft); err != nil {
-		return fmt.Errorf("failed to save NFT: %w", err)
+	// Note the creator and collection so their profiles get looked up,
+	// and their names are indexed with the NFT
+	if token.FirstMinter != nil && token.FirstMinter.Address != "" {
+		if err := bm.db.NoteCreator(token.FirstMinter.Address, token.FirstMinter.Alias); err != nil {
+			log.Printf("Could not record creator %s: %v", token.FirstMinter.Address, err)
+		}
+	}
+	if err := bm.db.NoteContract(token.Contract.Address, token.Contract.Alias); err != nil {
+		log.Printf("Could not record contract %s: %v", token.Contract.Address, err)
 	}
 
-	// 2. Queue assets for backup with proper types
-	type assetEntry struct {
-		uri      string
-		assetType string
+	if err := bm.db.SaveNFT(nft); err != nil {
+		return nil, nil, fmt.Errorf("failed to save NFT: %w", err)
 	}
-	
-	var assets []assetEntry
+	bm.recordMetadataVersion(ctx, nft, token, metadataLevel)
+
+	// 2. Collect assets for backup with proper types
+	var assets []nftAsset
 	
 	// Add artifact (main content)
 	if token.Metadata.ArtifactURI != "" {
-		assets = append(assets, assetEntry{token.Metadata.ArtifactURI, "artifact"})
+		assets = append(assets, nftAsset{token.Metadata.ArtifactURI, "artifact"})
 	}
 	
 	// Add display URI if different from artifact
 	if token.Metadata.DisplayURI != "" && token.Metadata.DisplayURI != token.Metadata.ArtifactURI {
-		assets = append(assets, assetEntry{token.Metadata.DisplayURI, "display"})
+		assets = append(assets, nftAsset{token.Metadata.DisplayURI, "display"})
 	}
 	
 	// Add thumbnail if different from artifact
 	if token.Metadata.ThumbnailURI != "" && token.Metadata.ThumbnailURI != token.Metadata.ArtifactURI {
-		assets = append(assets, assetEntry{token.Metadata.ThumbnailURI, "thumbnail"})
+		assets = append(assets, nftAsset{token.Metadata.ThumbnailURI, "thumbnail"})
 	}
 	
 	// Add additional formats
 	for _, format := range token.Metadata.Formats {
 		if format.URI != "" {
-			assets = append(assets, assetEntry{format.URI, "format"})
-		}
-	}
-
-	for _, asset := range assets {
-		if err := bm.backupAsset(ctx, nft.ID, asset.uri, asset.assetType); err != nil {
-			log.Printf("Failed to backup asset %s - %v", asset.uri, err)
+			assets = append(assets, nftAsset{format.URI, "format"})
 		}
 	}
 
-	return nil
+	return nft, assets, nil
 }
 
 // backupAsset downloads and pins an asset to IPFS
 func (bm *BackupManager) backupAsset(ctx context.Context, nftID uint64, uri string, assetType string) error {
-	// Check if we've already processed this URI in this sync (deduplication)
-	if _, loaded := bm.processedURIs.LoadOrStore(uri, true); loaded {
-		// Already processed in this sync, skip
-		return nil
-	}
-
-	// Create or update asset record regardless of pause state
-	// This prevents data loss where an NFT is processed but its assets are skipped due to pause
-	existingAsset, err := bm.db.GetAssetByURI(uri)
-	
-	// If it's already pinned, we can skip early
-	if err == nil && existingAsset != nil && existingAsset.Status == db.StatusPinned {
-		log.Printf("Asset %s already pinned, skipping", uri)
-		bm.updateProgress(func(p *SyncProgress) {
-			p.PinnedAssets++
-		})
-		return nil
-	}
-
-	asset := &db.Asset{
-		NFTID:  nftID,
-		URI:    uri,
-		Status: db.StatusPending,
-		Type:   assetType,
-	}
-
-	if existingAsset != nil {
-		asset.ID = existingAsset.ID
-		asset.RetryCount = existingAsset.RetryCount
-		// If it was failed, reset to pending
-		if strings.Contains(existingAsset.Status, "failed") {
-			asset.Status = db.StatusPending
-			asset.ErrorMsg = ""
-		} else {
-			asset.Status = existingAsset.Status
-		}
-	}
-
-	if err := bm.db.SaveAsset(asset); err != nil {
-		return fmt.Errorf("failed to save asset: %w", err)
+	asset, needsPin, err := bm.recordAsset(nftID, uri, assetType)
+	if err != nil || !needsPin {
+		return err
 	}
 
 	// Check for pause AFTER saving record
@@ -495,12 +575,6 @@ func (bm *BackupManager) backupAsset(ctx context.Context, nftID uint64, uri stri
 		return nil
 	}
 
-	// Skip non-IPFS URIs - we can only pin IPFS content
-	if !strings.HasPrefix(uri, "ipfs://") && !strings.Contains(uri, "/ipfs/") {
-		log.Printf("Skipping non-IPFS URI: %s", uri)
-		return nil
-	}
-
 	// Update progress phase
 	bm.updateProgress(func(p *SyncProgress) {
 		p.Phase = "pinning"
@@ -539,9 +613,9 @@ func (bm *BackupManager) backupAsset(ctx context.Context, nftID uint64, uri stri
 		log.Printf("Gateway unavailable for %s, pinning directly via IPFS", uri)
 	} else {
 		// Validate size only if we got it
-		if size > bm.config.IPFS.MaxFileSize {
+		if size > bm.Config().IPFS.MaxFileSize {
 			asset.Status = db.StatusFailed
-			asset.ErrorMsg = fmt.Sprintf("File too large: %d bytes (max %d)", size, bm.config.IPFS.MaxFileSize)
+			asset.ErrorMsg = fmt.Sprintf("File too large: %d bytes (max %d)", size, bm.Config().IPFS.MaxFileSize)
 			bm.db.SaveAsset(asset)
 			return fmt.Errorf("file too large: %d bytes", size)
 		}
@@ -565,6 +639,9 @@ func (bm *BackupManager) backupAsset(ctx context.Context, nftID uint64, uri stri
 	// Pin to IPFS with retry logic
 	err = bm.pinWithRetry(ctx, cid, asset.RetryCount)
 	if err != nil {
+		if serr := bm.holdForStorage(asset); serr != nil {
+			return serr
+		}
 		asset.RetryCount++
 		if isTimeoutError(err) {
 			asset.Status = db.StatusFailedUnavailable
@@ -589,6 +666,7 @@ func (bm *BackupManager) backupAsset(ctx context.Context, nftID uint64, uri stri
 
 	// Success
 	asset.Status = db.StatusPinned
+	asset.Tier = db.TierHot // New blocks are written to the repo
 	now := time.Now()
 	asset.PinnedAt = &now
 	bm.db.SaveAsset(asset)
@@ -604,6 +682,62 @@ func (bm *BackupManager) backupAsset(ctx context.Context, nftID uint64, uri stri
 	return nil
 }
 
+// recordAsset creates or updates the record for an asset found during a sync.
+// needsPin is false if the URI was already handled in this sync, is already
+// pinned, or is not IPFS content.
+func (bm *BackupManager) recordAsset(nftID uint64, uri string, assetType string) (*db.Asset, bool, error) {
+	// Check if we've already processed this URI in this sync (deduplication)
+	if _, loaded := bm.processedURIs.LoadOrStore(uri, true); loaded {
+		// Already processed in this sync, skip
+		return nil, false, nil
+	}
+
+	// Create or update asset record regardless of pause state
+	// This prevents data loss where an NFT is processed but its assets are skipped due to pause
+	existingAsset, err := bm.db.GetAssetByURI(uri)
+	
+	// If it's already pinned, we can skip early
+	if err == nil && existingAsset != nil && existingAsset.Status == db.StatusPinned {
+		log.Printf("Asset %s already pinned, skipping", uri)
+		bm.updateProgress(func(p *SyncProgress) {
+			p.PinnedAssets++
+		})
+		return nil, false, nil
+	}
+
+	asset := &db.Asset{
+		NFTID:  nftID,
+		URI:    uri,
+		Status: db.StatusPending,
+		Type:   assetType,
+	}
+
+	if existingAsset != nil {
+		asset.ID = existingAsset.ID
+		asset.RetryCount = existingAsset.RetryCount
+		asset.CreatedAt = existingAsset.CreatedAt // keeps the queue's age ordering stable
+		// If it was failed, reset to pending
+		if strings.Contains(existingAsset.Status, "failed") {
+			asset.Status = db.StatusPending
+			asset.ErrorMsg = ""
+		} else {
+			asset.Status = existingAsset.Status
+		}
+	}
+
+	if err := bm.db.SaveAsset(asset); err != nil {
+		return nil, false, fmt.Errorf("failed to save asset: %w", err)
+	}
+
+	// Skip non-IPFS URIs - we can only pin IPFS content
+	if !strings.HasPrefix(uri, "ipfs://") && !strings.Contains(uri, "/ipfs/") {
+		log.Printf("Skipping non-IPFS URI: %s", uri)
+		return nil, false, nil
+	}
+
+	return asset, true, nil
+}
+
 // downloadMetadata fetches metadata about an asset without downloading the full file
 func (bm *BackupManager) downloadMetadata(ctx context.Context, uri string) ([]byte, string, int64, error) {
 	resolvedURI := resolveURI(uri)
@@ -632,22 +766,32 @@ func (bm *BackupManager) downloadMetadata(ctx context.Context, uri string) ([]by
 // VerifyAndFixPins iterates through all NFTs and ensures their assets are properly tracked and pinned
 // This fixes data loss from the previous "pause bug" where assets weren't saved to DB
 func (bm *BackupManager) VerifyAndFixPins(ctx context.Context) (map[string]int, error) {
-	bm.mu.Lock()
-	// check if already running?
-	bm.mu.Unlock()
+	return bm.VerifyAndFixPinsFrom(ctx, 0, nil, nil)
+}
 
+// VerifyCheckpointFunc is called after each batch of VerifyAndFixPinsFrom with
+// the last NFT ID processed and the running stats
+type VerifyCheckpointFunc func(lastID uint64, stats map[string]int) error
+
+// VerifyAndFixPinsFrom runs VerifyAndFixPins starting after the given NFT ID.
+// Stats from a previous run may be passed in to continue counting; onBatch
+// (optional) lets callers persist progress so an interrupted run can resume.
+func (bm *BackupManager) VerifyAndFixPinsFrom(ctx context.Context, afterID uint64, stats map[string]int, onBatch VerifyCheckpointFunc) (map[string]int, error) {
 	log.Println("Starting VerifyAndFixPins...")
 	
-	stats := map[string]int{
-		"checked": 0,
-		"fixed":   0,
-		"errors":  0,
+	if stats == nil {
+		stats = make(map[string]int)
+	}
+	for _, key := range []string{"checked", "fixed", "errors"} {
+		if _, ok := stats[key]; !ok {
+			stats[key] = 0
+		}
 	}
 
 	// 1. Get all NFTs from DB
-	// Process in batches to avoid memory issues
+	// Process in batches by ID so the position stays valid across restarts
 	limit := 100
-	offset := 0
+	lastID := afterID
 	
 	for {
 		// Check for shutdown
@@ -658,7 +802,7 @@ func (bm *BackupManager) VerifyAndFixPins(ctx context.Context) (map[string]int,
 		}
 
 		var nfts []db.NFT
-		if err := bm.db.DB.Order("id asc").Offset(offset).Limit(limit).Find(&nfts).Error; err != nil {
+		if err := bm.db.DB.Where("id > ?", lastID).Order("id asc").Limit(limit).Find(&nfts).Error; err != nil {
 			return stats, fmt.Errorf("failed to fetch NFTs: %w", err)
 		}
 		
@@ -714,7 +858,12 @@ func (bm *BackupManager) VerifyAndFixPins(ctx context.Context) (map[string]int,
 			}
 		}
 		
-		offset += limit
+		lastID = nfts[len(nfts)-1].ID
+		if onBatch != nil {
+			if err := onBatch(lastID, stats); err != nil {
+				return stats, err
+			}
+		}
 	}
 	
 	log.Printf("VerifyAndFixPins complete: %+v", stats)
@@ -739,7 +888,7 @@ func (bm *BackupManager) pinWithRetry(ctx context.Context, cid string, retryCoun
 		}
 
 		// Use a shorter timeout per attempt to avoid blocking too long
-		timeout := bm.config.IPFS.PinTimeout
+		timeout := bm.Config().IPFS.PinTimeout
 		if timeout > 60*time.Second {
 			timeout = 60 * time.Second  // Cap at 60s per attempt
 		}
@@ -750,6 +899,11 @@ func (bm *BackupManager) pinWithRetry(ctx context.Context, cid string, retryCoun
 		}
 
 		log.Printf("Pin attempt %d failed for %s: %v", attempt+1, cid, err)
+		
+		// Retrying can't help while the node is stopped
+		if node, ok := bm.ipfs.(runningChecker); ok && !node.IsRunning() {
+			return err
+		}
 	}
 
 	return fmt.Errorf("max retries exceeded for CID %s", cid)
@@ -757,7 +911,7 @@ func (bm *BackupManager) pinWithRetry(ctx context.Context, cid string, retryCoun
 
 // isWithinStorageLimit checks if we're within the user's configured storage limit
 func (bm *BackupManager) isWithinStorageLimit() bool {
-	maxGB := bm.config.Backup.MaxStorageGB
+	maxGB := bm.Config().Backup.MaxStorageGB
 	if maxGB <= 0 {
 		return true // No limit set
 	}
@@ -783,35 +937,7 @@ func (bm *BackupManager) isWithinStorageLimit() bool {
 // ExtractCIDFromURI extracts a CID from an IPFS URI
 // Handles: ipfs://CID, ipfs://CID/path, ipfs://CID?query, /ipfs/CID, etc.
 func ExtractCIDFromURI(uri string) string {
-	var cid string
-
-	// Handle ipfs:// scheme
-	if len(uri) > 7 && uri[:7] == "ipfs://" {
-		cid = uri[7:]
-	} else {
-		// Find /ipfs/ in the URI (for gateway URLs)
-		const ipfsPrefix = "/ipfs/"
-		idx := indexOf(uri, ipfsPrefix)
-		if idx != -1 {
-			cid = uri[idx+len(ipfsPrefix):]
-		}
-	}
-
-	if cid == "" {
-		return ""
-	}
-
-	// Strip query parameters (e.g., ?fxhash=...)
-	if qIdx := indexOf(cid, "?"); qIdx != -1 {
-		cid = cid[:qIdx]
-	}
-
-	// Strip trailing path (e.g., /index.html) - keep only the CID
-	if slashIdx := indexOf(cid, "/"); slashIdx != -1 {
-		cid = cid[:slashIdx]
-	}
-
-	return cid
+	return db.CIDFromURI(uri)
 }
 
 // isTimeoutError checks if an error is a timeout error
@@ -822,16 +948,6 @@ func isTimeoutError(err error) bool {
 	return err == context.DeadlineExceeded || err.Error() == "context deadline exceeded"
 }
 
-// indexOf returns the index of substr in s, or -1 if not found
-func indexOf(s, substr string) int {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return i
-		}
-	}
-	return -1
-}
-
 // Shutdown gracefully shuts down the backup manager
 func (bm *BackupManager) Shutdown() {
 	// Update disk usage one final time before shutdown
@@ -979,7 +1095,7 @@ func (bm *BackupManager) pinAssetDirect(ctx context.Context, asset *db.Asset) er
 	// Try to get file info via HTTP HEAD
 	_, mimeType, size, err := bm.downloadMetadata(ctx, uri)
 	if err == nil {
-		if size > bm.config.IPFS.MaxFileSize {
+		if size > bm.Config().IPFS.MaxFileSize {
 			asset.Status = db.StatusFailed
 			asset.ErrorMsg = fmt.Sprintf("File too large: %d bytes", size)
 			bm.db.SaveAsset(asset)
@@ -1002,6 +1118,9 @@ func (bm *BackupManager) pinAssetDirect(ctx context.Context, asset *db.Asset) er
 	// Pin to IPFS
 	err = bm.pinWithRetry(ctx, cid, 0)
 	if err != nil {
+		if serr := bm.holdForStorage(asset); serr != nil {
+			return serr
+		}
 		asset.RetryCount++
 		if isTimeoutError(err) {
 			asset.Status = db.StatusFailedUnavailable
@@ -1021,6 +1140,7 @@ func (bm *BackupManager) pinAssetDirect(ctx context.Context, asset *db.Asset) er
 
 	// Success
 	asset.Status = db.StatusPinned
+	asset.Tier = db.TierHot // New blocks are written to the repo
 	now := time.Now()
 	asset.PinnedAt = &now
 	bm.db.SaveAsset(asset)
@@ -1059,8 +1179,17 @@ func (bm *BackupManager) ProcessPendingAssets(ctx context.Context, limit int) (p
 			return processed, pinned, failed
 		}
 
+		// Over-quota wallets keep their assets pending
+		if bm.isOverQuota(bm.walletForAsset(&asset)) {
+			continue
+		}
+
 		processed++
 		err := bm.pinAssetDirect(ctx, &asset)
+		if errors.Is(err, storage.ErrStorageUnavailable) {
+			log.Printf("Storage unavailable, stopping pending asset processing: %v", err)
+			return processed, pinned, failed
+		}
 		if err != nil {
 			failed++
 			log.Printf("Failed to pin pending asset %s: %v", asset.URI, err)
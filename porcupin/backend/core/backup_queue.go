@@ -0,0 +1,316 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"porcupin/backend/config"
+	"porcupin/backend/db"
+	"porcupin/backend/indexer"
+	"porcupin/backend/storage"
+)
+
+// ErrAssetAlreadyPinned is returned by PinNext for assets that need no pinning
+var ErrAssetAlreadyPinned = errors.New("asset already pinned")
+
+// pinQueue returns the pin queue, starting its workers on first use
+func (bm *BackupManager) pinQueue() *PinQueue {
+	bm.queueOnce.Do(func() {
+		var weights config.QueueConfig
+		if cfg := bm.Config(); cfg != nil {
+			weights = cfg.Backup.Queue
+		}
+		bm.queue = NewPinQueue(weights)
+
+		// Pins in flight are cancelled when the manager shuts down
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-bm.shutdown
+			cancel()
+		}()
+		bm.queueCtx = ctx
+		bm.startPinWorkers()
+	})
+	return bm.queue
+}
+
+// startPinWorkers runs a pin worker for each worker slot
+func (bm *BackupManager) startPinWorkers() {
+	workers := 1
+	if bm.workers != nil {
+		workers = bm.workers.size()
+	}
+
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	for ; bm.pinWorkers < workers; bm.pinWorkers++ {
+		go bm.pinWorker(bm.queueCtx)
+	}
+}
+
+// retirePinWorker reports whether a pin worker should exit because the
+// concurrency was lowered, counting it out if so
+func (bm *BackupManager) retirePinWorker() bool {
+	if bm.workers == nil {
+		return false
+	}
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	if bm.pinWorkers > bm.workers.size() {
+		bm.pinWorkers--
+		return true
+	}
+	return false
+}
+
+// SetConcurrency changes how many pins may run at once. Lowering it lets
+// the pins already running finish.
+func (bm *BackupManager) SetConcurrency(n int) {
+	bm.workers.setLimit(n)
+	q := bm.pinQueue()
+	bm.startPinWorkers()
+	q.signal() // Idle workers over the limit retire when woken
+}
+
+// pinWorker takes tasks from the pin queue until the manager shuts down
+func (bm *BackupManager) pinWorker(ctx context.Context) {
+	q := bm.queue
+	for {
+		if bm.retirePinWorker() {
+			q.signal() // Pass on a wake-up meant for a worker that stays
+			return
+		}
+		if bm.IsPaused() {
+			select {
+			case <-time.After(time.Second):
+			case <-bm.shutdown:
+				return
+			}
+			continue
+		}
+
+		task := q.Next()
+		if task == nil {
+			select {
+			case <-q.Ready():
+			case <-bm.shutdown:
+				return
+			}
+			continue
+		}
+
+		bm.runPinTask(ctx, task)
+	}
+}
+
+// runPinTask pins a single queued asset
+func (bm *BackupManager) runPinTask(ctx context.Context, task *PinTask) {
+	q := bm.queue
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic pinning %s: %v", task.URI, r)
+			q.Done(task)
+		}
+	}()
+
+	// Stop taking work at the storage limit; the task stays queued for when space is freed
+	if !bm.isWithinStorageLimit() {
+		log.Printf("Storage limit reached, pausing pin queue")
+		bm.SetPaused(true)
+		q.Requeue(task)
+		return
+	}
+	if !bm.hasSufficientDiskSpace() {
+		log.Printf("Insufficient disk space, pausing pin queue")
+		bm.SetPaused(true)
+		q.Requeue(task)
+		return
+	}
+
+	// Over-quota wallets keep their assets pending; they are queued again when the quota changes
+	if bm.isOverQuota(task.Wallet) {
+		q.Done(task)
+		return
+	}
+
+	// Share the worker slots with verification and direct pins
+	if bm.workers != nil {
+		if err := bm.workers.acquire(ctx, nil); err != nil {
+			q.Done(task)
+			return
+		}
+		defer bm.workers.release()
+	}
+
+	if bm.IsPaused() {
+		q.Requeue(task)
+		return
+	}
+	defer q.Done(task)
+
+	// The record may have changed while queued (deleted, pinned by a retry, ...)
+	asset, err := bm.db.GetAssetByID(task.AssetID)
+	if err != nil || asset == nil || asset.Status != db.StatusPending {
+		return
+	}
+
+	bm.updateProgress(func(p *SyncProgress) {
+		p.Phase = "pinning"
+		p.CurrentItem = asset.URI
+	})
+
+	if err := bm.pinAssetDirect(ctx, asset); err != nil {
+		log.Printf("Failed to pin queued asset %s: %v", asset.URI, err)
+		if !errors.Is(err, storage.ErrStorageUnavailable) {
+			bm.updateProgress(func(p *SyncProgress) {
+				p.FailedAssets++
+			})
+		}
+		return
+	}
+	bm.updateProgress(func(p *SyncProgress) {
+		p.PinnedAssets++
+	})
+	bm.addQuotaUsage(task.Wallet, asset)
+}
+
+// pinTaskFor builds a queue task for an asset record
+func pinTaskFor(asset *db.Asset, wallet string) PinTask {
+	return PinTask{
+		AssetID:   asset.ID,
+		URI:       asset.URI,
+		Type:      asset.Type,
+		Wallet:    wallet,
+		SizeBytes: asset.SizeBytes,
+		CreatedAt: asset.CreatedAt,
+	}
+}
+
+// queueNFT saves an NFT and its asset records and adds the assets to the pin queue.
+// Returns the number of assets queued.
+func (bm *BackupManager) queueNFT(ctx context.Context, walletAddr string, walletPriority int, token indexer.Token) (int, error) {
+	if bm.IsPaused() {
+		return 0, nil
+	}
+
+	nft, assets, err := bm.saveNFT(ctx, walletAddr, token)
+	if err != nil || nft == nil {
+		return 0, err
+	}
+
+	q := bm.pinQueue()
+	overQuota := bm.isOverQuota(walletAddr)
+	queued := 0
+	for _, a := range assets {
+		asset, needsPin, err := bm.recordAsset(nft.ID, a.uri, a.assetType)
+		if err != nil {
+			log.Printf("Failed to record asset %s - %v", a.uri, err)
+			continue
+		}
+		// Assets of over-quota wallets are recorded but left pending
+		if !needsPin || overQuota {
+			continue
+		}
+		if q.Push(pinTaskFor(asset, walletAddr), walletPriority) {
+			queued++
+		}
+	}
+	return queued, nil
+}
+
+// EnqueuePendingAssets adds every pending asset to the pin queue.
+// Assets already queued or being pinned are skipped. Returns the number added.
+func (bm *BackupManager) EnqueuePendingAssets() int {
+	var assets []db.Asset
+	if err := bm.db.DB.Preload("NFT").Where("status = ?", db.StatusPending).Order("id ASC").Find(&assets).Error; err != nil {
+		log.Printf("Failed to get pending assets: %v", err)
+		return 0
+	}
+	if len(assets) == 0 {
+		return 0
+	}
+
+	priorities := bm.walletPriorities()
+	q := bm.pinQueue()
+	added := 0
+	for i := range assets {
+		wallet := ""
+		if assets[i].NFT != nil {
+			wallet = assets[i].NFT.WalletAddress
+		}
+		if bm.isOverQuota(wallet) {
+			continue
+		}
+		if q.Push(pinTaskFor(&assets[i], wallet), priorities[wallet]) {
+			added++
+		}
+	}
+	return added
+}
+
+// PinNext moves an asset to the front of the pin queue, queueing it first if needed.
+// Failed assets are reset to pending.
+func (bm *BackupManager) PinNext(assetID uint64) error {
+	var asset db.Asset
+	if err := bm.db.DB.Preload("NFT").First(&asset, assetID).Error; err != nil {
+		return fmt.Errorf("asset not found: %w", err)
+	}
+	if asset.Status == db.StatusPinned {
+		return ErrAssetAlreadyPinned
+	}
+
+	q := bm.pinQueue()
+	if q.Bump(asset.URI) {
+		return nil
+	}
+
+	if asset.Status != db.StatusPending {
+		asset.Status = db.StatusPending
+		asset.ErrorMsg = ""
+		if err := bm.db.SaveAsset(&asset); err != nil {
+			return fmt.Errorf("failed to reset asset status: %w", err)
+		}
+	}
+
+	wallet := ""
+	if asset.NFT != nil {
+		wallet = asset.NFT.WalletAddress
+	}
+	task := pinTaskFor(&asset, wallet)
+	task.Bumped = true
+	// A no-op if the asset is already being pinned
+	q.Push(task, bm.walletPriorities()[wallet])
+	return nil
+}
+
+// SetWalletPriority updates the queue priority of a wallet's pending pins
+func (bm *BackupManager) SetWalletPriority(address string, priority int) {
+	bm.pinQueue().SetWalletPriority(address, priority)
+}
+
+// SetQueueWeights changes how queued pins are ordered
+func (bm *BackupManager) SetQueueWeights(weights config.QueueConfig) {
+	bm.pinQueue().SetWeights(weights)
+}
+
+// QueueStatus returns a snapshot of the pin queue
+func (bm *BackupManager) QueueStatus() QueueStatus {
+	return bm.pinQueue().Status()
+}
+
+// walletPriorities returns the configured priority of each wallet
+func (bm *BackupManager) walletPriorities() map[string]int {
+	priorities := make(map[string]int)
+	wallets, err := bm.db.GetAllWallets()
+	if err != nil {
+		log.Printf("Failed to get wallet priorities: %v", err)
+		return priorities
+	}
+	for _, w := range wallets {
+		priorities[w.Address] = w.Priority
+	}
+	return priorities
+}
@@ -2,12 +2,14 @@ package core
 
 import (
 	"context"
+	"errors"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -17,6 +19,8 @@ import (
 	"porcupin/backend/db"
 	"porcupin/backend/indexer"
 	"porcupin/backend/ipfs"
+	"porcupin/backend/pinning"
+	"porcupin/backend/storage"
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
@@ -36,6 +40,10 @@ func testDB(t *testing.T) *db.Database {
 	if err != nil {
 		t.Fatalf("Failed to create test database: %v", err)
 	}
+	// Each connection to :memory: is a separate database, and the pin queue
+	// and job workers use the DB from other goroutines
+	sqlDB, _ := gormDB.DB()
+	sqlDB.SetMaxOpenConns(1)
 	if err := db.InitDB(gormDB); err != nil {
 		t.Fatalf("Failed to init test database: %v", err)
 	}
@@ -313,9 +321,6 @@ func TestCountAssets(t *testing.T) {
 	}
 }
 
-// NOTE: TestIndexOf was removed. It tested a trivial 7-line helper with 7 test cases.
-// The indexOf function is used only by extractCIDFromURI which has comprehensive tests.
-
 // =============================================================================
 // BACKUPMANAGER TESTS (With Mocked Dependencies)
 // =============================================================================
@@ -367,6 +372,16 @@ func (m *mockIPFSNode) Unpin(ctx context.Context, cid string) error {
 	return nil
 }
 
+func (m *mockIPFSNode) ListPins(ctx context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var cids []string
+	for cid := range m.pinned {
+		cids = append(cids, cid)
+	}
+	return cids, nil
+}
+
 func (m *mockIPFSNode) Cat(ctx context.Context, cid string, sizeLimit int64) ([]byte, string, error) {
 	return []byte("mock content"), "text/plain", nil
 }
@@ -383,20 +398,20 @@ func TestNewBackupManager(t *testing.T) {
 	// This tests the constructor
 	bm := &BackupManager{
 		db:       database,
-		config:   cfg,
-		workers:  make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:  newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown: make(chan struct{}),
 		progress: SyncProgress{Phase: "idle"},
 	}
+	bm.config.Store(cfg)
 
 	if bm.db == nil {
 		t.Error("BackupManager.db should not be nil")
 	}
-	if bm.config == nil {
+	if bm.Config() == nil {
 		t.Error("BackupManager.config should not be nil")
 	}
-	if cap(bm.workers) != cfg.Backup.MaxConcurrency {
-		t.Errorf("BackupManager.workers capacity = %d, want %d", cap(bm.workers), cfg.Backup.MaxConcurrency)
+	if bm.workers.size() != cfg.Backup.MaxConcurrency {
+		t.Errorf("BackupManager.workers size = %d, want %d", bm.workers.size(), cfg.Backup.MaxConcurrency)
 	}
 	if bm.progress.Phase != "idle" {
 		t.Errorf("Initial phase = %q, want 'idle'", bm.progress.Phase)
@@ -409,11 +424,11 @@ func TestBackupManager_PauseResume(t *testing.T) {
 
 	bm := &BackupManager{
 		db:       database,
-		config:   cfg,
-		workers:  make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:  newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown: make(chan struct{}),
 		progress: SyncProgress{Phase: "idle"},
 	}
+	bm.config.Store(cfg)
 
 	// Initially not paused
 	if bm.IsPaused() {
@@ -445,8 +460,7 @@ func TestBackupManager_GetProgress(t *testing.T) {
 
 	bm := &BackupManager{
 		db:       database,
-		config:   cfg,
-		workers:  make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:  newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown: make(chan struct{}),
 		progress: SyncProgress{
 			Phase:        "syncing",
@@ -454,6 +468,7 @@ func TestBackupManager_GetProgress(t *testing.T) {
 			ProcessedNFTs: 50,
 		},
 	}
+	bm.config.Store(cfg)
 
 	progress := bm.GetProgress()
 
@@ -474,11 +489,11 @@ func TestBackupManager_UpdateProgress(t *testing.T) {
 
 	bm := &BackupManager{
 		db:       database,
-		config:   cfg,
-		workers:  make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:  newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown: make(chan struct{}),
 		progress: SyncProgress{Phase: "idle"},
 	}
+	bm.config.Store(cfg)
 
 	// Update progress
 	bm.updateProgress(func(p *SyncProgress) {
@@ -506,11 +521,11 @@ func TestBackupManager_ProgressConcurrency(t *testing.T) {
 
 	bm := &BackupManager{
 		db:       database,
-		config:   cfg,
-		workers:  make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:  newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown: make(chan struct{}),
 		progress: SyncProgress{Phase: "idle"},
 	}
+	bm.config.Store(cfg)
 
 	// Concurrent updates should not race
 	var wg sync.WaitGroup
@@ -538,11 +553,11 @@ func TestBackupManager_MarkDiskUsageDirty(t *testing.T) {
 
 	bm := &BackupManager{
 		db:       database,
-		config:   cfg,
-		workers:  make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:  newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown: make(chan struct{}),
 		progress: SyncProgress{Phase: "idle"},
 	}
+	bm.config.Store(cfg)
 
 	// Initially clean
 	if bm.diskUsageDirty != 0 {
@@ -572,11 +587,11 @@ func TestBackupManager_IsWithinStorageLimit(t *testing.T) {
 
 	bm := &BackupManager{
 		db:       database,
-		config:   cfg,
-		workers:  make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:  newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown: make(chan struct{}),
 		progress: SyncProgress{Phase: "idle"},
 	}
+	bm.config.Store(cfg)
 
 	// No limit set (0 = unlimited)
 	cfg.Backup.MaxStorageGB = 0
@@ -1020,38 +1035,37 @@ func TestBackupManager_WorkerSemaphore(t *testing.T) {
 
 	bm := &BackupManager{
 		db:       database,
-		config:   cfg,
-		workers:  make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:  newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown: make(chan struct{}),
 		progress: SyncProgress{Phase: "idle"},
 	}
+	bm.config.Store(cfg)
 
 	// Fill up worker slots
-	bm.workers <- struct{}{}
-	bm.workers <- struct{}{}
+	ctx := context.Background()
+	bm.workers.acquire(ctx, nil)
+	bm.workers.acquire(ctx, nil)
 
 	// Third worker should block
-	select {
-	case bm.workers <- struct{}{}:
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	if err := bm.workers.acquire(waitCtx, nil); err == nil {
 		t.Error("Third worker should not acquire slot immediately")
-	case <-time.After(50 * time.Millisecond):
-		// Expected - channel is full
 	}
+	cancel()
 
 	// Release one slot
-	<-bm.workers
+	bm.workers.release()
 
 	// Now third should acquire
-	select {
-	case bm.workers <- struct{}{}:
-		// Good
-	case <-time.After(50 * time.Millisecond):
+	waitCtx, cancel = context.WithTimeout(ctx, 50*time.Millisecond)
+	if err := bm.workers.acquire(waitCtx, nil); err != nil {
 		t.Error("Third worker should acquire after slot released")
 	}
+	cancel()
 
 	// Clean up
-	<-bm.workers
-	<-bm.workers
+	bm.workers.release()
+	bm.workers.release()
 }
 
 func TestBackupManager_Shutdown(t *testing.T) {
@@ -1060,11 +1074,11 @@ func TestBackupManager_Shutdown(t *testing.T) {
 
 	bm := &BackupManager{
 		db:       database,
-		config:   cfg,
-		workers:  make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:  newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown: make(chan struct{}),
 		progress: SyncProgress{Phase: "idle"},
 	}
+	bm.config.Store(cfg)
 
 	// Shutdown should close channel
 	bm.Shutdown()
@@ -1126,11 +1140,11 @@ func TestBackupManager_DownloadMetadata_Success(t *testing.T) {
 
 	bm := &BackupManager{
 		db:       database,
-		config:   cfg,
-		workers:  make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:  newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown: make(chan struct{}),
 		progress: SyncProgress{Phase: "idle"},
 	}
+	bm.config.Store(cfg)
 
 	// Call with our mock server URL
 	_, mimeType, size, err := bm.downloadMetadata(context.Background(), server.URL+"/test.png")
@@ -1157,11 +1171,11 @@ func TestBackupManager_DownloadMetadata_NotFound(t *testing.T) {
 
 	bm := &BackupManager{
 		db:       database,
-		config:   cfg,
-		workers:  make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:  newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown: make(chan struct{}),
 		progress: SyncProgress{Phase: "idle"},
 	}
+	bm.config.Store(cfg)
 
 	_, _, _, err := bm.downloadMetadata(context.Background(), server.URL+"/missing")
 
@@ -1182,11 +1196,11 @@ func TestBackupManager_DownloadMetadata_Timeout(t *testing.T) {
 
 	bm := &BackupManager{
 		db:       database,
-		config:   cfg,
-		workers:  make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:  newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown: make(chan struct{}),
 		progress: SyncProgress{Phase: "idle"},
 	}
+	bm.config.Store(cfg)
 
 	// Use context with very short timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
@@ -1246,12 +1260,12 @@ func TestBackupManager_ProcessedURIsDeduplication(t *testing.T) {
 
 	bm := &BackupManager{
 		db:            database,
-		config:        cfg,
-		workers:       make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:       newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:      make(chan struct{}),
 		progress:      SyncProgress{Phase: "idle"},
 		processedURIs: sync.Map{},
 	}
+	bm.config.Store(cfg)
 
 	uri := "ipfs://QmTest123"
 
@@ -1296,12 +1310,12 @@ func TestBackupManager_SyncWallet_ContextCancellation(t *testing.T) {
 	bm := &BackupManager{
 		indexer:       idx,
 		db:            database,
-		config:        cfg,
-		workers:       make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:       newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:      make(chan struct{}),
 		progress:      SyncProgress{Phase: "idle"},
 		processedURIs: sync.Map{},
 	}
+	bm.config.Store(cfg)
 
 	// Add wallet to database
 	wallet := &db.Wallet{
@@ -1338,12 +1352,12 @@ func TestBackupManager_BackupAsset_NonIPFSURI(t *testing.T) {
 
 	bm := &BackupManager{
 		db:            database,
-		config:        cfg,
-		workers:       make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:       newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:      make(chan struct{}),
 		progress:      SyncProgress{Phase: "idle"},
 		processedURIs: sync.Map{},
 	}
+	bm.config.Store(cfg)
 
 	// HTTP URLs should be skipped (no error)
 	err := bm.backupAsset(context.Background(), 1, "https://example.com/image.png", "artifact")
@@ -1364,12 +1378,12 @@ func TestBackupManager_BackupAsset_WhenPaused(t *testing.T) {
 
 	bm := &BackupManager{
 		db:            database,
-		config:        cfg,
-		workers:       make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:       newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:      make(chan struct{}),
 		progress:      SyncProgress{Phase: "idle"},
 		processedURIs: sync.Map{},
 	}
+	bm.config.Store(cfg)
 
 	// Pause the manager
 	bm.SetPaused(true)
@@ -1387,12 +1401,12 @@ func TestBackupManager_BackupAsset_Deduplication(t *testing.T) {
 
 	bm := &BackupManager{
 		db:            database,
-		config:        cfg,
-		workers:       make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:       newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:      make(chan struct{}),
 		progress:      SyncProgress{Phase: "idle"},
 		processedURIs: sync.Map{},
 	}
+	bm.config.Store(cfg)
 
 	uri := "ipfs://QmTestDedup"
 
@@ -1501,11 +1515,11 @@ func TestBackupManager_ConcurrentPauseCheck(t *testing.T) {
 
 	bm := &BackupManager{
 		db:       database,
-		config:   cfg,
-		workers:  make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:  newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown: make(chan struct{}),
 		progress: SyncProgress{Phase: "idle"},
 	}
+	bm.config.Store(cfg)
 
 	var wg sync.WaitGroup
 	var pausedCount, notPausedCount int32
@@ -1856,12 +1870,12 @@ func TestBackupManager_ProcessNFT_WhenPaused(t *testing.T) {
 
 	bm := &BackupManager{
 		db:            database,
-		config:        cfg,
-		workers:       make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:       newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:      make(chan struct{}),
 		progress:      SyncProgress{Phase: "idle"},
 		processedURIs: sync.Map{},
 	}
+	bm.config.Store(cfg)
 
 	// Pause the manager
 	bm.SetPaused(true)
@@ -1901,12 +1915,12 @@ func TestBackupManager_ProcessNFT_NoMetadata(t *testing.T) {
 	bm := &BackupManager{
 		indexer:       idx,
 		db:            database,
-		config:        cfg,
-		workers:       make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:       newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:      make(chan struct{}),
 		progress:      SyncProgress{Phase: "idle"},
 		processedURIs: sync.Map{},
 	}
+	bm.config.Store(cfg)
 
 	// Add wallet to DB
 	wallet := &db.Wallet{Address: "tz1TestWallet123456789012345678901234"}
@@ -1934,12 +1948,12 @@ func TestBackupManager_ProcessNFT_NoIPFSContent(t *testing.T) {
 
 	bm := &BackupManager{
 		db:            database,
-		config:        cfg,
-		workers:       make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:       newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:      make(chan struct{}),
 		progress:      SyncProgress{Phase: "idle"},
 		processedURIs: sync.Map{},
 	}
+	bm.config.Store(cfg)
 
 	// Add wallet to DB
 	wallet := &db.Wallet{Address: "tz1TestWallet123456789012345678901234"}
@@ -1976,12 +1990,12 @@ func TestBackupManager_ProcessNFT_ContextCancelled(t *testing.T) {
 		ipfs:          &ipfs.Node{}, // Non-nil but won't be used since we have metadata
 		indexer:       indexer.NewIndexer("http://localhost:1234"), // Non-nil indexer
 		db:            database,
-		config:        cfg,
-		workers:       make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:       newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:      make(chan struct{}),
 		progress:      SyncProgress{Phase: "idle"},
 		processedURIs: sync.Map{},
 	}
+	bm.config.Store(cfg)
 	_ = mockNode // Used only for type reference
 
 	// Add wallet to DB
@@ -2020,12 +2034,12 @@ func TestBackupManager_ProcessNFT_ShutdownChannel(t *testing.T) {
 		ipfs:          &ipfs.Node{}, // Non-nil but won't be used since we have metadata
 		indexer:       indexer.NewIndexer("http://localhost:1234"), // Non-nil indexer
 		db:            database,
-		config:        cfg,
-		workers:       make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:       newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:      shutdown,
 		progress:      SyncProgress{Phase: "idle"},
 		processedURIs: sync.Map{},
 	}
+	bm.config.Store(cfg)
 
 	// Add wallet to DB
 	wallet := &db.Wallet{Address: "tz1TestWallet123456789012345678901234"}
@@ -2074,12 +2088,12 @@ func TestBackupManager_UpdateDiskUsage_NotDirty(t *testing.T) {
 	bm := &BackupManager{
 		ipfs:           nil, // We'll use UpdateDiskUsage which needs GetRepoPath
 		db:             database,
-		config:         cfg,
-		workers:        make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:        newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:       make(chan struct{}),
 		progress:       SyncProgress{Phase: "idle"},
 		diskUsageDirty: 0, // Not dirty
 	}
+	bm.config.Store(cfg)
 
 	// UpdateDiskUsage should do nothing when not dirty
 	// We can't easily test this without the real IPFS node, but we verify
@@ -2120,12 +2134,12 @@ func TestBackupManager_UpdateDiskUsage_WhenDirty(t *testing.T) {
 	bm := &BackupManager{
 		ipfs:           &ipfs.Node{}, // We need a non-nil node for GetRepoPath to work
 		db:             database,
-		config:         cfg,
-		workers:        make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:        newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:       make(chan struct{}),
 		progress:       SyncProgress{Phase: "idle"},
 		diskUsageDirty: 1, // Mark dirty
 	}
+	bm.config.Store(cfg)
 
 	_ = mockNode // The real UpdateDiskUsage uses ipfs.GetRepoPath() which we can't mock easily
 
@@ -2154,12 +2168,12 @@ func TestBackupManager_PinAssetDirect_NonIPFS(t *testing.T) {
 
 	bm := &BackupManager{
 		db:            database,
-		config:        cfg,
-		workers:       make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:       newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:      make(chan struct{}),
 		progress:      SyncProgress{Phase: "idle"},
 		processedURIs: sync.Map{},
 	}
+	bm.config.Store(cfg)
 
 	// Create asset with non-IPFS URI
 	asset := &db.Asset{
@@ -2228,12 +2242,12 @@ func TestBackupManager_BackupAsset_StorageLimitReached(t *testing.T) {
 
 	bm := &BackupManager{
 		db:            database,
-		config:        cfg,
-		workers:       make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:       newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:      make(chan struct{}),
 		progress:      SyncProgress{Phase: "idle"},
 		processedURIs: sync.Map{},
 	}
+	bm.config.Store(cfg)
 
 	// Add wallet and NFT
 	wallet := &db.Wallet{Address: "tz1TestWallet123456789012345678901234"}
@@ -2277,12 +2291,12 @@ func TestBackupManager_BackupAsset_AlreadyPinned(t *testing.T) {
 
 	bm := &BackupManager{
 		db:            database,
-		config:        cfg,
-		workers:       make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:       newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:      make(chan struct{}),
 		progress:      SyncProgress{Phase: "idle"},
 		processedURIs: sync.Map{},
 	}
+	bm.config.Store(cfg)
 
 	// Add wallet and NFT
 	wallet := &db.Wallet{Address: "tz1TestWallet123456789012345678901234"}
@@ -2368,12 +2382,12 @@ func TestBackupManager_FetchMetadataFromChain_NonIPFSURI(t *testing.T) {
 	bm := &BackupManager{
 		indexer:       idx,
 		db:            database,
-		config:        cfg,
-		workers:       make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:       newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:      make(chan struct{}),
 		progress:      SyncProgress{Phase: "idle"},
 		processedURIs: sync.Map{},
 	}
+	bm.config.Store(cfg)
 
 	// Should fail because URI is not IPFS
 	_, err := bm.fetchMetadataFromChain(context.Background(), "KT1Test", "1")
@@ -2410,14 +2424,14 @@ func TestSyncWallet_NFTsArePersistedToDatabase(t *testing.T) {
 	bm := &BackupManager{
 		db:            database,
 		indexer:       idx,
-		config:        cfg,
-		workers:       make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:       newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:      make(chan struct{}),
 		progress:      SyncProgress{Phase: "idle"},
 		processedURIs: sync.Map{},
 		// NOTE: No IPFS node - we're testing DB persistence only
 		// backupAsset will skip non-IPFS URIs before needing the node
 	}
+	bm.config.Store(cfg)
 
 	// Create wallet in DB
 	wallet := &db.Wallet{
@@ -2441,6 +2455,12 @@ func TestSyncWallet_NFTsArePersistedToDatabase(t *testing.T) {
 			ArtifactURI:  "https://example.com/art.png",
 			DisplayURI:   "https://example.com/display.png",
 			ThumbnailURI: "https://example.com/thumb.png",
+			Tags:         json.RawMessage(`["abstract", "Abstract", "blue"]`),
+			Attributes:   json.RawMessage(`[{"name": "Edition", "value": 1}]`),
+			Rights:       "CC-BY-4.0",
+			Date:         "2021-03-01",
+			Royalties:    json.RawMessage(`{"decimals": 2, "shares": {"tz1artist": 10}}`),
+			Publishers:   json.RawMessage(`["Studio"]`),
 		},
 	}
 
@@ -2477,6 +2497,35 @@ func TestSyncWallet_NFTsArePersistedToDatabase(t *testing.T) {
 	if nft.WalletAddress != wallet.Address {
 		t.Errorf("NFT wallet = %q, want %q", nft.WalletAddress, wallet.Address)
 	}
+
+	// The creator and collection are recorded for enrichment
+	if creator, _ := database.GetCreator("tz1artist"); creator == nil || creator.NFTCount != 1 {
+		t.Errorf("creator = %+v, want tz1artist with 1 NFT", creator)
+	}
+	if contract, _ := database.GetContract(nft.ContractAddress); contract == nil || contract.Alias != "HEN" {
+		t.Errorf("contract = %+v, want the HEN alias", contract)
+	}
+
+	// Tags and attributes are stored, and the NFT can be searched by them
+	database.DB.Preload("Tags").Preload("Attributes").First(&nft)
+	if len(nft.Tags) != 2 || len(nft.Attributes) != 1 || nft.Attributes[0].Value != "1" {
+		t.Errorf("tags = %+v, attributes = %+v", nft.Tags, nft.Attributes)
+	}
+	if _, total, _ := database.SearchNFTs(db.SearchQuery{Text: "abstract hen"}); total != 1 {
+		t.Errorf("search by tag and collection found %d NFTs, want 1", total)
+	}
+
+	// So are the license, date, royalties and credits
+	database.DB.Preload("Royalties").Preload("Contributors").First(&nft)
+	if nft.Rights != "CC-BY-4.0" || nft.Date == nil || nft.Date.Format("2006-01-02") != "2021-03-01" {
+		t.Errorf("rights = %q, date = %v", nft.Rights, nft.Date)
+	}
+	if len(nft.Royalties) != 1 || nft.Royalties[0].Share != 0.1 {
+		t.Errorf("royalties = %+v", nft.Royalties)
+	}
+	if len(nft.Contributors) != 1 || nft.Contributors[0].Role != db.RolePublisher || nft.Contributors[0].Name != "Studio" {
+		t.Errorf("contributors = %+v", nft.Contributors)
+	}
 }
 
 // TestSyncWallet_AssetsAreQueuedForPinning proves that when NFTs are synced,
@@ -2484,11 +2533,78 @@ func TestSyncWallet_NFTsArePersistedToDatabase(t *testing.T) {
 // Note: This test requires IPFS node for full asset processing, so it tests
 // that the sync flow correctly identifies and attempts to queue assets.
 func TestSyncWallet_AssetsAreQueuedForPinning(t *testing.T) {
-	// This test is skipped because full asset processing requires a real IPFS node
-	// (hasSufficientDiskSpace calls ipfs.GetRepoPath which panics with nil node).
-	// The asset collection logic is tested in TestCollectAssetURIs and TestCountAssets.
-	// NFT persistence is tested in TestSyncWallet_NFTsArePersistedToDatabase.
-	t.Skip("Full asset queueing requires real IPFS node - collection logic tested separately")
+	database := testDB(t)
+	cfg := testConfig()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/head":
+			json.NewEncoder(w).Encode(map[string]int{"level": 1000})
+		case r.URL.Path == "/v1/tokens/balances":
+			response := []struct {
+				ID    uint64        `json:"id"`
+				Token indexer.Token `json:"token"`
+			}{
+				{
+					ID: 1,
+					Token: indexer.Token{
+						ID:       100,
+						TokenID:  "1",
+						Contract: indexer.ContractInfo{Address: "KT1Queued"},
+						Metadata: &indexer.TokenMetadata{
+							Name:         "Queued NFT",
+							ArtifactURI:  "ipfs://QmQueuedArtifact",
+							ThumbnailURI: "ipfs://QmQueuedThumb",
+						},
+					},
+				},
+			}
+			json.NewEncoder(w).Encode(response)
+		case r.URL.Path == "/v1/tokens":
+			json.NewEncoder(w).Encode([]indexer.Token{})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	bm := &BackupManager{
+		indexer:  indexer.NewIndexer(server.URL),
+		db:       database,
+		workers:  newWorkerSlots(cfg.Backup.MaxConcurrency),
+		shutdown: make(chan struct{}),
+		progress: SyncProgress{Phase: "idle"},
+	}
+	bm.config.Store(cfg)
+	// Create the queue without workers so nothing is pinned
+	bm.queueOnce.Do(func() { bm.queue = NewPinQueue(cfg.Backup.Queue) })
+
+	wallet := &db.Wallet{Address: "tz1Queued", SyncOwned: true, SyncCreated: false}
+	database.SaveWallet(wallet)
+
+	if _, err := bm.SyncWallet(context.Background(), wallet.Address); err != nil {
+		t.Fatalf("SyncWallet failed: %v", err)
+	}
+
+	var assets []db.Asset
+	database.DB.Find(&assets)
+	if len(assets) != 2 {
+		t.Fatalf("Expected 2 assets, got %d", len(assets))
+	}
+	for _, a := range assets {
+		if a.Status != db.StatusPending {
+			t.Errorf("Asset %s status = %s, want pending", a.URI, a.Status)
+		}
+	}
+
+	// The artifact is ahead of the thumbnail
+	task := bm.queue.Next()
+	if task == nil || task.URI != "ipfs://QmQueuedArtifact" || task.Wallet != wallet.Address {
+		t.Errorf("First task = %+v, want the artifact of %s", task, wallet.Address)
+	}
+	if bm.queue.Len() != 1 {
+		t.Errorf("Queue length = %d, want 1", bm.queue.Len())
+	}
 }
 
 // TestSyncWallet_DuplicateURIsAreDeduped proves that if the same IPFS URI
@@ -2536,12 +2652,12 @@ func TestSyncWallet_DuplicateURIsAreDeduped(t *testing.T) {
 	bm := &BackupManager{
 		indexer:       idx,
 		db:            database,
-		config:        cfg,
-		workers:       make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:       newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:      make(chan struct{}),
 		progress:      SyncProgress{Phase: "idle"},
 		processedURIs: sync.Map{},
 	}
+	bm.config.Store(cfg)
 
 	wallet := &db.Wallet{Address: "tz1Test", SyncOwned: true, SyncCreated: false}
 	database.SaveWallet(wallet)
@@ -2592,12 +2708,12 @@ func TestSyncWallet_IncrementalSyncPassesSinceLevel(t *testing.T) {
 	bm := &BackupManager{
 		indexer:       idx,
 		db:            database,
-		config:        cfg,
-		workers:       make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:       newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:      make(chan struct{}),
 		progress:      SyncProgress{Phase: "idle"},
 		processedURIs: sync.Map{},
 	}
+	bm.config.Store(cfg)
 
 	// Create wallet with a previous sync level
 	wallet := &db.Wallet{
@@ -2684,12 +2800,12 @@ func TestStorageLimitEnforcement(t *testing.T) {
 
 	bm := &BackupManager{
 		db:            database,
-		config:        cfg,
-		workers:       make(chan struct{}, cfg.Backup.MaxConcurrency),
+		workers:       newWorkerSlots(cfg.Backup.MaxConcurrency),
 		shutdown:      make(chan struct{}),
 		progress:      SyncProgress{Phase: "idle"},
 		processedURIs: sync.Map{},
 	}
+	bm.config.Store(cfg)
 
 	// Add pinned assets that exceed the limit
 	wallet := &db.Wallet{Address: "tz1Test"}
@@ -2720,3 +2836,1785 @@ func TestStorageLimitEnforcement(t *testing.T) {
 	}
 }
 
+
+// =============================================================================
+// JOB MANAGER TESTS
+// =============================================================================
+
+// waitForJobStatus polls until a job reaches the given status
+func waitForJobStatus(t *testing.T, database *db.Database, id uint64, status string) *db.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := database.GetJob(id)
+		if err != nil {
+			t.Fatalf("GetJob failed: %v", err)
+		}
+		if job != nil && job.Status == status {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	job, _ := database.GetJob(id)
+	t.Fatalf("Job %d did not reach status %q (last: %+v)", id, status, job)
+	return nil
+}
+
+func TestJobManager_SubmitCompletes(t *testing.T) {
+	database := testDB(t)
+	jm := NewJobManager(database)
+
+	type params struct {
+		Name string `json:"name"`
+	}
+	var got params
+	jm.RegisterHandler("test", func(ctx context.Context, jc *JobContext) error {
+		if err := jc.Params(&got); err != nil {
+			return err
+		}
+		jc.SetProgress(3, 3, "done")
+		return nil
+	})
+
+	var updates int32
+	jm.OnUpdate(func(db.Job) { atomic.AddInt32(&updates, 1) })
+
+	job, err := jm.Submit(context.Background(), "test", params{Name: "hello"})
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	jm.Wait(job.ID)
+
+	final := waitForJobStatus(t, database, job.ID, db.JobStatusCompleted)
+	if got.Name != "hello" {
+		t.Errorf("Params not decoded, got %+v", got)
+	}
+	if final.Current != 3 || final.Total != 3 {
+		t.Errorf("Progress = %d/%d, want 3/3", final.Current, final.Total)
+	}
+	if final.StartedAt == nil || final.FinishedAt == nil {
+		t.Error("StartedAt and FinishedAt should be set")
+	}
+	if atomic.LoadInt32(&updates) < 3 {
+		t.Errorf("Expected at least 3 updates (running, progress, completed), got %d", updates)
+	}
+}
+
+func TestJobManager_UnknownType(t *testing.T) {
+	jm := NewJobManager(testDB(t))
+	if _, err := jm.Submit(context.Background(), "nope", nil); err == nil {
+		t.Error("Submit should fail for unregistered job type")
+	}
+}
+
+func TestJobManager_FailedJobCanResume(t *testing.T) {
+	database := testDB(t)
+	jm := NewJobManager(database)
+
+	var attempts int32
+	jm.RegisterHandler("flaky", func(ctx context.Context, jc *JobContext) error {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			return fmt.Errorf("boom")
+		}
+		return nil
+	})
+
+	job, err := jm.Submit(context.Background(), "flaky", nil)
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	jm.Wait(job.ID)
+	failed := waitForJobStatus(t, database, job.ID, db.JobStatusFailed)
+	if failed.Error != "boom" {
+		t.Errorf("Error = %q, want 'boom'", failed.Error)
+	}
+
+	if _, err := jm.Resume(context.Background(), job.ID); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	jm.Wait(job.ID)
+	done := waitForJobStatus(t, database, job.ID, db.JobStatusCompleted)
+	if done.Error != "" {
+		t.Errorf("Error should be cleared on resume, got %q", done.Error)
+	}
+
+	if _, err := jm.Resume(context.Background(), job.ID); err == nil {
+		t.Error("Resume of a completed job should fail")
+	}
+}
+
+func TestJobManager_ConcurrentResumeStartsOnce(t *testing.T) {
+	database := testDB(t)
+	jm := NewJobManager(database)
+
+	var attempts int32
+	release := make(chan struct{})
+	jm.RegisterHandler("flaky", func(ctx context.Context, jc *JobContext) error {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			return fmt.Errorf("boom")
+		}
+		<-release
+		return nil
+	})
+
+	job, err := jm.Submit(context.Background(), "flaky", nil)
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	jm.Wait(job.ID)
+	waitForJobStatus(t, database, job.ID, db.JobStatusFailed)
+
+	var wg sync.WaitGroup
+	var resumed int32
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := jm.Resume(context.Background(), job.ID); err == nil {
+				atomic.AddInt32(&resumed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	close(release)
+	jm.Wait(job.ID)
+
+	if resumed != 1 {
+		t.Errorf("%d concurrent resumes succeeded, want 1", resumed)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("handler ran %d times, want 2", got)
+	}
+	waitForJobStatus(t, database, job.ID, db.JobStatusCompleted)
+}
+
+func TestJobManager_PanicMarksFailed(t *testing.T) {
+	database := testDB(t)
+	jm := NewJobManager(database)
+	jm.RegisterHandler("panic", func(ctx context.Context, jc *JobContext) error {
+		panic("oops")
+	})
+
+	job, _ := jm.Submit(context.Background(), "panic", nil)
+	jm.Wait(job.ID)
+	waitForJobStatus(t, database, job.ID, db.JobStatusFailed)
+}
+
+func TestJobManager_Cancel(t *testing.T) {
+	database := testDB(t)
+	jm := NewJobManager(database)
+
+	started := make(chan struct{})
+	jm.RegisterHandler("block", func(ctx context.Context, jc *JobContext) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	job, _ := jm.Submit(context.Background(), "block", nil)
+	<-started
+
+	if err := jm.Cancel(job.ID); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	cancelled := waitForJobStatus(t, database, job.ID, db.JobStatusCancelled)
+	if cancelled.FinishedAt == nil {
+		t.Error("FinishedAt should be set on cancel")
+	}
+
+	if err := jm.Cancel(job.ID); err == nil {
+		t.Error("Cancelling a finished job should fail")
+	}
+	if err := jm.Cancel(9999); err != ErrJobNotFound {
+		t.Errorf("Cancel of missing job = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestJobManager_ResumeFromCheckpointAfterShutdown(t *testing.T) {
+	database := testDB(t)
+
+	type checkpoint struct {
+		Next int `json:"next"`
+	}
+	const items = 10
+
+	var mu sync.Mutex
+	var processed []int
+	handler := func(stopAt int) JobHandler {
+		return func(ctx context.Context, jc *JobContext) error {
+			var cp checkpoint
+			if _, err := jc.Checkpoint(&cp); err != nil {
+				return err
+			}
+			for i := cp.Next; i < items; i++ {
+				if i == stopAt {
+					// Simulate shutdown mid-job
+					<-ctx.Done()
+					return ctx.Err()
+				}
+				mu.Lock()
+				processed = append(processed, i)
+				mu.Unlock()
+				if err := jc.SaveCheckpoint(checkpoint{Next: i + 1}); err != nil {
+					return err
+				}
+				jc.SetProgress(i+1, items, "")
+			}
+			return nil
+		}
+	}
+
+	// First run: stops at item 4 and is interrupted
+	jm1 := NewJobManager(database)
+	jm1.RegisterHandler("count", handler(4))
+	ctx, cancel := context.WithCancel(context.Background())
+	job, err := jm1.Submit(ctx, "count", nil)
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	waitForJobStatus(t, database, job.ID, db.JobStatusRunning)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		j, _ := database.GetJob(job.ID)
+		if j != nil && j.Current == 4 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for job to reach item 4")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	if !jm1.WaitAll(5 * time.Second) {
+		t.Fatal("WaitAll timed out")
+	}
+
+	interrupted := waitForJobStatus(t, database, job.ID, db.JobStatusPending)
+	if interrupted.Current != 4 {
+		t.Errorf("Current = %d after interrupt, want 4", interrupted.Current)
+	}
+
+	// Second run (simulated restart): resumes from the checkpoint
+	jm2 := NewJobManager(database)
+	jm2.RegisterHandler("count", handler(-1))
+	if n := jm2.ResumeUnfinished(context.Background()); n != 1 {
+		t.Fatalf("ResumeUnfinished = %d, want 1", n)
+	}
+	jm2.Wait(job.ID)
+	waitForJobStatus(t, database, job.ID, db.JobStatusCompleted)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(processed) != items {
+		t.Fatalf("Processed %d items, want %d (each exactly once): %v", len(processed), items, processed)
+	}
+	for i, v := range processed {
+		if v != i {
+			t.Errorf("processed[%d] = %d, want %d", i, v, i)
+		}
+	}
+}
+
+func TestJobManager_ResumeUnfinishedSkipsUnknownTypes(t *testing.T) {
+	database := testDB(t)
+	database.CreateJob(&db.Job{Type: JobTypeStorageMigrate})
+
+	jm := NewJobManager(database)
+	if n := jm.ResumeUnfinished(context.Background()); n != 0 {
+		t.Errorf("ResumeUnfinished = %d, want 0 for jobs without a handler", n)
+	}
+}
+
+func TestBackupManager_VerifyAndFixPinsFromCheckpoint(t *testing.T) {
+	database := testDB(t)
+	cfg := testConfig()
+	bm := NewBackupManager(newMockIPFSNode(), indexer.NewIndexer(cfg.TZKT.BaseURL), database, cfg)
+
+	wallet := &db.Wallet{Address: "tz1Checkpoint"}
+	database.SaveWallet(wallet)
+	var ids []uint64
+	for i := 0; i < 3; i++ {
+		nft := &db.NFT{
+			TokenID:         fmt.Sprintf("%d", i),
+			ContractAddress: "KT1Checkpoint",
+			WalletAddress:   wallet.Address,
+			ArtifactURI:     fmt.Sprintf("ipfs://QmCheckpoint%d", i),
+		}
+		database.SaveNFT(nft)
+		ids = append(ids, nft.ID)
+	}
+
+	var lastSeen uint64
+	stats, err := bm.VerifyAndFixPinsFrom(context.Background(), ids[0], map[string]int{"checked": 5}, func(lastID uint64, stats map[string]int) error {
+		lastSeen = lastID
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("VerifyAndFixPinsFrom failed: %v", err)
+	}
+	if stats["checked"] != 7 {
+		t.Errorf("checked = %d, want 7 (5 carried over + 2 new)", stats["checked"])
+	}
+	if lastSeen != ids[2] {
+		t.Errorf("Checkpoint last ID = %d, want %d", lastSeen, ids[2])
+	}
+
+	// NFT before the checkpoint must not have been processed
+	var count int64
+	database.Model(&db.Asset{}).Where("nft_id = ?", ids[0]).Count(&count)
+	if count != 0 {
+		t.Errorf("NFT before checkpoint got %d assets, want 0", count)
+	}
+	database.Model(&db.Asset{}).Where("nft_id = ?", ids[1]).Count(&count)
+	if count != 1 {
+		t.Errorf("NFT after checkpoint got %d assets, want 1", count)
+	}
+}
+
+func TestBackupService_VerifyAndFixPinsRunsAsJob(t *testing.T) {
+	database := testDB(t)
+	cfg := testConfig()
+	svc := NewBackupService(nil, indexer.NewIndexer(cfg.TZKT.BaseURL), database, cfg)
+
+	wallet := &db.Wallet{Address: "tz1JobVerify"}
+	database.SaveWallet(wallet)
+	database.SaveNFT(&db.NFT{TokenID: "1", ContractAddress: "KT1JobVerify", WalletAddress: wallet.Address})
+
+	stats, err := svc.VerifyAndFixPins()
+	if err != nil {
+		t.Fatalf("VerifyAndFixPins failed: %v", err)
+	}
+	if stats["checked"] != 1 {
+		t.Errorf("checked = %d, want 1", stats["checked"])
+	}
+
+	jobs, _ := svc.Jobs().List("", 0)
+	if len(jobs) != 1 || jobs[0].Type != JobTypeVerifyAndFix || jobs[0].Status != db.JobStatusCompleted {
+		t.Errorf("Expected one completed verify_and_fix job, got %+v", jobs)
+	}
+}
+
+func TestBackupService_DeleteWalletJob(t *testing.T) {
+	database := testDB(t)
+	cfg := testConfig()
+	svc := NewBackupService(nil, indexer.NewIndexer(cfg.TZKT.BaseURL), database, cfg)
+
+	wallet := &db.Wallet{Address: "tz1JobDelete"}
+	database.SaveWallet(wallet)
+	nft := &db.NFT{TokenID: "1", ContractAddress: "KT1JobDelete", WalletAddress: wallet.Address}
+	database.SaveNFT(nft)
+	for i := 0; i < 3; i++ {
+		database.SaveAsset(&db.Asset{NFTID: nft.ID, URI: fmt.Sprintf("ipfs://QmJobDelete%d", i), Status: db.StatusPinned})
+	}
+
+	job, err := svc.SubmitJob(JobTypeDeleteWallet, DeleteWalletParams{Address: wallet.Address, Unpin: true})
+	if err != nil {
+		t.Fatalf("SubmitJob failed: %v", err)
+	}
+	svc.Jobs().Wait(job.ID)
+	done := waitForJobStatus(t, database, job.ID, db.JobStatusCompleted)
+
+	var cp DeleteWalletCheckpoint
+	json.Unmarshal([]byte(done.Checkpoint), &cp)
+	if cp.Unpinned != 3 {
+		t.Errorf("Unpinned = %d, want 3", cp.Unpinned)
+	}
+
+	if w, _ := database.GetWallet(wallet.Address); w != nil {
+		t.Error("Wallet should be deleted")
+	}
+	var count int64
+	database.Model(&db.Asset{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Assets remaining = %d, want 0", count)
+	}
+}
+
+func TestBackupService_UnpinAllJobRequiresIPFS(t *testing.T) {
+	database := testDB(t)
+	cfg := testConfig()
+	svc := NewBackupService(nil, indexer.NewIndexer(cfg.TZKT.BaseURL), database, cfg)
+
+	job, err := svc.SubmitJob(JobTypeUnpinAll, UnpinAllParams{})
+	if err != nil {
+		t.Fatalf("SubmitJob failed: %v", err)
+	}
+	svc.Jobs().Wait(job.ID)
+	waitForJobStatus(t, database, job.ID, db.JobStatusFailed)
+}
+
+// =============================================================================
+// PIN QUEUE TESTS
+// =============================================================================
+
+func testQueueWeights() config.QueueConfig {
+	return config.DefaultConfig().Backup.Queue
+}
+
+// drainQueue takes every task from the queue in order
+func drainQueue(q *PinQueue) []*PinTask {
+	var tasks []*PinTask
+	for {
+		task := q.Next()
+		if task == nil {
+			return tasks
+		}
+		q.Done(task)
+		tasks = append(tasks, task)
+	}
+}
+
+func TestPinQueue_RoundRobinAcrossWallets(t *testing.T) {
+	q := NewPinQueue(testQueueWeights())
+
+	// A large wallet queued first must not starve a small one
+	for i := 0; i < 10; i++ {
+		q.Push(PinTask{AssetID: uint64(i), URI: fmt.Sprintf("ipfs://QmBig%d", i), Type: "artifact", Wallet: "tz1Big"}, 0)
+	}
+	q.Push(PinTask{AssetID: 100, URI: "ipfs://QmSmall0", Type: "artifact", Wallet: "tz1Small"}, 0)
+	q.Push(PinTask{AssetID: 101, URI: "ipfs://QmSmall1", Type: "artifact", Wallet: "tz1Small"}, 0)
+
+	tasks := drainQueue(q)
+	if len(tasks) != 12 {
+		t.Fatalf("Drained %d tasks, want 12", len(tasks))
+	}
+	var wallets []string
+	for _, task := range tasks[:4] {
+		wallets = append(wallets, task.Wallet)
+	}
+	want := []string{"tz1Big", "tz1Small", "tz1Big", "tz1Small"}
+	for i := range want {
+		if wallets[i] != want[i] {
+			t.Fatalf("First wallets = %v, want %v", wallets, want)
+		}
+	}
+}
+
+func TestPinQueue_WalletPriorityQuantum(t *testing.T) {
+	q := NewPinQueue(testQueueWeights())
+
+	for i := 0; i < 6; i++ {
+		q.Push(PinTask{URI: fmt.Sprintf("ipfs://QmHigh%d", i), Type: "artifact", Wallet: "tz1High"}, 2)
+		q.Push(PinTask{URI: fmt.Sprintf("ipfs://QmLow%d", i), Type: "artifact", Wallet: "tz1Low"}, 0)
+	}
+
+	// Priority 2 with weight 1.0 gives three tasks per turn
+	tasks := drainQueue(q)
+	var got []string
+	for _, task := range tasks[:8] {
+		got = append(got, task.Wallet)
+	}
+	want := []string{"tz1High", "tz1High", "tz1High", "tz1Low", "tz1High", "tz1High", "tz1High", "tz1Low"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Order = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestPinQueue_SetWalletPriority(t *testing.T) {
+	q := NewPinQueue(testQueueWeights())
+	for i := 0; i < 4; i++ {
+		q.Push(PinTask{URI: fmt.Sprintf("ipfs://QmA%d", i), Wallet: "tz1A"}, 0)
+		q.Push(PinTask{URI: fmt.Sprintf("ipfs://QmB%d", i), Wallet: "tz1B"}, 0)
+	}
+	q.SetWalletPriority("tz1B", 1)
+
+	status := q.Status()
+	if len(status.Wallets) != 2 || status.Wallets[1].Address != "tz1B" || status.Wallets[1].Priority != 1 {
+		t.Errorf("Wallets = %+v, want tz1B with priority 1", status.Wallets)
+	}
+}
+
+func TestPinQueue_AssetTypeOrder(t *testing.T) {
+	q := NewPinQueue(testQueueWeights())
+
+	for _, typ := range []string{"thumbnail", "format", "display", "artifact"} {
+		q.Push(PinTask{URI: "ipfs://Qm" + typ, Type: typ, Wallet: "tz1Test"}, 0)
+	}
+
+	tasks := drainQueue(q)
+	want := []string{"artifact", "display", "format", "thumbnail"}
+	for i, task := range tasks {
+		if task.Type != want[i] {
+			t.Errorf("Task %d type = %s, want %s", i, task.Type, want[i])
+		}
+	}
+}
+
+func TestPinQueue_SizeAndAgeWeights(t *testing.T) {
+	q := NewPinQueue(config.QueueConfig{SizeWeight: 1})
+	q.Push(PinTask{URI: "ipfs://QmLarge", Wallet: "tz1Test", SizeBytes: 500 << 20}, 0)
+	q.Push(PinTask{URI: "ipfs://QmSmall", Wallet: "tz1Test", SizeBytes: 1 << 20}, 0)
+	if task := q.Next(); task.URI != "ipfs://QmSmall" {
+		t.Errorf("Size weight: first = %s, want ipfs://QmSmall", task.URI)
+	}
+
+	q = NewPinQueue(config.QueueConfig{AgeWeight: 1})
+	q.Push(PinTask{URI: "ipfs://QmNew", Wallet: "tz1Test", CreatedAt: time.Now()}, 0)
+	q.Push(PinTask{URI: "ipfs://QmOld", Wallet: "tz1Test", CreatedAt: time.Now().Add(-72 * time.Hour)}, 0)
+	if task := q.Next(); task.URI != "ipfs://QmOld" {
+		t.Errorf("Age weight: first = %s, want ipfs://QmOld", task.URI)
+	}
+
+	// Re-weighting re-orders tasks already queued
+	q = NewPinQueue(config.QueueConfig{SizeWeight: 1})
+	q.Push(PinTask{URI: "ipfs://QmThumb", Type: "thumbnail", Wallet: "tz1Test", SizeBytes: 1 << 10}, 0)
+	q.Push(PinTask{URI: "ipfs://QmArt", Type: "artifact", Wallet: "tz1Test", SizeBytes: 500 << 20}, 0)
+	q.SetWeights(config.QueueConfig{AssetTypeWeight: 1})
+	if task := q.Next(); task.URI != "ipfs://QmArt" {
+		t.Errorf("After SetWeights: first = %s, want ipfs://QmArt", task.URI)
+	}
+}
+
+func TestPinQueue_Bump(t *testing.T) {
+	q := NewPinQueue(testQueueWeights())
+	for i := 0; i < 3; i++ {
+		q.Push(PinTask{URI: fmt.Sprintf("ipfs://Qm%d", i), Type: "artifact", Wallet: "tz1Test"}, 0)
+	}
+
+	if q.Bump("ipfs://QmMissing") {
+		t.Error("Bump of unknown URI should return false")
+	}
+	if !q.Bump("ipfs://Qm2") || !q.Bump("ipfs://Qm1") {
+		t.Fatal("Bump of queued URI should return true")
+	}
+
+	status := q.Status()
+	if len(status.Bumped) != 2 || status.Bumped[0].URI != "ipfs://Qm1" {
+		t.Errorf("Bumped = %+v, want Qm1 first", status.Bumped)
+	}
+
+	// Most recent bump runs first
+	tasks := drainQueue(q)
+	want := []string{"ipfs://Qm1", "ipfs://Qm2", "ipfs://Qm0"}
+	for i, task := range tasks {
+		if task.URI != want[i] {
+			t.Errorf("Task %d = %s, want %s", i, task.URI, want[i])
+		}
+	}
+}
+
+func TestPinQueue_Deduplication(t *testing.T) {
+	q := NewPinQueue(testQueueWeights())
+
+	if !q.Push(PinTask{URI: "ipfs://QmShared", Wallet: "tz1A"}, 0) {
+		t.Fatal("First push should succeed")
+	}
+	if q.Push(PinTask{URI: "ipfs://QmShared", Wallet: "tz1B"}, 0) {
+		t.Error("Push of queued URI should be rejected")
+	}
+
+	task := q.Next()
+	if q.Push(PinTask{URI: "ipfs://QmShared", Wallet: "tz1B"}, 0) {
+		t.Error("Push of in-flight URI should be rejected")
+	}
+	if !q.Contains("ipfs://QmShared") {
+		t.Error("In-flight URI should be reported by Contains")
+	}
+
+	q.Done(task)
+	if q.Contains("ipfs://QmShared") {
+		t.Error("Finished URI should not be reported by Contains")
+	}
+	if !q.Push(PinTask{URI: "ipfs://QmShared", Wallet: "tz1B"}, 0) {
+		t.Error("Push after Done should succeed")
+	}
+}
+
+func TestPinQueue_Requeue(t *testing.T) {
+	q := NewPinQueue(testQueueWeights())
+	q.Push(PinTask{URI: "ipfs://QmA", Wallet: "tz1Test"}, 0)
+
+	task := q.Next()
+	q.Requeue(task)
+
+	status := q.Status()
+	if status.Queued != 1 || status.InFlight != 0 {
+		t.Errorf("After Requeue: queued=%d in_flight=%d, want 1 and 0", status.Queued, status.InFlight)
+	}
+}
+
+func TestBackupManager_EnqueueAndPinNext(t *testing.T) {
+	database := testDB(t)
+	cfg := testConfig()
+	bm := NewBackupManager(newMockIPFSNode(), indexer.NewIndexer(cfg.TZKT.BaseURL), database, cfg)
+	defer bm.Shutdown()
+
+	// Keep the workers idle so the queue can be inspected
+	bm.SetPaused(true)
+
+	database.SaveWallet(&db.Wallet{Address: "tz1Queue", Priority: 3})
+	nft := &db.NFT{TokenID: "1", ContractAddress: "KT1Queue", WalletAddress: "tz1Queue"}
+	database.SaveNFT(nft)
+	pending := &db.Asset{NFTID: nft.ID, URI: "ipfs://QmPending", Type: "artifact", Status: db.StatusPending}
+	failed := &db.Asset{NFTID: nft.ID, URI: "ipfs://QmFailed", Type: "thumbnail", Status: db.StatusFailed}
+	pinned := &db.Asset{NFTID: nft.ID, URI: "ipfs://QmPinned", Type: "display", Status: db.StatusPinned}
+	database.SaveAsset(pending)
+	database.SaveAsset(failed)
+	database.SaveAsset(pinned)
+
+	if n := bm.EnqueuePendingAssets(); n != 1 {
+		t.Errorf("EnqueuePendingAssets = %d, want 1", n)
+	}
+	if n := bm.EnqueuePendingAssets(); n != 0 {
+		t.Errorf("Second EnqueuePendingAssets = %d, want 0", n)
+	}
+
+	status := bm.QueueStatus()
+	if len(status.Wallets) != 1 || status.Wallets[0].Priority != 3 {
+		t.Errorf("Wallets = %+v, want tz1Queue with priority 3", status.Wallets)
+	}
+
+	// A failed asset is reset and queued at the front
+	if err := bm.PinNext(failed.ID); err != nil {
+		t.Fatalf("PinNext failed: %v", err)
+	}
+	if a, _ := database.GetAssetByID(failed.ID); a.Status != db.StatusPending {
+		t.Errorf("Status after PinNext = %s, want pending", a.Status)
+	}
+	status = bm.QueueStatus()
+	if status.Queued != 2 || len(status.Bumped) != 1 || status.Bumped[0].AssetID != failed.ID {
+		t.Errorf("Queue after PinNext = %+v", status)
+	}
+
+	if err := bm.PinNext(pinned.ID); err != ErrAssetAlreadyPinned {
+		t.Errorf("PinNext of pinned asset = %v, want ErrAssetAlreadyPinned", err)
+	}
+	if err := bm.PinNext(9999); err == nil {
+		t.Error("PinNext of missing asset should fail")
+	}
+}
+
+func TestBackupManager_WalletQuotaDefersOnlyThatWallet(t *testing.T) {
+	database := testDB(t)
+	cfg := testConfig()
+	bm := NewBackupManager(newMockIPFSNode(), indexer.NewIndexer(cfg.TZKT.BaseURL), database, cfg)
+	defer bm.Shutdown()
+
+	// Keep the workers idle so the queue can be inspected
+	bm.SetPaused(true)
+
+	database.SaveWallet(&db.Wallet{Address: "tz1Full", QuotaGB: 1})
+	database.SaveWallet(&db.Wallet{Address: "tz1Free"})
+
+	full := &db.NFT{TokenID: "1", ContractAddress: "KT1Quota", WalletAddress: "tz1Full"}
+	free := &db.NFT{TokenID: "2", ContractAddress: "KT1Quota", WalletAddress: "tz1Free"}
+	database.SaveNFT(full)
+	database.SaveNFT(free)
+	database.SaveAsset(&db.Asset{NFTID: full.ID, URI: "ipfs://QmBig", Status: db.StatusPinned, SizeBytes: bytesPerGB})
+	fullPending := &db.Asset{NFTID: full.ID, URI: "ipfs://QmFullPending", Type: "artifact", Status: db.StatusPending}
+	freePending := &db.Asset{NFTID: free.ID, URI: "ipfs://QmFreePending", Type: "artifact", Status: db.StatusPending}
+	database.SaveAsset(fullPending)
+	database.SaveAsset(freePending)
+
+	if !bm.isOverQuota("tz1Full") {
+		t.Error("tz1Full should be over its quota")
+	}
+	if bm.isOverQuota("tz1Free") {
+		t.Error("tz1Free has no quota and should never be over it")
+	}
+
+	if n := bm.EnqueuePendingAssets(); n != 1 {
+		t.Errorf("EnqueuePendingAssets = %d, want 1", n)
+	}
+	q := bm.pinQueue()
+	if q.Contains(fullPending.URI) || !q.Contains(freePending.URI) {
+		t.Error("Only the wallet within its quota should be queued")
+	}
+
+	// Raising the quota queues the deferred assets again
+	database.SaveWallet(&db.Wallet{Address: "tz1Full", QuotaGB: 2})
+	bm.ApplyWalletQuota("tz1Full")
+	if !q.Contains(fullPending.URI) {
+		t.Error("Deferred asset should be queued after the quota is raised")
+	}
+
+	// A shared pin forces a refresh, where tz1Full is charged half of it
+	shared := &db.Asset{NFTID: free.ID, URI: "ipfs://QmShared", Status: db.StatusPinned, SizeBytes: bytesPerGB}
+	database.SaveAsset(shared)
+	database.SaveNFT(&db.NFT{TokenID: "3", ContractAddress: "KT1Quota", WalletAddress: "tz1Full", ArtifactURI: shared.URI})
+	bm.isOverQuota("tz1Full")
+	bm.addQuotaUsage("tz1Full", shared)
+	if bm.isOverQuota("tz1Full") {
+		t.Error("tz1Full should be charged only its share of a shared pin")
+	}
+	bm.quotaMu.Lock()
+	used := bm.quota.used["tz1Full"]
+	bm.quotaMu.Unlock()
+	if used != bytesPerGB+bytesPerGB/2 {
+		t.Errorf("tz1Full used = %d, want 1.5 GB", used)
+	}
+
+	// Its own pins are charged to the cached usage until the next refresh
+	own := &db.Asset{NFTID: full.ID, URI: "ipfs://QmOwn", Status: db.StatusPinned, SizeBytes: bytesPerGB}
+	database.SaveAsset(own)
+	bm.addQuotaUsage("tz1Full", own)
+	if !bm.isOverQuota("tz1Full") {
+		t.Error("tz1Full should be over its quota after pinning another GB")
+	}
+}
+
+func TestBackupManager_Quiesce(t *testing.T) {
+	cfg := testConfig()
+	bm := NewBackupManager(newMockIPFSNode(), indexer.NewIndexer(cfg.TZKT.BaseURL), testDB(t), cfg)
+
+	// A pin in flight holds one of the two worker slots
+	bm.workers.acquire(context.Background(), nil)
+	done := make(chan func())
+	go func() {
+		done <- bm.quiesce(time.Second)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("quiesce() returned while a pin was in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	bm.workers.release() // The pin finishes
+	release := <-done
+	if bm.workers.inUse() != bm.workers.size() {
+		t.Errorf("quiesce() holds %d of %d slots", bm.workers.inUse(), bm.workers.size())
+	}
+	release()
+	if bm.workers.inUse() != 0 {
+		t.Errorf("release() left %d slots held", bm.workers.inUse())
+	}
+
+	// A stuck pin only delays it until the timeout
+	bm.workers.acquire(context.Background(), nil)
+	release = bm.quiesce(10 * time.Millisecond)
+	release()
+	if bm.workers.inUse() != 1 {
+		t.Errorf("after a timed-out quiesce %d slots held, want the stuck pin's 1", bm.workers.inUse())
+	}
+}
+
+func TestBackupService_StorageMigrationUnavailable(t *testing.T) {
+	cfg := testConfig()
+	svc := NewBackupService(nil, indexer.NewIndexer(cfg.TZKT.BaseURL), testDB(t), cfg)
+	defer svc.GetManager().Shutdown()
+
+	if err := svc.StartStorageMigration(t.TempDir()); !errors.Is(err, ErrStorageMigrationUnavailable) {
+		t.Errorf("StartStorageMigration() without an IPFS node error = %v, want ErrStorageMigrationUnavailable", err)
+	}
+}
+
+// =============================================================================
+// REPLICATION TESTS
+// =============================================================================
+
+// fakeCatalog serves a peer catalog from memory, paged like the REST endpoint
+type fakeCatalog struct {
+	mu      sync.Mutex
+	entries []CatalogEntry
+	calls   int
+}
+
+func (f *fakeCatalog) Catalog(ctx context.Context, since *time.Time, offset, limit int) (*CatalogPage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+
+	var matching []CatalogEntry
+	for _, e := range f.entries {
+		if since == nil || e.PinnedAt.After(*since) {
+			matching = append(matching, e)
+		}
+	}
+	page := &CatalogPage{NodeID: "12D3KooWPeer", Total: int64(len(f.entries))}
+	if offset < len(matching) {
+		end := offset + limit
+		if end > len(matching) {
+			end = len(matching)
+		}
+		page.Entries = matching[offset:end]
+		page.HasMore = end < len(matching)
+	}
+	return page, nil
+}
+
+func TestBuildCatalogPage(t *testing.T) {
+	database := testDB(t)
+	nft := &db.NFT{TokenID: "1", ContractAddress: "KT1Catalog", WalletAddress: "tz1Catalog"}
+	database.SaveNFT(nft)
+
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		pinnedAt := base.Add(time.Duration(i) * time.Minute)
+		database.SaveAsset(&db.Asset{
+			NFTID:     nft.ID,
+			URI:       fmt.Sprintf("ipfs://QmCatalog%d/file.png", i),
+			Status:    db.StatusPinned,
+			SizeBytes: int64(i),
+			PinnedAt:  &pinnedAt,
+		})
+	}
+	database.SaveAsset(&db.Asset{NFTID: nft.ID, URI: "ipfs://QmNotPinned", Status: db.StatusPending})
+
+	page, err := BuildCatalogPage(database, nil, 0, 3)
+	if err != nil {
+		t.Fatalf("BuildCatalogPage failed: %v", err)
+	}
+	if page.Total != 5 || len(page.Entries) != 3 || !page.HasMore {
+		t.Fatalf("First page = total %d, %d entries, more %v; want 5, 3, true", page.Total, len(page.Entries), page.HasMore)
+	}
+	if page.Entries[0].CID != "QmCatalog0" {
+		t.Errorf("First entry CID = %q, want QmCatalog0 (oldest first, path stripped)", page.Entries[0].CID)
+	}
+
+	page, _ = BuildCatalogPage(database, nil, 3, 3)
+	if len(page.Entries) != 2 || page.HasMore {
+		t.Errorf("Second page = %d entries, more %v; want 2, false", len(page.Entries), page.HasMore)
+	}
+
+	since := base.Add(2 * time.Minute)
+	page, _ = BuildCatalogPage(database, &since, 0, 10)
+	if len(page.Entries) != 2 || page.Entries[0].CID != "QmCatalog3" {
+		t.Errorf("Entries since %v = %+v, want QmCatalog3 and QmCatalog4", since, page.Entries)
+	}
+}
+
+func TestReplicator_SyncPeer(t *testing.T) {
+	database := testDB(t)
+	cfg := testConfig()
+	mockIPFS := newMockIPFSNode()
+	bm := NewBackupManager(mockIPFS, indexer.NewIndexer(cfg.TZKT.BaseURL), database, cfg)
+	defer bm.Shutdown()
+	r := NewReplicator(bm, database)
+
+	peer := &db.ReplicationPeer{Name: "studio", Host: "studio.local", Port: 8085, Token: "secret", Enabled: true}
+	database.SaveReplicationPeer(peer)
+
+	ctx := context.Background()
+	if err := r.SyncPeer(ctx, peer.ID, false, nil); err != ErrReplicationUnavailable {
+		t.Fatalf("SyncPeer without a dialer = %v, want ErrReplicationUnavailable", err)
+	}
+
+	base := time.Now().Add(-time.Hour)
+	source := &fakeCatalog{}
+	for i := 0; i < catalogPageSize+2; i++ {
+		source.entries = append(source.entries, CatalogEntry{
+			CID:      fmt.Sprintf("QmRemote%d", i),
+			URI:      fmt.Sprintf("ipfs://QmRemote%d", i),
+			PinnedAt: base.Add(time.Duration(i) * time.Millisecond),
+		})
+	}
+	r.SetDialer(func(p *db.ReplicationPeer) CatalogSource { return source })
+
+	if err := r.SyncPeer(ctx, peer.ID, false, nil); err != nil {
+		t.Fatalf("SyncPeer failed: %v", err)
+	}
+	if source.calls != 2 {
+		t.Errorf("Catalog pages read = %d, want 2", source.calls)
+	}
+	if !mockIPFS.pinned["QmRemote0"] || !mockIPFS.pinned[fmt.Sprintf("QmRemote%d", catalogPageSize+1)] {
+		t.Error("Every CID in the peer's catalog should be pinned locally")
+	}
+
+	status, err := r.Status(peer.ID)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if status.Counts.Pinned != int64(catalogPageSize+2) || status.Divergence != 0 || status.LagSeconds != 0 {
+		t.Errorf("Status after sync = %+v, want all pinned with no lag or divergence", status)
+	}
+	if status.Peer.NodeID != "12D3KooWPeer" || status.Peer.Cursor == nil || status.Peer.LastFullAt == nil {
+		t.Errorf("Peer after sync = %+v, want node ID, cursor and full sync time recorded", status.Peer)
+	}
+
+	// An incremental sync only reads what the peer pinned since, and reports failures as lag
+	source.entries = append(source.entries, CatalogEntry{CID: "QmNew", URI: "ipfs://QmNew", PinnedAt: time.Now().Add(-time.Minute)})
+	source.calls = 0
+	mockIPFS.pinError = fmt.Errorf("not found")
+	if err := r.SyncPeer(ctx, peer.ID, false, nil); err != nil {
+		t.Fatalf("Incremental SyncPeer failed: %v", err)
+	}
+	status, _ = r.Status(peer.ID)
+	if status.Counts.Failed != 1 || status.Missing != 1 || status.LagSeconds < 60 {
+		t.Errorf("Status after failed pin = %+v, want 1 missing with about a minute of lag", status)
+	}
+	mockIPFS.pinError = nil
+
+	// A full sync flags CIDs the peer dropped
+	source.entries = source.entries[1:]
+	if err := r.SyncPeer(ctx, peer.ID, true, nil); err != nil {
+		t.Fatalf("Full SyncPeer failed: %v", err)
+	}
+	status, _ = r.Status(peer.ID)
+	if status.Counts.Removed != 1 || status.Missing != 0 || status.Divergence != 1 {
+		t.Errorf("Status after full sync = %+v, want 1 removed on the peer and nothing missing", status)
+	}
+
+	// Removing the peer unpins its CIDs, except ones local assets still use
+	nft := &db.NFT{TokenID: "1", ContractAddress: "KT1Local", WalletAddress: "tz1Local"}
+	database.SaveNFT(nft)
+	database.SaveAsset(&db.Asset{NFTID: nft.ID, URI: "ipfs://QmRemote1/index.html", Status: db.StatusPinned})
+
+	if err := r.RemovePeer(ctx, peer.ID, true, nil); err != nil {
+		t.Fatalf("RemovePeer failed: %v", err)
+	}
+	if mockIPFS.pinned["QmRemote2"] || mockIPFS.pinned["QmRemote0"] {
+		t.Error("Replicated CIDs should be unpinned when the peer is removed")
+	}
+	if !mockIPFS.pinned["QmRemote1"] {
+		t.Error("A CID used by a local asset should stay pinned")
+	}
+	if p, _ := database.GetReplicationPeer(peer.ID); p != nil {
+		t.Error("Peer should be deleted")
+	}
+	if _, err := r.Status(peer.ID); err != ErrPeerNotFound {
+		t.Errorf("Status of removed peer = %v, want ErrPeerNotFound", err)
+	}
+}
+
+func TestHostPeerAddrs(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"192.168.1.10", "/ip4/192.168.1.10/tcp/4001/p2p/12D3KooWPeer"},
+		{"::1", "/ip6/::1/tcp/4001/p2p/12D3KooWPeer"},
+		{"studio.example.com", "/dns/studio.example.com/tcp/4001/p2p/12D3KooWPeer"},
+	}
+	for _, tt := range tests {
+		addrs := hostPeerAddrs(tt.host, 4001, "12D3KooWPeer")
+		if len(addrs) != 2 || addrs[0] != tt.want {
+			t.Errorf("hostPeerAddrs(%q) = %v, want %q first", tt.host, addrs, tt.want)
+		}
+	}
+}
+
+// =============================================================================
+// MIRROR TESTS
+// =============================================================================
+
+func TestMirror_ReconcileService(t *testing.T) {
+	database := testDB(t)
+	cfg := testConfig()
+	cfg.Mirror.MaxRetries = 1
+	bm := NewBackupManager(newMockIPFSNode(), indexer.NewIndexer(cfg.TZKT.BaseURL), database, cfg)
+	defer bm.Shutdown()
+	m := NewMirror(bm, database)
+
+	remote := pinning.NewMemoryService("secret")
+	server := httptest.NewServer(remote)
+	defer server.Close()
+
+	// tz1mirror owns two pinned assets and a failing one, tz1other one shared with it
+	mine := &db.NFT{TokenID: "1", ContractAddress: "KT1mirror", WalletAddress: "tz1mirror", ArtifactURI: "ipfs://QmMine"}
+	database.Create(mine)
+	other := &db.NFT{TokenID: "2", ContractAddress: "KT1mirror", WalletAddress: "tz1other", ArtifactURI: "ipfs://QmOther", ThumbnailURI: "ipfs://QmShared"}
+	database.Create(other)
+	database.Create(&db.NFT{TokenID: "3", ContractAddress: "KT1mirror", WalletAddress: "tz1mirror", ThumbnailURI: "ipfs://QmShared"})
+	database.Create(&db.Asset{URI: "ipfs://QmMine", NFTID: mine.ID, Type: "artifact", Status: db.StatusPinned})
+	database.Create(&db.Asset{URI: "ipfs://QmFailing", NFTID: mine.ID, Type: "thumbnail", Status: db.StatusFailed})
+	database.Create(&db.Asset{URI: "ipfs://QmOther", NFTID: other.ID, Type: "artifact", Status: db.StatusPinned})
+	database.Create(&db.Asset{URI: "ipfs://QmShared", NFTID: other.ID, Type: "thumbnail", Status: db.StatusPinned})
+
+	svc := &db.PinningService{Name: "stand-in", Endpoint: server.URL, Token: "secret", Enabled: true}
+	svc.SetWalletList([]string{"tz1mirror"})
+	database.SavePinningService(svc)
+
+	ctx := context.Background()
+	remoteCIDs := func() map[string]string {
+		cids := make(map[string]string)
+		for _, p := range remote.Pins() {
+			cids[p.Pin.CID] = p.Status
+		}
+		return cids
+	}
+
+	if err := m.ReconcileService(ctx, svc.ID, nil); err != nil {
+		t.Fatalf("ReconcileService failed: %v", err)
+	}
+	got := remoteCIDs()
+	if len(got) != 2 || got["QmMine"] == "" || got["QmShared"] == "" {
+		t.Fatalf("pins at service = %v, want QmMine and QmShared", got)
+	}
+	status, err := m.Status(svc.ID)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if status.Counts.Pinned != 2 || status.Service.LastSyncAt == nil || status.Service.LastError != "" {
+		t.Errorf("Status = %+v, want 2 pinned and a clean sync", status)
+	}
+
+	// A reconcile with nothing changed requests nothing new
+	if err := m.ReconcileService(ctx, svc.ID, nil); err != nil {
+		t.Fatalf("second ReconcileService failed: %v", err)
+	}
+	if n := len(remote.Pins()); n != 2 {
+		t.Errorf("pins at service after second reconcile = %d, want 2", n)
+	}
+
+	// Lost and failed pins are requested again, up to MaxRetries
+	remote.Drop("QmMine")
+	remote.SetStatus("QmShared", pinning.StatusFailed)
+	if err := m.ReconcileService(ctx, svc.ID, nil); err != nil {
+		t.Fatalf("ReconcileService after losses failed: %v", err)
+	}
+	got = remoteCIDs()
+	if got["QmMine"] != pinning.StatusPinned || got["QmShared"] != pinning.StatusPinned || len(remote.Pins()) != 2 {
+		t.Errorf("pins at service = %v, want QmMine and QmShared pinned again without duplicates", got)
+	}
+
+	remote.SetStatus("QmShared", pinning.StatusFailed)
+	m.ReconcileService(ctx, svc.ID, nil)
+	pins, _ := database.GetRemotePinsByCID("QmShared")
+	if len(pins) != 1 || pins[0].Status != db.RemotePinFailed || pins[0].RetryCount != 1 {
+		t.Errorf("QmShared record = %+v, want failed after using its one retry", pins)
+	}
+
+	// A locally failing asset keeps its remote copy; a deselected wallet's pins are removed
+	database.Model(&db.Asset{}).Where("uri = ?", "ipfs://QmMine").Update("status", db.StatusFailed)
+	if err := m.ReconcileService(ctx, svc.ID, nil); err != nil {
+		t.Fatalf("ReconcileService with a failing local pin failed: %v", err)
+	}
+	if remoteCIDs()["QmMine"] == "" {
+		t.Error("QmMine should stay at the service while its local pin is failing")
+	}
+
+	svc, _ = database.GetPinningService(svc.ID)
+	svc.SetWalletList([]string{"tz1other"})
+	database.SavePinningService(svc)
+	if err := m.ReconcileService(ctx, svc.ID, nil); err != nil {
+		t.Fatalf("ReconcileService after changing wallets failed: %v", err)
+	}
+	got = remoteCIDs()
+	if got["QmMine"] != "" || got["QmOther"] == "" {
+		t.Errorf("pins at service = %v, want QmOther and not QmMine", got)
+	}
+
+	// Removing with unpin clears the service
+	if err := m.RemoveService(ctx, svc.ID, true, nil); err != nil {
+		t.Fatalf("RemoveService failed: %v", err)
+	}
+	if n := len(remote.Pins()); n != 0 {
+		t.Errorf("pins at service after RemoveService = %d, want 0", n)
+	}
+	if _, err := m.Status(svc.ID); err != ErrPinningServiceNotFound {
+		t.Errorf("Status of removed service error = %v, want ErrPinningServiceNotFound", err)
+	}
+}
+
+func TestMirror_ReconcileServiceUnreachable(t *testing.T) {
+	database := testDB(t)
+	cfg := testConfig()
+	bm := NewBackupManager(newMockIPFSNode(), indexer.NewIndexer(cfg.TZKT.BaseURL), database, cfg)
+	defer bm.Shutdown()
+	m := NewMirror(bm, database)
+
+	remote := pinning.NewMemoryService("secret")
+	server := httptest.NewServer(remote)
+	defer server.Close()
+
+	svc := &db.PinningService{Name: "stand-in", Endpoint: server.URL, Token: "wrong", Enabled: true}
+	database.SavePinningService(svc)
+
+	if err := m.ReconcileService(context.Background(), svc.ID, nil); err == nil {
+		t.Fatal("ReconcileService with a bad token should fail")
+	}
+	svc, _ = database.GetPinningService(svc.ID)
+	if svc.LastError == "" || svc.LastSyncAt == nil {
+		t.Errorf("service after failed reconcile = %+v, want the error recorded", svc)
+	}
+	if err := m.ReconcileService(context.Background(), 9999, nil); err != ErrPinningServiceNotFound {
+		t.Errorf("ReconcileService of unknown service = %v, want ErrPinningServiceNotFound", err)
+	}
+}
+
+// =============================================================================
+// TIERING TESTS
+// =============================================================================
+
+// mockTieredIPFSNode records the CIDs moved to the cold tier
+type mockTieredIPFSNode struct {
+	*mockIPFSNode
+	cold    []string
+	moveErr map[string]error
+}
+
+func (m *mockTieredIPFSNode) MoveToCold(ctx context.Context, cid string) (int64, error) {
+	if err := m.moveErr[cid]; err != nil {
+		return 0, err
+	}
+	m.cold = append(m.cold, cid)
+	return m.sizes[cid], nil
+}
+
+func TestBelongsCold(t *testing.T) {
+	rules := config.TieringConfig{
+		ColdTypes: []string{"artifact"},
+		MinSize:   1000,
+		MinAge:    24 * time.Hour,
+	}
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+	recent := now.Add(-time.Hour)
+
+	tests := []struct {
+		name  string
+		asset db.Asset
+		want  bool
+	}{
+		{"large old artifact", db.Asset{Type: "artifact", SizeBytes: 5000, PinnedAt: &old}, true},
+		{"small artifact", db.Asset{Type: "artifact", SizeBytes: 500, PinnedAt: &old}, false},
+		{"recent artifact", db.Asset{Type: "artifact", SizeBytes: 5000, PinnedAt: &recent}, false},
+		{"thumbnail", db.Asset{Type: "thumbnail", SizeBytes: 5000, PinnedAt: &old}, false},
+		{"never pinned", db.Asset{Type: "artifact", SizeBytes: 5000}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := belongsCold(tt.asset, rules, now); got != tt.want {
+				t.Errorf("belongsCold() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackupManager_ApplyTiering(t *testing.T) {
+	database := testDB(t)
+	cfg := testConfig()
+	cfg.IPFS.Tiering.ColdPath = t.TempDir()
+	cfg.IPFS.Tiering.ColdTypes = []string{"artifact"}
+	cfg.IPFS.Tiering.MinSize = 1000
+	cfg.IPFS.Tiering.MinAge = time.Hour
+
+	node := &mockTieredIPFSNode{mockIPFSNode: newMockIPFSNode(), moveErr: map[string]error{}}
+	node.sizes["QmLarge"] = 5000
+	node.moveErr["QmBroken"] = errors.New("block missing")
+	bm := NewBackupManager(node, indexer.NewIndexer(cfg.TZKT.BaseURL), database, cfg)
+	defer bm.Shutdown()
+
+	nft := &db.NFT{TokenID: "1", ContractAddress: "KT1", WalletAddress: "tz1"}
+	database.SaveNFT(nft)
+	old := time.Now().Add(-2 * time.Hour)
+	assets := []db.Asset{
+		{URI: "ipfs://QmLarge", NFTID: nft.ID, Type: "artifact", Status: db.StatusPinned, SizeBytes: 5000, PinnedAt: &old},
+		{URI: "ipfs://QmSmall", NFTID: nft.ID, Type: "artifact", Status: db.StatusPinned, SizeBytes: 10, PinnedAt: &old},
+		{URI: "ipfs://QmThumb", NFTID: nft.ID, Type: "thumbnail", Status: db.StatusPinned, SizeBytes: 5000, PinnedAt: &old},
+		{URI: "ipfs://QmBroken", NFTID: nft.ID, Type: "artifact", Status: db.StatusPinned, SizeBytes: 4000, PinnedAt: &old},
+	}
+	for i := range assets {
+		database.SaveAsset(&assets[i])
+	}
+
+	result, err := bm.ApplyTiering(context.Background())
+	if err != nil {
+		t.Fatalf("ApplyTiering failed: %v", err)
+	}
+	if result.Checked != 2 || result.Moved != 1 || result.Failed != 1 || result.MovedBytes != 5000 {
+		t.Errorf("ApplyTiering result = %+v, want 2 checked, 1 moved (5000 bytes), 1 failed", result)
+	}
+	if len(node.cold) != 1 || node.cold[0] != "QmLarge" {
+		t.Errorf("Moved CIDs = %v, want only QmLarge", node.cold)
+	}
+
+	moved, _ := database.GetAssetByURI("ipfs://QmLarge")
+	if moved.Tier != db.TierCold {
+		t.Errorf("Moved asset tier = %q, want %q", moved.Tier, db.TierCold)
+	}
+	broken, _ := database.GetAssetByURI("ipfs://QmBroken")
+	if broken.Tier != db.TierHot {
+		t.Errorf("Asset that failed to move tier = %q, want %q", broken.Tier, db.TierHot)
+	}
+
+	// Cold assets are not moved again
+	node.cold = nil
+	if _, err := bm.ApplyTiering(context.Background()); err != nil {
+		t.Fatalf("Second ApplyTiering failed: %v", err)
+	}
+	if len(node.cold) != 0 {
+		t.Errorf("Second pass moved %v, want nothing", node.cold)
+	}
+}
+
+func TestBackupManager_ApplyTieringUnavailable(t *testing.T) {
+	database := testDB(t)
+	cfg := testConfig()
+	bm := NewBackupManager(newMockIPFSNode(), indexer.NewIndexer(cfg.TZKT.BaseURL), database, cfg)
+	defer bm.Shutdown()
+
+	if _, err := bm.ApplyTiering(context.Background()); !errors.Is(err, ErrTieringUnavailable) {
+		t.Errorf("ApplyTiering without a cold tier = %v, want ErrTieringUnavailable", err)
+	}
+}
+
+// =============================================================================
+// STORAGE HEALTH TESTS
+// =============================================================================
+
+// mockStoppableIPFSNode is an IPFS client that reports whether it is running
+type mockStoppableIPFSNode struct {
+	*mockIPFSNode
+	running bool
+}
+
+func (m *mockStoppableIPFSNode) IsRunning() bool {
+	return m.running
+}
+
+func TestBackupManager_HoldForStorage(t *testing.T) {
+	database := testDB(t)
+	cfg := testConfig()
+
+	node := &mockStoppableIPFSNode{mockIPFSNode: newMockIPFSNode()}
+	node.pinError = errors.New("node not started")
+	bm := NewBackupManager(node, indexer.NewIndexer(cfg.TZKT.BaseURL), database, cfg)
+	defer bm.Shutdown()
+
+	asset := &db.Asset{URI: "ipfs://QmHeld", Type: "artifact", Status: db.StatusPending}
+	database.SaveAsset(asset)
+
+	err := bm.pinAssetDirect(context.Background(), asset)
+	if !errors.Is(err, storage.ErrStorageUnavailable) {
+		t.Fatalf("pinAssetDirect with a stopped node = %v, want ErrStorageUnavailable", err)
+	}
+
+	held, _ := database.GetAssetByID(asset.ID)
+	if held.Status != db.StatusPending {
+		t.Errorf("Asset status = %s, want pending", held.Status)
+	}
+	if held.RetryCount != 0 {
+		t.Errorf("RetryCount = %d, want 0 (not the content's fault)", held.RetryCount)
+	}
+	if held.ErrorMsg == "" {
+		t.Error("ErrorMsg should say why the asset is held")
+	}
+}
+
+func TestBackupManager_StorageUnavailableAlerts(t *testing.T) {
+	database := testDB(t)
+	cfg := testConfig()
+
+	// A file where the repository should be
+	repoPath := filepath.Join(t.TempDir(), "ipfs")
+	os.WriteFile(repoPath, []byte("not a repo"), 0644)
+
+	node := &mockStoppableIPFSNode{mockIPFSNode: newMockIPFSNode(), running: true}
+	node.repoPath = repoPath
+	bm := NewBackupManager(node, indexer.NewIndexer(cfg.TZKT.BaseURL), database, cfg)
+	defer bm.Shutdown()
+
+	if err := bm.storageUnavailable(); !errors.Is(err, storage.ErrStorageUnavailable) {
+		t.Fatalf("storageUnavailable() = %v, want ErrStorageUnavailable", err)
+	}
+	select {
+	case <-bm.storageAlerts:
+	default:
+		t.Error("The storage monitor should have been alerted")
+	}
+
+	node.repoPath = t.TempDir()
+	if err := bm.storageUnavailable(); err != nil {
+		t.Errorf("storageUnavailable() with a usable repo = %v, want nil", err)
+	}
+}
+
+func TestBackupService_StorageLost(t *testing.T) {
+	database := testDB(t)
+	cfg := testConfig()
+
+	// Something was pinned, so the repository should exist
+	database.SaveAsset(&db.Asset{URI: "ipfs://QmPinned", Status: db.StatusPinned})
+	node, _ := ipfs.NewNode(filepath.Join(t.TempDir(), "ipfs"), 0)
+
+	service := NewBackupService(node, indexer.NewIndexer(cfg.TZKT.BaseURL), database, cfg)
+	defer service.GetManager().Shutdown()
+
+	if err := service.CheckStorage(); !errors.Is(err, storage.ErrStorageMissing) {
+		t.Fatalf("CheckStorage() = %v, want ErrStorageMissing", err)
+	}
+
+	service.monitorStorage()
+
+	status := service.GetStatus()
+	if status.State != StateStorageUnavailable {
+		t.Errorf("State = %s, want %s", status.State, StateStorageUnavailable)
+	}
+	if status.StorageError == "" {
+		t.Error("StorageError should be set")
+	}
+	if service.StorageAvailable() {
+		t.Error("StorageAvailable() should be false")
+	}
+	if !service.GetManager().IsPaused() {
+		t.Error("Pinning should be suspended while the storage is unavailable")
+	}
+
+	// Resuming doesn't start pinning until the storage returns
+	service.Pause()
+	service.Resume()
+	if !service.GetManager().IsPaused() {
+		t.Error("Resume() should not unpause pinning while the storage is unavailable")
+	}
+	if service.GetStatus().State != StateStorageUnavailable {
+		t.Error("State should stay storage_unavailable")
+	}
+}
+
+func TestStartIPFS_RefusesMissingRepo(t *testing.T) {
+	database := testDB(t)
+	database.SaveAsset(&db.Asset{URI: "ipfs://QmPinned", Status: db.StatusPinned})
+
+	repoPath := filepath.Join(t.TempDir(), "ipfs")
+	node, _ := ipfs.NewNode(repoPath, 0)
+
+	err := StartIPFS(context.Background(), node, database)
+	if !errors.Is(err, storage.ErrStorageMissing) {
+		t.Fatalf("StartIPFS() = %v, want ErrStorageMissing", err)
+	}
+	if node.IsRunning() {
+		t.Error("The node should not have started")
+	}
+	if _, err := os.Stat(repoPath); !os.IsNotExist(err) {
+		t.Error("No repository should have been created")
+	}
+}
+
+// =============================================================================
+// CONFIG RELOAD TESTS
+// =============================================================================
+
+func TestWorkerSlots_SetLimit(t *testing.T) {
+	slots := newWorkerSlots(2)
+	ctx := context.Background()
+	slots.acquire(ctx, nil)
+	slots.acquire(ctx, nil)
+
+	// Raising the limit wakes a waiting pin
+	acquired := make(chan error, 1)
+	go func() {
+		acquired <- slots.acquire(ctx, nil)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	slots.setLimit(3)
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatalf("acquire() = %v after raising the limit", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Raising the limit should wake a waiting pin")
+	}
+
+	// Lowering it lets running pins finish and holds new ones back
+	slots.setLimit(1)
+	slots.release()
+	slots.release()
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := slots.acquire(waitCtx, nil); err == nil {
+		t.Error("acquire() should wait while pins over the new limit still run")
+	}
+	slots.release()
+	if err := slots.acquire(ctx, nil); err != nil {
+		t.Errorf("acquire() = %v once under the limit", err)
+	}
+
+	stop := make(chan struct{})
+	close(stop)
+	if err := slots.acquire(ctx, stop); err != errManagerShutdown {
+		t.Errorf("acquire() after stop = %v, want errManagerShutdown", err)
+	}
+}
+
+func TestBackupManager_SetConcurrency(t *testing.T) {
+	cfg := testConfig()
+	bm := NewBackupManager(newMockIPFSNode(), indexer.NewIndexer(cfg.TZKT.BaseURL), testDB(t), cfg)
+	defer bm.Shutdown()
+
+	pinWorkers := func() int {
+		bm.mu.Lock()
+		defer bm.mu.Unlock()
+		return bm.pinWorkers
+	}
+
+	bm.pinQueue()
+	if n := pinWorkers(); n != cfg.Backup.MaxConcurrency {
+		t.Fatalf("pin workers = %d, want %d", n, cfg.Backup.MaxConcurrency)
+	}
+
+	bm.SetConcurrency(6)
+	if bm.workers.size() != 6 || pinWorkers() != 6 {
+		t.Errorf("After raising: %d slots, %d pin workers, want 6 of each", bm.workers.size(), pinWorkers())
+	}
+
+	// Idle workers over the limit retire
+	bm.SetConcurrency(1)
+	deadline := time.Now().Add(2 * time.Second)
+	for pinWorkers() > 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := pinWorkers(); n != 1 {
+		t.Errorf("After lowering: %d pin workers, want 1", n)
+	}
+}
+
+func TestBackupService_ApplyConfig(t *testing.T) {
+	cfg := testConfig()
+	service := NewBackupService(&ipfs.Node{}, indexer.NewIndexer(cfg.TZKT.BaseURL), testDB(t), cfg)
+	defer service.GetManager().Shutdown()
+	rescheduled := service.rescheduled()
+
+	next := testConfig()
+	next.Backup.MaxConcurrency = 7
+	next.Mirror.ReconcileInterval = time.Minute
+	next.IPFS.SwarmPort = 4101
+	service.ApplyConfig(next, config.Diff(cfg, next))
+
+	applied := service.Config()
+	if applied.Backup.MaxConcurrency != 7 || service.GetManager().workers.size() != 7 {
+		t.Errorf("max_concurrency not applied: config %d, slots %d", applied.Backup.MaxConcurrency, service.GetManager().workers.size())
+	}
+	if applied.Mirror.ReconcileInterval != time.Minute {
+		t.Errorf("mirror.reconcile_interval = %s, want 1m", applied.Mirror.ReconcileInterval)
+	}
+	if applied.IPFS.SwarmPort == 4101 {
+		t.Error("ipfs.swarm_port needs a restart and should not be applied")
+	}
+	if cfg.Backup.MaxConcurrency == 7 {
+		t.Error("The config in use before the reload should not change")
+	}
+	select {
+	case <-rescheduled:
+	default:
+		t.Error("Schedules should be told about the reload")
+	}
+}
+
+func TestBackupService_ApplyConfigWhilePinning(t *testing.T) {
+	// Meant for -race: reloads must not change settings pin workers and the
+	// disk check are reading
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "10")
+	}))
+	defer gateway.Close()
+
+	database := testDB(t)
+	cfg := testConfig()
+	service := NewBackupService(&ipfs.Node{}, indexer.NewIndexer(cfg.TZKT.BaseURL), database, cfg)
+	bm := service.GetManager()
+	defer bm.Shutdown()
+	mockNode := newMockIPFSNode()
+	mockNode.repoPath = t.TempDir()
+	bm.ipfs = mockNode
+
+	nft := &db.NFT{TokenID: "1", ContractAddress: "KT1Race", WalletAddress: "tz1Race"}
+	database.SaveNFT(nft)
+	const assets = 40
+	for i := 0; i < assets; i++ {
+		database.SaveAsset(&db.Asset{NFTID: nft.ID, URI: fmt.Sprintf("%s/ipfs/QmRace%d", gateway.URL, i), Type: "artifact", Status: db.StatusPending})
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			next := testConfig()
+			next.Backup.MaxConcurrency = 1 + i%3
+			next.Backup.MinFreeDiskSpaceGB = i % 2
+			next.IPFS.MaxFileSize = int64(1000 + i)
+			next.IPFS.PinTimeout = time.Duration(i+1) * time.Second
+			service.ApplyConfig(next, config.Diff(service.Config(), next))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				bm.hasSufficientDiskSpace()
+			}
+		}
+	}()
+
+	bm.EnqueuePendingAssets()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		stats, _ := database.GetAssetStats()
+		if stats["pinned"] == assets {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Errorf("pinned %d of %d assets while reloading", stats["pinned"], assets)
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(done)
+	wg.Wait()
+}
+
+// =============================================================================
+// DOMAIN TESTS
+// =============================================================================
+
+func TestRefreshDomains(t *testing.T) {
+	database := testDB(t)
+	database.SaveWallet(&db.Wallet{Address: "tz1Alice"})
+	database.SaveWallet(&db.Wallet{Address: "tz1Bob", Alias: "Bob's Art", Domain: "bob.tez"})
+	database.SaveWallet(&db.Wallet{Address: "tz1Carol", Alias: "carol.tez", Domain: "carol.tez"})
+
+	// alice.tez is new, bob.tez is unchanged and carol's name has lapsed
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path != "/v1/domains" || r.URL.Query().Get("reverse") != "true" {
+			t.Errorf("Unexpected request: %s", r.URL)
+		}
+		expires := time.Now().Add(time.Hour)
+		json.NewEncoder(w).Encode([]indexer.Domain{
+			{Name: "alice.tez", Address: &indexer.AccountInfo{Address: "tz1Alice"}, Reverse: true, Expiration: expires},
+			{Name: "bob.tez", Address: &indexer.AccountInfo{Address: "tz1Bob"}, Reverse: true, Expiration: expires},
+		})
+	}))
+	defer server.Close()
+
+	if err := RefreshDomains(context.Background(), indexer.NewIndexer(server.URL), database); err != nil {
+		t.Fatalf("RefreshDomains failed: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected one batched lookup, got %d", requests)
+	}
+
+	want := map[string][2]string{
+		"tz1Alice": {"alice.tez", "alice.tez"},
+		"tz1Bob":   {"bob.tez", "Bob's Art"},
+		"tz1Carol": {"", "carol.tez"},
+	}
+	for address, w := range want {
+		wallet, _ := database.GetWallet(address)
+		if wallet.Domain != w[0] || wallet.Alias != w[1] {
+			t.Errorf("%s: domain %q, alias %q; want %q, %q", address, wallet.Domain, wallet.Alias, w[0], w[1])
+		}
+	}
+}
+
+// =============================================================================
+// PROFILE TESTS
+// =============================================================================
+
+func TestRefreshProfiles(t *testing.T) {
+	database := testDB(t)
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+	database.NoteCreator("tz1new", "")
+	database.SaveCreator(&db.Creator{Address: "tz1old", Alias: "Old", ProfileFetchedAt: &lastWeek})
+	database.NoteContract("KT1new", "Objkt")
+
+	var requests []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/v1/accounts/tz1new":
+			w.Write([]byte(`{"alias":"New","metadata":{"alias":"New Artist","twitter":"new"}}`))
+		case "/v1/contracts/KT1new":
+			w.Write([]byte(`{"creator":{"address":"tz1new"},"metadata":{"name":"New Series"}}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	idx := indexer.NewIndexer(server.URL)
+
+	// Without a refresh interval only the ones never looked up are
+	if err := RefreshProfiles(context.Background(), idx, database, 0); err != nil {
+		t.Fatalf("RefreshProfiles failed: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Errorf("requests = %v, want tz1new and KT1new", requests)
+	}
+	creator, _ := database.GetCreator("tz1new")
+	if creator.Name != "New Artist" || creator.Alias != "New" || creator.Twitter != "new" || creator.ProfileFetchedAt == nil {
+		t.Errorf("creator = %+v", creator)
+	}
+	contract, _ := database.GetContract("KT1new")
+	if contract.Name != "New Series" || contract.Alias != "Objkt" || contract.CreatorAddress != "tz1new" || contract.MetadataFetchedAt == nil {
+		t.Errorf("contract = %+v", contract)
+	}
+
+	// A day's interval picks up the week-old profile; one TZKT no longer
+	// knows keeps its alias
+	requests = nil
+	if err := RefreshProfiles(context.Background(), idx, database, 24*time.Hour); err != nil {
+		t.Fatalf("RefreshProfiles failed: %v", err)
+	}
+	if len(requests) != 1 || requests[0] != "/v1/accounts/tz1old" {
+		t.Errorf("requests = %v, want tz1old", requests)
+	}
+	if old, _ := database.GetCreator("tz1old"); old.Alias != "Old" || !old.ProfileFetchedAt.After(lastWeek) {
+		t.Errorf("old creator = %+v", old)
+	}
+}
+
+func TestBackupManager_MetadataVersions(t *testing.T) {
+	// The version level comes from the token's token_metadata bigmap entry
+	var entryLevel int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/contracts/KT1Mutable/bigmaps":
+			fmt.Fprint(w, `[{"ptr": 42, "path": "token_metadata"}]`)
+		case "/v1/bigmaps/keys":
+			fmt.Fprintf(w, `[{"value": {"token_info": {"": "%x"}}, "lastLevel": %d}]`, "ipfs://QmMeta", entryLevel)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	version := func(artifact string, level int64) indexer.Token {
+		entryLevel = level
+		return indexer.Token{
+			TokenID:  "7",
+			Contract: indexer.ContractInfo{Address: "KT1Mutable"},
+			Metadata: &indexer.TokenMetadata{
+				Name:         "Mutable",
+				ArtifactURI:  artifact,
+				DisplayURI:   "ipfs://QmDisplay",
+				ThumbnailURI: "ipfs://QmThumb",
+			},
+		}
+	}
+
+	for _, policy := range []string{config.PreviousVersionsKeep, config.PreviousVersionsUnpin} {
+		t.Run(policy, func(t *testing.T) {
+			database := testDB(t)
+			cfg := testConfig()
+			cfg.Backup.PreviousVersions = policy
+			mockNode := newMockIPFSNode()
+			bm := NewBackupManager(mockNode, indexer.NewIndexer(server.URL), database, cfg)
+			ctx := context.Background()
+
+			nft, _, err := bm.saveNFT(ctx, "tz1Holder", version("ipfs://QmOldArt", 100))
+			if err != nil || nft == nil {
+				t.Fatalf("saveNFT failed: %v", err)
+			}
+			for _, uri := range []string{"ipfs://QmOldArt", "ipfs://QmDisplay", "ipfs://QmThumb"} {
+				database.SaveAsset(&db.Asset{NFTID: nft.ID, URI: uri, Status: db.StatusPinned})
+				mockNode.pinned[ExtractCIDFromURI(uri)] = true
+			}
+			// Another NFT shows the old thumbnail, so it is never released
+			other := &db.NFT{TokenID: "8", ContractAddress: "KT1Mutable", ArtifactURI: "ipfs://QmThumb"}
+			database.SaveNFT(other)
+			// and another asset reaches the old display image through a
+			// gateway, so it stays pinned
+			database.SaveAsset(&db.Asset{NFTID: other.ID, URI: "https://gateway.example/ipfs/QmDisplay/index.html", Status: db.StatusPinned})
+
+			// Syncing the same metadata again records nothing new
+			if _, _, err := bm.saveNFT(ctx, "tz1Holder", version("ipfs://QmOldArt", 150)); err != nil {
+				t.Fatalf("saveNFT failed: %v", err)
+			}
+			updated := version("ipfs://QmNewArt", 200)
+			updated.Metadata.DisplayURI = "ipfs://QmNewDisplay"
+			updated.Metadata.ThumbnailURI = "ipfs://QmNewThumb"
+			if _, _, err := bm.saveNFT(ctx, "tz1Holder", updated); err != nil {
+				t.Fatalf("saveNFT failed: %v", err)
+			}
+
+			history, _ := database.MetadataHistory(nft.ID)
+			if len(history) != 2 || history[0].Level != 100 || history[1].Level != 200 {
+				t.Fatalf("history = %+v, want versions at levels 100 and 200", history)
+			}
+			if changes := history[1].Changes; len(changes) != 3 || changes[0].Field != "artifactUri" || changes[2].Field != "thumbnailUri" {
+				t.Errorf("changes = %+v, want artifactUri, displayUri and thumbnailUri", changes)
+			}
+			var saved db.NFT
+			if database.DB.First(&saved, nft.ID); saved.MetadataVersion != 2 {
+				t.Errorf("metadata version = %d, want 2", saved.MetadataVersion)
+			}
+			if !strings.Contains(saved.RawMetadata, "ipfs://QmMeta") {
+				t.Errorf("raw metadata = %q, want the bigmap entry's URI", saved.RawMetadata)
+			}
+
+			oldArt, _ := database.GetAssetByURI("ipfs://QmOldArt")
+			thumb, _ := database.GetAssetByURI("ipfs://QmThumb")
+			display, _ := database.GetAssetByURI("ipfs://QmDisplay")
+			if thumb == nil || !mockNode.pinned["QmThumb"] || !mockNode.pinned["QmDisplay"] {
+				t.Errorf("shared assets were released")
+			}
+			unpin := policy == config.PreviousVersionsUnpin
+			if released := oldArt == nil && !mockNode.pinned["QmOldArt"]; released != unpin {
+				t.Errorf("old artifact released = %v, want %v", released, unpin)
+			}
+			if forgotten := display == nil; forgotten != unpin {
+				t.Errorf("old display asset forgotten = %v, want %v", forgotten, unpin)
+			}
+		})
+	}
+}
+
+func TestReconcilePins(t *testing.T) {
+	database := testDB(t)
+	mockNode := newMockIPFSNode()
+	for _, cid := range []string{"QmKept", "QmShared", "QmOrphan", "QmPending", "QmReplica"} {
+		mockNode.pinned[cid] = true
+	}
+	nft := &db.NFT{TokenID: "1", ContractAddress: "KT1a"}
+	database.SaveNFT(nft)
+	for _, a := range []db.Asset{
+		{URI: "ipfs://QmKept", Status: db.StatusPinned},
+		{URI: "ipfs://QmLost", Status: db.StatusPinned},
+		{URI: "ipfs://QmShared", Status: db.StatusPinned},
+		{URI: "ipfs://QmShared/index.html", Status: db.StatusPinned},
+		{URI: "ipfs://QmPending", Status: db.StatusPending},
+		{URI: "https://example.com/art.png", Status: db.StatusPinned},
+	} {
+		a.NFTID = nft.ID
+		database.SaveAsset(&a)
+	}
+	database.SaveReplicatedPin(&db.ReplicatedPin{ReplicationPeerID: 1, CID: "QmReplica", Status: db.ReplicaStatusRemoved})
+	lost, _ := database.GetAssetByURI("ipfs://QmLost")
+
+	// A dry run only reports
+	report, err := ReconcilePins(context.Background(), mockNode, database, true)
+	if err != nil {
+		t.Fatalf("ReconcilePins failed: %v", err)
+	}
+	if report.Pins != 5 || report.Assets != 5 {
+		t.Errorf("compared %d pins with %d assets, want 5 and 5", report.Pins, report.Assets)
+	}
+	if fmt.Sprint(report.Unreferenced) != "[QmOrphan]" {
+		t.Errorf("unreferenced = %v, want [QmOrphan]", report.Unreferenced)
+	}
+	if len(report.Missing) != 1 || report.Missing[0].AssetID != lost.ID || report.Missing[0].CID != "QmLost" {
+		t.Errorf("missing = %+v, want the QmLost asset", report.Missing)
+	}
+	if len(report.Shared) != 1 || report.Shared[0].CID != "QmShared" || len(report.Shared[0].AssetIDs) != 2 {
+		t.Errorf("shared = %+v, want QmShared with 2 assets", report.Shared)
+	}
+	if !mockNode.pinned["QmOrphan"] {
+		t.Error("dry run unpinned QmOrphan")
+	}
+	if asset, _ := database.GetAssetByID(lost.ID); asset.Status != db.StatusPinned {
+		t.Errorf("dry run set the missing asset to %s", asset.Status)
+	}
+
+	// Applying unpins the orphan and queues the missing asset again
+	report, err = ReconcilePins(context.Background(), mockNode, database, false)
+	if err != nil {
+		t.Fatalf("ReconcilePins failed: %v", err)
+	}
+	if report.Unpinned != 1 || report.Reset != 1 || len(report.Errors) != 0 {
+		t.Errorf("report = %+v, want 1 unpinned and 1 reset", report)
+	}
+	if mockNode.pinned["QmOrphan"] || !mockNode.pinned["QmReplica"] || !mockNode.pinned["QmPending"] {
+		t.Errorf("pins after applying = %v", mockNode.pinned)
+	}
+	if asset, _ := database.GetAssetByID(lost.ID); asset.Status != db.StatusPending {
+		t.Errorf("missing asset status = %s, want pending", asset.Status)
+	}
+}
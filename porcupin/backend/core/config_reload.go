@@ -0,0 +1,92 @@
+package core
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"porcupin/backend/config"
+)
+
+// ApplyConfig applies a reloaded config to the running service. Of the
+// changed fields, those that config.Reloadable allows take effect at once;
+// the rest are reported as needing a restart. It suits config.Watch.
+func (s *BackupService) ApplyConfig(next *config.Config, changed []string) {
+	differs := make(map[string]bool)
+	for _, field := range config.Diff(s.Config(), next) {
+		differs[field] = true
+	}
+
+	var applied, restart []string
+	for _, field := range changed {
+		if !differs[field] {
+			continue // Already in effect, e.g. saved by this process
+		}
+		if config.Reloadable(field) {
+			applied = append(applied, field)
+		} else {
+			restart = append(restart, field)
+		}
+	}
+	if len(restart) > 0 {
+		log.Printf("Config: restart to apply %s", strings.Join(restart, ", "))
+	}
+	if len(applied) == 0 {
+		return
+	}
+
+	// Readers keep the config they loaded; the reloaded fields go into a copy
+	s.UpdateConfig(func(cfg *config.Config) error {
+		cfg.CopyFields(next, applied)
+		return nil
+	})
+	s.mu.Lock()
+	close(s.reschedule)
+	s.reschedule = make(chan struct{})
+	s.mu.Unlock()
+
+	cfg := s.Config()
+	s.manager.SetConcurrency(cfg.Backup.MaxConcurrency)
+	s.manager.SetQueueWeights(cfg.Backup.Queue)
+	log.Printf("Config: reloaded %s", strings.Join(applied, ", "))
+}
+
+// rescheduled returns a channel closed when a config reload changes the
+// schedules
+func (s *BackupService) rescheduled() <-chan struct{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.reschedule
+}
+
+// runEvery calls fn after delay and then every interval() while the service
+// isn't suspended. The interval is read again after each run and whenever
+// the config is reloaded; zero turns the schedule off.
+func (s *BackupService) runEvery(delay time.Duration, interval func() time.Duration, fn func()) {
+	select {
+	case <-s.ctx.Done():
+		return
+	case <-time.After(delay):
+	}
+
+	run := true
+	for {
+		every := interval()
+		if run && every > 0 && !s.suspended() {
+			fn()
+		}
+
+		var tick <-chan time.Time // Stays nil, waiting for a reload, while off
+		if every > 0 {
+			tick = time.After(every)
+		}
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-tick:
+			run = true
+		case <-s.rescheduled():
+			run = false // Wait out the new interval first
+		}
+	}
+}
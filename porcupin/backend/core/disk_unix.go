@@ -27,7 +27,7 @@ func (bm *BackupManager) hasSufficientDiskSpace() bool {
 	// Calculate free space in GB
 	// Use Bavail (blocks available to non-root) not Bfree (includes reserved blocks)
 	freeGB := float64(stat.Bavail*uint64(stat.Bsize)) / (1024 * 1024 * 1024)
-	minFree := float64(bm.config.Backup.MinFreeDiskSpaceGB)
+	minFree := float64(bm.Config().Backup.MinFreeDiskSpaceGB)
 
 	if freeGB < minFree {
 		log.Printf("Low disk space on %s: %.2f GB free (minimum: %.2f GB)", repoPath, freeGB, minFree)
@@ -51,7 +51,7 @@ func (bm *BackupManager) hasSufficientDiskSpace() bool {
 
 	// Calculate free space in GB
 	freeGB := float64(freeBytesAvailable) / (1024 * 1024 * 1024)
-	minFree := float64(bm.config.Backup.MinFreeDiskSpaceGB)
+	minFree := float64(bm.Config().Backup.MinFreeDiskSpaceGB)
 
 	if freeGB < minFree {
 		log.Printf("Low disk space on %s: %.2f GB free (minimum: %.2f GB)", pathToCheck, freeGB, minFree)
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"porcupin/backend/db"
+	"porcupin/backend/indexer"
+)
+
+// Indexer returns the TZKT indexer the service syncs wallets with
+func (s *BackupService) Indexer() *indexer.Indexer {
+	return s.indexer
+}
+
+// domainWorker periodically looks up the Tezos Domains names of the wallets
+func (s *BackupService) domainWorker() {
+	s.runEvery(2*time.Minute, func() time.Duration {
+		return s.Config().TZKT.DomainRefreshInterval
+	}, func() {
+		if err := RefreshDomains(s.ctx, s.indexer, s.db); err != nil && s.ctx.Err() == nil {
+			log.Printf("Domain refresh failed: %v", err)
+		}
+	})
+}
+
+// RefreshDomains looks up the name each wallet resolves back to and records
+// it, updating aliases that follow the domain (see db.UpdateWalletDomain)
+func RefreshDomains(ctx context.Context, idx *indexer.Indexer, database *db.Database) error {
+	wallets, err := database.GetAllWallets()
+	if err != nil || len(wallets) == 0 {
+		return err
+	}
+	addresses := make([]string, len(wallets))
+	for i, w := range wallets {
+		addresses[i] = w.Address
+	}
+	names, err := idx.ReverseDomains(ctx, addresses)
+	if err != nil {
+		return err
+	}
+	for _, w := range wallets {
+		if names[w.Address] == w.Domain {
+			continue
+		}
+		if err := database.UpdateWalletDomain(w.Address, names[w.Address]); err != nil {
+			return err
+		}
+		log.Printf("Wallet %s domain: %q -> %q", w.Address, w.Domain, names[w.Address])
+	}
+	return nil
+}
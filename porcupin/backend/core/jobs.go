@@ -0,0 +1,431 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"porcupin/backend/db"
+)
+
+// Job types handled by the backup service
+const (
+	JobTypeVerifyAndFix = "verify_and_fix"
+	JobTypeUnpinAll     = "unpin_all"
+	JobTypeDeleteWallet = "delete_wallet"
+	JobTypeReplicate    = "replicate"
+	JobTypeRemovePeer   = "remove_replication_peer"
+	JobTypeMirror       = "mirror"
+	JobTypeRemoveMirror = "remove_pinning_service"
+)
+
+// JobTypeStorageMigrate moves the IPFS repository. It needs to stop and
+// restart the IPFS node, so the owner of the node (the desktop app) registers it.
+const JobTypeStorageMigrate = "storage_migrate"
+
+// ErrJobNotFound is returned when a job ID does not exist
+var ErrJobNotFound = errors.New("job not found")
+
+// JobHandler executes a job. It should checkpoint regularly through the
+// JobContext and return ctx.Err() promptly when the context is cancelled.
+type JobHandler func(ctx context.Context, jc *JobContext) error
+
+// JobManager runs persistent jobs stored in the database.
+// Jobs interrupted by a shutdown are left pending and resumed from their
+// last checkpoint the next time ResumeUnfinished is called.
+type JobManager struct {
+	db *db.Database
+
+	mu        sync.Mutex
+	handlers  map[string]JobHandler
+	running   map[uint64]*runningJob
+	listeners []func(db.Job)
+}
+
+// runningJob tracks an in-process job so it can be cancelled or awaited
+type runningJob struct {
+	cancel        context.CancelFunc
+	userCancelled bool
+	done          chan struct{}
+}
+
+// NewJobManager creates a new job manager backed by the given database
+func NewJobManager(database *db.Database) *JobManager {
+	return &JobManager{
+		db:       database,
+		handlers: make(map[string]JobHandler),
+		running:  make(map[uint64]*runningJob),
+	}
+}
+
+// RegisterHandler registers the handler for a job type
+func (jm *JobManager) RegisterHandler(jobType string, handler JobHandler) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	jm.handlers[jobType] = handler
+}
+
+// OnUpdate registers a callback invoked whenever a job's state or progress changes
+func (jm *JobManager) OnUpdate(fn func(db.Job)) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	jm.listeners = append(jm.listeners, fn)
+}
+
+// Submit creates a new job and starts it in the background
+func (jm *JobManager) Submit(ctx context.Context, jobType string, params interface{}) (*db.Job, error) {
+	jm.mu.Lock()
+	_, ok := jm.handlers[jobType]
+	jm.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown job type: %s", jobType)
+	}
+
+	job := &db.Job{Type: jobType, Status: db.JobStatusPending}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode job params: %w", err)
+		}
+		job.Params = string(data)
+	}
+
+	if err := jm.db.CreateJob(job); err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	log.Printf("Job %d (%s) submitted", job.ID, job.Type)
+	rj, jobCtx, _ := jm.claim(ctx, job.ID) // Nothing else knows the new job yet
+	return jm.start(jobCtx, rj, job), nil
+}
+
+// Resume restarts a failed, cancelled or interrupted job from its last checkpoint
+func (jm *JobManager) Resume(ctx context.Context, id uint64) (*db.Job, error) {
+	// Claim the job first, so concurrent resumes can't both start it
+	rj, jobCtx, ok := jm.claim(ctx, id)
+	if !ok {
+		return nil, fmt.Errorf("job %d is already running", id)
+	}
+
+	job, err := jm.db.GetJob(id)
+	if err == nil && job == nil {
+		err = ErrJobNotFound
+	}
+	if err == nil && job.Status == db.JobStatusCompleted {
+		err = fmt.Errorf("job %d already completed", id)
+	}
+	if err == nil {
+		job.Status = db.JobStatusPending
+		job.Error = ""
+		job.FinishedAt = nil
+		err = jm.db.SaveJob(job)
+	}
+	if err != nil {
+		jm.release(id, rj)
+		return nil, err
+	}
+
+	return jm.start(jobCtx, rj, job), nil
+}
+
+// ResumeUnfinished restarts every pending or interrupted job that has a
+// registered handler. Returns the number of jobs resumed.
+func (jm *JobManager) ResumeUnfinished(ctx context.Context) int {
+	jobs, err := jm.db.GetUnfinishedJobs()
+	if err != nil {
+		log.Printf("Failed to load unfinished jobs: %v", err)
+		return 0
+	}
+
+	resumed := 0
+	for i := range jobs {
+		job := jobs[i]
+		jm.mu.Lock()
+		_, ok := jm.handlers[job.Type]
+		jm.mu.Unlock()
+		if !ok {
+			// Another process (e.g. the desktop app) owns this job type
+			continue
+		}
+		rj, jobCtx, ok := jm.claim(ctx, job.ID)
+		if !ok {
+			continue
+		}
+		log.Printf("Resuming job %d (%s) from checkpoint", job.ID, job.Type)
+		jm.start(jobCtx, rj, &job)
+		resumed++
+	}
+	return resumed
+}
+
+// Cancel stops a running job, or marks a pending job as cancelled
+func (jm *JobManager) Cancel(id uint64) error {
+	jm.mu.Lock()
+	rj, ok := jm.running[id]
+	if ok {
+		rj.userCancelled = true
+		rj.cancel()
+	}
+	jm.mu.Unlock()
+
+	if ok {
+		<-rj.done
+		return nil
+	}
+
+	job, err := jm.db.GetJob(id)
+	if err != nil {
+		return err
+	}
+	if job == nil {
+		return ErrJobNotFound
+	}
+	if job.IsFinished() {
+		return fmt.Errorf("job %d already %s", id, job.Status)
+	}
+
+	now := time.Now()
+	job.Status = db.JobStatusCancelled
+	job.Message = "Cancelled"
+	job.FinishedAt = &now
+	if err := jm.db.SaveJob(job); err != nil {
+		return err
+	}
+	jm.notify(*job)
+	return nil
+}
+
+// Wait blocks until the given job is no longer running in this process
+func (jm *JobManager) Wait(id uint64) {
+	jm.mu.Lock()
+	rj, ok := jm.running[id]
+	jm.mu.Unlock()
+	if ok {
+		<-rj.done
+	}
+}
+
+// IsRunning returns whether a job is currently executing in this process
+func (jm *JobManager) IsRunning(id uint64) bool {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	_, ok := jm.running[id]
+	return ok
+}
+
+// Get returns a job by ID
+func (jm *JobManager) Get(id uint64) (*db.Job, error) {
+	return jm.db.GetJob(id)
+}
+
+// List returns recent jobs, optionally filtered by status
+func (jm *JobManager) List(status string, limit int) ([]db.Job, error) {
+	return jm.db.ListJobs(status, limit)
+}
+
+// claim marks a job as running in this process, returning false if it
+// already is. The check and the mark happen under one lock, so only one
+// caller can claim a job; it must then start or release it.
+func (jm *JobManager) claim(ctx context.Context, id uint64) (*runningJob, context.Context, bool) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	if _, ok := jm.running[id]; ok {
+		return nil, nil, false
+	}
+	jobCtx, cancel := context.WithCancel(ctx)
+	rj := &runningJob{cancel: cancel, done: make(chan struct{})}
+	jm.running[id] = rj
+	return rj, jobCtx, true
+}
+
+// release gives up a claimed job that won't be started
+func (jm *JobManager) release(id uint64, rj *runningJob) {
+	jm.mu.Lock()
+	delete(jm.running, id)
+	jm.mu.Unlock()
+	rj.cancel()
+	close(rj.done)
+}
+
+// start launches the handler of a claimed job in a goroutine. The handler
+// owns job from then on, so callers get a copy of the job as it was when it
+// started.
+func (jm *JobManager) start(jobCtx context.Context, rj *runningJob, job *db.Job) *db.Job {
+	jm.mu.Lock()
+	handler := jm.handlers[job.Type]
+	jm.mu.Unlock()
+
+	now := time.Now()
+	job.Status = db.JobStatusRunning
+	if job.StartedAt == nil {
+		job.StartedAt = &now
+	}
+	if err := jm.db.SaveJob(job); err != nil {
+		log.Printf("Failed to mark job %d running: %v", job.ID, err)
+	}
+	jm.notify(*job)
+	started := *job
+
+	go func() {
+		defer close(rj.done)
+		defer rj.cancel()
+
+		jc := &JobContext{jm: jm, job: job}
+		err := jm.runHandler(jobCtx, handler, jc)
+
+		jm.mu.Lock()
+		userCancelled := rj.userCancelled
+		delete(jm.running, job.ID)
+		jm.mu.Unlock()
+
+		jm.finish(job, err, userCancelled)
+	}()
+	return &started
+}
+
+// runHandler invokes a handler, converting panics into errors
+func (jm *JobManager) runHandler(ctx context.Context, handler JobHandler, jc *JobContext) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic in job: %v", r)
+		}
+	}()
+	return handler(ctx, jc)
+}
+
+// finish records the outcome of a job run
+// The handler has returned, so nothing else touches job concurrently.
+func (jm *JobManager) finish(job *db.Job, err error, userCancelled bool) {
+	now := time.Now()
+	switch {
+	case err == nil:
+		job.Status = db.JobStatusCompleted
+		job.FinishedAt = &now
+		if job.Message == "" {
+			job.Message = "Completed"
+		}
+	case userCancelled:
+		job.Status = db.JobStatusCancelled
+		job.Message = "Cancelled"
+		job.FinishedAt = &now
+	case errors.Is(err, context.Canceled):
+		// Shutdown - leave pending so the job resumes on next start
+		job.Status = db.JobStatusPending
+		job.Message = "Interrupted, will resume from checkpoint"
+	default:
+		job.Status = db.JobStatusFailed
+		job.Error = err.Error()
+		job.FinishedAt = &now
+	}
+
+	if saveErr := jm.db.SaveJob(job); saveErr != nil {
+		log.Printf("Failed to save job %d result: %v", job.ID, saveErr)
+	}
+	log.Printf("Job %d (%s) %s", job.ID, job.Type, job.Status)
+	jm.notify(*job)
+}
+
+// notify sends a job update to all listeners
+func (jm *JobManager) notify(job db.Job) {
+	jm.mu.Lock()
+	listeners := append([]func(db.Job){}, jm.listeners...)
+	jm.mu.Unlock()
+	for _, fn := range listeners {
+		fn(job)
+	}
+}
+
+// JobContext gives a handler access to its parameters, checkpoint and progress
+type JobContext struct {
+	jm  *JobManager
+	job *db.Job
+	mu  sync.Mutex
+}
+
+// ID returns the job's ID
+func (jc *JobContext) ID() uint64 {
+	return jc.job.ID
+}
+
+// Params decodes the job parameters into v
+func (jc *JobContext) Params(v interface{}) error {
+	if jc.job.Params == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(jc.job.Params), v)
+}
+
+// Checkpoint decodes the last saved checkpoint into v.
+// Returns false if the job has no checkpoint yet.
+func (jc *JobContext) Checkpoint(v interface{}) (bool, error) {
+	jc.mu.Lock()
+	data := jc.job.Checkpoint
+	jc.mu.Unlock()
+	if data == "" {
+		return false, nil
+	}
+	if err := json.Unmarshal([]byte(data), v); err != nil {
+		return false, fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+	return true, nil
+}
+
+// SaveCheckpoint persists resume state for the job
+func (jc *JobContext) SaveCheckpoint(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	jc.mu.Lock()
+	jc.job.Checkpoint = string(data)
+	snapshot := *jc.job
+	jc.mu.Unlock()
+
+	if err := jc.jm.db.SaveJob(&snapshot); err != nil {
+		return err
+	}
+	jc.jm.notify(snapshot)
+	return nil
+}
+
+// SetProgress records the job's progress and persists it
+func (jc *JobContext) SetProgress(current, total int, message string) {
+	jc.mu.Lock()
+	jc.job.Current = current
+	jc.job.Total = total
+	if message != "" {
+		jc.job.Message = message
+	}
+	snapshot := *jc.job
+	jc.mu.Unlock()
+
+	if err := jc.jm.db.SaveJob(&snapshot); err != nil {
+		log.Printf("Failed to save progress for job %d: %v", snapshot.ID, err)
+	}
+	jc.jm.notify(snapshot)
+}
+
+// WaitAll blocks until all running jobs have recorded their final state,
+// or the timeout expires. Returns false on timeout.
+func (jm *JobManager) WaitAll(timeout time.Duration) bool {
+	jm.mu.Lock()
+	pending := make([]*runningJob, 0, len(jm.running))
+	for _, rj := range jm.running {
+		pending = append(pending, rj)
+	}
+	jm.mu.Unlock()
+
+	deadline := time.After(timeout)
+	for _, rj := range pending {
+		select {
+		case <-rj.done:
+		case <-deadline:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,458 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"porcupin/backend/db"
+	"porcupin/backend/pinning"
+)
+
+var (
+	// ErrPinningServiceNotFound is returned for pinning service IDs that do not exist
+	ErrPinningServiceNotFound = errors.New("pinning service not found")
+	// ErrMirrorInProgress is returned when a service is already being reconciled
+	ErrMirrorInProgress = errors.New("mirroring already in progress for this service")
+)
+
+// allPinStatuses lists pin requests in every state, rather than the spec's pinned-only default
+var allPinStatuses = []string{pinning.StatusQueued, pinning.StatusPinning, pinning.StatusPinned, pinning.StatusFailed}
+
+// PinningClient is the subset of pinning.Client used for mirroring
+type PinningClient interface {
+	Add(ctx context.Context, pin pinning.Pin) (*pinning.PinStatus, error)
+	Get(ctx context.Context, requestID string) (*pinning.PinStatus, error)
+	List(ctx context.Context, opts pinning.ListOptions) (*pinning.PinResults, error)
+	Remove(ctx context.Context, requestID string) error
+}
+
+// peerAddresser is implemented by IPFS clients that can report their libp2p addresses
+type peerAddresser interface {
+	PeerAddrs() []string
+}
+
+// MirrorStatus reports how much of the local collection a pinning service holds
+type MirrorStatus struct {
+	Service     db.PinningService  `json:"service"`
+	Wallets     []string           `json:"wallets"` // Empty when every wallet is mirrored
+	Counts      db.RemotePinCounts `json:"counts"`
+	Reconciling bool               `json:"reconciling"`
+}
+
+// Mirror keeps copies of pinned assets at IPFS pinning services
+type Mirror struct {
+	manager *BackupManager
+	db      *db.Database
+
+	mu          sync.Mutex
+	reconciling map[uint64]bool
+}
+
+// NewMirror creates a mirror for the manager's pinned assets
+func NewMirror(manager *BackupManager, database *db.Database) *Mirror {
+	return &Mirror{
+		manager:     manager,
+		db:          database,
+		reconciling: make(map[uint64]bool),
+	}
+}
+
+// begin marks a service as being reconciled, failing if it already is
+func (m *Mirror) begin(serviceID uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.reconciling[serviceID] {
+		return ErrMirrorInProgress
+	}
+	m.reconciling[serviceID] = true
+	return nil
+}
+
+// end clears a service's reconciling flag
+func (m *Mirror) end(serviceID uint64) {
+	m.mu.Lock()
+	delete(m.reconciling, serviceID)
+	m.mu.Unlock()
+}
+
+// isReconciling reports whether a service is being reconciled
+func (m *Mirror) isReconciling(serviceID uint64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.reconciling[serviceID]
+}
+
+// ReconcileService brings a pinning service in line with the local pins of its wallets.
+// Pinned assets missing at the service are requested, statuses are refreshed, pins
+// the service lost or failed are requested again, and CIDs whose assets are gone
+// or whose wallets are no longer mirrored are removed. progress may be nil.
+func (m *Mirror) ReconcileService(ctx context.Context, serviceID uint64, progress func(current, total int, message string)) error {
+	if err := m.begin(serviceID); err != nil {
+		return err
+	}
+	defer m.end(serviceID)
+
+	if progress == nil {
+		progress = func(int, int, string) {}
+	}
+
+	svc, err := m.db.GetPinningService(serviceID)
+	if err != nil {
+		return err
+	}
+	if svc == nil {
+		return ErrPinningServiceNotFound
+	}
+
+	client := pinning.NewClient(svc.Endpoint, svc.Token)
+	reconcileErr := m.reconcile(ctx, client, svc, progress)
+
+	now := time.Now()
+	svc.LastSyncAt = &now
+	svc.LastError = ""
+	if reconcileErr != nil {
+		svc.LastError = reconcileErr.Error()
+	}
+	if err := m.db.SavePinningService(svc); err != nil {
+		log.Printf("Mirror: failed to save pinning service %d: %v", svc.ID, err)
+	}
+	return reconcileErr
+}
+
+// reconcile runs one reconciliation against a service
+func (m *Mirror) reconcile(ctx context.Context, client PinningClient, svc *db.PinningService, progress func(int, int, string)) error {
+	progress(0, 0, "Reading local pins...")
+	assets, err := m.db.GetMirrorAssets(svc.WalletList())
+	if err != nil {
+		return fmt.Errorf("failed to get assets to mirror: %w", err)
+	}
+
+	// Every CID still in use is kept at the service, even if its local pin is
+	// failing; only pinned ones are requested, so the service can fetch them from us
+	keep := make(map[string]bool)
+	pinned := make(map[string]*db.Asset)
+	for i := range assets {
+		cid := ExtractCIDFromURI(assets[i].URI)
+		if cid == "" {
+			continue
+		}
+		keep[cid] = true
+		if assets[i].Status == db.StatusPinned && pinned[cid] == nil {
+			pinned[cid] = &assets[i]
+		}
+	}
+
+	records, err := m.db.GetRemotePins(svc.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get remote pins: %w", err)
+	}
+
+	progress(0, 0, "Reading pins at "+svc.Name+"...")
+	remote, err := listRemotePins(ctx, client)
+	if err != nil {
+		return fmt.Errorf("failed to list pins: %w", err)
+	}
+
+	maxRetries := m.manager.Config().Mirror.MaxRetries
+	recorded := make(map[string]bool, len(records))
+	var submit []*db.RemotePin
+	removed := 0
+
+	for i := range records {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		rec := &records[i]
+		recorded[rec.CID] = true
+
+		if !keep[rec.CID] {
+			if rec.RequestID != "" {
+				if err := client.Remove(ctx, rec.RequestID); err != nil && !errors.Is(err, pinning.ErrNotFound) {
+					return fmt.Errorf("failed to remove %s: %w", rec.CID, err)
+				}
+			}
+			if err := m.db.DeleteRemotePin(rec.ID); err != nil {
+				return err
+			}
+			removed++
+			continue
+		}
+
+		st, ok := remote[rec.RequestID]
+		if !ok && rec.RequestID != "" {
+			// Not in the listing; ask for it directly before treating it as lost
+			st, err = client.Get(ctx, rec.RequestID)
+			switch {
+			case err == nil:
+				ok = true
+			case errors.Is(err, pinning.ErrNotFound):
+			default:
+				return fmt.Errorf("failed to check %s: %w", rec.CID, err)
+			}
+		}
+
+		if ok {
+			now := time.Now()
+			rec.CheckedAt = &now
+			rec.Status = st.Status
+			rec.ErrorMsg = ""
+			if st.Status == pinning.StatusFailed {
+				rec.ErrorMsg = failureInfo(st)
+			}
+		} else if rec.RequestID != "" {
+			log.Printf("Mirror: %s no longer has %s, requesting it again", svc.Name, rec.CID)
+			rec.RequestID = ""
+			rec.Status = db.RemotePinFailed
+			rec.ErrorMsg = "pin request no longer exists at the service"
+		}
+
+		switch {
+		case rec.Status != db.RemotePinFailed:
+		case pinned[rec.CID] == nil:
+			// Nothing local for the service to fetch from right now
+		case rec.RetryCount < maxRetries:
+			submit = append(submit, rec)
+			continue
+		}
+		if err := m.db.SaveRemotePin(rec); err != nil {
+			return err
+		}
+	}
+
+	for cid, asset := range pinned {
+		if !recorded[cid] {
+			submit = append(submit, &db.RemotePin{
+				PinningServiceID: svc.ID,
+				CID:              cid,
+				AssetID:          asset.ID,
+			})
+		}
+	}
+
+	if removed > 0 {
+		log.Printf("Mirror: removed %d CIDs no longer mirrored from %s", removed, svc.Name)
+	}
+	if len(submit) == 0 {
+		progress(0, 0, "Up to date")
+		return nil
+	}
+
+	var origins []string
+	if addresser, ok := m.manager.ipfs.(peerAddresser); ok {
+		origins = addresser.PeerAddrs()
+	}
+
+	progress(0, len(submit), fmt.Sprintf("Requesting %d pins...", len(submit)))
+	for i, rec := range submit {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := m.submit(ctx, client, rec, pinned[rec.CID], origins); err != nil {
+			return err
+		}
+		progress(i+1, len(submit), fmt.Sprintf("Requested %d of %d pins", i+1, len(submit)))
+	}
+	return nil
+}
+
+// submit requests a pin for a CID, replacing any failed request for it. Rejected
+// requests are recorded as failed; other errors stop the reconciliation.
+func (m *Mirror) submit(ctx context.Context, client PinningClient, rec *db.RemotePin, asset *db.Asset, origins []string) error {
+	if rec.RequestID != "" {
+		// Best effort, the failed request is replaced either way
+		if err := client.Remove(ctx, rec.RequestID); err != nil && !errors.Is(err, pinning.ErrNotFound) {
+			log.Printf("Mirror: failed to remove failed request for %s: %v", rec.CID, err)
+		}
+		rec.RequestID = ""
+	}
+	if rec.Status == db.RemotePinFailed {
+		rec.RetryCount++
+	}
+
+	name := asset.URI
+	if len(name) > pinning.MaxNameLength {
+		name = name[:pinning.MaxNameLength]
+	}
+	st, err := client.Add(ctx, pinning.Pin{
+		CID:     rec.CID,
+		Name:    name,
+		Origins: origins,
+		Meta: map[string]string{
+			"source":   "porcupin",
+			"asset_id": strconv.FormatUint(asset.ID, 10),
+			"type":     asset.Type,
+		},
+	})
+
+	var apiErr *pinning.Error
+	switch {
+	case err == nil:
+		rec.RequestID = st.RequestID
+		rec.Status = st.Status
+		rec.ErrorMsg = ""
+	case errors.As(err, &apiErr) && apiErr.StatusCode == 400:
+		rec.Status = db.RemotePinFailed
+		rec.ErrorMsg = err.Error()
+	default:
+		return fmt.Errorf("failed to request pin of %s: %w", rec.CID, err)
+	}
+	rec.AssetID = asset.ID
+	return m.db.SaveRemotePin(rec)
+}
+
+// listRemotePins pages through every pin request at a service, keyed by request ID
+func listRemotePins(ctx context.Context, client PinningClient) (map[string]*pinning.PinStatus, error) {
+	pins := make(map[string]*pinning.PinStatus)
+	var before *time.Time
+	for {
+		res, err := client.List(ctx, pinning.ListOptions{
+			Status: allPinStatuses,
+			Before: before,
+			Limit:  pinning.MaxListLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for i := range res.Results {
+			pins[res.Results[i].RequestID] = &res.Results[i]
+		}
+		if len(res.Results) < pinning.MaxListLimit {
+			return pins, nil
+		}
+
+		// Results are newest first; continue from the oldest in this page
+		oldest := res.Results[len(res.Results)-1].Created
+		if before != nil && !oldest.Before(*before) {
+			return pins, nil
+		}
+		before = &oldest
+	}
+}
+
+// failureInfo describes why a service failed to pin a CID
+func failureInfo(st *pinning.PinStatus) string {
+	for _, key := range []string{"status_details", "error", "reason"} {
+		if v := st.Info[key]; v != "" {
+			return v
+		}
+	}
+	return "pinning service could not pin the CID"
+}
+
+// ReconcileAll reconciles every enabled service in turn, logging failures
+func (m *Mirror) ReconcileAll(ctx context.Context) {
+	services, err := m.db.GetPinningServices()
+	if err != nil {
+		log.Printf("Mirror: failed to get pinning services: %v", err)
+		return
+	}
+	for _, svc := range services {
+		if !svc.Enabled {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		err := m.ReconcileService(ctx, svc.ID, nil)
+		if err != nil && !errors.Is(err, ErrMirrorInProgress) {
+			log.Printf("Mirror: reconciling %s failed: %v", svc.Name, err)
+		}
+	}
+}
+
+// Status reports what a pinning service holds
+func (m *Mirror) Status(serviceID uint64) (*MirrorStatus, error) {
+	svc, err := m.db.GetPinningService(serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if svc == nil {
+		return nil, ErrPinningServiceNotFound
+	}
+	return m.status(svc)
+}
+
+// Statuses reports what every pinning service holds
+func (m *Mirror) Statuses() ([]MirrorStatus, error) {
+	services, err := m.db.GetPinningServices()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MirrorStatus, 0, len(services))
+	for i := range services {
+		st, err := m.status(&services[i])
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, *st)
+	}
+	return statuses, nil
+}
+
+// status computes the mirror status of a service record
+func (m *Mirror) status(svc *db.PinningService) (*MirrorStatus, error) {
+	counts, err := m.db.GetRemotePinCounts(svc.ID)
+	if err != nil {
+		return nil, err
+	}
+	wallets := svc.WalletList()
+	if wallets == nil {
+		wallets = []string{}
+	}
+	return &MirrorStatus{
+		Service:     *svc,
+		Wallets:     wallets,
+		Counts:      counts,
+		Reconciling: m.isReconciling(svc.ID),
+	}, nil
+}
+
+// RemoveService stops mirroring to a pinning service. With unpin, its pin requests
+// are removed at the service first. progress may be nil.
+func (m *Mirror) RemoveService(ctx context.Context, serviceID uint64, unpin bool, progress func(current, total int, message string)) error {
+	if err := m.begin(serviceID); err != nil {
+		return err
+	}
+	defer m.end(serviceID)
+
+	svc, err := m.db.GetPinningService(serviceID)
+	if err != nil {
+		return err
+	}
+	if svc == nil {
+		return ErrPinningServiceNotFound
+	}
+	if progress == nil {
+		progress = func(int, int, string) {}
+	}
+
+	if unpin {
+		records, err := m.db.GetRemotePins(serviceID)
+		if err != nil {
+			return fmt.Errorf("failed to get remote pins: %w", err)
+		}
+		client := pinning.NewClient(svc.Endpoint, svc.Token)
+		for i, rec := range records {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if rec.RequestID != "" {
+				if err := client.Remove(ctx, rec.RequestID); err != nil && !errors.Is(err, pinning.ErrNotFound) {
+					return fmt.Errorf("failed to remove %s: %w", rec.CID, err)
+				}
+			}
+			// Drop the record so a resumed removal skips it
+			if err := m.db.DeleteRemotePin(rec.ID); err != nil {
+				return err
+			}
+			progress(i+1, len(records), fmt.Sprintf("Removed %d of %d pins", i+1, len(records)))
+		}
+	}
+
+	return m.db.DeletePinningService(serviceID)
+}
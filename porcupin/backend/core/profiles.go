@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"porcupin/backend/db"
+	"porcupin/backend/indexer"
+)
+
+const (
+	// profileCheckInterval is how often creators and collections seen
+	// since the last check are looked up
+	profileCheckInterval = 15 * time.Minute
+
+	// profileBatchSize bounds the lookups of one kind made per check
+	profileBatchSize = 50
+)
+
+// profileWorker periodically fills in the profiles of creators and the
+// metadata of collections
+func (s *BackupService) profileWorker() {
+	s.runEvery(3*time.Minute, func() time.Duration {
+		return profileCheckInterval
+	}, func() {
+		if err := RefreshProfiles(s.ctx, s.indexer, s.db, s.Config().TZKT.ProfileRefreshInterval); err != nil && s.ctx.Err() == nil {
+			log.Printf("Profile refresh failed: %v", err)
+		}
+	})
+}
+
+// RefreshProfiles looks up creators and collections never looked up, and
+// those last looked up more than maxAge ago. A zero maxAge leaves the ones
+// already looked up alone.
+func RefreshProfiles(ctx context.Context, idx *indexer.Indexer, database *db.Database, maxAge time.Duration) error {
+	var olderThan time.Time
+	if maxAge > 0 {
+		olderThan = time.Now().Add(-maxAge)
+	}
+
+	creators, err := database.StaleCreators(olderThan, profileBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, c := range creators {
+		profile, err := idx.GetProfile(ctx, c.Address)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		c.Name = profile.Name
+		c.Description = profile.Description
+		c.Website = profile.Website
+		c.Twitter = profile.Twitter
+		c.Logo = profile.Logo
+		if profile.Alias != "" {
+			c.Alias = profile.Alias
+		}
+		c.ProfileFetchedAt = &now
+		if err := database.SaveCreator(&c); err != nil {
+			return err
+		}
+	}
+
+	contracts, err := database.StaleContracts(olderThan, profileBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, c := range contracts {
+		collection, err := idx.GetCollection(ctx, c.Address)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		c.Name = collection.Name
+		c.Description = collection.Description
+		c.Website = collection.Website
+		if collection.Alias != "" {
+			c.Alias = collection.Alias
+		}
+		if collection.Creator != "" {
+			c.CreatorAddress = collection.Creator
+		}
+		c.MetadataFetchedAt = &now
+		if err := database.SaveContract(&c); err != nil {
+			return err
+		}
+	}
+
+	if n := len(creators) + len(contracts); n > 0 {
+		log.Printf("Looked up %d creator profiles and %d collections", len(creators), len(contracts))
+	}
+	return nil
+}
@@ -0,0 +1,384 @@
+package core
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"porcupin/backend/config"
+)
+
+// Asset type ranks used by the asset type weight. Artifacts are the actual
+// artwork, so they go before previews.
+var assetTypeRank = map[string]float64{
+	"artifact":  1.0,
+	"display":   0.6,
+	"format":    0.4,
+	"thumbnail": 0.2,
+}
+
+// PinTask is a single asset waiting to be pinned
+type PinTask struct {
+	AssetID   uint64    `json:"asset_id"`
+	URI       string    `json:"uri"`
+	Type      string    `json:"type"`
+	Wallet    string    `json:"wallet"`
+	SizeBytes int64     `json:"size_bytes"` // 0 if not known yet
+	CreatedAt time.Time `json:"created_at"` // when the asset was first seen
+	Bumped    bool      `json:"bumped"`     // moved to the front with PinNext
+	Score     float64   `json:"score"`
+
+	seq   uint64
+	index int // position in the wallet heap, -1 when not in a heap
+}
+
+// WalletQueueStatus describes one wallet's share of the queue
+type WalletQueueStatus struct {
+	Address  string `json:"address"`
+	Priority int    `json:"priority"`
+	Queued   int    `json:"queued"`
+}
+
+// QueueStatus is a snapshot of the pin queue
+type QueueStatus struct {
+	Queued   int                 `json:"queued"`    // tasks waiting
+	InFlight int                 `json:"in_flight"` // tasks being pinned
+	Bumped   []PinTask           `json:"bumped"`    // tasks that will run next, in order
+	Wallets  []WalletQueueStatus `json:"wallets"`
+}
+
+// PinQueue orders pending pins. Wallets are served round-robin so a large
+// wallet cannot starve the others; a wallet's priority (scaled by the wallet
+// priority weight) gives it extra consecutive turns. Within a wallet, tasks
+// are ordered by a score built from asset type, size and age.
+// Tasks bumped with PinNext skip the rotation entirely.
+type PinQueue struct {
+	mu      sync.Mutex
+	weights config.QueueConfig
+
+	wallets  map[string]*walletQueue
+	ring     []string // wallets with queued tasks, in rotation order
+	ringPos  int
+	served   int // tasks taken from ring[ringPos] this turn
+	bumped   []*PinTask
+	byURI    map[string]*PinTask
+	inFlight map[string]bool
+	seq      uint64
+
+	ready chan struct{}
+}
+
+// walletQueue holds one wallet's tasks
+type walletQueue struct {
+	address  string
+	priority int
+	tasks    taskHeap
+	inRing   bool
+}
+
+// NewPinQueue creates an empty queue using the given weights
+func NewPinQueue(weights config.QueueConfig) *PinQueue {
+	return &PinQueue{
+		weights:  weights,
+		wallets:  make(map[string]*walletQueue),
+		byURI:    make(map[string]*PinTask),
+		inFlight: make(map[string]bool),
+		ready:    make(chan struct{}, 1),
+	}
+}
+
+// Push adds a task. Returns false if the URI is already queued or being pinned.
+// walletPriority is used when this is the wallet's first task in the queue.
+func (q *PinQueue) Push(task PinTask, walletPriority int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.byURI[task.URI]; ok || q.inFlight[task.URI] {
+		return false
+	}
+
+	q.seq++
+	t := task
+	t.seq = q.seq
+	t.index = -1
+	t.Score = q.score(&t)
+	q.byURI[t.URI] = &t
+
+	if t.Bumped {
+		q.bumped = append(q.bumped, &t)
+	} else {
+		wq := q.walletQueue(t.Wallet, walletPriority)
+		heap.Push(&wq.tasks, &t)
+	}
+
+	q.signal()
+	return true
+}
+
+// Next removes and returns the next task to pin, or nil if the queue is empty.
+// Callers must call Done with the task once it has been processed.
+func (q *PinQueue) Next() *PinTask {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var task *PinTask
+	if n := len(q.bumped); n > 0 {
+		// Most recent bump first - "pin next" means next
+		task = q.bumped[n-1]
+		q.bumped = q.bumped[:n-1]
+	} else {
+		task = q.nextFromRing()
+	}
+	if task == nil {
+		return nil
+	}
+
+	delete(q.byURI, task.URI)
+	q.inFlight[task.URI] = true
+	if len(q.byURI) > 0 {
+		// Wake another worker for the remaining tasks
+		q.signal()
+	}
+	return task
+}
+
+// Done marks a task returned by Next as finished
+func (q *PinQueue) Done(task *PinTask) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.inFlight, task.URI)
+}
+
+// Requeue returns a task taken with Next to the queue without pinning it,
+// e.g. because the manager was paused in the meantime
+func (q *PinQueue) Requeue(task *PinTask) {
+	q.Done(task)
+	// The wallet is already known, so its priority is kept
+	q.Push(*task, 0)
+}
+
+// Bump moves a queued task to the front of the queue.
+// Returns false if the URI is not queued.
+func (q *PinQueue) Bump(uri string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	task, ok := q.byURI[uri]
+	if !ok {
+		return false
+	}
+	if task.Bumped {
+		// Already bumped - move it to the top of the bump stack
+		for i, t := range q.bumped {
+			if t == task {
+				q.bumped = append(q.bumped[:i], q.bumped[i+1:]...)
+				break
+			}
+		}
+	} else if wq, ok := q.wallets[task.Wallet]; ok && task.index >= 0 {
+		heap.Remove(&wq.tasks, task.index)
+	}
+
+	task.Bumped = true
+	q.bumped = append(q.bumped, task)
+	q.signal()
+	return true
+}
+
+// Contains reports whether a URI is queued or being pinned
+func (q *PinQueue) Contains(uri string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, ok := q.byURI[uri]
+	return ok || q.inFlight[uri]
+}
+
+// SetWalletPriority updates the priority of a wallet already in the queue
+func (q *PinQueue) SetWalletPriority(address string, priority int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if wq, ok := q.wallets[address]; ok {
+		wq.priority = priority
+	}
+}
+
+// SetWeights replaces the scoring weights. Queued tasks are re-scored.
+func (q *PinQueue) SetWeights(weights config.QueueConfig) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.weights = weights
+	for _, wq := range q.wallets {
+		for _, t := range wq.tasks {
+			t.Score = q.score(t)
+		}
+		heap.Init(&wq.tasks)
+	}
+}
+
+// Len returns the number of queued tasks (excluding in-flight ones)
+func (q *PinQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.byURI)
+}
+
+// Ready returns a channel that receives when tasks are added
+func (q *PinQueue) Ready() <-chan struct{} {
+	return q.ready
+}
+
+// Status returns a snapshot of the queue
+func (q *PinQueue) Status() QueueStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	status := QueueStatus{
+		Queued:   len(q.byURI),
+		InFlight: len(q.inFlight),
+		Bumped:   make([]PinTask, 0, len(q.bumped)),
+		Wallets:  make([]WalletQueueStatus, 0, len(q.wallets)),
+	}
+	for i := len(q.bumped) - 1; i >= 0; i-- {
+		status.Bumped = append(status.Bumped, *q.bumped[i])
+	}
+	for _, wq := range q.wallets {
+		if len(wq.tasks) == 0 {
+			continue
+		}
+		status.Wallets = append(status.Wallets, WalletQueueStatus{
+			Address:  wq.address,
+			Priority: wq.priority,
+			Queued:   len(wq.tasks),
+		})
+	}
+	sort.Slice(status.Wallets, func(i, j int) bool {
+		return status.Wallets[i].Address < status.Wallets[j].Address
+	})
+	return status
+}
+
+// walletQueue returns the queue for a wallet, adding it to the rotation if needed
+func (q *PinQueue) walletQueue(address string, priority int) *walletQueue {
+	wq, ok := q.wallets[address]
+	if !ok {
+		wq = &walletQueue{address: address, priority: priority}
+		q.wallets[address] = wq
+	}
+	if !wq.inRing {
+		wq.inRing = true
+		q.ring = append(q.ring, address)
+	}
+	return wq
+}
+
+// nextFromRing takes the next task in the weighted round-robin rotation
+func (q *PinQueue) nextFromRing() *PinTask {
+	for len(q.ring) > 0 {
+		if q.ringPos >= len(q.ring) {
+			q.ringPos = 0
+		}
+		wq := q.wallets[q.ring[q.ringPos]]
+
+		if len(wq.tasks) == 0 {
+			// Wallet drained (e.g. by Bump) - drop it from the rotation
+			q.dropFromRing(wq)
+			continue
+		}
+
+		if q.served >= q.quantum(wq) {
+			q.ringPos++
+			q.served = 0
+			continue
+		}
+
+		q.served++
+		task := heap.Pop(&wq.tasks).(*PinTask)
+		if len(wq.tasks) == 0 {
+			q.dropFromRing(wq)
+		}
+		return task
+	}
+	return nil
+}
+
+// dropFromRing removes the wallet at ringPos from the rotation
+func (q *PinQueue) dropFromRing(wq *walletQueue) {
+	wq.inRing = false
+	q.ring = append(q.ring[:q.ringPos], q.ring[q.ringPos+1:]...)
+	q.served = 0
+}
+
+// quantum is how many tasks a wallet gets per turn
+func (q *PinQueue) quantum(wq *walletQueue) int {
+	if wq.priority <= 0 {
+		return 1
+	}
+	return 1 + int(math.Round(float64(wq.priority)*q.weights.WalletPriorityWeight))
+}
+
+// score ranks a task within its wallet; higher runs first
+func (q *PinQueue) score(t *PinTask) float64 {
+	typeScore := assetTypeRank[t.Type]
+
+	// Smaller files first: 1.0 for tiny files, 0.5 at 100MB. Unknown sizes sit in the middle.
+	sizeScore := 0.5
+	if t.SizeBytes > 0 {
+		sizeScore = 1 / (1 + float64(t.SizeBytes)/(100<<20))
+	}
+
+	// Older assets first: 0 for new, 0.5 after a day, approaching 1
+	ageScore := 0.0
+	if !t.CreatedAt.IsZero() {
+		age := time.Since(t.CreatedAt).Hours()
+		if age > 0 {
+			ageScore = age / (age + 24)
+		}
+	}
+
+	w := q.weights
+	return w.AssetTypeWeight*typeScore + w.SizeWeight*sizeScore + w.AgeWeight*ageScore
+}
+
+// signal wakes one waiting worker without blocking
+func (q *PinQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// taskHeap is a max-heap of tasks by score, oldest first on ties
+type taskHeap []*PinTask
+
+func (h taskHeap) Len() int { return len(h) }
+
+func (h taskHeap) Less(i, j int) bool {
+	if h[i].Score != h[j].Score {
+		return h[i].Score > h[j].Score
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h taskHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *taskHeap) Push(x interface{}) {
+	t := x.(*PinTask)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+
+func (h *taskHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	t := old[n-1]
+	old[n-1] = nil
+	t.index = -1
+	*h = old[:n-1]
+	return t
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"log"
+	"time"
+
+	"porcupin/backend/db"
+)
+
+// quotaRefreshInterval is how long wallet usage is reused before it is recomputed.
+// Pins made in between are added to the cached usage as they complete.
+const quotaRefreshInterval = 30 * time.Second
+
+// bytesPerGB converts quota settings to bytes
+const bytesPerGB = 1024 * 1024 * 1024
+
+// quotaState caches each wallet's quota and pinned usage for the pin workers
+type quotaState struct {
+	checkedAt time.Time
+	limits    map[string]int64 // quota in bytes, only wallets with a quota
+	used      map[string]int64
+	over      map[string]bool
+}
+
+// isOverQuota reports whether a wallet has used up its storage quota.
+// Fails open when usage cannot be computed.
+func (bm *BackupManager) isOverQuota(address string) bool {
+	if address == "" {
+		return false
+	}
+
+	bm.quotaMu.Lock()
+	defer bm.quotaMu.Unlock()
+
+	if time.Since(bm.quota.checkedAt) > quotaRefreshInterval {
+		bm.refreshQuotasLocked()
+	}
+	return bm.quota.over[address]
+}
+
+// refreshQuotasLocked recomputes wallet usage. Caller holds quotaMu.
+func (bm *BackupManager) refreshQuotasLocked() {
+	bm.quota.checkedAt = time.Now()
+
+	wallets, err := bm.db.GetAllWallets()
+	if err != nil {
+		log.Printf("Failed to get wallet quotas: %v", err)
+		return
+	}
+
+	limits := make(map[string]int64)
+	for _, w := range wallets {
+		if w.QuotaGB > 0 {
+			limits[w.Address] = int64(w.QuotaGB) * bytesPerGB
+		}
+	}
+
+	// Nothing to enforce, skip the usage query
+	if len(limits) == 0 {
+		bm.quota.limits, bm.quota.used, bm.quota.over = nil, nil, nil
+		return
+	}
+
+	usage, _, err := bm.db.GetWalletUsage()
+	if err != nil {
+		log.Printf("Failed to get wallet usage: %v", err)
+		return
+	}
+
+	used := make(map[string]int64, len(limits))
+	over := make(map[string]bool)
+	for address, limit := range limits {
+		if u := usage[address]; u != nil {
+			used[address] = u.UsedBytes
+		}
+		if used[address] >= limit {
+			over[address] = true
+			if !bm.quota.over[address] {
+				log.Printf("Wallet %s reached its storage quota (%.2f GB used, limit %d GB), deferring its pins",
+					address, float64(used[address])/bytesPerGB, limit/bytesPerGB)
+			}
+		}
+	}
+
+	bm.quota.limits, bm.quota.used, bm.quota.over = limits, used, over
+}
+
+// addQuotaUsage charges a newly pinned asset to a wallet's cached usage.
+// Content shared with other wallets is split between them, so pinning it
+// forces a refresh rather than charging the wallet in full.
+func (bm *BackupManager) addQuotaUsage(address string, asset *db.Asset) {
+	bm.quotaMu.Lock()
+	_, ok := bm.quota.limits[address]
+	bm.quotaMu.Unlock()
+	if !ok {
+		return
+	}
+
+	if bm.assetShared(asset) {
+		bm.invalidateQuotas()
+		return
+	}
+
+	bm.quotaMu.Lock()
+	defer bm.quotaMu.Unlock()
+
+	limit, ok := bm.quota.limits[address]
+	if !ok {
+		return
+	}
+	bm.quota.used[address] += asset.SizeBytes
+	if bm.quota.used[address] >= limit && !bm.quota.over[address] {
+		bm.quota.over[address] = true
+		log.Printf("Wallet %s reached its storage quota (limit %d GB), deferring its pins", address, limit/bytesPerGB)
+	}
+}
+
+// assetShared reports whether another wallet or asset also uses an asset's
+// content. Errors count as shared, so usage gets recomputed.
+func (bm *BackupManager) assetShared(asset *db.Asset) bool {
+	wallets, err := bm.db.CountAssetWallets(asset.ID)
+	if err != nil || wallets > 1 {
+		return true
+	}
+	cid := ExtractCIDFromURI(asset.URI)
+	if cid == "" {
+		return false
+	}
+	shared, err := bm.db.CIDUsedByOtherAsset(cid, asset.ID)
+	return err != nil || shared
+}
+
+// invalidateQuotas forces wallet usage to be recomputed on the next check
+func (bm *BackupManager) invalidateQuotas() {
+	bm.quotaMu.Lock()
+	bm.quota.checkedAt = time.Time{}
+	bm.quotaMu.Unlock()
+}
+
+// ApplyWalletQuota picks up a changed wallet quota. Deferred assets are queued again
+// so a raised quota takes effect immediately. The wallet record is saved by the caller.
+func (bm *BackupManager) ApplyWalletQuota(address string) {
+	bm.invalidateQuotas()
+	if !bm.isOverQuota(address) {
+		bm.EnqueuePendingAssets()
+	}
+}
+
+// walletForAsset returns the address of the wallet an asset was recorded under
+func (bm *BackupManager) walletForAsset(asset *db.Asset) string {
+	if asset.NFT != nil {
+		return asset.NFT.WalletAddress
+	}
+	var nft db.NFT
+	if err := bm.db.DB.Select("wallet_address").First(&nft, asset.NFTID).Error; err != nil {
+		return ""
+	}
+	return nft.WalletAddress
+}
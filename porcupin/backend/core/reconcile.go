@@ -0,0 +1,144 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"porcupin/backend/db"
+)
+
+// ErrReconcileUnavailable is returned when there is no IPFS node to compare
+// the database with
+var ErrReconcileUnavailable = errors.New("pin reconciliation is not available: no IPFS node")
+
+// PinSet is implemented by IPFS clients that can list and remove their pins
+type PinSet interface {
+	ListPins(ctx context.Context) ([]string, error)
+	Unpin(ctx context.Context, cid string) error
+}
+
+// MissingPin is an asset recorded as pinned whose CID the node doesn't pin
+type MissingPin struct {
+	AssetID uint64 `json:"asset_id"`
+	NFTID   uint64 `json:"nft_id"`
+	CID     string `json:"cid"`
+	URI     string `json:"uri"`
+}
+
+// SharedCID is a CID that several assets refer to. Unpinning it for one
+// unpins it for all of them.
+type SharedCID struct {
+	CID      string   `json:"cid"`
+	AssetIDs []uint64 `json:"asset_ids"`
+}
+
+// PinReconcileReport compares the node's recursive pins with the assets table
+type PinReconcileReport struct {
+	DryRun       bool         `json:"dry_run"`
+	Pins         int          `json:"pins"`         // Recursive pins on the node
+	Assets       int          `json:"assets"`       // Assets with an IPFS CID
+	Unreferenced []string     `json:"unreferenced"` // Pins no asset or replicated pin refers to
+	Missing      []MissingPin `json:"missing"`      // Pinned assets the node doesn't pin
+	Shared       []SharedCID  `json:"shared"`       // CIDs several assets refer to
+	Unpinned     int          `json:"unpinned"`     // Unreferenced pins removed
+	Reset        int          `json:"reset"`        // Missing assets set back to pending
+	Errors       []string     `json:"errors"`
+}
+
+// ReconcilePins compares the node's recursive pins with the assets table.
+// It reports pins nothing refers to, assets recorded as pinned that the node
+// doesn't pin, and CIDs several assets share. Unless dryRun is set, it
+// unpins the unreferenced pins and sets the missing assets back to pending
+// so they are pinned again. Pins kept for replication peers count as
+// referenced.
+func ReconcilePins(ctx context.Context, node PinSet, database *db.Database, dryRun bool) (*PinReconcileReport, error) {
+	pins, err := node.ListPins(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pinned := make(map[string]bool, len(pins))
+	for _, p := range pins {
+		pinned[db.NormalizeCID(p)] = true
+	}
+
+	var assets []db.Asset
+	if err := database.Select("id", "nft_id", "uri", "status").Order("id").Find(&assets).Error; err != nil {
+		return nil, fmt.Errorf("failed to get assets: %w", err)
+	}
+	var replicated []string
+	if err := database.Model(&db.ReplicatedPin{}).Distinct().Pluck("cid", &replicated).Error; err != nil {
+		return nil, fmt.Errorf("failed to get replicated pins: %w", err)
+	}
+
+	report := &PinReconcileReport{
+		DryRun:       dryRun,
+		Pins:         len(pinned),
+		Unreferenced: []string{},
+		Missing:      []MissingPin{},
+		Shared:       []SharedCID{},
+		Errors:       []string{},
+	}
+	referenced := make(map[string][]uint64)
+	for _, asset := range assets {
+		c := ExtractCIDFromURI(asset.URI)
+		if c == "" {
+			continue
+		}
+		c = db.NormalizeCID(c)
+		report.Assets++
+		referenced[c] = append(referenced[c], asset.ID)
+		if asset.Status == db.StatusPinned && !pinned[c] {
+			report.Missing = append(report.Missing, MissingPin{AssetID: asset.ID, NFTID: asset.NFTID, CID: c, URI: asset.URI})
+		}
+	}
+	kept := make(map[string]bool, len(replicated))
+	for _, c := range replicated {
+		kept[db.NormalizeCID(c)] = true
+	}
+
+	for c := range pinned {
+		if referenced[c] == nil && !kept[c] {
+			report.Unreferenced = append(report.Unreferenced, c)
+		}
+	}
+	sort.Strings(report.Unreferenced)
+	for c, ids := range referenced {
+		if len(ids) > 1 {
+			report.Shared = append(report.Shared, SharedCID{CID: c, AssetIDs: ids})
+		}
+	}
+	sort.Slice(report.Shared, func(i, j int) bool { return report.Shared[i].CID < report.Shared[j].CID })
+
+	if dryRun {
+		return report, nil
+	}
+
+	for _, c := range report.Unreferenced {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if err := node.Unpin(ctx, c); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("unpin %s: %v", c, err))
+			continue
+		}
+		report.Unpinned++
+	}
+	if len(report.Missing) > 0 {
+		ids := make([]uint64, 0, len(report.Missing))
+		for _, m := range report.Missing {
+			ids = append(ids, m.AssetID)
+		}
+		reset, err := database.ResetAssetsToPending(ids)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("reset missing assets: %v", err))
+		}
+		report.Reset = int(reset)
+	}
+
+	log.Printf("Pin reconciliation: %d unreferenced pins (%d unpinned), %d missing assets (%d reset), %d shared CIDs",
+		len(report.Unreferenced), report.Unpinned, len(report.Missing), report.Reset, len(report.Shared))
+	return report, nil
+}
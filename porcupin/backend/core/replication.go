@@ -0,0 +1,550 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"porcupin/backend/db"
+)
+
+// catalogPageSize is how many catalog entries are requested from a peer at once
+const catalogPageSize = 500
+
+// MaxCatalogPageSize is the largest page a node serves from its catalog
+const MaxCatalogPageSize = 1000
+
+// peerConnectTimeout bounds dialing a peer's libp2p addresses before pinning
+const peerConnectTimeout = 30 * time.Second
+
+var (
+	// ErrPeerNotFound is returned for replication peer IDs that do not exist
+	ErrPeerNotFound = errors.New("replication peer not found")
+	// ErrReplicationInProgress is returned when a peer is already being synced
+	ErrReplicationInProgress = errors.New("replication already in progress for this peer")
+	// ErrReplicationUnavailable is returned when no catalog client has been configured
+	ErrReplicationUnavailable = errors.New("replication client not configured")
+)
+
+// CatalogEntry is one pinned CID in a node's replication catalog
+type CatalogEntry struct {
+	CID       string    `json:"cid"`
+	URI       string    `json:"uri"`
+	SizeBytes int64     `json:"size_bytes"`
+	PinnedAt  time.Time `json:"pinned_at"`
+}
+
+// CatalogPage is a page of a node's replication catalog, along with the
+// libp2p addresses subscribers should fetch the content from
+type CatalogPage struct {
+	NodeID    string         `json:"node_id"`
+	NodeAddrs []string       `json:"node_addrs"`
+	SwarmPort int            `json:"swarm_port"`
+	Total     int64          `json:"total"` // Pinned assets in the whole catalog
+	Entries   []CatalogEntry `json:"entries"`
+	HasMore   bool           `json:"has_more"`
+}
+
+// CatalogSource reads a peer's replication catalog (api.RemoteClient)
+type CatalogSource interface {
+	Catalog(ctx context.Context, since *time.Time, offset, limit int) (*CatalogPage, error)
+}
+
+// CatalogDialer returns a catalog source for a replication peer
+type CatalogDialer func(peer *db.ReplicationPeer) CatalogSource
+
+// PeerConnector is implemented by IPFS clients that can dial a peer directly
+type PeerConnector interface {
+	ConnectPeer(ctx context.Context, addrs []string) error
+}
+
+// ReplicationStatus reports how far this node is behind a peer
+type ReplicationStatus struct {
+	Peer       db.ReplicationPeer `json:"peer"`
+	Counts     db.ReplicaCounts   `json:"counts"`
+	Syncing    bool               `json:"syncing"`
+	LagSeconds int64              `json:"lag_seconds"` // Age of the oldest CID the peer has that is not replicated here
+	Missing    int64              `json:"missing"`     // CIDs the peer has that are not pinned here
+	Divergence int64              `json:"divergence"`  // Missing plus CIDs the peer dropped that are still pinned here
+}
+
+// BuildCatalogPage lists this node's pinned content for replicating peers.
+// Entries are ordered by when they were pinned, so a subscriber can pass the
+// newest pinned_at it has seen as since to only receive new pins.
+func BuildCatalogPage(database *db.Database, since *time.Time, offset, limit int) (*CatalogPage, error) {
+	if limit <= 0 || limit > MaxCatalogPageSize {
+		limit = MaxCatalogPageSize
+	}
+
+	// Fetch one extra row to know whether another page follows
+	assets, total, err := database.GetPinnedCatalog(since, offset, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &CatalogPage{
+		Total:   total,
+		Entries: make([]CatalogEntry, 0, len(assets)),
+	}
+	if len(assets) > limit {
+		page.HasMore = true
+		assets = assets[:limit]
+	}
+
+	for _, a := range assets {
+		cid := ExtractCIDFromURI(a.URI)
+		if cid == "" || a.PinnedAt == nil {
+			continue
+		}
+		page.Entries = append(page.Entries, CatalogEntry{
+			CID:       cid,
+			URI:       a.URI,
+			SizeBytes: a.SizeBytes,
+			PinnedAt:  *a.PinnedAt,
+		})
+	}
+	return page, nil
+}
+
+// Replicator pins the content of other Porcupin nodes' catalogs
+type Replicator struct {
+	manager *BackupManager
+	db      *db.Database
+
+	mu      sync.Mutex
+	dial    CatalogDialer
+	syncing map[uint64]bool
+}
+
+// NewReplicator creates a replicator that pins through the manager's IPFS client
+func NewReplicator(manager *BackupManager, database *db.Database) *Replicator {
+	return &Replicator{
+		manager: manager,
+		db:      database,
+		syncing: make(map[uint64]bool),
+	}
+}
+
+// SetDialer sets how peers' catalogs are reached
+func (r *Replicator) SetDialer(dial CatalogDialer) {
+	r.mu.Lock()
+	r.dial = dial
+	r.mu.Unlock()
+}
+
+// begin marks a peer as syncing, failing if it already is
+func (r *Replicator) begin(peerID uint64) (CatalogDialer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.dial == nil {
+		return nil, ErrReplicationUnavailable
+	}
+	if r.syncing[peerID] {
+		return nil, ErrReplicationInProgress
+	}
+	r.syncing[peerID] = true
+	return r.dial, nil
+}
+
+// end clears a peer's syncing flag
+func (r *Replicator) end(peerID uint64) {
+	r.mu.Lock()
+	delete(r.syncing, peerID)
+	r.mu.Unlock()
+}
+
+// isSyncing reports whether a peer is being synced
+func (r *Replicator) isSyncing(peerID uint64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.syncing[peerID]
+}
+
+// SyncPeer fetches new entries from a peer's catalog and pins them locally.
+// The whole catalog is walked on the first sync, when full is set, and once per
+// full sync interval, so CIDs the peer no longer has can be flagged.
+// progress may be nil.
+func (r *Replicator) SyncPeer(ctx context.Context, peerID uint64, full bool, progress func(current, total int, message string)) error {
+	dial, err := r.begin(peerID)
+	if err != nil {
+		return err
+	}
+	defer r.end(peerID)
+
+	if progress == nil {
+		progress = func(int, int, string) {}
+	}
+
+	peer, err := r.db.GetReplicationPeer(peerID)
+	if err != nil {
+		return err
+	}
+	if peer == nil {
+		return ErrPeerNotFound
+	}
+
+	cfg := r.manager.Config().Replication
+	if peer.Cursor == nil || peer.LastFullAt == nil || time.Since(*peer.LastFullAt) > cfg.FullSyncInterval {
+		full = true
+	}
+
+	syncErr := r.syncPeer(ctx, dial(peer), peer, full, progress)
+
+	now := time.Now()
+	peer.LastSyncAt = &now
+	peer.LastError = ""
+	if syncErr != nil {
+		peer.LastError = syncErr.Error()
+	}
+	if err := r.db.SaveReplicationPeer(peer); err != nil {
+		log.Printf("Replication: failed to save peer %d: %v", peer.ID, err)
+	}
+	return syncErr
+}
+
+// syncPeer runs one sync against a peer, updating the peer record in place
+func (r *Replicator) syncPeer(ctx context.Context, source CatalogSource, peer *db.ReplicationPeer, full bool, progress func(int, int, string)) error {
+	// Compare seen_at in UTC so stored and queried times order consistently
+	syncStart := time.Now().UTC()
+
+	var since *time.Time
+	if !full {
+		since = peer.Cursor
+	}
+
+	progress(0, 0, "Reading peer catalog...")
+	cursor := peer.Cursor
+	var first *CatalogPage
+	offset := 0
+	for {
+		page, err := source.Catalog(ctx, since, offset, catalogPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to read catalog: %w", err)
+		}
+		if first == nil {
+			first = page
+		}
+
+		pins := make([]db.ReplicatedPin, 0, len(page.Entries))
+		seen := make(map[string]bool, len(page.Entries))
+		for _, e := range page.Entries {
+			if e.CID == "" || seen[e.CID] {
+				continue
+			}
+			seen[e.CID] = true
+			pins = append(pins, db.ReplicatedPin{
+				ReplicationPeerID: peer.ID,
+				CID:               e.CID,
+				URI:               e.URI,
+				SizeBytes:         e.SizeBytes,
+				RemotePinnedAt:    e.PinnedAt,
+				SeenAt:            syncStart,
+			})
+			if cursor == nil || e.PinnedAt.After(*cursor) {
+				t := e.PinnedAt
+				cursor = &t
+			}
+		}
+		if err := r.db.UpsertReplicatedPins(pins); err != nil {
+			return fmt.Errorf("failed to record catalog: %w", err)
+		}
+
+		offset += len(page.Entries)
+		if !page.HasMore || len(page.Entries) == 0 {
+			break
+		}
+	}
+
+	peer.Cursor = cursor
+	peer.RemoteSize = first.Total
+	peer.NodeID = first.NodeID
+	if addrs, err := json.Marshal(first.NodeAddrs); err == nil {
+		peer.NodeAddrs = string(addrs)
+	}
+
+	if full {
+		removed, err := r.db.MarkReplicatedPinsRemoved(peer.ID, syncStart)
+		if err != nil {
+			return fmt.Errorf("failed to reconcile catalog: %w", err)
+		}
+		if removed > 0 {
+			log.Printf("Replication: %d CIDs no longer in %s's catalog", removed, peer.Host)
+		}
+		now := time.Now()
+		peer.LastFullAt = &now
+	}
+
+	r.connect(ctx, peer, first)
+
+	return r.pinPending(ctx, peer, progress)
+}
+
+// connect dials the peer's IPFS node so content is fetched from it directly.
+// Failure is not fatal: the content can still be found through the DHT.
+func (r *Replicator) connect(ctx context.Context, peer *db.ReplicationPeer, page *CatalogPage) {
+	connector, ok := r.manager.ipfs.(PeerConnector)
+	if !ok || page.NodeID == "" {
+		return
+	}
+
+	addrs := append([]string{}, page.NodeAddrs...)
+	// The host we reach the API on is usually the best route to the swarm as well
+	if page.SwarmPort > 0 {
+		addrs = append(addrs, hostPeerAddrs(peer.Host, page.SwarmPort, page.NodeID)...)
+	}
+	if len(addrs) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, peerConnectTimeout)
+	defer cancel()
+	if err := connector.ConnectPeer(ctx, addrs); err != nil {
+		log.Printf("Replication: could not connect to %s's IPFS node, falling back to the DHT: %v", peer.Host, err)
+	}
+}
+
+// hostPeerAddrs builds libp2p addresses for a peer's swarm port on the given host
+func hostPeerAddrs(host string, port int, nodeID string) []string {
+	proto := "dns"
+	if ip := net.ParseIP(host); ip != nil {
+		proto = "ip4"
+		if ip.To4() == nil {
+			proto = "ip6"
+		}
+	}
+	return []string{
+		fmt.Sprintf("/%s/%s/tcp/%d/p2p/%s", proto, host, port, nodeID),
+		fmt.Sprintf("/%s/%s/udp/%d/quic-v1/p2p/%s", proto, host, port, nodeID),
+	}
+}
+
+// pinPending pins the peer's CIDs not yet replicated, in the order the peer pinned them
+func (r *Replicator) pinPending(ctx context.Context, peer *db.ReplicationPeer, progress func(int, int, string)) error {
+	cfg := r.manager.Config()
+	pins, err := r.db.GetReplicatedPinsToPin(peer.ID, cfg.Replication.MaxRetries, 0)
+	if err != nil {
+		return fmt.Errorf("failed to get pins to replicate: %w", err)
+	}
+	if len(pins) == 0 {
+		progress(0, 0, "Up to date")
+		return nil
+	}
+
+	workers := cfg.Backup.MaxConcurrency
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var (
+		mu      sync.Mutex
+		done    int
+		pinned  int
+		stopErr error
+		wg      sync.WaitGroup
+		tasks   = make(chan *db.ReplicatedPin)
+	)
+	progress(0, len(pins), fmt.Sprintf("Replicating %d CIDs...", len(pins)))
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pin := range tasks {
+				ok := r.pinOne(ctx, pin)
+				mu.Lock()
+				done++
+				if ok {
+					pinned++
+				}
+				progress(done, len(pins), fmt.Sprintf("Replicated %d of %d CIDs", pinned, len(pins)))
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for i := range pins {
+		// Stop taking work when paused or out of space; the rest stay pending
+		switch {
+		case ctx.Err() != nil:
+			stopErr = ctx.Err()
+		case r.manager.IsPaused():
+			stopErr = errors.New("backup paused")
+		case !r.manager.isWithinStorageLimit():
+			stopErr = errors.New("storage limit reached")
+		case !r.manager.hasSufficientDiskSpace():
+			stopErr = errors.New("insufficient disk space")
+		}
+		if stopErr != nil {
+			break feed
+		}
+		select {
+		case tasks <- &pins[i]:
+		case <-ctx.Done():
+			stopErr = ctx.Err()
+			break feed
+		}
+	}
+	close(tasks)
+	wg.Wait()
+
+	if pinned > 0 {
+		r.manager.MarkDiskUsageDirty()
+	}
+	return stopErr
+}
+
+// pinOne pins a single replicated CID and records the outcome
+func (r *Replicator) pinOne(ctx context.Context, pin *db.ReplicatedPin) bool {
+	err := r.manager.ipfs.Pin(ctx, pin.CID, r.manager.Config().IPFS.PinTimeout)
+	if err != nil {
+		if ctx.Err() != nil {
+			// Interrupted, not failed; it stays as it was
+			return false
+		}
+		pin.Status = db.ReplicaStatusFailed
+		pin.RetryCount++
+		pin.ErrorMsg = err.Error()
+	} else {
+		now := time.Now()
+		pin.Status = db.ReplicaStatusPinned
+		pin.ErrorMsg = ""
+		pin.PinnedAt = &now
+	}
+	if err := r.db.SaveReplicatedPin(pin); err != nil {
+		log.Printf("Replication: failed to save pin %s: %v", pin.CID, err)
+	}
+	return pin.Status == db.ReplicaStatusPinned
+}
+
+// SyncAll syncs every enabled peer in turn, logging failures
+func (r *Replicator) SyncAll(ctx context.Context) {
+	peers, err := r.db.GetReplicationPeers()
+	if err != nil {
+		log.Printf("Replication: failed to get peers: %v", err)
+		return
+	}
+	for _, peer := range peers {
+		if !peer.Enabled {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		err := r.SyncPeer(ctx, peer.ID, false, nil)
+		switch {
+		case err == nil, errors.Is(err, ErrReplicationInProgress):
+		case errors.Is(err, ErrReplicationUnavailable):
+			return
+		default:
+			log.Printf("Replication: sync with %s failed: %v", peer.Host, err)
+		}
+	}
+}
+
+// Status reports replication lag and divergence for a peer
+func (r *Replicator) Status(peerID uint64) (*ReplicationStatus, error) {
+	peer, err := r.db.GetReplicationPeer(peerID)
+	if err != nil {
+		return nil, err
+	}
+	if peer == nil {
+		return nil, ErrPeerNotFound
+	}
+	return r.status(peer)
+}
+
+// Statuses reports replication lag and divergence for every peer
+func (r *Replicator) Statuses() ([]ReplicationStatus, error) {
+	peers, err := r.db.GetReplicationPeers()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]ReplicationStatus, 0, len(peers))
+	for i := range peers {
+		st, err := r.status(&peers[i])
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, *st)
+	}
+	return statuses, nil
+}
+
+// status computes the replication status of a peer record
+func (r *Replicator) status(peer *db.ReplicationPeer) (*ReplicationStatus, error) {
+	counts, err := r.db.GetReplicaCounts(peer.ID)
+	if err != nil {
+		return nil, err
+	}
+	oldest, err := r.db.GetOldestUnreplicated(peer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &ReplicationStatus{
+		Peer:    *peer,
+		Counts:  counts,
+		Syncing: r.isSyncing(peer.ID),
+		Missing: counts.Pending + counts.Failed,
+	}
+	st.Divergence = st.Missing + counts.Removed
+	if oldest != nil {
+		st.LagSeconds = int64(time.Since(*oldest).Seconds())
+	}
+	return st, nil
+}
+
+// RemovePeer stops replicating a peer. With unpin, its replicated CIDs are unpinned
+// unless a local asset or another peer still needs them. progress may be nil.
+func (r *Replicator) RemovePeer(ctx context.Context, peerID uint64, unpin bool, progress func(current, total int, message string)) error {
+	peer, err := r.db.GetReplicationPeer(peerID)
+	if err != nil {
+		return err
+	}
+	if peer == nil {
+		return ErrPeerNotFound
+	}
+	if progress == nil {
+		progress = func(int, int, string) {}
+	}
+
+	if unpin {
+		var pins []db.ReplicatedPin
+		if err := r.db.Where("replication_peer_id = ? AND status IN (?, ?)", peerID, db.ReplicaStatusPinned, db.ReplicaStatusRemoved).
+			Find(&pins).Error; err != nil {
+			return fmt.Errorf("failed to get replicated pins: %w", err)
+		}
+
+		unpinned := 0
+		for i, pin := range pins {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			inUse, err := r.db.IsCIDReferenced(pin.CID, peerID)
+			if err != nil {
+				return err
+			}
+			if !inUse {
+				// Best effort, matching wallet deletes
+				if err := r.manager.ipfs.Unpin(ctx, pin.CID); err != nil {
+					log.Printf("Replication: failed to unpin %s: %v", pin.CID, err)
+				} else {
+					unpinned++
+				}
+			}
+			// Drop the record so a resumed removal skips it
+			r.db.Delete(&db.ReplicatedPin{}, pin.ID)
+			progress(i+1, len(pins), fmt.Sprintf("Unpinned %d of %d CIDs", unpinned, len(pins)))
+		}
+		r.manager.MarkDiskUsageDirty()
+	}
+
+	return r.db.DeleteReplicationPeer(peerID)
+}
@@ -2,6 +2,9 @@ package core
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"sync"
 	"time"
@@ -10,6 +13,7 @@ import (
 	"porcupin/backend/db"
 	"porcupin/backend/indexer"
 	"porcupin/backend/ipfs"
+	"porcupin/backend/storage"
 )
 
 // ServiceState represents the current state of the backup service
@@ -21,6 +25,10 @@ const (
 	StateWatching  ServiceState = "watching"
 	StatePaused    ServiceState = "paused"
 	StateStopped   ServiceState = "stopped"
+	
+	// StateStorageUnavailable means the IPFS repository's volume is unmounted
+	// or unwritable; backups resume when it returns
+	StateStorageUnavailable ServiceState = "storage_unavailable"
 )
 
 // ServiceStatus represents the current status of the backup service
@@ -37,50 +45,90 @@ type ServiceStatus struct {
 	PinnedAssets    int          `json:"pinned_assets"`
 	FailedAssets    int          `json:"failed_assets"`
 	PendingRetries  int          `json:"pending_retries"`
+	QueuedAssets    int          `json:"queued_assets"`
 	CurrentItem     string       `json:"current_item"`
 	LastSyncAt      *time.Time   `json:"last_sync_at"`
+	StorageError    string       `json:"storage_error,omitempty"`
 }
 
 // BackupService manages the automatic backup lifecycle
 type BackupService struct {
-	manager  *BackupManager
-	indexer  *indexer.Indexer
-	db       *db.Database
-	config   *config.Config
-	ipfs     *ipfs.Node
+	manager    *BackupManager
+	indexer    *indexer.Indexer
+	db         *db.Database
+	ipfs       *ipfs.Node
+	jobs       *JobManager
+	replicator *Replicator
+	mirror     *Mirror
 	
 	ctx       context.Context
 	cancel    context.CancelFunc
+	parentCtx context.Context // Context Start was called with, to restart after a storage migration
+	
+	configPath string     // Where the config is saved when a storage migration moves the repository
+	configMu   sync.Mutex // Serializes UpdateConfig
 	
 	mu        sync.RWMutex
 	status    ServiceStatus
 	isPaused  bool
+	migrating bool
+	starting  *storage.MigrationStatus // Reported until the storage manager takes over a migration
+	
+	storageDown bool // The repository's volume is unavailable, see storageWorker
 	
 	// Channels for coordination
-	pauseCh   chan struct{}
-	resumeCh  chan struct{}
-	triggerCh chan string  // wallet address to sync
+	pauseCh    chan struct{}
+	resumeCh   chan struct{}
+	triggerCh  chan string   // wallet address to sync
+	reschedule chan struct{} // closed when a config reload changes the schedules
 }
 
 // NewBackupService creates a new backup service
 func NewBackupService(ipfsNode *ipfs.Node, idx *indexer.Indexer, database *db.Database, cfg *config.Config) *BackupService {
 	manager := NewBackupManager(ipfsNode, idx, database, cfg)
 	
-	return &BackupService{
-		manager:   manager,
-		indexer:   idx,
-		db:        database,
-		config:    cfg,
-		ipfs:      ipfsNode,
-		status:    ServiceStatus{State: StateStopped},
-		pauseCh:   make(chan struct{}),
-		resumeCh:  make(chan struct{}),
-		triggerCh: make(chan string, 100),
+	s := &BackupService{
+		manager:    manager,
+		indexer:    idx,
+		db:         database,
+		ipfs:       ipfsNode,
+		jobs:       NewJobManager(database),
+		replicator: NewReplicator(manager, database),
+		mirror:     NewMirror(manager, database),
+		status:     ServiceStatus{State: StateStopped},
+		pauseCh:    make(chan struct{}),
+		resumeCh:   make(chan struct{}),
+		triggerCh:  make(chan string, 100),
+		reschedule: make(chan struct{}),
+	}
+	s.registerJobHandlers()
+	return s
+}
+
+// Config returns the settings in effect. It must not be modified; use
+// UpdateConfig.
+func (s *BackupService) Config() *config.Config {
+	return s.manager.Config()
+}
+
+// UpdateConfig changes the settings in effect. update is given a copy of
+// the current config; unless it returns an error, the copy replaces the
+// config, so readers never see one half changed.
+func (s *BackupService) UpdateConfig(update func(cfg *config.Config) error) error {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	next := *s.manager.Config()
+	if err := update(&next); err != nil {
+		return err
 	}
+	s.manager.config.Store(&next)
+	return nil
 }
 
 // Start begins the automatic backup service
 func (s *BackupService) Start(ctx context.Context) {
+	s.parentCtx = ctx
 	s.ctx, s.cancel = context.WithCancel(ctx)
 	
 	s.updateStatus(func(st *ServiceStatus) {
@@ -94,6 +142,34 @@ func (s *BackupService) Start(ctx context.Context) {
 	// Start the retry worker
 	go s.retryWorker()
 	
+	// Start replicating peer nodes
+	go s.replicationWorker()
+	
+	// Start mirroring to pinning services
+	go s.mirrorWorker()
+	
+	// Start moving assets to the cold storage tier
+	go s.tieringWorker()
+	
+	// Watch for the repository's volume going away
+	go s.storageWorker()
+	
+	// Keep wallets' Tezos Domains names current
+	go s.domainWorker()
+	
+	// Look up the profiles of creators and collections
+	go s.profileWorker()
+	
+	// Pick up assets left pending by the last run
+	if n := s.manager.EnqueuePendingAssets(); n > 0 {
+		log.Printf("Queued %d pending assets", n)
+	}
+	
+	// Resume jobs interrupted by the last shutdown
+	if n := s.jobs.ResumeUnfinished(s.ctx); n > 0 {
+		log.Printf("Resumed %d unfinished job(s)", n)
+	}
+	
 	log.Println("Backup service started")
 }
 
@@ -156,13 +232,13 @@ func (s *BackupService) run() {
 			
 		case walletAddr := <-s.triggerCh:
 			// Manual or WebSocket triggered sync for a specific wallet
-			if !s.isPaused {
+			if !s.suspended() {
 				s.syncWallet(walletAddr)
 			}
 			
 		case <-healthTicker.C:
 			// Periodic check - sync any wallets that haven't been synced in a while
-			if !s.isPaused {
+			if !s.suspended() {
 				s.performHealthCheck()
 			}
 			// Always update disk usage on health check interval too
@@ -190,7 +266,7 @@ func (s *BackupService) performCatchUpSync() {
 	})
 	
 	for i, wallet := range wallets {
-		if s.isPaused {
+		if s.suspended() {
 			break
 		}
 		
@@ -276,7 +352,7 @@ func (s *BackupService) watchWalletWithRetry(address string, crashCount int) {
 	}()
 
 	// Create a dedicated indexer for this wallet's WebSocket connection
-	idx := indexer.NewIndexer(s.config.TZKT.BaseURL)
+	idx := indexer.NewIndexer(s.Config().TZKT.BaseURL)
 	
 	// Set up the callback for when new tokens are received
 	idx.SetTokenCallback(func(token indexer.Token) {
@@ -349,7 +425,7 @@ func (s *BackupService) retryWorker() {
 		case <-s.ctx.Done():
 			return
 		case <-ticker.C:
-			if s.isPaused {
+			if s.suspended() {
 				continue
 			}
 			s.retryFailedAssets()
@@ -358,11 +434,48 @@ func (s *BackupService) retryWorker() {
 	}
 }
 
-// processPendingAssets processes assets stuck in pending status
+// replicationWorker periodically syncs the catalogs of replication peers
+func (s *BackupService) replicationWorker() {
+	// Let the catch-up sync get going first
+	s.runEvery(time.Minute, func() time.Duration {
+		return s.Config().Replication.SyncInterval
+	}, func() {
+		s.replicator.SyncAll(s.ctx)
+	})
+}
+
+// mirrorWorker periodically reconciles pinning services with the local pins
+func (s *BackupService) mirrorWorker() {
+	s.runEvery(2*time.Minute, func() time.Duration {
+		return s.Config().Mirror.ReconcileInterval
+	}, func() {
+		s.mirror.ReconcileAll(s.ctx)
+	})
+}
+
+// tieringWorker periodically moves assets matching the placement rules to the cold tier
+func (s *BackupService) tieringWorker() {
+	// The cold tier is opened with the node, so it can't be added by a reload
+	if s.Config().IPFS.Tiering.ColdPath == "" {
+		return
+	}
+	
+	s.runEvery(5*time.Minute, func() time.Duration {
+		return s.Config().IPFS.Tiering.Interval
+	}, func() {
+		result, err := s.manager.ApplyTiering(s.ctx)
+		if err != nil && s.ctx.Err() == nil {
+			log.Printf("Tiering failed: %v", err)
+		} else if result != nil && result.Moved > 0 {
+			log.Printf("Tiering: moved %d assets (%d bytes) to the cold tier", result.Moved, result.MovedBytes)
+		}
+	})
+}
+
+// processPendingAssets queues assets stuck in pending status
 func (s *BackupService) processPendingAssets() {
-	processed, pinned, failed := s.manager.ProcessPendingAssets(s.ctx, 50)
-	if processed > 0 {
-		log.Printf("Processed %d pending assets: %d pinned, %d failed", processed, pinned, failed)
+	if n := s.manager.EnqueuePendingAssets(); n > 0 {
+		log.Printf("Queued %d pending assets", n)
 	}
 }
 
@@ -391,7 +504,7 @@ func (s *BackupService) retryFailedAssets() {
 		default:
 		}
 		
-		if s.isPaused {
+		if s.suspended() {
 			return
 		}
 		
@@ -399,8 +512,7 @@ func (s *BackupService) retryFailedAssets() {
 		asset.Status = db.StatusPending
 		s.db.SaveAsset(&asset)
 		
-		// The BackupManager's processNFT will pick this up
-		// For now, we just mark them as pending and let the next sync handle them
+		// processPendingAssets queues it right after this
 	}
 }
 
@@ -446,10 +558,13 @@ func (s *BackupService) Pause() {
 func (s *BackupService) Resume() {
 	s.mu.Lock()
 	s.isPaused = false
+	storageDown := s.storageDown
 	s.mu.Unlock()
 	
-	// Resume the backup manager
-	s.manager.SetPaused(false)
+	// Resume the backup manager, unless it is waiting for the storage to return
+	if !storageDown {
+		s.manager.SetPaused(false)
+	}
 	
 	select {
 	case s.resumeCh <- struct{}{}:
@@ -472,6 +587,11 @@ func (s *BackupService) GetStatus() ServiceStatus {
 	// Merge with BackupManager progress
 	progress := s.manager.GetProgress()
 	status := s.status
+	status.QueuedAssets = s.manager.QueueStatus().Queued
+	if s.storageDown {
+		// Syncs finishing in the meantime don't clear the storage state
+		status.State = StateStorageUnavailable
+	}
 	
 	if progress.IsActive {
 		status.TotalNFTs = progress.TotalNFTs
@@ -506,9 +626,46 @@ func (s *BackupService) Stop() {
 	if s.cancel != nil {
 		s.cancel()
 	}
+	// Give running jobs a moment to record their checkpoint as interrupted
+	if !s.jobs.WaitAll(10 * time.Second) {
+		log.Println("Timed out waiting for jobs to stop")
+	}
 	s.manager.Shutdown()
 }
 
+// Jobs returns the persistent job manager
+func (s *BackupService) Jobs() *JobManager {
+	return s.jobs
+}
+
+// Replication returns the replicator that mirrors other Porcupin nodes
+func (s *BackupService) Replication() *Replicator {
+	return s.replicator
+}
+
+// Mirroring returns the mirror that copies pins to pinning services
+func (s *BackupService) Mirroring() *Mirror {
+	return s.mirror
+}
+
+// SubmitJob starts a new background job tied to the service's lifetime
+func (s *BackupService) SubmitJob(jobType string, params interface{}) (*db.Job, error) {
+	return s.jobs.Submit(s.jobContext(), jobType, params)
+}
+
+// ResumeJob restarts a failed, cancelled or interrupted job from its checkpoint
+func (s *BackupService) ResumeJob(id uint64) (*db.Job, error) {
+	return s.jobs.Resume(s.jobContext(), id)
+}
+
+// jobContext returns the context jobs run under
+func (s *BackupService) jobContext() context.Context {
+	if s.ctx != nil {
+		return s.ctx
+	}
+	return context.Background()
+}
+
 // GetManager returns the underlying backup manager
 func (s *BackupService) GetManager() *BackupManager {
 	return s.manager
@@ -538,6 +695,28 @@ func (s *BackupService) PinAsset(ctx context.Context, assetID uint64) error {
 	return s.manager.PinAssetByID(ctx, assetID)
 }
 
+// PinNext moves an asset to the front of the pin queue
+func (s *BackupService) PinNext(assetID uint64) error {
+	return s.manager.PinNext(assetID)
+}
+
+// SetWalletPriority updates a wallet's priority in the pin queue.
+// The wallet record itself is saved by the caller.
+func (s *BackupService) SetWalletPriority(address string, priority int) {
+	s.manager.SetWalletPriority(address, priority)
+}
+
+// ApplyWalletQuota picks up a changed wallet storage quota.
+// The wallet record itself is saved by the caller.
+func (s *BackupService) ApplyWalletQuota(address string) {
+	s.manager.ApplyWalletQuota(address)
+}
+
+// QueueStatus returns a snapshot of the pin queue
+func (s *BackupService) QueueStatus() QueueStatus {
+	return s.manager.QueueStatus()
+}
+
 // UnpinAsset unpins an asset by CID
 func (s *BackupService) UnpinAsset(cid string) error {
 	if s.ipfs == nil {
@@ -546,7 +725,53 @@ func (s *BackupService) UnpinAsset(cid string) error {
 	return s.ipfs.Unpin(s.ctx, cid)
 }
 
-// VerifyAndFixPins runs the verification and repair process
+// ReconcilePins compares the IPFS node's pins with the assets table and,
+// unless dryRun is set, fixes what differs. See ReconcilePins.
+func (s *BackupService) ReconcilePins(dryRun bool) (*PinReconcileReport, error) {
+	if s.ipfs == nil {
+		return nil, ErrReconcileUnavailable
+	}
+	report, err := ReconcilePins(s.ctx, s.ipfs, s.db, dryRun)
+	if report != nil && report.Unpinned > 0 {
+		s.manager.MarkDiskUsageDirty()
+	}
+	return report, err
+}
+
+// VerifyAndFixPins runs the verification and repair process as a persistent
+// job and waits for it to finish
 func (s *BackupService) VerifyAndFixPins() (map[string]int, error) {
-	return s.manager.VerifyAndFixPins(s.ctx)
+	job, err := s.SubmitJob(JobTypeVerifyAndFix, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.jobs.Wait(job.ID)
+
+	job, err = s.jobs.Get(job.ID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrJobNotFound
+	}
+
+	var cp VerifyAndFixCheckpoint
+	if job.Checkpoint != "" {
+		if err := json.Unmarshal([]byte(job.Checkpoint), &cp); err != nil {
+			return nil, fmt.Errorf("failed to decode job result: %w", err)
+		}
+	}
+	stats := map[string]int{"checked": 0, "fixed": 0, "errors": 0}
+	for k, v := range cp.Stats {
+		stats[k] = v
+	}
+
+	switch job.Status {
+	case db.JobStatusCompleted:
+		return stats, nil
+	case db.JobStatusFailed:
+		return stats, errors.New(job.Error)
+	default:
+		return stats, fmt.Errorf("job %d %s", job.ID, job.Status)
+	}
 }
@@ -0,0 +1,289 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"porcupin/backend/db"
+)
+
+// VerifyAndFixCheckpoint is the resume state of a verify_and_fix job
+type VerifyAndFixCheckpoint struct {
+	LastNFTID uint64         `json:"last_nft_id"`
+	Stats     map[string]int `json:"stats"`
+}
+
+// UnpinAllParams are the parameters of an unpin_all job
+type UnpinAllParams struct {
+	GarbageCollect bool `json:"gc"`             // Run IPFS garbage collection after unpinning
+	ClearDatabase  bool `json:"clear_database"` // Delete all NFT and asset records afterwards
+}
+
+// UnpinAllCheckpoint is the resume state of an unpin_all job
+type UnpinAllCheckpoint struct {
+	Phase    string `json:"phase"` // "unpin", "gc", "clear"
+	Unpinned int    `json:"unpinned"`
+}
+
+// DeleteWalletParams are the parameters of a delete_wallet job
+type DeleteWalletParams struct {
+	Address string `json:"address"`
+	Unpin   bool   `json:"unpin"`
+}
+
+// DeleteWalletCheckpoint is the resume state of a delete_wallet job
+type DeleteWalletCheckpoint struct {
+	LastAssetID uint64 `json:"last_asset_id"`
+	Unpinned    int    `json:"unpinned"`
+}
+
+// ReplicateParams are the parameters of a replicate job
+type ReplicateParams struct {
+	PeerID uint64 `json:"peer_id"`
+	Full   bool   `json:"full"` // Walk the whole catalog to find CIDs the peer removed
+}
+
+// RemovePeerParams are the parameters of a remove_replication_peer job
+type RemovePeerParams struct {
+	PeerID uint64 `json:"peer_id"`
+	Unpin  bool   `json:"unpin"`
+}
+
+// MirrorParams are the parameters of a mirror job
+type MirrorParams struct {
+	ServiceID uint64 `json:"service_id"`
+}
+
+// RemoveMirrorParams are the parameters of a remove_pinning_service job
+type RemoveMirrorParams struct {
+	ServiceID uint64 `json:"service_id"`
+	Unpin     bool   `json:"unpin"` // Remove the pins at the service as well
+}
+
+// unpin_all phases, in execution order
+const (
+	unpinPhaseUnpin = "unpin"
+	unpinPhaseGC    = "gc"
+	unpinPhaseClear = "clear"
+)
+
+// registerJobHandlers wires the service's long-running operations into the job manager
+func (s *BackupService) registerJobHandlers() {
+	s.jobs.RegisterHandler(JobTypeVerifyAndFix, s.runVerifyAndFixJob)
+	s.jobs.RegisterHandler(JobTypeUnpinAll, s.runUnpinAllJob)
+	s.jobs.RegisterHandler(JobTypeDeleteWallet, s.runDeleteWalletJob)
+	s.jobs.RegisterHandler(JobTypeReplicate, s.runReplicateJob)
+	s.jobs.RegisterHandler(JobTypeRemovePeer, s.runRemovePeerJob)
+	s.jobs.RegisterHandler(JobTypeMirror, s.runMirrorJob)
+	s.jobs.RegisterHandler(JobTypeRemoveMirror, s.runRemoveMirrorJob)
+}
+
+// runVerifyAndFixJob runs VerifyAndFixPins, checkpointing after every batch of NFTs
+func (s *BackupService) runVerifyAndFixJob(ctx context.Context, jc *JobContext) error {
+	var cp VerifyAndFixCheckpoint
+	if _, err := jc.Checkpoint(&cp); err != nil {
+		return err
+	}
+
+	var total int64
+	s.db.Model(&db.NFT{}).Count(&total)
+	jc.SetProgress(cp.Stats["checked"], int(total), "Verifying pins...")
+
+	stats, err := s.manager.VerifyAndFixPinsFrom(ctx, cp.LastNFTID, cp.Stats, func(lastID uint64, stats map[string]int) error {
+		if err := jc.SaveCheckpoint(VerifyAndFixCheckpoint{LastNFTID: lastID, Stats: stats}); err != nil {
+			return err
+		}
+		jc.SetProgress(stats["checked"], int(total), fmt.Sprintf("Checked %d NFTs", stats["checked"]))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	jc.SetProgress(stats["checked"], int(total), fmt.Sprintf("Checked %d NFTs, %d errors", stats["checked"], stats["errors"]))
+	return nil
+}
+
+// runUnpinAllJob unpins everything from the IPFS node, optionally followed by
+// garbage collection and clearing the database. Each phase is checkpointed;
+// unpinning itself is idempotent, so a resumed job only unpins what remains.
+func (s *BackupService) runUnpinAllJob(ctx context.Context, jc *JobContext) error {
+	if s.ipfs == nil {
+		return fmt.Errorf("IPFS node not available")
+	}
+
+	var params UnpinAllParams
+	if err := jc.Params(&params); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+
+	cp := UnpinAllCheckpoint{Phase: unpinPhaseUnpin}
+	if _, err := jc.Checkpoint(&cp); err != nil {
+		return err
+	}
+
+	if cp.Phase == unpinPhaseUnpin {
+		jc.SetProgress(0, 0, "Unpinning content...")
+		count, err := s.ipfs.UnpinAll(ctx, func(total, current int) {
+			jc.SetProgress(current, total, fmt.Sprintf("Unpinned %d of %d", current, total))
+		})
+		if err != nil {
+			return err
+		}
+		cp.Unpinned += count
+		cp.Phase = unpinPhaseGC
+		if err := jc.SaveCheckpoint(cp); err != nil {
+			return err
+		}
+	}
+
+	if cp.Phase == unpinPhaseGC {
+		if params.GarbageCollect {
+			jc.SetProgress(0, 0, "Running garbage collection...")
+			if err := s.ipfs.GarbageCollect(ctx); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				// Not fatal - space is reclaimed by the next GC
+				log.Printf("Warning: garbage collection failed: %v", err)
+			}
+		}
+		cp.Phase = unpinPhaseClear
+		if err := jc.SaveCheckpoint(cp); err != nil {
+			return err
+		}
+	}
+
+	if params.ClearDatabase {
+		jc.SetProgress(0, 0, "Clearing database...")
+		if err := s.db.Exec("DELETE FROM assets").Error; err != nil {
+			return fmt.Errorf("failed to clear assets: %w", err)
+		}
+		if err := s.db.ClearNFTs(); err != nil {
+			return fmt.Errorf("failed to clear NFTs: %w", err)
+		}
+	}
+
+	s.manager.MarkDiskUsageDirty()
+	jc.SetProgress(cp.Unpinned, cp.Unpinned, fmt.Sprintf("Unpinned %d items", cp.Unpinned))
+	return nil
+}
+
+// runDeleteWalletJob removes a wallet and, if requested, unpins its assets first.
+// Assets are walked by ID so a resumed job continues where it stopped.
+func (s *BackupService) runDeleteWalletJob(ctx context.Context, jc *JobContext) error {
+	var params DeleteWalletParams
+	if err := jc.Params(&params); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+	if params.Address == "" {
+		return fmt.Errorf("address is required")
+	}
+
+	var cp DeleteWalletCheckpoint
+	if _, err := jc.Checkpoint(&cp); err != nil {
+		return err
+	}
+
+	if params.Unpin {
+		var total int64
+		s.db.Model(&db.Asset{}).
+			Joins("JOIN nfts ON nfts.id = assets.nft_id").
+			Where("nfts.wallet_address = ?", params.Address).
+			Count(&total)
+
+		processed := cp.Unpinned
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+
+			var assets []db.Asset
+			if err := s.db.Joins("JOIN nfts ON nfts.id = assets.nft_id").
+				Where("nfts.wallet_address = ? AND assets.id > ?", params.Address, cp.LastAssetID).
+				Order("assets.id ASC").
+				Limit(100).
+				Find(&assets).Error; err != nil {
+				return fmt.Errorf("failed to get assets: %w", err)
+			}
+			if len(assets) == 0 {
+				break
+			}
+
+			for _, asset := range assets {
+				// Best effort, matching single-asset deletes
+				if cid := ExtractCIDFromURI(asset.URI); cid != "" && s.ipfs != nil {
+					if err := s.ipfs.Unpin(ctx, cid); err != nil {
+						log.Printf("Delete wallet %s: failed to unpin %s: %v", params.Address, cid, err)
+					}
+				}
+				processed++
+			}
+
+			cp.LastAssetID = assets[len(assets)-1].ID
+			cp.Unpinned = processed
+			if err := jc.SaveCheckpoint(cp); err != nil {
+				return err
+			}
+			jc.SetProgress(processed, int(total), fmt.Sprintf("Unpinned %d of %d assets", processed, total))
+		}
+
+		if err := s.db.DeleteAssetsByWallet(params.Address); err != nil {
+			return fmt.Errorf("failed to delete assets: %w", err)
+		}
+		if err := s.db.DeleteNFTsByWallet(params.Address); err != nil {
+			return fmt.Errorf("failed to delete NFTs: %w", err)
+		}
+		s.manager.MarkDiskUsageDirty()
+	}
+
+	if err := s.db.DeleteWallet(params.Address); err != nil {
+		return fmt.Errorf("failed to delete wallet: %w", err)
+	}
+
+	jc.SetProgress(cp.Unpinned, cp.Unpinned, fmt.Sprintf("Deleted wallet %s", params.Address))
+	return nil
+}
+
+// runReplicateJob syncs one replication peer. Progress lives in the replicated
+// pin records, so a resumed job simply syncs again.
+func (s *BackupService) runReplicateJob(ctx context.Context, jc *JobContext) error {
+	var params ReplicateParams
+	if err := jc.Params(&params); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+	return s.replicator.SyncPeer(ctx, params.PeerID, params.Full, jc.SetProgress)
+}
+
+// runRemovePeerJob stops replicating a peer, optionally unpinning what it replicated.
+// Pin records are deleted as they are unpinned, so a resumed job continues where it stopped.
+func (s *BackupService) runRemovePeerJob(ctx context.Context, jc *JobContext) error {
+	var params RemovePeerParams
+	if err := jc.Params(&params); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+	return s.replicator.RemovePeer(ctx, params.PeerID, params.Unpin, jc.SetProgress)
+}
+
+// runMirrorJob reconciles one pinning service. Progress lives in the remote pin
+// records, so a resumed job simply reconciles again.
+func (s *BackupService) runMirrorJob(ctx context.Context, jc *JobContext) error {
+	var params MirrorParams
+	if err := jc.Params(&params); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+	return s.mirror.ReconcileService(ctx, params.ServiceID, jc.SetProgress)
+}
+
+// runRemoveMirrorJob stops mirroring to a pinning service, optionally removing its pins.
+// Records are deleted as their pins are removed, so a resumed job continues where it stopped.
+func (s *BackupService) runRemoveMirrorJob(ctx context.Context, jc *JobContext) error {
+	var params RemoveMirrorParams
+	if err := jc.Params(&params); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+	return s.mirror.RemoveService(ctx, params.ServiceID, params.Unpin, jc.SetProgress)
+}
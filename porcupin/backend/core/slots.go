@@ -0,0 +1,89 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// errManagerShutdown is returned when the manager shuts down while waiting
+var errManagerShutdown = errors.New("backup manager shutdown")
+
+// workerSlots limits how many pins run at once. Unlike a buffered channel,
+// the limit can change while pins are running; lowering it takes effect as
+// running pins finish.
+type workerSlots struct {
+	mu    sync.Mutex
+	limit int
+	held  int
+	freed chan struct{} // Closed when a slot may have become free
+}
+
+func newWorkerSlots(limit int) *workerSlots {
+	if limit < 1 {
+		limit = 1
+	}
+	return &workerSlots{limit: limit, freed: make(chan struct{})}
+}
+
+// acquire takes a slot, waiting until one is free, ctx is done or stop is
+// closed. stop may be nil.
+func (w *workerSlots) acquire(ctx context.Context, stop <-chan struct{}) error {
+	for {
+		w.mu.Lock()
+		if w.held < w.limit {
+			w.held++
+			w.mu.Unlock()
+			return nil
+		}
+		freed := w.freed
+		w.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stop:
+			return errManagerShutdown
+		}
+	}
+}
+
+// release gives back a slot taken by acquire
+func (w *workerSlots) release() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.held--
+	w.wake()
+}
+
+// setLimit changes how many slots there are
+func (w *workerSlots) setLimit(limit int) {
+	if limit < 1 {
+		limit = 1
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.limit = limit
+	w.wake()
+}
+
+// size returns the number of slots
+func (w *workerSlots) size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.limit
+}
+
+// inUse returns the number of slots held
+func (w *workerSlots) inUse() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.held
+}
+
+// wake unblocks waiting acquirers; w.mu must be held
+func (w *workerSlots) wake() {
+	close(w.freed)
+	w.freed = make(chan struct{})
+}
@@ -0,0 +1,206 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"porcupin/backend/db"
+	"porcupin/backend/ipfs"
+	"porcupin/backend/storage"
+)
+
+// storageCheckInterval is how often the repository's volume is checked
+const storageCheckInterval = 30 * time.Second
+
+// runningChecker is implemented by IPFS clients that can be stopped
+type runningChecker interface {
+	IsRunning() bool
+}
+
+// storageUnavailable reports whether a failed pin is down to the repository
+// rather than the content, so the asset can stay pending. The storage
+// monitor is alerted when it is.
+func (bm *BackupManager) storageUnavailable() error {
+	if node, ok := bm.ipfs.(runningChecker); ok && !node.IsRunning() {
+		return fmt.Errorf("%w: IPFS node stopped", storage.ErrStorageUnavailable)
+	}
+	if err := storage.CheckAvailable(bm.ipfs.GetRepoPath(), false); err != nil {
+		select {
+		case bm.storageAlerts <- struct{}{}:
+		default:
+		}
+		return err
+	}
+	return nil
+}
+
+// holdForStorage keeps an asset pending when its pin failed because the repo
+// is unavailable, so it isn't counted against the content
+func (bm *BackupManager) holdForStorage(asset *db.Asset) error {
+	err := bm.storageUnavailable()
+	if err == nil {
+		return nil
+	}
+	asset.Status = db.StatusPending
+	asset.ErrorMsg = err.Error()
+	bm.db.SaveAsset(asset)
+	return err
+}
+
+// CheckStorage reports whether the repository and cold tier can be used
+func (s *BackupService) CheckStorage() error {
+	return checkStorage(s.ipfs, s.db)
+}
+
+// checkStorage reports whether a node's repository and cold tier can be used.
+// Once anything is pinned the repository must exist, so an empty mountpoint
+// isn't taken for a new one.
+func checkStorage(node *ipfs.Node, database *db.Database) error {
+	hasData, err := database.HasPinnedAssets()
+	if err != nil {
+		return err
+	}
+	if err := storage.CheckAvailable(node.GetRepoPath(), hasData); err != nil {
+		return err
+	}
+	if coldPath := node.GetColdPath(); coldPath != "" {
+		if err := storage.CheckAvailable(coldPath, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartIPFS starts node if its storage is available. The returned error wraps
+// storage.ErrStorageUnavailable when it isn't, in which case a running
+// BackupService starts the node once the storage returns.
+func StartIPFS(ctx context.Context, node *ipfs.Node, database *db.Database) error {
+	if err := checkStorage(node, database); err != nil {
+		return fmt.Errorf("IPFS repository unavailable: %w", err)
+	}
+	return node.Start(ctx)
+}
+
+// StorageAvailable reports whether the service is running with its storage
+// present
+func (s *BackupService) StorageAvailable() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return !s.storageDown
+}
+
+// suspended reports whether background work should wait, because the user
+// paused the service or its storage is gone
+func (s *BackupService) suspended() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.isPaused || s.storageDown
+}
+
+// storageWorker watches the repository's volume, suspending backups while it
+// is missing and resuming them when it returns
+func (s *BackupService) storageWorker() {
+	if s.ipfs == nil || s.ipfs.GetRepoPath() == "" {
+		return
+	}
+
+	ticker := time.NewTicker(storageCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.monitorStorage()
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.manager.storageAlerts:
+		}
+	}
+}
+
+// monitorStorage checks the storage once, moving the service into or out of
+// the storage unavailable state
+func (s *BackupService) monitorStorage() {
+	s.mu.RLock()
+	down, migrating := s.storageDown, s.migrating
+	s.mu.RUnlock()
+	if migrating {
+		return // The migration stops and restarts the node itself
+	}
+
+	err := s.CheckStorage()
+	switch {
+	case err != nil && !down:
+		s.storageLost(err)
+	case err != nil:
+		s.updateStatus(func(st *ServiceStatus) {
+			st.StorageError = err.Error()
+		})
+	case down || !s.ipfs.IsRunning():
+		s.storageRestored()
+	}
+}
+
+// storageLost suspends pinning and stops the IPFS node
+func (s *BackupService) storageLost(err error) {
+	log.Printf("Storage unavailable, suspending backups: %v", err)
+
+	s.mu.Lock()
+	s.storageDown = true
+	s.mu.Unlock()
+
+	s.manager.SetPaused(true)
+	s.updateStatus(func(st *ServiceStatus) {
+		st.State = StateStorageUnavailable
+		st.Message = "Storage unavailable - waiting for it to return"
+		st.StorageError = err.Error()
+	})
+
+	if err := s.ipfs.Stop(); err != nil {
+		log.Printf("Failed to stop IPFS node: %v", err)
+	}
+}
+
+// storageRestored starts the IPFS node again and resumes pinning
+func (s *BackupService) storageRestored() {
+	if err := s.ipfs.Start(s.parentCtx); err != nil {
+		log.Printf("Storage is back but the IPFS node failed to start: %v", err)
+		s.mu.Lock()
+		s.storageDown = true
+		s.mu.Unlock()
+		s.updateStatus(func(st *ServiceStatus) {
+			st.State = StateStorageUnavailable
+			st.StorageError = err.Error()
+		})
+		return
+	}
+
+	s.mu.Lock()
+	wasDown := s.storageDown
+	paused := s.isPaused
+	s.storageDown = false
+	s.mu.Unlock()
+
+	if wasDown {
+		log.Println("Storage available again, resuming backups")
+		s.manager.SetPaused(paused)
+		s.updateStatus(func(st *ServiceStatus) {
+			st.StorageError = ""
+			if paused {
+				st.State = StatePaused
+				st.Message = "Backup paused"
+			} else {
+				st.State = StateWatching
+				st.Message = "Storage available again"
+			}
+		})
+	}
+
+	// Assets held while the node was down are pinned now
+	s.manager.MarkDiskUsageDirty()
+	if n := s.manager.EnqueuePendingAssets(); n > 0 {
+		log.Printf("Queued %d pending assets", n)
+	}
+}
@@ -0,0 +1,223 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"porcupin/backend/config"
+	"porcupin/backend/storage"
+)
+
+var (
+	// ErrStorageMigrationInProgress is returned when the repository is already being moved
+	ErrStorageMigrationInProgress = errors.New("storage migration already in progress")
+	// ErrStorageMigrationUnavailable is returned when the service cannot move its repository
+	ErrStorageMigrationUnavailable = errors.New("storage migration is not available: no IPFS node or config path")
+)
+
+// quiesceTimeout is how long a storage migration waits for pins in flight
+const quiesceTimeout = 2 * time.Minute
+
+// SetConfigPath sets where the config is saved when a storage migration
+// switches the repository path. Migration is unavailable without it.
+func (s *BackupService) SetConfigPath(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configPath = path
+}
+
+// StartStorageMigration checks destPath and moves the IPFS repository there in
+// the background. Progress is available from StorageMigrationStatus.
+func (s *BackupService) StartStorageMigration(destPath string) error {
+	if err := s.beginStorageMigration(destPath); err != nil {
+		return err
+	}
+	go func() {
+		if err := s.migrateStorage(destPath, nil); err != nil {
+			log.Printf("Storage migration failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+// MigrateStorage moves the IPFS repository to destPath and waits for it to
+// finish, calling progress as it goes
+func (s *BackupService) MigrateStorage(destPath string, progress func(storage.MigrationStatus)) error {
+	if err := s.beginStorageMigration(destPath); err != nil {
+		return err
+	}
+	return s.migrateStorage(destPath, progress)
+}
+
+// StorageMigrationStatus returns the progress of the current or last storage
+// migration. A migration counts as in progress from the moment it is started
+// until the service is running again, including while the service stops
+// before the copy and restarts after it.
+func (s *BackupService) StorageMigrationStatus() storage.MigrationStatus {
+	status := storage.GetGlobalMigrationStatus()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.starting != nil {
+		return *s.starting
+	}
+	if s.migrating {
+		status.InProgress = true
+	}
+	return status
+}
+
+// CancelStorageMigration stops a storage migration. The service restarts on
+// the original repository; migrating to the same destination again resumes
+// the copy.
+func (s *BackupService) CancelStorageMigration() error {
+	return storage.CancelGlobalMigration()
+}
+
+// beginStorageMigration validates the destination and marks a migration as running
+func (s *BackupService) beginStorageMigration(destPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ipfs == nil || s.configPath == "" {
+		return ErrStorageMigrationUnavailable
+	}
+	if s.migrating {
+		return ErrStorageMigrationInProgress
+	}
+	if err := storage.ValidatePath(destPath); err != nil {
+		return fmt.Errorf("invalid destination: %w", err)
+	}
+	if expanded, _ := storage.ExpandPath(destPath); expanded == s.ipfs.GetRepoPath() {
+		return fmt.Errorf("destination is same as current location")
+	}
+	s.migrating = true
+	s.starting = &storage.MigrationStatus{
+		InProgress:  true,
+		SourcePath:  s.ipfs.GetRepoPath(),
+		DestPath:    destPath,
+		Phase:       "preparing",
+		CurrentFile: "Stopping the backup service...",
+	}
+	return nil
+}
+
+// migrateStorage stops the service and IPFS node, moves the repository
+// through storage.Manager, switches the config and restarts everything. On
+// failure the node and service restart on the original repository.
+func (s *BackupService) migrateStorage(destPath string, progress func(storage.MigrationStatus)) error {
+	defer func() {
+		s.mu.Lock()
+		s.migrating = false
+		s.starting = nil
+		s.mu.Unlock()
+	}()
+
+	s.mu.RLock()
+	configPath := s.configPath
+	s.mu.RUnlock()
+
+	ctx := s.parentCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	sourcePath := s.ipfs.GetRepoPath()
+	log.Printf("Storage migration: %s -> %s", sourcePath, destPath)
+
+	// Stop the service loops and jobs, and wait for pins in flight
+	running := s.cancel != nil
+	if running {
+		s.cancel()
+		if !s.jobs.WaitAll(10 * time.Second) {
+			log.Println("Timed out waiting for jobs to stop")
+		}
+	}
+	wasPaused := s.manager.IsPaused()
+	s.manager.SetPaused(true)
+	release := s.manager.quiesce(quiesceTimeout)
+
+	restart := func() error {
+		err := s.ipfs.Start(ctx)
+		s.manager.SetPaused(wasPaused)
+		release()
+		if running {
+			s.Start(ctx)
+		}
+		s.manager.MarkDiskUsageDirty()
+		return err
+	}
+
+	if err := s.ipfs.Stop(); err != nil {
+		restart()
+		return fmt.Errorf("failed to stop IPFS node: %w", err)
+	}
+
+	manager := storage.NewManager(sourcePath)
+	manager.SetSwitchFunc(func(newPath string) error {
+		return s.UpdateConfig(func(cfg *config.Config) error {
+			cfg.IPFS.RepoPath = newPath
+			if err := cfg.SaveConfig(configPath); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+			return nil
+		})
+	})
+
+	// From here the manager reports progress
+	s.mu.Lock()
+	s.starting = nil
+	s.mu.Unlock()
+
+	if err := manager.Migrate(ctx, destPath, progress); err != nil {
+		log.Printf("Storage migration failed, restarting at %s: %v", sourcePath, err)
+		if startErr := restart(); startErr != nil {
+			log.Printf("Failed to restart IPFS node: %v", startErr)
+		}
+		return fmt.Errorf("migration failed: %w", err)
+	}
+
+	newPath := manager.GetCurrentPath()
+	if err := s.ipfs.SetRepoPath(newPath); err != nil {
+		// The repository at sourcePath is gone, so never start there. Make
+		// sure the node is stopped and try the new location once more.
+		log.Printf("Failed to switch IPFS node to %s, retrying: %v", newPath, err)
+		if stopErr := s.ipfs.Stop(); stopErr != nil {
+			log.Printf("Failed to stop IPFS node: %v", stopErr)
+		}
+		if err := s.ipfs.SetRepoPath(newPath); err != nil {
+			// Leave the node stopped and the manager paused; the config
+			// already points at newPath for the next launch
+			release()
+			return fmt.Errorf("repository moved to %s but the IPFS node could not switch to it, restart Porcupin: %w", newPath, err)
+		}
+	}
+	if err := restart(); err != nil {
+		return fmt.Errorf("failed to start IPFS node at new location: %w", err)
+	}
+
+	log.Printf("Storage migration complete: %s -> %s", sourcePath, newPath)
+	return nil
+}
+
+// quiesce waits until no pin holds a worker slot, up to timeout, and keeps
+// the slots until the returned release function is called
+func (bm *BackupManager) quiesce(timeout time.Duration) (release func()) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	held := 0
+	for held < bm.workers.size() {
+		if err := bm.workers.acquire(ctx, nil); err != nil {
+			log.Printf("Timed out waiting for %d pin(s) in flight", bm.workers.inUse()-held)
+			break
+		}
+		held++
+	}
+	return func() {
+		for ; held > 0; held-- {
+			bm.workers.release()
+		}
+	}
+}
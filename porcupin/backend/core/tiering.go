@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"porcupin/backend/config"
+	"porcupin/backend/db"
+)
+
+// ErrTieringUnavailable is returned when no cold storage tier is configured
+var ErrTieringUnavailable = errors.New("cold storage tier not configured")
+
+// coldMover is implemented by IPFS clients with a cold storage tier
+type coldMover interface {
+	MoveToCold(ctx context.Context, cid string) (int64, error)
+}
+
+// TieringResult summarises a pass of the placement rules
+type TieringResult struct {
+	Checked    int   `json:"checked"`
+	Moved      int   `json:"moved"`
+	MovedBytes int64 `json:"moved_bytes"`
+	Failed     int   `json:"failed"`
+}
+
+// belongsCold applies the placement rules to a pinned asset. Assets of the
+// cold types go cold once they are neither small nor recently pinned.
+func belongsCold(asset db.Asset, rules config.TieringConfig, now time.Time) bool {
+	eligible := false
+	for _, t := range rules.ColdTypes {
+		if asset.Type == t {
+			eligible = true
+			break
+		}
+	}
+	if !eligible || asset.SizeBytes < rules.MinSize {
+		return false
+	}
+	return asset.PinnedAt != nil && now.Sub(*asset.PinnedAt) >= rules.MinAge
+}
+
+// ApplyTiering moves pinned assets that match the placement rules from the
+// repo to the cold tier
+func (bm *BackupManager) ApplyTiering(ctx context.Context) (*TieringResult, error) {
+	rules := bm.Config().IPFS.Tiering
+	mover, ok := bm.ipfs.(coldMover)
+	if !ok || rules.ColdPath == "" {
+		return nil, ErrTieringUnavailable
+	}
+
+	candidates, err := bm.db.GetTieringCandidates(rules.ColdTypes, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TieringResult{}
+	now := time.Now()
+	for _, asset := range candidates {
+		if !belongsCold(asset, rules, now) {
+			continue
+		}
+		cid := ExtractCIDFromURI(asset.URI)
+		if cid == "" {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		result.Checked++
+		moved, err := mover.MoveToCold(ctx, cid)
+		if err != nil {
+			log.Printf("Tiering: failed to move %s to the cold tier: %v", cid, err)
+			result.Failed++
+			continue
+		}
+		if err := bm.db.SetAssetTier(asset.ID, db.TierCold); err != nil {
+			return result, err
+		}
+		result.Moved++
+		result.MovedBytes += moved
+	}
+
+	if result.MovedBytes > 0 {
+		bm.MarkDiskUsageDirty()
+	}
+	return result, nil
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"porcupin/backend/config"
+	"porcupin/backend/db"
+	"porcupin/backend/indexer"
+)
+
+// recordMetadataVersion records the metadata a sync found for an NFT as its
+// latest version, changed at the given block level (0 if unknown). When it
+// replaces an earlier version and the policy is to unpin, the assets only
+// the earlier version used are let go.
+func (bm *BackupManager) recordMetadataVersion(ctx context.Context, nft *db.NFT, token indexer.Token, level int64) {
+	previous, err := bm.db.RecordMetadataVersion(nft.ID, level, string(token.Metadata.CanonicalJSON()))
+	if err != nil {
+		log.Printf("Could not record metadata version of %s:%s - %v", nft.ContractAddress, nft.TokenID, err)
+		return
+	}
+	if previous == nil {
+		return
+	}
+	log.Printf("Metadata of %s:%s changed since version %d (level %d)", nft.ContractAddress, nft.TokenID, previous.Version, level)
+
+	if bm.Config().Backup.PreviousVersions != config.PreviousVersionsUnpin {
+		return
+	}
+	if released := bm.releaseReplacedAssets(ctx, nft.ID, previous, token.Metadata); released > 0 {
+		bm.MarkDiskUsageDirty()
+	}
+}
+
+// releaseReplacedAssets unpins and forgets the assets of an NFT that a
+// replaced metadata version used and the current one doesn't. Assets that
+// another NFT uses are left alone. Returns the number released.
+func (bm *BackupManager) releaseReplacedAssets(ctx context.Context, nftID uint64, previous *db.MetadataVersion, current *indexer.TokenMetadata) int {
+	var old indexer.TokenMetadata
+	if err := json.Unmarshal([]byte(previous.Metadata), &old); err != nil {
+		log.Printf("Could not read metadata version %d of NFT %d: %v", previous.Version, nftID, err)
+		return 0
+	}
+	replaced := make(map[string]bool)
+	collectAssetURIs(&old, replaced)
+	inUse := make(map[string]bool)
+	collectAssetURIs(current, inUse)
+
+	released := 0
+	for uri := range replaced {
+		if inUse[uri] {
+			continue
+		}
+		asset, err := bm.db.GetAssetByURI(uri)
+		if err != nil || asset == nil || asset.NFTID != nftID {
+			continue
+		}
+		if shared, err := bm.db.URIUsedElsewhere(uri, nftID); err != nil || shared {
+			continue
+		}
+
+		// Another asset may refer to the same CID by a different URI
+		if cid := ExtractCIDFromURI(uri); cid != "" && asset.Status == db.StatusPinned {
+			shared, err := bm.db.CIDUsedByOtherAsset(cid, asset.ID)
+			if err != nil {
+				log.Printf("Could not check whether replaced asset %s is shared: %v", uri, err)
+				continue
+			}
+			if !shared {
+				if err := bm.ipfs.Unpin(ctx, cid); err != nil {
+					log.Printf("Could not unpin replaced asset %s: %v", uri, err)
+					continue
+				}
+			}
+		}
+		if err := bm.db.DeleteAsset(asset.ID); err != nil {
+			log.Printf("Could not remove replaced asset %s: %v", uri, err)
+			continue
+		}
+		log.Printf("Released asset %s of replaced metadata version %d", uri, previous.Version)
+		released++
+	}
+	return released
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"strings"
+
+	"github.com/ipfs/go-cid"
+)
+
+// CIDFromURI extracts a CID from an IPFS URI
+// Handles: ipfs://CID, ipfs://CID/path, ipfs://CID?query, /ipfs/CID, etc.
+func CIDFromURI(uri string) string {
+	var c string
+
+	// Handle ipfs:// scheme
+	if strings.HasPrefix(uri, "ipfs://") {
+		c = uri[len("ipfs://"):]
+	} else if idx := strings.Index(uri, "/ipfs/"); idx != -1 {
+		// Gateway URLs
+		c = uri[idx+len("/ipfs/"):]
+	}
+
+	// Strip query parameters (e.g., ?fxhash=...) and any trailing path
+	if i := strings.IndexAny(c, "?/"); i != -1 {
+		c = c[:i]
+	}
+	return c
+}
+
+// NormalizeCID returns the canonical form of a CID, so that a CID written in
+// another base matches the one the node lists. Strings that aren't CIDs are
+// returned unchanged.
+func NormalizeCID(s string) string {
+	c, err := cid.Decode(s)
+	if err != nil {
+		return s
+	}
+	return c.String()
+}
+
+// assetCID returns the normalized CID an asset URI points at, or "" if it has none
+func assetCID(uri string) string {
+	c := CIDFromURI(uri)
+	if c == "" {
+		return ""
+	}
+	return NormalizeCID(c)
+}
@@ -0,0 +1,240 @@
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Creator is an account that minted tracked NFTs, with the profile it
+// publishes
+type Creator struct {
+	Address          string     `gorm:"primaryKey" json:"address"`
+	Alias            string     `json:"alias"` // Name the indexer knows the account by
+	Name             string     `json:"name"`  // From the account's profile
+	Description      string     `json:"description"`
+	Website          string     `json:"website"`
+	Twitter          string     `json:"twitter"`
+	Logo             string     `json:"logo"`               // Avatar URI
+	ProfileFetchedAt *time.Time `json:"profile_fetched_at"` // nil until the profile is looked up
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// DisplayName returns the profile name, else the alias, else ""
+func (c *Creator) DisplayName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Alias
+}
+
+// Contract is an NFT contract. Marketplaces present each one as a
+// collection.
+type Contract struct {
+	Address           string     `gorm:"primaryKey" json:"address"`
+	Alias             string     `json:"alias"` // Name the indexer knows the contract by
+	Name              string     `json:"name"`  // From the contract's TZIP-16 metadata
+	Description       string     `json:"description"`
+	Website           string     `json:"website"`
+	CreatorAddress    string     `gorm:"index" json:"creator"` // Account that originated the contract
+	MetadataFetchedAt *time.Time `json:"metadata_fetched_at"`  // nil until the metadata is looked up
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// DisplayName returns the metadata name, else the alias, else ""
+func (c *Contract) DisplayName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Alias
+}
+
+// CreatorSummary is a creator and how many tracked NFTs it minted
+type CreatorSummary struct {
+	Creator
+	NFTCount int64 `json:"nft_count"`
+}
+
+// ContractSummary is a contract and how many tracked NFTs it holds
+type ContractSummary struct {
+	Contract
+	NFTCount int64 `json:"nft_count"`
+}
+
+// NoteCreator records a creator seen while syncing, with the alias the
+// indexer gave it. An empty alias doesn't clear a known one.
+func (d *Database) NoteCreator(address, alias string) error {
+	return d.noteEntity(&Creator{Address: address, Alias: alias}, "creators", address, alias, reindexCreator)
+}
+
+// NoteContract records a contract seen while syncing, with the alias the
+// indexer gave it. An empty alias doesn't clear a known one.
+func (d *Database) NoteContract(address, alias string) error {
+	return d.noteEntity(&Contract{Address: address, Alias: alias}, "contracts", address, alias, reindexContract)
+}
+
+// noteEntity inserts a creator or contract, or updates its alias if given
+// and different, updating the search rows of its NFTs when it does
+func (d *Database) noteEntity(entity interface{}, table, address, alias string, reindex func(*gorm.DB, string) error) error {
+	onConflict := clause.OnConflict{Columns: []clause.Column{{Name: "address"}}, DoNothing: true}
+	if alias != "" {
+		onConflict = clause.OnConflict{
+			Columns:   []clause.Column{{Name: "address"}},
+			DoUpdates: clause.AssignmentColumns([]string{"alias", "updated_at"}),
+			Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: table + ".alias <> excluded.alias"}}},
+		}
+	}
+	return d.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(onConflict).Create(entity)
+		if result.Error != nil || result.RowsAffected == 0 || alias == "" {
+			return result.Error
+		}
+		return reindex(tx, address)
+	})
+}
+
+// SaveCreator saves or updates a creator
+func (d *Database) SaveCreator(creator *Creator) error {
+	return d.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(creator).Error; err != nil {
+			return err
+		}
+		return reindexCreator(tx, creator.Address)
+	})
+}
+
+// SaveContract saves or updates a contract
+func (d *Database) SaveContract(contract *Contract) error {
+	return d.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(contract).Error; err != nil {
+			return err
+		}
+		return reindexContract(tx, contract.Address)
+	})
+}
+
+// GetCreator retrieves a creator and its NFT count, returning nil if it
+// doesn't exist
+func (d *Database) GetCreator(address string) (*CreatorSummary, error) {
+	creators, _, err := d.ListCreators(GroupQuery{Address: address, Limit: 1})
+	if err != nil || len(creators) == 0 {
+		return nil, err
+	}
+	return &creators[0], nil
+}
+
+// GetContract retrieves a contract and its NFT count, returning nil if it
+// doesn't exist
+func (d *Database) GetContract(address string) (*ContractSummary, error) {
+	contracts, _, err := d.ListContracts(GroupQuery{Address: address, Limit: 1})
+	if err != nil || len(contracts) == 0 {
+		return nil, err
+	}
+	return &contracts[0], nil
+}
+
+// GroupQuery selects creators or contracts to list
+type GroupQuery struct {
+	Search  string // Matches address, alias or name
+	Creator string // Contracts only: originated by this account
+	Address string // Just this one
+	Offset  int
+	Limit   int // 0 for all
+}
+
+// ListCreators returns creators with the number of tracked NFTs each
+// minted, most NFTs first, and the total matching
+func (d *Database) ListCreators(q GroupQuery) ([]CreatorSummary, int64, error) {
+	query := d.Model(&Creator{}).
+		Select("creators.*, COUNT(nfts.id) AS nft_count").
+		Joins("LEFT JOIN nfts ON nfts.creator_address = creators.address").
+		Group("creators.address")
+	query = q.filter(query, "creators")
+
+	var creators []CreatorSummary
+	total, err := q.page(query, &creators)
+	return creators, total, err
+}
+
+// ListContracts returns contracts with the number of tracked NFTs each
+// holds, most NFTs first, and the total matching
+func (d *Database) ListContracts(q GroupQuery) ([]ContractSummary, int64, error) {
+	query := d.Model(&Contract{}).
+		Select("contracts.*, COUNT(nfts.id) AS nft_count").
+		Joins("LEFT JOIN nfts ON nfts.contract_address = contracts.address").
+		Group("contracts.address")
+	query = q.filter(query, "contracts")
+	if q.Creator != "" {
+		query = query.Where("contracts.creator_address = ?", q.Creator)
+	}
+
+	var contracts []ContractSummary
+	total, err := q.page(query, &contracts)
+	return contracts, total, err
+}
+
+func (q GroupQuery) filter(query *gorm.DB, table string) *gorm.DB {
+	if q.Address != "" {
+		query = query.Where(table+".address = ?", q.Address)
+	}
+	if q.Search != "" {
+		like := "%" + q.Search + "%"
+		query = query.Where(table+".address LIKE ? OR "+table+".alias LIKE ? OR "+table+".name LIKE ?", like, like, like)
+	}
+	return query
+}
+
+// page counts the rows query groups into and loads a page of them into dest
+func (q GroupQuery) page(query *gorm.DB, dest interface{}) (int64, error) {
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return 0, err
+	}
+	query = query.Order("nft_count DESC").Order("address").Offset(q.Offset)
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+	return total, query.Scan(dest).Error
+}
+
+// StaleCreators returns creators whose profile was never looked up, or was
+// last looked up before olderThan. A zero olderThan only returns the ones
+// never looked up.
+func (d *Database) StaleCreators(olderThan time.Time, limit int) ([]Creator, error) {
+	var creators []Creator
+	err := staleQuery(d.DB, "profile_fetched_at", olderThan).Limit(limit).Find(&creators).Error
+	return creators, err
+}
+
+// StaleContracts returns contracts whose metadata was never looked up, or
+// was last looked up before olderThan. A zero olderThan only returns the
+// ones never looked up.
+func (d *Database) StaleContracts(olderThan time.Time, limit int) ([]Contract, error) {
+	var contracts []Contract
+	err := staleQuery(d.DB, "metadata_fetched_at", olderThan).Limit(limit).Find(&contracts).Error
+	return contracts, err
+}
+
+func staleQuery(db *gorm.DB, column string, olderThan time.Time) *gorm.DB {
+	if olderThan.IsZero() {
+		return db.Where(column + " IS NULL")
+	}
+	return db.Where(column+" IS NULL OR "+column+" < ?", olderThan).Order(column)
+}
+
+// noteNFTEntities records the creators and contracts of NFTs saved before
+// creators and contracts were tracked, so their profiles get looked up
+func noteNFTEntities(db *gorm.DB) error {
+	now := time.Now()
+	if err := db.Exec(`INSERT INTO creators (address, created_at, updated_at)
+		SELECT DISTINCT creator_address, ?, ? FROM nfts
+		WHERE creator_address <> '' AND creator_address NOT IN (SELECT address FROM creators)`, now, now).Error; err != nil {
+		return err
+	}
+	return db.Exec(`INSERT INTO contracts (address, created_at, updated_at)
+		SELECT DISTINCT contract_address, ?, ? FROM nfts
+		WHERE contract_address <> '' AND contract_address NOT IN (SELECT address FROM contracts)`, now, now).Error
+}
@@ -14,6 +14,12 @@ const (
 	StatusFailedUnavailable = "failed_unavailable"
 )
 
+// Asset storage tier constants
+const (
+	TierHot  = "hot"  // In the IPFS repo
+	TierCold = "cold" // Moved to the cold tier volume
+)
+
 // Database wraps gorm.DB with additional helper methods
 type Database struct {
 	*gorm.DB
@@ -28,9 +34,12 @@ func NewDatabase(db *gorm.DB) *Database {
 type Wallet struct {
 	Address         string     `gorm:"primaryKey" json:"address"`
 	Alias           string     `json:"alias"`
+	Domain          string     `json:"domain"` // Tezos Domains name the address resolves back to, if any
 	Type            string     `json:"type"` // "owned" or "created"
 	SyncOwned       bool       `json:"sync_owned" gorm:"default:true"`   // Whether to sync owned NFTs
 	SyncCreated     bool       `json:"sync_created" gorm:"default:true"` // Whether to sync created NFTs
+	Priority        int        `json:"priority" gorm:"default:0"`        // Extra pin queue turns; higher goes first
+	QuotaGB         int        `json:"quota_gb" gorm:"default:0"`        // Pinned storage allowed for this wallet (0 = unlimited)
 	LastSyncedAt    *time.Time `json:"last_synced_at"`    // When we last fully synced this wallet
 	LastSyncedLevel int64      `json:"last_synced_level"` // Blockchain level at last sync
 	LastUpdated     time.Time  `json:"last_updated"`
@@ -50,7 +59,22 @@ type NFT struct {
 	DisplayURI      string    `json:"display_uri"`   // Often a smaller preview
 	ThumbnailURI    string    `json:"thumbnail_uri"`
 	RawMetadata     string    `json:"raw_metadata"` // JSON string
+	Symbol          string     `json:"symbol,omitempty"`
+	Type            string     `json:"type,omitempty"`     // Kind of work, e.g. "Artwork"
+	Language        string     `json:"language,omitempty"` // e.g. "en"
+	Rights          string     `json:"rights,omitempty"`   // License statement, e.g. "CC-BY-4.0"
+	RightsURI       string     `json:"rights_uri,omitempty"`
+	ExternalURI     string     `json:"external_uri,omitempty"`
+	Date            *time.Time `json:"date,omitempty"` // When the work was made, per its metadata
+	MetadataVersion int        `json:"metadata_version"` // Latest MetadataVersion, 0 before one is recorded
 	Assets          []Asset   `gorm:"foreignKey:NFTID" json:"assets,omitempty"`
+	Creator         *Creator  `gorm:"foreignKey:CreatorAddress;references:Address;-:migration" json:"creator_profile,omitempty"`
+	Contract        *Contract `gorm:"foreignKey:ContractAddress;references:Address;-:migration" json:"collection,omitempty"`
+	Tags            []NFTTag       `gorm:"foreignKey:NFTID" json:"tags,omitempty"`
+	Attributes      []NFTAttribute `gorm:"foreignKey:NFTID" json:"attributes,omitempty"`
+	Royalties       []NFTRoyalty     `gorm:"foreignKey:NFTID" json:"royalties,omitempty"`
+	Contributors    []NFTContributor `gorm:"foreignKey:NFTID" json:"contributors,omitempty"`
+	Highlight       *Highlight     `gorm:"-" json:"highlight,omitempty"` // Set on search results
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 }
@@ -67,6 +91,7 @@ type Asset struct {
 	ErrorMsg   string     `json:"error_msg"` // Last error message if failed
 	SizeBytes  int64      `json:"size_bytes"`
 	RetryCount int        `json:"retry_count"`
+	Tier       string     `gorm:"default:hot;index" json:"tier"` // "hot", "cold"
 	CreatedAt  time.Time  `json:"created_at"`
 	PinnedAt   *time.Time `json:"pinned_at"`
 }
@@ -79,7 +104,7 @@ type Setting struct {
 
 // InitDB initializes the database and performs auto-migration
 func InitDB(db *gorm.DB) error {
-	if err := db.AutoMigrate(&Wallet{}, &NFT{}, &Asset{}, &Setting{}); err != nil {
+	if err := db.AutoMigrate(&Wallet{}, &NFT{}, &Asset{}, &Setting{}, &Job{}, &ReplicationPeer{}, &ReplicatedPin{}, &PinningService{}, &RemotePin{}, &APIToken{}, &RemoteServer{}, &Creator{}, &Contract{}, &NFTTag{}, &NFTAttribute{}, &NFTRoyalty{}, &NFTContributor{}, &MetadataVersion{}); err != nil {
 		return err
 	}
 
@@ -103,6 +128,61 @@ func InitDB(db *gorm.DB) error {
 		}
 	}
 
+	// Migration: Record the creators and contracts of NFTs synced before they
+	// were tracked
+	if err := db.Where("key = ?", "migration_note_creators_v1").First(&Setting{}).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return err
+		}
+		if err := noteNFTEntities(db); err != nil {
+			return err
+		}
+		if err := db.Create(&Setting{Key: "migration_note_creators_v1", Value: "true"}).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := db.Exec(createSearchTable).Error; err != nil {
+		return err
+	}
+
+	// Migration: Index NFTs for search, and sync wallets from the start so
+	// the tags and attributes of NFTs saved before they were kept are read
+	if err := db.Where("key = ?", "migration_nft_search_v1").First(&Setting{}).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return err
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := rebuildSearchIndex(tx); err != nil {
+				return err
+			}
+			if err := tx.Model(&Wallet{}).Where("1=1").Update("last_synced_level", 0).Error; err != nil {
+				return err
+			}
+			return tx.Create(&Setting{Key: "migration_nft_search_v1", Value: "true"}).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Migration: Sync wallets from the start again so the licenses,
+	// royalties and credits of NFTs saved before they were kept are read
+	if err := db.Where("key = ?", "migration_nft_metadata_v1").First(&Setting{}).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return err
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&Wallet{}).Where("1=1").Update("last_synced_level", 0).Error; err != nil {
+				return err
+			}
+			return tx.Create(&Setting{Key: "migration_nft_metadata_v1", Value: "true"}).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -126,15 +206,24 @@ func (d *Database) SetSetting(key, value string) error {
 
 // SaveNFT saves or updates an NFT (upsert by token_id + contract_address)
 func (d *Database) SaveNFT(nft *NFT) error {
-	// First try to find existing NFT
-	var existing NFT
-	err := d.Where("token_id = ? AND contract_address = ?", nft.TokenID, nft.ContractAddress).First(&existing).Error
-	if err == nil {
-		// Found existing - update it
-		nft.ID = existing.ID
-		nft.CreatedAt = existing.CreatedAt
-	}
-	return d.Save(nft).Error
+	return d.Transaction(func(tx *gorm.DB) error {
+		// First try to find existing NFT
+		var existing NFT
+		err := tx.Where("token_id = ? AND contract_address = ?", nft.TokenID, nft.ContractAddress).First(&existing).Error
+		if err == nil {
+			// Found existing - update it
+			nft.ID = existing.ID
+			nft.CreatedAt = existing.CreatedAt
+			nft.MetadataVersion = existing.MetadataVersion
+		}
+		if err := tx.Omit("Tags", "Attributes", "Royalties", "Contributors").Save(nft).Error; err != nil {
+			return err
+		}
+		if err := saveNFTDetails(tx, nft); err != nil {
+			return err
+		}
+		return indexNFT(tx, nft.ID)
+	})
 }
 
 // GetAssetByURI retrieves an asset by its URI
@@ -173,6 +262,23 @@ func (d *Database) SaveWallet(wallet *Wallet) error {
 	return d.Save(wallet).Error
 }
 
+// CreateWallet inserts a new wallet. Unlike SaveWallet, it keeps SyncOwned
+// and SyncCreated when they are false, which the column defaults would
+// otherwise turn into true.
+func (d *Database) CreateWallet(wallet *Wallet) error {
+	syncOwned, syncCreated := wallet.SyncOwned, wallet.SyncCreated
+	return d.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(wallet).Error; err != nil {
+			return err
+		}
+		wallet.SyncOwned, wallet.SyncCreated = syncOwned, syncCreated
+		return tx.Model(&Wallet{}).Where("address = ?", wallet.Address).Updates(map[string]interface{}{
+			"sync_owned":   syncOwned,
+			"sync_created": syncCreated,
+		}).Error
+	})
+}
+
 // GetPendingAssets retrieves all assets with pending status
 // If limit is 0 or negative, returns all pending assets
 func (d *Database) GetPendingAssets(limit int) ([]Asset, error) {
@@ -185,6 +291,32 @@ func (d *Database) GetPendingAssets(limit int) ([]Asset, error) {
 	return assets, err
 }
 
+// HasPinnedAssets reports whether anything has been pinned, i.e. whether an
+// IPFS repository with content should exist
+func (d *Database) HasPinnedAssets() (bool, error) {
+	var count int64
+	err := d.Model(&Asset{}).Where("status = ?", StatusPinned).Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+// GetTieringCandidates returns pinned assets of the given types that are
+// still in the hot tier, largest first
+func (d *Database) GetTieringCandidates(types []string, limit int) ([]Asset, error) {
+	var assets []Asset
+	query := d.Where("status = ? AND tier <> ? AND type IN ?", StatusPinned, TierCold, types).
+		Order("size_bytes DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	err := query.Find(&assets).Error
+	return assets, err
+}
+
+// SetAssetTier records which storage tier holds an asset
+func (d *Database) SetAssetTier(id uint64, tier string) error {
+	return d.Model(&Asset{}).Where("id = ?", id).Update("tier", tier).Error
+}
+
 // GetAssetStats returns statistics about assets
 func (d *Database) GetAssetStats() (map[string]int64, error) {
 	stats := make(map[string]int64)
@@ -216,6 +348,87 @@ func (d *Database) GetAssetStats() (map[string]int64, error) {
 	return stats, nil
 }
 
+// WalletUsage is the pinned storage referenced by one wallet's NFTs
+type WalletUsage struct {
+	Address     string `json:"address"`
+	AssetCount  int64  `json:"asset_count"`  // Distinct CIDs the wallet references
+	UsedBytes   int64  `json:"used_bytes"`   // The wallet's own CIDs plus its share of shared ones
+	SharedBytes int64  `json:"shared_bytes"` // Full size of the CIDs also referenced by other wallets
+}
+
+// GetWalletUsage returns the pinned storage referenced by each wallet.
+// Assets are grouped by CID, so URIs that point at the same content count once.
+// A CID referenced by several wallets is split evenly between them in UsedBytes,
+// which therefore adds up to the deduplicated total returned alongside, and is
+// reported in full in SharedBytes.
+func (d *Database) GetWalletUsage() (map[string]*WalletUsage, int64, error) {
+	// Assets are stored once per URI under the first NFT that referenced them;
+	// later NFTs with the same URI are matched through their own URI columns
+	var rows []struct {
+		WalletAddress string
+		URI           string
+		SizeBytes     int64
+	}
+	err := d.Raw(`
+		SELECT n.wallet_address, a.uri, a.size_bytes FROM assets a JOIN nfts n ON n.id = a.nft_id WHERE a.status = ?
+		UNION SELECT n.wallet_address, a.uri, a.size_bytes FROM assets a JOIN nfts n ON n.artifact_uri = a.uri WHERE a.status = ?
+		UNION SELECT n.wallet_address, a.uri, a.size_bytes FROM assets a JOIN nfts n ON n.display_uri = a.uri WHERE a.status = ?
+		UNION SELECT n.wallet_address, a.uri, a.size_bytes FROM assets a JOIN nfts n ON n.thumbnail_uri = a.uri WHERE a.status = ?`,
+		StatusPinned, StatusPinned, StatusPinned, StatusPinned).Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Group by CID; assets without one are keyed by their URI
+	sizes := make(map[string]int64)
+	holders := make(map[string]map[string]bool)
+	for _, r := range rows {
+		key := assetCID(r.URI)
+		if key == "" {
+			key = r.URI
+		}
+		if r.SizeBytes > sizes[key] {
+			sizes[key] = r.SizeBytes
+		}
+		if holders[key] == nil {
+			holders[key] = make(map[string]bool)
+		}
+		holders[key][r.WalletAddress] = true
+	}
+
+	var total int64
+	usage := make(map[string]*WalletUsage)
+	for key, wallets := range holders {
+		size := sizes[key]
+		total += size
+		for address := range wallets {
+			u := usage[address]
+			if u == nil {
+				u = &WalletUsage{Address: address}
+				usage[address] = u
+			}
+			u.AssetCount++
+			u.UsedBytes += size / int64(len(wallets))
+			if len(wallets) > 1 {
+				u.SharedBytes += size
+			}
+		}
+	}
+
+	return usage, total, nil
+}
+
+// CountAssetWallets returns how many wallets have an NFT that uses an asset,
+// matched the same way as GetWalletUsage
+func (d *Database) CountAssetWallets(assetID uint64) (int64, error) {
+	var count int64
+	err := d.Raw(`
+		SELECT COUNT(DISTINCT n.wallet_address) FROM assets a JOIN nfts n
+			ON n.id = a.nft_id OR n.artifact_uri = a.uri OR n.display_uri = a.uri OR n.thumbnail_uri = a.uri
+		WHERE a.id = ?`, assetID).Scan(&count).Error
+	return count, err
+}
+
 // GetAllWallets retrieves all wallets
 func (d *Database) GetAllWallets() ([]Wallet, error) {
 	var wallets []Wallet
@@ -249,6 +462,17 @@ func (d *Database) UpdateWalletSyncTime(address string, level int64) error {
 	}).Error
 }
 
+// UpdateWalletDomain records the Tezos Domains name a wallet resolves back
+// to. An alias that is empty or was the previous domain follows the new
+// one; an alias the user set is kept. A wallet that lost its domain keeps
+// its alias.
+func (d *Database) UpdateWalletDomain(address, domain string) error {
+	return d.Model(&Wallet{}).Where("address = ?", address).Updates(map[string]interface{}{
+		"alias":  gorm.Expr("CASE WHEN ? <> '' AND (alias = '' OR alias = domain) THEN ? ELSE alias END", domain, domain),
+		"domain": domain,
+	}).Error
+}
+
 // GetAssetsByWallet retrieves all assets for NFTs owned by a wallet
 func (d *Database) GetAssetsByWallet(walletAddress string) ([]Asset, error) {
 	var assets []Asset
@@ -275,7 +499,34 @@ func (d *Database) DeleteAssetsByWallet(walletAddress string) error {
 
 // DeleteNFTsByWallet deletes all NFTs for a wallet
 func (d *Database) DeleteNFTsByWallet(walletAddress string) error {
-	return d.Where("wallet_address = ?", walletAddress).Delete(&NFT{}).Error
+	return d.Transaction(func(tx *gorm.DB) error {
+		ids := tx.Model(&NFT{}).Select("id").Where("wallet_address = ?", walletAddress)
+		for _, table := range nftDetailTables {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE nft_id IN (?)", ids).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Exec("DELETE FROM nft_search WHERE rowid IN (?)", ids).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM metadata_versions WHERE nft_id IN (?)", ids).Error; err != nil {
+			return err
+		}
+		return tx.Where("wallet_address = ?", walletAddress).Delete(&NFT{}).Error
+	})
+}
+
+// ClearNFTs deletes every NFT with its metadata rows, metadata versions and
+// search rows
+func (d *Database) ClearNFTs() error {
+	return d.Transaction(func(tx *gorm.DB) error {
+		for _, table := range append(nftDetailTables, "metadata_versions", "nft_search", "nfts") {
+			if err := tx.Exec("DELETE FROM " + table).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // DeleteAsset deletes an asset by ID
@@ -283,3 +534,31 @@ func (d *Database) DeleteAsset(id uint64) error {
 	return d.Delete(&Asset{}, id).Error
 }
 
+// ResetAssetsToPending sets assets back to pending, with their retries
+// cleared, so they are pinned again. Returns the number reset.
+func (d *Database) ResetAssetsToPending(ids []uint64) (int64, error) {
+	result := d.Model(&Asset{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"status":      StatusPending,
+		"retry_count": 0,
+		"pinned_at":   nil,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// CIDUsedByOtherAsset reports whether an asset other than assetID refers to
+// a CID, which unpinning it would take from that asset too. URIs are compared
+// by the CID they point at, in any base.
+func (d *Database) CIDUsedByOtherAsset(cid string, assetID uint64) (bool, error) {
+	var uris []string
+	if err := d.Model(&Asset{}).Where("id <> ?", assetID).Pluck("uri", &uris).Error; err != nil {
+		return false, err
+	}
+	cid = NormalizeCID(cid)
+	for _, uri := range uris {
+		if assetCID(uri) == cid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
@@ -1,6 +1,10 @@
 package db
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -320,6 +324,57 @@ func TestGetRetryableAssets(t *testing.T) {
 	}
 }
 
+func TestGetTieringCandidates(t *testing.T) {
+	db := setupTestDB(t)
+
+	nft := &NFT{TokenID: "1", ContractAddress: "KT1", WalletAddress: "tz1"}
+	db.SaveNFT(nft)
+
+	assets := []Asset{
+		{URI: "ipfs://Qm1", NFTID: nft.ID, Type: "artifact", Status: StatusPinned, SizeBytes: 100},
+		{URI: "ipfs://Qm2", NFTID: nft.ID, Type: "artifact", Status: StatusPinned, SizeBytes: 300},
+		{URI: "ipfs://Qm3", NFTID: nft.ID, Type: "thumbnail", Status: StatusPinned, SizeBytes: 500},
+		{URI: "ipfs://Qm4", NFTID: nft.ID, Type: "artifact", Status: StatusPending, SizeBytes: 700},
+		{URI: "ipfs://Qm5", NFTID: nft.ID, Type: "artifact", Status: StatusPinned, SizeBytes: 900, Tier: TierCold},
+	}
+	for i := range assets {
+		db.SaveAsset(&assets[i])
+	}
+	if assets[0].Tier != TierHot {
+		t.Errorf("New asset tier = %q, want %q", assets[0].Tier, TierHot)
+	}
+
+	candidates, err := db.GetTieringCandidates([]string{"artifact"}, 0)
+	if err != nil {
+		t.Fatalf("GetTieringCandidates failed: %v", err)
+	}
+	if len(candidates) != 2 || candidates[0].URI != "ipfs://Qm2" || candidates[1].URI != "ipfs://Qm1" {
+		t.Errorf("Expected pinned hot artifacts largest first, got %+v", candidates)
+	}
+
+	if err := db.SetAssetTier(assets[1].ID, TierCold); err != nil {
+		t.Fatalf("SetAssetTier failed: %v", err)
+	}
+	candidates, _ = db.GetTieringCandidates([]string{"artifact"}, 0)
+	if len(candidates) != 1 || candidates[0].URI != "ipfs://Qm1" {
+		t.Errorf("Cold asset should no longer be a candidate, got %+v", candidates)
+	}
+}
+
+func TestHasPinnedAssets(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.SaveAsset(&Asset{URI: "ipfs://QmPending", Status: StatusPending})
+	if has, err := db.HasPinnedAssets(); err != nil || has {
+		t.Errorf("HasPinnedAssets() = %v, %v; want false with only pending assets", has, err)
+	}
+
+	db.SaveAsset(&Asset{URI: "ipfs://QmPinned", Status: StatusPinned})
+	if has, err := db.HasPinnedAssets(); err != nil || !has {
+		t.Errorf("HasPinnedAssets() = %v, %v; want true", has, err)
+	}
+}
+
 func TestGetAssetStats(t *testing.T) {
 	db := setupTestDB(t)
 
@@ -363,6 +418,81 @@ func TestGetAssetStats(t *testing.T) {
 	}
 }
 
+func TestGetWalletUsage(t *testing.T) {
+	db := setupTestDB(t)
+
+	nftA := &NFT{TokenID: "1", ContractAddress: "KT1", WalletAddress: "tz1A", ArtifactURI: "ipfs://QmShared"}
+	nftB := &NFT{TokenID: "2", ContractAddress: "KT1", WalletAddress: "tz1B", ArtifactURI: "ipfs://QmShared", ThumbnailURI: "ipfs://QmOwnB"}
+	nftA2 := &NFT{TokenID: "3", ContractAddress: "KT1", WalletAddress: "tz1A", DisplayURI: "ipfs://QmShared"}
+	nftC := &NFT{TokenID: "4", ContractAddress: "KT1", WalletAddress: "tz1C", ArtifactURI: "https://gateway.example/ipfs/QmShared/index.html"}
+	db.SaveNFT(nftA)
+	db.SaveNFT(nftB)
+	db.SaveNFT(nftA2)
+	db.SaveNFT(nftC)
+
+	assets := []Asset{
+		// Recorded under A's NFT, also used by B and by A's second NFT
+		{URI: "ipfs://QmShared", NFTID: nftA.ID, Status: StatusPinned, SizeBytes: 900},
+		// Same CID under another URI
+		{URI: "https://gateway.example/ipfs/QmShared/index.html", NFTID: nftC.ID, Status: StatusPinned, SizeBytes: 900},
+		{URI: "ipfs://QmOwnA", NFTID: nftA.ID, Status: StatusPinned, SizeBytes: 200},
+		{URI: "ipfs://QmOwnB", NFTID: nftB.ID, Status: StatusPinned, SizeBytes: 30},
+		{URI: "ipfs://QmPendingB", NFTID: nftB.ID, Status: StatusPending, SizeBytes: 5000},
+	}
+	for _, a := range assets {
+		db.SaveAsset(&a)
+	}
+
+	usage, total, err := db.GetWalletUsage()
+	if err != nil {
+		t.Fatalf("GetWalletUsage failed: %v", err)
+	}
+
+	if total != 1130 {
+		t.Errorf("Total: got %d, want 1130 (shared CID counted once)", total)
+	}
+
+	// The shared CID is split three ways, so used bytes add up to the total
+	a := usage["tz1A"]
+	if a == nil || a.UsedBytes != 500 || a.SharedBytes != 900 || a.AssetCount != 2 {
+		t.Errorf("tz1A usage = %+v, want used 500, shared 900, 2 assets", a)
+	}
+	b := usage["tz1B"]
+	if b == nil || b.UsedBytes != 330 || b.SharedBytes != 900 || b.AssetCount != 2 {
+		t.Errorf("tz1B usage = %+v, want used 330, shared 900, 2 assets", b)
+	}
+	c := usage["tz1C"]
+	if c == nil || c.UsedBytes != 300 || c.SharedBytes != 900 || c.AssetCount != 1 {
+		t.Errorf("tz1C usage = %+v, want used 300, shared 900, 1 asset", c)
+	}
+}
+
+func TestCIDFromURI(t *testing.T) {
+	const v1 = "bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi"
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{"ipfs://QmA", "QmA"},
+		{"ipfs://QmA/index.html?x=1", "QmA"},
+		{"https://gateway.example/ipfs/QmA?fxhash=1", "QmA"},
+		{"https://example.com/a.png", ""},
+		{"ipfs://", ""},
+	}
+	for _, tt := range tests {
+		if got := CIDFromURI(tt.uri); got != tt.want {
+			t.Errorf("CIDFromURI(%q) = %q, want %q", tt.uri, got, tt.want)
+		}
+	}
+
+	if got := NormalizeCID(v1); got != v1 {
+		t.Errorf("NormalizeCID(%q) = %q", v1, got)
+	}
+	if got := NormalizeCID("not-a-cid"); got != "not-a-cid" {
+		t.Errorf("NormalizeCID should leave non-CIDs unchanged, got %q", got)
+	}
+}
+
 func TestSettings(t *testing.T) {
 	db := setupTestDB(t)
 
@@ -427,6 +557,35 @@ func TestUpdateWalletSyncTime(t *testing.T) {
 	}
 }
 
+func TestUpdateWalletDomain(t *testing.T) {
+	db := setupTestDB(t)
+	db.SaveWallet(&Wallet{Address: "tz1Plain"})
+	db.SaveWallet(&Wallet{Address: "tz1Named", Alias: "My Art"})
+
+	// An empty alias follows the domain, from one name to the next
+	for _, domain := range []string{"alice.tez", "bob.tez"} {
+		if err := db.UpdateWalletDomain("tz1Plain", domain); err != nil {
+			t.Fatalf("UpdateWalletDomain failed: %v", err)
+		}
+		w, _ := db.GetWallet("tz1Plain")
+		if w.Domain != domain || w.Alias != domain {
+			t.Errorf("after %s: domain %q, alias %q", domain, w.Domain, w.Alias)
+		}
+	}
+
+	// Losing the domain keeps the alias
+	db.UpdateWalletDomain("tz1Plain", "")
+	if w, _ := db.GetWallet("tz1Plain"); w.Domain != "" || w.Alias != "bob.tez" {
+		t.Errorf("after losing the domain: domain %q, alias %q", w.Domain, w.Alias)
+	}
+
+	// An alias the user set is kept
+	db.UpdateWalletDomain("tz1Named", "carol.tez")
+	if w, _ := db.GetWallet("tz1Named"); w.Domain != "carol.tez" || w.Alias != "My Art" {
+		t.Errorf("named wallet: domain %q, alias %q", w.Domain, w.Alias)
+	}
+}
+
 func TestGetWalletNotFound(t *testing.T) {
 	db := setupTestDB(t)
 
@@ -450,3 +609,638 @@ func TestGetAssetByURINotFound(t *testing.T) {
 		t.Error("Should return nil for non-existent asset")
 	}
 }
+
+func TestJobCRUD(t *testing.T) {
+	db := setupTestDB(t)
+
+	job := &Job{Type: "verify_and_fix", Params: `{"a":1}`}
+	if err := db.CreateJob(job); err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+	if job.ID == 0 {
+		t.Fatal("CreateJob should assign an ID")
+	}
+	if job.Status != JobStatusPending {
+		t.Errorf("Status: got %q, want %q", job.Status, JobStatusPending)
+	}
+
+	job.Status = JobStatusRunning
+	job.Checkpoint = `{"last_nft_id":42}`
+	if err := db.SaveJob(job); err != nil {
+		t.Fatalf("SaveJob failed: %v", err)
+	}
+
+	retrieved, err := db.GetJob(job.ID)
+	if err != nil {
+		t.Fatalf("GetJob failed: %v", err)
+	}
+	if retrieved.Checkpoint != `{"last_nft_id":42}` {
+		t.Errorf("Checkpoint: got %q", retrieved.Checkpoint)
+	}
+	if retrieved.IsFinished() {
+		t.Error("Running job should not be finished")
+	}
+
+	missing, err := db.GetJob(9999)
+	if err != nil {
+		t.Fatalf("GetJob should not error for not found: %v", err)
+	}
+	if missing != nil {
+		t.Error("Should return nil for non-existent job")
+	}
+}
+
+func TestListAndUnfinishedJobs(t *testing.T) {
+	db := setupTestDB(t)
+
+	statuses := []string{JobStatusCompleted, JobStatusRunning, JobStatusPending, JobStatusFailed}
+	for _, status := range statuses {
+		db.CreateJob(&Job{Type: "test", Status: status})
+	}
+
+	all, err := db.ListJobs("", 0)
+	if err != nil {
+		t.Fatalf("ListJobs failed: %v", err)
+	}
+	if len(all) != 4 {
+		t.Fatalf("ListJobs: got %d, want 4", len(all))
+	}
+	if all[0].ID < all[1].ID {
+		t.Error("ListJobs should return newest first")
+	}
+
+	limited, _ := db.ListJobs("", 2)
+	if len(limited) != 2 {
+		t.Errorf("ListJobs with limit: got %d, want 2", len(limited))
+	}
+
+	failed, _ := db.ListJobs(JobStatusFailed, 0)
+	if len(failed) != 1 {
+		t.Errorf("ListJobs(failed): got %d, want 1", len(failed))
+	}
+
+	unfinished, err := db.GetUnfinishedJobs()
+	if err != nil {
+		t.Fatalf("GetUnfinishedJobs failed: %v", err)
+	}
+	if len(unfinished) != 2 {
+		t.Fatalf("GetUnfinishedJobs: got %d, want 2", len(unfinished))
+	}
+	if unfinished[0].Status != JobStatusRunning || unfinished[1].Status != JobStatusPending {
+		t.Errorf("GetUnfinishedJobs should be in creation order, got %s, %s", unfinished[0].Status, unfinished[1].Status)
+	}
+}
+
+func TestAPITokens(t *testing.T) {
+	db := setupTestDB(t)
+
+	token := &APIToken{Name: "laptop", TokenHash: "abc"}
+	if err := db.CreateAPIToken(token); err != nil {
+		t.Fatalf("CreateAPIToken failed: %v", err)
+	}
+	if err := db.CreateAPIToken(&APIToken{Name: "again", TokenHash: "abc"}); err == nil {
+		t.Error("CreateAPIToken should reject a duplicate hash")
+	}
+
+	used, err := db.UseAPIToken("abc")
+	if err != nil {
+		t.Fatalf("UseAPIToken failed: %v", err)
+	}
+	if used == nil || used.Name != "laptop" || used.LastUsedAt == nil {
+		t.Fatalf("UseAPIToken: got %+v, want laptop with its last use set", used)
+	}
+	if unknown, _ := db.UseAPIToken("xyz"); unknown != nil {
+		t.Error("UseAPIToken should return nil for an unknown hash")
+	}
+
+	tokens, _ := db.GetAPITokens()
+	if len(tokens) != 1 || tokens[0].LastUsedAt == nil {
+		t.Fatalf("GetAPITokens: got %+v", tokens)
+	}
+
+	deleted, err := db.DeleteAPIToken(token.ID)
+	if err != nil || !deleted {
+		t.Fatalf("DeleteAPIToken: got %v, %v", deleted, err)
+	}
+	if revoked, _ := db.UseAPIToken("abc"); revoked != nil {
+		t.Error("a revoked token should no longer be found")
+	}
+	if deleted, _ := db.DeleteAPIToken(token.ID); deleted {
+		t.Error("DeleteAPIToken should report a missing token")
+	}
+}
+
+func TestSecretBox(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SecretKeyFileName)
+	box, err := LoadOrCreateSecretBox(path)
+	if err != nil {
+		t.Fatalf("LoadOrCreateSecretBox failed: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("key file: %v, %v; want mode 0600", info, err)
+	}
+
+	sealed, err := box.Seal("prcpn_secret")
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if strings.Contains(sealed, "prcpn_secret") {
+		t.Error("sealed value contains the plaintext")
+	}
+
+	// The same key file opens it again
+	reloaded, _ := LoadOrCreateSecretBox(path)
+	if opened, err := reloaded.Open(sealed); err != nil || opened != "prcpn_secret" {
+		t.Errorf("Open: got %q, %v", opened, err)
+	}
+
+	other, _ := LoadOrCreateSecretBox(filepath.Join(t.TempDir(), SecretKeyFileName))
+	if _, err := other.Open(sealed); err == nil {
+		t.Error("Open with another key should fail")
+	}
+	if empty, _ := box.Seal(""); empty != "" {
+		t.Errorf("Seal(\"\") = %q, want empty", empty)
+	}
+}
+
+func TestRemoteServers(t *testing.T) {
+	db := setupTestDB(t)
+
+	home := &RemoteServer{Label: "Home", Host: "nas.local", Port: 8085, UseTLS: true, SealedToken: "v1:x"}
+	office := &RemoteServer{Host: "10.0.0.2", Port: 8085}
+	for _, server := range []*RemoteServer{home, office} {
+		if err := db.SaveRemoteServer(server); err != nil {
+			t.Fatalf("SaveRemoteServer failed: %v", err)
+		}
+	}
+	if err := db.SaveRemoteServer(&RemoteServer{Host: "nas.local", Port: 8085}); err == nil {
+		t.Error("SaveRemoteServer should reject a duplicate address")
+	}
+
+	now := time.Now()
+	office.LastConnectedAt = &now
+	db.SaveRemoteServer(office)
+
+	servers, err := db.GetRemoteServers()
+	if err != nil || len(servers) != 2 || servers[0].ID != office.ID {
+		t.Fatalf("GetRemoteServers: got %+v, %v; want office first", servers, err)
+	}
+
+	found, _ := db.GetRemoteServerByAddress("nas.local", 8085)
+	if found == nil || found.ID != home.ID || found.SealedToken != "v1:x" {
+		t.Errorf("GetRemoteServerByAddress: got %+v", found)
+	}
+
+	if err := db.DeleteRemoteServer(home.ID); err != nil {
+		t.Fatalf("DeleteRemoteServer failed: %v", err)
+	}
+	if missing, _ := db.GetRemoteServer(home.ID); missing != nil {
+		t.Error("a deleted server should no longer be found")
+	}
+}
+
+func TestNoteCreatorAndContract(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.NoteCreator("tz1artist", "")
+	db.NoteCreator("tz1artist", "Artist")
+	db.NoteCreator("tz1artist", "") // Doesn't clear the alias
+	db.NoteContract("KT1hen", "hic et nunc")
+
+	creator, err := db.GetCreator("tz1artist")
+	if err != nil || creator == nil {
+		t.Fatalf("GetCreator = %v, %v", creator, err)
+	}
+	if creator.Alias != "Artist" || creator.DisplayName() != "Artist" {
+		t.Errorf("creator alias %q, display name %q", creator.Alias, creator.DisplayName())
+	}
+	if contract, _ := db.GetContract("KT1hen"); contract == nil || contract.Alias != "hic et nunc" {
+		t.Errorf("contract = %+v", contract)
+	}
+	if missing, err := db.GetCreator("tz1nobody"); err != nil || missing != nil {
+		t.Errorf("GetCreator(missing) = %v, %v", missing, err)
+	}
+}
+
+func TestListCreatorsAndContracts(t *testing.T) {
+	db := setupTestDB(t)
+	db.SaveCreator(&Creator{Address: "tz1a", Name: "Alice"})
+	db.SaveCreator(&Creator{Address: "tz1b", Alias: "bob"})
+	db.SaveCreator(&Creator{Address: "tz1c"})
+	db.SaveContract(&Contract{Address: "KT1x", Name: "Alice's Series", CreatorAddress: "tz1a"})
+	db.SaveContract(&Contract{Address: "KT1y", Alias: "Objkt"})
+	for i, n := range []struct{ creator, contract string }{
+		{"tz1a", "KT1x"}, {"tz1a", "KT1x"}, {"tz1a", "KT1y"}, {"tz1b", "KT1y"},
+	} {
+		db.SaveNFT(&NFT{TokenID: string(rune('0' + i)), ContractAddress: n.contract, CreatorAddress: n.creator})
+	}
+
+	creators, total, err := db.ListCreators(GroupQuery{})
+	if err != nil {
+		t.Fatalf("ListCreators failed: %v", err)
+	}
+	if total != 3 || len(creators) != 3 {
+		t.Fatalf("got %d of %d creators, want 3", len(creators), total)
+	}
+	want := []struct {
+		address string
+		count   int64
+	}{{"tz1a", 3}, {"tz1b", 1}, {"tz1c", 0}}
+	for i, w := range want {
+		if creators[i].Address != w.address || creators[i].NFTCount != w.count {
+			t.Errorf("creators[%d] = %s with %d NFTs, want %s with %d", i, creators[i].Address, creators[i].NFTCount, w.address, w.count)
+		}
+	}
+
+	// Paging keeps the total
+	page, total, _ := db.ListCreators(GroupQuery{Offset: 1, Limit: 1})
+	if total != 3 || len(page) != 1 || page[0].Address != "tz1b" {
+		t.Errorf("second page = %+v of %d", page, total)
+	}
+
+	// Search matches alias and name
+	if found, _, _ := db.ListCreators(GroupQuery{Search: "bo"}); len(found) != 1 || found[0].Address != "tz1b" {
+		t.Errorf("search bo = %+v", found)
+	}
+
+	contracts, _, err := db.ListContracts(GroupQuery{Creator: "tz1a"})
+	if err != nil {
+		t.Fatalf("ListContracts failed: %v", err)
+	}
+	if len(contracts) != 1 || contracts[0].Address != "KT1x" || contracts[0].NFTCount != 2 {
+		t.Errorf("contracts of tz1a = %+v", contracts)
+	}
+	if c, _ := db.GetContract("KT1y"); c == nil || c.NFTCount != 2 || c.DisplayName() != "Objkt" {
+		t.Errorf("GetContract(KT1y) = %+v", c)
+	}
+
+	// NFTs load their creator and contract
+	var nft NFT
+	db.Preload("Creator").Preload("Contract").Where("contract_address = ?", "KT1x").First(&nft)
+	if nft.Creator == nil || nft.Creator.Name != "Alice" || nft.Contract == nil || nft.Contract.Name != "Alice's Series" {
+		t.Errorf("NFT relations = %+v, %+v", nft.Creator, nft.Contract)
+	}
+}
+
+func TestStaleCreatorsAndContracts(t *testing.T) {
+	db := setupTestDB(t)
+	old, recent := time.Now().Add(-48*time.Hour), time.Now()
+	db.SaveCreator(&Creator{Address: "tz1new"})
+	db.SaveCreator(&Creator{Address: "tz1old", ProfileFetchedAt: &old})
+	db.SaveCreator(&Creator{Address: "tz1fresh", ProfileFetchedAt: &recent})
+	db.SaveContract(&Contract{Address: "KT1new"})
+	db.SaveContract(&Contract{Address: "KT1fresh", MetadataFetchedAt: &recent})
+
+	addresses := func(creators []Creator) []string {
+		var out []string
+		for _, c := range creators {
+			out = append(out, c.Address)
+		}
+		return out
+	}
+
+	stale, _ := db.StaleCreators(time.Now().Add(-24*time.Hour), 10)
+	if got := strings.Join(addresses(stale), ","); got != "tz1new,tz1old" {
+		t.Errorf("stale creators = %s, want tz1new,tz1old", got)
+	}
+	stale, _ = db.StaleCreators(time.Time{}, 10)
+	if got := strings.Join(addresses(stale), ","); got != "tz1new" {
+		t.Errorf("never looked up = %s, want tz1new", got)
+	}
+	if contracts, _ := db.StaleContracts(time.Now().Add(-time.Hour), 10); len(contracts) != 1 || contracts[0].Address != "KT1new" {
+		t.Errorf("stale contracts = %+v", contracts)
+	}
+}
+
+func TestNoteNFTEntities(t *testing.T) {
+	db := setupTestDB(t)
+	db.SaveNFT(&NFT{TokenID: "1", ContractAddress: "KT1a", CreatorAddress: "tz1a"})
+	db.SaveNFT(&NFT{TokenID: "2", ContractAddress: "KT1a", CreatorAddress: "tz1b"})
+	db.SaveNFT(&NFT{TokenID: "3", ContractAddress: "KT1b"})
+	db.NoteCreator("tz1a", "Known")
+
+	if err := noteNFTEntities(db.DB); err != nil {
+		t.Fatalf("noteNFTEntities failed: %v", err)
+	}
+
+	creators, total, _ := db.ListCreators(GroupQuery{})
+	if total != 2 {
+		t.Errorf("creators = %+v, want tz1a and tz1b", creators)
+	}
+	if c, _ := db.GetCreator("tz1a"); c == nil || c.Alias != "Known" {
+		t.Errorf("existing creator = %+v, want its alias kept", c)
+	}
+	if _, total, _ := db.ListContracts(GroupQuery{}); total != 2 {
+		t.Errorf("contracts = %d, want 2", total)
+	}
+}
+
+// searchIDs returns the IDs of the NFTs a search finds, best first
+func searchIDs(t *testing.T, db *Database, q SearchQuery) []uint64 {
+	t.Helper()
+	hits, total, err := db.SearchNFTs(q)
+	if err != nil {
+		t.Fatalf("SearchNFTs(%q) failed: %v", q.Text, err)
+	}
+	if q.Limit == 0 && int(total) != len(hits) {
+		t.Errorf("SearchNFTs(%q) total %d, %d hits", q.Text, total, len(hits))
+	}
+	ids := make([]uint64, len(hits))
+	for i, h := range hits {
+		ids[i] = h.NFTID
+	}
+	return ids
+}
+
+func TestSearchNFTs(t *testing.T) {
+	db := setupTestDB(t)
+	db.NoteCreator("tz1alice", "Alice")
+	db.NoteContract("KT1objkt", "objkt.com")
+
+	inName := &NFT{TokenID: "1", ContractAddress: "KT1objkt", CreatorAddress: "tz1alice", WalletAddress: "tz1w",
+		Name: "Ocean Waves", Description: "A study in blue"}
+	inDescription := &NFT{TokenID: "2", ContractAddress: "KT1other", CreatorAddress: "tz1bob", WalletAddress: "tz1w",
+		Name: "Untitled", Description: "Waves <breaking> on rocks & sand, with a café by the shore"}
+	tagged := &NFT{TokenID: "3", ContractAddress: "KT1other", CreatorAddress: "tz1bob", WalletAddress: "tz1other",
+		Name: "Grid", Tags: []NFTTag{{Tag: "generative"}, {Tag: "plotter"}},
+		Attributes: []NFTAttribute{{Name: "Palette", Value: "Sunset"}}}
+	for _, nft := range []*NFT{inName, inDescription, tagged} {
+		if err := db.SaveNFT(nft); err != nil {
+			t.Fatalf("SaveNFT failed: %v", err)
+		}
+	}
+
+	// A match in the name ranks above one in the description
+	if got := searchIDs(t, db, SearchQuery{Text: "waves"}); fmt.Sprint(got) != fmt.Sprint([]uint64{inName.ID, inDescription.ID}) {
+		t.Errorf("waves = %v, want name match first", got)
+	}
+
+	// Tags, attributes, aliases and addresses are searched, words match
+	// as prefixes and accents are ignored
+	for text, want := range map[string]uint64{
+		"gener":         tagged.ID,
+		"sunset":        tagged.ID,
+		"alice":         inName.ID,
+		"objkt":         inName.ID,
+		"tz1ali":        inName.ID,
+		"cafe":          inDescription.ID,
+		"waves rocks":   inDescription.ID,
+		`"plotter`:      tagged.ID,
+		"grid OR ocean": 0, // Operators are taken as words
+	} {
+		got := searchIDs(t, db, SearchQuery{Text: text})
+		if want == 0 && len(got) != 0 || want != 0 && (len(got) != 1 || got[0] != want) {
+			t.Errorf("%s = %v, want %d", text, got, want)
+		}
+	}
+	if hits, _, err := db.SearchNFTs(SearchQuery{Text: ` " * `}); err != nil || hits != nil {
+		t.Errorf("search without words = %v, %v", hits, err)
+	}
+
+	// Filters narrow the NFTs searched
+	filter := func(q *gorm.DB) *gorm.DB { return q.Where("nfts.wallet_address = ?", "tz1other") }
+	if got := searchIDs(t, db, SearchQuery{Text: "grid", Filter: filter}); len(got) != 1 {
+		t.Errorf("filtered search = %v", got)
+	}
+
+	// Highlights mark the matches in escaped text
+	hits, _, _ := db.SearchNFTs(SearchQuery{Text: "breaking"})
+	nfts, err := db.NFTsForHits(hits)
+	if err != nil || len(nfts) != 1 || nfts[0].Highlight == nil {
+		t.Fatalf("NFTsForHits = %+v, %v", nfts, err)
+	}
+	if d := nfts[0].Highlight.Description; !strings.Contains(d, "&lt;<mark>breaking</mark>&gt; on rocks &amp; sand") {
+		t.Errorf("highlighted description = %q", d)
+	}
+
+	// Renaming a creator reaches its NFTs' rows
+	db.SaveCreator(&Creator{Address: "tz1bob", Name: "Roberta"})
+	if got := searchIDs(t, db, SearchQuery{Text: "roberta"}); len(got) != 2 {
+		t.Errorf("roberta = %v, want bob's two NFTs", got)
+	}
+
+	// MatchingNFTIDs filters other queries
+	var ids []uint64
+	db.Model(&NFT{}).Where("id IN (?)", db.MatchingNFTIDs("sunset")).Pluck("id", &ids)
+	if len(ids) != 1 || ids[0] != tagged.ID {
+		t.Errorf("MatchingNFTIDs(sunset) = %v", ids)
+	}
+
+	// Deleting a wallet's NFTs drops them from the index
+	db.DeleteNFTsByWallet("tz1other")
+	if got := searchIDs(t, db, SearchQuery{Text: "grid"}); len(got) != 0 {
+		t.Errorf("grid after deleting = %v", got)
+	}
+}
+
+func TestSaveNFTReplacesTagsAndAttributes(t *testing.T) {
+	db := setupTestDB(t)
+	db.SaveNFT(&NFT{TokenID: "1", ContractAddress: "KT1a", Name: "First",
+		Tags: []NFTTag{{Tag: "old"}}, Attributes: []NFTAttribute{{Name: "Mood", Value: "Calm"}}})
+	db.SaveNFT(&NFT{TokenID: "1", ContractAddress: "KT1a", Name: "Second", Tags: []NFTTag{{Tag: "new"}, {Tag: "fresh"}}})
+
+	var nft NFT
+	db.Preload("Tags").Preload("Attributes").First(&nft)
+	if nft.Name != "Second" || len(nft.Tags) != 2 || nft.Tags[0].Tag != "new" || len(nft.Attributes) != 0 {
+		t.Errorf("NFT = %+v", nft)
+	}
+	if got := searchIDs(t, db, SearchQuery{Text: "old"}); len(got) != 0 {
+		t.Errorf("old tag still found: %v", got)
+	}
+	if got := searchIDs(t, db, SearchQuery{Text: "fresh second"}); len(got) != 1 {
+		t.Errorf("fresh second = %v", got)
+	}
+}
+
+func TestRebuildSearchIndex(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&NFT{TokenID: "1", ContractAddress: "KT1a", Name: "Saved before the index"})
+
+	if got := searchIDs(t, db, SearchQuery{Text: "saved"}); len(got) != 0 {
+		t.Fatalf("unindexed NFT found: %v", got)
+	}
+	if err := rebuildSearchIndex(db.DB); err != nil {
+		t.Fatalf("rebuildSearchIndex failed: %v", err)
+	}
+	if got := searchIDs(t, db, SearchQuery{Text: "saved"}); len(got) != 1 {
+		t.Errorf("after rebuilding = %v", got)
+	}
+}
+
+func TestSaveNFTMetadataDetails(t *testing.T) {
+	db := setupTestDB(t)
+	date := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	db.SaveNFT(&NFT{TokenID: "1", ContractAddress: "KT1a", Rights: "CC-BY-4.0", Date: &date,
+		Royalties:    []NFTRoyalty{{Address: "tz1alice", Share: 0.1}},
+		Contributors: []NFTContributor{{Role: RoleCreator, Name: "tz1alice"}, {Role: RolePublisher, Name: "Studio"}}})
+
+	var nft NFT
+	db.Preload("Royalties").Preload("Contributors").First(&nft)
+	if nft.Rights != "CC-BY-4.0" || nft.Date == nil || !nft.Date.Equal(date) {
+		t.Errorf("NFT = %+v", nft)
+	}
+	if len(nft.Royalties) != 1 || nft.Royalties[0].Share != 0.1 || len(nft.Contributors) != 2 || nft.Contributors[1].Name != "Studio" {
+		t.Errorf("royalties = %+v, contributors = %+v", nft.Royalties, nft.Contributors)
+	}
+
+	// Saving again replaces them, and deleting the wallet's NFTs removes them
+	db.SaveNFT(&NFT{TokenID: "1", ContractAddress: "KT1a", WalletAddress: "tz1w",
+		Contributors: []NFTContributor{{Role: RoleContributor, Name: "Bob"}}})
+	var royalties, contributors int64
+	db.Model(&NFTRoyalty{}).Count(&royalties)
+	db.Model(&NFTContributor{}).Count(&contributors)
+	if royalties != 0 || contributors != 1 {
+		t.Errorf("after saving again: %d royalties, %d contributors", royalties, contributors)
+	}
+	db.DeleteNFTsByWallet("tz1w")
+	db.Model(&NFTContributor{}).Count(&contributors)
+	if contributors != 0 {
+		t.Errorf("after deleting: %d contributors", contributors)
+	}
+}
+
+func TestHasTagAndHasAttribute(t *testing.T) {
+	db := setupTestDB(t)
+	blue := &NFT{TokenID: "1", ContractAddress: "KT1a", Name: "Blue",
+		Tags: []NFTTag{{Tag: "Generative"}}, Attributes: []NFTAttribute{{Name: "Palette", Value: "Blue"}}}
+	red := &NFT{TokenID: "2", ContractAddress: "KT1a", Name: "Red",
+		Tags: []NFTTag{{Tag: "photo"}}, Attributes: []NFTAttribute{{Name: "Palette", Value: "Red"}}}
+	db.SaveNFT(blue)
+	db.SaveNFT(red)
+
+	names := func(filter func(*gorm.DB) *gorm.DB) string {
+		var got []string
+		filter(db.Model(&NFT{})).Order("id").Pluck("name", &got)
+		return strings.Join(got, ",")
+	}
+	for desc, tt := range map[string]struct {
+		filter func(*gorm.DB) *gorm.DB
+		want   string
+	}{
+		"tag, ignoring case":     {HasTag("generative"), "Blue"},
+		"unknown tag":            {HasTag("gen"), ""},
+		"attribute with a value": {HasAttribute("palette", "RED"), "Red"},
+		"attribute by name":      {HasAttribute("Palette", ""), "Blue,Red"},
+	} {
+		if got := names(tt.filter); got != tt.want {
+			t.Errorf("%s: %q, want %q", desc, got, tt.want)
+		}
+	}
+
+	// Filters apply to searches too
+	hits, _, _ := db.SearchNFTs(SearchQuery{Text: "palette", Filter: HasTag("photo")})
+	if len(hits) != 1 || hits[0].NFTID != red.ID {
+		t.Errorf("search palette tagged photo = %+v", hits)
+	}
+	hits, _, _ = db.SearchNFTs(SearchQuery{Text: "palette", Filter: HasAttribute("palette", "blue")})
+	if len(hits) != 1 || hits[0].NFTID != blue.ID {
+		t.Errorf("search palette with palette blue = %+v", hits)
+	}
+}
+
+func TestRecordMetadataVersion(t *testing.T) {
+	db := setupTestDB(t)
+	nft := &NFT{TokenID: "1", ContractAddress: "KT1a", WalletAddress: "tz1w", ArtifactURI: "ipfs://QmA"}
+	db.SaveNFT(nft)
+
+	v1 := `{"artifactUri":"ipfs://QmA","name":"One"}`
+	v2 := `{"artifactUri":"ipfs://QmB","name":"One","tags":["new"]}`
+	for i, tt := range []struct {
+		metadata     string
+		wantPrevious int
+	}{
+		{v1, 0}, // First version
+		{v1, 0}, // Unchanged
+		{v2, 1},
+	} {
+		previous, err := db.RecordMetadataVersion(nft.ID, int64(100*(i+1)), tt.metadata)
+		if err != nil {
+			t.Fatalf("RecordMetadataVersion %d failed: %v", i, err)
+		}
+		got := 0
+		if previous != nil {
+			got = previous.Version
+		}
+		if got != tt.wantPrevious {
+			t.Errorf("record %d replaced version %d, want %d", i, got, tt.wantPrevious)
+		}
+	}
+
+	history, err := db.MetadataHistory(nft.ID)
+	if err != nil || len(history) != 2 {
+		t.Fatalf("history = %+v, %v; want 2 versions", history, err)
+	}
+	if history[0].Level != 100 || len(history[0].Changes) != 2 {
+		t.Errorf("first version = %+v, want level 100 with every field", history[0])
+	}
+	changes := history[1].Changes
+	if history[1].Level != 300 || len(changes) != 2 ||
+		changes[0].Field != "artifactUri" || string(changes[0].Old) != `"ipfs://QmA"` || string(changes[0].New) != `"ipfs://QmB"` ||
+		changes[1].Field != "tags" || changes[1].Old != nil {
+		t.Errorf("second version = %+v", history[1])
+	}
+
+	// Saving the NFT keeps its version number, and deleting it drops its versions
+	db.SaveNFT(&NFT{TokenID: "1", ContractAddress: "KT1a", WalletAddress: "tz1w", ArtifactURI: "ipfs://QmB"})
+	var saved NFT
+	db.First(&saved, nft.ID)
+	if saved.MetadataVersion != 2 {
+		t.Errorf("metadata version after saving = %d, want 2", saved.MetadataVersion)
+	}
+	db.DeleteNFTsByWallet("tz1w")
+	if versions, _ := db.MetadataVersions(nft.ID); len(versions) != 0 {
+		t.Errorf("after deleting: %d versions", len(versions))
+	}
+}
+
+func TestURIUsedElsewhere(t *testing.T) {
+	db := setupTestDB(t)
+	a := &NFT{TokenID: "1", ContractAddress: "KT1a", ArtifactURI: "ipfs://QmA"}
+	b := &NFT{TokenID: "2", ContractAddress: "KT1a", DisplayURI: "ipfs://QmShown"}
+	db.SaveNFT(a)
+	db.SaveNFT(b)
+	db.RecordMetadataVersion(b.ID, 1, `{"displayUri":"ipfs://QmOld"}`)
+	db.RecordMetadataVersion(b.ID, 2, `{"displayUri":"ipfs://QmShown","formats":[{"uri":"ipfs://QmFormat"}]}`)
+
+	for uri, want := range map[string]bool{
+		"ipfs://QmA":      false, // Only a uses it
+		"ipfs://QmShown":  true,
+		"ipfs://QmFormat": true,  // In b's latest metadata
+		"ipfs://QmOld":    false, // Only in a version b replaced
+	} {
+		if got, err := db.URIUsedElsewhere(uri, a.ID); err != nil || got != want {
+			t.Errorf("URIUsedElsewhere(%s) = %v, %v; want %v", uri, got, err, want)
+		}
+	}
+}
+
+func TestResetAssetsAndSharedCIDs(t *testing.T) {
+	db := setupTestDB(t)
+	now := time.Now()
+	a := &Asset{NFTID: 1, URI: "ipfs://QmA", Status: StatusPinned, RetryCount: 2, PinnedAt: &now}
+	b := &Asset{NFTID: 2, URI: "ipfs://QmA/index.html", Status: StatusPinned}
+	c := &Asset{NFTID: 2, URI: "ipfs://QmC", Status: StatusPinned}
+	// A CID that merely starts with c's isn't the same content
+	d := &Asset{NFTID: 3, URI: "https://gateway.example/ipfs/QmCx", Status: StatusPinned}
+	for _, asset := range []*Asset{a, b, c, d} {
+		db.SaveAsset(asset)
+	}
+
+	if shared, err := db.CIDUsedByOtherAsset("QmA", a.ID); err != nil || !shared {
+		t.Errorf("CIDUsedByOtherAsset(QmA) = %v, %v; want true", shared, err)
+	}
+	if shared, err := db.CIDUsedByOtherAsset("QmC", c.ID); err != nil || shared {
+		t.Errorf("CIDUsedByOtherAsset(QmC) = %v, %v; want false", shared, err)
+	}
+
+	if n, err := db.ResetAssetsToPending([]uint64{a.ID, c.ID}); err != nil || n != 2 {
+		t.Fatalf("ResetAssetsToPending = %d, %v; want 2", n, err)
+	}
+	reset, _ := db.GetAssetByID(a.ID)
+	if reset.Status != StatusPending || reset.RetryCount != 0 || reset.PinnedAt != nil {
+		t.Errorf("reset asset = %+v", reset)
+	}
+	if kept, _ := db.GetAssetByID(b.ID); kept.Status != StatusPinned {
+		t.Errorf("untouched asset status = %s", kept.Status)
+	}
+}
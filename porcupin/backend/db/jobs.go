@@ -0,0 +1,89 @@
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Job status constants
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+// Job represents a long-running operation that survives restarts.
+// Handlers store their progress in Checkpoint so an interrupted job can resume.
+type Job struct {
+	ID         uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Type       string     `gorm:"index" json:"type"`   // e.g. "verify_and_fix", "unpin_all", "delete_wallet"
+	Status     string     `gorm:"index" json:"status"` // "pending", "running", "completed", "failed", "cancelled"
+	Params     string     `json:"params"`              // JSON-encoded job parameters
+	Checkpoint string     `json:"checkpoint"`          // JSON-encoded resume state (e.g. last NFT id processed)
+	Current    int        `json:"current"`             // Items processed so far
+	Total      int        `json:"total"`               // Total items, 0 if unknown
+	Message    string     `json:"message"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// IsFinished reports whether the job has reached a terminal state
+func (j *Job) IsFinished() bool {
+	return j.Status == JobStatusCompleted || j.Status == JobStatusFailed || j.Status == JobStatusCancelled
+}
+
+// CreateJob inserts a new pending job
+func (d *Database) CreateJob(job *Job) error {
+	if job.Status == "" {
+		job.Status = JobStatusPending
+	}
+	return d.Create(job).Error
+}
+
+// GetJob retrieves a job by ID, returning nil if it does not exist
+func (d *Database) GetJob(id uint64) (*Job, error) {
+	var job Job
+	err := d.First(&job, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// SaveJob saves or updates a job
+func (d *Database) SaveJob(job *Job) error {
+	return d.Save(job).Error
+}
+
+// ListJobs returns jobs newest first, optionally filtered by status.
+// If limit is 0 or negative, returns all matching jobs.
+func (d *Database) ListJobs(status string, limit int) ([]Job, error) {
+	var jobs []Job
+	query := d.Order("id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	err := query.Find(&jobs).Error
+	return jobs, err
+}
+
+// GetUnfinishedJobs returns pending and running jobs in creation order
+func (d *Database) GetUnfinishedJobs() ([]Job, error) {
+	var jobs []Job
+	err := d.Where("status IN ?", []string{JobStatusPending, JobStatusRunning}).
+		Order("id ASC").
+		Find(&jobs).Error
+	return jobs, err
+}
@@ -0,0 +1,110 @@
+package db
+
+import "gorm.io/gorm"
+
+// NFTTag is a tag from an NFT's metadata
+type NFTTag struct {
+	ID    uint64 `gorm:"primaryKey;autoIncrement" json:"-"`
+	NFTID uint64 `gorm:"index" json:"-"`
+	Tag   string `gorm:"index" json:"tag"`
+}
+
+// NFTAttribute is a trait from an NFT's metadata
+type NFTAttribute struct {
+	ID    uint64 `gorm:"primaryKey;autoIncrement" json:"-"`
+	NFTID uint64 `gorm:"index" json:"-"`
+	Name  string `gorm:"index" json:"name"`
+	Value string `json:"value"`
+	Type  string `json:"type,omitempty"`
+}
+
+// NFTRoyalty is a share of secondary sales an NFT's metadata asks for
+type NFTRoyalty struct {
+	ID      uint64  `gorm:"primaryKey;autoIncrement" json:"-"`
+	NFTID   uint64  `gorm:"index" json:"-"`
+	Address string  `gorm:"index" json:"address"`
+	Share   float64 `json:"share"` // Fraction of the sale price, e.g. 0.1 for 10%
+}
+
+// Roles of the people an NFT's metadata credits
+const (
+	RoleCreator     = "creator"
+	RoleContributor = "contributor"
+	RolePublisher   = "publisher"
+)
+
+// NFTContributor is a person or organisation an NFT's metadata credits
+type NFTContributor struct {
+	ID    uint64 `gorm:"primaryKey;autoIncrement" json:"-"`
+	NFTID uint64 `gorm:"index" json:"-"`
+	Role  string `json:"role"` // RoleCreator, RoleContributor or RolePublisher
+	Name  string `json:"name"` // An address or a name, as written
+}
+
+// nftDetailTables hold the parts of an NFT's metadata kept in rows of
+// their own. They're replaced when the NFT is saved and go with it.
+var nftDetailTables = []string{"nft_tags", "nft_attributes", "nft_royalties", "nft_contributors"}
+
+// saveNFTDetails replaces the stored tags, attributes, royalties and
+// contributors of a saved NFT with the ones it carries
+func saveNFTDetails(tx *gorm.DB, nft *NFT) error {
+	for _, table := range nftDetailTables {
+		if err := tx.Exec("DELETE FROM "+table+" WHERE nft_id = ?", nft.ID).Error; err != nil {
+			return err
+		}
+	}
+
+	for i := range nft.Tags {
+		nft.Tags[i].ID, nft.Tags[i].NFTID = 0, nft.ID
+	}
+	for i := range nft.Attributes {
+		nft.Attributes[i].ID, nft.Attributes[i].NFTID = 0, nft.ID
+	}
+	for i := range nft.Royalties {
+		nft.Royalties[i].ID, nft.Royalties[i].NFTID = 0, nft.ID
+	}
+	for i := range nft.Contributors {
+		nft.Contributors[i].ID, nft.Contributors[i].NFTID = 0, nft.ID
+	}
+
+	if len(nft.Tags) > 0 {
+		if err := tx.Create(&nft.Tags).Error; err != nil {
+			return err
+		}
+	}
+	if len(nft.Attributes) > 0 {
+		if err := tx.Create(&nft.Attributes).Error; err != nil {
+			return err
+		}
+	}
+	if len(nft.Royalties) > 0 {
+		if err := tx.Create(&nft.Royalties).Error; err != nil {
+			return err
+		}
+	}
+	if len(nft.Contributors) > 0 {
+		return tx.Create(&nft.Contributors).Error
+	}
+	return nil
+}
+
+// HasTag narrows an NFT query to NFTs with a tag, ignoring case
+func HasTag(tag string) func(*gorm.DB) *gorm.DB {
+	return func(query *gorm.DB) *gorm.DB {
+		return query.Where("nfts.id IN (?)",
+			query.Session(&gorm.Session{NewDB: true}).Table("nft_tags").Select("nft_id").Where("tag = ? COLLATE NOCASE", tag))
+	}
+}
+
+// HasAttribute narrows an NFT query to NFTs with an attribute, ignoring
+// case. An empty value matches any value.
+func HasAttribute(name, value string) func(*gorm.DB) *gorm.DB {
+	return func(query *gorm.DB) *gorm.DB {
+		sub := query.Session(&gorm.Session{NewDB: true}).Table("nft_attributes").Select("nft_id").
+			Where("name = ? COLLATE NOCASE", name)
+		if value != "" {
+			sub = sub.Where("value = ? COLLATE NOCASE", value)
+		}
+		return query.Where("nfts.id IN (?)", sub)
+	}
+}
@@ -0,0 +1,203 @@
+package db
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Remote pin status constants, as reported by the pinning service
+const (
+	RemotePinQueued  = "queued"
+	RemotePinPinning = "pinning"
+	RemotePinPinned  = "pinned"
+	RemotePinFailed  = "failed"
+)
+
+// PinningService is an IPFS Pinning Service API endpoint that pinned assets are mirrored to
+type PinningService struct {
+	ID         uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name       string     `json:"name"`
+	Endpoint   string     `json:"endpoint"` // API root, e.g. https://api.pinata.cloud/psa
+	Token      string     `json:"-"`        // Access token, never returned
+	Wallets    string     `json:"-"`        // JSON array of mirrored wallet addresses; empty mirrors every wallet
+	Enabled    bool       `json:"enabled" gorm:"default:true"`
+	LastSyncAt *time.Time `json:"last_sync_at"`
+	LastError  string     `json:"last_error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// WalletList returns the wallets mirrored to the service, or nil for all wallets
+func (s *PinningService) WalletList() []string {
+	if s.Wallets == "" {
+		return nil
+	}
+	var wallets []string
+	if err := json.Unmarshal([]byte(s.Wallets), &wallets); err != nil {
+		return nil
+	}
+	return wallets
+}
+
+// SetWalletList sets the wallets mirrored to the service; empty mirrors every wallet
+func (s *PinningService) SetWalletList(wallets []string) {
+	if len(wallets) == 0 {
+		s.Wallets = ""
+		return
+	}
+	data, _ := json.Marshal(wallets)
+	s.Wallets = string(data)
+}
+
+// RemotePin is a CID mirrored to a pinning service and its status there
+type RemotePin struct {
+	ID               uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	PinningServiceID uint64     `gorm:"uniqueIndex:idx_service_cid" json:"service_id"`
+	CID              string     `gorm:"column:cid;uniqueIndex:idx_service_cid;index" json:"cid"`
+	AssetID          uint64     `gorm:"index" json:"asset_id"` // Asset the CID was mirrored for
+	RequestID        string     `json:"request_id"`            // Pin request ID at the service
+	Status           string     `gorm:"index" json:"status"`   // "queued", "pinning", "pinned", "failed"
+	ErrorMsg         string     `json:"error_msg"`
+	RetryCount       int        `json:"retry_count"`
+	CheckedAt        *time.Time `json:"checked_at"` // Last time the service reported on it
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// RemotePinCounts holds the number of a service's remote pins in each status
+type RemotePinCounts struct {
+	Queued  int64 `json:"queued"`
+	Pinning int64 `json:"pinning"`
+	Pinned  int64 `json:"pinned"`
+	Failed  int64 `json:"failed"`
+}
+
+// SavePinningService saves or updates a pinning service
+func (d *Database) SavePinningService(svc *PinningService) error {
+	return d.Save(svc).Error
+}
+
+// GetPinningService retrieves a pinning service by ID, returning nil if it does not exist
+func (d *Database) GetPinningService(id uint64) (*PinningService, error) {
+	var svc PinningService
+	err := d.First(&svc, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &svc, nil
+}
+
+// GetPinningServices retrieves all pinning services
+func (d *Database) GetPinningServices() ([]PinningService, error) {
+	var services []PinningService
+	err := d.Order("id ASC").Find(&services).Error
+	return services, err
+}
+
+// DeletePinningService removes a pinning service and its remote pin records
+func (d *Database) DeletePinningService(id uint64) error {
+	return d.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("pinning_service_id = ?", id).Delete(&RemotePin{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&PinningService{}, id).Error
+	})
+}
+
+// GetMirrorAssets returns the assets used by any of the given wallets, in any
+// status, or every asset when wallets is empty
+func (d *Database) GetMirrorAssets(wallets []string) ([]Asset, error) {
+	var assets []Asset
+	if len(wallets) == 0 {
+		err := d.Order("id ASC").Find(&assets).Error
+		return assets, err
+	}
+
+	// Same matching as GetWalletUsage: the NFT the asset was recorded under,
+	// plus any NFT of the wallet that uses the same URI
+	err := d.Raw(`
+		SELECT * FROM assets WHERE id IN (
+			SELECT a.id FROM assets a JOIN nfts n ON n.id = a.nft_id WHERE n.wallet_address IN ?
+			UNION SELECT a.id FROM assets a JOIN nfts n ON n.artifact_uri = a.uri WHERE n.wallet_address IN ?
+			UNION SELECT a.id FROM assets a JOIN nfts n ON n.display_uri = a.uri WHERE n.wallet_address IN ?
+			UNION SELECT a.id FROM assets a JOIN nfts n ON n.thumbnail_uri = a.uri WHERE n.wallet_address IN ?
+		) ORDER BY id ASC`,
+		wallets, wallets, wallets, wallets).Scan(&assets).Error
+	return assets, err
+}
+
+// GetRemotePins retrieves every remote pin recorded for a service
+func (d *Database) GetRemotePins(serviceID uint64) ([]RemotePin, error) {
+	var pins []RemotePin
+	err := d.Where("pinning_service_id = ?", serviceID).Order("id ASC").Find(&pins).Error
+	return pins, err
+}
+
+// ListRemotePins retrieves a page of a service's remote pins, optionally by status
+func (d *Database) ListRemotePins(serviceID uint64, status string, offset, limit int) ([]RemotePin, int64, error) {
+	query := d.Model(&RemotePin{}).Where("pinning_service_id = ?", serviceID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var pins []RemotePin
+	err := query.Order("id ASC").Offset(offset).Limit(limit).Find(&pins).Error
+	return pins, total, err
+}
+
+// GetRemotePinsByCID retrieves the remote pins of a CID across all services
+func (d *Database) GetRemotePinsByCID(cid string) ([]RemotePin, error) {
+	var pins []RemotePin
+	err := d.Where("cid = ?", cid).Order("pinning_service_id ASC").Find(&pins).Error
+	return pins, err
+}
+
+// SaveRemotePin saves or updates a remote pin
+func (d *Database) SaveRemotePin(pin *RemotePin) error {
+	return d.Save(pin).Error
+}
+
+// DeleteRemotePin removes a remote pin record
+func (d *Database) DeleteRemotePin(id uint64) error {
+	return d.Delete(&RemotePin{}, id).Error
+}
+
+// GetRemotePinCounts counts a service's remote pins by status
+func (d *Database) GetRemotePinCounts(serviceID uint64) (RemotePinCounts, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	var counts RemotePinCounts
+	err := d.Model(&RemotePin{}).
+		Select("status, COUNT(*) AS count").
+		Where("pinning_service_id = ?", serviceID).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return counts, err
+	}
+	for _, r := range rows {
+		switch r.Status {
+		case RemotePinQueued:
+			counts.Queued = r.Count
+		case RemotePinPinning:
+			counts.Pinning = r.Count
+		case RemotePinPinned:
+			counts.Pinned = r.Count
+		case RemotePinFailed:
+			counts.Failed = r.Count
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RemoteServer is a Porcupin server saved in the desktop app, to connect to
+// and to include in the fleet overview
+type RemoteServer struct {
+	ID              uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Label           string     `json:"label"`
+	Host            string     `gorm:"uniqueIndex:idx_remote_address" json:"host"`
+	Port            int        `gorm:"uniqueIndex:idx_remote_address" json:"port"`
+	UseTLS          bool       `json:"use_tls"`
+	Fingerprint     string     `json:"fingerprint,omitempty"` // SHA-256 of the server's certificate, when it isn't CA-signed
+	SealedToken     string     `json:"-"`                     // API token sealed by a SecretBox, never returned
+	LastConnectedAt *time.Time `json:"last_connected_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// SaveRemoteServer saves or updates a remote server
+func (d *Database) SaveRemoteServer(server *RemoteServer) error {
+	return d.Save(server).Error
+}
+
+// GetRemoteServer retrieves a remote server by ID, returning nil if it does
+// not exist
+func (d *Database) GetRemoteServer(id uint64) (*RemoteServer, error) {
+	var server RemoteServer
+	err := d.First(&server, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &server, nil
+}
+
+// GetRemoteServerByAddress retrieves the remote server saved for host and
+// port, returning nil if there is none
+func (d *Database) GetRemoteServerByAddress(host string, port int) (*RemoteServer, error) {
+	var server RemoteServer
+	err := d.Where("host = ? AND port = ?", host, port).First(&server).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &server, nil
+}
+
+// GetRemoteServers retrieves all remote servers, most recently connected
+// first
+func (d *Database) GetRemoteServers() ([]RemoteServer, error) {
+	var servers []RemoteServer
+	err := d.Order("last_connected_at IS NULL, last_connected_at DESC, id ASC").Find(&servers).Error
+	return servers, err
+}
+
+// DeleteRemoteServer removes a remote server
+func (d *Database) DeleteRemoteServer(id uint64) error {
+	return d.Delete(&RemoteServer{}, id).Error
+}
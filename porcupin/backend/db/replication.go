@@ -0,0 +1,231 @@
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Replicated pin status constants
+const (
+	ReplicaStatusPending = "pending"
+	ReplicaStatusPinned  = "pinned"
+	ReplicaStatusFailed  = "failed"
+	ReplicaStatusRemoved = "removed" // No longer in the peer's catalog, still pinned here
+)
+
+// ReplicationPeer is another Porcupin node whose catalog this node replicates
+type ReplicationPeer struct {
+	ID         uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name       string     `json:"name"`
+	Host       string     `json:"host"`
+	Port       int        `json:"port"`
+	Token      string     `json:"-"` // API token of the peer, never returned
+	UseTLS     bool       `json:"use_tls"`
+	TLSPin     string     `json:"tls_pin,omitempty"` // SHA-256 of the peer's certificate, when it isn't CA-signed
+	Enabled    bool       `json:"enabled" gorm:"default:true"`
+	NodeID     string     `json:"node_id"`     // libp2p peer ID reported by the peer
+	NodeAddrs  string     `json:"node_addrs"`  // JSON array of libp2p multiaddrs reported by the peer
+	Cursor     *time.Time `json:"cursor"`      // Latest pinned_at seen in the peer's catalog
+	RemoteSize int64      `json:"remote_size"` // Entries in the peer's catalog at the last sync
+	LastSyncAt *time.Time `json:"last_sync_at"`
+	LastFullAt *time.Time `json:"last_full_sync_at"` // Last sync that walked the whole catalog
+	LastError  string     `json:"last_error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// ReplicatedPin is a CID from a peer's catalog and its local replication state
+type ReplicatedPin struct {
+	ID                uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	ReplicationPeerID uint64     `gorm:"uniqueIndex:idx_peer_cid" json:"peer_id"`
+	CID               string     `gorm:"column:cid;uniqueIndex:idx_peer_cid;index" json:"cid"`
+	URI               string     `json:"uri"`
+	SizeBytes         int64      `json:"size_bytes"`
+	RemotePinnedAt    time.Time  `json:"remote_pinned_at"`    // When the peer pinned it
+	Status            string     `gorm:"index" json:"status"` // "pending", "pinned", "failed", "removed"
+	ErrorMsg          string     `json:"error_msg"`
+	RetryCount        int        `json:"retry_count"`
+	SeenAt            time.Time  `json:"seen_at"` // Last sync that found it in the peer's catalog
+	PinnedAt          *time.Time `json:"pinned_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// ReplicaCounts holds the number of a peer's replicated pins in each status
+type ReplicaCounts struct {
+	Pending int64 `json:"pending"`
+	Pinned  int64 `json:"pinned"`
+	Failed  int64 `json:"failed"`
+	Removed int64 `json:"removed"`
+}
+
+// SaveReplicationPeer saves or updates a replication peer
+func (d *Database) SaveReplicationPeer(peer *ReplicationPeer) error {
+	return d.Save(peer).Error
+}
+
+// GetReplicationPeer retrieves a peer by ID, returning nil if it does not exist
+func (d *Database) GetReplicationPeer(id uint64) (*ReplicationPeer, error) {
+	var peer ReplicationPeer
+	err := d.First(&peer, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &peer, nil
+}
+
+// GetReplicationPeers retrieves all replication peers
+func (d *Database) GetReplicationPeers() ([]ReplicationPeer, error) {
+	var peers []ReplicationPeer
+	err := d.Order("id ASC").Find(&peers).Error
+	return peers, err
+}
+
+// DeleteReplicationPeer removes a peer and its replicated pin records
+func (d *Database) DeleteReplicationPeer(id uint64) error {
+	return d.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("replication_peer_id = ?", id).Delete(&ReplicatedPin{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&ReplicationPeer{}, id).Error
+	})
+}
+
+// UpsertReplicatedPins records catalog entries seen at a peer. New CIDs start pending,
+// CIDs the peer had dropped and re-added are pending again, everything else keeps its status.
+func (d *Database) UpsertReplicatedPins(pins []ReplicatedPin) error {
+	if len(pins) == 0 {
+		return nil
+	}
+	for i := range pins {
+		if pins[i].Status == "" {
+			pins[i].Status = ReplicaStatusPending
+		}
+	}
+	return d.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "replication_peer_id"}, {Name: "cid"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"uri":              gorm.Expr("excluded.uri"),
+			"size_bytes":       gorm.Expr("excluded.size_bytes"),
+			"remote_pinned_at": gorm.Expr("excluded.remote_pinned_at"),
+			"seen_at":          gorm.Expr("excluded.seen_at"),
+			"status": gorm.Expr("CASE WHEN replicated_pins.status = ? THEN ? ELSE replicated_pins.status END",
+				ReplicaStatusRemoved, ReplicaStatusPending),
+		}),
+	}).CreateInBatches(pins, 200).Error
+}
+
+// MarkReplicatedPinsRemoved flags a peer's pins not seen since the given time as
+// removed from its catalog. Returns the number of pins flagged.
+func (d *Database) MarkReplicatedPinsRemoved(peerID uint64, seenBefore time.Time) (int64, error) {
+	res := d.Model(&ReplicatedPin{}).
+		Where("replication_peer_id = ? AND seen_at < ? AND status <> ?", peerID, seenBefore, ReplicaStatusRemoved).
+		Update("status", ReplicaStatusRemoved)
+	return res.RowsAffected, res.Error
+}
+
+// GetReplicatedPinsToPin returns a peer's pins still to be replicated, oldest first.
+// Failed pins are included until they reach maxRetries.
+func (d *Database) GetReplicatedPinsToPin(peerID uint64, maxRetries int, limit int) ([]ReplicatedPin, error) {
+	var pins []ReplicatedPin
+	query := d.Where("replication_peer_id = ? AND (status = ? OR (status = ? AND retry_count < ?))",
+		peerID, ReplicaStatusPending, ReplicaStatusFailed, maxRetries).
+		Order("remote_pinned_at ASC, id ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	err := query.Find(&pins).Error
+	return pins, err
+}
+
+// SaveReplicatedPin saves or updates a replicated pin
+func (d *Database) SaveReplicatedPin(pin *ReplicatedPin) error {
+	return d.Save(pin).Error
+}
+
+// GetReplicaCounts counts a peer's replicated pins by status
+func (d *Database) GetReplicaCounts(peerID uint64) (ReplicaCounts, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	var counts ReplicaCounts
+	err := d.Model(&ReplicatedPin{}).
+		Select("status, COUNT(*) AS count").
+		Where("replication_peer_id = ?", peerID).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return counts, err
+	}
+	for _, r := range rows {
+		switch r.Status {
+		case ReplicaStatusPending:
+			counts.Pending = r.Count
+		case ReplicaStatusPinned:
+			counts.Pinned = r.Count
+		case ReplicaStatusFailed:
+			counts.Failed = r.Count
+		case ReplicaStatusRemoved:
+			counts.Removed = r.Count
+		}
+	}
+	return counts, nil
+}
+
+// GetOldestUnreplicated returns when the peer pinned the oldest CID not yet
+// replicated here, or nil when the peer is fully replicated
+func (d *Database) GetOldestUnreplicated(peerID uint64) (*time.Time, error) {
+	var pin ReplicatedPin
+	err := d.Where("replication_peer_id = ? AND status IN (?, ?)", peerID, ReplicaStatusPending, ReplicaStatusFailed).
+		Order("remote_pinned_at ASC").
+		First(&pin).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &pin.RemotePinnedAt, nil
+}
+
+// IsCIDReferenced reports whether a CID is used by a local asset or replicated
+// from a peer other than excludePeerID
+func (d *Database) IsCIDReferenced(cid string, excludePeerID uint64) (bool, error) {
+	var count int64
+	if err := d.Model(&Asset{}).
+		Where("uri = ? OR uri LIKE ? OR uri LIKE ?", "ipfs://"+cid, "ipfs://"+cid+"/%", "%/ipfs/"+cid+"%").
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+	if err := d.Model(&ReplicatedPin{}).
+		Where("cid = ? AND replication_peer_id <> ? AND status IN (?, ?)", cid, excludePeerID, ReplicaStatusPinned, ReplicaStatusRemoved).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetPinnedCatalog returns this node's pinned assets in the order they were pinned,
+// for peers replicating it. Only assets pinned after since are returned when it is set.
+func (d *Database) GetPinnedCatalog(since *time.Time, offset, limit int) ([]Asset, int64, error) {
+	var total int64
+	if err := d.Model(&Asset{}).Where("status = ?", StatusPinned).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query := d.Where("status = ?", StatusPinned)
+	if since != nil {
+		query = query.Where("pinned_at > ?", *since)
+	}
+	var assets []Asset
+	err := query.Order("pinned_at ASC, id ASC").Offset(offset).Limit(limit).Find(&assets).Error
+	return assets, total, err
+}
@@ -0,0 +1,213 @@
+package db
+
+import (
+	"html"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// NFTs are searched through nft_search, an FTS5 table with a row per NFT
+// keyed by its ID. SaveNFT keeps a row current with its NFT; changes to a
+// creator's or contract's names are copied to the rows that show them.
+
+const createSearchTable = `CREATE VIRTUAL TABLE IF NOT EXISTS nft_search USING fts5(
+	name, description, tags, attributes, creator, collection,
+	tokenize = 'unicode61 remove_diacritics 2'
+)`
+
+// searchRank orders matches best first. A match in the name counts the most,
+// one in the description the least.
+const searchRank = "bm25(nft_search, 10.0, 1.0, 5.0, 3.0, 4.0, 4.0)"
+
+// Matches are marked with control characters SQLite leaves alone, then
+// turned into <mark> tags once the text around them is escaped
+const (
+	markOpen  = "\x02"
+	markClose = "\x03"
+)
+
+// Highlight is an NFT's name and description with the words a search
+// matched in <mark> tags. The text is HTML escaped.
+type Highlight struct {
+	Name        string `json:"name"`
+	Description string `json:"description"` // An excerpt around the matches
+}
+
+// SearchHit is an NFT a search matched
+type SearchHit struct {
+	NFTID     uint64
+	Highlight Highlight
+}
+
+// SearchQuery is a full-text search of NFTs
+type SearchQuery struct {
+	Text   string                  // What the user typed
+	Filter func(*gorm.DB) *gorm.DB // Narrows the nfts rows searched, if set
+	Offset int
+	Limit  int // 0 for all
+}
+
+// SearchNFTs returns the NFTs matching a search, best first, and how many
+// match in all. Each word typed must match, as a word or the start of one.
+func (d *Database) SearchNFTs(q SearchQuery) ([]SearchHit, int64, error) {
+	match := searchMatch(q.Text)
+	if match == "" {
+		return nil, 0, nil
+	}
+
+	query := d.Table("nft_search").
+		Joins("JOIN nfts ON nfts.id = nft_search.rowid").
+		Where("nft_search MATCH ?", match)
+	if q.Filter != nil {
+		query = q.Filter(query)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []struct {
+		ID          uint64
+		Name        string
+		Description string
+	}
+	query = query.Select("nfts.id AS id, " +
+		"highlight(nft_search, 0, char(2), char(3)) AS name, " +
+		"snippet(nft_search, 1, char(2), char(3), '…', 24) AS description").
+		Order(searchRank).Offset(q.Offset)
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	hits := make([]SearchHit, len(rows))
+	for i, r := range rows {
+		hits[i] = SearchHit{NFTID: r.ID, Highlight: Highlight{
+			Name:        markMatches(r.Name),
+			Description: markMatches(r.Description),
+		}}
+	}
+	return hits, total, nil
+}
+
+// NFTsForHits loads the NFTs of search hits in the hits' order, with each
+// NFT's Highlight set. preload names the relations to load with them.
+func (d *Database) NFTsForHits(hits []SearchHit, preload ...string) ([]NFT, error) {
+	if len(hits) == 0 {
+		return []NFT{}, nil
+	}
+	ids := make([]uint64, len(hits))
+	for i, h := range hits {
+		ids[i] = h.NFTID
+	}
+
+	query := d.DB
+	for _, relation := range preload {
+		query = query.Preload(relation)
+	}
+	var found []NFT
+	if err := query.Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint64]NFT, len(found))
+	for _, nft := range found {
+		byID[nft.ID] = nft
+	}
+	nfts := make([]NFT, 0, len(hits))
+	for _, h := range hits {
+		nft, ok := byID[h.NFTID]
+		if !ok {
+			continue
+		}
+		highlight := h.Highlight
+		nft.Highlight = &highlight
+		nfts = append(nfts, nft)
+	}
+	return nfts, nil
+}
+
+// MatchingNFTIDs returns a subquery of the IDs of NFTs matching a search,
+// for filtering other queries, e.g. Where("nft_id IN (?)", ...). It matches
+// nothing when the search has no words.
+func (d *Database) MatchingNFTIDs(text string) *gorm.DB {
+	match := searchMatch(text)
+	if match == "" {
+		return d.Table("nft_search").Select("rowid").Where("0 = 1")
+	}
+	return d.Table("nft_search").Select("rowid").Where("nft_search MATCH ?", match)
+}
+
+// searchMatch turns what the user typed into an FTS5 query: every word must
+// match the start of a word. FTS5 operators and syntax are taken literally.
+func searchMatch(text string) string {
+	var terms []string
+	for _, word := range strings.Fields(text) {
+		word = strings.ReplaceAll(word, `"`, "")
+		if strings.Trim(word, "*^-+:()") == "" {
+			continue
+		}
+		terms = append(terms, `"`+word+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// markMatches escapes highlighted text and marks the matches in it
+func markMatches(s string) string {
+	s = html.EscapeString(s)
+	return strings.NewReplacer(markOpen, "<mark>", markClose, "</mark>").Replace(s)
+}
+
+// indexNFT writes the search row of an NFT from what is stored about it
+func indexNFT(tx *gorm.DB, id uint64) error {
+	if err := tx.Exec("DELETE FROM nft_search WHERE rowid = ?", id).Error; err != nil {
+		return err
+	}
+	return tx.Exec(indexSelect+" WHERE nfts.id = ?", id).Error
+}
+
+// rebuildSearchIndex indexes every NFT afresh
+func rebuildSearchIndex(tx *gorm.DB) error {
+	if err := tx.Exec("DELETE FROM nft_search").Error; err != nil {
+		return err
+	}
+	return tx.Exec(indexSelect).Error
+}
+
+// indexSelect fills search rows from nfts and what is linked to them
+const indexSelect = `INSERT INTO nft_search (rowid, name, description, tags, attributes, creator, collection)
+	SELECT nfts.id, nfts.name, nfts.description,
+		COALESCE((SELECT group_concat(tag, ' ') FROM nft_tags WHERE nft_id = nfts.id), ''),
+		COALESCE((SELECT group_concat(name || ' ' || value, ' ') FROM nft_attributes WHERE nft_id = nfts.id), ''),
+		` + creatorText + `,
+		` + collectionText + `
+	FROM nfts
+	LEFT JOIN creators ON creators.address = nfts.creator_address
+	LEFT JOIN contracts ON contracts.address = nfts.contract_address`
+
+// The creator and collection columns hold the names and address, so an
+// address pasted into the search box finds the NFTs too
+const (
+	creatorText    = `trim(COALESCE(creators.name, '') || ' ' || COALESCE(creators.alias, '') || ' ' || nfts.creator_address)`
+	collectionText = `trim(COALESCE(contracts.name, '') || ' ' || COALESCE(contracts.alias, '') || ' ' || nfts.contract_address)`
+)
+
+// reindexCreator copies a creator's names to the search rows of its NFTs
+func reindexCreator(tx *gorm.DB, address string) error {
+	return tx.Exec(`UPDATE nft_search SET creator = (
+		SELECT `+creatorText+` FROM nfts LEFT JOIN creators ON creators.address = nfts.creator_address
+		WHERE nfts.id = nft_search.rowid)
+		WHERE rowid IN (SELECT id FROM nfts WHERE creator_address = ?)`, address).Error
+}
+
+// reindexContract copies a contract's names to the search rows of its NFTs
+func reindexContract(tx *gorm.DB, address string) error {
+	return tx.Exec(`UPDATE nft_search SET collection = (
+		SELECT `+collectionText+` FROM nfts LEFT JOIN contracts ON contracts.address = nfts.contract_address
+		WHERE nfts.id = nft_search.rowid)
+		WHERE rowid IN (SELECT id FROM nfts WHERE contract_address = ?)`, address).Error
+}
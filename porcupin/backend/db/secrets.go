@@ -0,0 +1,97 @@
+package db
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// SecretKeyFileName is the key in the data directory that seals secrets
+	// stored in the database, such as the tokens of saved remote servers
+	SecretKeyFileName = ".secret-key"
+
+	secretKeySize = 32 // AES-256
+
+	// sealedPrefix versions sealed values, so the scheme can change
+	sealedPrefix = "v1:"
+)
+
+// SecretBox encrypts secrets for storage in the database with AES-GCM. A
+// copy of the database, such as a backup, doesn't reveal them without the
+// key file.
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+// LoadOrCreateSecretBox reads the key at path, generating it on first use
+func LoadOrCreateSecretBox(path string) (*SecretBox, error) {
+	key, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		key = make([]byte, secretKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate secret key: %w", err)
+		}
+		if err := os.WriteFile(path, key, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write secret key: %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read secret key: %w", err)
+	}
+	return NewSecretBox(key)
+}
+
+// NewSecretBox creates a SecretBox with a 32-byte key
+func NewSecretBox(key []byte) (*SecretBox, error) {
+	if len(key) != secretKeySize {
+		return nil, fmt.Errorf("secret key must be %d bytes, got %d", secretKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretBox{aead: aead}, nil
+}
+
+// Seal encrypts a secret for storage. The empty string stays empty.
+func (b *SecretBox) Seal(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return sealedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a secret sealed by Seal
+func (b *SecretBox) Open(sealed string) (string, error) {
+	if sealed == "" {
+		return "", nil
+	}
+	encoded, ok := strings.CutPrefix(sealed, sealedPrefix)
+	if !ok {
+		return "", fmt.Errorf("unsupported sealed secret")
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < b.aead.NonceSize() {
+		return "", fmt.Errorf("malformed sealed secret")
+	}
+	nonce, ciphertext := data[:b.aead.NonceSize()], data[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret; was %s replaced?", SecretKeyFileName)
+	}
+	return string(plaintext), nil
+}
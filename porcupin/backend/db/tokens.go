@@ -0,0 +1,61 @@
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// apiTokenTouchInterval limits how often a token's last use is written, so
+// every request doesn't update the database
+const apiTokenTouchInterval = time.Minute
+
+// APIToken is a named API token issued to a client, such as a desktop app
+// that paired with this server. Only a hash of the token is stored.
+type APIToken struct {
+	ID         uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name       string     `json:"name"`
+	TokenHash  string     `gorm:"uniqueIndex" json:"-"` // SHA-256 of the token
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateAPIToken saves a new API token
+func (d *Database) CreateAPIToken(token *APIToken) error {
+	return d.Create(token).Error
+}
+
+// GetAPITokens retrieves all API tokens
+func (d *Database) GetAPITokens() ([]APIToken, error) {
+	var tokens []APIToken
+	err := d.Order("id ASC").Find(&tokens).Error
+	return tokens, err
+}
+
+// DeleteAPIToken revokes an API token. Returns false if it does not exist.
+func (d *Database) DeleteAPIToken(id uint64) (bool, error) {
+	res := d.Delete(&APIToken{}, id)
+	return res.RowsAffected > 0, res.Error
+}
+
+// UseAPIToken looks up a token by its hash and records its use, returning
+// nil if no token has the hash
+func (d *Database) UseAPIToken(hash string) (*APIToken, error) {
+	var token APIToken
+	err := d.Where("token_hash = ?", hash).First(&token).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > apiTokenTouchInterval {
+		token.LastUsedAt = &now
+		if err := d.Model(&token).Update("last_used_at", now).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &token, nil
+}
@@ -0,0 +1,163 @@
+package db
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MetadataVersion is one version of an NFT's metadata. Syncs record a new
+// version whenever they find the metadata changed, since some contracts let
+// creators update it.
+type MetadataVersion struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	NFTID     uint64    `gorm:"uniqueIndex:idx_nft_version" json:"nft_id"`
+	Version   int       `gorm:"uniqueIndex:idx_nft_version" json:"version"` // 1 for the first version seen
+	Level     int64     `json:"level"`                                      // Block level the token_metadata entry changed at, 0 if unknown
+	Hash      string    `json:"hash"`                                       // SHA-256 of Metadata
+	Metadata  string    `json:"metadata"`                                   // Canonical JSON
+	CreatedAt time.Time `json:"created_at"`                                 // When a sync first saw it
+}
+
+// RecordMetadataVersion records metadata, as canonical JSON, as the latest
+// version of an NFT's metadata and updates the NFT's version number. Nothing
+// is recorded if the latest version is the same. Returns the version the
+// metadata replaced, or nil if it is the first or nothing changed.
+func (d *Database) RecordMetadataVersion(nftID uint64, level int64, metadata string) (*MetadataVersion, error) {
+	sum := sha256.Sum256([]byte(metadata))
+	hash := hex.EncodeToString(sum[:])
+
+	var previous *MetadataVersion
+	err := d.Transaction(func(tx *gorm.DB) error {
+		var latest MetadataVersion
+		err := tx.Where("nft_id = ?", nftID).Order("version DESC").First(&latest).Error
+		switch {
+		case err == nil && latest.Hash == hash:
+			return nil
+		case err == nil:
+			previous = &latest
+		case err != gorm.ErrRecordNotFound:
+			return err
+		}
+
+		version := &MetadataVersion{NFTID: nftID, Version: latest.Version + 1, Level: level, Hash: hash, Metadata: metadata}
+		if err := tx.Create(version).Error; err != nil {
+			return err
+		}
+		return tx.Model(&NFT{}).Where("id = ?", nftID).UpdateColumn("metadata_version", version.Version).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return previous, nil
+}
+
+// MetadataVersions returns the recorded versions of an NFT's metadata,
+// oldest first
+func (d *Database) MetadataVersions(nftID uint64) ([]MetadataVersion, error) {
+	var versions []MetadataVersion
+	err := d.Where("nft_id = ?", nftID).Order("version").Find(&versions).Error
+	return versions, err
+}
+
+// MetadataRevision is a version of an NFT's metadata and how it differs
+// from the version before it
+type MetadataRevision struct {
+	MetadataVersion
+	Changes []MetadataChange `json:"changes"` // Every field, for the first version
+}
+
+// MetadataHistory returns the versions of an NFT's metadata with the changes
+// each made, oldest first
+func (d *Database) MetadataHistory(nftID uint64) ([]MetadataRevision, error) {
+	versions, err := d.MetadataVersions(nftID)
+	if err != nil {
+		return nil, err
+	}
+	history := make([]MetadataRevision, 0, len(versions))
+	previous := ""
+	for _, v := range versions {
+		changes, err := DiffMetadata(previous, v.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("metadata version %d: %w", v.Version, err)
+		}
+		history = append(history, MetadataRevision{MetadataVersion: v, Changes: changes})
+		previous = v.Metadata
+	}
+	return history, nil
+}
+
+// URIUsedElsewhere reports whether an asset URI is used by an NFT other
+// than nftID, in its current URIs or its latest metadata version
+func (d *Database) URIUsedElsewhere(uri string, nftID uint64) (bool, error) {
+	var count int64
+	err := d.Model(&NFT{}).
+		Where("id <> ?", nftID).
+		Where("artifact_uri = ? OR display_uri = ? OR thumbnail_uri = ?", uri, uri, uri).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+
+	latest := d.Model(&MetadataVersion{}).Select("MAX(id)").Group("nft_id")
+	err = d.Model(&MetadataVersion{}).
+		Where("nft_id <> ? AND id IN (?)", nftID, latest).
+		Where("instr(metadata, ?) > 0", uri).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// MetadataChange is a top-level metadata field that differs between two
+// versions. Old is unset for added fields and New for removed ones.
+type MetadataChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old,omitempty"`
+	New   json.RawMessage `json:"new,omitempty"`
+}
+
+// DiffMetadata compares two versions of metadata field by field, sorted by
+// field name. An empty old version counts as having no fields.
+func DiffMetadata(old, new string) ([]MetadataChange, error) {
+	before, err := metadataFields(old)
+	if err != nil {
+		return nil, err
+	}
+	after, err := metadataFields(new)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(before)+len(after))
+	for field := range before {
+		fields = append(fields, field)
+	}
+	for field := range after {
+		if _, ok := before[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := []MetadataChange{}
+	for _, field := range fields {
+		if !bytes.Equal(before[field], after[field]) {
+			changes = append(changes, MetadataChange{Field: field, Old: before[field], New: after[field]})
+		}
+	}
+	return changes, nil
+}
+
+func metadataFields(metadata string) (map[string]json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if metadata == "" {
+		return fields, nil
+	}
+	err := json.Unmarshal([]byte(metadata), &fields)
+	return fields, err
+}
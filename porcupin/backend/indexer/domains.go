@@ -0,0 +1,100 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrDomainNotFound is returned when a Tezos Domains name doesn't resolve to
+// an address
+var ErrDomainNotFound = errors.New("domain not found")
+
+// domainPattern matches Tezos Domains names, e.g. alice.tez or art.alice.tez
+var domainPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)+tez$`)
+
+// reverseBatchSize bounds the addresses looked up in one request
+const reverseBatchSize = 100
+
+// Domain is a Tezos Domains record as TZKT reports it
+type Domain struct {
+	Name       string       `json:"name"`
+	Address    *AccountInfo `json:"address,omitempty"` // Where the name points, if anywhere
+	Reverse    bool         `json:"reverse"`           // Whether the address resolves back to the name
+	Expiration time.Time    `json:"expiration"`
+}
+
+type AccountInfo struct {
+	Address string `json:"address"`
+}
+
+// IsDomain reports whether name is a Tezos Domains name. Names are case
+// insensitive; see NormalizeDomain.
+func IsDomain(name string) bool {
+	return domainPattern.MatchString(NormalizeDomain(name))
+}
+
+// NormalizeDomain returns name in the form Tezos Domains stores it
+func NormalizeDomain(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// active reports whether the record still points somewhere
+func (d *Domain) active(now time.Time) bool {
+	return d.Address != nil && d.Address.Address != "" && (d.Expiration.IsZero() || d.Expiration.After(now))
+}
+
+// ResolveDomain returns the address a Tezos Domains name points to, or
+// ErrDomainNotFound if it points nowhere or has expired
+func (i *Indexer) ResolveDomain(ctx context.Context, name string) (string, error) {
+	name = NormalizeDomain(name)
+	var domains []Domain
+	if err := i.get(ctx, "/v1/domains", map[string]string{"name": name}, &domains); err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", name, err)
+	}
+	now := time.Now()
+	for _, d := range domains {
+		if d.Name == name && d.active(now) {
+			return d.Address.Address, nil
+		}
+	}
+	return "", fmt.Errorf("%s: %w", name, ErrDomainNotFound)
+}
+
+// ReverseDomain returns the name an address resolves back to, or "" if it
+// has none
+func (i *Indexer) ReverseDomain(ctx context.Context, address string) (string, error) {
+	names, err := i.ReverseDomains(ctx, []string{address})
+	if err != nil {
+		return "", err
+	}
+	return names[address], nil
+}
+
+// ReverseDomains looks up the names addresses resolve back to. Addresses
+// without one are left out of the result.
+func (i *Indexer) ReverseDomains(ctx context.Context, addresses []string) (map[string]string, error) {
+	names := make(map[string]string, len(addresses))
+	now := time.Now()
+	for start := 0; start < len(addresses); start += reverseBatchSize {
+		batch := addresses[start:min(start+reverseBatchSize, len(addresses))]
+		params := map[string]string{
+			"address.in": strings.Join(batch, ","),
+			"reverse":    "true",
+			"limit":      fmt.Sprintf("%d", len(batch)*2),
+		}
+		var domains []Domain
+		if err := i.get(ctx, "/v1/domains", params, &domains); err != nil {
+			return nil, fmt.Errorf("failed to look up domains: %w", err)
+		}
+		for _, d := range domains {
+			if d.Reverse && d.active(now) {
+				names[d.Address.Address] = d.Name
+			}
+		}
+	}
+	return names, nil
+}
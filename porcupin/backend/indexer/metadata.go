@@ -0,0 +1,199 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Text is a metadata value meant to be a string. Other JSON values are kept
+// as written rather than failing the whole token, and null is "".
+type Text string
+
+// UnmarshalJSON accepts any JSON value
+func (t *Text) UnmarshalJSON(data []byte) error {
+	*t = Text(strings.TrimSpace(rawString(data)))
+	return nil
+}
+
+// UnmarshalJSON decodes metadata and keeps it as given in Raw
+func (m *TokenMetadata) UnmarshalJSON(data []byte) error {
+	type plain TokenMetadata
+	if err := json.Unmarshal(data, (*plain)(m)); err != nil {
+		return err
+	}
+	m.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// CanonicalJSON returns the metadata as JSON with its keys sorted and no
+// spacing, so equal metadata gives equal bytes. Metadata that wasn't
+// decoded from JSON is encoded from its fields.
+func (m *TokenMetadata) CanonicalJSON() []byte {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(m.Raw))
+	dec.UseNumber() // Large numbers stay as written
+	if len(m.Raw) == 0 || dec.Decode(&v) != nil {
+		b, _ := json.Marshal(m)
+		return b
+	}
+	b, _ := json.Marshal(v) // Maps encode with sorted keys
+	return b
+}
+
+// Attribute is a TZIP-21 trait, e.g. {"name": "Background", "value": "Blue"}
+type Attribute struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`          // Numbers and booleans as written
+	Type  string `json:"type,omitempty"` // Optional hint, e.g. "string" or "integer"
+}
+
+// TagList returns the metadata's tags. Tags given as one comma separated
+// string, as some minting tools write them, are split.
+func (m *TokenMetadata) TagList() []string {
+	var tags []string
+	if err := json.Unmarshal(m.Tags, &tags); err != nil {
+		var joined string
+		if json.Unmarshal(m.Tags, &joined) != nil {
+			return nil
+		}
+		tags = strings.Split(joined, ",")
+	}
+
+	out := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+// AttributeList returns the metadata's attributes, skipping ones without a
+// name. Malformed attributes give an empty list rather than an error.
+func (m *TokenMetadata) AttributeList() []Attribute {
+	var raw []struct {
+		Name  string          `json:"name"`
+		Value json.RawMessage `json:"value"`
+		Type  string          `json:"type"`
+	}
+	if json.Unmarshal(m.Attributes, &raw) != nil {
+		return nil
+	}
+
+	attributes := make([]Attribute, 0, len(raw))
+	for _, a := range raw {
+		name := strings.TrimSpace(a.Name)
+		if name == "" {
+			continue
+		}
+		attributes = append(attributes, Attribute{Name: name, Value: rawString(a.Value), Type: a.Type})
+	}
+	return attributes
+}
+
+// rawString returns a JSON string's contents, or any other JSON value as
+// written
+func rawString(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	raw = bytes.TrimSpace(raw)
+	if bytes.Equal(raw, []byte("null")) {
+		return ""
+	}
+	return string(raw)
+}
+
+// NameList returns the names in a TZIP-21 list such as creators,
+// contributors or publishers, given as a list or as a single string
+func NameList(raw json.RawMessage) []string {
+	var names []string
+	if json.Unmarshal(raw, &names) != nil {
+		var name string
+		if json.Unmarshal(raw, &name) != nil {
+			return nil
+		}
+		names = []string{name}
+	}
+
+	out := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		out = append(out, name)
+	}
+	return out
+}
+
+// dateLayouts are the forms of ISO 8601 dates seen in metadata
+var dateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02", "2006-01", "2006"}
+
+// DateTime returns the metadata's date, or nil if it has none or it isn't
+// a date
+func (m *TokenMetadata) DateTime() *time.Time {
+	date := string(m.Date)
+	if date == "" {
+		return nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			t = t.UTC()
+			return &t
+		}
+	}
+	return nil
+}
+
+// Royalty is a share of secondary sales paid to an account
+type Royalty struct {
+	Address string
+	Share   float64 // Fraction of the sale price, e.g. 0.1 for 10%
+}
+
+// RoyaltyList returns the royalties the metadata asks for, largest share
+// first. They're written as {"decimals": 3, "shares": {"tz1...": 100}},
+// meaning 100/10^3 of the sale price; numbers may be quoted. Malformed
+// royalties give an empty list.
+func (m *TokenMetadata) RoyaltyList() []Royalty {
+	var raw struct {
+		Decimals json.RawMessage            `json:"decimals"`
+		Shares   map[string]json.RawMessage `json:"shares"`
+	}
+	if json.Unmarshal(m.Royalties, &raw) != nil || len(raw.Shares) == 0 {
+		return nil
+	}
+	decimals, err := strconv.Atoi(rawString(raw.Decimals))
+	if err != nil || decimals < 0 || decimals > 18 {
+		return nil
+	}
+
+	royalties := make([]Royalty, 0, len(raw.Shares))
+	for address, amount := range raw.Shares {
+		n, err := strconv.ParseFloat(rawString(amount), 64)
+		if err != nil || n <= 0 || strings.TrimSpace(address) == "" {
+			continue
+		}
+		royalties = append(royalties, Royalty{Address: strings.TrimSpace(address), Share: n / math.Pow10(decimals)})
+	}
+	sort.Slice(royalties, func(i, j int) bool {
+		if royalties[i].Share != royalties[j].Share {
+			return royalties[i].Share > royalties[j].Share
+		}
+		return royalties[i].Address < royalties[j].Address
+	})
+	return royalties
+}
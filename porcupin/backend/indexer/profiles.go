@@ -0,0 +1,88 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+)
+
+// Profile is what an account publishes about itself (TzProfiles and the
+// off-chain metadata TZKT collects)
+type Profile struct {
+	Alias       string `json:"alias"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Website     string `json:"website"`
+	Twitter     string `json:"twitter"`
+	Logo        string `json:"logo"`
+}
+
+// Collection is what a contract's TZIP-16 metadata says about it
+type Collection struct {
+	Alias       string `json:"alias"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Website     string `json:"website"`
+	Creator     string `json:"creator"` // Account that originated the contract
+}
+
+type accountResponse struct {
+	Alias    string           `json:"alias"`
+	Metadata *accountMetadata `json:"metadata"`
+}
+
+type accountMetadata struct {
+	Alias       string `json:"alias"`
+	Description string `json:"description"`
+	Site        string `json:"site"`
+	Twitter     string `json:"twitter"`
+	Logo        string `json:"logo"`
+}
+
+type contractResponse struct {
+	Alias    string            `json:"alias"`
+	Creator  *AccountInfo      `json:"creator"`
+	Metadata *contractMetadata `json:"metadata"`
+}
+
+type contractMetadata struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Homepage    string `json:"homepage"`
+}
+
+// GetProfile fetches an account's alias and published profile. Accounts
+// TZKT doesn't know return an empty profile.
+func (i *Indexer) GetProfile(ctx context.Context, address string) (*Profile, error) {
+	var account accountResponse
+	if err := i.get(ctx, "/v1/accounts/"+address, map[string]string{"metadata": "true"}, &account); err != nil {
+		return nil, fmt.Errorf("failed to get profile of %s: %w", address, err)
+	}
+	profile := &Profile{Alias: account.Alias}
+	if m := account.Metadata; m != nil {
+		profile.Name = m.Alias
+		profile.Description = m.Description
+		profile.Website = m.Site
+		profile.Twitter = m.Twitter
+		profile.Logo = m.Logo
+	}
+	return profile, nil
+}
+
+// GetCollection fetches a contract's alias, originator and TZIP-16
+// metadata. Contracts TZKT doesn't know return an empty collection.
+func (i *Indexer) GetCollection(ctx context.Context, address string) (*Collection, error) {
+	var contract contractResponse
+	if err := i.get(ctx, "/v1/contracts/"+address, map[string]string{"metadata": "true"}, &contract); err != nil {
+		return nil, fmt.Errorf("failed to get collection %s: %w", address, err)
+	}
+	collection := &Collection{Alias: contract.Alias}
+	if contract.Creator != nil {
+		collection.Creator = contract.Creator.Address
+	}
+	if m := contract.Metadata; m != nil {
+		collection.Name = m.Name
+		collection.Description = m.Description
+		collection.Website = m.Homepage
+	}
+	return collection, nil
+}
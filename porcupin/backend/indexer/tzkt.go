@@ -56,6 +56,24 @@ type TokenMetadata struct {
 	Creators     json.RawMessage `json:"creators,omitempty"`  // Can be string or []string
 	Formats      []Format        `json:"formats"`
 	Decimals     json.RawMessage `json:"decimals,omitempty"` // Can be string or int
+	Tags         json.RawMessage `json:"tags,omitempty"`       // Usually []string; see TagList
+	Attributes   json.RawMessage `json:"attributes,omitempty"` // Usually []Attribute; see AttributeList
+
+	// The rest of TZIP-21. Minting tools don't all follow the schema, so
+	// single values are read as Text and lists are decoded on demand.
+	Symbol       Text            `json:"symbol,omitempty"`
+	Minter       Text            `json:"minter,omitempty"` // Address that minted the token
+	Contributors json.RawMessage `json:"contributors,omitempty"` // See NameList
+	Publishers   json.RawMessage `json:"publishers,omitempty"`   // See NameList
+	Date         Text            `json:"date,omitempty"`         // ISO 8601; see DateTime
+	Type         Text            `json:"type,omitempty"`         // e.g. "Artwork"
+	Language     Text            `json:"language,omitempty"`     // RFC 1766, e.g. "en"
+	Rights       Text            `json:"rights,omitempty"`   // License statement, e.g. "CC-BY-4.0"
+	RightURI     Text            `json:"rightUri,omitempty"` // Link to the full license
+	ExternalURI  Text            `json:"externalUri,omitempty"`
+	Royalties    json.RawMessage `json:"royalties,omitempty"` // See RoyaltyList
+
+	Raw json.RawMessage `json:"-"` // The metadata as decoded; see CanonicalJSON
 }
 
 type Format struct {
@@ -125,6 +143,9 @@ func (i *Indexer) get(ctx context.Context, endpoint string, params map[string]st
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNoContent {
+		return nil // TZKT's answer for a missing object; v keeps its zero value
+	}
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("API request failed with status %d", resp.StatusCode)
 	}
@@ -326,13 +347,20 @@ func (i *Indexer) SyncCreatedSince(ctx context.Context, address string, sinceLev
 	return allTokens, nil
 }
 
-// FetchRawMetadataURI retrieves the raw IPFS URI for a token's metadata
-func (i *Indexer) FetchRawMetadataURI(ctx context.Context, contractAddress string, tokenId string) (string, error) {
+// TokenMetadataEntry is a token's entry in its contract's token_metadata bigmap
+type TokenMetadataEntry struct {
+	URI   string // Raw metadata URI, empty if the metadata is stored on chain
+	Level int64  // Block level the entry last changed at
+}
+
+// FetchTokenMetadataEntry retrieves a token's token_metadata bigmap entry,
+// which changes whenever the token's metadata is updated
+func (i *Indexer) FetchTokenMetadataEntry(ctx context.Context, contractAddress string, tokenId string) (*TokenMetadataEntry, error) {
 	// 1. Get contract storage schema to find `token_metadata` bigmap ID.
 	// Try to get it from the dedicated bigmaps endpoint which is more reliable
 	bigMapID, err := i.GetTokenMetadataBigMapID(ctx, contractAddress)
 	if err != nil {
-		return "", fmt.Errorf("failed to get token_metadata bigmap ID: %w", err)
+		return nil, fmt.Errorf("failed to get token_metadata bigmap ID: %w", err)
 	}
 
 	// 2. Query the BigMap for the specific token
@@ -340,6 +368,7 @@ func (i *Indexer) FetchRawMetadataURI(ctx context.Context, contractAddress strin
 		Value struct {
 			TokenInfo map[string]string `json:"token_info"`
 		} `json:"value"`
+		LastLevel int64 `json:"lastLevel"`
 	}
 
 	filters := map[string]string{
@@ -348,28 +377,40 @@ func (i *Indexer) FetchRawMetadataURI(ctx context.Context, contractAddress strin
 	}
 
 	if err := i.get(ctx, "/v1/bigmaps/keys", filters, &keys); err != nil {
-		return "", fmt.Errorf("failed to fetch bigmap key: %w", err)
+		return nil, fmt.Errorf("failed to fetch bigmap key: %w", err)
 	}
 
 	if len(keys) == 0 {
-		return "", fmt.Errorf("metadata not found in bigmap")
+		return nil, fmt.Errorf("metadata not found in bigmap")
 	}
+	entry := &TokenMetadataEntry{Level: keys[0].LastLevel}
 
 	// 3. Extract and decode the URI
 	hexURI, ok := keys[0].Value.TokenInfo[""]
 	if !ok {
 		hexURI, ok = keys[0].Value.TokenInfo["metadata"]
-		if !ok {
-			return "", fmt.Errorf("no URI found in token_info")
+	}
+	if ok {
+		bytesURI, err := hex.DecodeString(hexURI)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode hex URI: %w", err)
 		}
+		entry.URI = string(bytesURI)
 	}
 
-	bytesURI, err := hex.DecodeString(hexURI)
+	return entry, nil
+}
+
+// FetchRawMetadataURI retrieves the raw IPFS URI for a token's metadata
+func (i *Indexer) FetchRawMetadataURI(ctx context.Context, contractAddress string, tokenId string) (string, error) {
+	entry, err := i.FetchTokenMetadataEntry(ctx, contractAddress, tokenId)
 	if err != nil {
-		return "", fmt.Errorf("failed to decode hex URI: %w", err)
+		return "", err
 	}
-
-	return string(bytesURI), nil
+	if entry.URI == "" {
+		return "", fmt.Errorf("no URI found in token_info")
+	}
+	return entry.URI, nil
 }
 
 // GetTokenMetadataBigMapID finds the token_metadata bigmap ID for a contract
@@ -3,8 +3,12 @@ package indexer
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 )
@@ -576,3 +580,353 @@ func TestPagination(t *testing.T) {
 
 // NOTE: TestHeadJSONParsing and TestTokenBalanceJSONParsing were removed.
 // Testing JSON unmarshal on simple structs tests the stdlib, not our code.
+
+func TestIsDomain(t *testing.T) {
+	for name, want := range map[string]bool{
+		"alice.tez":     true,
+		"Alice.TEZ ":    true,
+		"art.alice.tez": true,
+		"a-b1.tez":      true,
+		"tez":           false,
+		".tez":          false,
+		"-a.tez":        false,
+		"alice.com":     false,
+		"alice..tez":    false,
+		"tz1alice":      false,
+	} {
+		if got := IsDomain(name); got != want {
+			t.Errorf("IsDomain(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+// domainServer serves /v1/domains from a fixed set of records
+func domainServer(t *testing.T, domains []Domain, requests *[]url.Values) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/domains" {
+			t.Errorf("expected path /v1/domains, got %s", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if requests != nil {
+			*requests = append(*requests, q)
+		}
+		in := map[string]bool{}
+		for _, a := range strings.Split(q.Get("address.in"), ",") {
+			in[a] = true
+		}
+		matches := []Domain{}
+		for _, d := range domains {
+			switch {
+			case q.Has("name") && d.Name == q.Get("name"):
+			case q.Has("address.in") && d.Address != nil && in[d.Address.Address] && d.Reverse:
+			default:
+				continue
+			}
+			matches = append(matches, d)
+		}
+		json.NewEncoder(w).Encode(matches)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestResolveDomain(t *testing.T) {
+	future, past := time.Now().Add(time.Hour), time.Now().Add(-time.Hour)
+	server := domainServer(t, []Domain{
+		{Name: "alice.tez", Address: &AccountInfo{Address: "tz1alice"}, Reverse: true, Expiration: future},
+		{Name: "old.tez", Address: &AccountInfo{Address: "tz1old"}, Expiration: past},
+		{Name: "parked.tez", Expiration: future},
+	}, nil)
+	idx := NewIndexer(server.URL)
+
+	address, err := idx.ResolveDomain(context.Background(), " Alice.tez")
+	if err != nil || address != "tz1alice" {
+		t.Errorf("ResolveDomain(alice.tez) = %q, %v", address, err)
+	}
+	for _, name := range []string{"old.tez", "parked.tez", "nobody.tez"} {
+		if _, err := idx.ResolveDomain(context.Background(), name); !errors.Is(err, ErrDomainNotFound) {
+			t.Errorf("ResolveDomain(%s) error = %v, want ErrDomainNotFound", name, err)
+		}
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	if _, err := NewIndexer(failing.URL).ResolveDomain(context.Background(), "alice.tez"); err == nil || errors.Is(err, ErrDomainNotFound) {
+		t.Errorf("ResolveDomain() with a failing indexer error = %v", err)
+	}
+}
+
+func TestReverseDomains(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	var addresses []string
+	for i := 0; i < reverseBatchSize+5; i++ {
+		addresses = append(addresses, fmt.Sprintf("tz1addr%03d", i))
+	}
+	last := addresses[len(addresses)-1]
+	var requests []url.Values
+	server := domainServer(t, []Domain{
+		{Name: "first.tez", Address: &AccountInfo{Address: addresses[0]}, Reverse: true, Expiration: future},
+		{Name: "last.tez", Address: &AccountInfo{Address: last}, Reverse: true, Expiration: future},
+		{Name: "expired.tez", Address: &AccountInfo{Address: addresses[1]}, Reverse: true, Expiration: time.Now().Add(-time.Hour)},
+	}, &requests)
+	idx := NewIndexer(server.URL)
+
+	names, err := idx.ReverseDomains(context.Background(), addresses)
+	if err != nil {
+		t.Fatalf("ReverseDomains() error = %v", err)
+	}
+	if len(names) != 2 || names[addresses[0]] != "first.tez" || names[last] != "last.tez" {
+		t.Errorf("ReverseDomains() = %v", names)
+	}
+	if len(requests) != 2 || requests[0].Get("reverse") != "true" {
+		t.Errorf("requests = %v, want 2 batches of reverse lookups", requests)
+	}
+
+	name, err := idx.ReverseDomain(context.Background(), addresses[2])
+	if err != nil || name != "" {
+		t.Errorf("ReverseDomain() without a domain = %q, %v", name, err)
+	}
+}
+
+// profileServer serves TZKT account and contract lookups from canned JSON,
+// answering 204 for anything else as TZKT does
+func profileServer(t *testing.T, objects map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("metadata") != "true" {
+			t.Errorf("%s requested without metadata", r.URL.Path)
+		}
+		body, ok := objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetProfile(t *testing.T) {
+	idx := NewIndexer(profileServer(t, map[string]string{
+		"/v1/accounts/tz1artist": `{"type":"user","address":"tz1artist","alias":"Artist",
+			"metadata":{"alias":"A. Artist","description":"Paints","site":"https://artist.example","twitter":"artist","logo":"ipfs://QmLogo"}}`,
+		"/v1/accounts/tz1plain": `{"type":"user","address":"tz1plain"}`,
+	}).URL)
+
+	profile, err := idx.GetProfile(context.Background(), "tz1artist")
+	if err != nil {
+		t.Fatalf("GetProfile() error = %v", err)
+	}
+	want := Profile{Alias: "Artist", Name: "A. Artist", Description: "Paints", Website: "https://artist.example", Twitter: "artist", Logo: "ipfs://QmLogo"}
+	if *profile != want {
+		t.Errorf("GetProfile() = %+v, want %+v", *profile, want)
+	}
+
+	for _, address := range []string{"tz1plain", "tz1unknown"} {
+		if profile, err := idx.GetProfile(context.Background(), address); err != nil || *profile != (Profile{}) {
+			t.Errorf("GetProfile(%s) = %+v, %v, want an empty profile", address, profile, err)
+		}
+	}
+}
+
+func TestGetCollection(t *testing.T) {
+	idx := NewIndexer(profileServer(t, map[string]string{
+		"/v1/contracts/KT1series": `{"address":"KT1series","alias":"Series","creator":{"address":"tz1artist"},
+			"metadata":{"name":"The Series","description":"Works","homepage":"https://series.example"}}`,
+	}).URL)
+
+	collection, err := idx.GetCollection(context.Background(), "KT1series")
+	if err != nil {
+		t.Fatalf("GetCollection() error = %v", err)
+	}
+	want := Collection{Alias: "Series", Name: "The Series", Description: "Works", Website: "https://series.example", Creator: "tz1artist"}
+	if *collection != want {
+		t.Errorf("GetCollection() = %+v, want %+v", *collection, want)
+	}
+	if collection, err := idx.GetCollection(context.Background(), "KT1unknown"); err != nil || *collection != (Collection{}) {
+		t.Errorf("GetCollection(unknown) = %+v, %v", collection, err)
+	}
+}
+
+func TestTagListAndAttributeList(t *testing.T) {
+	tests := []struct {
+		name       string
+		metadata   string
+		tags       []string
+		attributes []Attribute
+	}{
+		{
+			name:     "TZIP-21",
+			metadata: `{"tags":["art"," Generative ","ART",""],"attributes":[{"name":"Palette","value":"Blue"},{"name":"Edition","value":3,"type":"integer"},{"name":"Rare","value":true},{"value":"nameless"}]}`,
+			tags:     []string{"art", "Generative"},
+			attributes: []Attribute{
+				{Name: "Palette", Value: "Blue"},
+				{Name: "Edition", Value: "3", Type: "integer"},
+				{Name: "Rare", Value: "true"},
+			},
+		},
+		{
+			name:     "tags in one string",
+			metadata: `{"tags":"art, photo"}`,
+			tags:     []string{"art", "photo"},
+		},
+		{
+			name:     "malformed",
+			metadata: `{"tags":{"a":1},"attributes":"Blue"}`,
+		},
+		{
+			name:     "missing",
+			metadata: `{}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m TokenMetadata
+			if err := json.Unmarshal([]byte(tt.metadata), &m); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if got := m.TagList(); fmt.Sprint(got) != fmt.Sprint(tt.tags) {
+				t.Errorf("TagList() = %q, want %q", got, tt.tags)
+			}
+			if got := m.AttributeList(); fmt.Sprint(got) != fmt.Sprint(tt.attributes) {
+				t.Errorf("AttributeList() = %+v, want %+v", got, tt.attributes)
+			}
+		})
+	}
+}
+
+func TestTokenMetadata_TZIP21(t *testing.T) {
+	var m TokenMetadata
+	err := json.Unmarshal([]byte(`{
+		"name": "Tide",
+		"symbol": "OBJKT",
+		"minter": "tz1minter",
+		"creators": ["tz1alice", "Alice ", "tz1alice"],
+		"contributors": "Bob",
+		"publishers": ["Studio"],
+		"date": "2021-03-01T12:30:00+02:00",
+		"type": "Artwork",
+		"language": "en",
+		"rights": "CC-BY-4.0",
+		"rightUri": "https://creativecommons.org/licenses/by/4.0/",
+		"externalUri": 42,
+		"royalties": {"decimals": "3", "shares": {"tz1alice": 100, "tz1bob": "50", "tz1zero": 0, "": 5}}
+	}`), &m)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if m.Symbol != "OBJKT" || m.Rights != "CC-BY-4.0" || m.RightURI != "https://creativecommons.org/licenses/by/4.0/" || m.Type != "Artwork" {
+		t.Errorf("metadata = %+v", m)
+	}
+	// Values of the wrong type are kept as written rather than failing
+	if m.ExternalURI != "42" {
+		t.Errorf("ExternalURI = %q, want 42", m.ExternalURI)
+	}
+	if got := NameList(m.Creators); fmt.Sprint(got) != "[tz1alice Alice]" {
+		t.Errorf("NameList(creators) = %q", got)
+	}
+	if got := NameList(m.Contributors); fmt.Sprint(got) != "[Bob]" {
+		t.Errorf("NameList(contributors) = %q", got)
+	}
+	if got := m.DateTime(); got == nil || !got.Equal(time.Date(2021, 3, 1, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("DateTime() = %v", got)
+	}
+	want := []Royalty{{Address: "tz1alice", Share: 0.1}, {Address: "tz1bob", Share: 0.05}}
+	if got := m.RoyaltyList(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("RoyaltyList() = %+v, want %+v", got, want)
+	}
+}
+
+func TestTokenMetadata_DateTime(t *testing.T) {
+	tests := map[string]string{
+		"2021-03-01":          "2021-03-01T00:00:00Z",
+		"2021-03-01T12:30:00": "2021-03-01T12:30:00Z",
+		"2021":                "2021-01-01T00:00:00Z",
+		"last spring":         "",
+		"":                    "",
+	}
+	for date, want := range tests {
+		m := TokenMetadata{Date: Text(date)}
+		got := ""
+		if d := m.DateTime(); d != nil {
+			got = d.Format(time.RFC3339)
+		}
+		if got != want {
+			t.Errorf("DateTime(%q) = %q, want %q", date, got, want)
+		}
+	}
+}
+
+func TestTokenMetadata_MalformedRoyalties(t *testing.T) {
+	for _, royalties := range []string{`{"shares": {"tz1a": 10}}`, `{"decimals": -1, "shares": {"tz1a": 10}}`, `[1, 2]`, `"10%"`} {
+		m := TokenMetadata{Royalties: json.RawMessage(royalties)}
+		if got := m.RoyaltyList(); got != nil {
+			t.Errorf("RoyaltyList(%s) = %+v, want nil", royalties, got)
+		}
+	}
+}
+
+func TestTokenMetadata_CanonicalJSON(t *testing.T) {
+	var a, b TokenMetadata
+	json.Unmarshal([]byte(`{"name": "A", "artifactUri": "ipfs://Qm1", "mintingTool": "x", "royalties": {"decimals": 3}}`), &a)
+	json.Unmarshal([]byte(`{"royalties":{"decimals":3},"mintingTool":"x","artifactUri":"ipfs://Qm1","name":"A"}`), &b)
+
+	// Fields Porcupin doesn't read are kept, keys are sorted and numbers
+	// left as written
+	want := `{"artifactUri":"ipfs://Qm1","mintingTool":"x","name":"A","royalties":{"decimals":3}}`
+	if got := string(a.CanonicalJSON()); got != want {
+		t.Errorf("CanonicalJSON() = %s, want %s", got, want)
+	}
+	if string(a.CanonicalJSON()) != string(b.CanonicalJSON()) {
+		t.Errorf("Same metadata in another order gave %s", b.CanonicalJSON())
+	}
+
+	// Metadata built in code has no raw form to keep
+	built := TokenMetadata{Name: "Built"}
+	if got := string(built.CanonicalJSON()); !strings.Contains(got, `"name":"Built"`) {
+		t.Errorf("CanonicalJSON() of built metadata = %s", got)
+	}
+}
+
+func TestFetchTokenMetadataEntry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/contracts/KT1a/bigmaps":
+			fmt.Fprint(w, `[{"ptr": 7, "path": "ledger"}, {"ptr": 8, "path": "token_metadata"}]`)
+		case r.URL.Path == "/v1/bigmaps/keys" && r.URL.Query().Get("bigmap") == "8":
+			switch r.URL.Query().Get("key") {
+			case "1":
+				fmt.Fprintf(w, `[{"value": {"token_id": "1", "token_info": {"": "%x"}}, "lastLevel": 4200}]`, "ipfs://QmMeta")
+			case "2": // Metadata stored on chain
+				fmt.Fprint(w, `[{"value": {"token_id": "2", "token_info": {"name": "4f6e"}}, "lastLevel": 4300}]`)
+			default:
+				fmt.Fprint(w, `[]`)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	idx := NewIndexer(server.URL)
+	ctx := context.Background()
+
+	entry, err := idx.FetchTokenMetadataEntry(ctx, "KT1a", "1")
+	if err != nil || *entry != (TokenMetadataEntry{URI: "ipfs://QmMeta", Level: 4200}) {
+		t.Errorf("FetchTokenMetadataEntry(1) = %+v, %v", entry, err)
+	}
+
+	entry, err = idx.FetchTokenMetadataEntry(ctx, "KT1a", "2")
+	if err != nil || *entry != (TokenMetadataEntry{Level: 4300}) {
+		t.Errorf("FetchTokenMetadataEntry(2) = %+v, %v, want level 4300 and no URI", entry, err)
+	}
+	if _, err := idx.FetchRawMetadataURI(ctx, "KT1a", "2"); err == nil {
+		t.Error("FetchRawMetadataURI should fail for on-chain metadata")
+	}
+
+	if _, err := idx.FetchTokenMetadataEntry(ctx, "KT1a", "3"); err == nil {
+		t.Error("FetchTokenMetadataEntry should fail for an unknown token")
+	}
+}
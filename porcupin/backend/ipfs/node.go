@@ -12,6 +12,8 @@ import (
 	"sync"
 	"time"
 
+	"porcupin/backend/storage"
+
 	"github.com/ipfs/kubo/config"
 	"github.com/ipfs/kubo/core"
 	"github.com/ipfs/kubo/core/coreapi"
@@ -26,19 +28,33 @@ import (
 	"github.com/ipfs/boxo/path"
 	iface "github.com/ipfs/kubo/core/coreiface"
 	"github.com/ipfs/kubo/core/coreiface/options"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
 )
 
+// replicationTag protects connections to replication peers from the connection manager
+const replicationTag = "porcupin-replication"
+
 // ShutdownTimeout is the maximum time to wait for IPFS node to shut down gracefully
 const ShutdownTimeout = 30 * time.Second
 
 // DefaultSwarmPort is the default port for IPFS swarm connections
 const DefaultSwarmPort = 4001
 
+// Plugins can only be injected once per process, so a node restarted after
+// Stop (e.g. following a storage migration) reuses the first injection
+var (
+	pluginsOnce sync.Once
+	pluginsErr  error
+)
+
 // Node represents an embedded IPFS node
 type Node struct {
 	api       iface.CoreAPI
 	node      *core.IpfsNode
 	repoPath  string
+	coldPath  string           // cold tier volume; empty when tiering is off
+	tiers     *tieredDatastore // set while running with a cold tier
 	swarmPort int
 	mu        sync.RWMutex
 	cancel    context.CancelFunc
@@ -79,6 +95,16 @@ func (n *Node) Start(ctx context.Context) error {
 
 	log.Printf("IPFS node starting (repo: %s, swarm port: %d)...", n.repoPath, n.swarmPort)
 
+	// Never create a repository on the root disk in place of an unmounted volume
+	if err := storage.CheckAvailable(n.repoPath, false); err != nil {
+		return fmt.Errorf("IPFS repository unavailable: %w", err)
+	}
+	if n.coldPath != "" {
+		if err := storage.CheckAvailable(n.coldPath, false); err != nil {
+			return fmt.Errorf("cold storage tier unavailable: %w", err)
+		}
+	}
+
 	// Remove stale lock file if it exists from a previous unclean shutdown
 	// This can happen after a crash, forced quit, or migration
 	lockFile := filepath.Join(n.repoPath, "repo.lock")
@@ -121,6 +147,18 @@ func (n *Node) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to update swarm port: %w", err)
 	}
 
+	// Split block storage across the cold tier when one is configured
+	var tiers *tieredDatastore
+	if n.coldPath != "" {
+		tiers, err = openTieredDatastore(repo.Datastore(), n.coldPath)
+		if err != nil {
+			repo.Close()
+			return err
+		}
+		repo = &tieredRepo{Repo: repo, tiers: tiers}
+		log.Printf("Cold storage tier at %s", n.coldPath)
+	}
+
 	// Construct node
 	nodeOptions := &core.BuildCfg{
 		Online:  true,
@@ -143,6 +181,7 @@ func (n *Node) Start(ctx context.Context) error {
 
 	n.node = node
 	n.api = api
+	n.tiers = tiers
 	n.ctx, n.cancel = context.WithCancel(ctx)
 	
 	log.Printf("IPFS node started successfully")
@@ -193,6 +232,7 @@ func (n *Node) Stop() error {
 	// Clear our references regardless of how we exited
 	n.node = nil
 	n.api = nil
+	n.tiers = nil
 
 	// Remove the repo lock file if it exists - this is necessary when:
 	// 1. The node didn't shut down cleanly (timeout)
@@ -369,45 +409,174 @@ func (n *Node) Stat(ctx context.Context, cidStr string) (int64, error) {
 	return size, nil
 }
 
+// PeerID returns the libp2p peer ID of the node, or "" if it is not started
+func (n *Node) PeerID() string {
+	if n == nil {
+		return ""
+	}
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if n.node == nil {
+		return ""
+	}
+	return n.node.Identity.String()
+}
+
+// PeerAddrs returns the node's libp2p listen addresses, each ending in /p2p/<peer ID>
+func (n *Node) PeerAddrs() []string {
+	if n == nil {
+		return nil
+	}
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if n.node == nil || n.node.PeerHost == nil {
+		return nil
+	}
+
+	id := n.node.Identity
+	addrs := make([]string, 0, len(n.node.PeerHost.Addrs()))
+	for _, addr := range n.node.PeerHost.Addrs() {
+		addrs = append(addrs, fmt.Sprintf("%s/p2p/%s", addr, id))
+	}
+	return addrs
+}
+
+// ConnectPeer opens a direct connection to a peer given its /p2p/ multiaddrs,
+// so content is fetched from it rather than found through the DHT. The connection
+// is protected from being trimmed by the connection manager.
+func (n *Node) ConnectPeer(ctx context.Context, addrs []string) error {
+	if n == nil {
+		return fmt.Errorf("node not started")
+	}
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if n.api == nil {
+		return fmt.Errorf("node not started")
+	}
+
+	maddrs := make([]ma.Multiaddr, 0, len(addrs))
+	for _, a := range addrs {
+		maddr, err := ma.NewMultiaddr(a)
+		if err != nil {
+			return fmt.Errorf("invalid address %q: %w", a, err)
+		}
+		maddrs = append(maddrs, maddr)
+	}
+
+	infos, err := peer.AddrInfosFromP2pAddrs(maddrs...)
+	if err != nil {
+		return fmt.Errorf("invalid peer address: %w", err)
+	}
+
+	var lastErr error
+	connected := 0
+	for _, info := range infos {
+		if err := n.api.Swarm().Connect(ctx, info); err != nil {
+			lastErr = err
+			continue
+		}
+		n.node.PeerHost.ConnManager().Protect(info.ID, replicationTag)
+		connected++
+	}
+	if connected == 0 && lastErr != nil {
+		return fmt.Errorf("failed to connect: %w", lastErr)
+	}
+	return nil
+}
+
+// IsRunning reports whether the node has been started and not stopped
+func (n *Node) IsRunning() bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.node != nil
+}
+
 // GetRepoPath returns the path to the IPFS repository
 func (n *Node) GetRepoPath() string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
 	return n.repoPath
 }
 
+// RepoInUse reports whether another process holds the repository open
+func RepoInUse(repoPath string) (bool, error) {
+	if !fsrepo.IsInitialized(repoPath) {
+		return false, nil
+	}
+	return fsrepo.LockedByOtherProcess(repoPath)
+}
+
+// SetRepoPath points a stopped node at another repository, after the
+// repository has been moved. Start opens the new location.
+func (n *Node) SetRepoPath(repoPath string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.node != nil {
+		return fmt.Errorf("cannot change the repository of a running node")
+	}
+	if len(repoPath) > 0 && repoPath[0] == '~' {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		repoPath = filepath.Join(home, repoPath[1:])
+	}
+	n.repoPath = repoPath
+	return nil
+}
+
 // ProgressCallback is called during long operations to report progress
 type ProgressCallback func(total, current int)
 
-// UnpinAll removes all recursive pins from the node
-func (n *Node) UnpinAll(ctx context.Context, progress ProgressCallback) (int, error) {
+// ListPins returns the CIDs of the node's recursive pins
+func (n *Node) ListPins(ctx context.Context) ([]string, error) {
 	n.mu.RLock()
 	defer n.mu.RUnlock()
 
 	if n.api == nil {
-		return 0, fmt.Errorf("node not started")
+		return nil, fmt.Errorf("node not started")
 	}
+	return n.listPins(ctx)
+}
 
-	// Create a channel for Pin().Ls to write pins to
-	pinChan := make(chan iface.Pin)
-	
-	// Collect all CIDs first, then unpin them
-	// This avoids issues with modifying pins while iterating
-	var cids []string
-	
+// listPins lists the recursive pins; the caller holds n.mu
+func (n *Node) listPins(ctx context.Context) ([]string, error) {
 	// Start listing pins in a goroutine - Ls closes the channel when done
+	pinChan := make(chan iface.Pin)
 	errChan := make(chan error, 1)
 	go func() {
 		errChan <- n.api.Pin().Ls(ctx, pinChan, options.Pin.Ls.Recursive())
 	}()
 
-	// Collect all CIDs from the channel
+	var cids []string
 	for pin := range pinChan {
 		cids = append(cids, pin.Path().RootCid().String())
 	}
-
-	// Check if Ls had an error
 	if err := <-errChan; err != nil {
+		return nil, fmt.Errorf("failed to list pins: %w", err)
+	}
+	return cids, nil
+}
+
+// UnpinAll removes all recursive pins from the node
+func (n *Node) UnpinAll(ctx context.Context, progress ProgressCallback) (int, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if n.api == nil {
+		return 0, fmt.Errorf("node not started")
+	}
+
+	// Collect all CIDs first, then unpin them
+	// This avoids issues with modifying pins while iterating
+	cids, err := n.listPins(ctx)
+	if err != nil {
 		log.Printf("Error listing pins: %v", err)
-		return 0, fmt.Errorf("failed to list pins: %w", err)
+		return 0, err
 	}
 
 	total := len(cids)
@@ -648,6 +817,14 @@ func detectMimeType(data []byte) string {
 
 // Helper to setup plugins (required for Kubo)
 func setupPlugins(externalPluginsPath string) error {
+	pluginsOnce.Do(func() {
+		pluginsErr = loadPlugins(externalPluginsPath)
+	})
+	return pluginsErr
+}
+
+// loadPlugins loads, initializes and injects the kubo plugins
+func loadPlugins(externalPluginsPath string) error {
 	plugins, err := loader.NewPluginLoader(filepath.Join(externalPluginsPath, "plugins"))
 	if err != nil {
 		return fmt.Errorf("error loading plugins: %s", err)
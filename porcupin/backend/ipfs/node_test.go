@@ -7,6 +7,10 @@ import (
 	"path/filepath"
 	"testing"
 	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	dssync "github.com/ipfs/go-datastore/sync"
 )
 
 func TestNodePinAndVerify(t *testing.T) {
@@ -50,6 +54,15 @@ func TestNodePinAndVerify(t *testing.T) {
 		t.Error("Content should be pinned after Add")
 	}
 
+	// Test ListPins
+	pins, err := node.ListPins(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list pins: %v", err)
+	}
+	if len(pins) != 1 || pins[0] != cid {
+		t.Errorf("ListPins = %v, want [%s]", pins, cid)
+	}
+
 	// Test Verify
 	result := node.Verify(ctx, cid, 30*time.Second)
 	if !result.IsPinned {
@@ -87,6 +100,41 @@ func TestNodePinAndVerify(t *testing.T) {
 	if pinned {
 		t.Error("Content should not be pinned after Unpin")
 	}
+	if pins, _ := node.ListPins(ctx); len(pins) != 0 {
+		t.Errorf("ListPins after Unpin = %v, want none", pins)
+	}
+}
+
+func TestNodeRestart(t *testing.T) {
+	node, err := NewNode(filepath.Join(t.TempDir(), "ipfs"), 0)
+	if err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	if err := node.Start(ctx); err != nil {
+		t.Fatalf("Failed to start node: %v", err)
+	}
+	cid, err := node.Add(ctx, bytes.NewReader([]byte("survives a restart")))
+	if err != nil {
+		t.Fatalf("Failed to add content: %v", err)
+	}
+	if err := node.Stop(); err != nil {
+		t.Fatalf("Failed to stop node: %v", err)
+	}
+
+	// A stopped node can be started again in the same process
+	if err := node.Start(ctx); err != nil {
+		t.Fatalf("Failed to restart node: %v", err)
+	}
+	defer node.Stop()
+
+	pinned, err := node.IsPinned(ctx, cid)
+	if err != nil || !pinned {
+		t.Errorf("IsPinned after restart = %v, %v", pinned, err)
+	}
 }
 
 func TestDetectMimeType(t *testing.T) {
@@ -136,3 +184,135 @@ func TestDetectMimeType(t *testing.T) {
 		})
 	}
 }
+
+func TestTieredDatastore(t *testing.T) {
+	ctx := context.Background()
+	hot := dssync.MutexWrap(ds.NewMapDatastore())
+	tiers, err := openTieredDatastore(hot, t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open tiered datastore: %v", err)
+	}
+	defer tiers.Close()
+
+	block := ds.NewKey("/blocks/CIQABC")
+	other := ds.NewKey("/pins/state")
+	if err := tiers.Put(ctx, block, []byte("block data")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := tiers.Put(ctx, other, []byte("state")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	moved, err := tiers.demote(ctx, block)
+	if err != nil {
+		t.Fatalf("demote failed: %v", err)
+	}
+	if moved != len("block data") {
+		t.Errorf("demote moved %d bytes, expected %d", moved, len("block data"))
+	}
+	if inHot, _ := hot.Has(ctx, block); inHot {
+		t.Error("Block should have left the hot tier")
+	}
+	if _, err := tiers.demote(ctx, other); err == nil {
+		t.Error("Only blocks should be movable to the cold tier")
+	}
+
+	// Reads fall through to the cold tier
+	data, err := tiers.Get(ctx, block)
+	if err != nil || string(data) != "block data" {
+		t.Errorf("Get = %q, %v", data, err)
+	}
+	if size, err := tiers.GetSize(ctx, block); err != nil || size != len("block data") {
+		t.Errorf("GetSize = %d, %v", size, err)
+	}
+
+	// Block queries list both tiers
+	results, err := tiers.Query(ctx, query.Query{Prefix: "/blocks", KeysOnly: true})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	entries, err := results.Rest()
+	if err != nil {
+		t.Fatalf("Query results failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Key != block.String() {
+		t.Errorf("Query returned %v, expected only %s", entries, block)
+	}
+
+	// Deleting through a batch removes the cold copy
+	batch, err := tiers.Batch(ctx)
+	if err != nil {
+		t.Fatalf("Batch failed: %v", err)
+	}
+	if err := batch.Delete(ctx, block); err != nil {
+		t.Fatalf("Batch delete failed: %v", err)
+	}
+	if err := batch.Commit(ctx); err != nil {
+		t.Fatalf("Batch commit failed: %v", err)
+	}
+	if exists, _ := tiers.Has(ctx, block); exists {
+		t.Error("Block should be deleted from the cold tier")
+	}
+}
+
+func TestNodeMoveToCold(t *testing.T) {
+	tmpDir := t.TempDir()
+	coldPath := filepath.Join(tmpDir, "cold")
+
+	node, err := NewNode(filepath.Join(tmpDir, "ipfs"), 0)
+	if err != nil {
+		t.Fatalf("Failed to create node: %v", err)
+	}
+	if err := node.SetColdPath(coldPath); err != nil {
+		t.Fatalf("Failed to set cold path: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	if err := node.Start(ctx); err != nil {
+		t.Fatalf("Failed to start node: %v", err)
+	}
+	defer node.Stop()
+
+	if err := node.SetColdPath(""); err == nil {
+		t.Error("Changing the cold tier of a running node should fail")
+	}
+
+	// Large enough to be split across several blocks
+	content := bytes.Repeat([]byte("porcupin cold tier "), 50000)
+	cid, err := node.Add(ctx, bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("Failed to add content: %v", err)
+	}
+
+	moved, err := node.MoveToCold(ctx, cid)
+	if err != nil {
+		t.Fatalf("MoveToCold failed: %v", err)
+	}
+	if moved < int64(len(content)) {
+		t.Errorf("Moved %d bytes, expected at least %d", moved, len(content))
+	}
+	if again, err := node.MoveToCold(ctx, cid); err != nil || again != 0 {
+		t.Errorf("Second MoveToCold = %d, %v; expected nothing to move", again, err)
+	}
+
+	// Pinned cold blocks survive garbage collection and a restart
+	if err := node.GarbageCollect(ctx); err != nil {
+		t.Fatalf("GarbageCollect failed: %v", err)
+	}
+	if err := node.Stop(); err != nil {
+		t.Fatalf("Failed to stop node: %v", err)
+	}
+	if err := node.Start(ctx); err != nil {
+		t.Fatalf("Failed to restart node: %v", err)
+	}
+
+	data, _, err := node.Cat(ctx, cid, int64(len(content)))
+	if err != nil {
+		t.Fatalf("Failed to cat cold content: %v", err)
+	}
+	if !bytes.Equal(data, content) {
+		t.Error("Cat returned different content after moving to the cold tier")
+	}
+}
@@ -0,0 +1,332 @@
+package ipfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ipfs/boxo/blockservice"
+	"github.com/ipfs/boxo/blockstore"
+	"github.com/ipfs/boxo/datastore/dshelp"
+	"github.com/ipfs/boxo/ipld/merkledag"
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	flatfs "github.com/ipfs/go-ds-flatfs"
+	"github.com/ipfs/kubo/repo"
+)
+
+// coldBlocksDir is the directory under the cold path holding the flatfs block store
+const coldBlocksDir = "blocks"
+
+// tieredDatastore stores blocks across two tiers. Everything is written to
+// the hot datastore (the repo's own); blocks moved to the cold tier live in a
+// flatfs store on another volume. Reads check the hot tier first, so a block
+// being moved is readable from one tier or the other throughout.
+type tieredDatastore struct {
+	hot  repo.Datastore
+	cold *flatfs.Datastore
+}
+
+// openTieredDatastore wraps hot with a cold tier stored under coldPath
+func openTieredDatastore(hot repo.Datastore, coldPath string) (*tieredDatastore, error) {
+	dir := filepath.Join(coldPath, coldBlocksDir)
+	if err := os.MkdirAll(coldPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cold tier: %w", err)
+	}
+	cold, err := flatfs.CreateOrOpen(dir, flatfs.NextToLast(2), true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cold tier: %w", err)
+	}
+	return &tieredDatastore{hot: hot, cold: cold}, nil
+}
+
+// coldKey maps a datastore key to its cold tier key. Only blocks can be
+// stored cold.
+func coldKey(key ds.Key) (ds.Key, bool) {
+	if !blockstore.BlockPrefix.IsAncestorOf(key) {
+		return ds.Key{}, false
+	}
+	k := ds.NewKey(key.String()[len(blockstore.BlockPrefix.String()):])
+	if len(k.Namespaces()) != 1 {
+		return ds.Key{}, false
+	}
+	return k, true
+}
+
+// includesBlocks reports whether a query or sync prefix covers the blocks namespace
+func includesBlocks(prefix ds.Key) bool {
+	return prefix.String() == "/" || prefix.Equal(blockstore.BlockPrefix) || blockstore.BlockPrefix.IsAncestorOf(prefix)
+}
+
+func (t *tieredDatastore) Get(ctx context.Context, key ds.Key) ([]byte, error) {
+	value, err := t.hot.Get(ctx, key)
+	if !errors.Is(err, ds.ErrNotFound) {
+		return value, err
+	}
+	if ck, ok := coldKey(key); ok {
+		return t.cold.Get(ctx, ck)
+	}
+	return nil, err
+}
+
+func (t *tieredDatastore) Has(ctx context.Context, key ds.Key) (bool, error) {
+	exists, err := t.hot.Has(ctx, key)
+	if err != nil || exists {
+		return exists, err
+	}
+	if ck, ok := coldKey(key); ok {
+		return t.cold.Has(ctx, ck)
+	}
+	return false, nil
+}
+
+func (t *tieredDatastore) GetSize(ctx context.Context, key ds.Key) (int, error) {
+	size, err := t.hot.GetSize(ctx, key)
+	if !errors.Is(err, ds.ErrNotFound) {
+		return size, err
+	}
+	if ck, ok := coldKey(key); ok {
+		return t.cold.GetSize(ctx, ck)
+	}
+	return size, err
+}
+
+func (t *tieredDatastore) Put(ctx context.Context, key ds.Key, value []byte) error {
+	return t.hot.Put(ctx, key, value)
+}
+
+// Delete removes the key from both tiers
+func (t *tieredDatastore) Delete(ctx context.Context, key ds.Key) error {
+	if err := t.hot.Delete(ctx, key); err != nil {
+		return err
+	}
+	if ck, ok := coldKey(key); ok {
+		return t.cold.Delete(ctx, ck)
+	}
+	return nil
+}
+
+func (t *tieredDatastore) Sync(ctx context.Context, prefix ds.Key) error {
+	if err := t.hot.Sync(ctx, prefix); err != nil {
+		return err
+	}
+	if includesBlocks(prefix) {
+		return t.cold.Sync(ctx, ds.NewKey("/"))
+	}
+	return nil
+}
+
+// Query lists both tiers when the query covers blocks. The inner queries
+// only select keys; filters, ordering and paging are applied to the merged
+// results.
+func (t *tieredDatastore) Query(ctx context.Context, q query.Query) (query.Results, error) {
+	if !includesBlocks(ds.NewKey(q.Prefix)) {
+		return t.hot.Query(ctx, q)
+	}
+
+	inner := query.Query{
+		Prefix:       q.Prefix,
+		KeysOnly:     q.KeysOnly,
+		ReturnsSizes: q.ReturnsSizes,
+	}
+	hot, err := t.hot.Query(ctx, inner)
+	if err != nil {
+		return nil, err
+	}
+	inner.Prefix = "/"
+	cold, err := t.cold.Query(ctx, inner)
+	if err != nil {
+		hot.Close()
+		return nil, err
+	}
+
+	merged := query.ResultsWithContext(q, func(qctx context.Context, out chan<- query.Result) {
+		defer hot.Close()
+		defer cold.Close()
+
+		send := func(r query.Result) bool {
+			select {
+			case out <- r:
+				return true
+			case <-qctx.Done():
+				return false
+			}
+		}
+		for r := range hot.Next() {
+			if !send(r) {
+				return
+			}
+		}
+		for r := range cold.Next() {
+			if r.Error == nil {
+				r.Key = blockstore.BlockPrefix.Child(ds.RawKey(r.Key)).String()
+				// A block still in the hot tier was listed already
+				if inHot, _ := t.hot.Has(qctx, ds.RawKey(r.Key)); inHot {
+					continue
+				}
+			}
+			if !send(r) {
+				return
+			}
+		}
+	})
+	return query.NaiveQueryApply(q, merged), nil
+}
+
+func (t *tieredDatastore) Batch(ctx context.Context) (ds.Batch, error) {
+	hot, err := t.hot.Batch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tieredBatch{tiers: t, hot: hot}, nil
+}
+
+// Close closes the cold tier. The hot datastore belongs to the repo.
+func (t *tieredDatastore) Close() error {
+	return t.cold.Close()
+}
+
+// demote moves a block from the hot tier to the cold tier, returning the
+// bytes moved. Blocks already cold are left alone.
+func (t *tieredDatastore) demote(ctx context.Context, key ds.Key) (int, error) {
+	ck, ok := coldKey(key)
+	if !ok {
+		return 0, fmt.Errorf("%s is not a block", key)
+	}
+	value, err := t.hot.Get(ctx, key)
+	if errors.Is(err, ds.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := t.cold.Put(ctx, ck, value); err != nil {
+		return 0, err
+	}
+	if err := t.hot.Delete(ctx, key); err != nil {
+		return 0, err
+	}
+	return len(value), nil
+}
+
+// tieredBatch writes to the hot tier and deletes from both
+type tieredBatch struct {
+	tiers   *tieredDatastore
+	hot     ds.Batch
+	deletes []ds.Key
+}
+
+func (b *tieredBatch) Put(ctx context.Context, key ds.Key, value []byte) error {
+	return b.hot.Put(ctx, key, value)
+}
+
+func (b *tieredBatch) Delete(ctx context.Context, key ds.Key) error {
+	if ck, ok := coldKey(key); ok {
+		b.deletes = append(b.deletes, ck)
+	}
+	return b.hot.Delete(ctx, key)
+}
+
+func (b *tieredBatch) Commit(ctx context.Context) error {
+	if err := b.hot.Commit(ctx); err != nil {
+		return err
+	}
+	for _, key := range b.deletes {
+		if err := b.tiers.cold.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	b.deletes = nil
+	return nil
+}
+
+// tieredRepo is a repo whose datastore has a cold tier
+type tieredRepo struct {
+	repo.Repo
+	tiers *tieredDatastore
+}
+
+func (r *tieredRepo) Datastore() repo.Datastore {
+	return r.tiers
+}
+
+func (r *tieredRepo) Close() error {
+	coldErr := r.tiers.Close()
+	if err := r.Repo.Close(); err != nil {
+		return err
+	}
+	return coldErr
+}
+
+// GetColdPath returns where the cold tier is stored, or "" when tiering is off
+func (n *Node) GetColdPath() string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.coldPath
+}
+
+// SetColdPath sets the volume large blocks are moved to. It takes effect the
+// next time the node starts; an empty path turns tiering off.
+func (n *Node) SetColdPath(coldPath string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.node != nil {
+		return fmt.Errorf("cannot change the cold tier of a running node")
+	}
+	if len(coldPath) > 0 && coldPath[0] == '~' {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		coldPath = filepath.Join(home, coldPath[1:])
+	}
+	n.coldPath = coldPath
+	return nil
+}
+
+// MoveToCold moves every block of a pinned DAG to the cold tier and returns
+// the number of bytes moved. Blocks are read from the local blockstore only.
+func (n *Node) MoveToCold(ctx context.Context, cidStr string) (int64, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if n.node == nil {
+		return 0, fmt.Errorf("node not started")
+	}
+	if n.tiers == nil {
+		return 0, fmt.Errorf("cold tier not configured")
+	}
+
+	root, err := cid.Decode(cidStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cid: %w", err)
+	}
+
+	dag := merkledag.NewDAGService(blockservice.New(n.node.Blockstore, nil))
+	var blocks []cid.Cid
+	err = merkledag.Walk(ctx, merkledag.GetLinksWithDAG(dag), root, func(c cid.Cid) bool {
+		blocks = append(blocks, c)
+		return true
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to walk %s: %w", cidStr, err)
+	}
+
+	var moved int64
+	for _, c := range blocks {
+		if err := ctx.Err(); err != nil {
+			return moved, err
+		}
+		key := blockstore.BlockPrefix.Child(dshelp.MultihashToDsKey(c.Hash()))
+		size, err := n.tiers.demote(ctx, key)
+		if err != nil {
+			return moved, fmt.Errorf("failed to move block %s: %w", c, err)
+		}
+		moved += int64(size)
+	}
+	return moved, nil
+}
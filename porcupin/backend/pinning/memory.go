@@ -0,0 +1,258 @@
+package pinning
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryService is an in-memory Pinning Service API, for testing mirroring
+// without a real provider. Pins are accepted without fetching any content.
+type MemoryService struct {
+	token string
+
+	mu            sync.Mutex
+	initialStatus string
+	pins          map[string]*PinStatus
+}
+
+// NewMemoryService creates a stand-in service that accepts the given access
+// token. New pins report as pinned straight away.
+func NewMemoryService(token string) *MemoryService {
+	return &MemoryService{
+		token:         token,
+		initialStatus: StatusPinned,
+		pins:          make(map[string]*PinStatus),
+	}
+}
+
+// SetInitialStatus sets the status new pin requests start in
+func (m *MemoryService) SetInitialStatus(status string) {
+	m.mu.Lock()
+	m.initialStatus = status
+	m.mu.Unlock()
+}
+
+// Pins returns every pin request held by the service, oldest first
+func (m *MemoryService) Pins() []PinStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sortedLocked()
+}
+
+// SetStatus changes the status of every pin request for a CID
+func (m *MemoryService) SetStatus(cid, status string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range m.pins {
+		if p.Pin.CID == cid {
+			p.Status = status
+		}
+	}
+}
+
+// Drop deletes every pin request for a CID, as if the provider lost it
+func (m *MemoryService) Drop(cid string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, p := range m.pins {
+		if p.Pin.CID == cid {
+			delete(m.pins, id)
+		}
+	}
+}
+
+// sortedLocked returns the pins oldest first. Caller holds mu.
+func (m *MemoryService) sortedLocked() []PinStatus {
+	pins := make([]PinStatus, 0, len(m.pins))
+	for _, p := range m.pins {
+		pins = append(pins, *p)
+	}
+	sort.Slice(pins, func(i, j int) bool {
+		return pins[i].Created.Before(pins[j].Created)
+	})
+	return pins
+}
+
+// ServeHTTP implements the /pins endpoints of the spec
+func (m *MemoryService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+m.token {
+		writeFailure(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid access token")
+		return
+	}
+
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	switch {
+	case path == "/pins" && r.Method == http.MethodGet:
+		m.list(w, r)
+	case path == "/pins" && r.Method == http.MethodPost:
+		m.add(w, r, "")
+	case strings.HasPrefix(path, "/pins/"):
+		id := strings.TrimPrefix(path, "/pins/")
+		switch r.Method {
+		case http.MethodGet:
+			m.get(w, id)
+		case http.MethodPost:
+			m.add(w, r, id)
+		case http.MethodDelete:
+			m.remove(w, id)
+		default:
+			writeFailure(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "")
+		}
+	default:
+		writeFailure(w, http.StatusNotFound, "NOT_FOUND", "")
+	}
+}
+
+// list handles GET /pins
+func (m *MemoryService) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	cids := map[string]bool{}
+	for _, c := range strings.Split(q.Get("cid"), ",") {
+		if c != "" {
+			cids[c] = true
+		}
+	}
+	statuses := map[string]bool{}
+	for _, s := range strings.Split(q.Get("status"), ",") {
+		if s != "" {
+			statuses[s] = true
+		}
+	}
+	if len(statuses) == 0 {
+		statuses[StatusPinned] = true
+	}
+	var before *time.Time
+	if v := q.Get("before"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			writeFailure(w, http.StatusBadRequest, "BAD_REQUEST", "invalid before")
+			return
+		}
+		before = &t
+	}
+	limit := 10
+	if v := q.Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l < 1 || l > MaxListLimit {
+			writeFailure(w, http.StatusBadRequest, "BAD_REQUEST", "invalid limit")
+			return
+		}
+		limit = l
+	}
+
+	m.mu.Lock()
+	all := m.sortedLocked()
+	m.mu.Unlock()
+
+	results := PinResults{Results: []PinStatus{}}
+	// Newest first, as the spec requires
+	for i := len(all) - 1; i >= 0; i-- {
+		p := all[i]
+		if len(cids) > 0 && !cids[p.Pin.CID] {
+			continue
+		}
+		if name := q.Get("name"); name != "" && p.Pin.Name != name {
+			continue
+		}
+		if !statuses[p.Status] || (before != nil && !p.Created.Before(*before)) {
+			continue
+		}
+		results.Count++
+		if len(results.Results) < limit {
+			results.Results = append(results.Results, p)
+		}
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+// add handles POST /pins and POST /pins/{id}, which replaces a pin request
+func (m *MemoryService) add(w http.ResponseWriter, r *http.Request, replaceID string) {
+	var pin Pin
+	if err := json.NewDecoder(r.Body).Decode(&pin); err != nil || pin.CID == "" {
+		writeFailure(w, http.StatusBadRequest, "BAD_REQUEST", "a cid is required")
+		return
+	}
+	if len(pin.Name) > MaxNameLength {
+		writeFailure(w, http.StatusBadRequest, "BAD_REQUEST", "name is too long")
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if replaceID != "" {
+		if _, ok := m.pins[replaceID]; !ok {
+			writeFailure(w, http.StatusNotFound, "NOT_FOUND", "")
+			return
+		}
+		delete(m.pins, replaceID)
+	}
+
+	st := &PinStatus{
+		RequestID: newRequestID(),
+		Status:    m.initialStatus,
+		Created:   time.Now().UTC(),
+		Pin:       pin,
+		Delegates: []string{},
+	}
+	m.pins[st.RequestID] = st
+	writeJSON(w, http.StatusAccepted, st)
+}
+
+// get handles GET /pins/{id}
+func (m *MemoryService) get(w http.ResponseWriter, id string) {
+	m.mu.Lock()
+	p, ok := m.pins[id]
+	var st PinStatus
+	if ok {
+		st = *p
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		writeFailure(w, http.StatusNotFound, "NOT_FOUND", "")
+		return
+	}
+	writeJSON(w, http.StatusOK, st)
+}
+
+// remove handles DELETE /pins/{id}
+func (m *MemoryService) remove(w http.ResponseWriter, id string) {
+	m.mu.Lock()
+	_, ok := m.pins[id]
+	delete(m.pins, id)
+	m.mu.Unlock()
+
+	if !ok {
+		writeFailure(w, http.StatusNotFound, "NOT_FOUND", "")
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// newRequestID returns a random pin request ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeFailure(w http.ResponseWriter, status int, reason, details string) {
+	var f failure
+	f.Error.Reason = reason
+	f.Error.Details = details
+	writeJSON(w, status, f)
+}
@@ -0,0 +1,232 @@
+// Package pinning is a client for the IPFS Pinning Service API
+// (https://ipfs.github.io/pinning-services-api-spec/), used to mirror pins
+// to commercial or self-hosted pinning providers.
+package pinning
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pin status values defined by the spec
+const (
+	StatusQueued  = "queued"
+	StatusPinning = "pinning"
+	StatusPinned  = "pinned"
+	StatusFailed  = "failed"
+)
+
+// MaxListLimit is the largest page the spec allows when listing pins
+const MaxListLimit = 1000
+
+// MaxNameLength is the longest pin name the spec allows
+const MaxNameLength = 255
+
+// ErrNotFound is returned when a pin request does not exist at the service
+var ErrNotFound = errors.New("pin request not found")
+
+// Pin is a request to pin a CID
+type Pin struct {
+	CID     string            `json:"cid"`
+	Name    string            `json:"name,omitempty"`
+	Origins []string          `json:"origins,omitempty"` // Multiaddrs the service can fetch the content from
+	Meta    map[string]string `json:"meta,omitempty"`
+}
+
+// PinStatus is the state of a pin request at the service
+type PinStatus struct {
+	RequestID string            `json:"requestid"`
+	Status    string            `json:"status"`
+	Created   time.Time         `json:"created"`
+	Pin       Pin               `json:"pin"`
+	Delegates []string          `json:"delegates"`
+	Info      map[string]string `json:"info,omitempty"`
+}
+
+// PinResults is a page of pin requests
+type PinResults struct {
+	Count   int         `json:"count"` // Matching pins in total, not just in this page
+	Results []PinStatus `json:"results"`
+}
+
+// ListOptions filters the pin requests returned by List
+type ListOptions struct {
+	CIDs   []string
+	Name   string
+	Status []string   // Defaults to pinned only, as in the spec
+	Before *time.Time // Only pins created before this time, for paging
+	Limit  int
+}
+
+// Error is a failure response from the service
+type Error struct {
+	StatusCode int
+	Reason     string
+	Details    string
+}
+
+func (e *Error) Error() string {
+	if e.Details != "" {
+		return fmt.Sprintf("pinning service returned %d: %s: %s", e.StatusCode, e.Reason, e.Details)
+	}
+	return fmt.Sprintf("pinning service returned %d: %s", e.StatusCode, e.Reason)
+}
+
+// failure is the error body defined by the spec
+type failure struct {
+	Error struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details,omitempty"`
+	} `json:"error"`
+}
+
+// Client talks to one pinning service
+type Client struct {
+	endpoint   string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for a pinning service. endpoint is the API root,
+// e.g. https://api.pinata.cloud/psa, and token its access token.
+func NewClient(endpoint, token string) *Client {
+	return &Client{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		token:    token,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// ValidateEndpoint checks that endpoint is an http(s) URL usable as an API root
+func ValidateEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return errors.New("endpoint must be a URL such as https://api.example.com/psa")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("endpoint must use http or https")
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return errors.New("endpoint must not have a query or fragment")
+	}
+	return nil
+}
+
+// Add asks the service to pin a CID
+func (c *Client) Add(ctx context.Context, pin Pin) (*PinStatus, error) {
+	var st PinStatus
+	if err := c.do(ctx, "POST", "/pins", nil, pin, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Get returns the status of a pin request
+func (c *Client) Get(ctx context.Context, requestID string) (*PinStatus, error) {
+	var st PinStatus
+	if err := c.do(ctx, "GET", "/pins/"+url.PathEscape(requestID), nil, nil, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// List returns pin requests matching the options, newest first
+func (c *Client) List(ctx context.Context, opts ListOptions) (*PinResults, error) {
+	q := url.Values{}
+	if len(opts.CIDs) > 0 {
+		q.Set("cid", strings.Join(opts.CIDs, ","))
+	}
+	if opts.Name != "" {
+		q.Set("name", opts.Name)
+	}
+	if len(opts.Status) > 0 {
+		q.Set("status", strings.Join(opts.Status, ","))
+	}
+	if opts.Before != nil {
+		q.Set("before", opts.Before.UTC().Format(time.RFC3339Nano))
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+
+	var results PinResults
+	if err := c.do(ctx, "GET", "/pins", q, nil, &results); err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
+// Remove cancels a pin request, unpinning the CID at the service
+func (c *Client) Remove(ctx context.Context, requestID string) error {
+	return c.do(ctx, "DELETE", "/pins/"+url.PathEscape(requestID), nil, nil, nil)
+}
+
+// do sends a request to the service and decodes a successful response into out
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	u := c.endpoint + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		bodyReader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, bodyReader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16*1024*1024))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &Error{StatusCode: resp.StatusCode, Reason: http.StatusText(resp.StatusCode)}
+		var f failure
+		if json.Unmarshal(data, &f) == nil && f.Error.Reason != "" {
+			apiErr.Reason = f.Error.Reason
+			apiErr.Details = f.Error.Details
+		}
+		return apiErr
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package pinning
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T) (*Client, *MemoryService) {
+	t.Helper()
+	svc := NewMemoryService("secret")
+	server := httptest.NewServer(svc)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL+"/", "secret"), svc
+}
+
+func TestClient_AddGetRemove(t *testing.T) {
+	client, svc := newTestClient(t)
+	ctx := context.Background()
+
+	st, err := client.Add(ctx, Pin{CID: "QmAdd", Name: "artwork", Meta: map[string]string{"wallet": "tz1abc"}})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if st.RequestID == "" || st.Status != StatusPinned || st.Pin.CID != "QmAdd" {
+		t.Fatalf("Add() = %+v", st)
+	}
+
+	got, err := client.Get(ctx, st.RequestID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Pin.Meta["wallet"] != "tz1abc" {
+		t.Errorf("Get() meta = %v, want wallet tz1abc", got.Pin.Meta)
+	}
+
+	if err := client.Remove(ctx, st.RequestID); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if len(svc.Pins()) != 0 {
+		t.Errorf("service still holds %d pins after Remove()", len(svc.Pins()))
+	}
+	if _, err := client.Get(ctx, st.RequestID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of removed pin error = %v, want ErrNotFound", err)
+	}
+	if err := client.Remove(ctx, st.RequestID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Remove() of removed pin error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClient_List(t *testing.T) {
+	client, svc := newTestClient(t)
+	ctx := context.Background()
+
+	for _, cid := range []string{"QmOne", "QmTwo", "QmThree"} {
+		if _, err := client.Add(ctx, Pin{CID: cid}); err != nil {
+			t.Fatalf("Add(%s) error = %v", cid, err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	svc.SetStatus("QmTwo", StatusFailed)
+
+	// Pinned only by default, newest first
+	res, err := client.List(ctx, ListOptions{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if res.Count != 2 || len(res.Results) != 2 || res.Results[0].Pin.CID != "QmThree" {
+		t.Errorf("List() = %+v, want QmThree and QmOne", res)
+	}
+
+	all := []string{StatusQueued, StatusPinning, StatusPinned, StatusFailed}
+	res, err = client.List(ctx, ListOptions{Status: all, Limit: 1})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if res.Count != 3 || len(res.Results) != 1 {
+		t.Fatalf("List(limit 1) = %+v, want count 3 with 1 result", res)
+	}
+
+	before := res.Results[0].Created
+	res, err = client.List(ctx, ListOptions{Status: all, Before: &before, Limit: MaxListLimit})
+	if err != nil {
+		t.Fatalf("List(before) error = %v", err)
+	}
+	if len(res.Results) != 2 || res.Results[0].Pin.CID != "QmTwo" {
+		t.Errorf("List(before) = %+v, want QmTwo and QmOne", res.Results)
+	}
+
+	res, err = client.List(ctx, ListOptions{CIDs: []string{"QmTwo"}, Status: all})
+	if err != nil {
+		t.Fatalf("List(cid) error = %v", err)
+	}
+	if len(res.Results) != 1 || res.Results[0].Status != StatusFailed {
+		t.Errorf("List(cid) = %+v, want failed QmTwo", res.Results)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	svc := NewMemoryService("secret")
+	server := httptest.NewServer(svc)
+	defer server.Close()
+	ctx := context.Background()
+
+	_, err := NewClient(server.URL, "wrong").List(ctx, ListOptions{})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 401 || apiErr.Reason != "UNAUTHORIZED" {
+		t.Errorf("List() with a bad token error = %v, want 401 UNAUTHORIZED", err)
+	}
+
+	_, err = NewClient(server.URL, "secret").Add(ctx, Pin{})
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 400 {
+		t.Errorf("Add() without a CID error = %v, want 400", err)
+	}
+}
+
+func TestValidateEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		valid    bool
+	}{
+		{"https://api.pinata.cloud/psa", true},
+		{"http://127.0.0.1:5000", true},
+		{"api.pinata.cloud/psa", false},
+		{"ftp://example.com/psa", false},
+		{"https://example.com/psa?key=1", false},
+		{"", false},
+	}
+	for _, tc := range tests {
+		err := ValidateEndpoint(tc.endpoint)
+		if (err == nil) != tc.valid {
+			t.Errorf("ValidateEndpoint(%q) error = %v, want valid %v", tc.endpoint, err, tc.valid)
+		}
+	}
+}
@@ -0,0 +1,443 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// manifestName is the file at the destination root that records the files
+// already copied, so an interrupted copy resumes where it stopped
+const manifestName = ".porcupin-migration.json"
+
+// partialSuffix is appended to a file while it is being copied
+const partialSuffix = ".partial"
+
+// copyWorkers is the number of files copied or verified at once
+const copyWorkers = 4
+
+// manifestSaveInterval is how often the manifest is written while copying
+const manifestSaveInterval = 5 * time.Second
+
+// manifest records the files copied to a destination and their content hashes
+type manifest struct {
+	Source string                   `json:"source"`
+	Files  map[string]manifestEntry `json:"files"` // By slash-separated path relative to the root
+}
+
+// manifestEntry is a copied file, as it was in the source when copied
+type manifestEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	SHA256  string    `json:"sha256,omitempty"`
+	Link    string    `json:"link,omitempty"` // Target of a symlink
+}
+
+// sourceFile is a file or symlink found in the source tree
+type sourceFile struct {
+	rel     string // Slash-separated path relative to the root
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// copier copies a directory tree file by file. Every file is hashed while it
+// is read and recorded in a manifest at the destination; verify then re-reads
+// the destination and compares it against those hashes.
+type copier struct {
+	source   string
+	dest     string
+	workers  int
+	progress func(phase string, done, total int64, file string)
+
+	mu       sync.Mutex
+	manifest manifest
+	files    []sourceFile
+	total    int64
+	saved    time.Time
+}
+
+// newCopier creates a copier from source to dest. progress, if set, is called
+// as files complete with the bytes done so far in the current phase.
+func newCopier(source, dest string, progress func(phase string, done, total int64, file string)) *copier {
+	return &copier{
+		source:   source,
+		dest:     dest,
+		workers:  copyWorkers,
+		progress: progress,
+	}
+}
+
+// skipFile reports whether a source file is left behind. Lock files would
+// make the repository look in use at its new location.
+func skipFile(name string) bool {
+	return name == "repo.lock" || strings.HasSuffix(name, ".lock")
+}
+
+// scan lists the source tree, creates its directories at the destination and
+// loads the manifest of any earlier attempt. It returns the total size of the
+// source and the bytes still to copy.
+func (c *copier) scan() (total, pending int64, err error) {
+	c.files = nil
+	err = filepath.WalkDir(c.source, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(c.source, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return os.MkdirAll(filepath.Join(c.dest, rel), info.Mode().Perm()|0700)
+		}
+		if skipFile(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && info.Mode()&fs.ModeSymlink == 0 {
+			log.Printf("Migration: skipping special file %s", path)
+			return nil
+		}
+		c.files = append(c.files, sourceFile{
+			rel:     filepath.ToSlash(rel),
+			size:    info.Size(),
+			mode:    info.Mode(),
+			modTime: info.ModTime(),
+		})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot read source: %w", err)
+	}
+	c.total = total
+
+	c.manifest = c.loadManifest()
+	for _, f := range c.files {
+		if !c.copied(f) {
+			pending += f.size
+		}
+	}
+	return total, pending, nil
+}
+
+// loadManifest reads the manifest left at the destination by an earlier
+// attempt. A missing or unreadable manifest, or one for another source,
+// starts the copy afresh.
+func (c *copier) loadManifest() manifest {
+	fresh := manifest{Source: c.source, Files: make(map[string]manifestEntry)}
+	data, err := os.ReadFile(filepath.Join(c.dest, manifestName))
+	if err != nil {
+		return fresh
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil || m.Source != c.source || m.Files == nil {
+		log.Printf("Migration: ignoring manifest at %s", c.dest)
+		return fresh
+	}
+	log.Printf("Migration: resuming with %d files already copied", len(m.Files))
+	return m
+}
+
+// saveManifestLocked writes the manifest atomically. Caller holds mu.
+func (c *copier) saveManifestLocked() error {
+	data, err := json.Marshal(c.manifest)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(c.dest, manifestName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	c.saved = time.Now()
+	return nil
+}
+
+// removeManifest deletes the manifest once the destination is in use
+func (c *copier) removeManifest() {
+	if err := os.Remove(filepath.Join(c.dest, manifestName)); err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: failed to remove migration manifest: %v", err)
+	}
+}
+
+// copied reports whether the manifest holds the file as it is now in the
+// source and the destination still has a file of that size
+func (c *copier) copied(f sourceFile) bool {
+	c.mu.Lock()
+	entry, ok := c.manifest.Files[f.rel]
+	c.mu.Unlock()
+	if !ok || entry.Size != f.size || !entry.ModTime.Equal(f.modTime) {
+		return false
+	}
+	info, err := os.Lstat(filepath.Join(c.dest, filepath.FromSlash(f.rel)))
+	if err != nil {
+		return false
+	}
+	if entry.Link != "" {
+		return info.Mode()&fs.ModeSymlink != 0
+	}
+	return info.Mode().IsRegular() && info.Size() == f.size
+}
+
+// copy copies every file not already in the manifest. On error or
+// cancellation the manifest is saved so the next attempt resumes.
+func (c *copier) copy(ctx context.Context) error {
+	var done int64
+	var pending []sourceFile
+	for _, f := range c.files {
+		if c.copied(f) {
+			done += f.size
+		} else {
+			pending = append(pending, f)
+		}
+	}
+	c.report("copying", done, "")
+
+	err := c.forEach(ctx, pending, func(f sourceFile) error {
+		entry, err := c.copyFile(ctx, f)
+		if err != nil {
+			return err
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.manifest.Files[f.rel] = entry
+		done += f.size
+		c.report("copying", done, f.rel)
+		if time.Since(c.saved) >= manifestSaveInterval {
+			return c.saveManifestLocked()
+		}
+		return nil
+	})
+
+	c.mu.Lock()
+	saveErr := c.saveManifestLocked()
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return saveErr
+}
+
+// copyFile copies one file through a partial file, hashing the content as it
+// is read, and returns its manifest entry
+func (c *copier) copyFile(ctx context.Context, f sourceFile) (manifestEntry, error) {
+	src := filepath.Join(c.source, filepath.FromSlash(f.rel))
+	dst := filepath.Join(c.dest, filepath.FromSlash(f.rel))
+	entry := manifestEntry{Size: f.size, ModTime: f.modTime}
+
+	if f.mode&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return entry, fmt.Errorf("failed to read link %s: %w", f.rel, err)
+		}
+		os.Remove(dst)
+		if err := os.Symlink(target, dst); err != nil {
+			return entry, fmt.Errorf("failed to create link %s: %w", f.rel, err)
+		}
+		entry.Link = target
+		return entry, nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return entry, fmt.Errorf("failed to open %s: %w", f.rel, err)
+	}
+	defer in.Close()
+
+	partial := dst + partialSuffix
+	out, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, f.mode.Perm()|0600)
+	if err != nil {
+		return entry, fmt.Errorf("failed to create %s: %w", f.rel, err)
+	}
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(out, hash), &ctxReader{ctx: ctx, r: in})
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(partial)
+		if ctx.Err() != nil {
+			return entry, ctx.Err()
+		}
+		return entry, fmt.Errorf("failed to copy %s: %w", f.rel, err)
+	}
+
+	if err := os.Chtimes(partial, f.modTime, f.modTime); err != nil {
+		log.Printf("Warning: failed to set times on %s: %v", f.rel, err)
+	}
+	if err := os.Rename(partial, dst); err != nil {
+		os.Remove(partial)
+		return entry, fmt.Errorf("failed to move %s into place: %w", f.rel, err)
+	}
+
+	entry.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return entry, nil
+}
+
+// verify re-reads every destination file and compares its hash with the one
+// recorded while copying. A mismatched file is dropped from the manifest so
+// the next attempt copies it again.
+func (c *copier) verify(ctx context.Context) error {
+	var done int64
+	c.report("verifying", 0, "")
+
+	err := c.forEach(ctx, c.files, func(f sourceFile) error {
+		c.mu.Lock()
+		entry, ok := c.manifest.Files[f.rel]
+		c.mu.Unlock()
+		if !ok {
+			return fmt.Errorf("verification failed: %s was not copied", f.rel)
+		}
+
+		dst := filepath.Join(c.dest, filepath.FromSlash(f.rel))
+		var mismatch bool
+		if entry.Link != "" {
+			target, err := os.Readlink(dst)
+			mismatch = err != nil || target != entry.Link
+		} else {
+			sum, err := hashFile(ctx, dst)
+			if err != nil && ctx.Err() != nil {
+				return ctx.Err()
+			}
+			mismatch = err != nil || sum != entry.SHA256
+		}
+		if mismatch {
+			c.mu.Lock()
+			delete(c.manifest.Files, f.rel)
+			c.saveManifestLocked()
+			c.mu.Unlock()
+			return fmt.Errorf("verification failed: %s does not match the source", f.rel)
+		}
+
+		c.mu.Lock()
+		done += f.size
+		c.report("verifying", done, f.rel)
+		c.mu.Unlock()
+		return nil
+	})
+	return err
+}
+
+// forEach runs fn for each file on the copier's workers, stopping at the
+// first error
+func (c *copier) forEach(ctx context.Context, files []sourceFile, fn func(sourceFile) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	work := make(chan sourceFile)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for i := 0; i < c.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range work {
+				if err := fn(f); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, f := range files {
+		select {
+		case work <- f:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// report passes progress to the copier's callback. Workers call it holding
+// mu so the bytes reported never go backwards.
+func (c *copier) report(phase string, done int64, file string) {
+	if c.progress != nil {
+		c.progress(phase, done, c.total, file)
+	}
+}
+
+// hashFile returns the hex SHA-256 of a file's content
+func hashFile(ctx context.Context, path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, &ctxReader{ctx: ctx, r: f}); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// getDirSize calculates the total size of the files in a directory
+func getDirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// ctxReader stops a copy when its context is cancelled
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// ErrStorageUnavailable is wrapped by every reason a repository can't be used
+	ErrStorageUnavailable = errors.New("storage unavailable")
+	// ErrStorageUnmounted is returned when the volume holding a repository is not mounted
+	ErrStorageUnmounted = fmt.Errorf("%w: volume not mounted", ErrStorageUnavailable)
+	// ErrStorageMissing is returned when an existing repository can't be found,
+	// e.g. because its directory is now an empty mountpoint
+	ErrStorageMissing = fmt.Errorf("%w: repository not found", ErrStorageUnavailable)
+	// ErrStorageReadOnly is returned when a repository can't be written to
+	ErrStorageReadOnly = fmt.Errorf("%w: not writable", ErrStorageUnavailable)
+)
+
+// repoMarker is a file every initialized IPFS repository contains
+const repoMarker = "config"
+
+// CheckAvailable reports whether the IPFS repository at path can be used.
+// It fails when the removable or network volume holding it is not mounted,
+// when it is not writable, and, if mustExist is set, when the repository
+// isn't there, so an empty mountpoint on the root disk is never mistaken for
+// a new repository.
+func CheckAvailable(path string, mustExist bool) error {
+	path, err := ExpandPath(path)
+	if err != nil {
+		return err
+	}
+	mountPoint, onVolume := volumeMountPoint(path)
+	return checkAvailable(path, mountPoint, onVolume, mustExist)
+}
+
+// checkAvailable is the testable implementation of CheckAvailable
+func checkAvailable(path, mountPoint string, onVolume, mustExist bool) error {
+	if onVolume && !isMountedAt(mountPoint) {
+		return fmt.Errorf("%w: %s", ErrStorageUnmounted, mountPoint)
+	}
+
+	info, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		if mustExist {
+			return fmt.Errorf("%w: %s", ErrStorageMissing, path)
+		}
+		// A new repository is created under the nearest existing directory
+		for os.IsNotExist(err) && filepath.Dir(path) != path {
+			path = filepath.Dir(path)
+			_, err = os.Stat(path)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrStorageUnavailable, err)
+		}
+	case err != nil:
+		// e.g. I/O errors or stale network file handles
+		return fmt.Errorf("%w: %v", ErrStorageUnavailable, err)
+	case !info.IsDir():
+		return fmt.Errorf("%w: %s is not a directory", ErrStorageUnavailable, path)
+	case mustExist:
+		if _, err := os.Stat(filepath.Join(path, repoMarker)); err != nil {
+			return fmt.Errorf("%w: %s", ErrStorageMissing, path)
+		}
+	}
+
+	if !isWritable(path) {
+		return fmt.Errorf("%w: %s", ErrStorageReadOnly, path)
+	}
+	return nil
+}
@@ -22,6 +22,28 @@ func getDeviceID(path string) (uint64, error) {
 	return uint64(stat.Dev), nil
 }
 
+// isMountedAt reports whether a volume is mounted at mountPoint: an
+// unmounted mountpoint is an empty directory on its parent's device, or gone
+func isMountedAt(mountPoint string) bool {
+	dev, err := getDeviceID(mountPoint)
+	if err != nil {
+		return false
+	}
+	parentDev, err := getDeviceID(filepath.Dir(mountPoint))
+	if err != nil {
+		return false
+	}
+	return dev != parentDev
+}
+
+// volumeMountPoint returns the mount point of the volume holding path, when
+// path is where this OS mounts removable and network volumes
+func volumeMountPoint(path string) (string, bool) {
+	probe := filepath.Join(path, "probe")
+	mountPoint := getMountPoint(probe)
+	return mountPoint, mountPoint != probe
+}
+
 // isNetworkMount checks if a path is on a network mount
 func isNetworkMount(path string) bool {
 	switch runtime.GOOS {
@@ -184,6 +206,9 @@ func GetStorageInfo(path string) (*StorageLocation, error) {
 		loc.MountPoint = getMountPoint(path)
 	}
 
+	// Report the tier stored here, if any
+	annotateTier(loc)
+
 	return loc, nil
 }
 
@@ -374,5 +399,8 @@ func ListAvailableLocations() ([]*StorageLocation, error) {
 		}
 	}
 
+	// Include the configured tiers wherever they are
+	locations = appendTiers(locations)
+
 	return locations, nil
 }
@@ -4,9 +4,11 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -520,6 +522,9 @@ func TestManager_Migrate_NotWritable(t *testing.T) {
 	if err == nil {
 		t.Error("Migrate should fail for unwritable destination")
 	}
+	if st := m.GetMigrationStatus(); st.InProgress || st.Error == "" {
+		t.Errorf("status after a failed migration = %+v, want the error and not in progress", st)
+	}
 }
 
 func TestManager_Migrate_CreatesSubfolder(t *testing.T) {
@@ -555,6 +560,248 @@ func TestManager_Migrate_CreatesSubfolder(t *testing.T) {
 	}
 }
 
+// =============================================================================
+// COPY TESTS
+// =============================================================================
+
+// writeTree creates files under root from a map of relative path to content
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", rel, err)
+		}
+	}
+}
+
+var testRepo = map[string]string{
+	"config":                  "{}",
+	"blocks/AB/CIQAB.data":    "block one",
+	"blocks/CD/CIQCD.data":    "block two, a little longer",
+	"datastore/000001.ldb":    "leveldb table",
+	"datastore/LOCK":          "",
+	"repo.lock":               "",
+	"datastore/MANIFEST.lock": "",
+}
+
+func TestCopier_CopyAndVerify(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "src")
+	dst := filepath.Join(tmpDir, "dst")
+	writeTree(t, src, testRepo)
+	os.MkdirAll(filepath.Join(src, "keystore"), 0700)
+
+	var phases []string
+	c := newCopier(src, dst, func(phase string, done, total int64, file string) {
+		if len(phases) == 0 || phases[len(phases)-1] != phase {
+			phases = append(phases, phase)
+		}
+		if done > total {
+			t.Errorf("progress %d exceeds total %d", done, total)
+		}
+	})
+
+	total, pending, err := c.scan()
+	if err != nil {
+		t.Fatalf("scan() error: %v", err)
+	}
+	if total != pending || total == 0 {
+		t.Errorf("scan() = %d total, %d pending; want equal and non-zero", total, pending)
+	}
+	if err := c.copy(context.Background()); err != nil {
+		t.Fatalf("copy() error: %v", err)
+	}
+	if err := c.verify(context.Background()); err != nil {
+		t.Fatalf("verify() error: %v", err)
+	}
+
+	for rel, content := range testRepo {
+		data, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(rel)))
+		if strings.HasSuffix(rel, ".lock") {
+			if err == nil {
+				t.Errorf("lock file %s should not be copied", rel)
+			}
+			continue
+		}
+		if err != nil || string(data) != content {
+			t.Errorf("%s = %q, %v; want %q", rel, data, err, content)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, "keystore")); err != nil {
+		t.Errorf("empty directory should be created: %v", err)
+	}
+	if len(phases) != 2 || phases[0] != "copying" || phases[1] != "verifying" {
+		t.Errorf("phases = %v, want [copying verifying]", phases)
+	}
+	if _, err := os.Stat(filepath.Join(dst, manifestName)); err != nil {
+		t.Errorf("manifest should be kept until the switch: %v", err)
+	}
+}
+
+func TestCopier_Resume(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "src")
+	dst := filepath.Join(tmpDir, "dst")
+	writeTree(t, src, testRepo)
+
+	c := newCopier(src, dst, nil)
+	if _, _, err := c.scan(); err != nil {
+		t.Fatalf("scan() error: %v", err)
+	}
+	if err := c.copy(context.Background()); err != nil {
+		t.Fatalf("copy() error: %v", err)
+	}
+
+	// Lose one copied file, as if the copy had stopped before it
+	lost := filepath.Join(dst, "blocks", "CD", "CIQCD.data")
+	os.Remove(lost)
+
+	c = newCopier(src, dst, nil)
+	_, pending, err := c.scan()
+	if err != nil {
+		t.Fatalf("scan() error: %v", err)
+	}
+	if want := int64(len(testRepo["blocks/CD/CIQCD.data"])); pending != want {
+		t.Errorf("pending = %d, want only the lost file's %d bytes", pending, want)
+	}
+	if err := c.copy(context.Background()); err != nil {
+		t.Fatalf("copy() error: %v", err)
+	}
+	if err := c.verify(context.Background()); err != nil {
+		t.Fatalf("verify() error: %v", err)
+	}
+	if data, _ := os.ReadFile(lost); string(data) != testRepo["blocks/CD/CIQCD.data"] {
+		t.Errorf("lost file = %q after resume", data)
+	}
+}
+
+func TestCopier_VerifyDetectsCorruption(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "src")
+	dst := filepath.Join(tmpDir, "dst")
+	writeTree(t, src, testRepo)
+
+	c := newCopier(src, dst, nil)
+	if _, _, err := c.scan(); err != nil {
+		t.Fatalf("scan() error: %v", err)
+	}
+	if err := c.copy(context.Background()); err != nil {
+		t.Fatalf("copy() error: %v", err)
+	}
+
+	// Same size, different content
+	bad := filepath.Join(dst, "blocks", "AB", "CIQAB.data")
+	os.WriteFile(bad, []byte("block 0ne"), 0644)
+
+	if err := c.verify(context.Background()); err == nil {
+		t.Fatal("verify() should fail for a corrupted copy")
+	}
+
+	// The corrupted file is copied again on the next attempt
+	c = newCopier(src, dst, nil)
+	if _, _, err := c.scan(); err != nil {
+		t.Fatalf("scan() error: %v", err)
+	}
+	if err := c.copy(context.Background()); err != nil {
+		t.Fatalf("copy() error: %v", err)
+	}
+	if err := c.verify(context.Background()); err != nil {
+		t.Errorf("verify() after recopy error: %v", err)
+	}
+}
+
+func TestCopier_Cancelled(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "src")
+	dst := filepath.Join(tmpDir, "dst")
+	writeTree(t, src, testRepo)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := newCopier(src, dst, nil)
+	if _, _, err := c.scan(); err != nil {
+		t.Fatalf("scan() error: %v", err)
+	}
+	if err := c.copy(ctx); err != context.Canceled {
+		t.Errorf("copy() error = %v, want context.Canceled", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, manifestName)); err != nil {
+		t.Errorf("manifest should be saved on cancel: %v", err)
+	}
+}
+
+func TestManager_Migrate_Copy(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "source")
+	dst := filepath.Join(tmpDir, "external_drive", "porcupin-ipfs")
+	writeTree(t, src, testRepo)
+
+	// A manifest at the destination makes Migrate copy rather than rename,
+	// resuming the earlier attempt
+	writeTree(t, dst, map[string]string{
+		manifestName: `{"source":"` + src + `","files":{}}`,
+	})
+
+	m := NewManager(src)
+	var switched string
+	m.SetSwitchFunc(func(newPath string) error {
+		if _, err := os.Stat(src); err != nil {
+			t.Errorf("source removed before the switch: %v", err)
+		}
+		switched = newPath
+		return nil
+	})
+
+	if err := m.Migrate(context.Background(), dst, nil); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	status := m.GetMigrationStatus()
+	if status.Method != "copy" || status.Phase != "complete" || status.Progress != 100 {
+		t.Errorf("status = %+v, want a complete copy", status)
+	}
+	if switched != dst || m.GetCurrentPath() != dst {
+		t.Errorf("switched to %q, current path %q; want %q", switched, m.GetCurrentPath(), dst)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Error("source should be removed after the switch")
+	}
+	if _, err := os.Stat(filepath.Join(dst, manifestName)); !os.IsNotExist(err) {
+		t.Error("manifest should be removed after the switch")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "config")); string(data) != "{}" {
+		t.Errorf("config = %q after migration", data)
+	}
+}
+
+func TestManager_Migrate_SwitchFails(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "source")
+	destBase := filepath.Join(tmpDir, "external_drive")
+	writeTree(t, src, testRepo)
+	os.MkdirAll(destBase, 0755)
+
+	m := NewManager(src)
+	m.SetSwitchFunc(func(string) error {
+		return fmt.Errorf("config is read-only")
+	})
+
+	if err := m.Migrate(context.Background(), destBase, nil); err == nil {
+		t.Fatal("Migrate should fail when the switch fails")
+	}
+	if data, _ := os.ReadFile(filepath.Join(src, "config")); string(data) != "{}" {
+		t.Error("source should be kept when the switch fails")
+	}
+	if m.GetCurrentPath() != src {
+		t.Errorf("CurrentPath = %q, want %q", m.GetCurrentPath(), src)
+	}
+}
+
 // =============================================================================
 // CANCEL MIGRATION TESTS (WITH MIGRATION)
 // =============================================================================
@@ -954,3 +1201,110 @@ func TestListAvailableLocations(t *testing.T) {
 		t.Error("Should include 'Default (Home Directory)' location")
 	}
 }
+
+// =============================================================================
+// TIER TESTS
+// =============================================================================
+
+func TestTierReporting(t *testing.T) {
+	hot := filepath.Join(t.TempDir(), "ipfs")
+	cold := filepath.Join(t.TempDir(), "cold")
+	writeTree(t, hot, map[string]string{"config": "{}"})
+	writeTree(t, cold, map[string]string{"blocks/diskUsage.cache": `{"diskUsage":4096,"accuracy":"exact"}`})
+
+	SetTierPaths(hot, cold)
+	defer SetTierPaths("", "")
+
+	loc, err := GetStorageInfo(cold)
+	if err != nil {
+		t.Fatalf("GetStorageInfo error: %v", err)
+	}
+	if loc.Tier != TierCold || loc.UsedBytes != 4096 {
+		t.Errorf("cold location tier = %q, used = %d; want %q from the usage cache", loc.Tier, loc.UsedBytes, TierCold)
+	}
+
+	loc, err = GetStorageInfo(hot)
+	if err != nil {
+		t.Fatalf("GetStorageInfo error: %v", err)
+	}
+	if loc.Tier != TierHot || loc.UsedBytes != 2 {
+		t.Errorf("hot location tier = %q, used = %d; want %q measured from the directory", loc.Tier, loc.UsedBytes, TierHot)
+	}
+
+	locations, err := ListAvailableLocations()
+	if err != nil {
+		t.Fatalf("ListAvailableLocations error: %v", err)
+	}
+	tiers := map[string]bool{}
+	for _, l := range locations {
+		if l.Tier != "" {
+			tiers[l.Tier] = true
+		}
+	}
+	if !tiers[TierHot] || !tiers[TierCold] {
+		t.Errorf("ListAvailableLocations tiers = %v, want both hot and cold", tiers)
+	}
+
+	SetTierPaths(hot, "")
+	if loc, _ := GetStorageInfo(cold); loc.Tier != "" {
+		t.Errorf("location tier without a cold tier = %q, want none", loc.Tier)
+	}
+}
+
+// =============================================================================
+// HEALTH TESTS
+// =============================================================================
+
+func TestCheckAvailable(t *testing.T) {
+	root := t.TempDir()
+	repo := filepath.Join(root, "ipfs")
+	writeTree(t, repo, map[string]string{"config": "{}"})
+
+	if err := checkAvailable(repo, "", false, true); err != nil {
+		t.Errorf("existing repo: %v", err)
+	}
+
+	missing := filepath.Join(root, "new", "ipfs")
+	if err := checkAvailable(missing, "", false, false); err != nil {
+		t.Errorf("new repo: %v", err)
+	}
+	if err := checkAvailable(missing, "", false, true); !errors.Is(err, ErrStorageMissing) {
+		t.Errorf("missing repo = %v, want ErrStorageMissing", err)
+	}
+
+	// An empty directory is what an unmounted mountpoint looks like
+	empty := filepath.Join(root, "empty")
+	os.MkdirAll(empty, 0755)
+	if err := checkAvailable(empty, "", false, true); !errors.Is(err, ErrStorageMissing) {
+		t.Errorf("empty directory = %v, want ErrStorageMissing", err)
+	}
+
+	// A mountpoint on the same device as its parent has nothing mounted
+	err := checkAvailable(filepath.Join(root, "ipfs"), root, true, false)
+	if !errors.Is(err, ErrStorageUnmounted) || !errors.Is(err, ErrStorageUnavailable) {
+		t.Errorf("unmounted volume = %v, want ErrStorageUnmounted", err)
+	}
+
+	if os.Geteuid() != 0 {
+		readOnly := filepath.Join(root, "readonly")
+		writeTree(t, readOnly, map[string]string{"config": "{}"})
+		os.Chmod(readOnly, 0555)
+		defer os.Chmod(readOnly, 0755)
+		if err := checkAvailable(readOnly, "", false, true); !errors.Is(err, ErrStorageReadOnly) {
+			t.Errorf("read-only repo = %v, want ErrStorageReadOnly", err)
+		}
+	}
+}
+
+func TestVolumeMountPoint(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	if _, onVolume := volumeMountPoint(filepath.Join(home, ".porcupin", "ipfs")); onVolume {
+		t.Error("home directory should not be on a removable volume")
+	}
+	if runtime.GOOS == "linux" {
+		mp, onVolume := volumeMountPoint("/mnt/usb")
+		if !onVolume || mp != "/mnt/usb" {
+			t.Errorf("volumeMountPoint(/mnt/usb) = %q, %v; want the path itself", mp, onVolume)
+		}
+	}
+}
@@ -27,6 +27,21 @@ const (
 	DRIVE_RAMDISK     = 6
 )
 
+// isMountedAt reports whether the drive at mountPoint is present
+func isMountedAt(mountPoint string) bool {
+	_, err := os.Stat(mountPoint)
+	return err == nil
+}
+
+// volumeMountPoint returns the root of the drive holding path
+func volumeMountPoint(path string) (string, bool) {
+	volume := filepath.VolumeName(path)
+	if volume == "" {
+		return "", false
+	}
+	return volume + "\\", true
+}
+
 // getDeviceID returns a pseudo device ID for Windows (drive letter hash)
 func getDeviceID(path string) (uint64, error) {
 	// Expand path
@@ -162,6 +177,9 @@ func GetStorageInfo(path string) (*StorageLocation, error) {
 		loc.MountPoint = root
 	}
 
+	// Report the tier stored here, if any
+	annotateTier(loc)
+
 	return loc, nil
 }
 
@@ -239,5 +257,8 @@ func ListAvailableLocations() ([]*StorageLocation, error) {
 		}
 	}
 
+	// Include the configured tiers wherever they are
+	locations = appendTiers(locations)
+
 	return locations, nil
 }
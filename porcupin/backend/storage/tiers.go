@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Storage tiers. Blocks are written to the hot tier (the IPFS repo) and
+// moved to the cold tier, a second volume, by the tiering placement rules.
+const (
+	TierHot  = "hot"
+	TierCold = "cold"
+)
+
+// blockUsageCache is the usage file flatfs keeps in a tier's blocks directory
+const blockUsageCache = "blocks/diskUsage.cache"
+
+var (
+	tiersMu   sync.RWMutex
+	tierPaths = map[string]string{} // tier -> expanded path
+)
+
+// SetTierPaths records where each tier is stored, so GetStorageInfo and
+// ListAvailableLocations can report usage per tier. An empty cold path means
+// there is no cold tier.
+func SetTierPaths(hotPath, coldPath string) {
+	tiersMu.Lock()
+	defer tiersMu.Unlock()
+
+	tierPaths = map[string]string{}
+	if p, err := ExpandPath(hotPath); err == nil && hotPath != "" {
+		tierPaths[TierHot] = filepath.Clean(p)
+	}
+	if p, err := ExpandPath(coldPath); err == nil && coldPath != "" {
+		tierPaths[TierCold] = filepath.Clean(p)
+	}
+}
+
+// tierOf returns the tier stored at path, or "" if it holds none
+func tierOf(path string) string {
+	tiersMu.RLock()
+	defer tiersMu.RUnlock()
+
+	path = filepath.Clean(path)
+	for tier, p := range tierPaths {
+		if p == path {
+			return tier
+		}
+	}
+	return ""
+}
+
+// annotateTier fills in the tier held at a location and how much it stores
+func annotateTier(loc *StorageLocation) {
+	tier := tierOf(loc.Path)
+	if tier == "" {
+		return
+	}
+	loc.Tier = tier
+	loc.UsedBytes = tierUsage(loc.Path)
+}
+
+// appendTiers adds any tier missing from a list of locations, so every tier
+// in use is reported
+func appendTiers(locations []*StorageLocation) []*StorageLocation {
+	for _, tier := range []string{TierHot, TierCold} {
+		tiersMu.RLock()
+		path, ok := tierPaths[tier]
+		tiersMu.RUnlock()
+		if !ok {
+			continue
+		}
+
+		listed := false
+		for _, loc := range locations {
+			if filepath.Clean(loc.Path) == path {
+				listed = true
+				break
+			}
+		}
+		if listed {
+			continue
+		}
+		if loc, err := GetStorageInfo(path); err == nil {
+			locations = append(locations, loc)
+		}
+	}
+	return locations
+}
+
+// tierUsage returns the bytes stored in a tier. The block store's own usage
+// cache is preferred over walking what can be a very large directory.
+func tierUsage(path string) int64 {
+	if data, err := os.ReadFile(filepath.Join(path, blockUsageCache)); err == nil {
+		var cached struct {
+			DiskUsage int64 `json:"diskUsage"`
+		}
+		if json.Unmarshal(data, &cached) == nil && cached.DiskUsage > 0 {
+			return cached.DiskUsage
+		}
+	}
+	size, _ := getDirSize(path)
+	return size
+}
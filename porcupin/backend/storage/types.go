@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -32,6 +31,8 @@ type StorageLocation struct {
 	IsMounted  bool        `json:"is_mounted" yaml:"is_mounted"`
 	MountPoint string      `json:"mount_point" yaml:"mount_point"`
 	NetworkURI string      `json:"network_uri" yaml:"network_uri"`
+	Tier       string      `json:"tier,omitempty" yaml:"tier,omitempty"`             // "hot" or "cold" when this location holds a tier
+	UsedBytes  int64       `json:"used_bytes,omitempty" yaml:"used_bytes,omitempty"` // bytes stored in the tier
 }
 
 // MigrationStatus tracks the progress of a storage migration
@@ -54,7 +55,7 @@ type Manager struct {
 	currentPath     string
 	migrationStatus *MigrationStatus
 	cancelFunc      context.CancelFunc // To cancel ongoing migration
-	rsyncCmd        *exec.Cmd          // Reference to rsync process for cancellation
+	switchFunc      func(newPath string) error
 }
 
 // Global migration manager to persist status across calls
@@ -102,15 +103,7 @@ func (m *Manager) CancelMigration() error {
 	
 	log.Println("Cancelling migration...")
 	
-	// Kill rsync process if running
-	if m.rsyncCmd != nil && m.rsyncCmd.Process != nil {
-		log.Printf("Killing rsync process (PID %d)", m.rsyncCmd.Process.Pid)
-		if err := m.rsyncCmd.Process.Kill(); err != nil {
-			log.Printf("Warning: failed to kill rsync: %v", err)
-		}
-	}
-	
-	// Cancel context if set
+	// Stop the copy; its manifest is kept so a later attempt resumes
 	if m.cancelFunc != nil {
 		m.cancelFunc()
 	}
@@ -122,6 +115,16 @@ func (m *Manager) CancelMigration() error {
 	return nil
 }
 
+// SetSwitchFunc sets the function Migrate calls with the new repository path
+// once the data is in place and verified, before the source is removed. It is
+// where the caller points its config at the new path; if it fails the source
+// is kept and the migration fails.
+func (m *Manager) SetSwitchFunc(fn func(newPath string) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.switchFunc = fn
+}
+
 // GetCurrentPath returns the current storage path
 func (m *Manager) GetCurrentPath() string {
 	m.mu.RLock()
@@ -165,9 +168,6 @@ func isWritable(path string) bool {
 	}
 }
 
-// getDirSize calculates the total size of a directory
-// Platform-specific implementations in types_unix.go and types_windows.go
-
 // ExpandPath expands ~ to home directory
 func ExpandPath(path string) (string, error) {
 	if strings.HasPrefix(path, "~") {
@@ -180,8 +180,11 @@ func ExpandPath(path string) (string, error) {
 	return path, nil
 }
 
-// Migrate moves the IPFS repository to a new location
-func (m *Manager) Migrate(ctx context.Context, destPath string, progressCallback func(MigrationStatus)) error {
+// Migrate moves the IPFS repository to a new location. On the same device
+// the directory is renamed; otherwise it is copied and verified file by file,
+// resuming any earlier attempt at the same destination. The source is only
+// removed after the switch function has accepted the new location.
+func (m *Manager) Migrate(ctx context.Context, destPath string, progressCallback func(MigrationStatus)) (err error) {
 	log.Printf("Migrate called: destPath=%s", destPath)
 	
 	m.mu.Lock()
@@ -192,6 +195,7 @@ func (m *Manager) Migrate(ctx context.Context, destPath string, progressCallback
 	}
 
 	sourcePath := m.currentPath
+	switchFunc := m.switchFunc
 	log.Printf("Migration: source=%s, dest=%s", sourcePath, destPath)
 	
 	m.migrationStatus = &MigrationStatus{
@@ -200,6 +204,9 @@ func (m *Manager) Migrate(ctx context.Context, destPath string, progressCallback
 		DestPath:   destPath,
 		Phase:      "preparing",
 	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	m.cancelFunc = cancel
 	
 	// Set global manager for status queries
 	globalMigrationMu.Lock()
@@ -220,22 +227,27 @@ func (m *Manager) Migrate(ctx context.Context, destPath string, progressCallback
 	}
 
 	defer func() {
+		cancel()
 		if r := recover(); r != nil {
 			log.Printf("PANIC in migration: %v", r)
 			m.mu.Lock()
 			m.migrationStatus.InProgress = false
 			m.migrationStatus.Error = fmt.Sprintf("panic: %v", r)
+			m.cancelFunc = nil
 			m.mu.Unlock()
 		} else {
 			m.mu.Lock()
 			m.migrationStatus.InProgress = false
+			if err != nil && m.migrationStatus.Error == "" {
+				m.migrationStatus.Error = err.Error()
+			}
+			m.cancelFunc = nil
 			m.mu.Unlock()
 		}
 		log.Printf("Migration: defer executed, InProgress=false")
 	}()
 
 	// Expand destination path
-	var err error
 	destPath, err = ExpandPath(destPath)
 	if err != nil {
 		return err
@@ -270,38 +282,28 @@ func (m *Manager) Migrate(ctx context.Context, destPath string, progressCallback
 		return fmt.Errorf("destination is not writable")
 	}
 
-	log.Printf("Migration: calculating source size...")
-	updateStatus(func(s *MigrationStatus) {
-		s.CurrentFile = "Calculating source size..."
-	})
-
-	// Calculate source size
-	sourceSize, err := getDirSize(sourcePath)
-	if err != nil {
-		return fmt.Errorf("cannot calculate source size: %w", err)
-	}
-
-	if destInfo.FreeBytes < sourceSize {
-		return fmt.Errorf("insufficient space: need %.2f GB, have %.2f GB",
-			float64(sourceSize)/1024/1024/1024,
-			float64(destInfo.FreeBytes)/1024/1024/1024)
-	}
-
-	updateStatus(func(s *MigrationStatus) {
-		s.TotalBytes = sourceSize
-	})
-
 	// Determine migration method
 	sameDevice, err := SameDevice(sourcePath, destPath)
 	if err != nil {
 		sameDevice = false
 	}
 
+	// A destination holding a manifest is a copy in progress, not a candidate
+	// for rename
+	if _, err := os.Stat(filepath.Join(destPath, manifestName)); err == nil {
+		sameDevice = false
+	}
+
 	if sameDevice {
 		log.Printf("Migration: same device, using rename")
+		sourceSize, err := getDirSize(sourcePath)
+		if err != nil {
+			return fmt.Errorf("cannot calculate source size: %w", err)
+		}
 		updateStatus(func(s *MigrationStatus) {
 			s.Method = "rename"
 			s.Phase = "copying"
+			s.TotalBytes = sourceSize
 			s.CurrentFile = "Moving files (instant)..."
 		})
 
@@ -313,6 +315,15 @@ func (m *Manager) Migrate(ctx context.Context, destPath string, progressCallback
 			return fmt.Errorf("failed to move: %w", err)
 		}
 
+		if switchFunc != nil {
+			if err := switchFunc(destPath); err != nil {
+				if undoErr := os.Rename(destPath, sourcePath); undoErr != nil {
+					log.Printf("Warning: failed to move repository back to %s: %v", sourcePath, undoErr)
+				}
+				return fmt.Errorf("failed to switch to new location: %w", err)
+			}
+		}
+
 		updateStatus(func(s *MigrationStatus) {
 			s.Progress = 100
 			s.BytesCopied = sourceSize
@@ -323,17 +334,70 @@ func (m *Manager) Migrate(ctx context.Context, destPath string, progressCallback
 		m.currentPath = destPath
 		m.mu.Unlock()
 	} else {
-		log.Printf("Migration: cross-device, using rsync")
+		log.Printf("Migration: cross-device, copying")
 		updateStatus(func(s *MigrationStatus) {
-			s.Method = "rsync"
-			s.Phase = "copying"
+			s.Method = "copy"
+			s.CurrentFile = "Scanning source..."
 		})
 
-		if err := m.rsyncMigrate(ctx, sourcePath, destPath, sourceSize, progressCallback); err != nil {
+		// Progress covers copying then verifying, each half of the total
+		c := newCopier(sourcePath, destPath, func(phase string, done, total int64, file string) {
+			var pct float64
+			if total > 0 {
+				pct = float64(done) / float64(total) * 50
+			}
+			updateStatus(func(s *MigrationStatus) {
+				s.Phase = phase
+				s.CurrentFile = file
+				s.TotalBytes = total
+				if phase == "copying" {
+					s.BytesCopied = done
+					s.Progress = pct
+				} else {
+					s.BytesCopied = total
+					s.Progress = 50 + pct
+				}
+			})
+		})
+
+		sourceSize, pending, err := c.scan()
+		if err != nil {
+			return err
+		}
+		if destInfo.FreeBytes < pending {
+			return fmt.Errorf("insufficient space: need %.2f GB, have %.2f GB",
+				float64(pending)/1024/1024/1024,
+				float64(destInfo.FreeBytes)/1024/1024/1024)
+		}
+		log.Printf("Migration: %.2f GB to copy of %.2f GB",
+			float64(pending)/1024/1024/1024, float64(sourceSize)/1024/1024/1024)
+
+		if err := c.copy(ctx); err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("migration cancelled")
+			}
+			return err
+		}
+		if err := c.verify(ctx); err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("migration cancelled")
+			}
 			return err
 		}
 
-		log.Printf("Migration: rsync complete, cleaning up source")
+		// The copy is complete and verified; only now may the caller switch
+		if switchFunc != nil {
+			if err := switchFunc(destPath); err != nil {
+				return fmt.Errorf("failed to switch to new location: %w", err)
+			}
+		}
+		c.removeManifest()
+
+		m.mu.Lock()
+		m.currentPath = destPath
+		m.mu.Unlock()
+
+		log.Printf("Migration: copy verified, cleaning up source")
 		updateStatus(func(s *MigrationStatus) {
 			s.Phase = "cleanup"
 			s.CurrentFile = "Removing source files..."
@@ -342,10 +406,6 @@ func (m *Manager) Migrate(ctx context.Context, destPath string, progressCallback
 		if err := os.RemoveAll(sourcePath); err != nil {
 			log.Printf("Warning: failed to remove source after migration: %v", err)
 		}
-
-		m.mu.Lock()
-		m.currentPath = destPath
-		m.mu.Unlock()
 	}
 
 	updateStatus(func(s *MigrationStatus) {
@@ -356,9 +416,19 @@ func (m *Manager) Migrate(ctx context.Context, destPath string, progressCallback
 	return nil
 }
 
-// rsyncMigrate performs migration using platform-specific copy tool
-// Unix: rsync, Windows: robocopy
-// Platform-specific implementations in types_unix.go and types_windows.go
+// CleanupMigration removes what a migration that was interrupted after its
+// switch left behind: the source repository and the copy manifest at the
+// destination. It does nothing unless the destination exists.
+func CleanupMigration(sourcePath, destPath string) error {
+	if sourcePath == "" || sourcePath == destPath {
+		return nil
+	}
+	if _, err := os.Stat(destPath); err != nil {
+		return fmt.Errorf("new location is not accessible: %w", err)
+	}
+	newCopier(sourcePath, destPath, nil).removeManifest()
+	return os.RemoveAll(sourcePath)
+}
 
 // ValidatePath checks if a path is valid for storage
 func ValidatePath(path string) error {
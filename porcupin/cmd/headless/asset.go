@@ -0,0 +1,505 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"porcupin/backend/api"
+	"porcupin/backend/cli"
+	"porcupin/backend/core"
+	"porcupin/backend/db"
+	"porcupin/backend/indexer"
+)
+
+// assetEntry is an asset as printed by the asset commands
+type assetEntry struct {
+	ID        uint64     `json:"id"`
+	URI       string     `json:"uri"`
+	CID       string     `json:"cid"`
+	Type      string     `json:"type"`
+	MimeType  string     `json:"mime_type"`
+	Status    string     `json:"status"`
+	Error     string     `json:"error,omitempty"`
+	SizeBytes int64      `json:"size_bytes"`
+	Tier      string     `json:"tier"`
+	NFTID     uint64     `json:"nft_id"`
+	Wallet    string     `json:"wallet"`
+	PinnedAt  *time.Time `json:"pinned_at,omitempty"`
+}
+
+func newAssetEntry(a db.Asset) assetEntry {
+	entry := assetEntry{
+		ID:        a.ID,
+		URI:       a.URI,
+		CID:       core.ExtractCIDFromURI(a.URI),
+		Type:      a.Type,
+		MimeType:  a.MimeType,
+		Status:    a.Status,
+		Error:     a.ErrorMsg,
+		SizeBytes: a.SizeBytes,
+		Tier:      a.Tier,
+		NFTID:     a.NFTID,
+		PinnedAt:  a.PinnedAt,
+	}
+	if a.NFT != nil {
+		entry.Wallet = a.NFT.WalletAddress
+	}
+	return entry
+}
+
+// assetFilter selects assets for the asset commands
+type assetFilter struct {
+	status string
+	wallet string
+	kind   string
+}
+
+func (f *assetFilter) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.wallet, "wallet", "", "Only assets of this wallet")
+	fs.StringVar(&f.kind, "type", "", "Only assets of this type (artifact, display, thumbnail, format)")
+}
+
+// find returns the matching assets, newest first; limit 0 means all
+func (f *assetFilter) find(database *db.Database, limit int) ([]db.Asset, error) {
+	query := database.Model(&db.Asset{}).Preload("NFT").
+		Joins("LEFT JOIN nfts ON nfts.id = assets.nft_id")
+	switch f.status {
+	case "", "all":
+	case db.StatusFailed:
+		query = query.Where("assets.status IN ?", []string{db.StatusFailed, db.StatusFailedUnavailable})
+	default:
+		query = query.Where("assets.status = ?", f.status)
+	}
+	if f.wallet != "" {
+		query = query.Where("nfts.wallet_address = ?", f.wallet)
+	}
+	if f.kind != "" {
+		query = query.Where("assets.type = ?", f.kind)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var assets []db.Asset
+	err := query.Order("assets.id DESC").Find(&assets).Error
+	return assets, err
+}
+
+// fetch returns the matching assets from a server, newest first; limit 0
+// means all
+func (f *assetFilter) fetch(client *api.RemoteClient, limit int) ([]assetEntry, error) {
+	q := api.AssetQuery{Wallet: f.wallet, Type: f.kind}
+	switch f.status {
+	case "", "all":
+	case db.StatusFailed:
+		q.Status = []string{db.StatusFailed, db.StatusFailedUnavailable}
+	default:
+		q.Status = []string{f.status}
+	}
+
+	q.Limit = maxAssetPage
+	if limit > 0 && limit < q.Limit {
+		q.Limit = limit
+	}
+
+	entries := []assetEntry{}
+	for page := 1; ; page++ {
+		q.Page = page
+		resp, err := client.Assets(context.Background(), q)
+		if err != nil {
+			return nil, remoteError(err)
+		}
+		for _, a := range resp.Assets {
+			entry := assetEntry{
+				ID:        a.ID,
+				URI:       a.URI,
+				CID:       core.ExtractCIDFromURI(a.URI),
+				Type:      a.Type,
+				MimeType:  a.MimeType,
+				Status:    a.Status,
+				Error:     a.ErrorMsg,
+				SizeBytes: a.SizeBytes,
+				Tier:      a.Tier,
+				NFTID:     a.NFTID,
+				Wallet:    a.Wallet,
+			}
+			if a.PinnedAt != nil {
+				if t, err := time.Parse(time.RFC3339, *a.PinnedAt); err == nil {
+					entry.PinnedAt = &t
+				}
+			}
+			entries = append(entries, entry)
+			if len(entries) == limit {
+				return entries, nil
+			}
+		}
+		if len(resp.Assets) < q.Limit || int64(page*q.Limit) >= resp.Total {
+			return entries, nil
+		}
+	}
+}
+
+// maxAssetPage is the most assets the server returns per page
+const maxAssetPage = 500
+
+func setupAssetList(fs *flag.FlagSet) func(args []string) error {
+	filter := &assetFilter{}
+	filter.register(fs)
+	fs.StringVar(&filter.status, "status", "all", "Only assets with this status (pending, pinned, failed, all)")
+	limit := fs.Int("limit", 100, "Most assets to list (0 = all)")
+	asJSON := jsonFlag(fs)
+	return func(args []string) error {
+		if err := wantArgs(args, 0); err != nil {
+			return err
+		}
+		client, err := connect(false)
+		if err != nil {
+			return err
+		}
+		var entries []assetEntry
+		if client != nil {
+			entries, err = filter.fetch(client, *limit)
+			if err != nil {
+				return fmt.Errorf("failed to get assets: %w", err)
+			}
+		} else {
+			in, err := open()
+			if err != nil {
+				return err
+			}
+			assets, err := filter.find(in.db, *limit)
+			if err != nil {
+				return fmt.Errorf("failed to get assets: %w", err)
+			}
+			entries = make([]assetEntry, 0, len(assets))
+			for _, a := range assets {
+				entries = append(entries, newAssetEntry(a))
+			}
+		}
+		if *asJSON {
+			return printJSON(entries)
+		}
+		if len(entries) == 0 {
+			fmt.Println("No assets found")
+			return nil
+		}
+		fmt.Printf("%-8s %-18s %-10s %10s  %s\n", "ID", "STATUS", "TYPE", "SIZE", "URI")
+		for _, e := range entries {
+			fmt.Printf("%-8d %-18s %-10s %10s  %s\n", e.ID, e.Status, e.Type, formatSize(e.SizeBytes), e.URI)
+		}
+		return nil
+	}
+}
+
+// retryResult is the outcome of asset retry
+type retryResult struct {
+	Processed int `json:"processed"`
+	Pinned    int `json:"pinned"`
+	Failed    int `json:"failed"`
+}
+
+func setupAssetRetry(fs *flag.FlagSet) func(args []string) error {
+	includeFailed := fs.Bool("failed", false, "Also retry failed assets")
+	limit := fs.Int("limit", 0, "Most assets to pin (0 = all)")
+	asJSON := jsonFlag(fs)
+	return func(args []string) error {
+		if err := wantArgs(args, 0); err != nil {
+			return err
+		}
+		client, err := connect(true)
+		if err != nil {
+			return err
+		}
+		if client != nil {
+			if *limit > 0 {
+				return cli.Errorf(cli.ExitUsage, "--limit is not available through the server, which pins pending assets itself")
+			}
+			return retryAssetsRemote(client, *includeFailed, *asJSON)
+		}
+		in, err := open()
+		if err != nil {
+			return err
+		}
+
+		if *includeFailed {
+			reset := in.db.Model(&db.Asset{}).
+				Where("status IN ?", []string{db.StatusFailed, db.StatusFailedUnavailable}).
+				Updates(map[string]interface{}{
+					"status":      db.StatusPending,
+					"error_msg":   "",
+					"retry_count": 0,
+				})
+			if reset.Error != nil {
+				return fmt.Errorf("failed to reset failed assets: %w", reset.Error)
+			}
+		}
+
+		// Check if there are pending assets first
+		stats, err := in.db.GetAssetStats()
+		if err != nil {
+			return fmt.Errorf("failed to get stats: %w", err)
+		}
+		pendingCount := stats["pending"]
+		if pendingCount == 0 {
+			if *asJSON {
+				return printJSON(retryResult{})
+			}
+			fmt.Println("No pending assets to process")
+			return nil
+		}
+		if !*asJSON {
+			fmt.Printf("Found %d pending assets, starting IPFS node...\n", pendingCount)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		node, err := in.startNode(ctx)
+		if err != nil {
+			return err
+		}
+		defer node.Stop()
+
+		if !*asJSON {
+			fmt.Println("IPFS node started, processing pending assets...")
+		}
+
+		// Create a minimal backup manager just for pinning
+		manager := core.NewBackupManager(node, indexer.NewIndexer(in.cfg.TZKT.BaseURL), in.db, in.cfg)
+		defer manager.Shutdown()
+
+		var result retryResult
+		result.Processed, result.Pinned, result.Failed = manager.ProcessPendingAssets(ctx, *limit)
+		if *asJSON {
+			if err := printJSON(result); err != nil {
+				return err
+			}
+		} else {
+			fmt.Printf("Processed %d assets: %d pinned, %d failed\n", result.Processed, result.Pinned, result.Failed)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if result.Failed > 0 {
+			return cli.Errorf(cli.ExitPartial, "%d assets failed to pin", result.Failed)
+		}
+		return nil
+	}
+}
+
+// remoteRetryResult is the outcome of asset retry through a server
+type remoteRetryResult struct {
+	Retried int `json:"retried"` // Failed assets set back to pending
+	Queued  int `json:"queued"`  // Assets waiting in the server's pin queue
+}
+
+// retryAssetsRemote retries failed assets on the server. The server pins
+// pending assets continuously, so this only queues them.
+func retryAssetsRemote(client *api.RemoteClient, includeFailed, asJSON bool) error {
+	var result remoteRetryResult
+	if includeFailed {
+		count, err := client.RetryAllFailed(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to retry failed assets: %w", remoteError(err))
+		}
+		result.Retried = int(count)
+	}
+	queue, err := client.Queue(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get pin queue: %w", remoteError(err))
+	}
+	result.Queued = queue.Queued + queue.InFlight
+
+	if asJSON {
+		return printJSON(result)
+	}
+	if includeFailed {
+		fmt.Printf("Set %d failed assets back to pending\n", result.Retried)
+	}
+	fmt.Printf("The server is pinning %d queued assets\n", result.Queued)
+	return nil
+}
+
+// verifyResult is the outcome of asset verify
+type verifyResult struct {
+	Checked int          `json:"checked"`
+	Missing []assetEntry `json:"missing"` // Pinned in the database but not on the node
+	Reset   int          `json:"reset"`   // Missing assets set back to pending by --fix
+}
+
+func setupAssetVerify(fs *flag.FlagSet) func(args []string) error {
+	filter := &assetFilter{status: db.StatusPinned}
+	filter.register(fs)
+	fix := fs.Bool("fix", false, "Set missing assets back to pending so they are pinned again")
+	asJSON := jsonFlag(fs)
+	return func(args []string) error {
+		if err := wantArgs(args, 0); err != nil {
+			return err
+		}
+		if err := localOnly("asset verify"); err != nil {
+			return err
+		}
+		in, err := open()
+		if err != nil {
+			return err
+		}
+		assets, err := filter.find(in.db, 0)
+		if err != nil {
+			return fmt.Errorf("failed to get assets: %w", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		node, err := in.startNode(ctx)
+		if err != nil {
+			return err
+		}
+		defer node.Stop()
+
+		result := verifyResult{Missing: []assetEntry{}}
+		for _, asset := range assets {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			cid := core.ExtractCIDFromURI(asset.URI)
+			if cid == "" {
+				continue
+			}
+			result.Checked++
+			pinned, err := node.IsPinned(ctx, cid)
+			if err != nil {
+				asset.ErrorMsg = err.Error()
+			}
+			if pinned {
+				continue
+			}
+			result.Missing = append(result.Missing, newAssetEntry(asset))
+			if *fix {
+				if err := in.db.Model(&db.Asset{}).Where("id = ?", asset.ID).Updates(map[string]interface{}{
+					"status":      db.StatusPending,
+					"retry_count": 0,
+					"pinned_at":   nil,
+				}).Error; err != nil {
+					return fmt.Errorf("failed to reset asset %d: %w", asset.ID, err)
+				}
+				result.Reset++
+			}
+		}
+
+		if *asJSON {
+			if err := printJSON(result); err != nil {
+				return err
+			}
+		} else {
+			for _, m := range result.Missing {
+				fmt.Printf("  ✗ %d %s\n", m.ID, m.URI)
+			}
+			fmt.Printf("Checked %d pinned assets: %d missing\n", result.Checked, len(result.Missing))
+			if result.Reset > 0 {
+				fmt.Printf("Set %d assets back to pending; run 'porcupin asset retry' to pin them now.\n", result.Reset)
+			}
+		}
+		if len(result.Missing) > 0 {
+			return cli.Errorf(cli.ExitPartial, "%d pinned assets are missing from the IPFS node", len(result.Missing))
+		}
+		return nil
+	}
+}
+
+func setupAssetReconcile(fs *flag.FlagSet) func(args []string) error {
+	apply := fs.Bool("apply", false, "Unpin the unreferenced pins and set the missing assets back to pending")
+	asJSON := jsonFlag(fs)
+	return func(args []string) error {
+		if err := wantArgs(args, 0); err != nil {
+			return err
+		}
+		// Even a dry run needs the IPFS repository, which a daemon holds
+		client, err := connect(true)
+		if err != nil {
+			return err
+		}
+
+		var report *core.PinReconcileReport
+		if client != nil {
+			report, err = client.ReconcilePins(context.Background(), !*apply)
+			if err != nil {
+				return fmt.Errorf("failed to reconcile pins: %w", remoteError(err))
+			}
+		} else {
+			in, err := open()
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			node, err := in.startNode(ctx)
+			if err != nil {
+				return err
+			}
+			defer node.Stop()
+
+			report, err = core.ReconcilePins(ctx, node, in.db, !*apply)
+			if err != nil {
+				return fmt.Errorf("failed to reconcile pins: %w", err)
+			}
+		}
+
+		if *asJSON {
+			if err := printJSON(report); err != nil {
+				return err
+			}
+		} else {
+			printReconcileReport(report)
+		}
+		if len(report.Errors) > 0 {
+			return cli.Errorf(cli.ExitPartial, "%d pins or assets could not be fixed", len(report.Errors))
+		}
+		if report.DryRun && len(report.Unreferenced)+len(report.Missing) > 0 {
+			return cli.Errorf(cli.ExitPartial, "the IPFS node and the database differ; run with --apply to fix")
+		}
+		return nil
+	}
+}
+
+// printReconcileReport prints the differences ReconcilePins found and what
+// it did about them
+func printReconcileReport(report *core.PinReconcileReport) {
+	for _, c := range report.Unreferenced {
+		fmt.Printf("  unreferenced pin  %s\n", c)
+	}
+	for _, m := range report.Missing {
+		fmt.Printf("  missing pin       %s (asset %d, %s)\n", m.CID, m.AssetID, m.URI)
+	}
+	for _, sh := range report.Shared {
+		fmt.Printf("  shared CID        %s (assets %v)\n", sh.CID, sh.AssetIDs)
+	}
+	for _, e := range report.Errors {
+		fmt.Printf("  ✗ %s\n", e)
+	}
+	fmt.Printf("Compared %d pins with %d assets: %d unreferenced pins, %d missing pins, %d shared CIDs\n",
+		report.Pins, report.Assets, len(report.Unreferenced), len(report.Missing), len(report.Shared))
+	if report.DryRun {
+		return
+	}
+	fmt.Printf("Unpinned %d pins and set %d assets back to pending.\n", report.Unpinned, report.Reset)
+	if report.Reset > 0 {
+		fmt.Println("Run 'porcupin asset retry' to pin them now.")
+	}
+}
+
+// formatSize formats a byte count for tables
+func formatSize(bytes int64) string {
+	switch {
+	case bytes <= 0:
+		return "-"
+	case bytes < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/1024)
+	case bytes < 1024*1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1024*1024))
+	default:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/(1024*1024*1024))
+	}
+}
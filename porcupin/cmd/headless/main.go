@@ -2,480 +2,291 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
-	"os/signal"
 	"path/filepath"
-	"syscall"
-	"time"
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
 
-	"porcupin/backend/api"
 	"porcupin/backend/cli"
 	"porcupin/backend/config"
 	"porcupin/backend/core"
 	"porcupin/backend/db"
-	"porcupin/backend/indexer"
 	"porcupin/backend/ipfs"
-	"porcupin/backend/version"
+	"porcupin/backend/storage"
 )
 
 func main() {
+	os.Exit(program().Run(legacyArgs(os.Args[1:])))
+}
 
-	// Parse command line flags
-	configPath := flag.String("config", "", "Path to config file (default: ~/.porcupin/config.yaml)")
-	dataDir := flag.String("data", "", "Data directory (default: ~/.porcupin)")
-	addWallet := flag.String("add-wallet", "", "Add a wallet address and exit")
-	walletAlias := flag.String("alias", "", "Alias for wallet (use with --add-wallet or --rename-wallet)")
-	renameWallet := flag.String("rename-wallet", "", "Rename a wallet (set alias), use with --alias")
-	listWallets := flag.Bool("list-wallets", false, "List all tracked wallets and exit")
-	removeWallet := flag.String("remove-wallet", "", "Remove a wallet address and exit")
-	unpinWallet := flag.String("unpin-wallet", "", "Unpin all assets for a wallet and exit")
-	deleteWallet := flag.String("delete-wallet", "", "Remove wallet and unpin all its assets, then exit")
-	runGC := flag.Bool("gc", false, "Run IPFS garbage collection and exit")
-	showStats := flag.Bool("stats", false, "Show current stats and exit")
-	showVersion := flag.Bool("version", false, "Show version and exit")
-	showVersionShort := flag.Bool("v", false, "Show version and exit")
-	showAbout := flag.Bool("about", false, "Show about information and exit")
-	retryPending := flag.Bool("retry-pending", false, "Process all pending assets and exit")
-
-	// API server flags
-	serveAPI := flag.Bool("serve", false, "Start API server for remote access")
-	apiPort := flag.Int("api-port", 8085, "API server port (use with --serve)")
-	apiBind := flag.String("api-bind", "0.0.0.0", "API server bind address (use with --serve)")
-	apiToken := flag.String("api-token", "", "Set API token (WARNING: visible in ps, prefer env var)")
-	allowPublic := flag.Bool("allow-public", false, "Allow public IP connections (use with --serve)")
-	tlsCert := flag.String("tls-cert", "", "Path to TLS certificate file (use with --serve)")
-	tlsKey := flag.String("tls-key", "", "Path to TLS private key file (use with --serve)")
-	regenerateToken := flag.Bool("regenerate-token", false, "Regenerate API token and exit")
+// globals holds the flags every command accepts
+var globals struct {
+	dataDir     string
+	configPath  string
+	ipfsPort    int
+	remote      string
+	token       string
+	fingerprint string
+}
 
-	// IPFS flags
-	ipfsPort := flag.Int("ipfs-port", 0, "IPFS swarm port (default 4001, 0 = use config)")
+func program() *cli.Program {
+	return &cli.Program{
+		Name: "porcupin",
+		Globals: func(fs *flag.FlagSet) {
+			fs.StringVar(&globals.dataDir, "data", "", "Data directory (default: ~/.porcupin)")
+			fs.StringVar(&globals.configPath, "config", "", "Path to config file (default: <data>/config.yaml)")
+			fs.IntVar(&globals.ipfsPort, "ipfs-port", 0, "IPFS swarm port (0 = use config)")
+			fs.StringVar(&globals.remote, "remote", os.Getenv("PORCUPIN_REMOTE"), "Send commands to the server at host:port or https://host:port (default: $PORCUPIN_REMOTE)")
+			fs.StringVar(&globals.token, "token", "", "API token for --remote (default: $PORCUPIN_API_TOKEN)")
+			fs.StringVar(&globals.fingerprint, "fingerprint", os.Getenv("PORCUPIN_FINGERPRINT"), "SHA-256 fingerprint of the --remote server's certificate, if it isn't CA-signed (default: $PORCUPIN_FINGERPRINT)")
+		},
+		Default: []string{"run"},
+		Commands: []*cli.Command{
+			{Name: "run", Summary: "Back up the tracked wallets until stopped (the default)", Setup: setupRun},
+			{Name: "serve", Summary: "Back up the tracked wallets and serve the REST API", Setup: setupServe},
+			{Name: "wallet", Commands: []*cli.Command{
+				{Name: "add", Args: "<address|name.tez>", Summary: "Track a wallet, by address or .tez domain", Setup: setupWalletAdd},
+				{Name: "list", Summary: "List tracked wallets", Setup: setupWalletList},
+				{Name: "rename", Args: "<address>", Summary: "Set or clear a wallet's alias", Setup: setupWalletRename},
+				{Name: "rm", Args: "<address>", Summary: "Stop tracking a wallet", Setup: setupWalletRemove},
+				{Name: "unpin", Args: "<address>", Summary: "Unpin a wallet's assets but keep tracking it", Setup: setupWalletUnpin},
+				{Name: "sync", Args: "[address...]", Summary: "Sync wallets now and pin their new assets", Setup: setupWalletSync},
+				{Name: "import", Args: "<file>", Summary: "Track the wallets listed in a CSV or JSON file", Setup: setupWalletImport},
+				{Name: "export", Summary: "Write the tracked wallets as CSV or JSON", Setup: setupWalletExport},
+			}},
+			{Name: "asset", Commands: []*cli.Command{
+				{Name: "list", Summary: "List assets", Setup: setupAssetList},
+				{Name: "retry", Summary: "Pin pending assets now", Setup: setupAssetRetry},
+				{Name: "verify", Summary: "Check that pinned assets are pinned on the IPFS node", Setup: setupAssetVerify},
+				{Name: "reconcile", Summary: "Compare the IPFS node's pins with the assets and fix what differs", Setup: setupAssetReconcile},
+			}},
+			{Name: "stats", Summary: "Show pin counts and storage use", Setup: setupStats},
+			{Name: "gc", Summary: "Run IPFS garbage collection", Setup: setupGC},
+			{Name: "storage", Commands: []*cli.Command{
+				{Name: "migrate", Args: "<path>", Summary: "Move the IPFS repository to a new location", Setup: setupStorageMigrate},
+			}},
+			{Name: "config", Commands: []*cli.Command{
+				{Name: "check", Summary: "Validate the config file and PORCUPIN_* environment", Setup: setupConfigCheck},
+			}},
+			{Name: "token", Commands: []*cli.Command{
+				{Name: "regenerate", Summary: "Replace the API token", Setup: setupTokenRegenerate},
+				{Name: "pair", Summary: "Show a code that gives an app its own token", Setup: setupTokenPair},
+				{Name: "list", Summary: "List paired clients", Setup: setupTokenList},
+				{Name: "revoke", Args: "<id>", Summary: "Revoke a paired client's token", Setup: setupTokenRevoke},
+			}},
+			{Name: "version", Summary: "Show the version", Setup: setupVersion},
+			{Name: "about", Summary: "Show information about Porcupin", Setup: setupAbout},
+		},
+	}
+}
 
-	flag.Parse()
+// legacyCommands maps the flags of the old flag-only command line to
+// commands, in the order the old command line checked them. The flag's
+// value, unless it is a boolean, becomes the command's argument.
+var legacyCommands = []struct {
+	flag    string
+	command []string
+}{
+	{"version", []string{"version"}},
+	{"v", []string{"version"}},
+	{"about", []string{"about"}},
+	{"regenerate-token", []string{"token", "regenerate"}},
+	{"check-config", []string{"config", "check"}},
+	{"add-wallet", []string{"wallet", "add"}},
+	{"rename-wallet", []string{"wallet", "rename"}},
+	{"remove-wallet", []string{"wallet", "rm"}},
+	{"unpin-wallet", []string{"wallet", "unpin"}},
+	{"delete-wallet", []string{"wallet", "rm", "--unpin"}},
+	{"gc", []string{"gc"}},
+	{"list-wallets", []string{"wallet", "list"}},
+	{"stats", []string{"stats"}},
+	{"migrate-storage", []string{"storage", "migrate"}},
+	{"retry-pending", []string{"asset", "retry"}},
+	{"serve", []string{"serve"}},
+}
 
-	// Warn if --api-token flag is used (visible in ps)
-	if *apiToken != "" {
-		log.Println("⚠️  WARNING: --api-token flag is visible in process list.")
-		log.Println("   Consider using PORCUPIN_API_TOKEN env var instead.")
+// legacyArgs rewrites an old command line such as "--add-wallet tz1...
+// --alias Me" as "wallet add --alias=Me tz1...". Other command lines are
+// returned unchanged.
+func legacyArgs(args []string) []string {
+	if len(args) == 0 || args[0] == "" || args[0][0] != '-' {
+		return args
 	}
 
-	if *showVersion || *showVersionShort {
-		cli.PrintBannerWithVersion(version.Version)
-		return
+	fs := flag.NewFlagSet("porcupin", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	for _, name := range []string{"config", "data", "alias", "add-wallet", "rename-wallet", "remove-wallet",
+		"unpin-wallet", "delete-wallet", "migrate-storage", "api-bind", "api-token", "tls-cert", "tls-key"} {
+		fs.String(name, "", "")
+	}
+	for _, name := range []string{"list-wallets", "gc", "stats", "version", "v", "about", "retry-pending",
+		"check-config", "serve", "allow-public", "regenerate-token"} {
+		fs.Bool(name, false, "")
+	}
+	fs.Int("api-port", 0, "")
+	fs.Int("ipfs-port", 0, "")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 {
+		return args // A new-style command line, or an error the new parser reports
 	}
 
-	if *showAbout {
-		cli.PrintAbout(version.Version)
-		return
+	set := make(map[string]*flag.Flag)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = f })
+	for _, legacy := range legacyCommands {
+		f := set[legacy.flag]
+		if f == nil {
+			continue
+		}
+		out := append([]string{}, legacy.command...)
+		for _, other := range legacyCommands {
+			delete(set, other.flag) // Only the first command runs, as before
+		}
+		fs.VisitAll(func(o *flag.Flag) {
+			if set[o.Name] != nil {
+				out = append(out, "--"+o.Name+"="+o.Value.String())
+			}
+		})
+		if _, isBool := f.Value.(interface{ IsBoolFlag() bool }); !isBool {
+			out = append(out, "--", f.Value.String())
+		}
+		return out
 	}
+	return args
+}
 
-	// Determine data directory
-	var dataPath string
-	if *dataDir != "" {
-		dataPath = *dataDir
-	} else {
+// dataPath returns the data directory, creating it if needed
+func dataPath() (string, error) {
+	path := globals.dataDir
+	if path == "" {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
-			log.Fatalf("Failed to get home directory: %v", err)
+			return "", fmt.Errorf("failed to get home directory: %w", err)
 		}
-		dataPath = filepath.Join(homeDir, ".porcupin")
+		path = filepath.Join(homeDir, ".porcupin")
 	}
-
-	// Ensure data directory exists
-	if err := os.MkdirAll(dataPath, 0755); err != nil {
-		log.Fatalf("Failed to create data directory: %v", err)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return "", fmt.Errorf("failed to create data directory: %w", err)
 	}
+	return path, nil
+}
 
-	// Handle token management commands (before other initialization)
-	if *regenerateToken {
-		token, err := api.RegenerateToken(dataPath)
-		if err != nil {
-			log.Fatalf("Failed to regenerate token: %v", err)
-		}
-		fmt.Println("New API token generated:")
-		fmt.Println()
-		fmt.Printf("  %s\n", token)
-		fmt.Println()
-		fmt.Println("⚠️  Save this token securely - it will not be shown again!")
-		fmt.Println("   Token hash stored at:", filepath.Join(dataPath, api.TokenFileName))
-		return
+// configPath returns the config file path
+func configPath(dataPath string) string {
+	if globals.configPath != "" {
+		return globals.configPath
 	}
+	return filepath.Join(dataPath, "config.yaml")
+}
 
-	// Load configuration
-	var cfgPath string
-	if *configPath != "" {
-		cfgPath = *configPath
-	} else {
-		cfgPath = filepath.Join(dataPath, "config.yaml")
-	}
+// instance is an opened data directory: its config and database
+type instance struct {
+	dataPath string
+	cfgPath  string
+	cfg      *config.Config
+	db       *db.Database
+}
 
-	cfg, err := config.LoadConfig(cfgPath)
+// open loads the config and opens the database
+func open() (*instance, error) {
+	in, err := load()
 	if err != nil {
-		log.Printf("No config file found, using defaults")
-		cfg = config.DefaultConfig()
+		return nil, err
 	}
-
-	// Apply CLI overrides to config
-	if *ipfsPort > 0 {
-		cfg.IPFS.SwarmPort = *ipfsPort
-		log.Printf("Using CLI-specified IPFS swarm port: %d", *ipfsPort)
-	}
-
-	// Initialize database
-	dbPath := filepath.Join(dataPath, "porcupin.db")
+	dbPath := filepath.Join(in.dataPath, "porcupin.db")
 	gormDB, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
 	if err != nil {
-		log.Fatalf("Failed to open database: %v", err)
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 	if err := db.InitDB(gormDB); err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
-	database := db.NewDatabase(gormDB)
+	in.db = db.NewDatabase(gormDB)
+	return in, nil
+}
 
-	// Handle one-off commands
-	if *addWallet != "" {
-		wallet := &db.Wallet{Address: *addWallet, Alias: *walletAlias}
-		if err := database.SaveWallet(wallet); err != nil {
-			log.Fatalf("Failed to add wallet: %v", err)
-		}
-		if *walletAlias != "" {
-			fmt.Printf("Added wallet: %s (%s)\n", *walletAlias, *addWallet)
-		} else {
-			fmt.Printf("Added wallet: %s\n", *addWallet)
-		}
-		return
+// load loads the config, applying --ipfs-port, without opening the database
+func load() (*instance, error) {
+	dataPath, err := dataPath()
+	if err != nil {
+		return nil, err
 	}
+	in := &instance{dataPath: dataPath, cfgPath: configPath(dataPath)}
 
-	if *renameWallet != "" {
-		if err := database.Model(&db.Wallet{}).Where("address = ?", *renameWallet).Update("alias", *walletAlias).Error; err != nil {
-			log.Fatalf("Failed to rename wallet: %v", err)
-		}
-		if *walletAlias != "" {
-			fmt.Printf("Renamed wallet %s to: %s\n", *renameWallet, *walletAlias)
-		} else {
-			fmt.Printf("Cleared alias for wallet: %s\n", *renameWallet)
-		}
-		return
+	// Defaults, then the config file, then PORCUPIN_* variables
+	in.cfg, err = config.LoadConfig(in.cfgPath)
+	if err != nil {
+		return nil, cli.Errorf(cli.ExitConfig, "%s: %v (run 'porcupin config check' for details)", in.cfgPath, err)
 	}
-
-	if *removeWallet != "" {
-		if err := database.DeleteWallet(*removeWallet); err != nil {
-			log.Fatalf("Failed to remove wallet: %v", err)
+	if globals.ipfsPort > 0 {
+		in.cfg.IPFS.SwarmPort = globals.ipfsPort
+		if err := in.cfg.Validate(); err != nil {
+			return nil, cli.Errorf(cli.ExitUsage, "invalid --ipfs-port: %v", err)
 		}
-		fmt.Printf("Removed wallet: %s (assets still pinned, use --unpin-wallet to unpin)\n", *removeWallet)
-		return
 	}
+	return in, nil
+}
 
-	// Commands that require IPFS: unpin-wallet, delete-wallet, gc
-	if *unpinWallet != "" || *deleteWallet != "" || *runGC {
-		// Start IPFS node
-		ipfsRepoPath := filepath.Join(dataPath, "ipfs")
-		ipfsNode, err := ipfs.NewNode(ipfsRepoPath, cfg.IPFS.SwarmPort)
-		if err != nil {
-			log.Fatalf("Failed to create IPFS node: %v", err)
-		}
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-		if err := ipfsNode.Start(ctx); err != nil {
-			log.Fatalf("Failed to start IPFS node: %v", err)
-		}
-		defer ipfsNode.Stop()
-
-		if *unpinWallet != "" {
-			assets, err := database.GetAssetsByWallet(*unpinWallet)
-			if err != nil {
-				log.Fatalf("Failed to get assets: %v", err)
-			}
-			if len(assets) == 0 {
-				fmt.Printf("No assets found for wallet: %s\n", *unpinWallet)
-				return
-			}
-			fmt.Printf("Unpinning %d assets for wallet %s...\n", len(assets), *unpinWallet)
-			unpinned := 0
-			for _, asset := range assets {
-				cid := core.ExtractCIDFromURI(asset.URI)
-				if cid == "" {
-					continue
-				}
-				if err := ipfsNode.Unpin(ctx, cid); err != nil {
-					log.Printf("Warning: failed to unpin %s: %v", cid, err)
-				} else {
-					unpinned++
-				}
-			}
-			fmt.Printf("Unpinned %d/%d assets. Run --gc to reclaim disk space.\n", unpinned, len(assets))
-			return
-		}
-
-		if *deleteWallet != "" {
-			assets, err := database.GetAssetsByWallet(*deleteWallet)
-			if err != nil {
-				log.Fatalf("Failed to get assets: %v", err)
-			}
-			fmt.Printf("Deleting wallet %s: unpinning %d assets...\n", *deleteWallet, len(assets))
-			for _, asset := range assets {
-				cid := core.ExtractCIDFromURI(asset.URI)
-				if cid == "" {
-					continue
-				}
-				if err := ipfsNode.Unpin(ctx, cid); err != nil {
-					log.Printf("Warning: failed to unpin %s: %v", cid, err)
-				}
-			}
-			// Delete from database
-			if err := database.DeleteAssetsByWallet(*deleteWallet); err != nil {
-				log.Printf("Warning: failed to delete assets from DB: %v", err)
-			}
-			if err := database.DeleteNFTsByWallet(*deleteWallet); err != nil {
-				log.Printf("Warning: failed to delete NFTs from DB: %v", err)
-			}
-			if err := database.DeleteWallet(*deleteWallet); err != nil {
-				log.Fatalf("Failed to delete wallet: %v", err)
-			}
-			fmt.Printf("Deleted wallet %s and unpinned assets. Run --gc to reclaim disk space.\n", *deleteWallet)
-			return
-		}
-
-		if *runGC {
-			fmt.Println("Running IPFS garbage collection...")
-			if err := ipfsNode.GarbageCollect(ctx); err != nil {
-				log.Fatalf("Garbage collection failed: %v", err)
-			}
-			fmt.Println("Garbage collection complete.")
-			return
-		}
+// newNode creates the IPFS node without starting it
+func (in *instance) newNode() (*ipfs.Node, error) {
+	node, err := ipfs.NewNode(in.repoPath(), in.cfg.IPFS.SwarmPort)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create IPFS node: %w", err)
 	}
-
-	if *listWallets {
-		wallets, err := database.GetAllWallets()
-		if err != nil {
-			log.Fatalf("Failed to get wallets: %v", err)
-		}
-		if len(wallets) == 0 {
-			fmt.Println("No wallets configured")
-		} else {
-			fmt.Println("Tracked wallets:")
-			for _, w := range wallets {
-				alias := w.Alias
-				if alias == "" {
-					alias = "(no alias)"
-				}
-				fmt.Printf("  %s - %s\n", w.Address, alias)
-			}
-		}
-		return
+	if err := node.SetColdPath(in.cfg.IPFS.Tiering.ColdPath); err != nil {
+		return nil, fmt.Errorf("failed to set cold storage tier: %w", err)
 	}
+	return node, nil
+}
 
-	if *showStats {
-		stats, err := database.GetAssetStats()
-		if err != nil {
-			log.Fatalf("Failed to get stats: %v", err)
-		}
-		totalAssets := stats["pending"] + stats["pinned"] + stats["failed"] + stats["failed_unavailable"]
-		
-		// Get actual disk usage from IPFS repo directory
-		ipfsRepoPath := filepath.Join(dataPath, "ipfs")
-		storageBytes, err := core.GetDiskUsageBytes(ipfsRepoPath)
-		if err != nil {
-			log.Printf("Warning: could not get disk usage: %v", err)
-			storageBytes = 0
-		}
-		
-		cli.PrintStats(
-			stats["nft_count"],
-			totalAssets,
-			stats["pinned"],
-			stats["pending"],
-			stats["failed"]+stats["failed_unavailable"],
-			float64(storageBytes)/(1024*1024*1024),
-		)
-		return
+// startNode creates and starts the IPFS node for a one-off command; the
+// caller stops it
+func (in *instance) startNode(ctx context.Context) (*ipfs.Node, error) {
+	node, err := in.newNode()
+	if err != nil {
+		return nil, err
 	}
-
-	// Handle --retry-pending (requires IPFS)
-	if *retryPending {
-		// Check if there are pending assets first
-		stats, err := database.GetAssetStats()
-		if err != nil {
-			log.Fatalf("Failed to get stats: %v", err)
-		}
-		pendingCount := stats["pending"]
-		if pendingCount == 0 {
-			fmt.Println("No pending assets to process")
-			return
+	if err := core.StartIPFS(ctx, node, in.db); err != nil {
+		if errors.Is(err, storage.ErrStorageUnavailable) {
+			return nil, fmt.Errorf("storage unavailable: %w", err)
 		}
-
-		fmt.Printf("Found %d pending assets, starting IPFS node...\n", pendingCount)
-
-		ipfsRepoPath := filepath.Join(dataPath, "ipfs")
-		ipfsNode, err := ipfs.NewNode(ipfsRepoPath, cfg.IPFS.SwarmPort)
-		if err != nil {
-			log.Fatalf("Failed to create IPFS node: %v", err)
-		}
-
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-
-		if err := ipfsNode.Start(ctx); err != nil {
-			log.Fatalf("Failed to start IPFS node: %v", err)
-		}
-		defer ipfsNode.Stop()
-
-		fmt.Println("IPFS node started, processing pending assets...")
-
-		// Create a minimal backup manager just for pinning
-		idx := indexer.NewIndexer(cfg.TZKT.BaseURL)
-		manager := core.NewBackupManager(ipfsNode, idx, database, cfg)
-
-		processed, pinned, failed := manager.ProcessPendingAssets(ctx, 0) // 0 = no limit
-		fmt.Printf("Processed %d assets: %d pinned, %d failed\n", processed, pinned, failed)
-		return
+		return nil, fmt.Errorf("failed to start IPFS node: %w", err)
 	}
+	return node, nil
+}
 
-	// Start IPFS node
-	fmt.Println("🦔 Porcupin Headless Server")
-	fmt.Println("Starting IPFS node...")
-
-	ipfsRepoPath := filepath.Join(dataPath, "ipfs")
-	ipfsNode, err := ipfs.NewNode(ipfsRepoPath, cfg.IPFS.SwarmPort)
-	if err != nil {
-		log.Fatalf("Failed to create IPFS node: %v", err)
+// repoPath returns the IPFS repository path: the config's repo_path once it
+// has been changed from the default (e.g. by storage migrate), otherwise
+// ipfs under the data directory
+func (in *instance) repoPath() string {
+	cfg := in.cfg
+	if cfg.IPFS.RepoPath == "" || cfg.IPFS.RepoPath == config.DefaultConfig().IPFS.RepoPath {
+		return filepath.Join(in.dataPath, "ipfs")
 	}
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	if err := ipfsNode.Start(ctx); err != nil {
-		log.Fatalf("Failed to start IPFS node: %v", err)
+	path, err := storage.ExpandPath(cfg.IPFS.RepoPath)
+	if err != nil {
+		return cfg.IPFS.RepoPath
 	}
-	defer ipfsNode.Stop()
-
-	fmt.Println("IPFS node started")
-
-	// Initialize indexer
-	idx := indexer.NewIndexer(cfg.TZKT.BaseURL)
-
-	// Create and start backup service
-	service := core.NewBackupService(ipfsNode, idx, database, cfg)
-
-	service.Start(ctx)
-	fmt.Println("Backup service started. Monitoring wallets...")
-
-	// Print initial wallet count
-	wallets, _ := database.GetAllWallets()
-	fmt.Printf("Tracking %d wallet(s)\n", len(wallets))
-
-	// Handle shutdown signals
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-
-	// Start API server if requested
-	if *serveAPI {
-		var plainToken string  // Used for env var or flag (direct comparison)
-		var tokenHash string   // Used for file-based auth (bcrypt comparison)
-		var isNew bool
-
-		if *apiToken != "" {
-			// Token from flag (already warned above)
-			plainToken = *apiToken
-			fmt.Println("Using API token from --api-token flag")
-		} else if envToken := api.GetTokenFromEnv(); envToken != "" {
-			// Token from environment variable
-			if !api.ValidateTokenFormat(envToken) {
-				log.Fatalf("PORCUPIN_API_TOKEN has invalid format")
-			}
-			plainToken = envToken
-			fmt.Println("Using API token from PORCUPIN_API_TOKEN environment variable")
-		} else {
-			// Check for existing token hash in file, or create new token
-			var err error
-			tokenHash, err = api.GetTokenHashFromFile(dataPath)
-			if err != nil {
-				log.Fatalf("Failed to read token file: %v", err)
-			}
-
-			if tokenHash == "" {
-				// No token exists - generate new one
-				var newToken string
-				newToken, isNew, err = api.GetOrCreateToken(dataPath)
-				if err != nil {
-					log.Fatalf("Failed to create API token: %v", err)
-				}
-
-				if isNew {
-					fmt.Println()
-					fmt.Println("═══════════════════════════════════════════════════════════════")
-					fmt.Println("  NEW API TOKEN GENERATED - SAVE THIS NOW!")
-					fmt.Println("═══════════════════════════════════════════════════════════════")
-					fmt.Println()
-					fmt.Printf("  %s\n", newToken)
-					fmt.Println()
-					fmt.Println("  ⚠️  This token will NOT be displayed again!")
-					fmt.Println("  Token hash stored at:", filepath.Join(dataPath, api.TokenFileName))
-					fmt.Println("═══════════════════════════════════════════════════════════════")
-					fmt.Println()
-
-					// Re-read the hash we just created
-					tokenHash, err = api.GetTokenHashFromFile(dataPath)
-					if err != nil {
-						log.Fatalf("Failed to read token hash: %v", err)
-					}
-				}
-			} else {
-				fmt.Println("Using API token from file (hash-based authentication)")
-			}
-		}
-
-		// Create API server config
-		serverCfg := api.ServerConfig{
-			Port:            *apiPort,
-			BindAddress:     *apiBind,
-			Token:           plainToken,
-			TokenHash:       tokenHash,
-			AllowPublic:     *allowPublic,
-			DataDir:         dataPath,
-			Version:         version.Version,
-			PerIPRateLimit:  10,
-			GlobalRateLimit: 100,
-			TLSCert:         *tlsCert,
-			TLSKey:          *tlsKey,
-		}
+	return path
+}
 
-		// Create and start API server in a goroutine
-		apiServer := api.NewServer(serverCfg, database, service)
-		apiServer.SetIPFS(ipfsNode)
-		go func() {
-			if err := apiServer.Start(ctx); err != nil {
-				log.Printf("API server error: %v", err)
-			}
-		}()
-	}
+// jsonFlag adds the --json flag to a read command
+func jsonFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("json", false, "Print JSON instead of text")
+}
 
-	// Status ticker
-	statusTicker := time.NewTicker(30 * time.Second)
-	defer statusTicker.Stop()
+// printJSON writes v to stdout as JSON
+func printJSON(v interface{}) error {
+	return cli.WriteJSON(os.Stdout, v)
+}
 
-	for {
-		select {
-		case <-sigCh:
-			fmt.Println("\nShutting down...")
-			service.Stop()
-			return
-		case <-statusTicker.C:
-			status := service.GetStatus()
-			stats, _ := database.GetAssetStats()
-			log.Printf("[%s] Pinned: %d/%d, Failed: %d, Pending retries: %d",
-				status.State,
-				stats["pinned_assets"],
-				stats["total_assets"],
-				stats["failed_assets"],
-				status.PendingRetries,
-			)
-		}
+// wantArgs checks the number of positional arguments
+func wantArgs(args []string, n int) error {
+	if len(args) != n {
+		return cli.Errorf(cli.ExitUsage, "expected %d argument(s), got %d", n, len(args))
 	}
+	return nil
 }
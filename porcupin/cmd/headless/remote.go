@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"porcupin/backend/api"
+	"porcupin/backend/cli"
+	"porcupin/backend/ipfs"
+)
+
+// healthTimeout bounds the check that a local server is answering
+const healthTimeout = 3 * time.Second
+
+// connect returns a client for the server that should run the command: the
+// --remote server, or a daemon serving the API from this data directory. It
+// returns nil when the command should open the data directory itself. A
+// daemon running without the API holds the directory too; commands that
+// write to it then fail with ExitInUse, while read-only commands open it
+// alongside the daemon, as SQLite allows.
+func connect(writes bool) (*api.RemoteClient, error) {
+	if globals.remote != "" {
+		return remoteClient()
+	}
+	client, running, err := localDaemon()
+	if err != nil || client != nil {
+		return client, err
+	}
+	if running && writes {
+		return nil, cli.Errorf(cli.ExitInUse, "a porcupin daemon is using this data directory; stop it first, or run it with 'porcupin serve' so commands go through its API")
+	}
+	return nil, nil
+}
+
+// localOnly fails a command that has no API equivalent when it would have
+// to go through a server
+func localOnly(command string) error {
+	if globals.remote != "" {
+		return cli.Errorf(cli.ExitUsage, "%s is not available with --remote", command)
+	}
+	_, running, err := localDaemon()
+	if err != nil {
+		return err
+	}
+	if running {
+		return cli.Errorf(cli.ExitInUse, "%s needs the data directory, which a running porcupin daemon is using; stop it first", command)
+	}
+	return nil
+}
+
+// remoteClient returns the client for --remote
+func remoteClient() (*api.RemoteClient, error) {
+	host, port, useTLS, err := api.ParseRemoteAddress(globals.remote)
+	if err != nil {
+		return nil, cli.Errorf(cli.ExitUsage, "invalid --remote: %v", err)
+	}
+	token := globals.token
+	if token == "" {
+		token = api.GetTokenFromEnv()
+	}
+	if token == "" {
+		return nil, cli.Errorf(cli.ExitUsage, "--remote needs an API token: set --token or PORCUPIN_API_TOKEN")
+	}
+	if !useTLS {
+		return api.NewRemoteClient(host, port, token, false), nil
+	}
+	if globals.fingerprint != "" {
+		return api.NewPinnedRemoteClient(host, port, token, globals.fingerprint), nil
+	}
+
+	// Without a fingerprint the certificate must be CA-signed. The CLI keeps
+	// no record of servers, so it shows the certificate to pin instead of
+	// trusting it silently.
+	ctx, cancel := context.WithTimeout(context.Background(), healthTimeout)
+	defer cancel()
+	client, pin, err := api.TrustOnFirstUse(ctx, host, port, token)
+	if err != nil {
+		return nil, err
+	}
+	if pin != "" {
+		return nil, cli.Errorf(cli.ExitFailure, "the server's certificate is self-signed, with fingerprint %s; "+
+			"check it against the server's log and pass it with --fingerprint or PORCUPIN_FINGERPRINT", pin)
+	}
+	return client, nil
+}
+
+// localDaemon looks for a daemon running from the data directory. It
+// returns a client if the daemon serves the API; running reports a daemon
+// either way.
+func localDaemon() (client *api.RemoteClient, running bool, err error) {
+	dataPath, err := dataPath()
+	if err != nil {
+		return nil, false, err
+	}
+	ep, err := api.ReadLocalEndpoint(dataPath)
+	if err != nil {
+		return nil, false, err
+	}
+	if ep != nil {
+		// The file outlives a daemon that crashed; any answer, even an
+		// error such as rate limiting, means the daemon is up
+		client := api.NewLocalClient(ep)
+		ctx, cancel := context.WithTimeout(context.Background(), healthTimeout)
+		defer cancel()
+		var apiErr *api.APIError
+		if _, err := client.Health(api.WithoutRetries(ctx)); err == nil || errors.As(err, &apiErr) {
+			return client, true, nil
+		}
+	}
+
+	in, err := load()
+	if err != nil {
+		return nil, false, err
+	}
+	inUse, err := ipfs.RepoInUse(in.repoPath())
+	if err != nil {
+		return nil, false, fmt.Errorf("cannot check repository lock: %w", err)
+	}
+	return nil, inUse, nil
+}
+
+// remoteError sets the exit code for an error from the server
+func remoteError(err error) error {
+	if errors.Is(err, api.ErrFingerprintMismatch) {
+		return cli.Errorf(cli.ExitFailure, "%v; if the server's certificate was replaced, pass its new fingerprint with --fingerprint", err)
+	}
+	switch {
+	case errors.Is(err, api.ErrNotFound):
+		return &cli.ExitError{Code: cli.ExitNotFound, Err: err}
+	case errors.Is(err, api.ErrBadRequest):
+		return &cli.ExitError{Code: cli.ExitUsage, Err: err}
+	case errors.Is(err, api.ErrUnauthorized):
+		return cli.Errorf(cli.ExitUsage, "the server rejected the API token: %v", err)
+	default:
+		return err
+	}
+}
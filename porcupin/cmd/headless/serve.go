@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"porcupin/backend/api"
+	"porcupin/backend/cli"
+	"porcupin/backend/config"
+	"porcupin/backend/core"
+	"porcupin/backend/indexer"
+	"porcupin/backend/storage"
+	"porcupin/backend/version"
+)
+
+// serveOptions are the API server flags
+type serveOptions struct {
+	port        int
+	bind        string
+	token       string
+	allowPublic bool
+	tlsCert     string
+	tlsKey      string
+	noTLS       bool
+
+	acmeDomains  string
+	acmeEmail    string
+	acmeCA       string
+	acmeCARoot   string
+	acmeHTTPPort int
+	acmeDNS      string
+}
+
+func setupRun(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if err := wantArgs(args, 0); err != nil {
+			return err
+		}
+		if err := localOnly("run"); err != nil {
+			return err
+		}
+		return runDaemon(nil)
+	}
+}
+
+func setupServe(fs *flag.FlagSet) func(args []string) error {
+	opts := &serveOptions{}
+	fs.IntVar(&opts.port, "api-port", 8085, "API server port")
+	fs.StringVar(&opts.bind, "api-bind", "0.0.0.0", "API server bind address")
+	fs.StringVar(&opts.token, "api-token", "", "Set API token (WARNING: visible in ps, prefer PORCUPIN_API_TOKEN)")
+	fs.BoolVar(&opts.allowPublic, "allow-public", false, "Allow connections from public IPs")
+	fs.StringVar(&opts.tlsCert, "tls-cert", "", "Path to TLS certificate file")
+	fs.StringVar(&opts.tlsKey, "tls-key", "", "Path to TLS private key file")
+	fs.BoolVar(&opts.noTLS, "no-tls", false, "Serve plain HTTP instead of generating a self-signed certificate")
+	fs.StringVar(&opts.acmeDomains, "acme-domain", "", "Get a certificate for these comma-separated domains from Let's Encrypt")
+	fs.StringVar(&opts.acmeEmail, "acme-email", "", "Email for certificate expiry notices")
+	fs.StringVar(&opts.acmeCA, "acme-ca", api.LetsEncryptCA, "ACME directory URL")
+	fs.StringVar(&opts.acmeCARoot, "acme-ca-root", "", "PEM file of roots trusted for a private ACME CA")
+	fs.IntVar(&opts.acmeHTTPPort, "acme-http-port", api.DefaultACMEHTTPPort, "Port for the HTTP-01 challenge")
+	fs.StringVar(&opts.acmeDNS, "acme-dns", "", "Solve the DNS-01 challenge with a provider, e.g. exec:/path/to/hook")
+	return func(args []string) error {
+		if err := wantArgs(args, 0); err != nil {
+			return err
+		}
+		if err := localOnly("serve"); err != nil {
+			return err
+		}
+		if opts.acmeDomains != "" && (opts.tlsCert != "" || opts.noTLS) {
+			return cli.Errorf(cli.ExitUsage, "--acme-domain cannot be combined with --tls-cert or --no-tls")
+		}
+		// Warn if --api-token flag is used (visible in ps)
+		if opts.token != "" {
+			log.Println("⚠️  WARNING: --api-token flag is visible in process list.")
+			log.Println("   Consider using PORCUPIN_API_TOKEN env var instead.")
+		}
+		return runDaemon(opts)
+	}
+}
+
+// runDaemon backs up the tracked wallets until interrupted, serving the API
+// when opts is set
+func runDaemon(opts *serveOptions) error {
+	in, err := open()
+	if err != nil {
+		return err
+	}
+
+	// Start IPFS node
+	fmt.Println("🦔 Porcupin Headless Server")
+	fmt.Println("Starting IPFS node...")
+
+	ipfsNode, err := in.newNode()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The backup service starts the node once an unmounted volume returns
+	if err := core.StartIPFS(ctx, ipfsNode, in.db); errors.Is(err, storage.ErrStorageUnavailable) {
+		fmt.Printf("IPFS node not started, waiting for storage: %v\n", err)
+	} else if err != nil {
+		return fmt.Errorf("failed to start IPFS node: %w", err)
+	} else {
+		fmt.Println("IPFS node started")
+	}
+	defer ipfsNode.Stop()
+
+	// Initialize indexer
+	idx := indexer.NewIndexer(in.cfg.TZKT.BaseURL)
+
+	// Create and start backup service
+	service := core.NewBackupService(ipfsNode, idx, in.db, in.cfg)
+	service.Replication().SetDialer(api.CatalogDialer)
+	service.SetConfigPath(in.cfgPath)
+
+	service.Start(ctx)
+	fmt.Println("Backup service started. Monitoring wallets...")
+
+	// Apply edits to the config file without a restart
+	go config.Watch(ctx, in.cfgPath, config.WatchInterval, service.ApplyConfig)
+
+	// Print initial wallet count
+	wallets, _ := in.db.GetAllWallets()
+	fmt.Printf("Tracking %d wallet(s)\n", len(wallets))
+
+	// Handle shutdown signals
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	// Start API server if requested
+	apiDone := make(chan struct{})
+	if opts == nil {
+		close(apiDone)
+	} else {
+		serverCfg, err := apiServerConfig(opts, in.dataPath)
+		if err != nil {
+			return err
+		}
+
+		// Create and start API server in a goroutine
+		apiServer := api.NewServer(serverCfg, in.db, service)
+		apiServer.SetIPFS(ipfsNode)
+		go func() {
+			defer close(apiDone)
+			if err := apiServer.Start(ctx); err != nil {
+				log.Printf("API server error: %v", err)
+			}
+		}()
+	}
+
+	// Status ticker
+	statusTicker := time.NewTicker(30 * time.Second)
+	defer statusTicker.Stop()
+
+	for {
+		select {
+		case <-sigCh:
+			fmt.Println("\nShutting down...")
+			service.Stop()
+			cancel()
+			<-apiDone // Removes the local endpoint file
+			return nil
+		case <-statusTicker.C:
+			status := service.GetStatus()
+			stats, _ := in.db.GetAssetStats()
+			log.Printf("[%s] Pinned: %d/%d, Failed: %d, Pending retries: %d",
+				status.State,
+				stats["pinned_assets"],
+				stats["total_assets"],
+				stats["failed_assets"],
+				status.PendingRetries,
+			)
+		}
+	}
+}
+
+// apiServerConfig picks the API token, from the flag, the environment or
+// the token file, generating one on first use
+func apiServerConfig(opts *serveOptions, dataPath string) (api.ServerConfig, error) {
+	var plainToken string // Used for env var or flag (direct comparison)
+	var tokenHash string  // Used for file-based auth (bcrypt comparison)
+
+	if opts.token != "" {
+		// Token from flag (already warned above)
+		plainToken = opts.token
+		fmt.Println("Using API token from --api-token flag")
+	} else if envToken := api.GetTokenFromEnv(); envToken != "" {
+		// Token from environment variable
+		if !api.ValidateTokenFormat(envToken) {
+			return api.ServerConfig{}, fmt.Errorf("PORCUPIN_API_TOKEN has invalid format")
+		}
+		plainToken = envToken
+		fmt.Println("Using API token from PORCUPIN_API_TOKEN environment variable")
+	} else {
+		// Check for existing token hash in file, or create new token
+		var err error
+		tokenHash, err = api.GetTokenHashFromFile(dataPath)
+		if err != nil {
+			return api.ServerConfig{}, fmt.Errorf("failed to read token file: %w", err)
+		}
+
+		if tokenHash == "" {
+			// No token exists - generate new one
+			newToken, isNew, err := api.GetOrCreateToken(dataPath)
+			if err != nil {
+				return api.ServerConfig{}, fmt.Errorf("failed to create API token: %w", err)
+			}
+
+			if isNew {
+				fmt.Println()
+				fmt.Println("═══════════════════════════════════════════════════════════════")
+				fmt.Println("  NEW API TOKEN GENERATED - SAVE THIS NOW!")
+				fmt.Println("═══════════════════════════════════════════════════════════════")
+				fmt.Println()
+				fmt.Printf("  %s\n", newToken)
+				fmt.Println()
+				fmt.Println("  ⚠️  This token will NOT be displayed again!")
+				fmt.Println("  Token hash stored at:", filepath.Join(dataPath, api.TokenFileName))
+				fmt.Println("═══════════════════════════════════════════════════════════════")
+				fmt.Println()
+
+				// Re-read the hash we just created
+				tokenHash, err = api.GetTokenHashFromFile(dataPath)
+				if err != nil {
+					return api.ServerConfig{}, fmt.Errorf("failed to read token hash: %w", err)
+				}
+			}
+		} else {
+			fmt.Println("Using API token from file (hash-based authentication)")
+		}
+	}
+
+	acme, err := acmeConfig(opts)
+	if err != nil {
+		return api.ServerConfig{}, err
+	}
+
+	return api.ServerConfig{
+		Port:            opts.port,
+		BindAddress:     opts.bind,
+		Token:           plainToken,
+		TokenHash:       tokenHash,
+		AllowPublic:     opts.allowPublic,
+		DataDir:         dataPath,
+		Version:         version.Version,
+		PerIPRateLimit:  10,
+		GlobalRateLimit: 100,
+		TLSCert:         opts.tlsCert,
+		TLSKey:          opts.tlsKey,
+		SelfSignedTLS:   !opts.noTLS,
+		ACME:            acme,
+	}, nil
+}
+
+// acmeConfig returns the ACME settings for --acme-domain, or nil without it
+func acmeConfig(opts *serveOptions) (*api.ACMEConfig, error) {
+	if opts.acmeDomains == "" {
+		return nil, nil
+	}
+	acme := &api.ACMEConfig{
+		Email:    opts.acmeEmail,
+		CA:       opts.acmeCA,
+		HTTPPort: opts.acmeHTTPPort,
+	}
+	for _, domain := range strings.Split(opts.acmeDomains, ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			acme.Domains = append(acme.Domains, domain)
+		}
+	}
+	if opts.acmeCARoot != "" {
+		roots, err := api.LoadCertPool(opts.acmeCARoot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load --acme-ca-root: %w", err)
+		}
+		acme.TrustedRoots = roots
+	}
+	if opts.acmeDNS != "" {
+		provider, err := api.NewDNSProvider(opts.acmeDNS)
+		if err != nil {
+			return nil, cli.Errorf(cli.ExitUsage, "invalid --acme-dns: %v", err)
+		}
+		acme.DNSProvider = provider
+	}
+	return acme, nil
+}
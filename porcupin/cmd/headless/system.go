@@ -0,0 +1,425 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"porcupin/backend/api"
+	"porcupin/backend/cli"
+	"porcupin/backend/config"
+	"porcupin/backend/core"
+	"porcupin/backend/ipfs"
+	"porcupin/backend/storage"
+	"porcupin/backend/version"
+)
+
+// statsOutput is the JSON form of stats
+type statsOutput struct {
+	NFTs         int64             `json:"nfts"`
+	Assets       int64             `json:"assets"`
+	Pinned       int64             `json:"pinned"`
+	Pending      int64             `json:"pending"`
+	Failed       int64             `json:"failed"`
+	StorageBytes int64             `json:"storage_bytes"`
+	Wallets      []walletStatsJSON `json:"wallets"`
+}
+
+// walletStatsJSON is one wallet's storage use in statsOutput
+type walletStatsJSON struct {
+	Address     string `json:"address"`
+	Alias       string `json:"alias"`
+	QuotaGB     int    `json:"quota_gb"` // 0 = unlimited
+	UsedBytes   int64  `json:"used_bytes"`
+	SharedBytes int64  `json:"shared_bytes"`
+}
+
+func setupStats(fs *flag.FlagSet) func(args []string) error {
+	asJSON := jsonFlag(fs)
+	return func(args []string) error {
+		if err := wantArgs(args, 0); err != nil {
+			return err
+		}
+		client, err := connect(false)
+		if err != nil {
+			return err
+		}
+		var out *statsOutput
+		if client != nil {
+			out, err = fetchStats(client)
+		} else {
+			out, err = readStats()
+		}
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(out)
+		}
+
+		const gb = 1024 * 1024 * 1024
+		cli.PrintStats(out.NFTs, out.Assets, out.Pinned, out.Pending, out.Failed, float64(out.StorageBytes)/gb)
+		walletStats := make([]cli.WalletStat, 0, len(out.Wallets))
+		for _, w := range out.Wallets {
+			walletStats = append(walletStats, cli.WalletStat{
+				Address:  w.Address,
+				Alias:    w.Alias,
+				QuotaGB:  w.QuotaGB,
+				UsedGB:   float64(w.UsedBytes) / gb,
+				SharedGB: float64(w.SharedBytes) / gb,
+			})
+		}
+		fmt.Println()
+		cli.PrintWalletStats(walletStats)
+		return nil
+	}
+}
+
+// readStats reads stats from the data directory
+func readStats() (*statsOutput, error) {
+	in, err := open()
+	if err != nil {
+		return nil, err
+	}
+	stats, err := in.db.GetAssetStats()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+
+	// Get actual disk usage from IPFS repo directory
+	storageBytes, err := core.GetDiskUsageBytes(in.repoPath())
+	if err != nil {
+		log.Printf("Warning: could not get disk usage: %v", err)
+		storageBytes = 0
+	}
+
+	// Per-wallet usage; CIDs shared between wallets are split between them
+	wallets, err := in.db.GetAllWallets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallets: %w", err)
+	}
+	usage, _, err := in.db.GetWalletUsage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet usage: %w", err)
+	}
+
+	out := &statsOutput{
+		NFTs:         stats["nft_count"],
+		Assets:       stats["pending"] + stats["pinned"] + stats["failed"] + stats["failed_unavailable"],
+		Pinned:       stats["pinned"],
+		Pending:      stats["pending"],
+		Failed:       stats["failed"] + stats["failed_unavailable"],
+		StorageBytes: storageBytes,
+		Wallets:      make([]walletStatsJSON, 0, len(wallets)),
+	}
+	for _, w := range wallets {
+		ws := walletStatsJSON{Address: w.Address, Alias: w.Alias, QuotaGB: w.QuotaGB}
+		if u := usage[w.Address]; u != nil {
+			ws.UsedBytes, ws.SharedBytes = u.UsedBytes, u.SharedBytes
+		}
+		out.Wallets = append(out.Wallets, ws)
+	}
+	return out, nil
+}
+
+// fetchStats gets stats from a server, which reports sizes in GB
+func fetchStats(client *api.RemoteClient) (*statsOutput, error) {
+	resp, err := client.Stats(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", remoteError(err))
+	}
+	const gb = 1024 * 1024 * 1024
+	out := &statsOutput{
+		NFTs:         resp.TotalNFTs,
+		Assets:       resp.TotalAssets,
+		Pinned:       resp.PinnedAssets,
+		Pending:      resp.PendingAssets,
+		Failed:       resp.FailedAssets,
+		StorageBytes: int64(resp.StorageUsedGB * gb),
+		Wallets:      make([]walletStatsJSON, 0, len(resp.WalletUsage)),
+	}
+	for _, w := range resp.WalletUsage {
+		out.Wallets = append(out.Wallets, walletStatsJSON{
+			Address:     w.Address,
+			Alias:       w.Alias,
+			QuotaGB:     w.QuotaGB,
+			UsedBytes:   int64(w.UsedGB * gb),
+			SharedBytes: int64(w.SharedGB * gb),
+		})
+	}
+	return out, nil
+}
+
+func setupGC(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if err := wantArgs(args, 0); err != nil {
+			return err
+		}
+		client, err := connect(true)
+		if err != nil {
+			return err
+		}
+		if client != nil {
+			if err := client.RunGC(context.Background()); err != nil {
+				return fmt.Errorf("garbage collection failed: %w", remoteError(err))
+			}
+			fmt.Println("Garbage collection started on the server.")
+			return nil
+		}
+		in, err := open()
+		if err != nil {
+			return err
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		node, err := in.startNode(ctx)
+		if err != nil {
+			return err
+		}
+		defer node.Stop()
+
+		fmt.Println("Running IPFS garbage collection...")
+		if err := node.GarbageCollect(ctx); err != nil {
+			return fmt.Errorf("garbage collection failed: %w", err)
+		}
+		fmt.Println("Garbage collection complete.")
+		return nil
+	}
+}
+
+func setupStorageMigrate(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if err := wantArgs(args, 1); err != nil {
+			return err
+		}
+		client, err := connect(true)
+		if err != nil {
+			return err
+		}
+		if client != nil {
+			if err := migrateStorageRemote(client, args[0]); err != nil {
+				return fmt.Errorf("storage migration failed: %w", err)
+			}
+			return nil
+		}
+		in, err := open()
+		if err != nil {
+			return err
+		}
+		// The IPFS node must not be running
+		if err := runStorageMigration(in.cfg, in.cfgPath, in.repoPath(), args[0]); err != nil {
+			return fmt.Errorf("storage migration failed: %w", err)
+		}
+		return nil
+	}
+}
+
+// runStorageMigration moves the IPFS repository for storage migrate,
+// printing progress. The config switches to the new path only once the copy
+// is verified. Interrupting it keeps the copy made so far, and running the
+// same command again resumes it.
+func runStorageMigration(cfg *config.Config, cfgPath, sourcePath, destPath string) error {
+	if inUse, err := ipfs.RepoInUse(sourcePath); err != nil {
+		return fmt.Errorf("cannot check repository lock: %w", err)
+	} else if inUse {
+		return fmt.Errorf("the IPFS repository is in use; stop the running server or migrate through its API (POST /api/v1/storage/migration)")
+	}
+	if err := storage.ValidatePath(destPath); err != nil {
+		return cli.Errorf(cli.ExitUsage, "invalid destination: %v", err)
+	}
+
+	manager := storage.NewManager(sourcePath)
+	manager.SetSwitchFunc(func(newPath string) error {
+		cfg.IPFS.RepoPath = newPath
+		return cfg.SaveConfig(cfgPath)
+	})
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		if _, ok := <-sigCh; ok {
+			fmt.Println("\nCancelling migration...")
+			manager.CancelMigration()
+		}
+	}()
+
+	fmt.Printf("Migrating IPFS repository from %s to %s\n", sourcePath, destPath)
+	err := manager.Migrate(context.Background(), destPath, newMigrationPrinter())
+	fmt.Println()
+	if err != nil {
+		if manager.GetMigrationStatus().Method == "copy" {
+			fmt.Println("The copy made so far was kept; run the same command again to resume.")
+		}
+		return err
+	}
+
+	fmt.Printf("Repository moved to %s and config updated.\n", manager.GetCurrentPath())
+	return nil
+}
+
+// migrationPollInterval is how often a migration on a server is checked
+const migrationPollInterval = 500 * time.Millisecond
+
+// migrateStorageRemote moves the server's IPFS repository to destPath, a
+// path on the server, printing progress until the server finishes.
+// Interrupting the command leaves the migration running.
+func migrateStorageRemote(client *api.RemoteClient, destPath string) error {
+	ctx := context.Background()
+	st, err := client.StartStorageMigration(ctx, destPath)
+	if err != nil {
+		return remoteError(err)
+	}
+	fmt.Printf("Migrating the server's IPFS repository to %s\n", destPath)
+	printer := newMigrationPrinter()
+	for st.InProgress {
+		printer(*st)
+		time.Sleep(migrationPollInterval)
+		if st, err = client.StorageMigration(ctx); err != nil {
+			return remoteError(err)
+		}
+	}
+	printer(*st)
+	fmt.Println()
+	if st.Error != "" {
+		return errors.New(st.Error)
+	}
+	fmt.Printf("Repository moved to %s.\n", st.DestPath)
+	return nil
+}
+
+// newMigrationPrinter returns a progress callback that redraws one line on a
+// terminal, or prints a line per phase and every 5% otherwise
+func newMigrationPrinter() func(storage.MigrationStatus) {
+	tty := cli.IsTTY()
+	lastPhase := ""
+	lastStep := -1
+	var lastDraw time.Time
+	return func(st storage.MigrationStatus) {
+		gb := func(b int64) float64 { return float64(b) / (1024 * 1024 * 1024) }
+		line := fmt.Sprintf("%-9s %5.1f%%  %.2f / %.2f GB", st.Phase, st.Progress, gb(st.BytesCopied), gb(st.TotalBytes))
+
+		if tty {
+			if st.Phase == lastPhase && time.Since(lastDraw) < 200*time.Millisecond {
+				return
+			}
+			lastPhase, lastDraw = st.Phase, time.Now()
+			fmt.Printf("\r\033[K%s", line)
+			return
+		}
+
+		step := int(st.Progress) / 5
+		if st.Phase == lastPhase && step == lastStep {
+			return
+		}
+		lastPhase, lastStep = st.Phase, step
+		fmt.Println(line)
+	}
+}
+
+// configCheck is the JSON form of config check
+type configCheck struct {
+	Path         string               `json:"path"`
+	Found        bool                 `json:"found"`
+	Valid        bool                 `json:"valid"`
+	Problems     []string             `json:"problems"`
+	EnvOverrides []config.EnvOverride `json:"env_overrides"`
+	UnknownEnv   []string             `json:"unknown_env"` // PORCUPIN_* variables matching no field
+}
+
+func setupConfigCheck(fs *flag.FlagSet) func(args []string) error {
+	asJSON := jsonFlag(fs)
+	return func(args []string) error {
+		if err := wantArgs(args, 0); err != nil {
+			return err
+		}
+		dataPath, err := dataPath()
+		if err != nil {
+			return err
+		}
+		check := configCheck{
+			Path:         configPath(dataPath),
+			Problems:     []string{},
+			EnvOverrides: []config.EnvOverride{},
+			UnknownEnv:   config.UnknownEnv(os.Environ()),
+		}
+		if check.UnknownEnv == nil {
+			check.UnknownEnv = []string{}
+		}
+		if _, err := os.Stat(check.Path); err == nil {
+			check.Found = true
+		}
+
+		cfg, err := config.LoadConfig(check.Path)
+		var invalid *config.ValidationError
+		switch {
+		case errors.As(err, &invalid):
+			check.Problems = invalid.Problems
+		case err != nil:
+			check.Problems = []string{err.Error()}
+		default:
+			check.Valid = true
+			check.EnvOverrides = append(check.EnvOverrides, cfg.EnvOverrides()...)
+		}
+
+		if *asJSON {
+			if err := printJSON(check); err != nil {
+				return err
+			}
+		} else {
+			printConfigCheck(check)
+		}
+		if !check.Valid {
+			return cli.Errorf(cli.ExitConfig, "%s is invalid", check.Path)
+		}
+		return nil
+	}
+}
+
+func printConfigCheck(check configCheck) {
+	fmt.Printf("Config file: %s\n", check.Path)
+	if !check.Found {
+		fmt.Println("  (not found, using defaults)")
+	}
+	for _, name := range check.UnknownEnv {
+		fmt.Printf("Warning: %s is not a config field\n", name)
+	}
+	if !check.Valid {
+		fmt.Println("Config is invalid:")
+		for _, problem := range check.Problems {
+			fmt.Printf("  ✗ %s\n", problem)
+		}
+		return
+	}
+	if len(check.EnvOverrides) > 0 {
+		fmt.Println("Set from the environment:")
+		for _, o := range check.EnvOverrides {
+			fmt.Printf("  %s (%s)\n", o.Field, o.Name)
+		}
+	}
+	fmt.Println("✓ Config is valid")
+}
+
+func setupVersion(fs *flag.FlagSet) func(args []string) error {
+	asJSON := jsonFlag(fs)
+	return func(args []string) error {
+		if *asJSON {
+			return printJSON(map[string]string{"version": version.Version})
+		}
+		cli.PrintBannerWithVersion(version.Version)
+		return nil
+	}
+}
+
+func setupAbout(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		cli.PrintAbout(version.Version)
+		return nil
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"porcupin/backend/api"
+	"porcupin/backend/cli"
+	"porcupin/backend/db"
+)
+
+func setupTokenRegenerate(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if err := wantArgs(args, 0); err != nil {
+			return err
+		}
+		dataPath, err := dataPath()
+		if err != nil {
+			return err
+		}
+		token, err := api.RegenerateToken(dataPath)
+		if err != nil {
+			return fmt.Errorf("failed to regenerate token: %w", err)
+		}
+		fmt.Println("New API token generated:")
+		fmt.Println()
+		fmt.Printf("  %s\n", token)
+		fmt.Println()
+		fmt.Println("⚠️  Save this token securely - it will not be shown again!")
+		fmt.Println("   Token hash stored at:", filepath.Join(dataPath, api.TokenFileName))
+		return nil
+	}
+}
+
+func setupTokenPair(fs *flag.FlagSet) func(args []string) error {
+	window := fs.Duration("window", api.DefaultPairingWindow, "How long the pairing code can be used")
+	asJSON := jsonFlag(fs)
+	return func(args []string) error {
+		if err := wantArgs(args, 0); err != nil {
+			return err
+		}
+		client, err := connect(true)
+		if err != nil {
+			return err
+		}
+		if client == nil {
+			return cli.Errorf(cli.ExitFailure, "pairing needs a running server; start it with 'porcupin serve'")
+		}
+		resp, err := client.OpenPairing(context.Background(), int(window.Seconds()))
+		if err != nil {
+			return fmt.Errorf("failed to start pairing: %w", remoteError(err))
+		}
+		if *asJSON {
+			return printJSON(resp)
+		}
+
+		fmt.Println("Enter this code in the Porcupin app (Settings → Remote Server → Pair):")
+		fmt.Println()
+		fmt.Printf("  %s\n", resp.Code)
+		fmt.Println()
+		fmt.Println("Or paste this pairing URL:")
+		fmt.Printf("  %s\n", resp.URL)
+		if resp.Fingerprint != "" {
+			fmt.Println()
+			fmt.Println("Certificate fingerprint (SHA-256), to check in the app:")
+			fmt.Printf("  %s\n", resp.Fingerprint)
+		}
+		fmt.Println()
+		fmt.Printf("The code can be used once, until %s.\n", resp.ExpiresAt.Local().Format("15:04:05"))
+		return nil
+	}
+}
+
+func setupTokenList(fs *flag.FlagSet) func(args []string) error {
+	asJSON := jsonFlag(fs)
+	return func(args []string) error {
+		if err := wantArgs(args, 0); err != nil {
+			return err
+		}
+		client, err := connect(false)
+		if err != nil {
+			return err
+		}
+		var tokens []db.APIToken
+		if client != nil {
+			if tokens, err = client.APITokens(context.Background()); err != nil {
+				return fmt.Errorf("failed to list tokens: %w", remoteError(err))
+			}
+		} else {
+			in, err := open()
+			if err != nil {
+				return err
+			}
+			if tokens, err = in.db.GetAPITokens(); err != nil {
+				return fmt.Errorf("failed to list tokens: %w", err)
+			}
+		}
+		if *asJSON {
+			return printJSON(tokens)
+		}
+		if len(tokens) == 0 {
+			fmt.Println("No paired clients")
+			return nil
+		}
+		fmt.Println("Paired clients:")
+		for _, t := range tokens {
+			lastUsed := "never used"
+			if t.LastUsedAt != nil {
+				lastUsed = "last used " + t.LastUsedAt.Local().Format(time.DateTime)
+			}
+			fmt.Printf("  %d  %s - paired %s, %s\n", t.ID, t.Name, t.CreatedAt.Local().Format(time.DateOnly), lastUsed)
+		}
+		return nil
+	}
+}
+
+func setupTokenRevoke(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if err := wantArgs(args, 1); err != nil {
+			return err
+		}
+		id, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return cli.Errorf(cli.ExitUsage, "invalid token ID: %s", args[0])
+		}
+		client, err := connect(true)
+		if err != nil {
+			return err
+		}
+		if client != nil {
+			if err := client.RevokeAPIToken(context.Background(), id); err != nil {
+				return fmt.Errorf("failed to revoke token: %w", remoteError(err))
+			}
+		} else {
+			in, err := open()
+			if err != nil {
+				return err
+			}
+			deleted, err := in.db.DeleteAPIToken(id)
+			if err != nil {
+				return fmt.Errorf("failed to revoke token: %w", err)
+			}
+			if !deleted {
+				return cli.Errorf(cli.ExitNotFound, "no token with ID %d", id)
+			}
+		}
+		fmt.Printf("Revoked token %d\n", id)
+		return nil
+	}
+}
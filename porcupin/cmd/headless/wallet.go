@@ -0,0 +1,593 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"porcupin/backend/api"
+	"porcupin/backend/cli"
+	"porcupin/backend/core"
+	"porcupin/backend/db"
+	"porcupin/backend/indexer"
+)
+
+func setupWalletAdd(fs *flag.FlagSet) func(args []string) error {
+	alias := fs.String("alias", "", "Alias for the wallet (default: its .tez domain)")
+	return func(args []string) error {
+		if err := wantArgs(args, 1); err != nil {
+			return err
+		}
+		input := args[0]
+		if !api.IsValidTezosAddress(input) && !indexer.IsDomain(input) {
+			return cli.Errorf(cli.ExitUsage, "invalid Tezos address or domain: %s", input)
+		}
+		client, err := connect(true)
+		if err != nil {
+			return err
+		}
+
+		// The server, or the indexer here, resolves a domain to its address
+		var wallet db.Wallet
+		if client != nil {
+			req := api.AddWalletRequest{Address: input, Alias: *alias}
+			added, err := client.AddWallet(context.Background(), req)
+			if errors.Is(err, api.ErrBadRequest) {
+				return cli.Errorf(cli.ExitUsage, "%v", remoteError(err))
+			}
+			if err != nil {
+				return fmt.Errorf("failed to add wallet: %w", remoteError(err))
+			}
+			wallet = db.Wallet{Address: added.Address, Alias: added.Alias}
+		} else {
+			in, err := open()
+			if err != nil {
+				return err
+			}
+			idx := indexer.NewIndexer(in.cfg.TZKT.BaseURL)
+			address, domain, err := api.ResolveWallet(context.Background(), idx, input)
+			if errors.Is(err, indexer.ErrDomainNotFound) {
+				return cli.Errorf(cli.ExitUsage, "%v", err)
+			}
+			if err != nil {
+				return err
+			}
+			wallet = db.Wallet{Address: address, Alias: *alias, Domain: domain}
+			if wallet.Alias == "" {
+				wallet.Alias = domain
+			}
+			if err := in.db.SaveWallet(&wallet); err != nil {
+				return fmt.Errorf("failed to add wallet: %w", err)
+			}
+		}
+		if wallet.Alias != "" {
+			fmt.Printf("Added wallet: %s (%s)\n", wallet.Alias, wallet.Address)
+		} else {
+			fmt.Printf("Added wallet: %s\n", wallet.Address)
+		}
+		return nil
+	}
+}
+
+func setupWalletList(fs *flag.FlagSet) func(args []string) error {
+	asJSON := jsonFlag(fs)
+	return func(args []string) error {
+		if err := wantArgs(args, 0); err != nil {
+			return err
+		}
+		wallets, err := listWallets()
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(wallets)
+		}
+		if len(wallets) == 0 {
+			fmt.Println("No wallets configured")
+			return nil
+		}
+		fmt.Println("Tracked wallets:")
+		for _, w := range wallets {
+			alias := w.Alias
+			if alias == "" {
+				alias = "(no alias)"
+			}
+			fmt.Printf("  %s - %s\n", w.Address, alias)
+		}
+		return nil
+	}
+}
+
+func setupWalletRename(fs *flag.FlagSet) func(args []string) error {
+	alias := fs.String("alias", "", "New alias (empty clears it)")
+	return func(args []string) error {
+		if err := wantArgs(args, 1); err != nil {
+			return err
+		}
+		client, err := connect(true)
+		if err != nil {
+			return err
+		}
+		if client != nil {
+			req := api.UpdateWalletRequest{Alias: alias}
+			if _, err := client.UpdateWallet(context.Background(), args[0], req); err != nil {
+				return fmt.Errorf("failed to rename wallet: %w", remoteError(err))
+			}
+		} else {
+			in, err := open()
+			if err != nil {
+				return err
+			}
+			if _, err := trackedWallet(in, args[0]); err != nil {
+				return err
+			}
+			if err := in.db.Model(&db.Wallet{}).Where("address = ?", args[0]).Update("alias", *alias).Error; err != nil {
+				return fmt.Errorf("failed to rename wallet: %w", err)
+			}
+		}
+		if *alias != "" {
+			fmt.Printf("Renamed wallet %s to: %s\n", args[0], *alias)
+		} else {
+			fmt.Printf("Cleared alias for wallet: %s\n", args[0])
+		}
+		return nil
+	}
+}
+
+func setupWalletRemove(fs *flag.FlagSet) func(args []string) error {
+	unpin := fs.Bool("unpin", false, "Also unpin the wallet's assets and delete its NFTs")
+	return func(args []string) error {
+		if err := wantArgs(args, 1); err != nil {
+			return err
+		}
+		address := args[0]
+		client, err := connect(true)
+		if err != nil {
+			return err
+		}
+		if client != nil {
+			return removeWalletRemote(client, address, *unpin)
+		}
+		in, err := open()
+		if err != nil {
+			return err
+		}
+		if _, err := trackedWallet(in, address); err != nil {
+			return err
+		}
+
+		if !*unpin {
+			if err := in.db.DeleteWallet(address); err != nil {
+				return fmt.Errorf("failed to remove wallet: %w", err)
+			}
+			fmt.Printf("Removed wallet: %s (assets still pinned, use 'porcupin wallet unpin' first to unpin)\n", address)
+			return nil
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		node, err := in.startNode(ctx)
+		if err != nil {
+			return err
+		}
+		defer node.Stop()
+
+		assets, err := in.db.GetAssetsByWallet(address)
+		if err != nil {
+			return fmt.Errorf("failed to get assets: %w", err)
+		}
+		fmt.Printf("Deleting wallet %s: unpinning %d assets...\n", address, len(assets))
+		for _, asset := range assets {
+			cid := core.ExtractCIDFromURI(asset.URI)
+			if cid == "" {
+				continue
+			}
+			if err := node.Unpin(ctx, cid); err != nil {
+				log.Printf("Warning: failed to unpin %s: %v", cid, err)
+			}
+		}
+		// Delete from database
+		if err := in.db.DeleteAssetsByWallet(address); err != nil {
+			log.Printf("Warning: failed to delete assets from DB: %v", err)
+		}
+		if err := in.db.DeleteNFTsByWallet(address); err != nil {
+			log.Printf("Warning: failed to delete NFTs from DB: %v", err)
+		}
+		if err := in.db.DeleteWallet(address); err != nil {
+			return fmt.Errorf("failed to delete wallet: %w", err)
+		}
+		fmt.Printf("Deleted wallet %s and unpinned assets. Run 'porcupin gc' to reclaim disk space.\n", address)
+		return nil
+	}
+}
+
+// removeWalletRemote removes a wallet through the server, which unpins
+// its assets in a background job
+func removeWalletRemote(client *api.RemoteClient, address string, unpin bool) error {
+	if !unpin {
+		if err := client.DeleteWallet(context.Background(), address); err != nil {
+			return fmt.Errorf("failed to remove wallet: %w", remoteError(err))
+		}
+		fmt.Printf("Removed wallet: %s (assets still pinned, use 'porcupin wallet rm --unpin' to unpin)\n", address)
+		return nil
+	}
+	job, err := client.DeleteWalletAndUnpin(context.Background(), address)
+	if err != nil {
+		return fmt.Errorf("failed to delete wallet: %w", remoteError(err))
+	}
+	fmt.Printf("Deleting wallet %s on the server (job %d). Run 'porcupin gc' once it finishes to reclaim disk space.\n", address, job.ID)
+	return nil
+}
+
+func setupWalletUnpin(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if err := wantArgs(args, 1); err != nil {
+			return err
+		}
+		address := args[0]
+		if err := localOnly("wallet unpin"); err != nil {
+			return err
+		}
+		in, err := open()
+		if err != nil {
+			return err
+		}
+		assets, err := in.db.GetAssetsByWallet(address)
+		if err != nil {
+			return fmt.Errorf("failed to get assets: %w", err)
+		}
+		if len(assets) == 0 {
+			fmt.Printf("No assets found for wallet: %s\n", address)
+			return nil
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		node, err := in.startNode(ctx)
+		if err != nil {
+			return err
+		}
+		defer node.Stop()
+
+		fmt.Printf("Unpinning %d assets for wallet %s...\n", len(assets), address)
+		unpinned, attempted := 0, 0
+		for _, asset := range assets {
+			cid := core.ExtractCIDFromURI(asset.URI)
+			if cid == "" {
+				continue
+			}
+			attempted++
+			if err := node.Unpin(ctx, cid); err != nil {
+				log.Printf("Warning: failed to unpin %s: %v", cid, err)
+			} else {
+				unpinned++
+			}
+		}
+		fmt.Printf("Unpinned %d/%d assets. Run 'porcupin gc' to reclaim disk space.\n", unpinned, len(assets))
+		if unpinned < attempted {
+			return cli.Errorf(cli.ExitPartial, "%d assets could not be unpinned", attempted-unpinned)
+		}
+		return nil
+	}
+}
+
+// walletSyncResult is the outcome of syncing one wallet
+type walletSyncResult struct {
+	Address string `json:"address"`
+	Level   int64  `json:"level,omitempty"` // Blockchain level synced up to
+	Error   string `json:"error,omitempty"`
+}
+
+func setupWalletSync(fs *flag.FlagSet) func(args []string) error {
+	asJSON := jsonFlag(fs)
+	return func(addresses []string) error {
+		client, err := connect(true)
+		if err != nil {
+			return err
+		}
+		if client != nil {
+			return syncWalletsRemote(client, addresses, *asJSON)
+		}
+		in, err := open()
+		if err != nil {
+			return err
+		}
+		if len(addresses) == 0 {
+			wallets, err := in.db.GetAllWallets()
+			if err != nil {
+				return fmt.Errorf("failed to get wallets: %w", err)
+			}
+			for _, w := range wallets {
+				addresses = append(addresses, w.Address)
+			}
+		}
+		for _, address := range addresses {
+			if _, err := trackedWallet(in, address); err != nil {
+				return err
+			}
+		}
+		if len(addresses) == 0 {
+			if *asJSON {
+				return printJSON([]walletSyncResult{})
+			}
+			fmt.Println("No wallets configured")
+			return nil
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		node, err := in.startNode(ctx)
+		if err != nil {
+			return err
+		}
+		defer node.Stop()
+
+		manager := core.NewBackupManager(node, indexer.NewIndexer(in.cfg.TZKT.BaseURL), in.db, in.cfg)
+		defer manager.Shutdown()
+
+		results := make([]walletSyncResult, 0, len(addresses))
+		failed := 0
+		for _, address := range addresses {
+			if !*asJSON {
+				fmt.Printf("Syncing %s...\n", address)
+			}
+			result := walletSyncResult{Address: address}
+			level, err := manager.SyncWallet(ctx, address)
+			if err != nil {
+				result.Error = err.Error()
+				failed++
+			} else if level > 0 {
+				result.Level = level
+				in.db.UpdateWalletSyncTime(address, level)
+			}
+			results = append(results, result)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+
+		// New assets were queued by the sync; pin them before exiting
+		waitForPinQueue(ctx, manager, !*asJSON)
+
+		if *asJSON {
+			if err := printJSON(results); err != nil {
+				return err
+			}
+		} else {
+			for _, r := range results {
+				if r.Error != "" {
+					fmt.Printf("  ✗ %s: %s\n", r.Address, r.Error)
+				} else {
+					fmt.Printf("  ✓ %s synced to level %d\n", r.Address, r.Level)
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return cli.Errorf(cli.ExitPartial, "%d of %d wallets failed to sync", failed, len(addresses))
+		}
+		return nil
+	}
+}
+
+// syncWalletsRemote starts syncs on the server. They run in the background,
+// so the results carry no level.
+func syncWalletsRemote(client *api.RemoteClient, addresses []string, asJSON bool) error {
+	if len(addresses) == 0 {
+		wallets, err := listWallets()
+		if err != nil {
+			return err
+		}
+		for _, w := range wallets {
+			addresses = append(addresses, w.Address)
+		}
+	}
+	results := make([]walletSyncResult, 0, len(addresses))
+	for _, address := range addresses {
+		if err := client.SyncWallet(context.Background(), address); err != nil {
+			return fmt.Errorf("failed to sync %s: %w", address, remoteError(err))
+		}
+		results = append(results, walletSyncResult{Address: address})
+	}
+	if asJSON {
+		return printJSON(results)
+	}
+	if len(results) == 0 {
+		fmt.Println("No wallets configured")
+		return nil
+	}
+	for _, r := range results {
+		fmt.Printf("  ✓ %s sync started on the server\n", r.Address)
+	}
+	return nil
+}
+
+// waitForPinQueue waits until the manager's pin queue is empty or ctx is done
+func waitForPinQueue(ctx context.Context, manager *core.BackupManager, progress bool) {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	last := -1
+	for {
+		status := manager.QueueStatus()
+		remaining := status.Queued + status.InFlight
+		if remaining == 0 {
+			return
+		}
+		if progress && remaining != last {
+			fmt.Printf("Pinning: %d assets left\n", remaining)
+			last = remaining
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func setupWalletImport(fs *flag.FlagSet) func(args []string) error {
+	format := fs.String("format", "", "File format: csv or json (default: detected)")
+	dryRun := fs.Bool("dry-run", false, "Report what would be added without adding anything")
+	asJSON := jsonFlag(fs)
+	return func(args []string) error {
+		if err := wantArgs(args, 1); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return cli.Errorf(cli.ExitUsage, "failed to read wallet file: %v", err)
+		}
+		client, err := connect(!*dryRun)
+		if err != nil {
+			return err
+		}
+
+		var report *api.WalletImportReport
+		if client != nil {
+			report, err = client.ImportWallets(context.Background(), data, *format, *dryRun)
+			if errors.Is(err, api.ErrBadRequest) {
+				return cli.Errorf(cli.ExitUsage, "%s: %v", args[0], remoteError(err))
+			}
+			if err != nil {
+				return fmt.Errorf("failed to import wallets: %w", remoteError(err))
+			}
+		} else {
+			rows, err := api.ParseWalletFile(data, *format)
+			if err != nil {
+				return cli.Errorf(cli.ExitUsage, "%s: %v", args[0], err)
+			}
+			in, err := open()
+			if err != nil {
+				return err
+			}
+			opts := api.WalletImportOptions{
+				DryRun:      *dryRun,
+				SyncOwned:   in.cfg.Backup.SyncOwned,
+				SyncCreated: in.cfg.Backup.SyncCreated,
+			}
+			report, err = api.ImportWallets(in.db, rows, opts)
+			if err != nil {
+				return fmt.Errorf("failed to import wallets: %w", err)
+			}
+		}
+
+		if *asJSON {
+			return printJSON(report)
+		}
+		for _, r := range report.Results {
+			name := r.Address
+			if r.Alias != "" {
+				name = fmt.Sprintf("%s (%s)", r.Address, r.Alias)
+			}
+			if r.Error != "" {
+				fmt.Printf("  line %d: %s - %s: %s\n", r.Line, name, r.Status, r.Error)
+			} else {
+				fmt.Printf("  line %d: %s - %s\n", r.Line, name, r.Status)
+			}
+		}
+		verb := "Added"
+		if report.DryRun {
+			verb = "Would add"
+		}
+		fmt.Printf("%s %d wallet(s); %d already tracked, %d duplicate, %d invalid\n",
+			verb, report.Added, report.Existing, report.Duplicates, report.Invalid)
+		return nil
+	}
+}
+
+func setupWalletExport(fs *flag.FlagSet) func(args []string) error {
+	format := fs.String("format", api.WalletFileCSV, "File format: csv or json")
+	output := fs.String("output", "", "File to write (default: standard output)")
+	return func(args []string) error {
+		if err := wantArgs(args, 0); err != nil {
+			return err
+		}
+		if *format != api.WalletFileCSV && *format != api.WalletFileJSON {
+			return cli.Errorf(cli.ExitUsage, "--format must be csv or json")
+		}
+		wallets, err := listWallets()
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		if err := api.WriteWalletFile(&buf, wallets, *format); err != nil {
+			return fmt.Errorf("failed to export wallets: %w", err)
+		}
+		if *output == "" {
+			_, err := os.Stdout.Write(buf.Bytes())
+			return err
+		}
+		if err := os.WriteFile(*output, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write wallet file: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Exported %d wallet(s) to %s\n", len(wallets), *output)
+		return nil
+	}
+}
+
+// listWallets returns the tracked wallets, through the server if one is
+// running
+func listWallets() ([]db.Wallet, error) {
+	client, err := connect(false)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		in, err := open()
+		if err != nil {
+			return nil, err
+		}
+		wallets, err := in.db.GetAllWallets()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get wallets: %w", err)
+		}
+		return wallets, nil
+	}
+
+	resp, err := client.Wallets(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallets: %w", remoteError(err))
+	}
+	wallets := make([]db.Wallet, 0, len(resp))
+	for _, r := range resp {
+		w := db.Wallet{
+			Address:     r.Address,
+			Alias:       r.Alias,
+			Domain:      r.Domain,
+			SyncOwned:   r.SyncOwned,
+			SyncCreated: r.SyncCreated,
+			Priority:    r.Priority,
+			QuotaGB:     r.QuotaGB,
+		}
+		if r.LastSyncedAt != nil {
+			if t, err := time.Parse(time.RFC3339, *r.LastSyncedAt); err == nil {
+				w.LastSyncedAt = &t
+			}
+		}
+		wallets = append(wallets, w)
+	}
+	return wallets, nil
+}
+
+// trackedWallet returns a tracked wallet, or an error exiting with
+// ExitNotFound
+func trackedWallet(in *instance, address string) (*db.Wallet, error) {
+	wallet, err := in.db.GetWallet(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	if wallet == nil {
+		return nil, cli.Errorf(cli.ExitNotFound, "wallet not tracked: %s", address)
+	}
+	return wallet, nil
+}
@@ -6,6 +6,7 @@ import { Sidebar } from "./components/Sidebar";
 import { Dashboard } from "./components/Dashboard";
 import { Wallets } from "./components/Wallets";
 import { Assets } from "./components/Assets";
+import { Fleet } from "./components/Fleet";
 import { Settings } from "./components/Settings";
 import { About } from "./components/About";
 import { ConnectionProvider, useConnection } from "./lib/connection";
@@ -127,6 +128,8 @@ function AppContent() {
 
                 {activeTab === "assets" && <Assets onStatsChange={updateStats} />}
 
+                {activeTab === "fleet" && <Fleet />}
+
                 {activeTab === "settings" && <Settings onStatsChange={updateStats} />}
 
                 {activeTab === "about" && <About />}
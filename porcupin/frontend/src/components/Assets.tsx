@@ -1,6 +1,15 @@
 import { useState, useEffect, useCallback, useMemo } from "react";
-import { GetNFTsWithAssets, RetryAsset, UnpinAsset, DeleteAsset, ShowInFinder } from "../lib/backend";
+import {
+    GetNFTsWithAssets,
+    GetCreators,
+    GetCollections,
+    RetryAsset,
+    UnpinAsset,
+    DeleteAsset,
+    ShowInFinder,
+} from "../lib/backend";
 import { BrowserOpenURL } from "../../wailsjs/runtime/runtime";
+import { MetadataHistoryModal } from "./MetadataHistoryModal";
 import {
     Search,
     Grid3X3,
@@ -24,6 +33,10 @@ import {
     Clock,
     CheckCircle,
     XCircle,
+    Scale,
+    Tag,
+    X,
+    History,
 } from "lucide-react";
 import type { db } from "../../wailsjs/go/models";
 import { formatBytes } from "../utils";
@@ -35,8 +48,19 @@ interface AssetsProps {
 }
 
 type LayoutMode = "grid" | "list" | "compact";
+
+// Tags shown on a grid card; the rest are left to the filter
+const CARD_TAG_LIMIT = 4;
 type StatusFilter = "all" | "pinned" | "pending" | "failed";
 
+// Artists and collections offered in the filters, most NFTs first
+const GROUP_LIMIT = 200;
+
+// Name to show for an artist or collection
+function groupName(group: { name?: string; alias?: string; address: string }): string {
+    return group.name || group.alias || `${group.address.slice(0, 8)}…${group.address.slice(-4)}`;
+}
+
 // Extract CID from IPFS URI
 function getCidFromUri(uri: string): string | null {
     if (!uri) return null;
@@ -49,6 +73,33 @@ function getCidFromUri(uri: string): string | null {
     return match ? match[1] : null;
 }
 
+// Undo the HTML escaping of search highlights
+function unescapeHtml(text: string): string {
+    return text
+        .replace(/&lt;/g, "<")
+        .replace(/&gt;/g, ">")
+        .replace(/&#34;/g, '"')
+        .replace(/&#39;/g, "'")
+        .replace(/&amp;/g, "&");
+}
+
+// Render a search highlight: escaped text with the matches in <mark> tags
+function Highlighted({ html }: { html: string }) {
+    const parts = html.split(/(<mark>.*?<\/mark>)/g);
+    return (
+        <>
+            {parts.map((part, i) =>
+                part.startsWith("<mark>") ? (
+                    // biome-ignore lint/suspicious/noArrayIndexKey: parts never reorder
+                    <mark key={i}>{unescapeHtml(part.slice(6, -7))}</mark>
+                ) : (
+                    unescapeHtml(part)
+                )
+            )}
+        </>
+    );
+}
+
 // Get preview URL for an asset
 function getPreviewUrl(asset: db.Asset): string | null {
     const cid = getCidFromUri(asset.uri);
@@ -94,6 +145,12 @@ export function Assets({ onStatsChange }: AssetsProps) {
     });
     const [searchQuery, setSearchQuery] = useState("");
     const [statusFilter, setStatusFilter] = useState<StatusFilter>("all");
+    const [creatorFilter, setCreatorFilter] = useState("");
+    const [collectionFilter, setCollectionFilter] = useState("");
+    const [tagFilter, setTagFilter] = useState("");
+    const [historyNft, setHistoryNft] = useState<db.NFT | null>(null);
+    const [creators, setCreators] = useState<db.CreatorSummary[]>([]);
+    const [collections, setCollections] = useState<db.ContractSummary[]>([]);
 
     // Debounced search query for API calls
     const [debouncedSearch, setDebouncedSearch] = useState("");
@@ -109,16 +166,39 @@ export function Assets({ onStatsChange }: AssetsProps) {
         return () => clearTimeout(timer);
     }, [searchQuery]);
 
-    // Reset page on status change
+    // Reset page on filter change
     useEffect(() => {
         setPage(1);
-    }, [statusFilter]);
+    }, [statusFilter, creatorFilter, collectionFilter, tagFilter]);
+
+    // Load the artists to filter by
+    useEffect(() => {
+        GetCreators(1, GROUP_LIMIT, "")
+            .then((res) => setCreators(res || []))
+            .catch((err: unknown) => console.error(err));
+    }, []);
+
+    // Load the collections to filter by, narrowed to the chosen artist's
+    useEffect(() => {
+        GetCollections(1, GROUP_LIMIT, "", creatorFilter)
+            .then((res) => setCollections(res || []))
+            .catch((err: unknown) => console.error(err));
+    }, [creatorFilter]);
 
     const loadNfts = useCallback(async () => {
         setLoading(true);
         try {
             // Pass status and search to backend
-            const res = await GetNFTsWithAssets(page, PAGE_SIZE, statusFilter, debouncedSearch);
+            const res = await GetNFTsWithAssets(
+                page,
+                PAGE_SIZE,
+                statusFilter,
+                debouncedSearch,
+                creatorFilter,
+                collectionFilter,
+                tagFilter,
+                ""
+            );
             setNfts(res || []);
             setHasMore((res?.length || 0) >= PAGE_SIZE);
 
@@ -140,7 +220,7 @@ export function Assets({ onStatsChange }: AssetsProps) {
         } finally {
             setLoading(false);
         }
-    }, [page, statusFilter, debouncedSearch]);
+    }, [page, statusFilter, debouncedSearch, creatorFilter, collectionFilter, tagFilter]);
 
     useEffect(() => {
         loadNfts();
@@ -224,6 +304,11 @@ export function Assets({ onStatsChange }: AssetsProps) {
 
     const statusCounts = getStatusCounts();
 
+    const handleCreatorChange = (address: string) => {
+        setCreatorFilter(address);
+        setCollectionFilter("");
+    };
+
     // Render asset card for grid view
     const renderGridCard = (nft: db.NFT) => {
         const thumbnailAsset =
@@ -252,9 +337,82 @@ export function Assets({ onStatsChange }: AssetsProps) {
                     </div>
                 </button>
                 <div className="asset-card-content">
-                    <div className="asset-card-title">{nft.name || `Token #${nft.token_id}`}</div>
+                    <div className="asset-card-title">
+                        {nft.highlight?.name ? (
+                            <Highlighted html={nft.highlight.name} />
+                        ) : (
+                            nft.name || `Token #${nft.token_id}`
+                        )}
+                    </div>
+                    {nft.highlight?.description.includes("<mark>") && (
+                        <div className="asset-card-snippet">
+                            <Highlighted html={nft.highlight.description} />
+                        </div>
+                    )}
+                    {(nft.creator || nft.collection) && (
+                        <div className="asset-card-byline">
+                            {nft.creator && (
+                                <button
+                                    type="button"
+                                    onClick={() => handleCreatorChange(nft.creator)}
+                                    title="Show this artist's NFTs"
+                                >
+                                    {groupName({ ...nft.creator_profile, address: nft.creator })}
+                                </button>
+                            )}
+                            {nft.collection && (
+                                <button
+                                    type="button"
+                                    onClick={() => setCollectionFilter(nft.contract_address)}
+                                    title="Show this collection's NFTs"
+                                >
+                                    {groupName(nft.collection)}
+                                </button>
+                            )}
+                        </div>
+                    )}
+                    {nft.tags && nft.tags.length > 0 && (
+                        <div className="asset-card-tags">
+                            {nft.tags.slice(0, CARD_TAG_LIMIT).map((t) => (
+                                <button
+                                    key={t.tag}
+                                    type="button"
+                                    className={t.tag.toLowerCase() === tagFilter.toLowerCase() ? "active" : ""}
+                                    onClick={() => setTagFilter(t.tag)}
+                                    title="Show NFTs with this tag"
+                                >
+                                    {t.tag}
+                                </button>
+                            ))}
+                            {nft.tags.length > CARD_TAG_LIMIT && (
+                                <span className="more">+{nft.tags.length - CARD_TAG_LIMIT}</span>
+                            )}
+                        </div>
+                    )}
+                    {nft.rights && (
+                        <div className="asset-card-rights" title="License from the NFT's metadata">
+                            <Scale size={12} aria-hidden="true" />
+                            {/^https?:\/\//.test(nft.rights_uri || "") ? (
+                                <button type="button" onClick={() => BrowserOpenURL(nft.rights_uri || "")}>
+                                    {nft.rights}
+                                </button>
+                            ) : (
+                                <span>{nft.rights}</span>
+                            )}
+                        </div>
+                    )}
                     <div className="asset-card-meta">
                         <span className="asset-count">{nft.assets?.length || 0} assets</span>
+                        {nft.metadata_version > 1 && (
+                            <button
+                                type="button"
+                                className="metadata-version"
+                                onClick={() => setHistoryNft(nft)}
+                                title="The metadata has been updated; show its history"
+                            >
+                                <History size={12} aria-hidden="true" />v{nft.metadata_version}
+                            </button>
+                        )}
                         <span
                             className={`status-indicator ${
                                 allPinned ? "pinned" : hasFailed ? "failed" : hasPending ? "pending" : ""
@@ -312,7 +470,13 @@ export function Assets({ onStatsChange }: AssetsProps) {
                     )}
                 </button>
                 <div className="asset-list-info">
-                    <div className="asset-list-title">{asset.nft?.name || `Token #${asset.nft?.token_id}`}</div>
+                    <div className="asset-list-title">
+                        {asset.nft?.highlight?.name ? (
+                            <Highlighted html={asset.nft.highlight.name} />
+                        ) : (
+                            asset.nft?.name || `Token #${asset.nft?.token_id}`
+                        )}
+                    </div>
                     <div className="asset-list-meta">
                         <span className="asset-type-badge">{asset.type}</span>
                         {asset.mime_type && <span className="mime-type">{asset.mime_type.split("/")[1]}</span>}
@@ -435,7 +599,7 @@ export function Assets({ onStatsChange }: AssetsProps) {
                     <Search size={18} aria-hidden="true" />
                     <input
                         type="text"
-                        placeholder="Search assets..."
+                        placeholder="Search names, tags, artists..."
                         value={searchQuery}
                         onChange={(e) => setSearchQuery(e.target.value)}
                         aria-label="Search assets"
@@ -483,6 +647,51 @@ export function Assets({ onStatsChange }: AssetsProps) {
                     </button>
                 </fieldset>
 
+                <div className="group-filters">
+                    <select
+                        value={creatorFilter}
+                        onChange={(e) => handleCreatorChange(e.target.value)}
+                        aria-label="Filter by artist"
+                    >
+                        <option value="">All artists</option>
+                        {creatorFilter && !creators.some((c) => c.address === creatorFilter) && (
+                            <option value={creatorFilter}>{groupName({ address: creatorFilter })}</option>
+                        )}
+                        {creators.map((c) => (
+                            <option key={c.address} value={c.address}>
+                                {groupName(c)} ({c.nft_count})
+                            </option>
+                        ))}
+                    </select>
+                    <select
+                        value={collectionFilter}
+                        onChange={(e) => setCollectionFilter(e.target.value)}
+                        aria-label="Filter by collection"
+                    >
+                        <option value="">All collections</option>
+                        {collectionFilter && !collections.some((c) => c.address === collectionFilter) && (
+                            <option value={collectionFilter}>{groupName({ address: collectionFilter })}</option>
+                        )}
+                        {collections.map((c) => (
+                            <option key={c.address} value={c.address}>
+                                {groupName(c)} ({c.nft_count})
+                            </option>
+                        ))}
+                    </select>
+                    {tagFilter && (
+                        <button
+                            type="button"
+                            className="tag-filter"
+                            onClick={() => setTagFilter("")}
+                            aria-label={`Stop filtering by tag ${tagFilter}`}
+                        >
+                            <Tag size={12} aria-hidden="true" />
+                            {tagFilter}
+                            <X size={12} aria-hidden="true" />
+                        </button>
+                    )}
+                </div>
+
                 <fieldset className="layout-toggle" aria-label="View layout">
                     <button
                         type="button"
@@ -577,6 +786,8 @@ export function Assets({ onStatsChange }: AssetsProps) {
                     <ChevronRight size={18} />
                 </button>
             </div>
+
+            <MetadataHistoryModal nft={historyNft} onClose={() => setHistoryNft(null)} />
         </div>
     );
 }
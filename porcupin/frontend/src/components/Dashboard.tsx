@@ -77,7 +77,10 @@ export function Dashboard({ stats, walletCount }: DashboardProps) {
 
     const failedCount = (stats.failed || 0) + (stats.failed_unavailable || 0);
 
+    const storageUnavailable = status?.state === "storage_unavailable";
+
     const getStateIcon = () => {
+        if (storageUnavailable) return <AlertTriangle size={14} />;
         if (isPaused) return <Pause size={14} />;
         switch (status?.state) {
             case "syncing":
@@ -92,6 +95,7 @@ export function Dashboard({ stats, walletCount }: DashboardProps) {
     };
 
     const getStateLabel = () => {
+        if (storageUnavailable) return "Storage Offline";
         if (isPaused) return "Paused";
         switch (status?.state) {
             case "syncing":
@@ -106,6 +110,7 @@ export function Dashboard({ stats, walletCount }: DashboardProps) {
     };
 
     const getStatusClass = () => {
+        if (storageUnavailable) return "storage-unavailable";
         if (isPaused) return "paused";
         switch (status?.state) {
             case "syncing":
@@ -217,6 +222,14 @@ export function Dashboard({ stats, walletCount }: DashboardProps) {
                 </div>
             )}
 
+            {/* Backups wait for the repository's volume to come back */}
+            {storageUnavailable && (
+                <div className="storage-unavailable-info">
+                    <AlertTriangle size={14} /> Storage unavailable: {status?.storage_error}. Backups resume
+                    automatically when it is reconnected.
+                </div>
+            )}
+
             {/* Last sync info when watching */}
             {status?.state === "watching" && status.last_sync_at && (
                 <div className="last-sync-info">Last synced: {new Date(status.last_sync_at).toLocaleTimeString()}</div>
@@ -0,0 +1,165 @@
+import { useEffect, useState, useCallback } from "react";
+import { AlertTriangle, CheckCircle, HardDrive, Pin, RefreshCw, Server, WifiOff } from "lucide-react";
+import { GetFleetOverview } from "../lib/backend";
+import type { api } from "../../wailsjs/go/models";
+
+/** Overview of every server saved in Settings, refreshed on demand */
+export function Fleet() {
+    const [overview, setOverview] = useState<api.FleetOverview | null>(null);
+    const [loading, setLoading] = useState(true);
+    const [error, setError] = useState("");
+
+    const loadOverview = useCallback(async () => {
+        setLoading(true);
+        setError("");
+        try {
+            setOverview(await GetFleetOverview());
+        } catch (err: unknown) {
+            setError(err instanceof Error ? err.message : String(err));
+        } finally {
+            setLoading(false);
+        }
+    }, []);
+
+    useEffect(() => {
+        loadOverview();
+    }, [loadOverview]);
+
+    const totals = overview?.totals;
+    const nodes = overview?.nodes || [];
+    const failedAssets = overview?.failed_assets || [];
+
+    return (
+        <div className="fleet-page">
+            <div className="page-header">
+                <div className="page-header-row">
+                    <div>
+                        <h1>Fleet</h1>
+                        <p className="page-subtitle">Every saved server at a glance</p>
+                    </div>
+                    <div className="header-actions">
+                        <button type="button" className="btn-secondary" onClick={loadOverview} disabled={loading}>
+                            <RefreshCw size={14} className={loading ? "spin" : ""} />
+                            {loading ? "Checking..." : "Refresh"}
+                        </button>
+                    </div>
+                </div>
+            </div>
+
+            {error && (
+                <div className="remote-error">
+                    <AlertTriangle size={14} />
+                    {error}
+                </div>
+            )}
+
+            {!loading && !error && nodes.length === 0 && (
+                <div className="empty-state">No saved servers yet. Connect to a server in Settings to add it.</div>
+            )}
+
+            {totals && nodes.length > 0 && (
+                <div className="stats-grid-3">
+                    <div className={`stat-card ${totals.unreachable > 0 ? "danger" : "primary"}`}>
+                        <div className="stat-icon">
+                            <Server size={24} />
+                        </div>
+                        <div className="stat-content">
+                            <div className="stat-value">
+                                {totals.reachable - totals.errors} / {totals.nodes}
+                            </div>
+                            <div className="stat-label">Servers Online</div>
+                        </div>
+                    </div>
+
+                    <div className="stat-card primary">
+                        <div className="stat-icon">
+                            <Pin size={24} />
+                        </div>
+                        <div className="stat-content">
+                            <div className="stat-value">{totals.pinned_assets}</div>
+                            <div className="stat-label">
+                                Assets Pinned, {totals.pending_assets} pending, {totals.failed_assets} failed
+                            </div>
+                        </div>
+                    </div>
+
+                    <div className="stat-card info">
+                        <div className="stat-icon">
+                            <HardDrive size={24} />
+                        </div>
+                        <div className="stat-content">
+                            <div className="stat-value">{totals.storage_used_gb.toFixed(1)} GB</div>
+                            <div className="stat-label">Storage Used</div>
+                        </div>
+                    </div>
+                </div>
+            )}
+
+            {nodes.length > 0 && (
+                <div className="fleet-nodes">
+                    {nodes.map((node) => {
+                        const healthy = node.reachable && !node.error;
+                        return (
+                            <div key={node.id} className={`fleet-node ${healthy ? "" : "unhealthy"}`}>
+                                <div className="fleet-node-header">
+                                    {healthy ? (
+                                        <CheckCircle size={16} className="fleet-node-ok" />
+                                    ) : node.reachable ? (
+                                        <AlertTriangle size={16} className="fleet-node-warn" />
+                                    ) : (
+                                        <WifiOff size={16} className="fleet-node-down" />
+                                    )}
+                                    <span className="fleet-node-name">{node.name}</span>
+                                    <span className="fleet-node-address">{node.address}</span>
+                                    {node.version && <span className="server-version">v{node.version}</span>}
+                                </div>
+                                {node.error ? (
+                                    <div className="fleet-node-error">
+                                        {node.reachable ? node.error : `Unreachable: ${node.error}`}
+                                    </div>
+                                ) : (
+                                    node.stats && (
+                                        <div className="fleet-node-stats">
+                                            <span>{node.stats.total_nfts} NFTs</span>
+                                            <span>
+                                                {node.stats.pinned_assets} / {node.stats.total_assets} pinned
+                                            </span>
+                                            {node.stats.failed_assets > 0 && (
+                                                <span className="fleet-node-failed">
+                                                    {node.stats.failed_assets} failed
+                                                </span>
+                                            )}
+                                            <span>{node.stats.storage_used_gb.toFixed(1)} GB</span>
+                                            {node.status && <span>{node.status.state}</span>}
+                                        </div>
+                                    )
+                                )}
+                            </div>
+                        );
+                    })}
+                </div>
+            )}
+
+            {failedAssets.length > 0 && (
+                <div className="fleet-failed">
+                    <h3>Failed Assets</h3>
+                    <ul className="fleet-failed-list">
+                        {failedAssets.map((asset) => (
+                            <li key={`${asset.node_id}:${asset.id}`}>
+                                <span className="fleet-failed-node">{asset.node_name}</span>
+                                <span className="fleet-failed-uri" title={asset.uri}>
+                                    {asset.uri}
+                                </span>
+                                {asset.error_msg && <span className="fleet-failed-error">{asset.error_msg}</span>}
+                            </li>
+                        ))}
+                    </ul>
+                </div>
+            )}
+
+            {overview && (
+                <div className="last-sync-info">Checked at {new Date(overview.checked_at).toLocaleTimeString()}</div>
+            )}
+        </div>
+    );
+}
@@ -0,0 +1,107 @@
+import { useEffect, useState } from "react";
+import { RefreshCw } from "lucide-react";
+import { GetNFTMetadataHistory } from "../lib/backend";
+import type { db } from "../../wailsjs/go/models";
+
+interface MetadataHistoryModalProps {
+    nft: db.NFT | null;
+    onClose: () => void;
+}
+
+// Longest value shown for a changed field before it's cut short
+const VALUE_LIMIT = 120;
+
+function formatValue(value: unknown): string {
+    if (value === undefined) return "";
+    const text = typeof value === "string" ? value : JSON.stringify(value);
+    return text.length > VALUE_LIMIT ? `${text.slice(0, VALUE_LIMIT)}…` : text;
+}
+
+export function MetadataHistoryModal({ nft, onClose }: MetadataHistoryModalProps) {
+    const [revisions, setRevisions] = useState<db.MetadataRevision[]>([]);
+    const [loading, setLoading] = useState(false);
+    const [error, setError] = useState("");
+
+    useEffect(() => {
+        if (!nft) return;
+        setLoading(true);
+        setError("");
+        GetNFTMetadataHistory(nft.id)
+            .then((history) => setRevisions([...(history || [])].reverse()))
+            .catch((err: unknown) => setError(String(err)))
+            .finally(() => setLoading(false));
+    }, [nft]);
+
+    if (!nft) return null;
+
+    const handleKeyDown = (e: React.KeyboardEvent) => {
+        if (e.key === "Escape") onClose();
+    };
+
+    return (
+        <div
+            className="modal-overlay"
+            onClick={onClose}
+            onKeyDown={handleKeyDown}
+            role="dialog"
+            aria-modal="true"
+            aria-labelledby="history-title"
+            tabIndex={-1}
+        >
+            <div
+                className="modal-content history-modal"
+                onClick={(e) => e.stopPropagation()}
+                onKeyDown={(e) => e.stopPropagation()}
+                role="document"
+            >
+                <h3 className="modal-title" id="history-title">
+                    Metadata history of {nft.name || `Token #${nft.token_id}`}
+                </h3>
+                {loading ? (
+                    <output className="history-loading" aria-live="polite">
+                        <RefreshCw size={16} className="spin" aria-hidden="true" />
+                        Loading history...
+                    </output>
+                ) : error ? (
+                    <p className="modal-message">{error}</p>
+                ) : (
+                    <ol className="history-list">
+                        {revisions.map((rev) => (
+                            <li key={rev.version}>
+                                <div className="history-version">
+                                    <strong>Version {rev.version}</strong>
+                                    <span>
+                                        {rev.level > 0 ? `Level ${rev.level} · ` : ""}
+                                        {new Date(rev.created_at).toLocaleString()}
+                                    </span>
+                                </div>
+                                {rev.version === 1 ? (
+                                    <p className="history-first">First version seen</p>
+                                ) : (
+                                    <ul className="history-changes">
+                                        {rev.changes.map((c) => (
+                                            <li key={c.field}>
+                                                <code>{c.field}</code>
+                                                {c.old !== undefined && (
+                                                    <span className="old">{formatValue(c.old)}</span>
+                                                )}
+                                                {c.new !== undefined && (
+                                                    <span className="new">{formatValue(c.new)}</span>
+                                                )}
+                                            </li>
+                                        ))}
+                                    </ul>
+                                )}
+                            </li>
+                        ))}
+                    </ol>
+                )}
+                <div className="modal-actions">
+                    <button type="button" className="btn-secondary" onClick={onClose}>
+                        Close
+                    </button>
+                </div>
+            </div>
+        </div>
+    );
+}
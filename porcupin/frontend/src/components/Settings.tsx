@@ -14,6 +14,7 @@ import {
     CancelMigration,
     DiscoverServers,
     TestRemoteConnection,
+    PairRemoteServer,
     isRemote,
 } from "../lib/backend";
 import { useConnection } from "../lib/connection";
@@ -36,7 +37,7 @@ import {
     Search,
 } from "lucide-react";
 import type { api, main, storage } from "../../wailsjs/go/models";
-import { formatBytes } from "../utils";
+import { formatBytes, formatFingerprint } from "../utils";
 
 interface SettingsProps {
     onStatsChange: () => void;
@@ -97,13 +98,17 @@ export function Settings({ onStatsChange }: SettingsProps) {
     const [remoteHost, setRemoteHost] = useState("");
     const [remotePort, setRemotePort] = useState("8085");
     const [remoteToken, setRemoteToken] = useState("");
-    const [remoteUseTLS, setRemoteUseTLS] = useState(false);
+    const [remoteServerId, setRemoteServerId] = useState<number | undefined>(undefined);
+    const [remoteUseTLS, setRemoteUseTLS] = useState(true);
+    const [remoteFingerprint, setRemoteFingerprint] = useState<string | undefined>(undefined);
     const [remoteLabel, setRemoteLabel] = useState("");
     const [remoteTesting, setRemoteTesting] = useState(false);
     const [remoteConnecting, setRemoteConnecting] = useState(false);
     const [remoteError, setRemoteError] = useState("");
     const [remoteTestResult, setRemoteTestResult] = useState<string | null>(null);
-    const [savedProfiles, setSavedProfiles] = useState<ReturnType<typeof getSavedConfigs>>([]);
+    const [pairingCode, setPairingCode] = useState("");
+    const [pairing, setPairing] = useState(false);
+    const [savedProfiles, setSavedProfiles] = useState<Awaited<ReturnType<typeof getSavedConfigs>>>([]);
 
     // Discovery state
     const [discoveredServers, setDiscoveredServers] = useState<api.DiscoveredServer[]>([]);
@@ -160,10 +165,18 @@ export function Settings({ onStatsChange }: SettingsProps) {
     };
 
     // Load saved connection profiles
-    useEffect(() => {
-        setSavedProfiles(getSavedConfigs());
+    const loadSavedProfiles = useCallback(async () => {
+        try {
+            setSavedProfiles(await getSavedConfigs());
+        } catch (err) {
+            console.error("Failed to load saved servers:", err);
+        }
     }, [getSavedConfigs]);
 
+    useEffect(() => {
+        loadSavedProfiles();
+    }, [loadSavedProfiles]);
+
     useEffect(() => {
         loadSettings();
 
@@ -409,6 +422,16 @@ export function Settings({ onStatsChange }: SettingsProps) {
                             {currentLocation?.type || "local"}
                         </span>
                     </div>
+                    {availableLocations
+                        .filter((loc) => loc.tier === "cold")
+                        .map((loc) => (
+                            <div className="storage-stat" key={loc.path}>
+                                <span className="label">Cold Tier:</span>
+                                <span className="value path">
+                                    {formatBytes(loc.used_bytes || 0)} • {loc.path}
+                                </span>
+                            </div>
+                        ))}
                     {storageInfo?.is_warning && (
                         <div className="storage-warning">
                             <AlertTriangle size={16} /> Storage usage at {storageInfo.usage_pct.toFixed(0)}% of limit
@@ -465,6 +488,7 @@ export function Settings({ onStatsChange }: SettingsProps) {
                             <span>
                                 {migrationStatus?.phase === "preparing" && "Preparing migration..."}
                                 {migrationStatus?.phase === "copying" && "Copying files..."}
+                                {migrationStatus?.phase === "verifying" && "Verifying copy..."}
                                 {migrationStatus?.phase === "cleanup" && "Cleaning up..."}
                                 {migrationStatus?.phase === "complete" && "Migration complete!"}
                                 {migrationStatus?.phase === "cancelled" && "Migration cancelled"}
@@ -480,14 +504,14 @@ export function Settings({ onStatsChange }: SettingsProps) {
                                     To: <code>{migrationStatus.dest_path}</code>
                                 </p>
                                 <p>
-                                    Method: {migrationStatus.method === "rename" ? "Move (instant)" : "Rsync (copying)"}
+                                    Method: {migrationStatus.method === "rename" ? "Move (instant)" : "Copy and verify"}
                                 </p>
                                 {migrationStatus.current_file && (
                                     <p className="current-file">{migrationStatus.current_file}</p>
                                 )}
                             </div>
                         )}
-                        {migrationStatus?.method === "rsync" && migrationStatus.total_bytes > 0 && (
+                        {migrationStatus?.method === "copy" && migrationStatus.total_bytes > 0 && (
                             <>
                                 <div className="progress-bar">
                                     <div className="progress-fill" style={{ width: `${migrationStatus.progress}%` }} />
@@ -541,13 +565,14 @@ export function Settings({ onStatsChange }: SettingsProps) {
                                         type="button"
                                         className={`location-option ${selectedPath === loc.path ? "selected" : ""}`}
                                         onClick={() => handleSelectLocation(loc.path)}
-                                        disabled={!loc.is_writable}
+                                        disabled={!loc.is_writable || loc.tier === "cold"}
                                     >
                                         <span className="location-icon">{getStorageIcon(loc.type)}</span>
                                         <span className="location-details">
                                             <span className="location-label">{loc.label || loc.path}</span>
                                             <span className="location-meta">
                                                 {formatBytes(loc.free_bytes)} free • {loc.type}
+                                                {loc.tier && ` • ${loc.tier} tier, ${formatBytes(loc.used_bytes || 0)} used`}
                                             </span>
                                         </span>
                                         {selectedPath === loc.path && <Check size={16} className="check-icon" />}
@@ -789,7 +814,9 @@ export function Settings({ onStatsChange }: SettingsProps) {
                                             onClick={() => {
                                                 setRemoteHost(server.host);
                                                 setRemotePort(String(server.port));
+                                                setRemoteServerId(undefined);
                                                 setRemoteUseTLS(server.useTLS);
+                                                setRemoteFingerprint(server.fingerprint);
                                                 setRemoteError("");
                                                 setRemoteTestResult(null);
                                             }}
@@ -817,15 +844,17 @@ export function Settings({ onStatsChange }: SettingsProps) {
                                 <h4>Saved Servers</h4>
                                 <div className="profile-list">
                                     {savedProfiles.map((profile) => (
-                                        <div key={`${profile.host}:${profile.port}`} className="saved-profile">
+                                        <div key={profile.id} className="saved-profile">
                                             <button
                                                 type="button"
                                                 className="profile-select"
                                                 onClick={() => {
                                                     setRemoteHost(profile.host);
                                                     setRemotePort(String(profile.port));
-                                                    setRemoteToken(profile.token);
+                                                    setRemoteToken("");
+                                                    setRemoteServerId(profile.id);
                                                     setRemoteUseTLS(profile.useTLS);
+                                                    setRemoteFingerprint(profile.fingerprint);
                                                     setRemoteLabel(profile.label || "");
                                                     setRemoteError("");
                                                     setRemoteTestResult(null);
@@ -844,9 +873,13 @@ export function Settings({ onStatsChange }: SettingsProps) {
                                             <button
                                                 type="button"
                                                 className="profile-remove"
-                                                onClick={() => {
-                                                    removeConfig(profile.host, profile.port);
-                                                    setSavedProfiles(getSavedConfigs());
+                                                onClick={async () => {
+                                                    if (!profile.id) return;
+                                                    await removeConfig(profile.id);
+                                                    if (remoteServerId === profile.id) {
+                                                        setRemoteServerId(undefined);
+                                                    }
+                                                    loadSavedProfiles();
                                                 }}
                                                 title="Remove saved server"
                                             >
@@ -867,6 +900,8 @@ export function Settings({ onStatsChange }: SettingsProps) {
                                     value={remoteHost}
                                     onChange={(e) => {
                                         setRemoteHost(e.target.value);
+                                        setRemoteServerId(undefined);
+                                        setRemoteFingerprint(undefined);
                                         setRemoteError("");
                                         setRemoteTestResult(null);
                                     }}
@@ -881,6 +916,8 @@ export function Settings({ onStatsChange }: SettingsProps) {
                                     value={remotePort}
                                     onChange={(e) => {
                                         setRemotePort(e.target.value);
+                                        setRemoteServerId(undefined);
+                                        setRemoteFingerprint(undefined);
                                         setRemoteError("");
                                         setRemoteTestResult(null);
                                     }}
@@ -899,9 +936,68 @@ export function Settings({ onStatsChange }: SettingsProps) {
                                     setRemoteError("");
                                     setRemoteTestResult(null);
                                 }}
-                                placeholder="prcpn_..."
+                                placeholder={remoteServerId ? "Saved - leave empty to keep it" : "prcpn_..."}
                             />
                         </div>
+                        <div className="form-group">
+                            <label htmlFor="pairingCode">Or Pair With a Code</label>
+                            <div className="input-with-button">
+                                <input
+                                    id="pairingCode"
+                                    type="text"
+                                    value={pairingCode}
+                                    onChange={(e) => {
+                                        setPairingCode(e.target.value);
+                                        setRemoteError("");
+                                        setRemoteTestResult(null);
+                                    }}
+                                    placeholder="ABCD-EFGH or porcupin://pair?..."
+                                />
+                                <button
+                                    type="button"
+                                    onClick={async () => {
+                                        setPairing(true);
+                                        setRemoteError("");
+                                        setRemoteTestResult(null);
+                                        try {
+                                            const paired = await PairRemoteServer({
+                                                host: remoteHost || undefined,
+                                                port: parseInt(remotePort) || 8085,
+                                                fingerprint: remoteFingerprint,
+                                                code: pairingCode,
+                                            });
+                                            LogInfo(`[Settings] Paired with ${paired.host}:${paired.port}`);
+                                            setRemoteHost(paired.host);
+                                            setRemotePort(String(paired.port));
+                                            setRemoteToken(paired.token);
+                                            setRemoteServerId(undefined);
+                                            setRemoteUseTLS(paired.useTLS);
+                                            setRemoteFingerprint(paired.fingerprint || undefined);
+                                            setPairingCode("");
+                                            setRemoteTestResult(
+                                                paired.fingerprint
+                                                    ? `Paired - this app has its own token; certificate ${formatFingerprint(paired.fingerprint)}`
+                                                    : "Paired - this app has its own token"
+                                            );
+                                        } catch (err) {
+                                            const errMsg = err instanceof Error ? err.message : String(err);
+                                            LogError(`[Settings] Pairing error: ${errMsg}`);
+                                            setRemoteError(errMsg);
+                                        } finally {
+                                            setPairing(false);
+                                        }
+                                    }}
+                                    disabled={pairing || !pairingCode}
+                                    className="btn-secondary"
+                                >
+                                    {pairing ? "Pairing..." : "Pair"}
+                                </button>
+                            </div>
+                            <span className="hint">
+                                Run <code>porcupin token pair</code> on the server, then enter the code it shows for
+                                the server selected above, or paste its pairing URL
+                            </span>
+                        </div>
                         <div className="form-group toggle-group">
                             <label htmlFor="remoteUseTLS">
                                 <input
@@ -944,7 +1040,7 @@ export function Settings({ onStatsChange }: SettingsProps) {
                                 type="button"
                                 onClick={async () => {
                                     LogInfo("[Settings] Test Connection button clicked");
-                                    if (!remoteHost || !remoteToken) {
+                                    if (!remoteHost || (!remoteToken && !remoteServerId)) {
                                         setRemoteError("Host and token are required");
                                         return;
                                     }
@@ -959,9 +1055,16 @@ export function Settings({ onStatsChange }: SettingsProps) {
                                             port: parseInt(remotePort) || 8085,
                                             token: remoteToken,
                                             useTLS: remoteUseTLS,
+                                            fingerprint: remoteFingerprint,
+                                            serverId: remoteToken ? undefined : remoteServerId,
                                         });
                                         LogInfo(`[Settings] Test Connection success: ${health.version}`);
-                                        setRemoteTestResult(`Connection OK - Server v${health.version}`);
+                                        setRemoteFingerprint(health.fingerprint || undefined);
+                                        setRemoteTestResult(
+                                            health.fingerprint
+                                                ? `Connection OK - Server v${health.version}, certificate ${formatFingerprint(health.fingerprint)}`
+                                                : `Connection OK - Server v${health.version}`
+                                        );
                                     } catch (err) {
                                         const errMsg = err instanceof Error ? err.message : "Connection failed";
                                         LogError(`[Settings] Test Connection error: ${errMsg}`);
@@ -970,7 +1073,9 @@ export function Settings({ onStatsChange }: SettingsProps) {
                                         setRemoteTesting(false);
                                     }
                                 }}
-                                disabled={remoteTesting || remoteConnecting || !remoteHost || !remoteToken}
+                                disabled={
+                                    remoteTesting || remoteConnecting || !remoteHost || (!remoteToken && !remoteServerId)
+                                }
                                 className="btn-secondary"
                             >
                                 {remoteTesting ? "Testing..." : "Test Connection"}
@@ -978,7 +1083,7 @@ export function Settings({ onStatsChange }: SettingsProps) {
                             <button
                                 type="button"
                                 onClick={async () => {
-                                    if (!remoteHost || !remoteToken) {
+                                    if (!remoteHost || (!remoteToken && !remoteServerId)) {
                                         setRemoteError("Host and token are required");
                                         return;
                                     }
@@ -990,19 +1095,26 @@ export function Settings({ onStatsChange }: SettingsProps) {
                                             port: parseInt(remotePort) || 8085,
                                             token: remoteToken,
                                             useTLS: remoteUseTLS,
+                                            fingerprint: remoteFingerprint,
                                             label: remoteLabel || undefined,
+                                            // A new token is tried before it replaces the saved one
+                                            id: remoteToken ? undefined : remoteServerId,
                                         };
-                                        await connect(config);
-                                        // Save profile on successful connection
-                                        saveConfig(config);
-                                        setSavedProfiles(getSavedConfigs());
+                                        const pinned = await connect(config);
+                                        // Save profile on successful connection; the app keeps the token
+                                        const saved = await saveConfig({ ...pinned, id: remoteServerId });
+                                        setRemoteServerId(saved.id);
+                                        setRemoteToken("");
+                                        loadSavedProfiles();
                                     } catch (err) {
                                         setRemoteError(err instanceof Error ? err.message : "Connection failed");
                                     } finally {
                                         setRemoteConnecting(false);
                                     }
                                 }}
-                                disabled={remoteTesting || remoteConnecting || !remoteHost || !remoteToken}
+                                disabled={
+                                    remoteTesting || remoteConnecting || !remoteHost || (!remoteToken && !remoteServerId)
+                                }
                                 className="btn-primary"
                             >
                                 <Plug size={14} />
@@ -9,6 +9,7 @@ import {
     ChevronRight,
     Monitor,
     Server,
+    Network,
 } from "lucide-react";
 import { Logo } from "./Logo";
 import { useConnectionStatus } from "../lib/connection";
@@ -22,6 +23,7 @@ const navItems = [
     { id: "dashboard", icon: LayoutDashboard, label: "Dashboard" },
     { id: "wallets", icon: Wallet, label: "Wallets" },
     { id: "assets", icon: Image, label: "Assets" },
+    { id: "fleet", icon: Network, label: "Fleet" },
     { id: "settings", icon: Settings, label: "Settings" },
     { id: "about", icon: HelpCircle, label: "About" },
 ];
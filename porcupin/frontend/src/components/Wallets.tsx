@@ -1,4 +1,4 @@
-import { useState } from "react";
+import { useRef, useState } from "react";
 import {
     AddWallet,
     SyncWallet,
@@ -7,8 +7,11 @@ import {
     DeleteWallet,
     DeleteWalletWithUnpin,
     UpdateWalletAlias,
+    ImportWallets,
+    ExportWallets,
+    SaveTextFile,
 } from "../lib/backend";
-import type { db } from "../../wailsjs/go/models";
+import type { api, db } from "../../wailsjs/go/models";
 import { ConfirmModal } from "./ConfirmModal";
 
 interface WalletsProps {
@@ -22,6 +25,21 @@ interface WalletsProps {
 
 type DeleteMode = "keep-pins" | "unpin";
 
+// A wallet file checked with a dry run, waiting for confirmation
+interface PendingImport {
+    name: string;
+    content: string;
+    format: string;
+    report: api.WalletImportReport;
+}
+
+function walletFileFormat(name: string): string {
+    const lower = name.toLowerCase();
+    if (lower.endsWith(".csv")) return "csv";
+    if (lower.endsWith(".json")) return "json";
+    return "";
+}
+
 export function Wallets({ wallets, loading, setLoading, setError, onWalletsChange, onStatsChange }: WalletsProps) {
     const [newAddress, setNewAddress] = useState("");
     const [newAlias, setNewAlias] = useState("");
@@ -29,6 +47,8 @@ export function Wallets({ wallets, loading, setLoading, setError, onWalletsChang
     const [deleteMode, setDeleteMode] = useState<DeleteMode>("keep-pins");
     const [editingWallet, setEditingWallet] = useState<string | null>(null);
     const [editAlias, setEditAlias] = useState("");
+    const [pendingImport, setPendingImport] = useState<PendingImport | null>(null);
+    const fileInput = useRef<HTMLInputElement>(null);
 
     const handleAddWallet = async () => {
         if (!newAddress) return;
@@ -131,6 +151,47 @@ export function Wallets({ wallets, loading, setLoading, setError, onWalletsChang
         setEditAlias("");
     };
 
+    const handleImportFile = async (file: File | undefined) => {
+        if (!file) return;
+        setError("");
+        try {
+            const content = await file.text();
+            const format = walletFileFormat(file.name);
+            const report = await ImportWallets(content, format, true);
+            setPendingImport({ name: file.name, content, format, report });
+        } catch (err: unknown) {
+            setError(err instanceof Error ? err.message : String(err));
+        } finally {
+            if (fileInput.current) fileInput.current.value = "";
+        }
+    };
+
+    const confirmImport = async () => {
+        if (!pendingImport) return;
+        setLoading(true);
+        setError("");
+        try {
+            await ImportWallets(pendingImport.content, pendingImport.format, false);
+            onWalletsChange();
+            onStatsChange();
+        } catch (err: unknown) {
+            setError(err instanceof Error ? err.message : String(err));
+        } finally {
+            setLoading(false);
+            setPendingImport(null);
+        }
+    };
+
+    const handleExport = async (format: string) => {
+        setError("");
+        try {
+            const content = await ExportWallets(format);
+            await SaveTextFile(`porcupin-wallets.${format}`, content);
+        } catch (err: unknown) {
+            setError(err instanceof Error ? err.message : String(err));
+        }
+    };
+
     return (
         <div className="wallets">
             <div className="page-header">
@@ -142,6 +203,38 @@ export function Wallets({ wallets, loading, setLoading, setError, onWalletsChang
                         </p>
                     </div>
                     <div className="header-actions">
+                        <input
+                            ref={fileInput}
+                            type="file"
+                            accept=".csv,.json,text/csv,application/json"
+                            onChange={(e) => handleImportFile(e.target.files?.[0])}
+                            hidden
+                        />
+                        <button
+                            type="button"
+                            onClick={() => fileInput.current?.click()}
+                            disabled={loading}
+                            className="btn-secondary"
+                            title="Add wallets from a CSV or JSON file"
+                        >
+                            Import
+                        </button>
+                        <button
+                            type="button"
+                            onClick={() => handleExport("csv")}
+                            disabled={wallets.length === 0}
+                            className="btn-secondary"
+                        >
+                            Export CSV
+                        </button>
+                        <button
+                            type="button"
+                            onClick={() => handleExport("json")}
+                            disabled={wallets.length === 0}
+                            className="btn-secondary"
+                        >
+                            Export JSON
+                        </button>
                         <button type="button" onClick={() => handleSync()} disabled={loading} className="btn-primary">
                             {loading ? "Syncing..." : "Sync All"}
                         </button>
@@ -152,10 +245,10 @@ export function Wallets({ wallets, loading, setLoading, setError, onWalletsChang
             <div className="add-wallet">
                 <input
                     type="text"
-                    placeholder="Enter Tezos Address (tz1...)"
+                    placeholder="Enter Tezos Address (tz1...) or Domain (name.tez)"
                     value={newAddress}
                     onChange={(e) => setNewAddress(e.target.value)}
-                    aria-label="Tezos wallet address"
+                    aria-label="Tezos wallet address or .tez domain"
                 />
                 <input
                     type="text"
@@ -213,7 +306,10 @@ export function Wallets({ wallets, loading, setLoading, setError, onWalletsChang
                                             ✏️
                                         </button>
                                     </div>
-                                    <div className="wallet-meta">{wallet.address}</div>
+                                    <div className="wallet-meta">
+                                        {wallet.address}
+                                        {wallet.domain && wallet.domain !== wallet.alias && ` · ${wallet.domain}`}
+                                    </div>
                                 </>
                             )}
                         </div>
@@ -292,6 +388,36 @@ export function Wallets({ wallets, loading, setLoading, setError, onWalletsChang
                     </label>
                 </div>
             </ConfirmModal>
+
+            <ConfirmModal
+                isOpen={pendingImport !== null}
+                title="Import Wallets"
+                confirmText={`Add ${pendingImport?.report.added ?? 0} Wallet${pendingImport?.report.added === 1 ? "" : "s"}`}
+                cancelText="Cancel"
+                onConfirm={confirmImport}
+                onCancel={() => setPendingImport(null)}
+            >
+                {pendingImport && (
+                    <>
+                        <p className="modal-message">
+                            {pendingImport.name}: {pendingImport.report.added} to add,{" "}
+                            {pendingImport.report.existing} already tracked, {pendingImport.report.duplicates}{" "}
+                            duplicate, {pendingImport.report.invalid} invalid.
+                        </p>
+                        <div className="import-results">
+                            {pendingImport.report.results.map((result) => (
+                                <div key={result.line} className={`import-result import-${result.status}`}>
+                                    <span className="import-line">{result.line}</span>
+                                    <span className="import-address" title={result.address}>
+                                        {result.alias ? `${result.alias} (${result.address})` : result.address || "—"}
+                                    </span>
+                                    <span className="import-status">{result.error || result.status}</span>
+                                </div>
+                            ))}
+                        </div>
+                    </>
+                )}
+            </ConfirmModal>
         </div>
     );
 }
@@ -15,7 +15,7 @@
  */
 
 import * as WailsApp from "../../wailsjs/go/main/App";
-import type { config, core, db, ipfs, main, storage } from "../../wailsjs/go/models";
+import type { api, config, core, db, ipfs, main, storage } from "../../wailsjs/go/models";
 import type { ProxyAPIClient } from "./proxy-api-client";
 
 // =============================================================================
@@ -31,7 +31,9 @@ export interface Backend {
     AddWallet(address: string, alias: string): Promise<void>;
     DeleteWallet(address: string, keepAssets: boolean): Promise<void>;
     DeleteWalletWithUnpin(address: string): Promise<void>;
+    ExportWallets(format: string): Promise<string>;
     GetWallets(): Promise<db.Wallet[]>;
+    ImportWallets(content: string, format: string, dryRun: boolean): Promise<api.WalletImportReport>;
     SyncWallet(address: string): Promise<void>;
     UpdateWalletAlias(address: string, alias: string): Promise<void>;
     UpdateWalletSettings(address: string, syncOwned: boolean, syncCreated: boolean): Promise<void>;
@@ -52,7 +54,19 @@ export interface Backend {
     VerifyAsset(id: number): Promise<ipfs.VerifyResult>;
 
     // NFT operations
-    GetNFTsWithAssets(page: number, limit: number, status: string, search: string): Promise<db.NFT[]>;
+    GetCollections(page: number, limit: number, search: string, creator: string): Promise<db.ContractSummary[]>;
+    GetCreators(page: number, limit: number, search: string): Promise<db.CreatorSummary[]>;
+    GetNFTsWithAssets(
+        page: number,
+        limit: number,
+        status: string,
+        search: string,
+        creator: string,
+        contract: string,
+        tag: string,
+        attribute: string
+    ): Promise<db.NFT[]>;
+    GetNFTMetadataHistory(nftId: number): Promise<db.MetadataRevision[]>;
 
     // Service control
     GetRecentActivity(limit: number): Promise<db.Asset[]>;
@@ -129,7 +143,9 @@ const localBackend: Backend = {
     AddWallet: WailsApp.AddWallet,
     DeleteWallet: WailsApp.DeleteWallet,
     DeleteWalletWithUnpin: WailsApp.DeleteWalletWithUnpin,
+    ExportWallets: WailsApp.ExportWallets,
     GetWallets: WailsApp.GetWallets,
+    ImportWallets: WailsApp.ImportWallets,
     SyncWallet: WailsApp.SyncWallet,
     UpdateWalletAlias: WailsApp.UpdateWalletAlias,
     UpdateWalletSettings: WailsApp.UpdateWalletSettings,
@@ -150,7 +166,10 @@ const localBackend: Backend = {
     VerifyAsset: WailsApp.VerifyAsset,
 
     // NFT operations
+    GetCollections: WailsApp.GetCollections,
+    GetCreators: WailsApp.GetCreators,
     GetNFTsWithAssets: WailsApp.GetNFTsWithAssets,
+    GetNFTMetadataHistory: WailsApp.GetNFTMetadataHistory,
 
     // Service control
     GetRecentActivity: WailsApp.GetRecentActivity,
@@ -194,7 +213,9 @@ function createRemoteBackend(client: ProxyAPIClient): Backend {
         AddWallet: (address, alias) => client.addWallet(address, alias),
         DeleteWallet: (address, keepAssets) => client.deleteWallet(address, keepAssets),
         DeleteWalletWithUnpin: (address) => client.deleteWalletWithUnpin(address),
+        ExportWallets: (format) => client.exportWallets(format),
         GetWallets: () => client.getWallets(),
+        ImportWallets: (content, format, dryRun) => client.importWallets(content, format, dryRun),
         SyncWallet: (address) => client.syncWallet(address),
         UpdateWalletAlias: (address, alias) => client.updateWalletAlias(address, alias),
         UpdateWalletSettings: (address, syncOwned, syncCreated) =>
@@ -217,7 +238,11 @@ function createRemoteBackend(client: ProxyAPIClient): Backend {
 
         // NFT operations
         // NFT operations
-        GetNFTsWithAssets: (page, limit, status, search) => client.getNFTsWithAssets(page, limit, status, search),
+        GetCollections: (page, limit, search, creator) => client.getCollections(page, limit, search, creator),
+        GetCreators: (page, limit, search) => client.getCreators(page, limit, search),
+        GetNFTsWithAssets: (page, limit, status, search, creator, contract, tag, attribute) =>
+            client.getNFTsWithAssets(page, limit, status, search, creator, contract, tag, attribute),
+        GetNFTMetadataHistory: (nftId) => client.getNFTMetadataHistory(nftId),
 
         // Service control
         GetRecentActivity: (limit) => client.getRecentActivity(limit),
@@ -281,7 +306,9 @@ export const AddWallet = (...args: Parameters<Backend["AddWallet"]>) => getBacke
 export const DeleteWallet = (...args: Parameters<Backend["DeleteWallet"]>) => getBackend().DeleteWallet(...args);
 export const DeleteWalletWithUnpin = (...args: Parameters<Backend["DeleteWalletWithUnpin"]>) =>
     getBackend().DeleteWalletWithUnpin(...args);
+export const ExportWallets = (...args: Parameters<Backend["ExportWallets"]>) => getBackend().ExportWallets(...args);
 export const GetWallets = () => getBackend().GetWallets();
+export const ImportWallets = (...args: Parameters<Backend["ImportWallets"]>) => getBackend().ImportWallets(...args);
 export const SyncWallet = (...args: Parameters<Backend["SyncWallet"]>) => getBackend().SyncWallet(...args);
 export const UpdateWalletAlias = (...args: Parameters<Backend["UpdateWalletAlias"]>) =>
     getBackend().UpdateWalletAlias(...args);
@@ -303,8 +330,12 @@ export const RetryAsset = (...args: Parameters<Backend["RetryAsset"]>) => getBac
 export const UnpinAsset = (...args: Parameters<Backend["UnpinAsset"]>) => getBackend().UnpinAsset(...args);
 export const VerifyAsset = (...args: Parameters<Backend["VerifyAsset"]>) => getBackend().VerifyAsset(...args);
 
+export const GetCollections = (...args: Parameters<Backend["GetCollections"]>) => getBackend().GetCollections(...args);
+export const GetCreators = (...args: Parameters<Backend["GetCreators"]>) => getBackend().GetCreators(...args);
 export const GetNFTsWithAssets = (...args: Parameters<Backend["GetNFTsWithAssets"]>) =>
     getBackend().GetNFTsWithAssets(...args);
+export const GetNFTMetadataHistory = (...args: Parameters<Backend["GetNFTMetadataHistory"]>) =>
+    getBackend().GetNFTMetadataHistory(...args);
 
 export const GetRecentActivity = (...args: Parameters<Backend["GetRecentActivity"]>) =>
     getBackend().GetRecentActivity(...args);
@@ -338,3 +369,13 @@ export const ValidateStoragePath = (...args: Parameters<Backend["ValidateStorage
 // Discovery and remote connection - only works in local/desktop mode
 export const DiscoverServers = WailsApp.DiscoverServers;
 export const TestRemoteConnection = WailsApp.TestRemoteConnection;
+export const PairRemoteServer = WailsApp.PairRemoteServer;
+
+// Saved remote servers and the fleet overview - local/desktop mode only
+export const DeleteRemoteServer = WailsApp.DeleteRemoteServer;
+export const GetFleetOverview = WailsApp.GetFleetOverview;
+export const GetRemoteServers = WailsApp.GetRemoteServers;
+export const SaveRemoteServer = WailsApp.SaveRemoteServer;
+
+// Saves a file on this computer, also in remote mode
+export const SaveTextFile = WailsApp.SaveTextFile;
@@ -9,7 +9,14 @@ import React, { createContext, useContext, useEffect, useState, useCallback, use
 
 import { isWailsEnvironment, waitForWails, type HealthResponse } from "./api-client";
 import { ProxyAPIClient, type ProxyAPIConfig } from "./proxy-api-client";
-import { setAPIClient as setBackendAPIClient, TestRemoteConnection } from "./backend";
+import type { main } from "../../wailsjs/go/models";
+import {
+    setAPIClient as setBackendAPIClient,
+    DeleteRemoteServer,
+    GetRemoteServers,
+    SaveRemoteServer,
+    TestRemoteConnection,
+} from "./backend";
 
 // =============================================================================
 // Types
@@ -24,7 +31,11 @@ export interface RemoteServerConfig {
     port: number;
     token: string;
     useTLS: boolean;
+    /** SHA-256 of the server's certificate, pinned on first connection */
+    fingerprint?: string;
     label?: string;
+    /** ID of the server saved in the app, which keeps its token sealed */
+    id?: number;
 }
 
 export interface ConnectionState {
@@ -51,8 +62,8 @@ export interface ConnectionContextValue {
     /** Whether in remote mode */
     isRemote: boolean;
 
-    /** Connect to a remote server */
-    connect: (config: RemoteServerConfig) => Promise<void>;
+    /** Connect to a remote server, resolving to the config with its certificate pinned */
+    connect: (config: RemoteServerConfig) => Promise<RemoteServerConfig>;
 
     /** Disconnect from remote server (switch to local if available) */
     disconnect: () => void;
@@ -60,14 +71,14 @@ export interface ConnectionContextValue {
     /** Test connection to a remote server without connecting */
     testRemoteConnection: (config: RemoteServerConfig) => Promise<HealthResponse>;
 
-    /** Get saved remote configs from localStorage */
-    getSavedConfigs: () => RemoteServerConfig[];
+    /** Get the saved remote servers, without their tokens */
+    getSavedConfigs: () => Promise<RemoteServerConfig[]>;
 
-    /** Save a remote config to localStorage */
-    saveConfig: (config: RemoteServerConfig) => void;
+    /** Save a remote server in the app, resolving to it with its ID */
+    saveConfig: (config: RemoteServerConfig) => Promise<RemoteServerConfig>;
 
-    /** Remove a saved config from localStorage */
-    removeConfig: (host: string, port: number) => void;
+    /** Forget a saved remote server */
+    removeConfig: (id: number) => Promise<void>;
 }
 
 // =============================================================================
@@ -76,8 +87,15 @@ export interface ConnectionContextValue {
 
 const STORAGE_KEY_MODE = "porcupin_connection_mode";
 const STORAGE_KEY_REMOTE_CONFIG = "porcupin_remote_config";
+// Saved servers used to live here, tokens included; they are moved to the
+// app's database on first start
 const STORAGE_KEY_SAVED_SERVERS = "porcupin_saved_servers";
 
+/** Strips the token from a config that refers to a saved server */
+function withoutToken(config: RemoteServerConfig): RemoteServerConfig {
+    return config.id ? { ...config, token: "" } : config;
+}
+
 // =============================================================================
 // Context
 // =============================================================================
@@ -129,7 +147,7 @@ export function ConnectionProvider({ children }: ConnectionProviderProps): React
     const hasAutoConnected = useRef(false);
 
     // connectToRemote - defined before useEffect that uses it
-    const connectToRemote = useCallback(async (config: RemoteServerConfig): Promise<void> => {
+    const connectToRemote = useCallback(async (config: RemoteServerConfig): Promise<RemoteServerConfig> => {
         console.log("[Connection] Starting connection to:", config.host, config.port, "TLS:", config.useTLS);
 
         // Wait for Wails runtime to be available
@@ -159,15 +177,22 @@ export function ConnectionProvider({ children }: ConnectionProviderProps): React
                 port: config.port,
                 token: config.token,
                 useTLS: config.useTLS,
+                fingerprint: config.fingerprint,
+                serverId: config.id,
             });
             console.log("[Connection] Health check passed:", health);
 
+            // Keep the certificate pinned on first use for later connections
+            const pinned: RemoteServerConfig = { ...config, fingerprint: health.fingerprint || undefined };
+
             // Create proxy client for subsequent API calls (uses Go proxy binding)
             const apiConfig: ProxyAPIConfig = {
-                host: config.host,
-                port: config.port,
-                token: config.token,
-                useTLS: config.useTLS,
+                host: pinned.host,
+                port: pinned.port,
+                token: pinned.token,
+                useTLS: pinned.useTLS,
+                fingerprint: pinned.fingerprint,
+                serverId: pinned.id,
             };
             const client = new ProxyAPIClient(apiConfig);
             setApiClient(client);
@@ -178,14 +203,15 @@ export function ConnectionProvider({ children }: ConnectionProviderProps): React
                 mode: "remote",
                 status: "connected",
                 serverVersion: health.version,
-                remoteConfig: config,
+                remoteConfig: pinned,
             });
 
             console.log("[Connection] Successfully connected to server v" + health.version);
 
-            // Persist to localStorage
+            // Persist to localStorage; a saved server's token stays in the app
             localStorage.setItem(STORAGE_KEY_MODE, "remote");
-            localStorage.setItem(STORAGE_KEY_REMOTE_CONFIG, JSON.stringify(config));
+            localStorage.setItem(STORAGE_KEY_REMOTE_CONFIG, JSON.stringify(withoutToken(pinned)));
+            return pinned;
         } catch (err) {
             const errorMsg = err instanceof Error ? err.message : "Connection failed";
             console.error("[Connection] Connection failed:", errorMsg, err);
@@ -257,6 +283,8 @@ export function ConnectionProvider({ children }: ConnectionProviderProps): React
                 port: config.port,
                 token: config.token,
                 useTLS: config.useTLS,
+                fingerprint: config.fingerprint,
+                serverId: config.id,
             });
             console.log("[Connection] Test successful:", result);
             return result as HealthResponse;
@@ -266,40 +294,79 @@ export function ConnectionProvider({ children }: ConnectionProviderProps): React
         }
     }, []);
 
-    const getSavedConfigs = useCallback((): RemoteServerConfig[] => {
-        try {
-            const saved = localStorage.getItem(STORAGE_KEY_SAVED_SERVERS);
-            if (saved) {
-                return JSON.parse(saved);
+    // Move servers saved by earlier versions out of localStorage
+    useEffect(() => {
+        if (!isDesktopApp) return;
+        const legacy = localStorage.getItem(STORAGE_KEY_SAVED_SERVERS);
+        if (!legacy) return;
+
+        const migrate = async () => {
+            if (!(await waitForWails(3000))) return;
+            let configs: RemoteServerConfig[] = [];
+            try {
+                configs = JSON.parse(legacy);
+            } catch {
+                // Invalid JSON, nothing to keep
             }
-        } catch {
-            // Invalid JSON
-        }
-        return [];
+            for (const config of configs) {
+                try {
+                    await SaveRemoteServer({
+                        id: 0,
+                        label: config.label || "",
+                        host: config.host,
+                        port: config.port,
+                        useTLS: config.useTLS,
+                        fingerprint: config.fingerprint,
+                        token: config.token,
+                    } as main.SavedRemoteServer);
+                } catch (err) {
+                    console.error("[Connection] Failed to migrate saved server:", config.host, err);
+                }
+            }
+            localStorage.removeItem(STORAGE_KEY_SAVED_SERVERS);
+        };
+        migrate();
+    }, [isDesktopApp]);
+
+    const getSavedConfigs = useCallback(async (): Promise<RemoteServerConfig[]> => {
+        const servers = await GetRemoteServers();
+        return (servers || []).map((server) => ({
+            id: server.id,
+            label: server.label || undefined,
+            host: server.host,
+            port: server.port,
+            token: "",
+            useTLS: server.useTLS,
+            fingerprint: server.fingerprint,
+        }));
     }, []);
 
-    const saveConfig = useCallback(
-        (config: RemoteServerConfig): void => {
-            const configs = getSavedConfigs();
-            // Remove existing config with same host:port
-            const filtered = configs.filter((c) => c.host !== config.host || c.port !== config.port);
-            // Add new config at the start
-            filtered.unshift(config);
-            // Keep only last 10 configs
-            const limited = filtered.slice(0, 10);
-            localStorage.setItem(STORAGE_KEY_SAVED_SERVERS, JSON.stringify(limited));
-        },
-        [getSavedConfigs]
-    );
-
-    const removeConfig = useCallback(
-        (host: string, port: number): void => {
-            const configs = getSavedConfigs();
-            const filtered = configs.filter((c) => c.host !== host || c.port !== port);
-            localStorage.setItem(STORAGE_KEY_SAVED_SERVERS, JSON.stringify(filtered));
-        },
-        [getSavedConfigs]
-    );
+    const saveConfig = useCallback(async (config: RemoteServerConfig): Promise<RemoteServerConfig> => {
+        const saved = await SaveRemoteServer({
+            id: config.id || 0,
+            label: config.label || "",
+            host: config.host,
+            port: config.port,
+            useTLS: config.useTLS,
+            fingerprint: config.fingerprint,
+            token: config.token,
+        } as main.SavedRemoteServer);
+        const result = { ...config, id: saved.id, fingerprint: saved.fingerprint };
+
+        // Once the current server is saved, only its ID needs to be kept here
+        setState((prev) => {
+            if (prev.remoteConfig?.host !== result.host || prev.remoteConfig?.port !== result.port) {
+                return prev;
+            }
+            localStorage.setItem(STORAGE_KEY_REMOTE_CONFIG, JSON.stringify(withoutToken(result)));
+            return { ...prev, remoteConfig: result };
+        });
+        return result;
+    }, []);
+
+    const removeConfig = useCallback(async (id: number): Promise<void> => {
+        await DeleteRemoteServer(id);
+    }, []);
 
     const value: ConnectionContextValue = {
         state,
@@ -9,7 +9,7 @@
  */
 
 import { RemoteProxy } from "../../wailsjs/go/main/App";
-import type { config, core, db, ipfs, main, storage } from "../../wailsjs/go/models";
+import type { api, config, core, db, ipfs, main, storage } from "../../wailsjs/go/models";
 
 // =============================================================================
 // Types
@@ -20,6 +20,29 @@ export interface ProxyAPIConfig {
     port: number;
     token: string;
     useTLS: boolean;
+    /** SHA-256 of the server's certificate, pinned on first connection */
+    fingerprint?: string;
+    /** ID of a saved server; the app fills in its token */
+    serverId?: number;
+}
+
+// NFT as the REST API lists it
+interface NFTResponse {
+    contract_address: string;
+    creator: string;
+    creator_name?: string;
+    collection_name?: string;
+    tags?: string[];
+}
+
+// Version of an NFT's metadata as the REST API lists it
+interface MetadataVersionResponse {
+    version: number;
+    level: number;
+    hash: string;
+    seen_at: string;
+    changes: db.MetadataChange[];
+    metadata: unknown;
 }
 
 export interface APIError {
@@ -47,6 +70,12 @@ export class ProxyAPIClient {
     // =========================================================================
 
     private async request<T>(method: string, path: string, body?: unknown): Promise<T> {
+        const text = await this.requestText(method, path, body !== undefined ? JSON.stringify(body) : "");
+        return text ? JSON.parse(text) : (undefined as T);
+    }
+
+    /** Sends body as is and returns the response body as is, for files */
+    private async requestText(method: string, path: string, body: string): Promise<string> {
         console.log(`[ProxyAPI] ${method} ${path}`);
 
         const proxyRequest = {
@@ -54,9 +83,11 @@ export class ProxyAPIClient {
             port: this.config.port,
             token: this.config.token,
             useTLS: this.config.useTLS,
+            fingerprint: this.config.fingerprint,
+            serverId: this.config.serverId,
             method,
             path,
-            body: body !== undefined ? JSON.stringify(body) : "",
+            body,
         };
 
         const response = await RemoteProxy(proxyRequest);
@@ -81,11 +112,11 @@ export class ProxyAPIClient {
         }
 
         // Handle 204 No Content
-        if (response.statusCode === 204 || !response.body) {
-            return undefined as T;
+        if (response.statusCode === 204) {
+            return "";
         }
 
-        return JSON.parse(response.body);
+        return response.body;
     }
 
     private async get<T>(path: string): Promise<T> {
@@ -207,6 +238,17 @@ export class ProxyAPIClient {
         });
     }
 
+    async importWallets(content: string, format: string, dryRun: boolean): Promise<api.WalletImportReport> {
+        const query = new URLSearchParams({ dry_run: String(dryRun) });
+        if (format) query.set("format", format);
+        const text = await this.requestText("POST", `/api/v1/wallets/import?${query}`, content);
+        return JSON.parse(text).data;
+    }
+
+    async exportWallets(format: string): Promise<string> {
+        return this.requestText("GET", `/api/v1/wallets/export?format=${format}`, "");
+    }
+
     // =========================================================================
     // Asset Endpoints
     // =========================================================================
@@ -290,7 +332,16 @@ export class ProxyAPIClient {
     // NFT Endpoints
     // =========================================================================
 
-    async getNFTsWithAssets(page: number, limit: number, status: string, search: string): Promise<db.NFT[]> {
+    async getNFTsWithAssets(
+        page: number,
+        limit: number,
+        status: string,
+        search: string,
+        creator: string,
+        contract: string,
+        tag: string,
+        attribute: string
+    ): Promise<db.NFT[]> {
         const params = new URLSearchParams({
             page: page.toString(),
             limit: limit.toString(),
@@ -301,8 +352,75 @@ export class ProxyAPIClient {
         if (search) {
             params.append("search", search);
         }
-        const resp = await this.get<{ data: { nfts: db.NFT[] } }>(`/api/v1/nfts?${params.toString()}`);
-        return resp.data?.nfts || [];
+        if (creator) {
+            params.append("creator", creator);
+        }
+        if (contract) {
+            params.append("contract", contract);
+        }
+        if (tag) {
+            params.append("tag", tag);
+        }
+        if (attribute) {
+            params.append("attribute", attribute);
+        }
+        const resp = await this.get<{ data: { nfts: NFTResponse[] } }>(`/api/v1/nfts?${params.toString()}`);
+        // The API names the artist and collection rather than embedding them,
+        // and lists tags as plain strings
+        return (resp.data?.nfts || []).map(
+            (n) =>
+                ({
+                    ...n,
+                    creator_profile: n.creator_name ? { address: n.creator, name: n.creator_name } : undefined,
+                    collection: n.collection_name ? { address: n.contract_address, name: n.collection_name } : undefined,
+                    tags: (n.tags || []).map((tag) => ({ tag })),
+                }) as unknown as db.NFT
+        );
+    }
+
+    async getNFTMetadataHistory(nftId: number): Promise<db.MetadataRevision[]> {
+        const resp = await this.get<{ data: { versions: MetadataVersionResponse[] } }>(
+            `/api/v1/nfts/${nftId}/history`
+        );
+        // The API embeds each version's metadata as JSON rather than a string
+        return (resp.data?.versions || []).map(
+            (v) =>
+                ({
+                    ...v,
+                    nft_id: nftId,
+                    created_at: v.seen_at,
+                    metadata: JSON.stringify(v.metadata),
+                }) as unknown as db.MetadataRevision
+        );
+    }
+
+    // =========================================================================
+    // Creator and Collection Endpoints
+    // =========================================================================
+
+    async getCreators(page: number, limit: number, search: string): Promise<db.CreatorSummary[]> {
+        const params = new URLSearchParams({ page: page.toString(), limit: limit.toString() });
+        if (search) {
+            params.append("search", search);
+        }
+        const resp = await this.get<{ data: { creators: db.CreatorSummary[] } }>(
+            `/api/v1/creators?${params.toString()}`
+        );
+        return resp.data?.creators || [];
+    }
+
+    async getCollections(page: number, limit: number, search: string, creator: string): Promise<db.ContractSummary[]> {
+        const params = new URLSearchParams({ page: page.toString(), limit: limit.toString() });
+        if (search) {
+            params.append("search", search);
+        }
+        if (creator) {
+            params.append("creator", creator);
+        }
+        const resp = await this.get<{ data: { collections: db.ContractSummary[] } }>(
+            `/api/v1/collections?${params.toString()}`
+        );
+        return resp.data?.collections || [];
     }
 
     // =========================================================================
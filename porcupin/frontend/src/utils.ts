@@ -5,3 +5,9 @@ export function formatBytes(bytes: number): string {
     const i = Math.floor(Math.log(bytes) / Math.log(k));
     return parseFloat((bytes / Math.pow(k, i)).toFixed(2)) + " " + sizes[i];
 }
+
+/** Shortens a hex SHA-256 certificate fingerprint for display, e.g. "ab:cd:ef:12…" */
+export function formatFingerprint(fingerprint: string): string {
+    const pairs = fingerprint.slice(0, 16).match(/../g) || [];
+    return pairs.join(":") + "…";
+}
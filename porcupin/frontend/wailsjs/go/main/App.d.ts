@@ -8,40 +8,70 @@ import {main} from '../models';
 import {core} from '../models';
 import {ipfs} from '../models';
 
+export function AddPinningService(arg1:string,arg2:string,arg3:string,arg4:Array<string>):Promise<core.MirrorStatus>;
+
+export function AddReplicationPeer(arg1:string,arg2:main.RemoteServerConfig):Promise<core.ReplicationStatus>;
+
 export function AddWallet(arg1:string,arg2:string):Promise<void>;
 
 export function BrowseForFolder():Promise<string>;
 
+export function CancelJob(arg1:number):Promise<void>;
+
 export function CancelMigration():Promise<void>;
 
 export function ClearFailed():Promise<number>;
 
 export function DeleteAsset(arg1:number):Promise<void>;
 
+export function DeleteRemoteServer(arg1:number):Promise<void>;
+
 export function DeleteWallet(arg1:string,arg2:boolean):Promise<void>;
 
 export function DeleteWalletWithUnpin(arg1:string):Promise<void>;
 
 export function DiscoverServers():Promise<Array<api.DiscoveredServer>>;
 
+export function ExportWallets(arg1:string):Promise<string>;
+
 export function GetAssetGatewayURL(arg1:number):Promise<Record<string, string>>;
 
+export function GetAssetRemotePins(arg1:number):Promise<Array<db.RemotePin>>;
+
 export function GetAssetStats():Promise<Record<string, number>>;
 
 export function GetAssets(arg1:number,arg2:number,arg3:string,arg4:string):Promise<Array<db.Asset>>;
 
+export function GetCollections(arg1:number,arg2:number,arg3:string,arg4:string):Promise<Array<db.ContractSummary>>;
+
 export function GetConfig():Promise<config.Config>;
 
+export function GetCreators(arg1:number,arg2:number,arg3:string):Promise<Array<db.CreatorSummary>>;
+
 export function GetFailedAssets():Promise<Array<db.Asset>>;
 
+export function GetFleetOverview():Promise<api.FleetOverview>;
+
 export function GetIPFSRepoPath():Promise<string>;
 
+export function GetJobs(arg1:string,arg2:number):Promise<Array<db.Job>>;
+
 export function GetMigrationStatus():Promise<storage.MigrationStatus>;
 
-export function GetNFTsWithAssets(arg1:number,arg2:number,arg3:string,arg4:string):Promise<Array<db.NFT>>;
+export function GetNFTMetadataHistory(arg1:number):Promise<Array<db.MetadataRevision>>;
+
+export function GetNFTsWithAssets(arg1:number,arg2:number,arg3:string,arg4:string,arg5:string,arg6:string,arg7:string,arg8:string):Promise<Array<db.NFT>>;
+
+export function GetPinningServices():Promise<Array<core.MirrorStatus>>;
+
+export function GetQueueStatus():Promise<core.QueueStatus>;
 
 export function GetRecentActivity(arg1:number):Promise<Array<db.Asset>>;
 
+export function GetRemoteServers():Promise<Array<main.SavedRemoteServer>>;
+
+export function GetReplicationPeers():Promise<Array<core.ReplicationStatus>>;
+
 export function GetStatus():Promise<Record<string, any>>;
 
 export function GetStorageInfo():Promise<main.StorageInfo>;
@@ -54,22 +84,36 @@ export function GetSyncProgress():Promise<core.ServiceStatus>;
 
 export function GetVersion():Promise<string>;
 
+export function GetWalletUsage():Promise<Record<string, db.WalletUsage>>;
+
 export function GetWallets():Promise<Array<db.Wallet>>;
 
+export function ImportWallets(arg1:string,arg2:string,arg3:boolean):Promise<api.WalletImportReport>;
+
 export function IsBackupPaused():Promise<boolean>;
 
 export function ListStorageLocations():Promise<Array<storage.StorageLocation>>;
 
 export function MigrateStorage(arg1:string):Promise<void>;
 
+export function PairRemoteServer(arg1:main.PairRemoteRequest):Promise<main.RemoteServerConfig>;
+
 export function PauseBackup():Promise<void>;
 
+export function PinAssetNext(arg1:number):Promise<void>;
+
 export function PreviewAsset(arg1:number,arg2:number):Promise<Record<string, any>>;
 
+export function ReconcilePinningService(arg1:number):Promise<db.Job>;
+
 export function RecoverMissingAssets():Promise<Record<string, number>>;
 
 export function RemoteProxy(arg1:main.RemoteProxyRequest):Promise<main.RemoteProxyResponse>;
 
+export function RemovePinningService(arg1:number,arg2:boolean):Promise<void>;
+
+export function RemoveReplicationPeer(arg1:number,arg2:boolean):Promise<void>;
+
 export function RepinAsset(arg1:number):Promise<void>;
 
 export function RepinZeroSizeAssets():Promise<number>;
@@ -78,24 +122,38 @@ export function ResetDatabase():Promise<void>;
 
 export function ResumeBackup():Promise<void>;
 
+export function ResumeJob(arg1:number):Promise<void>;
+
 export function ResyncAsset(arg1:number):Promise<void>;
 
 export function RetryAllFailed():Promise<number>;
 
 export function RetryAsset(arg1:number):Promise<void>;
 
+export function SaveRemoteServer(arg1:main.SavedRemoteServer):Promise<main.SavedRemoteServer>;
+
+export function SaveTextFile(arg1:string,arg2:string):Promise<string>;
+
 export function ShowInFinder():Promise<void>;
 
+export function SyncReplicationPeer(arg1:number,arg2:boolean):Promise<db.Job>;
+
 export function SyncWallet(arg1:string):Promise<void>;
 
 export function TestRemoteConnection(arg1:main.RemoteServerConfig):Promise<main.RemoteHealthResponse>;
 
 export function UnpinAsset(arg1:number):Promise<void>;
 
+export function UpdatePinningService(arg1:number,arg2:Array<string>,arg3:boolean):Promise<core.MirrorStatus>;
+
 export function UpdateSettings(arg1:Record<string, any>):Promise<void>;
 
 export function UpdateWalletAlias(arg1:string,arg2:string):Promise<void>;
 
+export function UpdateWalletPriority(arg1:string,arg2:number):Promise<void>;
+
+export function UpdateWalletQuota(arg1:string,arg2:number):Promise<void>;
+
 export function UpdateWalletSettings(arg1:string,arg2:boolean,arg3:boolean):Promise<void>;
 
 export function ValidateStoragePath(arg1:string):Promise<void>;
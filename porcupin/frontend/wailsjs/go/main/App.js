@@ -2,6 +2,14 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AddPinningService(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['AddPinningService'](arg1, arg2, arg3, arg4);
+}
+
+export function AddReplicationPeer(arg1, arg2) {
+  return window['go']['main']['App']['AddReplicationPeer'](arg1, arg2);
+}
+
 export function AddWallet(arg1, arg2) {
   return window['go']['main']['App']['AddWallet'](arg1, arg2);
 }
@@ -10,6 +18,10 @@ export function BrowseForFolder() {
   return window['go']['main']['App']['BrowseForFolder']();
 }
 
+export function CancelJob(arg1) {
+  return window['go']['main']['App']['CancelJob'](arg1);
+}
+
 export function CancelMigration() {
   return window['go']['main']['App']['CancelMigration']();
 }
@@ -22,6 +34,10 @@ export function DeleteAsset(arg1) {
   return window['go']['main']['App']['DeleteAsset'](arg1);
 }
 
+export function DeleteRemoteServer(arg1) {
+  return window['go']['main']['App']['DeleteRemoteServer'](arg1);
+}
+
 export function DeleteWallet(arg1, arg2) {
   return window['go']['main']['App']['DeleteWallet'](arg1, arg2);
 }
@@ -34,10 +50,18 @@ export function DiscoverServers() {
   return window['go']['main']['App']['DiscoverServers']();
 }
 
+export function ExportWallets(arg1) {
+  return window['go']['main']['App']['ExportWallets'](arg1);
+}
+
 export function GetAssetGatewayURL(arg1) {
   return window['go']['main']['App']['GetAssetGatewayURL'](arg1);
 }
 
+export function GetAssetRemotePins(arg1) {
+  return window['go']['main']['App']['GetAssetRemotePins'](arg1);
+}
+
 export function GetAssetStats() {
   return window['go']['main']['App']['GetAssetStats']();
 }
@@ -46,30 +70,66 @@ export function GetAssets(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['GetAssets'](arg1, arg2, arg3, arg4);
 }
 
+export function GetCollections(arg1,arg2,arg3,arg4) {
+  return window['go']['main']['App']['GetCollections'](arg1,arg2,arg3,arg4);
+}
+
 export function GetConfig() {
   return window['go']['main']['App']['GetConfig']();
 }
 
+export function GetCreators(arg1,arg2,arg3) {
+  return window['go']['main']['App']['GetCreators'](arg1,arg2,arg3);
+}
+
 export function GetFailedAssets() {
   return window['go']['main']['App']['GetFailedAssets']();
 }
 
+export function GetFleetOverview() {
+  return window['go']['main']['App']['GetFleetOverview']();
+}
+
 export function GetIPFSRepoPath() {
   return window['go']['main']['App']['GetIPFSRepoPath']();
 }
 
+export function GetJobs(arg1,arg2) {
+  return window['go']['main']['App']['GetJobs'](arg1,arg2);
+}
+
 export function GetMigrationStatus() {
   return window['go']['main']['App']['GetMigrationStatus']();
 }
 
-export function GetNFTsWithAssets(arg1, arg2, arg3, arg4) {
-  return window['go']['main']['App']['GetNFTsWithAssets'](arg1, arg2, arg3, arg4);
+export function GetNFTMetadataHistory(arg1) {
+  return window['go']['main']['App']['GetNFTMetadataHistory'](arg1);
+}
+
+export function GetNFTsWithAssets(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8) {
+  return window['go']['main']['App']['GetNFTsWithAssets'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8);
+}
+
+export function GetPinningServices() {
+  return window['go']['main']['App']['GetPinningServices']();
+}
+
+export function GetQueueStatus() {
+  return window['go']['main']['App']['GetQueueStatus']();
 }
 
 export function GetRecentActivity(arg1) {
   return window['go']['main']['App']['GetRecentActivity'](arg1);
 }
 
+export function GetRemoteServers() {
+  return window['go']['main']['App']['GetRemoteServers']();
+}
+
+export function GetReplicationPeers() {
+  return window['go']['main']['App']['GetReplicationPeers']();
+}
+
 export function GetStatus() {
   return window['go']['main']['App']['GetStatus']();
 }
@@ -94,10 +154,18 @@ export function GetVersion() {
   return window['go']['main']['App']['GetVersion']();
 }
 
+export function GetWalletUsage() {
+  return window['go']['main']['App']['GetWalletUsage']();
+}
+
 export function GetWallets() {
   return window['go']['main']['App']['GetWallets']();
 }
 
+export function ImportWallets(arg1,arg2,arg3) {
+  return window['go']['main']['App']['ImportWallets'](arg1,arg2,arg3);
+}
+
 export function IsBackupPaused() {
   return window['go']['main']['App']['IsBackupPaused']();
 }
@@ -110,14 +178,26 @@ export function MigrateStorage(arg1) {
   return window['go']['main']['App']['MigrateStorage'](arg1);
 }
 
+export function PairRemoteServer(arg1) {
+  return window['go']['main']['App']['PairRemoteServer'](arg1);
+}
+
 export function PauseBackup() {
   return window['go']['main']['App']['PauseBackup']();
 }
 
+export function PinAssetNext(arg1) {
+  return window['go']['main']['App']['PinAssetNext'](arg1);
+}
+
 export function PreviewAsset(arg1, arg2) {
   return window['go']['main']['App']['PreviewAsset'](arg1, arg2);
 }
 
+export function ReconcilePinningService(arg1) {
+  return window['go']['main']['App']['ReconcilePinningService'](arg1);
+}
+
 export function RecoverMissingAssets() {
   return window['go']['main']['App']['RecoverMissingAssets']();
 }
@@ -126,6 +206,14 @@ export function RemoteProxy(arg1) {
   return window['go']['main']['App']['RemoteProxy'](arg1);
 }
 
+export function RemovePinningService(arg1, arg2) {
+  return window['go']['main']['App']['RemovePinningService'](arg1, arg2);
+}
+
+export function RemoveReplicationPeer(arg1, arg2) {
+  return window['go']['main']['App']['RemoveReplicationPeer'](arg1, arg2);
+}
+
 export function RepinAsset(arg1) {
   return window['go']['main']['App']['RepinAsset'](arg1);
 }
@@ -142,6 +230,10 @@ export function ResumeBackup() {
   return window['go']['main']['App']['ResumeBackup']();
 }
 
+export function ResumeJob(arg1) {
+  return window['go']['main']['App']['ResumeJob'](arg1);
+}
+
 export function ResyncAsset(arg1) {
   return window['go']['main']['App']['ResyncAsset'](arg1);
 }
@@ -154,10 +246,22 @@ export function RetryAsset(arg1) {
   return window['go']['main']['App']['RetryAsset'](arg1);
 }
 
+export function SaveRemoteServer(arg1) {
+  return window['go']['main']['App']['SaveRemoteServer'](arg1);
+}
+
+export function SaveTextFile(arg1,arg2) {
+  return window['go']['main']['App']['SaveTextFile'](arg1,arg2);
+}
+
 export function ShowInFinder() {
   return window['go']['main']['App']['ShowInFinder']();
 }
 
+export function SyncReplicationPeer(arg1, arg2) {
+  return window['go']['main']['App']['SyncReplicationPeer'](arg1, arg2);
+}
+
 export function SyncWallet(arg1) {
   return window['go']['main']['App']['SyncWallet'](arg1);
 }
@@ -170,6 +274,10 @@ export function UnpinAsset(arg1) {
   return window['go']['main']['App']['UnpinAsset'](arg1);
 }
 
+export function UpdatePinningService(arg1, arg2, arg3) {
+  return window['go']['main']['App']['UpdatePinningService'](arg1, arg2, arg3);
+}
+
 export function UpdateSettings(arg1) {
   return window['go']['main']['App']['UpdateSettings'](arg1);
 }
@@ -178,6 +286,14 @@ export function UpdateWalletAlias(arg1, arg2) {
   return window['go']['main']['App']['UpdateWalletAlias'](arg1, arg2);
 }
 
+export function UpdateWalletPriority(arg1, arg2) {
+  return window['go']['main']['App']['UpdateWalletPriority'](arg1, arg2);
+}
+
+export function UpdateWalletQuota(arg1, arg2) {
+  return window['go']['main']['App']['UpdateWalletQuota'](arg1, arg2);
+}
+
 export function UpdateWalletSettings(arg1, arg2, arg3) {
   return window['go']['main']['App']['UpdateWalletSettings'](arg1, arg2, arg3);
 }
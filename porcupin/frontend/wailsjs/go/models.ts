@@ -1,5 +1,37 @@
 export namespace api {
 	
+	export class AssetResponse {
+	    id: number;
+	    uri: string;
+	    type: string;
+	    mime_type?: string;
+	    status: string;
+	    error_msg?: string;
+	    size_bytes?: number;
+	    pinned_at?: string;
+	    nft_id: number;
+	    tier?: string;
+	    wallet?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AssetResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.uri = source["uri"];
+	        this.type = source["type"];
+	        this.mime_type = source["mime_type"];
+	        this.status = source["status"];
+	        this.error_msg = source["error_msg"];
+	        this.size_bytes = source["size_bytes"];
+	        this.pinned_at = source["pinned_at"];
+	        this.nft_id = source["nft_id"];
+	        this.tier = source["tier"];
+	        this.wallet = source["wallet"];
+	    }
+	}
 	export class DiscoveredServer {
 	    name: string;
 	    host: string;
@@ -7,6 +39,7 @@ export namespace api {
 	    version: string;
 	    useTLS: boolean;
 	    ips: string[];
+	    fingerprint?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new DiscoveredServer(source);
@@ -20,9 +53,291 @@ export namespace api {
 	        this.version = source["version"];
 	        this.useTLS = source["useTLS"];
 	        this.ips = source["ips"];
+	        this.fingerprint = source["fingerprint"];
+	    }
+	}
+
+	export class FleetFailedAsset {
+	    node_id: number;
+	    node_name: string;
+	    id: number;
+	    uri: string;
+	    type: string;
+	    mime_type?: string;
+	    status: string;
+	    error_msg?: string;
+	    size_bytes?: number;
+	    pinned_at?: string;
+	    nft_id: number;
+	    tier?: string;
+	    wallet?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FleetFailedAsset(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.node_id = source["node_id"];
+	        this.node_name = source["node_name"];
+	        this.id = source["id"];
+	        this.uri = source["uri"];
+	        this.type = source["type"];
+	        this.mime_type = source["mime_type"];
+	        this.status = source["status"];
+	        this.error_msg = source["error_msg"];
+	        this.size_bytes = source["size_bytes"];
+	        this.pinned_at = source["pinned_at"];
+	        this.nft_id = source["nft_id"];
+	        this.tier = source["tier"];
+	        this.wallet = source["wallet"];
+	    }
+	}
+	export class WalletUsageResponse {
+	    address: string;
+	    alias?: string;
+	    asset_count: number;
+	    used_gb: number;
+	    shared_gb: number;
+	    quota_gb: number;
+	    over_quota: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new WalletUsageResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.address = source["address"];
+	        this.alias = source["alias"];
+	        this.asset_count = source["asset_count"];
+	        this.used_gb = source["used_gb"];
+	        this.shared_gb = source["shared_gb"];
+	        this.quota_gb = source["quota_gb"];
+	        this.over_quota = source["over_quota"];
+	    }
+	}
+	export class StatsResponse {
+	    total_nfts: number;
+	    total_assets: number;
+	    pinned_assets: number;
+	    pending_assets: number;
+	    failed_assets: number;
+	    storage_used_gb: number;
+	    wallets_count: number;
+	    service_state: string;
+	    last_sync_at?: string;
+	    pinned_gb: number;
+	    wallet_usage: WalletUsageResponse[];
+	
+	    static createFrom(source: any = {}) {
+	        return new StatsResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.total_nfts = source["total_nfts"];
+	        this.total_assets = source["total_assets"];
+	        this.pinned_assets = source["pinned_assets"];
+	        this.pending_assets = source["pending_assets"];
+	        this.failed_assets = source["failed_assets"];
+	        this.storage_used_gb = source["storage_used_gb"];
+	        this.wallets_count = source["wallets_count"];
+	        this.service_state = source["service_state"];
+	        this.last_sync_at = source["last_sync_at"];
+	        this.pinned_gb = source["pinned_gb"];
+	        this.wallet_usage = this.convertValues(source["wallet_usage"], WalletUsageResponse);
+	    }
+
+	convertValues(a: any, classs: any, asMap: boolean = false): any {
+	    if (!a) {
+	        return a;
+	    }
+	    if (a.slice && a.map) {
+	        return (a as any[]).map(elem => this.convertValues(elem, classs));
+	    } else if ("object" === typeof a) {
+	        if (asMap) {
+	            for (const key of Object.keys(a)) {
+	                a[key] = new classs(a[key]);
+	            }
+	            return a;
+	        }
+	        return new classs(a);
+	    }
+	    return a;
+	}
+	}
+	export class FleetNode {
+	    id: number;
+	    name: string;
+	    address: string;
+	    reachable: boolean;
+	    error?: string;
+	    version?: string;
+	    status?: core.ServiceStatus;
+	    stats?: StatsResponse;
+	
+	    static createFrom(source: any = {}) {
+	        return new FleetNode(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.address = source["address"];
+	        this.reachable = source["reachable"];
+	        this.error = source["error"];
+	        this.version = source["version"];
+	        this.status = this.convertValues(source["status"], core.ServiceStatus);
+	        this.stats = this.convertValues(source["stats"], StatsResponse);
+	    }
+
+	convertValues(a: any, classs: any, asMap: boolean = false): any {
+	    if (!a) {
+	        return a;
+	    }
+	    if (a.slice && a.map) {
+	        return (a as any[]).map(elem => this.convertValues(elem, classs));
+	    } else if ("object" === typeof a) {
+	        if (asMap) {
+	            for (const key of Object.keys(a)) {
+	                a[key] = new classs(a[key]);
+	            }
+	            return a;
+	        }
+	        return new classs(a);
+	    }
+	    return a;
+	}
+	}
+	export class FleetTotals {
+	    nodes: number;
+	    reachable: number;
+	    unreachable: number;
+	    errors: number;
+	    total_nfts: number;
+	    total_assets: number;
+	    pinned_assets: number;
+	    pending_assets: number;
+	    failed_assets: number;
+	    storage_used_gb: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FleetTotals(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.nodes = source["nodes"];
+	        this.reachable = source["reachable"];
+	        this.unreachable = source["unreachable"];
+	        this.errors = source["errors"];
+	        this.total_nfts = source["total_nfts"];
+	        this.total_assets = source["total_assets"];
+	        this.pinned_assets = source["pinned_assets"];
+	        this.pending_assets = source["pending_assets"];
+	        this.failed_assets = source["failed_assets"];
+	        this.storage_used_gb = source["storage_used_gb"];
+	    }
+	}
+	export class FleetOverview {
+	    nodes: FleetNode[];
+	    totals: FleetTotals;
+	    failed_assets: FleetFailedAsset[];
+	    // Go type: time
+	    checked_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new FleetOverview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.nodes = this.convertValues(source["nodes"], FleetNode);
+	        this.totals = this.convertValues(source["totals"], FleetTotals);
+	        this.failed_assets = this.convertValues(source["failed_assets"], FleetFailedAsset);
+	        this.checked_at = this.convertValues(source["checked_at"], null);
+	    }
+
+	convertValues(a: any, classs: any, asMap: boolean = false): any {
+	    if (!a) {
+	        return a;
+	    }
+	    if (a.slice && a.map) {
+	        return (a as any[]).map(elem => this.convertValues(elem, classs));
+	    } else if ("object" === typeof a) {
+	        if (asMap) {
+	            for (const key of Object.keys(a)) {
+	                a[key] = new classs(a[key]);
+	            }
+	            return a;
+	        }
+	        return new classs(a);
+	    }
+	    return a;
+	}
+	}
+	export class WalletImportResult {
+	    line: number;
+	    address: string;
+	    alias?: string;
+	    status: string;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new WalletImportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.line = source["line"];
+	        this.address = source["address"];
+	        this.alias = source["alias"];
+	        this.status = source["status"];
+	        this.error = source["error"];
 	    }
 	}
+	export class WalletImportReport {
+	    dry_run: boolean;
+	    added: number;
+	    existing: number;
+	    duplicates: number;
+	    invalid: number;
+	    results: WalletImportResult[];
+	
+	    static createFrom(source: any = {}) {
+	        return new WalletImportReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.dry_run = source["dry_run"];
+	        this.added = source["added"];
+	        this.existing = source["existing"];
+	        this.duplicates = source["duplicates"];
+	        this.invalid = source["invalid"];
+	        this.results = this.convertValues(source["results"], WalletImportResult);
+	    }
 
+	convertValues(a: any, classs: any, asMap: boolean = false): any {
+	    if (!a) {
+	        return a;
+	    }
+	    if (a.slice && a.map) {
+	        return (a as any[]).map(elem => this.convertValues(elem, classs));
+	    } else if ("object" === typeof a) {
+	        if (asMap) {
+	            for (const key of Object.keys(a)) {
+	                a[key] = new classs(a[key]);
+	            }
+	            return a;
+	        }
+	        return new classs(a);
+	    }
+	    return a;
+	}
+	}
 }
 
 export namespace config {
@@ -98,6 +413,24 @@ export namespace config {
 		}
 	}
 	
+	export class QueueConfig {
+	    wallet_priority_weight: number;
+	    asset_type_weight: number;
+	    size_weight: number;
+	    age_weight: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new QueueConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.wallet_priority_weight = source["wallet_priority_weight"];
+	        this.asset_type_weight = source["asset_type_weight"];
+	        this.size_weight = source["size_weight"];
+	        this.age_weight = source["age_weight"];
+	    }
+	}
 	export class BackupConfig {
 	    max_concurrency: number;
 	    min_free_disk_space_gb: number;
@@ -106,6 +439,7 @@ export namespace config {
 	    storage_warning_pct: number;
 	    sync_owned: boolean;
 	    sync_created: boolean;
+	    queue: QueueConfig;
 	
 	    static createFrom(source: any = {}) {
 	        return new BackupConfig(source);
@@ -120,10 +454,30 @@ export namespace config {
 	        this.storage_warning_pct = source["storage_warning_pct"];
 	        this.sync_owned = source["sync_owned"];
 	        this.sync_created = source["sync_created"];
+	        this.queue = this.convertValues(source["queue"], QueueConfig);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class TZKTConfig {
 	    BaseURL: string;
+	    domain_refresh_interval: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new TZKTConfig(source);
@@ -132,6 +486,7 @@ export namespace config {
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.BaseURL = source["BaseURL"];
+	        this.domain_refresh_interval = source["domain_refresh_interval"];
 	    }
 	}
 	export class ServerConfig {
@@ -152,12 +507,33 @@ export namespace config {
 	        this.AuthPass = source["AuthPass"];
 	    }
 	}
+	export class TieringConfig {
+	    cold_path: string;
+	    cold_types: string[];
+	    min_size: number;
+	    min_age: number;
+	    interval: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TieringConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.cold_path = source["cold_path"];
+	        this.cold_types = source["cold_types"];
+	        this.min_size = source["min_size"];
+	        this.min_age = source["min_age"];
+	        this.interval = source["interval"];
+	    }
+	}
 	export class IPFSConfig {
 	    repo_path: string;
 	    swarm_port: number;
 	    max_file_size: number;
 	    pin_timeout: number;
 	    rate_limit_mbps: number;
+	    tiering: TieringConfig;
 	
 	    static createFrom(source: any = {}) {
 	        return new IPFSConfig(source);
@@ -170,6 +546,55 @@ export namespace config {
 	        this.max_file_size = source["max_file_size"];
 	        this.pin_timeout = source["pin_timeout"];
 	        this.rate_limit_mbps = source["rate_limit_mbps"];
+	        this.tiering = this.convertValues(source["tiering"], TieringConfig);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ReplicationConfig {
+	    sync_interval: number;
+	    full_sync_interval: number;
+	    max_retries: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReplicationConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sync_interval = source["sync_interval"];
+	        this.full_sync_interval = source["full_sync_interval"];
+	        this.max_retries = source["max_retries"];
+	    }
+	}
+	export class MirrorConfig {
+	    reconcile_interval: number;
+	    max_retries: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MirrorConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.reconcile_interval = source["reconcile_interval"];
+	        this.max_retries = source["max_retries"];
 	    }
 	}
 	export class Config {
@@ -178,18 +603,797 @@ export namespace config {
 	    Backup: BackupConfig;
 	    TZKT: TZKTConfig;
 	    API: APIConfig;
+	    Replication: ReplicationConfig;
+	    Mirror: MirrorConfig;
+	
+	    static createFrom(source: any = {}) {
+	        return new Config(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.IPFS = this.convertValues(source["IPFS"], IPFSConfig);
+	        this.Server = this.convertValues(source["Server"], ServerConfig);
+	        this.Backup = this.convertValues(source["Backup"], BackupConfig);
+	        this.TZKT = this.convertValues(source["TZKT"], TZKTConfig);
+	        this.API = this.convertValues(source["API"], APIConfig);
+	        this.Replication = this.convertValues(source["Replication"], ReplicationConfig);
+	        this.Mirror = this.convertValues(source["Mirror"], MirrorConfig);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	
+
+}
+
+export namespace core {
+	
+	export class ServiceStatus {
+	    state: string;
+	    message: string;
+	    is_paused: boolean;
+	    current_wallet: string;
+	    wallets_total: number;
+	    wallets_synced: number;
+	    total_nfts: number;
+	    processed_nfts: number;
+	    total_assets: number;
+	    pinned_assets: number;
+	    failed_assets: number;
+	    pending_retries: number;
+	    queued_assets: number;
+	    current_item: string;
+	    // Go type: time
+	    last_sync_at?: any;
+	    storage_error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ServiceStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.state = source["state"];
+	        this.message = source["message"];
+	        this.is_paused = source["is_paused"];
+	        this.current_wallet = source["current_wallet"];
+	        this.wallets_total = source["wallets_total"];
+	        this.wallets_synced = source["wallets_synced"];
+	        this.total_nfts = source["total_nfts"];
+	        this.processed_nfts = source["processed_nfts"];
+	        this.total_assets = source["total_assets"];
+	        this.pinned_assets = source["pinned_assets"];
+	        this.failed_assets = source["failed_assets"];
+	        this.pending_retries = source["pending_retries"];
+	        this.queued_assets = source["queued_assets"];
+	        this.current_item = source["current_item"];
+	        this.last_sync_at = this.convertValues(source["last_sync_at"], null);
+	        this.storage_error = source["storage_error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PinTask {
+	    asset_id: number;
+	    uri: string;
+	    type: string;
+	    wallet: string;
+	    size_bytes: number;
+	    // Go type: time
+	    created_at: any;
+	    bumped: boolean;
+	    score: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PinTask(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.asset_id = source["asset_id"];
+	        this.uri = source["uri"];
+	        this.type = source["type"];
+	        this.wallet = source["wallet"];
+	        this.size_bytes = source["size_bytes"];
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.bumped = source["bumped"];
+	        this.score = source["score"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class WalletQueueStatus {
+	    address: string;
+	    priority: number;
+	    queued: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new WalletQueueStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.address = source["address"];
+	        this.priority = source["priority"];
+	        this.queued = source["queued"];
+	    }
+	}
+	export class QueueStatus {
+	    queued: number;
+	    in_flight: number;
+	    bumped: PinTask[];
+	    wallets: WalletQueueStatus[];
+	
+	    static createFrom(source: any = {}) {
+	        return new QueueStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.queued = source["queued"];
+	        this.in_flight = source["in_flight"];
+	        this.bumped = this.convertValues(source["bumped"], PinTask);
+	        this.wallets = this.convertValues(source["wallets"], WalletQueueStatus);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ReplicationStatus {
+	    peer: db.ReplicationPeer;
+	    counts: db.ReplicaCounts;
+	    syncing: boolean;
+	    lag_seconds: number;
+	    missing: number;
+	    divergence: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReplicationStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.peer = this.convertValues(source["peer"], db.ReplicationPeer);
+	        this.counts = this.convertValues(source["counts"], db.ReplicaCounts);
+	        this.syncing = source["syncing"];
+	        this.lag_seconds = source["lag_seconds"];
+	        this.missing = source["missing"];
+	        this.divergence = source["divergence"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MirrorStatus {
+	    service: db.PinningService;
+	    wallets: string[];
+	    counts: db.RemotePinCounts;
+	    reconciling: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new MirrorStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.service = this.convertValues(source["service"], db.PinningService);
+	        this.wallets = source["wallets"];
+	        this.counts = this.convertValues(source["counts"], db.RemotePinCounts);
+	        this.reconciling = source["reconciling"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace db {
+	
+	export class NFT {
+	    id: number;
+	    token_id: string;
+	    contract_address: string;
+	    wallet_address: string;
+	    name: string;
+	    description: string;
+	    creator: string;
+	    artifact_uri: string;
+	    display_uri: string;
+	    thumbnail_uri: string;
+	    raw_metadata: string;
+	    symbol?: string;
+	    type?: string;
+	    language?: string;
+	    rights?: string;
+	    rights_uri?: string;
+	    external_uri?: string;
+	    // Go type: time
+	    date?: any;
+	    metadata_version: number;
+	    assets?: Asset[];
+	    creator_profile?: Creator;
+	    collection?: Contract;
+	    tags?: NFTTag[];
+	    attributes?: NFTAttribute[];
+	    royalties?: NFTRoyalty[];
+	    contributors?: NFTContributor[];
+	    highlight?: Highlight;
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    updated_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new NFT(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.token_id = source["token_id"];
+	        this.contract_address = source["contract_address"];
+	        this.wallet_address = source["wallet_address"];
+	        this.name = source["name"];
+	        this.description = source["description"];
+	        this.creator = source["creator"];
+	        this.artifact_uri = source["artifact_uri"];
+	        this.display_uri = source["display_uri"];
+	        this.thumbnail_uri = source["thumbnail_uri"];
+	        this.raw_metadata = source["raw_metadata"];
+	        this.symbol = source["symbol"];
+	        this.type = source["type"];
+	        this.language = source["language"];
+	        this.rights = source["rights"];
+	        this.rights_uri = source["rights_uri"];
+	        this.external_uri = source["external_uri"];
+	        this.date = this.convertValues(source["date"], null);
+	        this.metadata_version = source["metadata_version"];
+	        this.assets = this.convertValues(source["assets"], Asset);
+	        this.creator_profile = this.convertValues(source["creator_profile"], Creator);
+	        this.collection = this.convertValues(source["collection"], Contract);
+	        this.tags = this.convertValues(source["tags"], NFTTag);
+	        this.attributes = this.convertValues(source["attributes"], NFTAttribute);
+	        this.royalties = this.convertValues(source["royalties"], NFTRoyalty);
+	        this.contributors = this.convertValues(source["contributors"], NFTContributor);
+	        this.highlight = this.convertValues(source["highlight"], Highlight);
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.updated_at = this.convertValues(source["updated_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Asset {
+	    id: number;
+	    uri: string;
+	    nft_id: number;
+	    nft?: NFT;
+	    type: string;
+	    mime_type: string;
+	    status: string;
+	    error_msg: string;
+	    size_bytes: number;
+	    retry_count: number;
+	    tier: string;
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    pinned_at?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Asset(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.uri = source["uri"];
+	        this.nft_id = source["nft_id"];
+	        this.nft = this.convertValues(source["nft"], NFT);
+	        this.type = source["type"];
+	        this.mime_type = source["mime_type"];
+	        this.status = source["status"];
+	        this.error_msg = source["error_msg"];
+	        this.size_bytes = source["size_bytes"];
+	        this.retry_count = source["retry_count"];
+	        this.tier = source["tier"];
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.pinned_at = this.convertValues(source["pinned_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class Wallet {
+	    address: string;
+	    alias: string;
+	    domain: string;
+	    type: string;
+	    sync_owned: boolean;
+	    sync_created: boolean;
+	    priority: number;
+	    quota_gb: number;
+	    // Go type: time
+	    last_synced_at?: any;
+	    last_synced_level: number;
+	    // Go type: time
+	    last_updated: any;
+	    nfts?: NFT[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Wallet(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.address = source["address"];
+	        this.alias = source["alias"];
+	        this.domain = source["domain"];
+	        this.type = source["type"];
+	        this.sync_owned = source["sync_owned"];
+	        this.sync_created = source["sync_created"];
+	        this.priority = source["priority"];
+	        this.quota_gb = source["quota_gb"];
+	        this.last_synced_at = this.convertValues(source["last_synced_at"], null);
+	        this.last_synced_level = source["last_synced_level"];
+	        this.last_updated = this.convertValues(source["last_updated"], null);
+	        this.nfts = this.convertValues(source["nfts"], NFT);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Job {
+	    id: number;
+	    type: string;
+	    status: string;
+	    params: string;
+	    checkpoint: string;
+	    current: number;
+	    total: number;
+	    message: string;
+	    error?: string;
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    updated_at: any;
+	    // Go type: time
+	    started_at?: any;
+	    // Go type: time
+	    finished_at?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Job(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.type = source["type"];
+	        this.status = source["status"];
+	        this.params = source["params"];
+	        this.checkpoint = source["checkpoint"];
+	        this.current = source["current"];
+	        this.total = source["total"];
+	        this.message = source["message"];
+	        this.error = source["error"];
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.updated_at = this.convertValues(source["updated_at"], null);
+	        this.started_at = this.convertValues(source["started_at"], null);
+	        this.finished_at = this.convertValues(source["finished_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class WalletUsage {
+	    address: string;
+	    asset_count: number;
+	    used_bytes: number;
+	    shared_bytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new WalletUsage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.address = source["address"];
+	        this.asset_count = source["asset_count"];
+	        this.used_bytes = source["used_bytes"];
+	        this.shared_bytes = source["shared_bytes"];
+	    }
+	}
+	export class ReplicaCounts {
+	    pending: number;
+	    pinned: number;
+	    failed: number;
+	    removed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReplicaCounts(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pending = source["pending"];
+	        this.pinned = source["pinned"];
+	        this.failed = source["failed"];
+	        this.removed = source["removed"];
+	    }
+	}
+	export class ReplicationPeer {
+	    id: number;
+	    name: string;
+	    host: string;
+	    port: number;
+	    use_tls: boolean;
+	    tls_pin?: string;
+	    enabled: boolean;
+	    node_id: string;
+	    node_addrs: string;
+	    // Go type: time
+	    cursor: any;
+	    remote_size: number;
+	    // Go type: time
+	    last_sync_at: any;
+	    // Go type: time
+	    last_full_sync_at: any;
+	    last_error?: string;
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    updated_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReplicationPeer(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.host = source["host"];
+	        this.port = source["port"];
+	        this.use_tls = source["use_tls"];
+	        this.tls_pin = source["tls_pin"];
+	        this.enabled = source["enabled"];
+	        this.node_id = source["node_id"];
+	        this.node_addrs = source["node_addrs"];
+	        this.cursor = this.convertValues(source["cursor"], null);
+	        this.remote_size = source["remote_size"];
+	        this.last_sync_at = this.convertValues(source["last_sync_at"], null);
+	        this.last_full_sync_at = this.convertValues(source["last_full_sync_at"], null);
+	        this.last_error = source["last_error"];
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.updated_at = this.convertValues(source["updated_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RemotePinCounts {
+	    queued: number;
+	    pinning: number;
+	    pinned: number;
+	    failed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RemotePinCounts(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.queued = source["queued"];
+	        this.pinning = source["pinning"];
+	        this.pinned = source["pinned"];
+	        this.failed = source["failed"];
+	    }
+	}
+	export class PinningService {
+	    id: number;
+	    name: string;
+	    endpoint: string;
+	    enabled: boolean;
+	    // Go type: time
+	    last_sync_at: any;
+	    last_error?: string;
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    updated_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new PinningService(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.endpoint = source["endpoint"];
+	        this.enabled = source["enabled"];
+	        this.last_sync_at = this.convertValues(source["last_sync_at"], null);
+	        this.last_error = source["last_error"];
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.updated_at = this.convertValues(source["updated_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RemotePin {
+	    id: number;
+	    service_id: number;
+	    cid: string;
+	    asset_id: number;
+	    request_id: string;
+	    status: string;
+	    error_msg: string;
+	    retry_count: number;
+	    // Go type: time
+	    checked_at: any;
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    updated_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new RemotePin(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.service_id = source["service_id"];
+	        this.cid = source["cid"];
+	        this.asset_id = source["asset_id"];
+	        this.request_id = source["request_id"];
+	        this.status = source["status"];
+	        this.error_msg = source["error_msg"];
+	        this.retry_count = source["retry_count"];
+	        this.checked_at = this.convertValues(source["checked_at"], null);
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.updated_at = this.convertValues(source["updated_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Creator {
+	    address: string;
+	    alias: string;
+	    name: string;
+	    description: string;
+	    website: string;
+	    twitter: string;
+	    logo: string;
+	    // Go type: time
+	    profile_fetched_at?: any;
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    updated_at: any;
 	
 	    static createFrom(source: any = {}) {
-	        return new Config(source);
+	        return new Creator(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.IPFS = this.convertValues(source["IPFS"], IPFSConfig);
-	        this.Server = this.convertValues(source["Server"], ServerConfig);
-	        this.Backup = this.convertValues(source["Backup"], BackupConfig);
-	        this.TZKT = this.convertValues(source["TZKT"], TZKTConfig);
-	        this.API = this.convertValues(source["API"], APIConfig);
+	        this.address = source["address"];
+	        this.alias = source["alias"];
+	        this.name = source["name"];
+	        this.description = source["description"];
+	        this.website = source["website"];
+	        this.twitter = source["twitter"];
+	        this.logo = source["logo"];
+	        this.profile_fetched_at = this.convertValues(source["profile_fetched_at"], null);
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.updated_at = this.convertValues(source["updated_at"], null);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -210,51 +1414,35 @@ export namespace config {
 		    return a;
 		}
 	}
-	
-	
-	
-
-}
-
-export namespace core {
-	
-	export class ServiceStatus {
-	    state: string;
-	    message: string;
-	    is_paused: boolean;
-	    current_wallet: string;
-	    wallets_total: number;
-	    wallets_synced: number;
-	    total_nfts: number;
-	    processed_nfts: number;
-	    total_assets: number;
-	    pinned_assets: number;
-	    failed_assets: number;
-	    pending_retries: number;
-	    current_item: string;
+	export class Contract {
+	    address: string;
+	    alias: string;
+	    name: string;
+	    description: string;
+	    website: string;
+	    creator: string;
 	    // Go type: time
-	    last_sync_at?: any;
+	    metadata_fetched_at?: any;
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    updated_at: any;
 	
 	    static createFrom(source: any = {}) {
-	        return new ServiceStatus(source);
+	        return new Contract(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.state = source["state"];
-	        this.message = source["message"];
-	        this.is_paused = source["is_paused"];
-	        this.current_wallet = source["current_wallet"];
-	        this.wallets_total = source["wallets_total"];
-	        this.wallets_synced = source["wallets_synced"];
-	        this.total_nfts = source["total_nfts"];
-	        this.processed_nfts = source["processed_nfts"];
-	        this.total_assets = source["total_assets"];
-	        this.pinned_assets = source["pinned_assets"];
-	        this.failed_assets = source["failed_assets"];
-	        this.pending_retries = source["pending_retries"];
-	        this.current_item = source["current_item"];
-	        this.last_sync_at = this.convertValues(source["last_sync_at"], null);
+	        this.address = source["address"];
+	        this.alias = source["alias"];
+	        this.name = source["name"];
+	        this.description = source["description"];
+	        this.website = source["website"];
+	        this.creator = source["creator"];
+	        this.metadata_fetched_at = this.convertValues(source["metadata_fetched_at"], null);
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.updated_at = this.convertValues(source["updated_at"], null);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -275,49 +1463,39 @@ export namespace core {
 		    return a;
 		}
 	}
-
-}
-
-export namespace db {
-	
-	export class NFT {
-	    id: number;
-	    token_id: string;
-	    contract_address: string;
-	    wallet_address: string;
+	export class CreatorSummary {
+	    address: string;
+	    alias: string;
 	    name: string;
 	    description: string;
-	    creator: string;
-	    artifact_uri: string;
-	    display_uri: string;
-	    thumbnail_uri: string;
-	    raw_metadata: string;
-	    assets?: Asset[];
+	    website: string;
+	    twitter: string;
+	    logo: string;
+	    // Go type: time
+	    profile_fetched_at?: any;
 	    // Go type: time
 	    created_at: any;
 	    // Go type: time
 	    updated_at: any;
+	    nft_count: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new NFT(source);
+	        return new CreatorSummary(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.id = source["id"];
-	        this.token_id = source["token_id"];
-	        this.contract_address = source["contract_address"];
-	        this.wallet_address = source["wallet_address"];
+	        this.address = source["address"];
+	        this.alias = source["alias"];
 	        this.name = source["name"];
 	        this.description = source["description"];
-	        this.creator = source["creator"];
-	        this.artifact_uri = source["artifact_uri"];
-	        this.display_uri = source["display_uri"];
-	        this.thumbnail_uri = source["thumbnail_uri"];
-	        this.raw_metadata = source["raw_metadata"];
-	        this.assets = this.convertValues(source["assets"], Asset);
+	        this.website = source["website"];
+	        this.twitter = source["twitter"];
+	        this.logo = source["logo"];
+	        this.profile_fetched_at = this.convertValues(source["profile_fetched_at"], null);
 	        this.created_at = this.convertValues(source["created_at"], null);
 	        this.updated_at = this.convertValues(source["updated_at"], null);
+	        this.nft_count = source["nft_count"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -338,40 +1516,37 @@ export namespace db {
 		    return a;
 		}
 	}
-	export class Asset {
-	    id: number;
-	    uri: string;
-	    nft_id: number;
-	    nft?: NFT;
-	    type: string;
-	    mime_type: string;
-	    status: string;
-	    error_msg: string;
-	    size_bytes: number;
-	    retry_count: number;
+	export class ContractSummary {
+	    address: string;
+	    alias: string;
+	    name: string;
+	    description: string;
+	    website: string;
+	    creator: string;
+	    // Go type: time
+	    metadata_fetched_at?: any;
 	    // Go type: time
 	    created_at: any;
 	    // Go type: time
-	    pinned_at?: any;
+	    updated_at: any;
+	    nft_count: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new Asset(source);
+	        return new ContractSummary(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.id = source["id"];
-	        this.uri = source["uri"];
-	        this.nft_id = source["nft_id"];
-	        this.nft = this.convertValues(source["nft"], NFT);
-	        this.type = source["type"];
-	        this.mime_type = source["mime_type"];
-	        this.status = source["status"];
-	        this.error_msg = source["error_msg"];
-	        this.size_bytes = source["size_bytes"];
-	        this.retry_count = source["retry_count"];
+	        this.address = source["address"];
+	        this.alias = source["alias"];
+	        this.name = source["name"];
+	        this.description = source["description"];
+	        this.website = source["website"];
+	        this.creator = source["creator"];
+	        this.metadata_fetched_at = this.convertValues(source["metadata_fetched_at"], null);
 	        this.created_at = this.convertValues(source["created_at"], null);
-	        this.pinned_at = this.convertValues(source["pinned_at"], null);
+	        this.updated_at = this.convertValues(source["updated_at"], null);
+	        this.nft_count = source["nft_count"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -392,35 +1567,117 @@ export namespace db {
 		    return a;
 		}
 	}
+	export class NFTTag {
+	    tag: string;
 	
-	export class Wallet {
+	    static createFrom(source: any = {}) {
+	        return new NFTTag(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tag = source["tag"];
+	    }
+	}
+	export class NFTAttribute {
+	    name: string;
+	    value: string;
+	    type?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new NFTAttribute(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.value = source["value"];
+	        this.type = source["type"];
+	    }
+	}
+	export class Highlight {
+	    name: string;
+	    description: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Highlight(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.description = source["description"];
+	    }
+	}
+	export class NFTRoyalty {
 	    address: string;
-	    alias: string;
-	    type: string;
-	    sync_owned: boolean;
-	    sync_created: boolean;
-	    // Go type: time
-	    last_synced_at?: any;
-	    last_synced_level: number;
-	    // Go type: time
-	    last_updated: any;
-	    nfts?: NFT[];
+	    share: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new Wallet(source);
+	        return new NFTRoyalty(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.address = source["address"];
-	        this.alias = source["alias"];
-	        this.type = source["type"];
-	        this.sync_owned = source["sync_owned"];
-	        this.sync_created = source["sync_created"];
-	        this.last_synced_at = this.convertValues(source["last_synced_at"], null);
-	        this.last_synced_level = source["last_synced_level"];
-	        this.last_updated = this.convertValues(source["last_updated"], null);
-	        this.nfts = this.convertValues(source["nfts"], NFT);
+	        this.share = source["share"];
+	    }
+	}
+	export class NFTContributor {
+	    role: string;
+	    name: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new NFTContributor(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.role = source["role"];
+	        this.name = source["name"];
+	    }
+	}
+	export class MetadataChange {
+	    field: string;
+	    old?: any;
+	    new?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new MetadataChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.field = source["field"];
+	        this.old = source["old"];
+	        this.new = source["new"];
+	    }
+	}
+	export class MetadataRevision {
+	    id: number;
+	    nft_id: number;
+	    version: number;
+	    level: number;
+	    hash: string;
+	    metadata: string;
+	    // Go type: time
+	    created_at: any;
+	    changes: MetadataChange[];
+	
+	    static createFrom(source: any = {}) {
+	        return new MetadataRevision(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.nft_id = source["nft_id"];
+	        this.version = source["version"];
+	        this.level = source["level"];
+	        this.hash = source["hash"];
+	        this.metadata = source["metadata"];
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.changes = this.convertValues(source["changes"], MetadataChange);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -471,10 +1728,29 @@ export namespace ipfs {
 
 export namespace main {
 	
+	export class PairRemoteRequest {
+	    host?: string;
+	    port?: number;
+	    fingerprint?: string;
+	    code: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PairRemoteRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.host = source["host"];
+	        this.port = source["port"];
+	        this.fingerprint = source["fingerprint"];
+	        this.code = source["code"];
+	    }
+	}
 	export class RemoteHealthResponse {
 	    status: string;
 	    version: string;
 	    timestamp: string;
+	    fingerprint?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new RemoteHealthResponse(source);
@@ -485,6 +1761,7 @@ export namespace main {
 	        this.status = source["status"];
 	        this.version = source["version"];
 	        this.timestamp = source["timestamp"];
+	        this.fingerprint = source["fingerprint"];
 	    }
 	}
 	export class RemoteProxyRequest {
@@ -496,6 +1773,8 @@ export namespace main {
 	    path: string;
 	    headers?: Record<string, string>;
 	    body?: string;
+	    fingerprint?: string;
+	    serverId?: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new RemoteProxyRequest(source);
@@ -511,6 +1790,8 @@ export namespace main {
 	        this.path = source["path"];
 	        this.headers = source["headers"];
 	        this.body = source["body"];
+	        this.fingerprint = source["fingerprint"];
+	        this.serverId = source["serverId"];
 	    }
 	}
 	export class RemoteProxyResponse {
@@ -534,6 +1815,8 @@ export namespace main {
 	    port: number;
 	    token: string;
 	    useTLS: boolean;
+	    fingerprint?: string;
+	    serverId?: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new RemoteServerConfig(source);
@@ -545,7 +1828,54 @@ export namespace main {
 	        this.port = source["port"];
 	        this.token = source["token"];
 	        this.useTLS = source["useTLS"];
+	        this.fingerprint = source["fingerprint"];
+	        this.serverId = source["serverId"];
+	    }
+	}
+	export class SavedRemoteServer {
+	    id: number;
+	    label: string;
+	    host: string;
+	    port: number;
+	    useTLS: boolean;
+	    fingerprint?: string;
+	    token?: string;
+	    // Go type: time
+	    lastConnectedAt?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new SavedRemoteServer(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.label = source["label"];
+	        this.host = source["host"];
+	        this.port = source["port"];
+	        this.useTLS = source["useTLS"];
+	        this.fingerprint = source["fingerprint"];
+	        this.token = source["token"];
+	        this.lastConnectedAt = this.convertValues(source["lastConnectedAt"], null);
+	    }
+
+	convertValues(a: any, classs: any, asMap: boolean = false): any {
+	    if (!a) {
+	        return a;
+	    }
+	    if (a.slice && a.map) {
+	        return (a as any[]).map(elem => this.convertValues(elem, classs));
+	    } else if ("object" === typeof a) {
+	        if (asMap) {
+	            for (const key of Object.keys(a)) {
+	                a[key] = new classs(a[key]);
+	            }
+	            return a;
+	        }
+	        return new classs(a);
 	    }
+	    return a;
+	}
 	}
 	export class StorageInfo {
 	    used_bytes: number;
@@ -624,6 +1954,8 @@ export namespace storage {
 	    is_mounted: boolean;
 	    mount_point: string;
 	    network_uri: string;
+	    tier?: string;
+	    used_bytes?: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new StorageLocation(source);
@@ -640,6 +1972,8 @@ export namespace storage {
 	        this.is_mounted = source["is_mounted"];
 	        this.mount_point = source["mount_point"];
 	        this.network_uri = source["network_uri"];
+	        this.tier = source["tier"];
+	        this.used_bytes = source["used_bytes"];
 	    }
 	}
 